@@ -4,19 +4,29 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
 
 	"martianoff/gala/internal/build"
+	"martianoff/gala/internal/transpiler"
 )
 
 var (
-	buildOutput  string
-	buildVerbose bool
+	buildOutput    string
+	buildVerbose   bool
+	buildGoVersion string
+	buildGOOS      string
+	buildGOARCH    string
+	buildVerify    bool
+	buildPGO       string
+	buildDefines   []string
+	buildOptimize  bool
+	buildRace      bool
 )
 
 var buildCmd = &cobra.Command{
-	Use:   "build [directory]",
+	Use:   "build [directory | directory/...]",
 	Short: "Build a GALA project",
 	Long: `Build compiles GALA source files into an executable binary.
 
@@ -28,11 +38,61 @@ This command:
 The binary is placed in the current directory by default.
 No go.mod or generated files are created in your project directory.
 
+A directory ending in /... builds the whole tree in place instead: every
+package below it is transpiled after the packages it imports, each
+<name>.gen.go is written beside its <name>.gala source, and go build
+compiles them in the project, where a go.mod is written unless the project
+keeps its own. Generated files whose source was removed are deleted:
+
+  gala build ./...
+
 Examples:
   gala build                    # Build current directory
   gala build ./myproject        # Build specific directory
+  gala build ./...              # Generate .gen.go beside sources and build all packages
   gala build -o myapp           # Custom output name
-  gala build -v                 # Verbose output`,
+  gala build -v                 # Verbose output
+  gala build --go 1.21          # Target Go 1.21
+  gala build --goos linux --goarch arm64              # Cross-compile
+  gala build --goos linux,darwin --goarch amd64,arm64 # Four binaries at once
+
+With --goos or --goarch, each binary is named <output>-<goos>-<goarch>.
+
+Code between #if feature("name") and #endif lines is only compiled when the
+feature is enabled, either in the project's gala.toml or with -D:
+
+  gala build -D experimental
+
+With --pgo the build is guided by a CPU profile of an earlier binary, such as
+one written by runtime/pprof: functions taking at least 1% of the samples are
+inlined by the transpiler where possible, and go build gets the profile too
+(-pgo) for its own inlining and devirtualization:
+
+  gala build --pgo cpu.pprof
+
+With -O chains of Array combinators ending in FoldLeft, ForEach or another
+Map or Filter, such as xs.Map(f).Filter(p).FoldLeft(0, g), are fused into a
+single loop that builds no intermediate Arrays, and immutable fields holding
+structs larger than 128 bytes hold them behind a pointer, so copying the
+enclosing value copies a pointer instead. Values built only from literals
+inside loops, such as None[int]() or Point(1, 2), are built once into
+package-level vars, and calls of pure functions repeated in one statement are
+made once:
+
+  gala build -O
+
+With --race the binary is built with Go's race detector, which reports
+data races between goroutines while it runs, at their lines in the .gala
+files:
+
+  gala build --race
+
+With --verify nothing is built: every <name>.gen.go committed beside its
+<name>.gala source is regenerated in memory, and the command fails with a
+summary of the differences if any of them is stale. Use it in CI to keep
+generated code from drifting:
+
+  gala build --verify`,
 	Args: cobra.MaximumNArgs(1),
 	Run:  runBuild,
 }
@@ -40,13 +100,28 @@ Examples:
 func init() {
 	buildCmd.Flags().StringVarP(&buildOutput, "output", "o", "", "Output binary name")
 	buildCmd.Flags().BoolVarP(&buildVerbose, "verbose", "v", false, "Verbose output")
+	buildCmd.Flags().StringVar(&buildGoVersion, "go", "", "Target Go version for generated code (e.g. 1.21)")
+	buildCmd.Flags().StringVar(&buildGOOS, "goos", "", "Comma-separated target operating systems (GOOS)")
+	buildCmd.Flags().StringVar(&buildGOARCH, "goarch", "", "Comma-separated target architectures (GOARCH)")
+	buildCmd.Flags().BoolVar(&buildVerify, "verify", false, "Check that committed .gen.go files match their sources instead of building")
+	buildCmd.Flags().StringVar(&buildPGO, "pgo", "", "CPU profile (pprof) to guide inlining")
+	buildCmd.Flags().StringSliceVarP(&buildDefines, "define", "D", nil, "Enable a feature for #if feature(\"name\") blocks (repeatable)")
+	buildCmd.Flags().BoolVarP(&buildOptimize, "optimize", "O", false, "Fuse Array combinator chains, hold large immutable fields by reference, hoist constant values out of loops and repeated pure calls into temps")
+	buildCmd.Flags().BoolVar(&buildRace, "race", false, "Build with the race detector")
 }
 
 func runBuild(cmd *cobra.Command, args []string) {
-	// Determine project directory
+	// Determine project directory; a trailing /... builds the whole tree in place
 	projectDir := "."
+	inPlace := false
 	if len(args) > 0 {
 		projectDir = args[0]
+		if projectDir == "..." || strings.HasSuffix(projectDir, "/...") {
+			projectDir, inPlace = strings.TrimSuffix(strings.TrimSuffix(projectDir, "..."), "/"), true
+			if projectDir == "" {
+				projectDir = "."
+			}
+		}
 	}
 
 	// Resolve to absolute path
@@ -71,12 +146,68 @@ func runBuild(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
+	if buildGoVersion != "" {
+		target, err := transpiler.ParseGoVersion(buildGoVersion)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		builder.SetGoVersion(target)
+	}
+	builder.SetFeatures(buildDefines)
+	builder.SetOptimize(buildOptimize)
+	builder.SetRace(buildRace)
+
+	if buildVerify {
+		verifyGenerated(builder)
+		return
+	}
+
+	if buildPGO != "" {
+		if err := builder.SetProfile(buildPGO); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	targets, err := build.ParseTargets(buildGOOS, buildGOARCH)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Run build
-	outputPath, err := builder.Build(buildOutput)
+	var outputPaths []string
+	if inPlace {
+		outputPaths, err = builder.BuildInPlace(buildOutput, targets)
+	} else {
+		outputPaths, err = builder.BuildTargets(buildOutput, targets)
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Build failed: %v\n", err)
 		os.Exit(1)
 	}
 
-	fmt.Printf("Built: %s\n", outputPath)
+	for _, outputPath := range outputPaths {
+		fmt.Printf("Built: %s\n", outputPath)
+	}
+}
+
+// verifyGenerated reports committed generated files that differ from their
+// sources and exits with status 1 if there are any.
+func verifyGenerated(builder *build.Builder) {
+	drifts, err := builder.Verify()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Verify failed: %v\n", err)
+		os.Exit(1)
+	}
+	if len(drifts) == 0 {
+		fmt.Println("Generated files are up to date")
+		return
+	}
+	for _, d := range drifts {
+		fmt.Fprintln(os.Stderr, d)
+	}
+	fmt.Fprintf(os.Stderr, "%d generated file(s) out of date\n", len(drifts))
+	os.Exit(1)
 }
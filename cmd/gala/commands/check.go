@@ -0,0 +1,97 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"martianoff/gala/internal/build"
+)
+
+var (
+	checkSearch    string
+	checkGoVersion string
+	checkVerbose   bool
+	checkDefines   []string
+)
+
+var checkCmd = &cobra.Command{
+	Use:   "check [packages]",
+	Short: "Type-check GALA packages without writing output",
+	Long: `Check transpiles GALA packages in memory and type-checks the generated Go
+with go/types. It reports the same errors as gala build but writes no files,
+which makes it suitable for pre-commit hooks and editor save actions.
+
+A package argument is a directory, a .gala file (its directory is checked)
+or a pattern ending in /... that matches every package below a directory.
+
+Examples:
+  gala check                   # Every package below the current directory
+  gala check ./models          # Only the package in ./models
+  gala check ./cmd/...         # Every package below ./cmd
+  gala check -D experimental   # Also check #if feature("experimental") blocks
+
+Exits with status 1 if any errors were found.`,
+	Run: runCheck,
+}
+
+func init() {
+	checkCmd.Flags().StringVarP(&checkSearch, "search", "s", ".", "Comma-separated search paths")
+	checkCmd.Flags().StringVar(&checkGoVersion, "go", "", "Target Go version for generated code (e.g. 1.21)")
+	checkCmd.Flags().BoolVarP(&checkVerbose, "verbose", "v", false, "Print each package as it is checked")
+	checkCmd.Flags().StringSliceVarP(&checkDefines, "define", "D", nil, "Enable a feature for #if feature(\"name\") blocks (repeatable)")
+}
+
+func runCheck(cmd *cobra.Command, args []string) {
+	patterns := args
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+
+	var dirs []string
+	for _, pattern := range patterns {
+		found, err := build.FindCheckDirs(pattern)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		dirs = append(dirs, found...)
+	}
+	if len(dirs) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: no GALA packages found")
+		os.Exit(1)
+	}
+
+	searchPaths := strings.Split(checkSearch, ",")
+	if !insideGalaModule() {
+		stdlibDir, err := build.EnsureStdlib(build.DefaultConfig(), Version, checkVerbose)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		searchPaths = append(searchPaths, stdlibDir)
+	}
+
+	checker, err := build.NewChecker(searchPaths, checkGoVersion, checkDefines)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	failed := false
+	for _, dir := range dirs {
+		if checkVerbose {
+			fmt.Printf("Checking %s\n", dir)
+		}
+		for _, d := range checker.CheckDir(dir) {
+			fmt.Fprintln(os.Stderr, d)
+			if !d.Warning {
+				failed = true
+			}
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
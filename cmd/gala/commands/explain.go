@@ -0,0 +1,57 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"martianoff/gala/galaerr"
+)
+
+var explainCmd = &cobra.Command{
+	Use:   "explain [error-code]",
+	Short: "Explain a GALA error code",
+	Long: `Explain prints the extended description of an error code, a minimal
+program that triggers it and the corrected program.
+
+Error codes appear in compiler messages, e.g. "[SemanticError E0001] ...".
+Without arguments, all documented codes are listed.
+
+Examples:
+  gala explain            # List all error codes
+  gala explain E0001      # Explain error E0001`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runExplain,
+}
+
+func runExplain(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		for _, code := range galaerr.Codes() {
+			e, _ := galaerr.Explain(code)
+			fmt.Printf("%s  %s\n", code, e.Title)
+		}
+		return
+	}
+
+	code := galaerr.Code(strings.ToUpper(strings.TrimSpace(args[0])))
+	e, ok := galaerr.Explain(code)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: unknown error code %q\n", args[0])
+		fmt.Fprintln(os.Stderr, "Run 'gala explain' to list all error codes.")
+		os.Exit(1)
+	}
+
+	fmt.Printf("%s: %s\n\n", e.Code, e.Title)
+	fmt.Println(e.Details)
+	fmt.Println("\nExample:")
+	fmt.Println(indentBlock(e.Example))
+	fmt.Println("\nFix:")
+	fmt.Println(indentBlock(e.Fix))
+}
+
+// indentBlock prefixes every line of s with four spaces.
+func indentBlock(s string) string {
+	return "    " + strings.ReplaceAll(s, "\n", "\n    ")
+}
@@ -0,0 +1,256 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"martianoff/gala/internal/parser"
+)
+
+var (
+	fmtWrite bool
+	fmtDiff  bool
+	fmtList  bool
+)
+
+var fmtCmd = &cobra.Command{
+	Use:   "fmt [paths]",
+	Short: "Format GALA source files",
+	Long: `Fmt rewrites .gala files in the canonical layout: four-space indentation,
+opening braces on the line they belong to, aligned match cases, struct fields
+and trailing comments, and normalized spacing between tokens. Line breaks stay
+where they are.
+
+A path is a .gala file or a directory, whose .gala files are formatted
+recursively. Without paths the source is read from standard input.
+
+Examples:
+  gala fmt main.gala           # Print the formatted file
+  gala fmt -w .                # Format every file below the current directory
+  gala fmt -d ./models         # Show what formatting would change
+  gala fmt -l .                # List the files that are not formatted
+
+Exits with status 1 if a file does not parse.`,
+	Run: runFmt,
+}
+
+func init() {
+	fmtCmd.Flags().BoolVarP(&fmtWrite, "write", "w", false, "Write the result to the file instead of printing it")
+	fmtCmd.Flags().BoolVarP(&fmtDiff, "diff", "d", false, "Print a diff of the changes instead of the result")
+	fmtCmd.Flags().BoolVarP(&fmtList, "list", "l", false, "Print the names of files whose formatting differs")
+}
+
+func runFmt(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		if fmtWrite {
+			fmt.Fprintln(os.Stderr, "Error: cannot use -w with standard input")
+			os.Exit(1)
+		}
+		src, err := io.ReadAll(os.Stdin)
+		if err == nil {
+			err = formatFile("<standard input>", src)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	failed := false
+	for _, arg := range args {
+		files, err := galaFiles(arg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			failed = true
+			continue
+		}
+		for _, file := range files {
+			src, err := os.ReadFile(file)
+			if err == nil {
+				err = formatFile(file, src)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", file, err)
+				failed = true
+			}
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// galaFiles returns path if it is a file, or the .gala files below it if it
+// is a directory, leaving out directories whose names start with . or _.
+func galaFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	var files []string
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if name := d.Name(); p != path && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(p, ".gala") {
+			files = append(files, p)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// formatFile formats the source src of file and reports the result as the
+// flags ask.
+func formatFile(file string, src []byte) error {
+	out, err := parser.Format(string(src))
+	if err != nil {
+		return err
+	}
+	changed := out != string(src)
+	if fmtList && changed {
+		fmt.Println(file)
+	}
+	if fmtDiff && changed {
+		fmt.Print(unifiedDiff(file, string(src), out))
+	}
+	if fmtWrite && changed {
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(file, []byte(out), info.Mode().Perm())
+	}
+	if !fmtList && !fmtDiff && !fmtWrite {
+		fmt.Print(out)
+	}
+	return nil
+}
+
+// diffContext is the number of unchanged lines shown around a change.
+const diffContext = 3
+
+// unifiedDiff returns the changes from a to b in the unified diff format.
+func unifiedDiff(file, a, b string) string {
+	x, y := strings.SplitAfter(a, "\n"), strings.SplitAfter(b, "\n")
+	if x[len(x)-1] == "" {
+		x = x[:len(x)-1]
+	}
+	if y[len(y)-1] == "" {
+		y = y[:len(y)-1]
+	}
+
+	// ops is the edit script: ' ' keeps a line of both, '-' drops a line of
+	// x and '+' adds a line of y, computed from the longest common
+	// subsequence of the lines between the common prefix and suffix
+	prefix := 0
+	for prefix < len(x) && prefix < len(y) && x[prefix] == y[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(x)-prefix && suffix < len(y)-prefix && x[len(x)-1-suffix] == y[len(y)-1-suffix] {
+		suffix++
+	}
+	mx, my := x[prefix:len(x)-suffix], y[prefix:len(y)-suffix]
+	lcs := make([][]int, len(mx)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(my)+1)
+	}
+	for i := len(mx) - 1; i >= 0; i-- {
+		for j := len(my) - 1; j >= 0; j-- {
+			if mx[i] == my[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	ops := []byte(strings.Repeat(" ", prefix))
+	for i, j := 0, 0; i < len(mx) || j < len(my); {
+		switch {
+		case i < len(mx) && j < len(my) && mx[i] == my[j]:
+			ops = append(ops, ' ')
+			i, j = i+1, j+1
+		case j == len(my) || i < len(mx) && lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, '-')
+			i++
+		default:
+			ops = append(ops, '+')
+			j++
+		}
+	}
+	ops = append(ops, strings.Repeat(" ", suffix)...)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", file, file)
+	// Hunks are the changed runs of ops with their context, merged when the
+	// context of two runs overlaps
+	for start := 0; start < len(ops); {
+		if ops[start] == ' ' {
+			start++
+			continue
+		}
+		from := max(start-diffContext, 0)
+		end := start
+		for unchanged := 0; end < len(ops) && unchanged <= 2*diffContext; end++ {
+			if ops[end] == ' ' {
+				unchanged++
+			} else {
+				unchanged = 0
+			}
+		}
+		// Drop the context beyond diffContext after the last change
+		for end > start && ops[end-1] == ' ' {
+			end--
+		}
+		end = min(end+diffContext, len(ops))
+
+		xi, yi := 0, 0
+		for _, op := range ops[:from] {
+			if op != '+' {
+				xi++
+			}
+			if op != '-' {
+				yi++
+			}
+		}
+		var hunk strings.Builder
+		xn, yn := 0, 0
+		for _, op := range ops[from:end] {
+			switch op {
+			case ' ':
+				hunk.WriteString(" " + x[xi+xn])
+				xn, yn = xn+1, yn+1
+			case '-':
+				hunk.WriteString("-" + x[xi+xn])
+				xn++
+			case '+':
+				hunk.WriteString("+" + y[yi+yn])
+				yn++
+			}
+			if !strings.HasSuffix(hunk.String(), "\n") {
+				hunk.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", xi+1, xn, yi+1, yn)
+		sb.WriteString(hunk.String())
+		start = end
+	}
+	return sb.String()
+}
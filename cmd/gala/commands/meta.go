@@ -0,0 +1,122 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"martianoff/gala/internal/transpiler"
+	"martianoff/gala/internal/transpiler/analyzer"
+	"martianoff/gala/internal/transpiler/buildtags"
+)
+
+var (
+	metaSearch string
+	metaOutput string
+)
+
+var metaCmd = &cobra.Command{
+	Use:   "meta [package-dir]",
+	Short: "Print package type metadata as JSON",
+	Long: `Meta analyzes the GALA package in a directory and prints its types, fields,
+methods, sealed variants, functions and companion objects as JSON.
+
+The output is meant for external generators (ORMs, API clients, docs).
+Its layout is versioned by the "schemaVersion" field.
+
+Examples:
+  gala meta                    # Package in the current directory
+  gala meta ./models           # Package in ./models
+  gala meta ./models -o m.json # Write to a file`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runMeta,
+}
+
+func init() {
+	metaCmd.Flags().StringVarP(&metaSearch, "search", "s", ".", "Comma-separated search paths")
+	metaCmd.Flags().StringVarP(&metaOutput, "output", "o", "", "Write JSON to this file instead of stdout")
+}
+
+func runMeta(cmd *cobra.Command, args []string) {
+	dir := "."
+	if len(args) > 0 {
+		dir = args[0]
+	}
+
+	richAST, err := analyzePackage(dir, metaSearch)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	data, err := json.MarshalIndent(transpiler.ExportMeta(richAST, richAST.PackageName), "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	data = append(data, '\n')
+
+	if metaOutput == "" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(metaOutput, data, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to write output file: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// analyzePackage analyzes the GALA package in dir, resolving imports from the
+// comma-separated search paths.
+func analyzePackage(dir, search string) (*transpiler.RichAST, error) {
+	files, err := packageSourceFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(files[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", files[0], err)
+	}
+
+	p := transpiler.NewAntlrGalaParser()
+	tree, err := p.Parse(string(content))
+	if err != nil {
+		return nil, err
+	}
+	a := analyzer.NewGalaAnalyzerWithPackageFiles(p, strings.Split(search, ","), files[1:])
+	richAST, err := a.Analyze(tree, files[0])
+	if err != nil {
+		return nil, fmt.Errorf("analysis failed: %v", err)
+	}
+	return richAST, nil
+}
+
+// packageSourceFiles returns the non-test .gala files of dir that are built
+// for the current target, in name order.
+func packageSourceFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".gala") || strings.HasSuffix(name, "_test.gala") {
+			continue
+		}
+		if path := filepath.Join(dir, name); buildtags.Default().MatchFile(path) {
+			files = append(files, path)
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no .gala files in %s", dir)
+	}
+	sort.Strings(files)
+	return files, nil
+}
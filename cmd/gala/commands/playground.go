@@ -0,0 +1,167 @@
+package commands
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"martianoff/gala/internal/build"
+)
+
+//go:embed playground.html
+var playgroundPage []byte
+
+// playgroundMaxSource caps the size of a submitted program.
+const playgroundMaxSource = 1 << 20
+
+// playgroundTokenHeader carries the session token the page is served with.
+// Other sites can neither read the page nor send the header without a CORS
+// preflight, which the playground does not answer.
+const playgroundTokenHeader = "X-Gala-Playground-Token"
+
+var (
+	playgroundAddr    string
+	playgroundTimeout time.Duration
+)
+
+var playgroundCmd = &cobra.Command{
+	Use:   "playground",
+	Short: "Start a local web UI for trying GALA code",
+	Long: `Playground serves a page where GALA source can be edited, shown next to
+the generated Go code and run. Programs are built in a temporary module with
+its own copy of the standard library, get no stdin, and are killed after
+--timeout. The server listens on localhost only unless --addr says otherwise.
+
+WARNING: programs are not sandboxed. They run as you, in an empty scratch
+directory, and can read and write all of your files; on Linux they get no
+network access, elsewhere they do. Only the page served by the playground
+can submit programs, but never expose it to a network you do not trust.
+
+Examples:
+  gala playground                        # Serve on http://localhost:8080
+  gala playground --addr localhost:9000  # Serve on port 9000
+  gala playground --timeout 30s          # Allow longer-running programs`,
+	Args: cobra.NoArgs,
+	Run:  runPlayground,
+}
+
+func init() {
+	playgroundCmd.Flags().StringVar(&playgroundAddr, "addr", "localhost:8080", "Address to listen on")
+	playgroundCmd.Flags().DurationVar(&playgroundTimeout, "timeout", 10*time.Second, "Time limit for running a program")
+}
+
+func runPlayground(cmd *cobra.Command, args []string) {
+	pg, err := build.NewPlayground(playgroundTimeout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer pg.Close()
+
+	token, err := newPlaygroundToken()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	page := bytes.ReplaceAll(playgroundPage, []byte("{{token}}"), []byte(token))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", playgroundGuard("", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(page)
+	}))
+	mux.HandleFunc("POST /transpile", playgroundGuard(token, playgroundHandler(pg.Transpile)))
+	mux.HandleFunc("POST /run", playgroundGuard(token, playgroundHandler(pg.Run)))
+
+	fmt.Printf("GALA playground listening on http://%s\n", playgroundAddr)
+	if !build.PlaygroundNetworkIsolated {
+		fmt.Println("Warning: programs run as you, with access to your files and the network")
+	}
+	if err := http.ListenAndServe(playgroundAddr, mux); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// newPlaygroundToken returns a random token for one playground session.
+func newPlaygroundToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating session token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// playgroundGuard serves next only for requests from the playground's own
+// page. The Host header must name the playground, so a site rebinding its DNS
+// name to localhost is turned away, and a request sent by a page must come
+// from the playground's origin. Unless token is empty, the request must also
+// be JSON and carry the session token.
+func playgroundGuard(token string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !playgroundHost(r.Host) {
+			http.Error(w, "unknown host "+r.Host, http.StatusForbidden)
+			return
+		}
+		if origin := r.Header.Get("Origin"); origin != "" && origin != "http://"+r.Host {
+			http.Error(w, "cross-origin requests are not allowed", http.StatusForbidden)
+			return
+		}
+		if token == "" {
+			next(w, r)
+			return
+		}
+		if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+			http.Error(w, "requests must be application/json", http.StatusUnsupportedMediaType)
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get(playgroundTokenHeader)), []byte(token)) != 1 {
+			http.Error(w, "missing or wrong session token", http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// playgroundHost reports whether host, the Host header of a request, names the
+// playground: a loopback address, localhost, or the host of --addr.
+func playgroundHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if host == "localhost" {
+		return true
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return true
+	}
+	addrHost, _, err := net.SplitHostPort(playgroundAddr)
+	return err == nil && addrHost != "" && host == addrHost
+}
+
+// playgroundHandler serves action for requests of the form {"source": "..."}
+// and responds with the result as JSON.
+func playgroundHandler(action func(source string) build.PlaygroundResult) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Source string `json:"source"`
+		}
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, playgroundMaxSource)).Decode(&req); err != nil {
+			http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(action(req.Source))
+	}
+}
@@ -0,0 +1,90 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"martianoff/gala/internal/protogen"
+)
+
+var (
+	protogenSearch string
+	protogenOutput string
+	protogenPb     string
+	protogenAlias  string
+	protogenOneof  string
+	protogenTypes  []string
+)
+
+var protogenCmd = &cobra.Command{
+	Use:   "protogen [package-dir] --pb <import-path>",
+	Short: "Generate conversions between GALA types and protobuf messages",
+	Long: `Protogen generates GALA functions converting the structs and sealed types
+of a package to and from the Go structs protoc-gen-go generates: UserToProto
+and UserFromProto for a type User.
+
+The messages must mirror the GALA types: message User with a field for each
+field of User, Option fields as optional fields, slices as repeated fields,
+int as int64. A sealed type maps to a message holding a oneof ("kind", or the
+name given with --oneof) with one member per variant, each a message named
+after the variant.
+
+Without --type, every type that can be converted is, and the others are
+listed on stderr.
+
+Examples:
+  gala protogen ./shop --pb example.com/shop/shoppb
+  gala protogen ./shop --pb example.com/shop/shoppb -o shop/proto.gala
+  gala protogen ./shop --pb example.com/api/v1 --alias apiv1 --type Order`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runProtogen,
+}
+
+func init() {
+	protogenCmd.Flags().StringVarP(&protogenSearch, "search", "s", ".", "Comma-separated search paths")
+	protogenCmd.Flags().StringVarP(&protogenOutput, "output", "o", "", "Write the GALA source to this file instead of stdout")
+	protogenCmd.Flags().StringVar(&protogenPb, "pb", "", "Import path of the Go package generated by protoc-gen-go")
+	protogenCmd.Flags().StringVar(&protogenAlias, "alias", "pb", "Name to import the protobuf package as")
+	protogenCmd.Flags().StringVar(&protogenOneof, "oneof", "kind", "Oneof holding the variants of a sealed type")
+	protogenCmd.Flags().StringSliceVarP(&protogenTypes, "type", "t", nil, "Types to convert (default: all that can be)")
+	protogenCmd.MarkFlagRequired("pb")
+}
+
+func runProtogen(cmd *cobra.Command, args []string) {
+	dir := "."
+	if len(args) > 0 {
+		dir = args[0]
+	}
+
+	richAST, err := analyzePackage(dir, protogenSearch)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	src, skipped, err := protogen.Generate(richAST, protogen.Options{
+		Package:  richAST.PackageName,
+		PbImport: protogenPb,
+		PbAlias:  protogenAlias,
+		Oneof:    protogenOneof,
+		Types:    protogenTypes,
+	})
+	for _, s := range skipped {
+		fmt.Fprintf(os.Stderr, "skipped %s: %s\n", s.Type, s.Reason)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if protogenOutput == "" {
+		fmt.Print(src)
+		return
+	}
+	if err := os.WriteFile(protogenOutput, []byte(src), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to write output file: %v\n", err)
+		os.Exit(1)
+	}
+}
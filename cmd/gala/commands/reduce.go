@@ -0,0 +1,171 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"martianoff/gala/compiler"
+	"martianoff/gala/internal/build"
+	"martianoff/gala/internal/reduce"
+	"martianoff/gala/internal/transpiler"
+)
+
+var (
+	reduceMatch   string
+	reduceGo      bool
+	reduceOutput  string
+	reduceVerbose bool
+)
+
+var reduceCmd = &cobra.Command{
+	Use:   "reduce <file.gala>",
+	Short: "Shrink a failing .gala file to a minimal reproducer",
+	Long: `Reduce removes lines from a .gala file for as long as it keeps failing the
+same way, and writes the smallest failing version it finds. Attach the result
+to bug reports.
+
+By default the failure is a transpiler error; with --go it is the generated Go
+failing to compile. A candidate counts as failing the same way when one of its
+error messages contains --match, which defaults to the first error message of
+the original file (without its position).
+
+Examples:
+  gala reduce crash.gala                          # Writes crash.min.gala
+  gala reduce crash.gala --match "cannot infer"   # Keep a specific error
+  gala reduce bad_codegen.gala --go               # Generated Go does not compile`,
+	Args: cobra.ExactArgs(1),
+	Run:  runReduce,
+}
+
+func init() {
+	reduceCmd.Flags().StringVar(&reduceMatch, "match", "", "Text the error must contain (default: the original error)")
+	reduceCmd.Flags().BoolVar(&reduceGo, "go", false, "Reduce a go build failure of the generated code")
+	reduceCmd.Flags().StringVarP(&reduceOutput, "output", "o", "", "Output file (default: <file>.min.gala)")
+	reduceCmd.Flags().BoolVarP(&reduceVerbose, "verbose", "v", false, "Print every reduction step")
+}
+
+// goErrorPosition matches the position prefix of a go build error line.
+var goErrorPosition = regexp.MustCompile(`^\S+:\d+(:\d+)?: `)
+
+func runReduce(cmd *cobra.Command, args []string) {
+	path := args[0]
+	content, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to read input file: %v\n", err)
+		os.Exit(1)
+	}
+
+	dir, err := os.MkdirTemp("", "gala-reduce-*")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	// exit removes the temp module, which a deferred call would miss on os.Exit
+	exit := func(code int) {
+		os.RemoveAll(dir)
+		os.Exit(code)
+	}
+	m, err := build.NewStandaloneModule(dir, transpiler.GoVersion{})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		exit(1)
+	}
+
+	failures := func(src string) []string {
+		// Listing only the file itself keeps sibling files, including earlier
+		// reproducers, out of the analysis: the result must fail on its own
+		goCode, diags, _ := compiler.Compile(src, compiler.Options{
+			FileName:     path,
+			SearchPaths:  []string{filepath.Dir(path), m.StdlibDir()},
+			PackageFiles: []string{path},
+		})
+		if !reduceGo {
+			var msgs []string
+			for _, d := range diags {
+				if d.Severity == compiler.SeverityError {
+					msgs = append(msgs, d.Message)
+				}
+			}
+			return msgs
+		}
+		if diags.HasErrors() {
+			return nil
+		}
+		if err := m.WriteMain(string(goCode)); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		var out bytes.Buffer
+		if _, err := m.BuildTo(&out); err == nil {
+			return nil
+		}
+		var msgs []string
+		for _, line := range strings.Split(out.String(), "\n") {
+			if msg := goErrorPosition.ReplaceAllString(line, ""); msg != line {
+				msgs = append(msgs, msg)
+			}
+		}
+		return msgs
+	}
+
+	original := failures(string(content))
+	if len(original) == 0 {
+		what := "transpiles"
+		if reduceGo {
+			what = "compiles"
+		}
+		fmt.Fprintf(os.Stderr, "Error: %s %s without errors; nothing to reduce\n", path, what)
+		exit(1)
+	}
+	match := reduceMatch
+	if match == "" {
+		match = original[0]
+	}
+	if !containsMatch(original, match) {
+		fmt.Fprintf(os.Stderr, "Error: no error of %s contains %q; it fails with:\n  %s\n", path, match, strings.Join(original, "\n  "))
+		exit(1)
+	}
+	fmt.Printf("Reducing %s, keeping the error %q\n", path, match)
+
+	// Reduction steps revisit the same candidates, so results are cached
+	tested := map[string]bool{}
+	reduced := reduce.Source(string(content), func(src string) bool {
+		if failing, ok := tested[src]; ok {
+			return failing
+		}
+		failing := containsMatch(failures(src), match)
+		tested[src] = failing
+		if reduceVerbose && failing {
+			fmt.Printf("  %d lines\n", strings.Count(src, "\n")+1)
+		}
+		return failing
+	})
+
+	output := reduceOutput
+	if output == "" {
+		output = strings.TrimSuffix(path, ".gala") + ".min.gala"
+	}
+	if err := os.WriteFile(output, []byte(strings.TrimRight(reduced, "\n")+"\n"), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to write output file: %v\n", err)
+		exit(1)
+	}
+	fmt.Printf("Reduced %d lines to %d in %d attempts: %s\n",
+		strings.Count(string(content), "\n")+1, strings.Count(reduced, "\n")+1, len(tested), output)
+	exit(0)
+}
+
+// containsMatch reports whether any of msgs contains match.
+func containsMatch(msgs []string, match string) bool {
+	for _, msg := range msgs {
+		if strings.Contains(msg, match) {
+			return true
+		}
+	}
+	return false
+}
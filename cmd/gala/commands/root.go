@@ -7,6 +7,8 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+
+	"martianoff/gala/internal/transpiler"
 )
 
 var rootCmd = &cobra.Command{
@@ -22,11 +24,20 @@ This tool provides:
 Usage:
   gala build                    Build project to binary
   gala run                      Build and run project
+  gala run --watch              Rebuild and rerun on every change
+  gala script.gala [-- args]    Run a single file (also via #!/usr/bin/env gala)
   gala build -o myapp           Build with custom output name
+  gala check ./...              Type-check packages without writing output
+  gala fmt -w .                 Format .gala files in place
   gala mod init                 Initialize gala.mod
   gala mod add <pkg>@<version>  Add a dependency
   gala mod tidy                 Tidy dependencies
   gala clean                    Clean build workspace
+  gala explain <code>           Explain an error code
+  gala meta [dir]               Print package type metadata as JSON
+  gala protogen [dir] --pb <pkg> Generate protobuf message conversions
+  gala playground               Start a local web UI to edit and run GALA
+  gala reduce <file.gala>       Shrink a failing file to a minimal reproducer
   gala version                  Print version
 
 Legacy transpilation (creates files in project directory):
@@ -46,6 +57,12 @@ Legacy transpilation (creates files in project directory):
 
 		// Check if first argument is a .gala file
 		if len(args) > 0 && strings.HasSuffix(args[0], ".gala") {
+			// Without flags the file is run with the remaining arguments, which is
+			// how a "#!/usr/bin/env gala" line invokes an executable script
+			if cmd.Flags().NFlag() == 0 {
+				runFile(args[0], args[1:])
+				return nil
+			}
 			runTranspile(cmd, args)
 			return nil
 		}
@@ -62,6 +79,7 @@ Legacy transpilation (creates files in project directory):
 
 // Execute runs the root command.
 func Execute() {
+	transpiler.Version = Version
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, "Error:", err)
 		os.Exit(1)
@@ -75,7 +93,14 @@ func init() {
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(buildCmd)
 	rootCmd.AddCommand(runCmd)
+	rootCmd.AddCommand(checkCmd)
+	rootCmd.AddCommand(fmtCmd)
 	rootCmd.AddCommand(cleanCmd)
+	rootCmd.AddCommand(explainCmd)
+	rootCmd.AddCommand(metaCmd)
+	rootCmd.AddCommand(protogenCmd)
+	rootCmd.AddCommand(playgroundCmd)
+	rootCmd.AddCommand(reduceCmd)
 
 	// Add global flags that mirror transpile flags for backward compatibility
 	rootCmd.Flags().StringVarP(&transpileInput, "input", "i", "", "Path to the input .gala file")
@@ -83,4 +108,10 @@ func init() {
 	rootCmd.Flags().BoolVarP(&transpileRun, "run", "r", false, "Execute the generated Go code")
 	rootCmd.Flags().StringVarP(&transpileSearch, "search", "s", ".", "Comma-separated search paths")
 	rootCmd.Flags().StringVar(&transpilePackageFiles, "package-files", "", "Comma-separated list of sibling .gala files in the same package")
+	rootCmd.Flags().StringVar(&transpileGoVersion, "go", "", "Target Go version for generated code (e.g. 1.21)")
+	rootCmd.Flags().BoolVar(&transpileKeepArtifacts, "keep-artifacts", false, "With --run, keep the generated code and build files")
+	rootCmd.Flags().StringVar(&transpileArtifactDir, "artifact-dir", "", "With --run, build in <dir>/<file name> and keep it")
+	addTraceFlags(rootCmd)
+	addJSONDiagnosticsFlag(rootCmd)
+	addWatchFlag(rootCmd)
 }
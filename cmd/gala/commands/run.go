@@ -5,26 +5,49 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
 
 	"martianoff/gala/internal/build"
+	"martianoff/gala/internal/transpiler"
 )
 
-var runVerbose bool
+var (
+	runVerbose bool
+	runGOOS    string
+	runGOARCH  string
+	runDefines []string
+	runRace    bool
+	runWatch   bool
+)
 
 var runCmd = &cobra.Command{
-	Use:   "run [directory] [-- args...]",
+	Use:   "run [directory | file.gala] [-- args...]",
 	Short: "Build and run a GALA project",
 	Long: `Run builds a GALA project and executes it immediately.
 
 Arguments after -- are passed to the executed program.
 
+A single .gala file needs no gala.mod: it is built in a temporary module
+that carries its own copy of the GALA standard library.
+
 Examples:
   gala run                      # Build and run current directory
   gala run ./myproject          # Build and run specific directory
+  gala run hello.gala           # Build and run a single file
   gala run -- arg1 arg2         # Pass arguments to the program
-  gala run -v                   # Verbose output`,
+  gala run -v                   # Verbose output
+  gala run --goarch amd64       # Run an amd64 build (e.g. under Rosetta)
+  gala run -D experimental      # Compile #if feature("experimental") blocks
+  gala run --race               # Detect data races, reported with .gala locations
+  gala run --watch              # Rebuild and rerun when a source file changes
+
+With --watch the program is rebuilt and restarted whenever a .gala file of the
+project, its gala.mod or gala.toml changes; a single file is rebuilt when it or
+another .gala file of its directory changes. Either way, so is the program
+when a file of a GALA package it imports changes. A run still in progress is
+stopped first. Build errors are printed and the next change is awaited.`,
 	Args:               cobra.ArbitraryArgs,
 	DisableFlagParsing: false,
 	Run:                runRun,
@@ -32,6 +55,11 @@ Examples:
 
 func init() {
 	runCmd.Flags().BoolVarP(&runVerbose, "verbose", "v", false, "Verbose output")
+	runCmd.Flags().StringVar(&runGOOS, "goos", "", "Target operating system (GOOS)")
+	runCmd.Flags().StringVar(&runGOARCH, "goarch", "", "Target architecture (GOARCH)")
+	runCmd.Flags().StringSliceVarP(&runDefines, "define", "D", nil, "Enable a feature for #if feature(\"name\") blocks (repeatable)")
+	runCmd.Flags().BoolVar(&runRace, "race", false, "Build with the race detector")
+	runCmd.Flags().BoolVarP(&runWatch, "watch", "w", false, "Rebuild and rerun when source files change")
 }
 
 func runRun(cmd *cobra.Command, args []string) {
@@ -59,6 +87,19 @@ func runRun(cmd *cobra.Command, args []string) {
 		projectDir = args[0]
 	}
 
+	if strings.HasSuffix(projectDir, ".gala") {
+		if runRace {
+			fmt.Fprintln(os.Stderr, "Error: --race needs a project with a gala.mod")
+			os.Exit(1)
+		}
+		if runWatch {
+			watchFile(projectDir, programArgs)
+			return
+		}
+		runFile(projectDir, programArgs)
+		return
+	}
+
 	// Resolve to absolute path
 	absProjectDir, err := filepath.Abs(projectDir)
 	if err != nil {
@@ -74,18 +115,22 @@ func runRun(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
-	// Create builder
-	builder, err := build.NewBuilder(absProjectDir, Version, runVerbose)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+	rebuild := func() (string, error) { return buildProject(absProjectDir) }
+	if runWatch {
+		searchPaths := func() []string {
+			builder, err := build.NewBuilder(absProjectDir, Version, runVerbose)
+			if err != nil {
+				return nil
+			}
+			return builder.SearchPaths()
+		}
+		if err := watchLoop(withImports(projectFiles(absProjectDir), searchPaths), rebuild, programArgs, absProjectDir); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
 	}
-
-	// Build to the workspace directory (not project dir)
-	tempOutput := filepath.Join(builder.Workspace().Dir, "run-output")
-
-	// Run build with absolute path to workspace
-	outputPath, err := builder.Build(tempOutput)
+	outputPath, err := rebuild()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Build failed: %v\n", err)
 		os.Exit(1)
@@ -107,3 +152,65 @@ func runRun(cmd *cobra.Command, args []string) {
 	}
 }
 
+// buildProject builds the project in absProjectDir for gala run and returns
+// the path of the binary.
+func buildProject(absProjectDir string) (string, error) {
+	builder, err := build.NewBuilder(absProjectDir, Version, runVerbose)
+	if err != nil {
+		return "", err
+	}
+
+	builder.SetFeatures(runDefines)
+	builder.SetRace(runRace)
+
+	// Build to the workspace directory (not project dir)
+	tempOutput := filepath.Join(builder.Workspace().Dir, "run-output")
+
+	targets, err := build.ParseTargets(runGOOS, runGOARCH)
+	if err == nil && len(targets) > 1 {
+		err = fmt.Errorf("gala run builds a single target, got %d", len(targets))
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// Run build with absolute path to workspace
+	outputPaths, err := builder.BuildTargets(tempOutput, targets)
+	if err != nil {
+		return "", err
+	}
+	return outputPaths[0], nil
+}
+
+// watchFile runs a single .gala file like runFile, again after every change.
+func watchFile(path string, programArgs []string) {
+	dir, err := os.MkdirTemp("", "gala-run-*")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer os.RemoveAll(dir)
+	rebuild := func() (string, error) {
+		return build.BuildFile(path, dir, transpiler.GoVersion{}, runDefines, runVerbose)
+	}
+	searchPaths := func() []string { return build.StandaloneSearchPaths(path, dir) }
+	if err := watchLoop(withImports(scriptFiles(path), searchPaths), rebuild, programArgs, "."); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// runFile builds and runs a single .gala file outside of any GALA project.
+func runFile(path string, programArgs []string) {
+	err := build.RunFile(path, transpiler.GoVersion{}, runDefines, programArgs, runVerbose)
+	if err == nil {
+		return
+	}
+	// The program itself failed: keep its exit code
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		os.Exit(exitErr.ExitCode())
+	}
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	os.Exit(1)
+}
+
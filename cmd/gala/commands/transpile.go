@@ -1,26 +1,36 @@
 package commands
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
 
 	"github.com/spf13/cobra"
 
+	"martianoff/gala/compiler"
+	"martianoff/gala/internal/build"
 	"martianoff/gala/internal/transpiler"
-	"martianoff/gala/internal/transpiler/analyzer"
-	"martianoff/gala/internal/transpiler/generator"
-	"martianoff/gala/internal/transpiler/transformer"
+	"martianoff/gala/internal/transpiler/module"
 )
 
 var (
-	transpileInput        string
-	transpileOutput       string
-	transpileRun          bool
-	transpileSearch       string
-	transpilePackageFiles string
+	transpileInput         string
+	transpileOutput        string
+	transpileRun           bool
+	transpileSearch        string
+	transpilePackageFiles  string
+	transpileGoVersion     string
+	transpileKeepArtifacts bool
+	transpileArtifactDir   string
+	transpileTrace         string
+	transpileTraceFilter   string
+	transpileDefines       []string
+	transpileJSONDiags     bool
+	transpileWatch         bool
 )
 
 var transpileCmd = &cobra.Command{
@@ -34,7 +44,13 @@ Use 'gala build' for a complete build workflow.
 Examples:
   gala transpile main.gala               # Output to stdout
   gala transpile -i main.gala -o main.go # Output to file
-  gala transpile main.gala --run         # Transpile and execute (temp dir)`,
+  gala transpile main.gala --run         # Transpile and execute (temp dir)
+  gala transpile main.gala --go 1.21     # Emit code compatible with Go 1.21
+  gala transpile main.gala -D experimental  # Compile #if feature("experimental") blocks
+  gala transpile main.gala --run --artifact-dir out  # Keep out/main/main.gen.go
+  gala transpile main.gala --trace --trace-filter Parse  # Dump every phase for Parse
+  gala transpile main.gala --json-diagnostics  # Report errors and warnings as JSON
+  gala transpile main.gala --run --watch  # Transpile and run again on every change`,
 	Args: cobra.MaximumNArgs(1),
 	Run:  runTranspile,
 }
@@ -45,6 +61,30 @@ func init() {
 	transpileCmd.Flags().BoolVarP(&transpileRun, "run", "r", false, "Execute the generated Go code")
 	transpileCmd.Flags().StringVarP(&transpileSearch, "search", "s", ".", "Comma-separated search paths")
 	transpileCmd.Flags().StringVar(&transpilePackageFiles, "package-files", "", "Comma-separated list of sibling .gala files in the same package")
+	transpileCmd.Flags().StringVar(&transpileGoVersion, "go", "", "Target Go version for generated code (e.g. 1.21)")
+	transpileCmd.Flags().StringSliceVarP(&transpileDefines, "define", "D", nil, "Enable a feature for #if feature(\"name\") blocks (repeatable)")
+	transpileCmd.Flags().BoolVar(&transpileKeepArtifacts, "keep-artifacts", false, "With --run, keep the generated code and build files")
+	transpileCmd.Flags().StringVar(&transpileArtifactDir, "artifact-dir", "", "With --run, build in <dir>/<file name> and keep it")
+	addTraceFlags(transpileCmd)
+	addJSONDiagnosticsFlag(transpileCmd)
+	addWatchFlag(transpileCmd)
+}
+
+// addWatchFlag registers the flag that transpiles again on every change.
+func addWatchFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVarP(&transpileWatch, "watch", "w", false, "Transpile again, and with --run rerun, when the input or a sibling .gala file changes")
+}
+
+// addJSONDiagnosticsFlag registers the flag that reports diagnostics as JSON.
+func addJSONDiagnosticsFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&transpileJSONDiags, "json-diagnostics", false, "Print errors and warnings to stderr as a JSON array, for editors")
+}
+
+// addTraceFlags registers the flags that dump transpiler phases to stderr.
+func addTraceFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&transpileTrace, "trace", "", "Dump transpiler phases to stderr: all or a comma-separated list of parse, analysis, goast")
+	cmd.Flags().Lookup("trace").NoOptDefVal = "all"
+	cmd.Flags().StringVar(&transpileTraceFilter, "trace-filter", "", "With --trace, dump only the declaration with this name (and a type's methods)")
 }
 
 func runTranspile(cmd *cobra.Command, args []string) {
@@ -67,63 +107,300 @@ func runTranspile(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
-	// Create transpiler pipeline
-	p := transpiler.NewAntlrGalaParser()
-	paths := strings.Split(transpileSearch, ",")
-	var a transpiler.Analyzer
-	if transpilePackageFiles != "" {
-		pkgFiles := strings.Split(transpilePackageFiles, ",")
-		a = analyzer.NewGalaAnalyzerWithPackageFiles(p, paths, pkgFiles)
-	} else {
-		a = analyzer.NewGalaAnalyzer(p, paths)
+	var target transpiler.GoVersion
+	if transpileGoVersion != "" {
+		target, err = transpiler.ParseGoVersion(transpileGoVersion)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
 	}
-	tr := transformer.NewGalaASTTransformer()
-	g := generator.NewGoCodeGenerator()
-	t := transpiler.NewGalaToGoTranspiler(p, a, tr, g)
 
-	// Transpile
-	goCode, err := t.Transpile(string(content), inputPath)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: transpilation failed: %v\n", err)
-		os.Exit(1)
+	if transpileWatch {
+		watchTranspile(inputPath, target)
+		return
 	}
 
-	// Determine output handling
-	tempDir := ""
-	actualOutput := transpileOutput
-	if transpileRun && transpileOutput == "" {
-		tempDir, err = os.MkdirTemp("", "gala-run-*")
+	// --run builds in its own directory, removed afterwards unless artifacts are kept
+	var run *runArtifacts
+	if transpileRun {
+		run, err = newRunArtifacts(inputPath)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error: failed to create temp dir: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
-		defer os.RemoveAll(tempDir)
-		actualOutput = filepath.Join(tempDir, "main.go")
+	}
+	// exit releases the run directory, which a deferred call would miss on os.Exit
+	exit := func(code int) {
+		if run != nil {
+			run.cleanup(code != 0)
+		}
+		os.Exit(code)
+	}
+
+	// Outside the gala module the generated code cannot import martianoff/gala,
+	// so the run directory becomes a self-contained module with a copy of the stdlib
+	var standalone *build.StandaloneModule
+	if run != nil && !insideGalaModule() {
+		standalone, err = build.NewStandaloneModule(run.dir, target)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+	}
+
+	// Transpile
+	opts, err := transpileOptions(inputPath, standalone)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		exit(1)
+	}
+	goCode, err := compileInput(string(content), opts)
+	if err != nil {
+		if !transpileJSONDiags {
+			fmt.Fprintf(os.Stderr, "Error: transpilation failed: %v\n", err)
+		}
+		exit(1)
 	}
 
 	// Write output
-	if actualOutput != "" {
-		err = os.WriteFile(actualOutput, []byte(goCode), 0644)
+	if transpileOutput != "" {
+		err = os.WriteFile(transpileOutput, []byte(goCode), 0644)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: failed to write output file: %v\n", err)
-			os.Exit(1)
+			exit(1)
 		}
-		if !transpileRun || transpileOutput != "" {
-			fmt.Printf("Generated Go code saved to %s\n", actualOutput)
-		}
-	} else if !transpileRun {
+		fmt.Printf("Generated Go code saved to %s\n", transpileOutput)
+	} else if run == nil {
 		fmt.Println(goCode)
 	}
 
-	// Run if requested
+	// Run if requested, from the run directory whether or not -o was given
+	if run != nil {
+		exit(run.execute(goCode, standalone))
+	}
+}
+
+// transpileOptions returns the compiler options the transpile flags select for
+// inputPath, searching the stdlib copy of standalone too when it is not nil.
+func transpileOptions(inputPath string, standalone *build.StandaloneModule) (compiler.Options, error) {
+	paths := strings.Split(transpileSearch, ",")
+	if standalone != nil {
+		paths = append(paths, standalone.StdlibDir())
+	}
+	opts := compiler.Options{FileName: inputPath, SearchPaths: paths, GoVersion: transpileGoVersion, Features: transpileDefines}
+	if transpileOutput != "" {
+		opts.RegenerateCommand = fmt.Sprintf("gala transpile %s -o %s", filepath.ToSlash(inputPath), filepath.ToSlash(transpileOutput))
+	}
+	if transpilePackageFiles != "" {
+		opts.PackageFiles = strings.Split(transpilePackageFiles, ",")
+	}
+	if transpileTrace != "" {
+		phases, err := transpiler.ParseTracePhases(transpileTrace)
+		if err != nil {
+			return opts, err
+		}
+		opts.Trace, opts.TracePhases, opts.TraceFilter = os.Stderr, phases, transpileTraceFilter
+	}
+	return opts, nil
+}
+
+// compileInput transpiles content and returns the Go code. Warnings are
+// printed, and so are errors with --json-diagnostics; the first error is
+// returned either way.
+func compileInput(content string, opts compiler.Options) (string, error) {
+	goSrc, diags, _ := compiler.Compile(content, opts)
+	if transpileJSONDiags {
+		printJSONDiagnostics(diags)
+	} else {
+		for _, d := range diags {
+			if d.Severity == compiler.SeverityWarning {
+				fmt.Fprintln(os.Stderr, d)
+			}
+		}
+	}
+	if err := diags.Err(); err != nil {
+		return "", err
+	}
+	return string(goSrc), nil
+}
+
+// watchTranspile transpiles inputPath as runTranspile does, and again whenever
+// it, another .gala file of its directory, one of --package-files or a file of
+// a GALA package they import changes. With --run the program is rebuilt and
+// restarted each time.
+func watchTranspile(inputPath string, target transpiler.GoVersion) {
+	files := scriptFiles(inputPath)
+	if transpilePackageFiles != "" {
+		dirFiles, siblings := files, strings.Split(transpilePackageFiles, ",")
+		files = func() ([]string, error) {
+			paths, err := dirFiles()
+			return append(paths, siblings...), err
+		}
+	}
+
+	var run *runArtifacts
+	var standalone *build.StandaloneModule
 	if transpileRun {
-		execCmd := exec.Command("go", "run", actualOutput)
-		execCmd.Stdout = os.Stdout
-		execCmd.Stderr = os.Stderr
-		err = execCmd.Run()
+		var err error
+		run, err = newRunArtifacts(inputPath)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error: failed to run generated code: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
+		defer run.cleanup(false)
+		if !insideGalaModule() {
+			if standalone, err = build.NewStandaloneModule(run.dir, target); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return
+			}
+		}
+	}
+
+	rebuild := func() (string, error) {
+		content, err := os.ReadFile(inputPath)
+		if err != nil {
+			return "", err
+		}
+		opts, err := transpileOptions(inputPath, standalone)
+		if err != nil {
+			return "", err
+		}
+		goCode, err := compileInput(string(content), opts)
+		if err != nil {
+			return "", fmt.Errorf("transpilation failed: %w", err)
+		}
+		if transpileOutput != "" {
+			if err := os.WriteFile(transpileOutput, []byte(goCode), 0644); err != nil {
+				return "", fmt.Errorf("failed to write output file: %w", err)
+			}
+			fmt.Printf("Generated Go code saved to %s\n", transpileOutput)
+		} else if run == nil {
+			fmt.Println(goCode)
+		}
+		if run == nil {
+			return "", nil
+		}
+		return run.build(goCode, standalone)
+	}
+	searchPaths := func() []string {
+		opts, err := transpileOptions(inputPath, standalone)
+		if err != nil {
+			return nil
+		}
+		return opts.SearchPaths
+	}
+	if err := watchLoop(withImports(files, searchPaths), rebuild, nil, "."); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	}
+}
+
+// runArtifacts is the directory a --run invocation writes and builds the generated code in.
+type runArtifacts struct {
+	dir    string
+	goFile string // the generated code, named after the input file
+	keep   bool
+}
+
+// newRunArtifacts creates the run directory for inputPath. With --artifact-dir it is
+// <artifact-dir>/<input name>, emptied first so every run starts from the same state;
+// otherwise it is a new temp dir.
+func newRunArtifacts(inputPath string) (*runArtifacts, error) {
+	name := strings.TrimSuffix(filepath.Base(inputPath), ".gala")
+	r := &runArtifacts{keep: transpileKeepArtifacts}
+	if transpileArtifactDir != "" {
+		r.dir = filepath.Join(transpileArtifactDir, name)
+		r.keep = true
+		if err := os.RemoveAll(r.dir); err != nil {
+			return nil, fmt.Errorf("failed to clear artifact dir: %w", err)
+		}
+		if err := os.MkdirAll(r.dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create artifact dir: %w", err)
+		}
+	} else {
+		dir, err := os.MkdirTemp("", "gala-run-"+name+"-*")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create temp dir: %w", err)
+		}
+		r.dir = dir
+	}
+	r.goFile = filepath.Join(r.dir, name+".gen.go")
+	return r, nil
+}
+
+// execute builds goCode and runs it from the current directory. It returns
+// the exit code for gala.
+func (r *runArtifacts) execute(goCode string, standalone *build.StandaloneModule) int {
+	binPath, err := r.build(goCode, standalone)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	execCmd := exec.Command(binPath)
+	execCmd.Stdin = os.Stdin
+	execCmd.Stdout = os.Stdout
+	execCmd.Stderr = os.Stderr
+	if err := execCmd.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to run generated code: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// build writes goCode to the run directory, builds it and returns the path
+// of the binary.
+func (r *runArtifacts) build(goCode string, standalone *build.StandaloneModule) (string, error) {
+	if err := os.WriteFile(r.goFile, []byte(goCode), 0644); err != nil {
+		return "", fmt.Errorf("failed to write output file: %w", err)
+	}
+	if standalone != nil {
+		return standalone.Build()
+	}
+	binPath := filepath.Join(r.dir, "gala-run")
+	if runtime.GOOS == "windows" {
+		binPath += ".exe"
+	}
+	cmd := exec.Command("go", "build", "-o", binPath, r.goFile)
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("go build: %w", err)
+	}
+	return binPath, nil
+}
+
+// cleanup removes the run directory unless artifacts are kept.
+func (r *runArtifacts) cleanup(failed bool) {
+	if r.keep {
+		fmt.Fprintf(os.Stderr, "Generated code kept in %s\n", r.goFile)
+		return
+	}
+	os.RemoveAll(r.dir)
+	if failed {
+		fmt.Fprintln(os.Stderr, "Rerun with --keep-artifacts to inspect the generated code.")
+	}
+}
+
+// insideGalaModule reports whether the current directory belongs to the gala
+// module itself, where generated code imports the in-tree standard library.
+func insideGalaModule() bool {
+	wd, err := os.Getwd()
+	if err != nil {
+		return false
+	}
+	_, name := module.FindModuleRoot(wd)
+	return name == "martianoff/gala"
+}
+
+// printJSONDiagnostics writes diags to stderr as one JSON array, which is
+// empty when there are none, so editors can always parse the output.
+func printJSONDiagnostics(diags compiler.Diagnostics) {
+	if diags == nil {
+		diags = compiler.Diagnostics{}
+	}
+	data, err := json.Marshal(diags)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
 	}
+	fmt.Fprintln(os.Stderr, string(data))
 }
@@ -0,0 +1,255 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"martianoff/gala/internal/transpiler"
+	"martianoff/gala/internal/transpiler/analyzer"
+)
+
+// watchInterval is how often watch mode looks for changed files.
+const watchInterval = 250 * time.Millisecond
+
+// watchDebounce is how long the files must stay unchanged after a change
+// before the program is rebuilt, so that saving several files, or an editor
+// writing a file in steps, causes a single rebuild.
+const watchDebounce = 300 * time.Millisecond
+
+// fileStamp is what a change to a watched file is detected by.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// watchLoop builds the program with build and runs it with args from dir,
+// then does so again whenever one of the files returned by files changes,
+// stopping the previous run first. build returns an empty path when there is
+// nothing to run. watchLoop returns on an interrupt, or if files fails.
+func watchLoop(files func() ([]string, error), build func() (string, error), args []string, dir string) error {
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	for {
+		stamps, err := stampFiles(files)
+		if err != nil {
+			return err
+		}
+		var run *watchedRun
+		if binPath, err := build(); err != nil {
+			printWatchError("Build failed: %v", err)
+		} else if binPath != "" {
+			run = startWatchedRun(binPath, args, dir)
+		}
+		watchStatus("watching for changes (Ctrl+C to stop)")
+		changed, err := waitForChange(files, stamps, interrupt)
+		if run != nil {
+			run.stop()
+		}
+		if !changed {
+			return err
+		}
+		watchStatus("change detected, rebuilding")
+	}
+}
+
+// stampFiles returns the stamps of the files returned by files. Files that
+// disappeared in the meantime are left out.
+func stampFiles(files func() ([]string, error)) (map[string]fileStamp, error) {
+	paths, err := files()
+	if err != nil {
+		return nil, err
+	}
+	return statFiles(paths), nil
+}
+
+// statFiles returns the stamps of the files at paths that exist.
+func statFiles(paths []string) map[string]fileStamp {
+	stamps := make(map[string]fileStamp, len(paths))
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil {
+			stamps[path] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+		}
+	}
+	return stamps
+}
+
+// waitForChange polls the files until they differ from stamps, including
+// files added or removed, and then until they stay the same for
+// watchDebounce. It reports false if interrupted first or if files fails.
+func waitForChange(files func() ([]string, error), stamps map[string]fileStamp, interrupt <-chan os.Signal) (bool, error) {
+	changed := false
+	for {
+		delay := watchInterval
+		if changed {
+			delay = watchDebounce
+		}
+		select {
+		case <-interrupt:
+			return false, nil
+		case <-time.After(delay):
+		}
+		current, err := stampFiles(files)
+		if err != nil {
+			return false, err
+		}
+		if sameStamps(current, stamps) {
+			if changed {
+				return true, nil
+			}
+			continue
+		}
+		stamps, changed = current, true
+	}
+}
+
+func sameStamps(a, b map[string]fileStamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, stamp := range a {
+		if other, ok := b[path]; !ok || other != stamp {
+			return false
+		}
+	}
+	return true
+}
+
+// watchedRun is a run of the program started by watchLoop.
+type watchedRun struct {
+	cmd  *exec.Cmd
+	done chan struct{}
+}
+
+// startWatchedRun starts the binary at binPath and reports how it ends. A
+// program that fails to start is reported the same way.
+func startWatchedRun(binPath string, args []string, dir string) *watchedRun {
+	cmd := exec.Command(binPath, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Dir = dir
+	run := &watchedRun{cmd: cmd, done: make(chan struct{})}
+	if err := cmd.Start(); err != nil {
+		printWatchError("Error running program: %v", err)
+		close(run.done)
+		return run
+	}
+	go func() {
+		defer close(run.done)
+		err := cmd.Wait()
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			if exitErr.Exited() {
+				printWatchError("program exited with status %d", exitErr.ExitCode())
+			}
+			return
+		}
+		if err != nil {
+			printWatchError("Error running program: %v", err)
+			return
+		}
+		watchStatus("program exited")
+	}()
+	return run
+}
+
+// stop kills the program if it is still running and waits for it to end.
+func (r *watchedRun) stop() {
+	select {
+	case <-r.done:
+		return
+	default:
+	}
+	r.cmd.Process.Kill()
+	<-r.done
+}
+
+// projectFiles returns the files a rebuild of the project in dir depends on:
+// its .gala files and its module and configuration files.
+func projectFiles(dir string) func() ([]string, error) {
+	return func() ([]string, error) {
+		files, err := galaFiles(dir)
+		if err != nil {
+			return nil, err
+		}
+		return append(files, filepath.Join(dir, "gala.mod"), filepath.Join(dir, "gala.toml")), nil
+	}
+}
+
+// scriptFiles returns the files a rebuild of the single file path depends on:
+// the file and the other .gala files of its directory, which its imports can
+// resolve to.
+func scriptFiles(path string) func() ([]string, error) {
+	return func() ([]string, error) {
+		matches, err := filepath.Glob(filepath.Join(filepath.Dir(path), "*.gala"))
+		if err != nil {
+			return nil, err
+		}
+		files := []string{path}
+		for _, match := range matches {
+			if filepath.Clean(match) != filepath.Clean(path) {
+				files = append(files, match)
+			}
+		}
+		return files, nil
+	}
+}
+
+// withImports extends the files returned by files with the .gala files of the
+// GALA packages they import, directly or indirectly, as the analyzer resolves
+// them through searchPaths. Since the imports are read by parsing, they are
+// looked up again only when one of the files found last time changed, or the
+// files returned by files did.
+func withImports(files func() ([]string, error), searchPaths func() []string) func() ([]string, error) {
+	var lastPaths, last []string
+	var lastStamps map[string]fileStamp
+	return func() ([]string, error) {
+		paths, err := files()
+		if err != nil {
+			return nil, err
+		}
+		if last != nil && slices.Equal(paths, lastPaths) && sameStamps(statFiles(last), lastStamps) {
+			return last, nil
+		}
+		var sources []string
+		for _, path := range paths {
+			if filepath.Ext(path) == ".gala" {
+				sources = append(sources, path)
+			}
+		}
+		imported := analyzer.ImportedFiles(transpiler.NewAntlrGalaParser(), searchPaths(), sources)
+		lastPaths = paths
+		last = append(slices.Clip(paths), imported...)
+		lastStamps = statFiles(last)
+		return last, nil
+	}
+}
+
+// watchStatus prints a progress message of watch mode.
+func watchStatus(msg string) {
+	fmt.Fprintln(os.Stderr, paintStderr("2", "[gala] "+msg))
+}
+
+// printWatchError prints an error of watch mode, in red on a terminal.
+func printWatchError(format string, args ...any) {
+	msg := strings.TrimRight(fmt.Sprintf(format, args...), "\n")
+	fmt.Fprintln(os.Stderr, paintStderr("31", msg))
+}
+
+// paintStderr colors s with the ANSI color code when standard error is a
+// terminal and colors are not turned off with NO_COLOR.
+func paintStderr(code string, s string) string {
+	if os.Getenv("NO_COLOR") != "" {
+		return s
+	}
+	if info, err := os.Stderr.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return s
+	}
+	return "\x1b[" + code + "m" + s + "\x1b[0m"
+}
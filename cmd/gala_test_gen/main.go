@@ -1,54 +1,113 @@
 // gala_test_gen generates a main.go file that runs all Test* functions found in the input files.
 // This enables Go-style test conventions where test functions start with "Test" and take a T parameter.
+// Example* functions ending in an // Output: comment run too, and pass if they print that output.
+// With -fuzz it generates a _test.go file instead, exposing the Fuzz* functions to Go's native fuzzing.
 package main
 
 import (
-	"bufio"
 	"flag"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
-	"regexp"
 	"sort"
 	"strings"
+
+	"martianoff/gala/internal/parser/grammar"
+	"martianoff/gala/internal/transpiler"
 )
 
-// testFuncRegex matches function declarations that start with Test.
-// Pattern: func TestXxx(t T) T or func TestXxx(t test.T) test.T
-var testFuncRegex = regexp.MustCompile(`^\s*func\s+(Test\w+)\s*\(\s*\w+\s+(?:test\.)?T\s*\)\s+(?:test\.)?T`)
+// testFunc is a test found in a test file: a function
+//
+//	func TestXxx(t T) T
+//
+// or a method of the same signature on a struct declared in the test files,
+// which runs on the zero value of the struct.
+type testFunc struct {
+	Name    string // reported name, e.g. TestAdd or Suite.TestAdd
+	Expr    string // Go expression of the func(T) T running the test
+	Skipped bool   // annotated @skip
+	Example bool   // an example: Expr is a func() printing Output
+	Output  string
+}
+
+// fuzzFunc is a fuzz test found in a test file:
+//
+//	func FuzzXxx(t T, data string, n int) T
+//
+// which after T takes values of types Go's fuzzing generates. It runs as the
+// Go fuzz target FuzzXxxGo.
+type fuzzFunc struct {
+	Name    string
+	Params  []string // Go types of the fuzzed values
+	Skipped bool     // annotated @skip
+}
+
+// fuzzTargetSuffix names the Go fuzz target of a GALA fuzz test, which cannot
+// share its name.
+const fuzzTargetSuffix = "Go"
+
+// fuzzTypes are the types of values Go's fuzzing generates.
+var fuzzTypes = map[string]bool{
+	"string": true, "[]byte": true, "bool": true, "rune": true, "byte": true,
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true,
+	"float32": true, "float64": true,
+}
 
 func main() {
 	var (
 		outputPath string
 		pkgName    string
+		fuzz       bool
 	)
 
 	flag.StringVar(&outputPath, "output", "", "Path to the output main.go file")
 	flag.StringVar(&pkgName, "package", "main", "Package name for the generated file")
+	flag.BoolVar(&fuzz, "fuzz", false, "Generate Go fuzz targets for the Fuzz* functions instead of a main file")
 	flag.Parse()
 
 	if flag.NArg() == 0 {
-		fmt.Println("Usage: gala_test_gen [options] <test_files...>")
+		fmt.Println("Usage: gala_test_gen [options] <test_files or dirs...>")
 		flag.PrintDefaults()
 		os.Exit(1)
 	}
 
-	// Collect all test functions from all files
-	testFuncs := make(map[string][]string) // file -> []funcName
-	for _, path := range flag.Args() {
-		funcs, err := findTestFunctions(path)
+	paths, err := testFiles(flag.Args())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Structs may be declared in another file than their test methods
+	p := transpiler.NewAntlrGalaParser()
+	var files []*grammar.SourceFileContext
+	for _, path := range paths {
+		sf, err := parseFile(p, path)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error scanning %s: %v\n", path, err)
 			os.Exit(1)
 		}
-		if len(funcs) > 0 {
-			testFuncs[path] = funcs
+		files = append(files, sf)
+	}
+	var code string
+	if fuzz {
+		var fuzzes []fuzzFunc
+		for _, sf := range files {
+			fuzzes = append(fuzzes, findFuzzFunctions(sf)...)
+		}
+		code = generateFuzzFile(pkgName, fuzzes)
+	} else {
+		structs := structNames(files)
+		var tests []testFunc
+		for _, sf := range files {
+			tests = append(tests, findTestFunctions(sf, structs)...)
+			tests = append(tests, findExamples(sf)...)
 		}
+		// Generate the main.go file (Go code, not GALA)
+		code = generateMainFile(pkgName, tests)
 	}
 
-	// Generate the main.go file (Go code, not GALA)
-	code := generateMainFile(pkgName, testFuncs)
-
 	if outputPath != "" {
 		err := os.WriteFile(outputPath, []byte(code), 0644)
 		if err != nil {
@@ -60,71 +119,360 @@ func main() {
 	}
 }
 
-func findTestFunctions(path string) ([]string, error) {
-	file, err := os.Open(path)
+// testFiles returns the files of args, with each directory replaced by the
+// _test.gala files in it and its subdirectories.
+func testFiles(args []string) ([]string, error) {
+	var files []string
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, arg)
+			continue
+		}
+		err = filepath.WalkDir(arg, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && strings.HasSuffix(path, "_test.gala") {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+func parseFile(p transpiler.GalaParser, path string) (*grammar.SourceFileContext, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := p.Parse(string(content))
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
+	sf, ok := tree.(*grammar.SourceFileContext)
+	if !ok {
+		return nil, fmt.Errorf("expected a source file, got %T", tree)
+	}
+	return sf, nil
+}
 
-	var funcs []string
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
-		matches := testFuncRegex.FindStringSubmatch(line)
-		if len(matches) >= 2 {
-			funcs = append(funcs, matches[1])
+// structNames returns the non-generic struct types declared in files, whose
+// zero values test methods can run on.
+func structNames(files []*grammar.SourceFileContext) map[string]bool {
+	structs := make(map[string]bool)
+	for _, sf := range files {
+		for _, decl := range sf.AllTopLevelDeclaration() {
+			if td, ok := decl.TypeDeclaration().(*grammar.TypeDeclarationContext); ok && td.StructType() != nil && td.TypeParameters() == nil {
+				structs[td.Identifier().GetText()] = true
+			}
+			if sd, ok := decl.StructShorthandDeclaration().(*grammar.StructShorthandDeclarationContext); ok {
+				structs[sd.Identifier().GetText()] = true
+			}
 		}
 	}
+	return structs
+}
 
-	if err := scanner.Err(); err != nil {
-		return nil, err
+// findTestFunctions returns the tests declared in sf. Functions and methods
+// that look like tests but take type parameters, or methods of types other
+// than structs, are helpers and left out.
+func findTestFunctions(sf *grammar.SourceFileContext, structs map[string]bool) []testFunc {
+	var tests []testFunc
+	for _, decl := range sf.AllTopLevelDeclaration() {
+		fn, ok := decl.FunctionDeclaration().(*grammar.FunctionDeclarationContext)
+		if !ok {
+			continue
+		}
+		name := fn.Identifier().GetText()
+		if !strings.HasPrefix(name, "Test") || fn.TypeParameters() != nil || !isTestSignature(fn.Signature()) {
+			continue
+		}
+		test := testFunc{Name: name, Expr: name}
+		if recv := fn.Receiver(); recv != nil {
+			typ := recv.Type_().GetText()
+			ptr := strings.HasPrefix(typ, "*")
+			typ = strings.TrimPrefix(typ, "*")
+			if !structs[typ] {
+				continue
+			}
+			test.Name = typ + "." + name
+			test.Expr = typ + "{}." + name
+			if ptr {
+				test.Expr = "(&" + typ + "{})." + name
+			}
+		}
+		for _, an := range decl.AllAnnotation() {
+			if an.Identifier().GetText() == transpiler.AnnotationSkip {
+				test.Skipped = true
+			}
+		}
+		tests = append(tests, test)
 	}
+	return tests
+}
 
-	return funcs, nil
+// isTestSignature reports whether sig is (t T) T, with T or test.T.
+func isTestSignature(sig grammar.ISignatureContext) bool {
+	if sig.Type_() == nil || !isTestType(sig.Type_().GetText()) {
+		return false
+	}
+	list := sig.Parameters().ParameterList()
+	if list == nil || len(list.AllParameter()) != 1 {
+		return false
+	}
+	param := list.Parameter(0)
+	return param.ELLIPSIS() == nil && param.GetByName() == nil && param.Type_() != nil && isTestType(param.Type_().GetText())
 }
 
-func generateMainFile(pkgName string, testFuncs map[string][]string) string {
+// findFuzzFunctions returns the fuzz tests declared in sf.
+func findFuzzFunctions(sf *grammar.SourceFileContext) []fuzzFunc {
+	var fuzzes []fuzzFunc
+	for _, decl := range sf.AllTopLevelDeclaration() {
+		fn, ok := decl.FunctionDeclaration().(*grammar.FunctionDeclarationContext)
+		if !ok || fn.Receiver() != nil || fn.TypeParameters() != nil {
+			continue
+		}
+		name := fn.Identifier().GetText()
+		sig := fn.Signature()
+		if !strings.HasPrefix(name, "Fuzz") || sig.Type_() == nil || !isTestType(sig.Type_().GetText()) {
+			continue
+		}
+		list := sig.Parameters().ParameterList()
+		if list == nil || len(list.AllParameter()) < 2 {
+			continue
+		}
+		fuzz := fuzzFunc{Name: name}
+		for i, param := range list.AllParameter() {
+			if param.ELLIPSIS() != nil || param.GetByName() != nil || param.Type_() == nil {
+				fuzz.Params = nil
+				break
+			}
+			typ := param.Type_().GetText()
+			if i == 0 && !isTestType(typ) || i > 0 && !fuzzTypes[typ] {
+				fuzz.Params = nil
+				break
+			}
+			if i > 0 {
+				fuzz.Params = append(fuzz.Params, typ)
+			}
+		}
+		if fuzz.Params == nil {
+			continue
+		}
+		for _, an := range decl.AllAnnotation() {
+			if an.Identifier().GetText() == transpiler.AnnotationSkip {
+				fuzz.Skipped = true
+			}
+		}
+		fuzzes = append(fuzzes, fuzz)
+	}
+	return fuzzes
+}
+
+// findExamples returns the examples declared in sf: functions
+//
+//	func ExampleXxx() {
+//	    fmt.Println("hello")
+//	    // Output: hello
+//	}
+//
+// whose body ends in a comment starting with Output:, which holds what they
+// must print. Examples without one are only compiled, as in Go.
+func findExamples(sf *grammar.SourceFileContext) []testFunc {
+	var examples []testFunc
+	for _, decl := range sf.AllTopLevelDeclaration() {
+		fn, ok := decl.FunctionDeclaration().(*grammar.FunctionDeclarationContext)
+		if !ok || fn.Receiver() != nil || fn.TypeParameters() != nil || fn.Block() == nil {
+			continue
+		}
+		name := fn.Identifier().GetText()
+		sig := fn.Signature()
+		if !strings.HasPrefix(name, "Example") || sig.Type_() != nil || sig.Parameters().ParameterList() != nil {
+			continue
+		}
+		start, stop := fn.Block().GetStart(), fn.Block().GetStop()
+		output, ok := exampleOutput(start.GetInputStream().GetText(start.GetStart(), stop.GetStop()))
+		if !ok {
+			continue
+		}
+		example := testFunc{Name: name, Expr: name, Example: true, Output: output}
+		for _, an := range decl.AllAnnotation() {
+			if an.Identifier().GetText() == transpiler.AnnotationSkip {
+				example.Skipped = true
+			}
+		}
+		examples = append(examples, example)
+	}
+	return examples
+}
+
+// exampleOutput returns the output expected from an example with body
+// block, the text of the comment lines closing the block after Output:, and
+// whether there is such a comment.
+func exampleOutput(block string) (string, bool) {
+	lines := strings.Split(strings.TrimSuffix(strings.TrimSpace(block), "}"), "\n")
+	var comment []string
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := strings.TrimSpace(lines[i])
+		if line == "" && len(comment) == 0 {
+			continue
+		}
+		text, ok := strings.CutPrefix(line, "//")
+		if !ok {
+			break
+		}
+		comment = append([]string{text}, comment...)
+	}
+	if len(comment) == 0 {
+		return "", false
+	}
+	first, ok := strings.CutPrefix(strings.TrimSpace(comment[0]), "Output:")
+	if !ok {
+		return "", false
+	}
+	output := []string{first}
+	for _, text := range comment[1:] {
+		output = append(output, strings.TrimPrefix(text, " "))
+	}
+	return strings.TrimSpace(strings.Join(output, "\n")), true
+}
+
+func isTestType(typ string) bool {
+	return typ == "T" || typ == "test.T"
+}
+
+func generateMainFile(pkgName string, tests []testFunc) string {
 	var sb strings.Builder
 
 	sb.WriteString(fmt.Sprintf("package %s\n\n", pkgName))
 
-	// Always import std for NewImmutable
+	// Always import std for NewImmutable and RecoverPretty
 	sb.WriteString("import \"martianoff/gala/std\"\n")
 
 	// Import test framework if not in package test (to avoid circular import)
 	if pkgName != "test" {
 		sb.WriteString("import . \"martianoff/gala/test\"\n")
 	}
+	examples := false
+	for _, test := range tests {
+		examples = examples || test.Example
+	}
+	if examples {
+		sb.WriteString("import \"io\"\n")
+		sb.WriteString("import \"os\"\n")
+		sb.WriteString("import \"strings\"\n")
+	}
 	sb.WriteString("\n")
 
-	// Collect all functions sorted for deterministic output
-	var allFuncs []string
-	for _, funcs := range testFuncs {
-		allFuncs = append(allFuncs, funcs...)
-	}
-	sort.Strings(allFuncs)
+	// Sort tests for deterministic output
+	sorted := append([]testFunc(nil), tests...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
 
 	sb.WriteString("func main() {\n")
+	// Panics outside a test function report the .gala declarations on the stack
+	sb.WriteString("\tdefer std.RecoverPretty()\n")
 	sb.WriteString("\tRunTests(")
 
-	for i, funcName := range allFuncs {
+	for i, test := range sorted {
 		if i > 0 {
 			sb.WriteString(", ")
 		}
+		f := test.Expr
+		if test.Skipped {
+			f = "func(t T) T { return t.Skip() }"
+		} else if test.Example {
+			sb.WriteString(fmt.Sprintf("NewExample(\"%s\", func() string { return captureStdout(%s) }, %q)", test.Name, test.Expr, test.Output))
+			continue
+		}
 		// Generate Go struct literal syntax
-		sb.WriteString(fmt.Sprintf("TestFunc{Name: std.NewImmutable(\"%s\"), F: std.NewImmutable(%s)}", funcName, funcName))
+		sb.WriteString(fmt.Sprintf("TestFunc{Name: std.NewImmutable(\"%s\"), F: std.NewImmutable(%s)}", test.Name, f))
 	}
 
 	sb.WriteString(")\n")
 	sb.WriteString("}\n")
 
+	if examples {
+		sb.WriteString(captureStdoutFunc)
+	}
+
 	return sb.String()
 }
 
-// Helper to get the base name without extension
-func baseName(path string) string {
-	base := filepath.Base(path)
-	ext := filepath.Ext(base)
-	return base[:len(base)-len(ext)]
+// captureStdoutFunc runs an example and returns what it printed. Output is
+// read while the example runs, so it may print more than a pipe buffers.
+const captureStdoutFunc = `
+func captureStdout(f func()) string {
+	r, w, err := os.Pipe()
+	if err != nil {
+		panic(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	out := make(chan string)
+	go func() {
+		var sb strings.Builder
+		io.Copy(&sb, r)
+		out <- sb.String()
+	}()
+	restore := func() string {
+		os.Stdout = stdout
+		w.Close()
+		return <-out
+	}
+	// A panicking example fails as a test does, once stdout is back
+	defer func() {
+		if p := recover(); p != nil {
+			restore()
+			panic(p)
+		}
+	}()
+	f()
+	return restore()
+}
+`
+
+// generateFuzzFile generates a Go test file with a fuzz target for each of
+// fuzzes, which runs the GALA fuzz test on every generated input through
+// FuzzCase and fails with its output.
+func generateFuzzFile(pkgName string, fuzzes []fuzzFunc) string {
+	var sb strings.Builder
+
+	sb.WriteString("// Code generated by gala_test_gen. DO NOT EDIT.\n\n")
+	sb.WriteString(fmt.Sprintf("package %s\n\n", pkgName))
+	sb.WriteString("import \"testing\"\n")
+	if pkgName != "test" {
+		sb.WriteString("import . \"martianoff/gala/test\"\n")
+	}
+
+	sorted := append([]fuzzFunc(nil), fuzzes...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	for _, fuzz := range sorted {
+		var params, args []string
+		for i, typ := range fuzz.Params {
+			params = append(params, fmt.Sprintf("p%d %s", i, typ))
+			args = append(args, fmt.Sprintf("p%d", i))
+		}
+		sb.WriteString(fmt.Sprintf("\nfunc %s%s(f *testing.F) {\n", fuzz.Name, fuzzTargetSuffix))
+		sb.WriteString(fmt.Sprintf("\tf.Fuzz(func(t *testing.T, %s) {\n", strings.Join(params, ", ")))
+		if fuzz.Skipped {
+			sb.WriteString("\t\tt.Skip()\n")
+		} else {
+			sb.WriteString(fmt.Sprintf("\t\tif out := FuzzCase(%q, func(gt T) T { return %s(gt, %s) }); out != \"\" {\n",
+				fuzz.Name, fuzz.Name, strings.Join(args, ", ")))
+			sb.WriteString("\t\t\tt.Fatal(out)\n")
+			sb.WriteString("\t\t}\n")
+		}
+		sb.WriteString("\t})\n")
+		sb.WriteString("}\n")
+	}
+
+	return sb.String()
 }
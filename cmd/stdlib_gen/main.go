@@ -23,10 +23,17 @@ func packageFromPath(path string) string {
 		"collection_mutable",
 		"go_interop",
 		"concurrent",
+		"cli",
+		"env",
+		"files",
 		"lazy",
+		"logging",
+		"random",
+		"sql",
 		"stream",
 		"string_utils",
 		"time_utils",
+		"web",
 		"std",
 	}
 
@@ -133,10 +140,17 @@ var PackageImportPaths = map[string]string{
 	"collection_immutable": "martianoff/gala/collection_immutable",
 	"collection_mutable":   "martianoff/gala/collection_mutable",
 	"concurrent":           "martianoff/gala/concurrent",
+	"cli":                  "martianoff/gala/cli",
+	"env":                  "martianoff/gala/env",
+	"files":                "martianoff/gala/files",
 	"lazy":                 "martianoff/gala/lazy",
+	"logging":              "martianoff/gala/logging",
+	"random":               "martianoff/gala/random",
+	"sql":                  "martianoff/gala/sql",
 	"stream":               "martianoff/gala/stream",
 	"string_utils":         "martianoff/gala/string_utils",
 	"time_utils":           "martianoff/gala/time_utils",
+	"web":                  "martianoff/gala/web",
 }
 `)
 
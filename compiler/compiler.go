@@ -0,0 +1,254 @@
+// Package compiler is the supported Go API for embedding GALA compilation in
+// other tools. It wraps the internal transpiler pipeline (parse, analyze,
+// transform, generate) behind a small surface that is kept stable across
+// releases: Compile for one-off translation and Project for long-lived hosts
+// such as editors, build plugins and the gala CLI.
+package compiler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"martianoff/gala/galaerr"
+	"martianoff/gala/internal/transpiler"
+	"martianoff/gala/internal/transpiler/analyzer"
+	"martianoff/gala/internal/transpiler/generator"
+	"martianoff/gala/internal/transpiler/transformer"
+)
+
+// Options configures a compilation.
+type Options struct {
+	// FileName is the path of the source, used in diagnostics and to locate
+	// the enclosing module. It does not have to exist.
+	FileName string
+	// SearchPaths are the directories imported GALA packages are resolved in,
+	// in addition to the enclosing module and gala.mod dependencies.
+	SearchPaths []string
+	// PackageFiles are the other .gala files of the same package, read for
+	// cross-file type information.
+	PackageFiles []string
+	// GoVersion is the Go release the generated code must compile with,
+	// e.g. "1.21". Empty targets the newest supported release.
+	GoVersion string
+	// Features are the features whose #if feature("name") blocks are
+	// compiled. Blocks of other features are left out.
+	Features []string
+	// RegenerateCommand is the command that regenerates the output, e.g.
+	// "gala transpile main.gala -o main.gen.go". It is recorded in the header
+	// of the generated code, which also names the source when FileName is set.
+	RegenerateCommand string
+	// Trace, if set, receives a dump of the compiler state after each phase in
+	// TracePhases: "parse" (the parse tree), "analysis" (collected type and
+	// function metadata) and "goast" (the generated Go syntax tree). With a
+	// non-empty TraceFilter, only declarations of that name and methods of the
+	// type of that name are dumped.
+	Trace       io.Writer
+	TracePhases []string
+	TraceFilter string
+	// Passes are run on every compiled file, in order, after analysis and
+	// on the generated Go syntax tree; see Pass. Companion files they emit
+	// are returned in Result.Companions by Project; Compile discards them.
+	Passes []Pass
+}
+
+// Pass is a hook run on each compiled file, to check project rules, rewrite
+// the generated code or derive companion files from the declarations. Its
+// Analyzed function sees the analyzed file, its Generated function the Go
+// syntax tree before it is printed; either may be nil. An error fails the
+// compilation and is reported as "pass <Name>: <error>".
+type Pass = transpiler.Pass
+
+// PassContext is what a Pass is given: the analyzed file, with its
+// declarations in Metadata form, the generated Go syntax tree in File and
+// FileSet (nil in Analyzed), and EmitFile to produce companion files.
+type PassContext = transpiler.PassContext
+
+// GoSource is generated Go code.
+type GoSource string
+
+// Metadata describes the declarations of the compiled package. It is the
+// document printed by `gala meta`.
+type Metadata = transpiler.PackageMeta
+
+// Element types of Metadata.
+type (
+	TypeMeta      = transpiler.TypeMeta
+	TypeParam     = transpiler.TypeParam
+	FieldMeta     = transpiler.FieldMeta
+	FuncMeta      = transpiler.FuncMeta
+	VariantMeta   = transpiler.VariantMeta
+	CompanionMeta = transpiler.CompanionMeta
+)
+
+// Compile translates the GALA source src to Go. The source is empty when the
+// diagnostics contain an error; metadata is nil if analysis did not complete.
+func Compile(src string, opts Options) (GoSource, Diagnostics, *Metadata) {
+	goSrc, diags, meta, _ := compile(src, opts, analyzer.NewPackageCache())
+	return goSrc, diags, meta
+}
+
+// compile is Compile with the analyzed imports kept in cache. It also returns
+// the companion files emitted by opts.Passes.
+func compile(src string, opts Options, cache *analyzer.PackageCache) (GoSource, Diagnostics, *Metadata, map[string]string) {
+	goVersion, err := parseGoVersion(opts.GoVersion)
+	if err != nil {
+		return "", Diagnostics{{Severity: SeverityError, Message: err.Error()}}, nil, nil
+	}
+
+	var meta *Metadata
+	capture := transpiler.Pass{
+		Name: "compiler.metadata",
+		Analyzed: func(ctx *transpiler.PassContext) error {
+			meta = ctx.Metadata()
+			return nil
+		},
+	}
+
+	options := []transpiler.Option{
+		transpiler.WithPass(capture),
+		transpiler.WithRegenerateCommand(opts.RegenerateCommand),
+	}
+	for _, pass := range opts.Passes {
+		options = append(options, transpiler.WithPass(pass))
+	}
+	if opts.Trace != nil {
+		options = append(options, transpiler.WithTrace(opts.Trace, opts.TracePhases, opts.TraceFilter))
+	}
+
+	p := transpiler.NewAntlrGalaParserWithFeatures(opts.Features)
+	a := analyzer.NewGalaAnalyzerWithCache(p, opts.SearchPaths, opts.PackageFiles, cache)
+	t := transpiler.NewGalaToGoTranspiler(p, a,
+		transformer.NewGalaASTTransformerWithTarget(goVersion),
+		generator.NewGoCodeGeneratorWithTarget(goVersion),
+		options...)
+
+	goCode, err := t.Transpile(src, opts.FileName)
+	var diags Diagnostics
+	for _, w := range t.Warnings() {
+		diags = append(diags, Diagnostic{
+			Severity: SeverityWarning,
+			File:     w.FilePath,
+			Line:     w.Line,
+			Column:   w.Column,
+			Message:  w.Msg,
+		})
+	}
+	if err != nil {
+		return "", append(diags, errorDiagnostics(err, opts.FileName)...), meta, nil
+	}
+	return GoSource(goCode), diags, meta, t.CompanionFiles()
+}
+
+func parseGoVersion(s string) (transpiler.GoVersion, error) {
+	if s == "" {
+		return transpiler.GoVersion{}, nil
+	}
+	return transpiler.ParseGoVersion(s)
+}
+
+// errorDiagnostics converts a transpiler error into diagnostics, one per
+// error of a MultiError.
+func errorDiagnostics(err error, file string) Diagnostics {
+	var multi *galaerr.MultiError
+	if errors.As(err, &multi) {
+		var diags Diagnostics
+		for _, e := range multi.Errors {
+			diags = append(diags, errorDiagnostics(e, file)...)
+		}
+		return diags
+	}
+
+	d := Diagnostic{Severity: SeverityError, File: file, Message: err.Error()}
+	var syntaxErr *galaerr.SyntaxError
+	var semanticErr *galaerr.SemanticError
+	switch {
+	case errors.As(err, &syntaxErr):
+		d.Kind, d.Line, d.Column, d.Code, d.Message = string(syntaxErr.ErrType), syntaxErr.Line, syntaxErr.Column, string(syntaxErr.Code), syntaxErr.Msg
+	case errors.As(err, &semanticErr):
+		d.Kind, d.Line, d.Column, d.Code, d.Message = string(semanticErr.ErrType), semanticErr.Line, semanticErr.Column, string(semanticErr.Code), semanticErr.Msg
+		if semanticErr.FilePath != "" {
+			d.File = semanticErr.FilePath
+		}
+	}
+	return Diagnostics{d}
+}
+
+// Severity distinguishes errors, which fail a compilation, from warnings.
+type Severity int
+
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+)
+
+func (s Severity) String() string {
+	if s == SeverityWarning {
+		return "Warning"
+	}
+	return "Error"
+}
+
+// MarshalText encodes s as "error" or "warning".
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(strings.ToLower(s.String())), nil
+}
+
+// Diagnostic is an error or warning reported by the compiler. Line and Column
+// are 1-based and zero when the problem has no position.
+// It encodes to JSON with lower-case keys, leaving out empty fields.
+type Diagnostic struct {
+	Severity Severity `json:"severity"`
+	Kind     string   `json:"kind,omitempty"` // "SyntaxError" or "SemanticError" for GALA errors
+	File     string   `json:"file,omitempty"`
+	Line     int      `json:"line,omitempty"`
+	Column   int      `json:"column,omitempty"`
+	Code     string   `json:"code,omitempty"` // error code, documented by `gala explain`; may be empty
+	Message  string   `json:"message"`
+}
+
+// String formats d the way the gala CLI prints it, e.g.
+// "[SemanticError E0001] main.gala:4:5 cannot assign to immutable variable count".
+func (d Diagnostic) String() string {
+	label := d.Kind
+	if label == "" {
+		label = d.Severity.String()
+	}
+	if d.Code != "" {
+		label += " " + d.Code
+	}
+	switch {
+	case d.Line > 0 && d.File != "":
+		return fmt.Sprintf("[%s] %s:%d:%d %s", label, d.File, d.Line, d.Column, d.Message)
+	case d.Line > 0:
+		return fmt.Sprintf("[%s] line %d:%d %s", label, d.Line, d.Column, d.Message)
+	case d.File != "":
+		return fmt.Sprintf("[%s] %s: %s", label, d.File, d.Message)
+	}
+	return fmt.Sprintf("[%s] %s", label, d.Message)
+}
+
+// Diagnostics is the list of problems reported by a compilation.
+type Diagnostics []Diagnostic
+
+// HasErrors reports whether any diagnostic is an error.
+func (ds Diagnostics) HasErrors() bool {
+	for _, d := range ds {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Err returns the errors among ds as a single error, or nil if there are none.
+func (ds Diagnostics) Err() error {
+	var errs []error
+	for _, d := range ds {
+		if d.Severity == SeverityError {
+			errs = append(errs, errors.New(d.String()))
+		}
+	}
+	return errors.Join(errs...)
+}
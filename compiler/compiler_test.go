@@ -0,0 +1,235 @@
+package compiler_test
+
+import (
+	"encoding/json"
+	"errors"
+	"go/ast"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bazelbuild/rules_go/go/tools/bazel"
+	"github.com/stretchr/testify/assert"
+
+	"martianoff/gala/compiler"
+)
+
+// stdSearchPath returns the directory containing std, from Bazel runfiles or
+// by walking up to the module root.
+func stdSearchPath() []string {
+	if stdFilePath, err := bazel.Runfile("std/option.gala"); err == nil {
+		return []string{filepath.Dir(filepath.Dir(stdFilePath))}
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return []string{dir}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil
+		}
+		dir = parent
+	}
+}
+
+func TestCompile(t *testing.T) {
+	src := `package shapes
+
+type Point struct {
+    X int
+    Y int
+}
+
+func Origin() Point = Point(X = 0, Y = 0)
+`
+	goSrc, diags, meta := compiler.Compile(src, compiler.Options{FileName: "shapes.gala", SearchPaths: stdSearchPath()})
+	assert.Empty(t, diags)
+	assert.Contains(t, string(goSrc), "package shapes")
+	assert.Contains(t, string(goSrc), "func Origin() Point")
+
+	if assert.NotNil(t, meta) {
+		assert.Equal(t, "shapes", meta.Package)
+		if assert.Len(t, meta.Types, 1) {
+			assert.Equal(t, "Point", meta.Types[0].Name)
+		}
+		if assert.Len(t, meta.Functions, 1) {
+			assert.Equal(t, "Origin", meta.Functions[0].Name)
+		}
+	}
+}
+
+func TestCompileDiagnostics(t *testing.T) {
+	src := `package main
+
+func main() {
+    val count = 0
+    count = count + 1
+}
+`
+	goSrc, diags, _ := compiler.Compile(src, compiler.Options{FileName: "main.gala", SearchPaths: stdSearchPath()})
+	assert.Empty(t, goSrc)
+	assert.True(t, diags.HasErrors())
+	if assert.Len(t, diags, 1) {
+		d := diags[0]
+		assert.Equal(t, compiler.SeverityError, d.Severity)
+		assert.Equal(t, "E0001", d.Code)
+		assert.Equal(t, "main.gala", d.File)
+		assert.Equal(t, 5, d.Line)
+	}
+	assert.ErrorContains(t, diags.Err(), "E0001")
+}
+
+func TestCompileDiagnosticsPosition(t *testing.T) {
+	src := `package main
+
+func main() {
+    val xs = []int{1, 2}
+}
+`
+	_, diags, _ := compiler.Compile(src, compiler.Options{FileName: "main.gala", SearchPaths: stdSearchPath()})
+	if assert.Len(t, diags, 1) {
+		assert.Equal(t, "main.gala", diags[0].File)
+		assert.Equal(t, 4, diags[0].Line)
+		assert.Equal(t, 13, diags[0].Column)
+	}
+}
+
+func TestDiagnosticJSON(t *testing.T) {
+	d := compiler.Diagnostic{Severity: compiler.SeverityError, Kind: "SemanticError", File: "main.gala", Line: 4, Column: 13, Code: "E0001", Message: "cannot assign"}
+	data, err := json.Marshal(d)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"severity":"error","kind":"SemanticError","file":"main.gala","line":4,"column":13,"code":"E0001","message":"cannot assign"}`, string(data))
+
+	data, err = json.Marshal(compiler.Diagnostic{Severity: compiler.SeverityWarning, Message: "deprecated"})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"severity":"warning","message":"deprecated"}`, string(data))
+}
+
+func TestCompileWarnings(t *testing.T) {
+	src := `package main
+
+@deprecated("use hello")
+func hi() string = "hi"
+
+func main() {
+    println(hi())
+}
+`
+	goSrc, diags, _ := compiler.Compile(src, compiler.Options{FileName: "main.gala", SearchPaths: stdSearchPath()})
+	assert.NotEmpty(t, goSrc)
+	assert.False(t, diags.HasErrors())
+	assert.NoError(t, diags.Err())
+	if assert.Len(t, diags, 1) {
+		assert.Equal(t, compiler.SeverityWarning, diags[0].Severity)
+		assert.Equal(t, "[Warning] main.gala:7:12 function hi is deprecated: use hello", diags[0].String())
+	}
+}
+
+func TestCompileInvalidGoVersion(t *testing.T) {
+	_, diags, meta := compiler.Compile("package main", compiler.Options{GoVersion: "1.9"})
+	assert.True(t, diags.HasErrors())
+	assert.Nil(t, meta)
+}
+
+func TestProject(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	write("point.gala", "package geo\n\ntype Point struct {\n    X int\n}\n")
+	write("ops.gala", "package geo\n\nfunc Shift(p Point) Point = Point(X = p.X + 1)\n")
+	write("ops_test.gala", "package geo\n")
+
+	project, err := compiler.NewProject(dir, compiler.Options{SearchPaths: stdSearchPath()})
+	assert.NoError(t, err)
+
+	results, err := project.CompilePackage(dir)
+	assert.NoError(t, err)
+	if assert.Len(t, results, 2) {
+		assert.Equal(t, filepath.Join(dir, "ops.gala"), results[0].File)
+		assert.Empty(t, results[0].Diagnostics)
+		assert.Contains(t, string(results[0].Go), "func Shift(p Point) Point")
+	}
+
+	// Unchanged sources are served from the cache
+	again, err := project.CompileFile(filepath.Join(dir, "ops.gala"))
+	assert.NoError(t, err)
+	assert.Same(t, results[0], again)
+
+	// Editing a sibling recompiles the file
+	write("point.gala", "package geo\n\ntype Point struct {\n    X int\n    Y int\n}\n")
+	changed, err := project.CompileFile(filepath.Join(dir, "ops.gala"))
+	assert.NoError(t, err)
+	assert.NotSame(t, results[0], changed)
+
+	project.Invalidate()
+	fresh, err := project.CompileFile(filepath.Join(dir, "ops.gala"))
+	assert.NoError(t, err)
+	assert.NotSame(t, changed, fresh)
+	assert.Equal(t, changed.Go, fresh.Go)
+}
+
+func TestNewProjectRejectsFiles(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "main.gala")
+	assert.NoError(t, os.WriteFile(file, []byte("package main\n"), 0644))
+	_, err := compiler.NewProject(file, compiler.Options{})
+	assert.Error(t, err)
+}
+
+func TestProjectPasses(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "user.gala")
+	assert.NoError(t, os.WriteFile(file, []byte("package users\n\ntype User struct {\n    Name string\n    Age int\n}\n"), 0644))
+
+	schema := compiler.Pass{
+		Name: "schema",
+		Analyzed: func(ctx *compiler.PassContext) error {
+			for _, typ := range ctx.Metadata().Types {
+				var columns []string
+				for _, f := range typ.Fields {
+					columns = append(columns, f.Name)
+				}
+				ctx.EmitFile(strings.ToLower(typ.Name)+".sql", "CREATE TABLE "+typ.Name+" ("+strings.Join(columns, ", ")+");")
+			}
+			return nil
+		},
+	}
+	marker := compiler.Pass{
+		Name: "marker",
+		Generated: func(ctx *compiler.PassContext) error {
+			ctx.File.Decls = append(ctx.File.Decls, &ast.FuncDecl{
+				Name: ast.NewIdent("addedByPass"),
+				Type: &ast.FuncType{Params: &ast.FieldList{}},
+				Body: &ast.BlockStmt{},
+			})
+			return nil
+		},
+	}
+	project, err := compiler.NewProject(dir, compiler.Options{
+		SearchPaths: stdSearchPath(),
+		Passes:      []compiler.Pass{schema, marker},
+	})
+	assert.NoError(t, err)
+	result, err := project.CompileFile(file)
+	assert.NoError(t, err)
+	assert.Empty(t, result.Diagnostics)
+	assert.Contains(t, string(result.Go), "func addedByPass()")
+	assert.Equal(t, map[string]string{"user.sql": "CREATE TABLE User (Name, Age);"}, result.Companions)
+
+	failing := compiler.Pass{
+		Name:     "lint",
+		Analyzed: func(ctx *compiler.PassContext) error { return errors.New("exported struct without doc comment") },
+	}
+	_, diags, _ := compiler.Compile("package users\n\ntype User struct {\n    Name string\n}\n", compiler.Options{
+		SearchPaths: stdSearchPath(),
+		Passes:      []compiler.Pass{failing},
+	})
+	if assert.Len(t, diags, 1) {
+		assert.Equal(t, "pass lint: exported struct without doc comment", diags[0].Message)
+	}
+}
@@ -0,0 +1,177 @@
+package compiler
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"martianoff/gala/internal/transpiler/analyzer"
+	"martianoff/gala/internal/transpiler/buildtags"
+)
+
+// Result is the outcome of compiling one file of a Project.
+type Result struct {
+	File        string // absolute path of the .gala file
+	Go          GoSource
+	Diagnostics Diagnostics
+	Metadata    *Metadata
+	Companions  map[string]string // files emitted by Options.Passes, keyed by name
+}
+
+// Project compiles the .gala files under a root directory and caches the
+// results. A file is recompiled only when it or another file of its package
+// changes; call Invalidate after changing imported packages or search paths
+// on disk. Imported packages are analyzed once for all files. A Project is
+// safe for concurrent use.
+type Project struct {
+	root string
+	opts Options
+
+	mu       sync.Mutex
+	cache    map[string]cachedResult
+	packages *analyzer.PackageCache
+}
+
+type cachedResult struct {
+	fingerprint [sha256.Size]byte
+	result      *Result
+}
+
+// NewProject creates a Project for the module rooted at root. The root is
+// searched for imports before opts.SearchPaths; opts.FileName and
+// opts.PackageFiles are ignored since every file names its own package.
+func NewProject(root string, opts Options) (*Project, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(abs); err != nil {
+		return nil, err
+	} else if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", root)
+	}
+	if _, err := parseGoVersion(opts.GoVersion); err != nil {
+		return nil, err
+	}
+	opts.SearchPaths = append([]string{abs}, opts.SearchPaths...)
+	opts.FileName, opts.PackageFiles = "", nil
+	return &Project{
+		root:     abs,
+		opts:     opts,
+		cache:    make(map[string]cachedResult),
+		packages: analyzer.NewPackageCache(),
+	}, nil
+}
+
+// Root returns the absolute root directory of the project.
+func (p *Project) Root() string {
+	return p.root
+}
+
+// CompileFile compiles the .gala file at path together with the other files
+// of its package. The error reports only files that could not be read;
+// compilation problems are in the result's diagnostics.
+func (p *Project) CompileFile(path string) (*Result, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	pkgFiles, err := PackageFiles(filepath.Dir(abs))
+	if err != nil {
+		return nil, err
+	}
+	src, err := os.ReadFile(abs)
+	if err != nil {
+		return nil, err
+	}
+
+	var siblings []string
+	h := sha256.New()
+	h.Write(src)
+	for _, f := range pkgFiles {
+		if f == abs {
+			continue
+		}
+		content, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		siblings = append(siblings, f)
+		fmt.Fprintf(h, "\x00%s\x00%d\x00", f, len(content))
+		h.Write(content)
+	}
+	var fingerprint [sha256.Size]byte
+	copy(fingerprint[:], h.Sum(nil))
+
+	p.mu.Lock()
+	cached, ok := p.cache[abs]
+	packages := p.packages
+	p.mu.Unlock()
+	if ok && cached.fingerprint == fingerprint {
+		return cached.result, nil
+	}
+
+	opts := p.opts
+	opts.FileName, opts.PackageFiles = abs, siblings
+	goSrc, diags, meta, companions := compile(string(src), opts, packages)
+	result := &Result{File: abs, Go: goSrc, Diagnostics: diags, Metadata: meta, Companions: companions}
+
+	p.mu.Lock()
+	p.cache[abs] = cachedResult{fingerprint: fingerprint, result: result}
+	p.mu.Unlock()
+	return result, nil
+}
+
+// CompilePackage compiles every non-test .gala file in dir, in name order.
+func (p *Project) CompilePackage(dir string) ([]*Result, error) {
+	files, err := PackageFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]*Result, 0, len(files))
+	for _, f := range files {
+		r, err := p.CompileFile(f)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+// Invalidate drops every cached result and analyzed import.
+func (p *Project) Invalidate() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cache = make(map[string]cachedResult)
+	p.packages = analyzer.NewPackageCache()
+}
+
+// PackageFiles returns the absolute paths of the non-test .gala files in dir
+// that are built for the current GOOS, GOARCH and build tags, sorted by name.
+func PackageFiles(dir string) ([]string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(abs)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".gala") || strings.HasSuffix(name, "_test.gala") {
+			continue
+		}
+		if path := filepath.Join(abs, name); buildtags.Default().MatchFile(path) {
+			files = append(files, path)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
@@ -0,0 +1,103 @@
+package concurrent
+
+import "sync"
+
+// Mailbox is an unbounded FIFO queue of messages with a single consumer, the
+// goroutine started by Serve. It is the mailbox of an Actor. Put never blocks,
+// so actors can message each other, and themselves, without deadlocking.
+type Mailbox[T any] struct {
+	mu     sync.Mutex
+	ready  *sync.Cond
+	queue  []T
+	closed bool
+}
+
+// NewMailbox creates an empty, open Mailbox.
+func NewMailbox[T any]() *Mailbox[T] {
+	m := &Mailbox[T]{}
+	m.ready = sync.NewCond(&m.mu)
+	return m
+}
+
+// Put appends msg to the mailbox. It returns false, dropping msg, if the
+// mailbox is closed.
+func (m *Mailbox[T]) Put(msg T) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return false
+	}
+	m.queue = append(m.queue, msg)
+	m.ready.Signal()
+	return true
+}
+
+// Close closes the mailbox. Messages put before are still served.
+func (m *Mailbox[T]) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = true
+	m.ready.Signal()
+}
+
+// Abort closes the mailbox and returns the messages that have not been served
+// yet, which will not be.
+func (m *Mailbox[T]) Abort() []T {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	pending := m.queue
+	m.queue = nil
+	m.closed = true
+	m.ready.Signal()
+	return pending
+}
+
+// IsClosed reports whether the mailbox has been closed.
+func (m *Mailbox[T]) IsClosed() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.closed
+}
+
+// Len returns the number of messages waiting to be served.
+func (m *Mailbox[T]) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.queue)
+}
+
+// Serve starts the goroutine that calls handle with every message, one at a
+// time and in order, until the mailbox is closed and empty; it then calls done.
+// Serve must be called once.
+// Accepts func(T) any and func() any to be compatible with GALA's lambda generation.
+func (m *Mailbox[T]) Serve(handle func(T) any, done func() any) {
+	go func() {
+		defer done()
+		for {
+			msg, ok := m.take()
+			if !ok {
+				return
+			}
+			handle(msg)
+		}
+	}()
+}
+
+// take waits for the next message. It returns false once the mailbox is
+// closed and empty.
+func (m *Mailbox[T]) take() (T, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for len(m.queue) == 0 && !m.closed {
+		m.ready.Wait()
+	}
+	if len(m.queue) == 0 {
+		var zero T
+		return zero, false
+	}
+	msg := m.queue[0]
+	var zero T
+	m.queue[0] = zero
+	m.queue = m.queue[1:]
+	return msg, true
+}
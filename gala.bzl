@@ -1,4 +1,4 @@
-load("@rules_go//go:def.bzl", "go_binary", "go_library")
+load("@rules_go//go:def.bzl", "go_binary", "go_library", "go_test")
 
 def _gala_test_impl(ctx):
     binary = ctx.executable.binary
@@ -279,13 +279,16 @@ def gala_test(name, src = None, srcs = None, expected = "", deps = [], **kwargs)
     )
 
 def _gala_go_test_gen_impl(ctx):
-    """Generate a main.go file that runs all Test* functions."""
-    out = ctx.actions.declare_file(ctx.label.name + "_main.go")
+    """Generate a main.go file that runs all Test* functions, or with fuzz a _test.go file of Go fuzz targets."""
+    suffix = "_fuzz_test.go" if ctx.attr.fuzz else "_main.go"
+    out = ctx.actions.declare_file(ctx.label.name + suffix)
 
     # Build the command to scan test files and generate main
     args = ctx.actions.args()
     args.add("-output", out)
     args.add("-package", ctx.attr.pkg)
+    if ctx.attr.fuzz:
+        args.add("-fuzz")
     args.add_all(ctx.files.srcs)
 
     ctx.actions.run(
@@ -310,6 +313,10 @@ gala_go_test_gen = rule(
             default = "main",
             doc = "Package name for the generated main file",
         ),
+        "fuzz": attr.bool(
+            default = False,
+            doc = "Generate Go fuzz targets for the Fuzz* functions instead of a main file",
+        ),
         "_test_gen": attr.label(
             default = "//cmd/gala_test_gen",
             executable = True,
@@ -326,6 +333,9 @@ def gala_go_test(name, srcs, deps = [], pkg = "main", embed = [], **kwargs):
     - Start with "Test" prefix (e.g., TestAddition)
     - Take a single parameter of type T (e.g., func TestXxx(t T) T)
 
+    Methods of that signature on structs declared in srcs are tests too, run on
+    the struct's zero value. Tests annotated with @skip are reported as skipped.
+
     For external tests (pkg="main"):
     - Use package main and import the packages being tested
 
@@ -338,7 +348,8 @@ def gala_go_test(name, srcs, deps = [], pkg = "main", embed = [], **kwargs):
 
     Args:
         name: The name of the test target.
-        srcs: List of test source files (e.g., ["foo_test.gala"]).
+        srcs: List of test source files (e.g., ["foo_test.gala"]), which may
+            be in nested directories (e.g., glob(["**/*_test.gala"])).
         deps: Dependencies for the test.
         pkg: Package name for tests (default "main" for external tests).
         embed: Go source files to embed (for internal tests in same package).
@@ -397,3 +408,60 @@ def gala_go_test(name, srcs, deps = [], pkg = "main", embed = [], **kwargs):
             "//conditions:default": False,
         }),
     )
+
+def gala_fuzz_test(name, srcs, deps = [], pkg = "main", embed = [], **kwargs):
+    """
+    Creates a Go test of the GALA fuzz tests in srcs.
+
+    Fuzz test functions must:
+    - Start with "Fuzz" prefix (e.g., FuzzParse)
+    - Take a T, then the fuzzed values, and return T
+      (e.g., func FuzzParse(t T, data string) T)
+
+    The fuzzed values may be string, []byte, bool, rune, byte, or any integer
+    or float type. Each function becomes the Go fuzz target of the same name
+    with a "Go" suffix (e.g., FuzzParseGo). `bazel test` runs the targets on
+    their seed corpus in testdata/fuzz; the generated _test.go file and the
+    transpiled sources also fuzz with `go test -fuzz=FuzzParseGo`.
+
+    Args:
+        name: The name of the test target.
+        srcs: List of test source files (e.g., ["parse_fuzz_test.gala"]).
+        deps: Dependencies for the test.
+        pkg: Package name for tests (default "main" for external tests).
+        embed: Go source files to embed (for internal tests in same package).
+        **kwargs: Additional arguments passed to go_test.
+    """
+    gen_name = name + "_gen"
+    gala_go_test_gen(
+        name = gen_name,
+        srcs = srcs,
+        pkg = pkg,
+        fuzz = True,
+    )
+
+    transpiled_srcs = []
+    for i, src in enumerate(srcs):
+        transpile_name = name + "_transpile_" + str(i)
+        go_src = name + "_fuzz_" + str(i) + ".go"
+        siblings = [other for j, other in enumerate(srcs) if j != i]
+        gala_transpile(
+            name = transpile_name,
+            src = src,
+            out = go_src,
+            package_files = siblings,
+        )
+        transpiled_srcs.append(go_src)
+
+    final_deps = list(deps)
+    if pkg != "test":
+        final_deps.append("//test")
+    if pkg != "std":
+        final_deps.append("//std")
+
+    go_test(
+        name = name,
+        srcs = transpiled_srcs + [":" + gen_name] + embed,
+        deps = final_deps,
+        **kwargs
+    )
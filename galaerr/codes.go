@@ -0,0 +1,380 @@
+package galaerr
+
+import "sort"
+
+// Code identifies a class of GALA diagnostics. Codes are stable across releases
+// so they can be searched for and explained with `gala explain <code>`.
+type Code string
+
+const (
+	CodeImmutableAssign    Code = "E0001"
+	CodeUntypedNone        Code = "E0002"
+	CodeMatchNoDefault     Code = "E0003"
+	CodeMatchSubjectType   Code = "E0004"
+	CodeMatchResultType    Code = "E0005"
+	CodeNestedImmutable    Code = "E0006"
+	CodeDotImportClash     Code = "E0007"
+	CodeGoVersion          Code = "E0008"
+	CodeLambdaTypeInfer    Code = "E0009"
+	CodeUnsupportedLiteral Code = "E0010"
+	CodeBadAnnotation      Code = "E0011"
+	CodeNotTailRecursive   Code = "E0012"
+	CodeVisibility         Code = "E0013"
+	CodeImportCycle        Code = "E0014"
+	CodeNewtypeMismatch    Code = "E0015"
+	CodeSelectiveImport    Code = "E0016"
+	CodeExtractorShape     Code = "E0017"
+	CodeInitCycle          Code = "E0018"
+	CodeNameCollision      Code = "E0019"
+	CodeAmbiguousName      Code = "E0020"
+	CodeConstructorArgs    Code = "E0021"
+)
+
+// Explanation is the long-form documentation of an error code.
+type Explanation struct {
+	Code    Code
+	Title   string
+	Details string
+	Example string // minimal GALA program that triggers the error
+	Fix     string // the same program, corrected
+}
+
+var explanations = map[Code]Explanation{
+	CodeImmutableAssign: {
+		Code:  CodeImmutableAssign,
+		Title: "assignment to an immutable value",
+		Details: `Variables declared with val, function parameters and struct fields without
+the var modifier are immutable. They cannot be reassigned, incremented or
+written through a ConstPtr. This includes package-level vals inside init
+blocks, which run after the vals are initialized.`,
+		Example: `func main() {
+    val count = 0
+    count = count + 1
+}`,
+		Fix: `func main() {
+    var count = 0
+    count = count + 1
+}`,
+	},
+	CodeUntypedNone: {
+		Code:  CodeUntypedNone,
+		Title: "None() without an element type",
+		Details: `None() carries no value, so the element type of the Option cannot be inferred
+from it. Declare the variable type or pass the type argument explicitly.`,
+		Example: `val missing = None()`,
+		Fix: `val missing Option[int] = None()
+val other = None[int]()`,
+	},
+	CodeMatchNoDefault: {
+		Code:  CodeMatchNoDefault,
+		Title: "match expression without a default case",
+		Details: `A match over a value that is not a sealed type must end with a default case
+(case _ => ...) so that every input produces a result.`,
+		Example: `val name = n match {
+    case 1 => "one"
+    case 2 => "two"
+}`,
+		Fix: `val name = n match {
+    case 1 => "one"
+    case 2 => "two"
+    case _ => "many"
+}`,
+	},
+	CodeMatchSubjectType: {
+		Code:  CodeMatchSubjectType,
+		Title: "type of matched expression cannot be inferred",
+		Details: `Patterns are checked against the static type of the matched expression. When
+that type is unknown, for example because the value comes from an untyped
+lambda parameter, annotate it.`,
+		Example: `val f = (x) => x match {
+    case Some(v) => v
+    case _ => 0
+}`,
+		Fix: `val f = (x Option[int]) => x match {
+    case Some(v) => v
+    case _ => 0
+}`,
+	},
+	CodeMatchResultType: {
+		Code:  CodeMatchResultType,
+		Title: "result type of match expression cannot be inferred",
+		Details: `The result type of a match expression is taken from its branches. Branches
+that return different types, or that only panic, leave it undetermined.
+Annotate the variable that receives the result.`,
+		Example: `val r = x match {
+    case 1 => "one"
+    case _ => panic("unexpected")
+}`,
+		Fix: `val r string = x match {
+    case 1 => "one"
+    case _ => panic("unexpected")
+}`,
+	},
+	CodeNestedImmutable: {
+		Code:  CodeNestedImmutable,
+		Title: "recursive Immutable wrapping",
+		Details: `val declarations and immutable struct fields are already wrapped in
+Immutable[T]. Declaring them with an Immutable type would wrap the value twice.`,
+		Example: `val x Immutable[int] = NewImmutable(1)`,
+		Fix:     `val x int = 1`,
+	},
+	CodeDotImportClash: {
+		Code:  CodeDotImportClash,
+		Title: "dot-imported packages export the same symbol",
+		Details: `Two packages imported with "." export a symbol with the same name, which Go
+rejects as a redeclaration. Import one of them under a name instead.`,
+		Example: `import (
+    . "example.com/a"
+    . "example.com/b"
+)`,
+		Fix: `import (
+    . "example.com/a"
+    b "example.com/b"
+)`,
+	},
+	CodeGoVersion: {
+		Code:  CodeGoVersion,
+		Title: "construct not available for the target Go version",
+		Details: `The --go flag selects the Go release generated code must compile with. Some
+constructs, such as ranging over iterator functions (Go 1.23), cannot be
+lowered for older releases.`,
+		Example: `// gala build --go 1.22
+for x := range seq {
+    println(x)
+}`,
+		Fix: `// gala build --go 1.23
+for x := range seq {
+    println(x)
+}`,
+	},
+	CodeLambdaTypeInfer: {
+		Code:  CodeLambdaTypeInfer,
+		Title: "method type argument cannot be inferred from a lambda",
+		Details: `Generic methods such as Map[U] infer U from the type the lambda returns. When
+the lambda body's type is not known (for example it calls a function of
+another package whose result type the compiler cannot see), inference falls
+back to any and the call yields Option[any]. Using that value where a concrete
+type such as Option[string] is expected is reported here rather than as a Go
+type error in the generated code. Pass the type argument explicitly.`,
+		Example: `val name Option[string] = id.Map((i) => users.Lookup(i))`,
+		Fix:     `val name Option[string] = id.Map[string]((i) => users.Lookup(i))`,
+	},
+	CodeUnsupportedLiteral: {
+		Code:  CodeUnsupportedLiteral,
+		Title: "Go slice or map literal",
+		Details: `GALA does not support Go slice and map literals. Use the immutable or mutable
+collection types, or the go_interop helpers when a Go slice or map is needed.`,
+		Example: `val xs = []int{1, 2, 3}`,
+		Fix:     `val xs = ArrayOf(1, 2, 3)`,
+	},
+	CodeBadAnnotation: {
+		Code:  CodeBadAnnotation,
+		Title: "unknown or misplaced annotation",
+		Details: `The compiler understands @inline and @tailrec on top-level functions,
+@noCopy on struct types and @deprecated("reason") on functions, methods and
+types. Any other name, a repeated annotation or an annotation on the wrong
+kind of declaration is rejected.`,
+		Example: `@inline
+type Point struct {
+    X int
+    Y int
+}`,
+		Fix: `@noCopy
+type Point struct {
+    X int
+    Y int
+}`,
+	},
+	CodeNotTailRecursive: {
+		Code:  CodeNotTailRecursive,
+		Title: "@tailrec function calls itself outside tail position",
+		Details: `A @tailrec function is compiled into a loop, which is only possible when
+every recursive call is the last thing the function does. Move pending work
+into an accumulator parameter.`,
+		Example: `@tailrec
+func sum(n int) int = if (n == 0) 0 else n + sum(n - 1)`,
+		Fix: `@tailrec
+func sum(n int, acc int) int = if (n == 0) acc else sum(n - 1, acc + n)`,
+	},
+	CodeVisibility: {
+		Code:  CodeVisibility,
+		Title: "reference to a private or internal declaration",
+		Details: `A private declaration can only be used inside its own package, and its name
+must start with a lowercase letter. An internal declaration can be used by
+every package of its module but not by packages importing it from another
+module; its name must start with an uppercase letter.`,
+		Example: `import "example.com/geometry"
+
+val p = geometry.origin`,
+		Fix: `import "example.com/geometry"
+
+val p = geometry.Origin`,
+	},
+	CodeImportCycle: {
+		Code:  CodeImportCycle,
+		Title: "import cycle",
+		Details: `GALA packages cannot import each other directly or through other packages,
+just like Go packages. The error lists the chain of imports that leads back to
+the first package. Move the shared declarations into a package that both sides
+import, or let one side depend on an interface instead.`,
+		Example: `// shapes/shapes.gala
+package shapes
+
+import "example.com/app/render"
+
+// render/render.gala
+package render
+
+import "example.com/app/shapes"`,
+		Fix: `// shapes/shapes.gala
+package shapes
+
+// render/render.gala
+package render
+
+import "example.com/app/shapes"`,
+	},
+	CodeNewtypeMismatch: {
+		Code:  CodeNewtypeMismatch,
+		Title: "newtype used where another type is expected",
+		Details: `A newtype is a distinct type even though it shares the representation of its
+underlying type, so two newtypes over string cannot be passed for each other,
+nor can a plain string be passed for either. Convert explicitly with the From
+function generated for the newtype, and back with its Value method. Literals
+convert implicitly.`,
+		Example: `newtype UserId = string
+newtype OrderId = string
+
+func cancel(id OrderId) string = "cancelled " + id.Value()
+
+val user = UserIdFrom("u-17")
+val msg = cancel(user)`,
+		Fix: `newtype UserId = string
+newtype OrderId = string
+
+func cancel(id OrderId) string = "cancelled " + id.Value()
+
+val order = OrderIdFrom("o-42")
+val msg = cancel(order)`,
+	},
+	CodeSelectiveImport: {
+		Code:  CodeSelectiveImport,
+		Title: "name not brought into scope by a selective import",
+		Details: `A selective import, import "pkg".{A, B => C}, makes only the listed symbols
+usable without the package qualifier, C being B under another name. Other
+symbols of the package are still available as pkg.Name. A listed name cannot be
+listed twice, declared again in the importing package or used for a local
+variable or parameter, and a dot import already imports every symbol so it
+cannot list any.`,
+		Example: `import "example.com/geometry".{Point}
+
+val zero = Origin()`,
+		Fix: `import "example.com/geometry".{Point, Origin}
+
+val zero = Origin()`,
+	},
+	CodeExtractorShape: {
+		Code:  CodeExtractorShape,
+		Title: "Unapply extracts a different number of values than Apply takes",
+		Details: `A type with both an Apply and an Unapply method works as a constructor and as
+an extractor: Pair(1, 2) calls Apply and case Pair(a, b) calls Unapply. The
+pattern mirrors the construction, so Unapply must give back as many values as
+Apply takes: one value in an Option for one parameter, and a tuple of n values
+in an Option for n parameters. The error shows both signatures.`,
+		Example: `struct Box(A int, B int)
+
+type Pair struct {}
+func (p Pair) Apply(a int, b int) Box = Box(a, b)
+func (p Pair) Unapply(b Box) Option[int] = Some(b.A + b.B)`,
+		Fix: `struct Box(A int, B int)
+
+type Pair struct {}
+func (p Pair) Apply(a int, b int) Box = Box(a, b)
+func (p Pair) Unapply(b Box) Option[Tuple[int, int]] = Some((b.A, b.B))`,
+	},
+	CodeInitCycle: {
+		Code:  CodeInitCycle,
+		Title: "package-level val initialized from itself",
+		Details: `Package-level vals and vars are initialized after the ones their initializers
+use, directly or through the functions they call, whatever their order in the
+file. A val whose initializer leads back to the val itself has no such order.
+The error lists the chain of vals and functions that closes the cycle. Pass
+the value as a parameter or compute it in an init block instead.`,
+		Example: `val limit = defaultLimit()
+
+func defaultLimit() int = limit * 2`,
+		Fix: `val limit = defaultLimit(10)
+
+func defaultLimit(base int) int = base * 2`,
+	},
+	CodeNameCollision: {
+		Code:  CodeNameCollision,
+		Title: "name collides with a generated one",
+		Details: `The transpiler declares members of its own on the types it generates: Copy
+and Equal on structs and sealed types, String and an isX method per case on
+sealed types, and the _variant field of sealed types, as well as a function
+listing the cases of each sealed type, such as ShapeVariants. A method, field
+or function of the same name declared in GALA would make the Go declaration
+ambiguous. Rename it, or annotate the type with @noCopy to leave Copy out. A
+ShapeVariants function written before the transpiler generated one can be
+deleted if it listed the cases the same way. GALA names that are Go
+keywords, such as default or select, are written with a trailing underscore in
+Go, so default_ cannot be declared in the same file as default.`,
+		Example: `struct Point(X int, Y int)
+
+func (p Point) Equal(other Point) bool = p.X == other.X`,
+		Fix: `struct Point(X int, Y int)
+
+func (p Point) SameX(other Point) bool = p.X == other.X`,
+	},
+	CodeAmbiguousName: {
+		Code:  CodeAmbiguousName,
+		Title: "name declared by several imported packages",
+		Details: `An unqualified name that is neither declared in the package nor in std is
+looked up in the imported packages. When more than one of them declares it, the
+transpiler cannot tell which is meant. Qualify the name with its package.`,
+		Example: `import (
+    "example.com/shapes"
+    "example.com/geometry"
+)
+
+func area(p Point) float64 = p.X * p.Y`,
+		Fix: `import (
+    "example.com/shapes"
+    "example.com/geometry"
+)
+
+func area(p shapes.Point) float64 = p.X * p.Y`,
+	},
+	CodeConstructorArgs: {
+		Code:  CodeConstructorArgs,
+		Title: "arguments do not match the fields of a struct",
+		Details: `A struct built with positional arguments takes one per field, in the order
+the fields are declared. To leave fields at their zero value, name the fields
+that are set; positional arguments may come first and set the leading fields.
+An argument naming no field, or a field already set by position, is an error
+too.`,
+		Example: `struct Point(X int, Y int, Z int)
+
+val p = Point(1, 2)`,
+		Fix: `struct Point(X int, Y int, Z int)
+
+val p = Point(1, 2, Z = 0)`,
+	},
+}
+
+// Explain returns the explanation for code.
+func Explain(code Code) (Explanation, bool) {
+	e, ok := explanations[code]
+	return e, ok
+}
+
+// Codes returns all documented error codes in ascending order.
+func Codes() []Code {
+	codes := make([]Code, 0, len(explanations))
+	for c := range explanations {
+		codes = append(codes, c)
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i] < codes[j] })
+	return codes
+}
@@ -1,6 +1,7 @@
 package galaerr
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 )
@@ -23,16 +24,25 @@ type GalaError interface {
 type BaseError struct {
 	Msg     string
 	ErrType ErrorType
+	Code    Code // optional; see `gala explain`
 }
 
 func (e *BaseError) Error() string {
-	return fmt.Sprintf("[%s] %s", e.ErrType, e.Msg)
+	return fmt.Sprintf("[%s] %s", e.label(), e.Msg)
 }
 
 func (e *BaseError) Type() ErrorType {
 	return e.ErrType
 }
 
+// label returns the bracketed prefix of the error message, e.g. "SemanticError E0001".
+func (e *BaseError) label() string {
+	if e.Code == "" {
+		return string(e.ErrType)
+	}
+	return fmt.Sprintf("%s %s", e.ErrType, e.Code)
+}
+
 // SyntaxError represents an error during the parsing phase.
 type SyntaxError struct {
 	BaseError
@@ -41,7 +51,7 @@ type SyntaxError struct {
 }
 
 func (e *SyntaxError) Error() string {
-	return fmt.Sprintf("[%s] line %d:%d %s", e.ErrType, e.Line, e.Column, e.Msg)
+	return fmt.Sprintf("[%s] line %d:%d %s", e.label(), e.Line, e.Column, e.Msg)
 }
 
 // SemanticError represents an error during the transformation/transpilation phase.
@@ -55,11 +65,48 @@ type SemanticError struct {
 func (e *SemanticError) Error() string {
 	if e.Line > 0 {
 		if e.FilePath != "" {
-			return fmt.Sprintf("[%s] %s:%d:%d %s", e.ErrType, e.FilePath, e.Line, e.Column, e.Msg)
+			return fmt.Sprintf("[%s] %s:%d:%d %s", e.label(), e.FilePath, e.Line, e.Column, e.Msg)
 		}
-		return fmt.Sprintf("[%s] line %d:%d %s", e.ErrType, e.Line, e.Column, e.Msg)
+		return fmt.Sprintf("[%s] line %d:%d %s", e.label(), e.Line, e.Column, e.Msg)
+	}
+	return fmt.Sprintf("[%s] %s", e.label(), e.Msg)
+}
+
+// WithCode tags the error with a diagnostic code and returns it.
+func (e *SemanticError) WithCode(code Code) *SemanticError {
+	e.Code = code
+	return e
+}
+
+// Locate fills in the position of err, if it is a SemanticError, with
+// filePath, line and column where it has none and returns err. A position
+// that is already set is kept, so the innermost caller that knows one wins;
+// a line of 0 sets the file only.
+func Locate(err error, filePath string, line, column int) error {
+	semErr, ok := err.(*SemanticError)
+	if !ok {
+		return err
+	}
+	if semErr.Line == 0 && line > 0 {
+		semErr.Line, semErr.Column = line, column
+	}
+	if semErr.FilePath == "" && semErr.Line > 0 {
+		semErr.FilePath = filePath
+	}
+	return err
+}
+
+// CodeOf returns the diagnostic code carried by err, or "" if it has none.
+func CodeOf(err error) Code {
+	var semErr *SemanticError
+	if errors.As(err, &semErr) {
+		return semErr.Code
+	}
+	var synErr *SyntaxError
+	if errors.As(err, &synErr) {
+		return synErr.Code
 	}
-	return fmt.Sprintf("[%s] %s", e.ErrType, e.Msg)
+	return ""
 }
 
 // MultiError collects multiple GALA errors.
@@ -1,6 +1,7 @@
 package galaerr_test
 
 import (
+	"fmt"
 	"martianoff/gala/galaerr"
 	"strings"
 	"testing"
@@ -46,6 +47,25 @@ func TestSemanticErrorNoPosition(t *testing.T) {
 	assert.Equal(t, "[SemanticError] undefined variable x", err.Error())
 }
 
+func TestLocate(t *testing.T) {
+	err := galaerr.Locate(galaerr.NewSemanticError("undefined variable x"), "main.gala", 10, 5)
+	assert.Equal(t, "[SemanticError] main.gala:10:5 undefined variable x", err.Error())
+
+	// The innermost position is kept
+	err = galaerr.Locate(err, "other.gala", 2, 1)
+	assert.Equal(t, "[SemanticError] main.gala:10:5 undefined variable x", err.Error())
+
+	err = galaerr.Locate(galaerr.NewSemanticErrorAt(3, 4, "undefined variable y"), "main.gala", 0, 0)
+	assert.Equal(t, "[SemanticError] main.gala:3:4 undefined variable y", err.Error())
+
+	err = galaerr.Locate(galaerr.NewSemanticError("no position"), "main.gala", 0, 0)
+	assert.Equal(t, "[SemanticError] no position", err.Error())
+
+	wrapped := fmt.Errorf("in package util: %w", galaerr.NewSemanticError("undefined variable z"))
+	assert.Equal(t, wrapped, galaerr.Locate(wrapped, "main.gala", 1, 1))
+	assert.Nil(t, galaerr.Locate(nil, "main.gala", 1, 1))
+}
+
 func TestMultiError(t *testing.T) {
 	e1 := galaerr.NewSyntaxError(1, 1, "error 1")
 	e2 := galaerr.NewSyntaxError(2, 2, "error 2")
@@ -71,3 +91,33 @@ func TestMultiErrorEmpty(t *testing.T) {
 	assert.Equal(t, galaerr.ErrorType("MultiError"), multi.Type())
 	assert.True(t, strings.HasPrefix(multi.Error(), "0 error(s) occurred:"))
 }
+
+func TestErrorCode(t *testing.T) {
+	err := galaerr.NewSemanticErrorInFile("main.gala", 3, 4, "cannot assign to immutable variable x").WithCode(galaerr.CodeImmutableAssign)
+	assert.Equal(t, "[SemanticError E0001] main.gala:3:4 cannot assign to immutable variable x", err.Error())
+	assert.Equal(t, galaerr.CodeImmutableAssign, galaerr.CodeOf(fmt.Errorf("wrapped: %w", err)))
+	assert.Equal(t, galaerr.Code(""), galaerr.CodeOf(galaerr.NewSemanticError("no code")))
+}
+
+func TestExplain(t *testing.T) {
+	codes := galaerr.Codes()
+	assert.NotEmpty(t, codes)
+	for _, code := range codes {
+		e, ok := galaerr.Explain(code)
+		assert.True(t, ok)
+		assert.Equal(t, code, e.Code)
+		assert.NotEmpty(t, e.Title)
+		assert.NotEmpty(t, e.Example)
+		assert.NotEmpty(t, e.Fix)
+	}
+
+	_, ok := galaerr.Explain("E9999")
+	assert.False(t, ok)
+}
+
+func TestWarningString(t *testing.T) {
+	w := galaerr.Warning{FilePath: "main.gala", Line: 7, Column: 2, Msg: "Old is deprecated: use New"}
+	assert.Equal(t, "[Warning] main.gala:7:2 Old is deprecated: use New", w.String())
+	assert.Equal(t, "[Warning] line 7:2 x", galaerr.Warning{Line: 7, Column: 2, Msg: "x"}.String())
+	assert.Equal(t, "[Warning] x", galaerr.Warning{Msg: "x"}.String())
+}
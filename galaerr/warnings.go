@@ -0,0 +1,22 @@
+package galaerr
+
+import "fmt"
+
+// Warning is a non-fatal diagnostic, such as the use of a deprecated declaration.
+// Warnings never stop transpilation.
+type Warning struct {
+	FilePath string
+	Line     int
+	Column   int
+	Msg      string
+}
+
+func (w Warning) String() string {
+	switch {
+	case w.Line > 0 && w.FilePath != "":
+		return fmt.Sprintf("[Warning] %s:%d:%d %s", w.FilePath, w.Line, w.Column, w.Msg)
+	case w.Line > 0:
+		return fmt.Sprintf("[Warning] line %d:%d %s", w.Line, w.Column, w.Msg)
+	}
+	return fmt.Sprintf("[Warning] %s", w.Msg)
+}
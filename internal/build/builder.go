@@ -1,17 +1,22 @@
 package build
 
 import (
+	"bufio"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"martianoff/gala/internal/depman/mod"
+	"martianoff/gala/internal/pgo"
 	"martianoff/gala/internal/stdlib"
 	"martianoff/gala/internal/transpiler"
 	"martianoff/gala/internal/transpiler/analyzer"
+	"martianoff/gala/internal/transpiler/buildtags"
 	"martianoff/gala/internal/transpiler/generator"
+	"martianoff/gala/internal/transpiler/module"
 	"martianoff/gala/internal/transpiler/transformer"
 )
 
@@ -22,9 +27,20 @@ type Builder struct {
 	galaMod        *mod.File
 	stdlibVersion  string
 	verbose        bool
-	transpiledDeps map[string]string // modulePath -> transpiled directory
+	transpiledDeps map[string]string    // modulePath -> transpiled directory
+	goVersion      transpiler.GoVersion // target Go release for generated code
+	profilePath    string               // CPU profile for a profile-guided build, "" for none
+	hotFuncs       map[string]bool      // functions the profile found hot, as pkg.name
+	defines        []string             // features enabled on the command line (-D)
+	features       []string             // features enabled for the build: gala.toml and defines
+	optimize       bool                 // fuse Array combinator chains, hold large fields by reference (-O)
+	race           bool                 // build with the race detector (--race)
 }
 
+// hotFunctionShare is the share of a profile's samples a function needs to be
+// inlined by the transpiler in a profile-guided build.
+const hotFunctionShare = 0.01
+
 // NewBuilder creates a new builder for the given project directory.
 func NewBuilder(projectDir string, stdlibVersion string, verbose bool) (*Builder, error) {
 	config := DefaultConfig()
@@ -56,75 +72,169 @@ func NewBuilder(projectDir string, stdlibVersion string, verbose bool) (*Builder
 	}, nil
 }
 
+// SetGoVersion sets the Go release generated code must compile with.
+func (b *Builder) SetGoVersion(v transpiler.GoVersion) {
+	b.goVersion = v
+}
+
+// SetProfile makes the build profile-guided: the transpiler inlines the
+// functions that take at least 1% of the CPU profile at path, and go build gets
+// the profile with -pgo for its own inlining and devirtualization.
+func (b *Builder) SetProfile(path string) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	profile, err := pgo.Load(absPath)
+	if err != nil {
+		return fmt.Errorf("reading profile: %w", err)
+	}
+	b.profilePath = absPath
+	b.hotFuncs = profile.HotFunctions(hotFunctionShare)
+	if b.verbose {
+		fmt.Printf("Hot functions in %s: %s\n", path, strings.Join(pgo.SortedNames(b.hotFuncs), ", "))
+	}
+	return nil
+}
+
+// SetFeatures enables features, as given with -D, in addition to those listed
+// in the project's gala.toml. Their #if feature("name") blocks are compiled,
+// in the project and in its GALA dependencies.
+func (b *Builder) SetFeatures(features []string) {
+	b.defines = features
+}
+
+// SetRace builds the binary with the race detector.
+func (b *Builder) SetRace(race bool) {
+	b.race = race
+}
+
+// SetOptimize makes the transpiler fuse chains of Array combinators in the
+// project's code into single loops and hold the immutable fields of large
+// struct types by reference.
+func (b *Builder) SetOptimize(optimize bool) {
+	b.optimize = optimize
+}
+
+// loadFeatures resolves the features enabled for the build.
+func (b *Builder) loadFeatures() error {
+	config, err := module.LoadConfig(b.workspace.ProjectDir)
+	if err != nil {
+		return err
+	}
+	b.features = append(slices.Clone(config.Features), b.defines...)
+	if b.verbose && len(b.features) > 0 {
+		fmt.Printf("Enabled features: %s\n", strings.Join(b.features, ", "))
+	}
+	return nil
+}
+
 // Build executes the full build process and returns the path to the output binary.
 // If outputPath is empty, uses the module name. If it's an absolute path, uses it directly.
 // Otherwise, treats it as relative to the project directory.
 func (b *Builder) Build(outputPath string) (string, error) {
+	paths, err := b.BuildTargets(outputPath, []Target{HostTarget})
+	if err != nil {
+		return "", err
+	}
+	return paths[0], nil
+}
+
+// BuildTargets transpiles the project once and runs go build for every target,
+// returning the binary paths in the order of targets. Output paths are derived
+// from outputPath as in Build, then named per target by Target.OutputPath.
+func (b *Builder) BuildTargets(outputPath string, targets []Target) ([]string, error) {
+	if err := b.prepare(); err != nil {
+		return nil, err
+	}
+
+	// Step 5: Run go build
+	var paths []string
+	for _, target := range targets {
+		finalPath, err := b.goBuild(outputPath, target)
+		if err != nil {
+			if !target.IsHost() {
+				return nil, fmt.Errorf("go build for %s: %w", target, err)
+			}
+			return nil, fmt.Errorf("go build: %w", err)
+		}
+		paths = append(paths, finalPath)
+	}
+	return paths, nil
+}
+
+// prepare fills the workspace with transpiled code and a go.mod, ready for go build.
+func (b *Builder) prepare() error {
 	// Step 1: Ensure workspace exists
 	if b.verbose {
 		fmt.Printf("Using workspace: %s\n", b.workspace.Dir)
 	}
 	if err := b.workspace.Ensure(); err != nil {
-		return "", fmt.Errorf("ensuring workspace: %w", err)
+		return fmt.Errorf("ensuring workspace: %w", err)
 	}
 
 	// Step 2: Ensure stdlib is extracted to versioned cache
 	if err := b.ensureStdlib(); err != nil {
-		return "", fmt.Errorf("ensuring stdlib: %w", err)
+		return fmt.Errorf("ensuring stdlib: %w", err)
+	}
+
+	if err := b.loadFeatures(); err != nil {
+		return err
 	}
 
 	// Step 2.5: Transpile GALA dependencies
 	if err := b.transpileDeps(); err != nil {
-		return "", fmt.Errorf("transpiling dependencies: %w", err)
+		return fmt.Errorf("transpiling dependencies: %w", err)
 	}
 
 	// Step 3: Transpile .gala files to workspace
 	if err := b.transpile(); err != nil {
-		return "", fmt.Errorf("transpiling: %w", err)
+		return fmt.Errorf("transpiling: %w", err)
 	}
 
 	// Step 4: Generate go.mod in workspace
 	if err := b.generateGoMod(); err != nil {
-		return "", fmt.Errorf("generating go.mod: %w", err)
+		return fmt.Errorf("generating go.mod: %w", err)
 	}
 
-	// Step 5: Run go build
-	finalPath, err := b.goBuild(outputPath)
-	if err != nil {
-		return "", fmt.Errorf("go build: %w", err)
-	}
-
-	return finalPath, nil
+	return nil
 }
 
 // ensureStdlib extracts the stdlib to the versioned cache if not present.
 func (b *Builder) ensureStdlib() error {
-	stdlibDir := b.config.StdlibVersionDir(b.stdlibVersion)
+	_, err := EnsureStdlib(b.config, b.stdlibVersion, b.verbose)
+	return err
+}
+
+// EnsureStdlib extracts the embedded stdlib of the given version into the cache
+// of config unless it is already there, and returns its directory.
+func EnsureStdlib(config *Config, version string, verbose bool) (string, error) {
+	stdlibDir := config.StdlibVersionDir(version)
 
 	// Check if already extracted
 	markerPath := filepath.Join(stdlibDir, ".stdlib-extracted")
 	if _, err := os.Stat(markerPath); err == nil {
-		if b.verbose {
+		if verbose {
 			fmt.Printf("Stdlib already extracted at: %s\n", stdlibDir)
 		}
-		return nil
+		return stdlibDir, nil
 	}
 
-	if b.verbose {
+	if verbose {
 		fmt.Printf("Extracting stdlib to: %s\n", stdlibDir)
 	}
 
 	// Extract stdlib (includes go.mod files for each package)
 	if err := stdlib.ExtractTo(stdlibDir); err != nil {
-		return fmt.Errorf("extracting stdlib: %w", err)
+		return "", fmt.Errorf("extracting stdlib: %w", err)
 	}
 
 	// Write marker file
-	if err := os.WriteFile(markerPath, []byte(b.stdlibVersion), 0644); err != nil {
-		return fmt.Errorf("writing marker: %w", err)
+	if err := os.WriteFile(markerPath, []byte(version), 0644); err != nil {
+		return "", fmt.Errorf("writing marker: %w", err)
 	}
 
-	return nil
+	return stdlibDir, nil
 }
 
 // transpile transpiles all .gala files in the project to the workspace.
@@ -149,17 +259,22 @@ func (b *Builder) transpile() error {
 	}
 
 	// Create transpiler pipeline
-	// Include stdlib directory in search paths so analyzer can find std package types
-	stdlibDir := b.config.StdlibVersionDir(b.stdlibVersion)
-	searchPaths := []string{b.workspace.ProjectDir, stdlibDir}
-
-	// Add GALA dependency source dirs so the analyzer can resolve types from deps
-	for _, req := range b.galaMod.GalaRequires() {
-		searchPaths = append(searchPaths, b.config.GalaModulePath(req.Path, req.Version))
-	}
-	p := transpiler.NewAntlrGalaParser()
-	tr := transformer.NewGalaASTTransformer()
-	g := generator.NewGoCodeGenerator()
+	searchPaths := b.SearchPaths()
+	p := transpiler.NewAntlrGalaParserWithFeatures(b.features)
+	tr := transformer.NewGalaASTTransformerWithTarget(b.goVersion)
+	if b.profilePath != "" {
+		tr = transformer.NewGalaASTTransformerWithProfile(b.goVersion, b.hotFuncs)
+	}
+	// Panics report the .gala declarations of the functions on the stack, and
+	// Go errors and traces the .gala lines of the generated code
+	tr = transformer.WithLineDirectives(transformer.WithStackRemapping(tr))
+	if b.optimize {
+		tr = transformer.WithFusion(tr)
+		tr = transformer.WithImmutableRefs(tr)
+		tr = transformer.WithLiteralInterning(tr)
+		tr = transformer.WithCommonSubexpressions(tr)
+	}
+	g := generator.NewGoCodeGeneratorWithTarget(b.goVersion)
 
 	// Transpile each file, passing sibling files for cross-file type resolution
 	for _, galaFile := range galaFiles {
@@ -182,12 +297,7 @@ func (b *Builder) transpile() error {
 		} else {
 			a = analyzer.NewGalaAnalyzer(p, searchPaths)
 		}
-		t := transpiler.NewGalaToGoTranspiler(p, a, tr, g)
-
-		goCode, err := t.Transpile(string(content), galaFile)
-		if err != nil {
-			return fmt.Errorf("transpiling %s: %w", galaFile, err)
-		}
+		t := transpiler.NewGalaToGoTranspiler(p, a, tr, g, transpiler.WithRegenerateCommand("gala build"))
 
 		// Generate output filename
 		relPath, err := filepath.Rel(b.workspace.ProjectDir, galaFile)
@@ -197,7 +307,26 @@ func (b *Builder) transpile() error {
 		outName := strings.TrimSuffix(relPath, ".gala") + ".gen.go"
 		outName = strings.ReplaceAll(outName, string(filepath.Separator), "_")
 
-		if err := b.workspace.WriteGenFile(outName, []byte(goCode)); err != nil {
+		// The code is streamed to the file rather than built as a string first,
+		// which matters for large files such as sealed types with many variants.
+		// A file left incomplete by an error is removed by the next CleanGen.
+		out, err := b.workspace.CreateGenFile(outName)
+		if err != nil {
+			return fmt.Errorf("writing %s: %w", outName, err)
+		}
+		w := bufio.NewWriter(out)
+		if err := t.TranspileTo(w, string(content), galaFile); err != nil {
+			out.Close()
+			return fmt.Errorf("transpiling %s: %w", galaFile, err)
+		}
+		for _, warning := range t.Warnings() {
+			fmt.Fprintln(os.Stderr, warning)
+		}
+		if err := w.Flush(); err != nil {
+			out.Close()
+			return fmt.Errorf("writing %s: %w", outName, err)
+		}
+		if err := out.Close(); err != nil {
 			return fmt.Errorf("writing %s: %w", outName, err)
 		}
 
@@ -209,6 +338,18 @@ func (b *Builder) transpile() error {
 	return nil
 }
 
+// SearchPaths returns the directories imported GALA packages are resolved in:
+// the project, the stdlib, so the analyzer can find std package types, and
+// the source of every GALA dependency.
+func (b *Builder) SearchPaths() []string {
+	stdlibDir := b.config.StdlibVersionDir(b.stdlibVersion)
+	searchPaths := []string{b.workspace.ProjectDir, stdlibDir}
+	for _, req := range b.galaMod.GalaRequires() {
+		searchPaths = append(searchPaths, b.config.GalaModulePath(req.Path, req.Version))
+	}
+	return searchPaths
+}
+
 // generateGoMod generates the go.mod file in the workspace and downloads Go dependencies.
 func (b *Builder) generateGoMod() error {
 	if b.verbose {
@@ -216,17 +357,24 @@ func (b *Builder) generateGoMod() error {
 	}
 
 	gen := NewGoModGenerator(b.config)
+	gen.SetGoVersion(b.goVersion)
 	if err := gen.WriteGoMod(b.workspace, b.galaMod, b.stdlibVersion, b.transpiledDeps); err != nil {
 		return err
 	}
 
 	// Run go mod tidy to download dependencies and create proper go.sum
+	return b.goModTidy(b.workspace.Dir)
+}
+
+// goModTidy runs go mod tidy in dir, downloading the Go dependencies into the
+// GALA Go module cache.
+func (b *Builder) goModTidy(dir string) error {
 	if b.verbose {
 		fmt.Println("Downloading Go dependencies...")
 	}
 
 	cmd := exec.Command("go", "mod", "tidy")
-	cmd.Dir = b.workspace.Dir
+	cmd.Dir = dir
 	cmd.Env = append(os.Environ(), "GOMODCACHE="+b.config.GoPkgDir)
 
 	if b.verbose {
@@ -243,13 +391,26 @@ func (b *Builder) generateGoMod() error {
 	return nil
 }
 
-// goBuild runs `go build` in the workspace and returns the output path.
-func (b *Builder) goBuild(outputPath string) (string, error) {
+// goBuild runs `go build` for target in the workspace and returns the output path.
+func (b *Builder) goBuild(outputPath string, target Target) (string, error) {
 	if b.verbose {
 		fmt.Println("Running go build...")
 	}
 
-	// Determine output path
+	outputPath = b.binaryPath(outputPath, target)
+	args := append([]string{"build", "-o", outputPath}, b.goBuildFlags()...)
+	args = append(args, "./gen/...")
+
+	if err := b.runGoBuild(b.workspace.Dir, args, target); err != nil {
+		return "", err
+	}
+	return outputPath, nil
+}
+
+// binaryPath returns the path of the binary built for target: outputPath, by
+// default the project directory's name, relative to the project directory and
+// named per target.
+func (b *Builder) binaryPath(outputPath string, target Target) string {
 	if outputPath == "" {
 		// Use module name or directory name, in project directory
 		outputPath = filepath.Join(b.workspace.ProjectDir, filepath.Base(b.workspace.ProjectDir))
@@ -258,36 +419,44 @@ func (b *Builder) goBuild(outputPath string) (string, error) {
 		outputPath = filepath.Join(b.workspace.ProjectDir, outputPath)
 	}
 
-	// Add .exe extension on Windows if not present
-	if isWindows() && !strings.HasSuffix(outputPath, ".exe") {
-		outputPath += ".exe"
-	}
+	// Name the binary per target; adds the .exe extension for Windows
+	return target.OutputPath(outputPath)
+}
 
-	// Build command
-	args := []string{"build", "-o", outputPath, "./gen/..."}
+// goBuildFlags returns the go build flags for the profile and race detector.
+func (b *Builder) goBuildFlags() []string {
+	var flags []string
+	if b.profilePath != "" {
+		flags = append(flags, "-pgo="+b.profilePath)
+	}
+	if b.race {
+		flags = append(flags, "-race")
+	}
+	return flags
+}
 
+// runGoBuild runs go with args in dir for target, using the GALA Go module cache.
+func (b *Builder) runGoBuild(dir string, args []string, target Target) error {
 	cmd := exec.Command("go", args...)
-	cmd.Dir = b.workspace.Dir
+	cmd.Dir = dir
 
-	// Set GOMODCACHE to our Go cache
+	// Set GOMODCACHE to our Go cache, and GOOS/GOARCH for cross builds
 	cmd.Env = append(os.Environ(),
 		"GOMODCACHE="+b.config.GoPkgDir,
 	)
+	cmd.Env = append(cmd.Env, target.Env()...)
 
 	if b.verbose {
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
-		fmt.Printf("Running: go %s\n", strings.Join(args, " "))
+		cmdLine := append(target.Env(), "go")
+		fmt.Printf("Running: %s\n", strings.Join(append(cmdLine, args...), " "))
 	} else {
 		// Capture stderr for error messages
 		cmd.Stderr = os.Stderr
 	}
 
-	if err := cmd.Run(); err != nil {
-		return "", err
-	}
-
-	return outputPath, nil
+	return cmd.Run()
 }
 
 // Workspace returns the builder's workspace.
@@ -308,6 +477,8 @@ func (b *Builder) transpileDeps() error {
 	}
 
 	dt := NewDepTranspiler(b.config, b.workspace, b.galaMod, b.stdlibVersion, b.verbose)
+	dt.SetGoVersion(b.goVersion)
+	dt.SetFeatures(b.features)
 	transpiledDeps, err := dt.TranspileDeps()
 	if err != nil {
 		return err
@@ -318,6 +489,7 @@ func (b *Builder) transpileDeps() error {
 }
 
 // findGalaFiles finds all .gala files in the given directory (non-recursive for now).
+// Files excluded by build tags for the current target are skipped.
 func findGalaFiles(dir string) ([]string, error) {
 	var files []string
 
@@ -330,8 +502,9 @@ func findGalaFiles(dir string) ([]string, error) {
 		if entry.IsDir() {
 			continue
 		}
-		if strings.HasSuffix(entry.Name(), ".gala") && !strings.HasSuffix(entry.Name(), "_test.gala") {
-			files = append(files, filepath.Join(dir, entry.Name()))
+		path := filepath.Join(dir, entry.Name())
+		if strings.HasSuffix(path, ".gala") && !strings.HasSuffix(path, "_test.gala") && buildtags.Default().MatchFile(path) {
+			files = append(files, path)
 		}
 	}
 
@@ -342,12 +515,24 @@ func findGalaFiles(dir string) ([]string, error) {
 func findGalaFilesRecursive(dir string) ([]string, error) {
 	var files []string
 
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+	err := walkSourceTree(dir, func(path string) {
+		// Only process .gala files (skip test files and files excluded by build tags)
+		if strings.HasSuffix(path, ".gala") && !strings.HasSuffix(path, "_test.gala") && buildtags.Default().MatchFile(path) {
+			files = append(files, path)
+		}
+	})
+
+	return files, err
+}
+
+// walkSourceTree calls fn for every file below dir, skipping hidden
+// directories and common non-source directories.
+func walkSourceTree(dir string, fn func(path string)) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
 
-		// Skip hidden directories and common non-source directories
 		if info.IsDir() {
 			name := info.Name()
 			if name != "." && (strings.HasPrefix(name, ".") || name == "vendor" ||
@@ -357,15 +542,9 @@ func findGalaFilesRecursive(dir string) ([]string, error) {
 			return nil
 		}
 
-		// Only process .gala files (skip test files)
-		if strings.HasSuffix(path, ".gala") && !strings.HasSuffix(path, "_test.gala") {
-			files = append(files, path)
-		}
-
+		fn(path)
 		return nil
 	})
-
-	return files, err
 }
 
 // isWindows returns true if running on Windows.
@@ -0,0 +1,297 @@
+package build
+
+import (
+	"fmt"
+	"go/ast"
+	gobuild "go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"martianoff/gala/compiler"
+	"martianoff/gala/internal/transpiler/buildtags"
+	"martianoff/gala/internal/transpiler/module"
+)
+
+// Diagnostic is a problem reported by Checker.
+type Diagnostic struct {
+	// Pos is the position of a Go type error in the generated code, such as
+	// "pkg/main.gen.go:12:5". It is empty for GALA errors, which carry their
+	// position in Msg.
+	Pos     string
+	Msg     string
+	Warning bool
+}
+
+func (d Diagnostic) String() string {
+	if d.Pos == "" {
+		return d.Msg
+	}
+	return d.Pos + ": " + d.Msg
+}
+
+// Checker validates GALA packages without writing anything: each package is
+// transpiled in memory and the generated Go is type-checked with go/types.
+// Imported GALA packages are loaded the same way, reusing their .gen.go files
+// when present; all other imports are type-checked from Go source.
+type Checker struct {
+	project    *compiler.Project
+	resolver   *module.Resolver
+	fset       *token.FileSet
+	goImporter types.ImporterFrom
+	packages   map[string]*types.Package // imported GALA packages by import path
+	loading    map[string]bool
+}
+
+// NewChecker creates a Checker for the current directory, resolving GALA
+// imports through searchPaths. goVersion is as in compiler.Options. The
+// features enabled in gala.toml are compiled, along with those in features.
+func NewChecker(searchPaths []string, goVersion string, features []string) (*Checker, error) {
+	resolver := module.NewResolver(searchPaths)
+	config, err := resolver.Config()
+	if err != nil {
+		return nil, err
+	}
+	project, err := compiler.NewProject(".", compiler.Options{
+		SearchPaths: searchPaths,
+		GoVersion:   goVersion,
+		Features:    append(slices.Clone(config.Features), features...),
+	})
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	return &Checker{
+		project:    project,
+		resolver:   resolver,
+		fset:       fset,
+		goImporter: importer.ForCompiler(fset, "source", nil).(types.ImporterFrom),
+		packages:   make(map[string]*types.Package),
+		loading:    make(map[string]bool),
+	}, nil
+}
+
+// CheckDir checks the GALA package in dir. Generated files already in dir are
+// ignored in favor of the current .gala sources.
+func (c *Checker) CheckDir(dir string) []Diagnostic {
+	_, diags := c.checkPackage(c.importPath(dir), dir, true)
+	return diags
+}
+
+// checkPackage type-checks the package in dir. With fromSource set every .gala
+// file is transpiled; otherwise existing .gen.go files stand in for their sources.
+func (c *Checker) checkPackage(path, dir string, fromSource bool) (*types.Package, []Diagnostic) {
+	files, diags := c.packageFiles(dir, fromSource)
+	if hasErrors(diags) {
+		return nil, diags
+	}
+
+	conf := types.Config{
+		Importer: c,
+		Error: func(err error) {
+			if terr, ok := err.(types.Error); ok {
+				diags = append(diags, Diagnostic{Pos: c.fset.Position(terr.Pos).String(), Msg: terr.Msg})
+				return
+			}
+			diags = append(diags, Diagnostic{Msg: err.Error()})
+		},
+	}
+	pkg, _ := conf.Check(path, c.fset, files, nil)
+	return pkg, diags
+}
+
+// packageFiles parses the Go files making up the package in dir, transpiling
+// .gala files as needed. Test files and files excluded by build tags are
+// skipped.
+func (c *Checker) packageFiles(dir string, fromSource bool) ([]*ast.File, []Diagnostic) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, []Diagnostic{{Msg: err.Error()}}
+	}
+
+	target := buildtags.Default()
+	goContext := gobuild.Default
+	goContext.BuildTags = target.Tags
+
+	var galaFiles, goFiles []string
+	for _, e := range entries {
+		name := e.Name()
+		switch {
+		case e.IsDir():
+		case strings.HasSuffix(name, ".gala") && !strings.HasSuffix(name, "_test.gala"):
+			if target.MatchFile(filepath.Join(dir, name)) {
+				galaFiles = append(galaFiles, filepath.Join(dir, name))
+			}
+		case strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, "_test.go"):
+			if ok, err := goContext.MatchFile(dir, name); err != nil || ok {
+				goFiles = append(goFiles, name)
+			}
+		}
+	}
+	sort.Strings(galaFiles)
+
+	generated := make(map[string]bool)
+	for _, galaFile := range galaFiles {
+		generated[genFileName(galaFile)] = true
+	}
+
+	var files []*ast.File
+	var diags []Diagnostic
+	for _, name := range goFiles {
+		if fromSource && generated[name] {
+			continue
+		}
+		if ok, err := gobuild.Default.MatchFile(dir, name); err != nil || !ok {
+			continue
+		}
+		f, err := parser.ParseFile(c.fset, filepath.Join(dir, name), nil, parser.SkipObjectResolution)
+		if err != nil {
+			diags = append(diags, Diagnostic{Msg: err.Error()})
+			continue
+		}
+		files = append(files, f)
+	}
+
+	for _, galaFile := range galaFiles {
+		genPath := filepath.Join(dir, genFileName(galaFile))
+		if !fromSource {
+			if _, err := os.Stat(genPath); err == nil {
+				continue
+			}
+		}
+		result, err := c.project.CompileFile(galaFile)
+		if err != nil {
+			diags = append(diags, Diagnostic{Msg: err.Error()})
+			continue
+		}
+		for _, d := range result.Diagnostics {
+			diags = append(diags, Diagnostic{Msg: d.String(), Warning: d.Severity == compiler.SeverityWarning})
+		}
+		if result.Diagnostics.HasErrors() {
+			continue
+		}
+		f, err := parser.ParseFile(c.fset, genPath, string(result.Go), parser.SkipObjectResolution)
+		if err != nil {
+			diags = append(diags, Diagnostic{Msg: err.Error()})
+			continue
+		}
+		files = append(files, f)
+	}
+	return files, diags
+}
+
+// Import implements types.Importer.
+func (c *Checker) Import(path string) (*types.Package, error) {
+	return c.ImportFrom(path, "", 0)
+}
+
+// ImportFrom implements types.ImporterFrom. Errors inside an imported GALA
+// package are not reported here; they surface when that package is checked.
+func (c *Checker) ImportFrom(path, dir string, mode types.ImportMode) (*types.Package, error) {
+	if pkg, ok := c.packages[path]; ok {
+		return pkg, nil
+	}
+	pkgDir, ok := c.galaPackageDir(path)
+	if !ok {
+		return c.goImporter.ImportFrom(path, dir, mode)
+	}
+	if c.loading[path] {
+		return nil, fmt.Errorf("import cycle through %s", path)
+	}
+	c.loading[path] = true
+	defer delete(c.loading, path)
+
+	pkg, _ := c.checkPackage(path, pkgDir, false)
+	if pkg == nil {
+		return nil, fmt.Errorf("could not load GALA package %s", path)
+	}
+	c.packages[path] = pkg
+	return pkg, nil
+}
+
+// galaPackageDir resolves an import path the way the analyzer does and reports
+// whether it names a package of the gala module, the current module or a GALA
+// dependency.
+func (c *Checker) galaPackageDir(path string) (string, bool) {
+	rel := path
+	if strings.HasPrefix(path, "martianoff/gala/") && c.resolver.ModuleName() != "martianoff/gala" {
+		rel = strings.TrimPrefix(path, "martianoff/gala/")
+	} else if !c.resolver.IsGalaPackage(path) {
+		return "", false
+	}
+	dir, err := c.resolver.ResolvePackagePath(rel)
+	if err != nil {
+		return "", false
+	}
+	return dir, true
+}
+
+// importPath returns the import path of the package in dir, or "main" outside a module.
+func (c *Checker) importPath(dir string) string {
+	root, name := c.resolver.ModuleRoot(), c.resolver.ModuleName()
+	if root == "" || name == "" {
+		return "main"
+	}
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "main"
+	}
+	if rel == "." {
+		return name
+	}
+	return name + "/" + filepath.ToSlash(rel)
+}
+
+// genFileName returns the name of the Go file generated for galaFile.
+func genFileName(galaFile string) string {
+	return strings.TrimSuffix(filepath.Base(galaFile), ".gala") + ".gen.go"
+}
+
+func hasErrors(diags []Diagnostic) bool {
+	for _, d := range diags {
+		if !d.Warning {
+			return true
+		}
+	}
+	return false
+}
+
+// FindCheckDirs expands a package pattern into the directories holding .gala
+// files. A pattern ending in /... matches its directory tree, a .gala file
+// stands for its directory, and anything else is taken as a single directory.
+func FindCheckDirs(pattern string) ([]string, error) {
+	if strings.HasSuffix(pattern, ".gala") {
+		return []string{filepath.Dir(pattern)}, nil
+	}
+	if root, ok := strings.CutSuffix(pattern, "..."); ok {
+		root = filepath.Clean(strings.TrimSuffix(root, "/"))
+		files, err := findGalaFilesRecursive(root)
+		if err != nil {
+			return nil, err
+		}
+		seen := make(map[string]bool)
+		var dirs []string
+		for _, f := range files {
+			if dir := filepath.Dir(f); !seen[dir] {
+				seen[dir] = true
+				dirs = append(dirs, dir)
+			}
+		}
+		sort.Strings(dirs)
+		return dirs, nil
+	}
+	files, err := findGalaFiles(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no .gala files in %s", pattern)
+	}
+	return []string{pattern}, nil
+}
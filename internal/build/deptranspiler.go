@@ -20,6 +20,8 @@ type DepTranspiler struct {
 	galaMod       *mod.File
 	stdlibVersion string
 	verbose       bool
+	goVersion     transpiler.GoVersion
+	features      []string
 }
 
 // NewDepTranspiler creates a new dependency transpiler.
@@ -33,6 +35,17 @@ func NewDepTranspiler(config *Config, workspace *Workspace, galaMod *mod.File, s
 	}
 }
 
+// SetGoVersion sets the Go release the transpiled dependencies must compile with.
+func (dt *DepTranspiler) SetGoVersion(v transpiler.GoVersion) {
+	dt.goVersion = v
+}
+
+// SetFeatures sets the features whose #if feature("name") blocks are compiled
+// in the dependencies.
+func (dt *DepTranspiler) SetFeatures(features []string) {
+	dt.features = features
+}
+
 // TranspileDeps transpiles all GALA dependencies and returns a map of
 // modulePath -> transpiled directory path.
 func (dt *DepTranspiler) TranspileDeps() (map[string]string, error) {
@@ -136,9 +149,9 @@ func (dt *DepTranspiler) transpileSingleDep(dep mod.Require, transpiledDirs map[
 	}
 
 	// Create transpiler pipeline
-	p := transpiler.NewAntlrGalaParser()
-	tr := transformer.NewGalaASTTransformer()
-	g := generator.NewGoCodeGenerator()
+	p := transpiler.NewAntlrGalaParserWithFeatures(dt.features)
+	tr := transformer.WithLineDirectives(transformer.WithStackRemapping(transformer.NewGalaASTTransformerWithTarget(dt.goVersion)))
+	g := generator.NewGoCodeGeneratorWithTarget(dt.goVersion)
 
 	for _, galaFile := range galaFiles {
 		content, err := os.ReadFile(galaFile)
@@ -160,7 +173,7 @@ func (dt *DepTranspiler) transpileSingleDep(dep mod.Require, transpiledDirs map[
 		} else {
 			a = analyzer.NewGalaAnalyzer(p, searchPaths)
 		}
-		t := transpiler.NewGalaToGoTranspiler(p, a, tr, g)
+		t := transpiler.NewGalaToGoTranspiler(p, a, tr, g, transpiler.WithRegenerateCommand("gala build"))
 
 		goCode, err := t.Transpile(string(content), galaFile)
 		if err != nil {
@@ -194,7 +207,7 @@ func (dt *DepTranspiler) generateDepGoMod(dep mod.Require, outDir string, transp
 
 	sb.WriteString("// Code generated by GALA build system. DO NOT EDIT.\n")
 	sb.WriteString(fmt.Sprintf("module %s\n\n", dep.Path))
-	sb.WriteString("go 1.21\n\n")
+	sb.WriteString(goDirective(dt.goVersion))
 
 	// Scan generated Go files for imports
 	imports, err := CollectImports(outDir)
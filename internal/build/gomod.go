@@ -8,11 +8,17 @@ import (
 	"strings"
 
 	"martianoff/gala/internal/depman/mod"
+	"martianoff/gala/internal/transpiler"
 )
 
+// goModHeader starts every go.mod written by GoModGenerator.
+const goModHeader = "// Code generated by GALA build system. DO NOT EDIT.\n"
+
 // GoModGenerator generates go.mod files for build workspaces.
 type GoModGenerator struct {
-	config *Config
+	config     *Config
+	goVersion  transpiler.GoVersion
+	modulePath string
 }
 
 // NewGoModGenerator creates a new go.mod generator.
@@ -20,6 +26,26 @@ func NewGoModGenerator(config *Config) *GoModGenerator {
 	return &GoModGenerator{config: config}
 }
 
+// SetGoVersion sets the go directive written to generated go.mod files.
+func (g *GoModGenerator) SetGoVersion(v transpiler.GoVersion) {
+	g.goVersion = v
+}
+
+// SetModulePath sets the module path of generated go.mod files, which is
+// gala-build-workspace by default.
+func (g *GoModGenerator) SetModulePath(path string) {
+	g.modulePath = path
+}
+
+// goDirective returns the go directive line for a generated go.mod.
+// Without an explicit target it falls back to Go 1.21.
+func goDirective(v transpiler.GoVersion) string {
+	if !v.IsSet() {
+		return "go 1.21\n\n"
+	}
+	return fmt.Sprintf("go %d.%d\n\n", v.Major, v.Minor)
+}
+
 // StdlibPackages lists all GALA stdlib packages.
 var StdlibPackages = []string{
 	"std",
@@ -27,10 +53,17 @@ var StdlibPackages = []string{
 	"collection_immutable",
 	"collection_mutable",
 	"concurrent",
+	"cli",
+	"env",
+	"files",
 	"lazy",
+	"logging",
+	"random",
+	"sql",
 	"stream",
 	"string_utils",
 	"time_utils",
+	"web",
 }
 
 // StdlibImportPaths maps package names to their import paths.
@@ -40,10 +73,17 @@ var StdlibImportPaths = map[string]string{
 	"collection_immutable": "martianoff/gala/collection_immutable",
 	"collection_mutable":   "martianoff/gala/collection_mutable",
 	"concurrent":           "martianoff/gala/concurrent",
+	"cli":                  "martianoff/gala/cli",
+	"env":                  "martianoff/gala/env",
+	"files":                "martianoff/gala/files",
 	"lazy":                 "martianoff/gala/lazy",
+	"logging":              "martianoff/gala/logging",
+	"random":               "martianoff/gala/random",
+	"sql":                  "martianoff/gala/sql",
 	"stream":               "martianoff/gala/stream",
 	"string_utils":         "martianoff/gala/string_utils",
 	"time_utils":           "martianoff/gala/time_utils",
+	"web":                  "martianoff/gala/web",
 }
 
 // GenerateGoMod generates a go.mod file content for the workspace.
@@ -53,9 +93,13 @@ func (g *GoModGenerator) GenerateGoMod(galaMod *mod.File, stdlibVersion string,
 	var sb strings.Builder
 
 	// Header
-	sb.WriteString("// Code generated by GALA build system. DO NOT EDIT.\n")
-	sb.WriteString("module gala-build-workspace\n\n")
-	sb.WriteString("go 1.21\n\n")
+	sb.WriteString(goModHeader)
+	modulePath := g.modulePath
+	if modulePath == "" {
+		modulePath = "gala-build-workspace"
+	}
+	sb.WriteString("module " + modulePath + "\n\n")
+	sb.WriteString(goDirective(g.goVersion))
 
 	// Collect all requires
 	var stdlibReqs []string
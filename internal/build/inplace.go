@@ -0,0 +1,306 @@
+package build
+
+import (
+	"bytes"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"martianoff/gala/compiler"
+	"martianoff/gala/internal/transpiler"
+)
+
+// inPlaceCommand is the regeneration command recorded in the files written by
+// BuildInPlace.
+const inPlaceCommand = "gala build ./..."
+
+// treePackage is a package of the project tree compiled by BuildInPlace.
+type treePackage struct {
+	dir     string
+	name    string // Go package name, empty if the package has errors
+	results []*compiler.Result
+	imports []string // directories of the project packages it imports
+}
+
+// BuildInPlace builds every package of the project tree, writing each
+// <name>.gen.go beside its <name>.gala source, the files `gala build --verify`
+// checks. Packages are transpiled after the project packages they import, so
+// an error is reported in the package it comes from. The project's go.mod is
+// written too unless the project keeps its own, and go build compiles every
+// package. If the root package is a command, its binary is built for every
+// target and the paths are returned, named as in BuildTargets.
+func (b *Builder) BuildInPlace(outputPath string, targets []Target) ([]string, error) {
+	if err := b.workspace.Ensure(); err != nil {
+		return nil, fmt.Errorf("ensuring workspace: %w", err)
+	}
+	if err := b.ensureStdlib(); err != nil {
+		return nil, fmt.Errorf("ensuring stdlib: %w", err)
+	}
+	if err := b.loadFeatures(); err != nil {
+		return nil, err
+	}
+	if err := b.transpileDeps(); err != nil {
+		return nil, fmt.Errorf("transpiling dependencies: %w", err)
+	}
+
+	packages, err := b.compileTree()
+	if err != nil {
+		return nil, err
+	}
+	if err := b.writeGenFiles(packages); err != nil {
+		return nil, err
+	}
+	if err := b.removeOrphanedGenFiles(); err != nil {
+		return nil, err
+	}
+	if err := b.writeProjectGoMod(); err != nil {
+		return nil, fmt.Errorf("generating go.mod: %w", err)
+	}
+
+	if b.verbose {
+		fmt.Println("Running go build...")
+	}
+	root := b.workspace.ProjectDir
+	isCommand := false
+	for _, pkg := range packages {
+		if pkg.dir == root {
+			isCommand = pkg.name == "main"
+		}
+	}
+	var paths []string
+	for _, target := range targets {
+		// Every package is compiled, including those the command does not
+		// import; the results are discarded
+		args := append([]string{"build", "-o", os.DevNull}, b.goBuildFlags()...)
+		err := b.runGoBuild(root, append(args, "./..."), target)
+		if err == nil && isCommand {
+			path := b.binaryPath(outputPath, target)
+			args := append([]string{"build", "-o", path}, b.goBuildFlags()...)
+			err = b.runGoBuild(root, append(args, "."), target)
+			paths = append(paths, path)
+		}
+		if err != nil {
+			if !target.IsHost() {
+				return nil, fmt.Errorf("go build for %s: %w", target, err)
+			}
+			return nil, fmt.Errorf("go build: %w", err)
+		}
+	}
+	return paths, nil
+}
+
+// compileTree compiles every package of the project tree and returns the
+// packages ordered so that each comes after the project packages it imports.
+func (b *Builder) compileTree() ([]*treePackage, error) {
+	root := b.workspace.ProjectDir
+	project, err := compiler.NewProject(root, compiler.Options{
+		SearchPaths:       b.SearchPaths(),
+		GoVersion:         b.goVersion.String(),
+		Features:          b.features,
+		RegenerateCommand: inPlaceCommand,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	galaFiles, err := findGalaFilesRecursive(root)
+	if err != nil {
+		return nil, fmt.Errorf("finding gala files: %w", err)
+	}
+	if len(galaFiles) == 0 {
+		return nil, fmt.Errorf("no .gala files found in %s", root)
+	}
+
+	if b.verbose {
+		fmt.Println("Transpiling GALA files...")
+	}
+	var dirs []string
+	byDir := make(map[string]*treePackage)
+	for _, galaFile := range galaFiles {
+		dir := filepath.Dir(galaFile)
+		if byDir[dir] != nil {
+			continue
+		}
+		results, err := project.CompilePackage(dir)
+		if err != nil {
+			return nil, err
+		}
+		dirs = append(dirs, dir)
+		byDir[dir] = &treePackage{dir: dir, results: results}
+	}
+
+	fset := token.NewFileSet()
+	for _, dir := range dirs {
+		pkg := byDir[dir]
+		seen := make(map[string]bool)
+		for _, r := range pkg.results {
+			if r.Diagnostics.HasErrors() {
+				continue
+			}
+			f, err := parser.ParseFile(fset, r.File, string(r.Go), parser.ImportsOnly)
+			if err != nil {
+				return nil, fmt.Errorf("transpiling %s: %w", b.relPath(r.File), err)
+			}
+			pkg.name = f.Name.Name
+			for _, spec := range f.Imports {
+				imported, ok := b.projectPackageDir(strings.Trim(spec.Path.Value, `"`))
+				if ok && imported != dir && byDir[imported] != nil && !seen[imported] {
+					seen[imported] = true
+					pkg.imports = append(pkg.imports, imported)
+				}
+			}
+		}
+	}
+
+	// Order the packages depth-first along their imports, in directory order
+	var order []*treePackage
+	done := make(map[string]bool)
+	var stack []string
+	var visit func(dir string) error
+	visit = func(dir string) error {
+		if done[dir] {
+			return nil
+		}
+		for i, d := range stack {
+			if d == dir {
+				var chain []string
+				for _, d := range append(stack[i:], dir) {
+					chain = append(chain, b.importPath(d))
+				}
+				return fmt.Errorf("import cycle: %s", strings.Join(chain, " -> "))
+			}
+		}
+		stack = append(stack, dir)
+		for _, imported := range byDir[dir].imports {
+			if err := visit(imported); err != nil {
+				return err
+			}
+		}
+		stack = stack[:len(stack)-1]
+		done[dir] = true
+		order = append(order, byDir[dir])
+		return nil
+	}
+	for _, dir := range dirs {
+		if err := visit(dir); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// projectPackageDir returns the directory of the project package with the
+// import path path, and whether path names a package of the project.
+func (b *Builder) projectPackageDir(path string) (string, bool) {
+	modulePath := b.galaMod.Module.Path
+	switch {
+	case modulePath == "":
+		return "", false
+	case path == modulePath:
+		return b.workspace.ProjectDir, true
+	case strings.HasPrefix(path, modulePath+"/"):
+		rel := strings.TrimPrefix(path, modulePath+"/")
+		return filepath.Join(b.workspace.ProjectDir, filepath.FromSlash(rel)), true
+	}
+	return "", false
+}
+
+// importPath returns the import path of the project package in dir.
+func (b *Builder) importPath(dir string) string {
+	rel := b.relPath(dir)
+	if rel == "." {
+		return b.galaMod.Module.Path
+	}
+	return b.galaMod.Module.Path + "/" + filepath.ToSlash(rel)
+}
+
+// writeGenFiles writes the generated file of every source of packages, in
+// order, stopping at the first package with errors. Files whose content did not
+// change are left untouched.
+func (b *Builder) writeGenFiles(packages []*treePackage) error {
+	for _, pkg := range packages {
+		for _, r := range pkg.results {
+			for _, d := range r.Diagnostics {
+				if d.Severity == compiler.SeverityWarning {
+					fmt.Fprintln(os.Stderr, d)
+				}
+			}
+		}
+		for _, r := range pkg.results {
+			if err := r.Diagnostics.Err(); err != nil {
+				return fmt.Errorf("transpiling %s: %w", b.relPath(r.File), err)
+			}
+		}
+		for _, r := range pkg.results {
+			genPath := filepath.Join(pkg.dir, genFileName(r.File))
+			if existing, err := os.ReadFile(genPath); err == nil && bytes.Equal(existing, []byte(r.Go)) {
+				continue
+			}
+			if err := os.WriteFile(genPath, []byte(r.Go), 0644); err != nil {
+				return fmt.Errorf("writing %s: %w", b.relPath(genPath), err)
+			}
+			if b.verbose {
+				fmt.Printf("  %s -> %s\n", b.relPath(r.File), b.relPath(genPath))
+			}
+		}
+	}
+	return nil
+}
+
+// removeOrphanedGenFiles removes the generated files of the project whose
+// source was removed. Go files without a provenance header are kept.
+func (b *Builder) removeOrphanedGenFiles() error {
+	var orphans []string
+	err := walkSourceTree(b.workspace.ProjectDir, func(path string) {
+		if !strings.HasSuffix(path, ".gen.go") {
+			return
+		}
+		if _, err := os.Stat(strings.TrimSuffix(path, ".gen.go") + ".gala"); !os.IsNotExist(err) {
+			return
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return
+		}
+		if _, ok := transpiler.ParseProvenance(string(content)); ok {
+			orphans = append(orphans, path)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	for _, path := range orphans {
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		if b.verbose {
+			fmt.Printf("  removed %s\n", b.relPath(path))
+		}
+	}
+	return nil
+}
+
+// writeProjectGoMod writes the go.mod of the project, declaring its module
+// path from gala.mod, and downloads the Go dependencies. A go.mod the project
+// maintains itself, one not written by GALA, is left as it is.
+func (b *Builder) writeProjectGoMod() error {
+	goModPath := filepath.Join(b.workspace.ProjectDir, "go.mod")
+	if content, err := os.ReadFile(goModPath); err == nil && !strings.HasPrefix(string(content), goModHeader) {
+		return nil
+	}
+	if b.verbose {
+		fmt.Println("Generating go.mod...")
+	}
+
+	gen := NewGoModGenerator(b.config)
+	gen.SetGoVersion(b.goVersion)
+	gen.SetModulePath(b.galaMod.Module.Path)
+	content := gen.GenerateGoMod(b.galaMod, b.stdlibVersion, b.transpiledDeps)
+	if err := os.WriteFile(goModPath, []byte(content), 0644); err != nil {
+		return err
+	}
+	return b.goModTidy(b.workspace.ProjectDir)
+}
@@ -0,0 +1,161 @@
+package build
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"martianoff/gala/compiler"
+	"martianoff/gala/internal/transpiler"
+)
+
+// playgroundOutputLimit caps the build and program output kept for one run.
+const playgroundOutputLimit = 1 << 20
+
+// Playground transpiles and runs GALA snippets for `gala playground`. All runs
+// share one StandaloneModule, so the stdlib is extracted only once; since they
+// also share its main.go, runs are serialized.
+//
+// Each program runs in an empty scratch directory, which also serves as its
+// HOME and TMPDIR, with no other environment variables. On Linux it also gets
+// no network access (see PlaygroundNetworkIsolated). It is not a full sandbox:
+// the program runs as the user and can read and write whatever they can.
+type Playground struct {
+	mu      sync.Mutex
+	module  *StandaloneModule
+	timeout time.Duration
+}
+
+// PlaygroundResult is the outcome of transpiling or running a snippet.
+type PlaygroundResult struct {
+	GoCode      string   `json:"goCode"`
+	Diagnostics []string `json:"diagnostics,omitempty"`
+	Output      string   `json:"output,omitempty"`
+	// Error is set when transpiling, building or running failed.
+	Error string `json:"error,omitempty"`
+}
+
+// NewPlayground creates a playground whose programs are killed after timeout.
+func NewPlayground(timeout time.Duration) (*Playground, error) {
+	dir, err := os.MkdirTemp("", "gala-playground-*")
+	if err != nil {
+		return nil, fmt.Errorf("creating temp dir: %w", err)
+	}
+	m, err := NewStandaloneModule(dir, transpiler.GoVersion{})
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	return &Playground{module: m, timeout: timeout}, nil
+}
+
+// Close removes the playground's module.
+func (p *Playground) Close() error {
+	return os.RemoveAll(p.module.Dir)
+}
+
+// Transpile returns the Go code generated for source.
+func (p *Playground) Transpile(source string) PlaygroundResult {
+	goCode, diags, _ := compiler.Compile(source, compiler.Options{
+		FileName:    "main.gala",
+		SearchPaths: []string{p.module.StdlibDir()},
+	})
+	var res PlaygroundResult
+	res.GoCode = string(goCode)
+	for _, d := range diags {
+		res.Diagnostics = append(res.Diagnostics, d.String())
+	}
+	if diags.HasErrors() {
+		res.Error = "transpilation failed"
+	}
+	return res
+}
+
+// Run transpiles source, builds it and runs it without stdin. The result holds
+// the combined build and program output.
+func (p *Playground) Run(source string) PlaygroundResult {
+	res := p.Transpile(source)
+	if res.Error != "" {
+		return res
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.module.WriteMain(res.GoCode); err != nil {
+		res.Error = fmt.Sprintf("writing main.go: %v", err)
+		return res
+	}
+	out := &limitedBuffer{limit: playgroundOutputLimit}
+	binPath, err := p.module.BuildTo(out)
+	if err != nil {
+		res.Output, res.Error = out.String(), err.Error()
+		return res
+	}
+
+	scratch, err := os.MkdirTemp("", "gala-playground-run-*")
+	if err != nil {
+		res.Error = fmt.Sprintf("creating scratch dir: %v", err)
+		return res
+	}
+	defer os.RemoveAll(scratch)
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, binPath)
+	cmd.Dir = scratch
+	cmd.Env = playgroundEnv(scratch)
+	isolate(cmd)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	err = cmd.Run()
+	res.Output = out.String()
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		res.Error = fmt.Sprintf("program killed after %s", p.timeout)
+	case err != nil && cmd.Process == nil && PlaygroundNetworkIsolated:
+		res.Error = fmt.Sprintf("starting the program without network access: %v (unprivileged user namespaces may be disabled)", err)
+	case err != nil:
+		res.Error = err.Error()
+	}
+	return res
+}
+
+// playgroundEnv returns the environment of a program run in dir: dir as its
+// home and temp directory, and nothing of the playground's own environment.
+func playgroundEnv(dir string) []string {
+	env := []string{"HOME=" + dir, "TMPDIR=" + dir}
+	if isWindows() {
+		// Windows programs cannot start without it
+		env = append(env, "SYSTEMROOT="+os.Getenv("SYSTEMROOT"), "TEMP="+dir, "TMP="+dir)
+	}
+	return env
+}
+
+// limitedBuffer keeps the first limit bytes written to it and drops the rest,
+// so a program printing in a loop cannot exhaust memory.
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(data []byte) (int, error) {
+	if room := b.limit - b.buf.Len(); len(data) > room {
+		b.buf.Write(data[:max(room, 0)])
+		b.truncated = true
+		return len(data), nil
+	}
+	return b.buf.Write(data)
+}
+
+func (b *limitedBuffer) String() string {
+	if b.truncated {
+		return b.buf.String() + "\n... output truncated"
+	}
+	return b.buf.String()
+}
@@ -0,0 +1,152 @@
+package build
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"martianoff/gala/internal/stdlib"
+	"martianoff/gala/internal/transpiler"
+	"martianoff/gala/internal/transpiler/analyzer"
+	"martianoff/gala/internal/transpiler/generator"
+	"martianoff/gala/internal/transpiler/transformer"
+)
+
+// standaloneStdDir is the directory of a StandaloneModule holding the stdlib copy.
+const standaloneStdDir = "gala_std"
+
+// StandaloneModule is a throwaway Go module for running a single transpiled file
+// without a gala.mod project. The embedded stdlib packages are extracted into it
+// and wired up with replace directives, so nothing has to resolve martianoff/gala
+// from the surrounding directory.
+type StandaloneModule struct {
+	// Dir is the module root, where go.mod and main.go are written.
+	Dir string
+}
+
+// NewStandaloneModule extracts the stdlib into dir and writes its go.mod.
+func NewStandaloneModule(dir string, goVersion transpiler.GoVersion) (*StandaloneModule, error) {
+	m := &StandaloneModule{Dir: dir}
+	if err := stdlib.ExtractTo(m.StdlibDir()); err != nil {
+		return nil, fmt.Errorf("extracting stdlib: %w", err)
+	}
+	goMod := standaloneGoMod(goVersion)
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(goMod), 0644); err != nil {
+		return nil, fmt.Errorf("writing go.mod: %w", err)
+	}
+	return m, nil
+}
+
+// StdlibDir returns the directory of the extracted stdlib. The GALA sources are
+// extracted too, so it doubles as an analyzer search path.
+func (m *StandaloneModule) StdlibDir() string {
+	return filepath.Join(m.Dir, standaloneStdDir)
+}
+
+// WriteMain writes the generated Go code of the main package.
+func (m *StandaloneModule) WriteMain(goCode string) error {
+	return os.WriteFile(filepath.Join(m.Dir, "main.go"), []byte(goCode), 0644)
+}
+
+// Build compiles the module and returns the path of the binary.
+func (m *StandaloneModule) Build() (string, error) {
+	return m.BuildTo(os.Stderr)
+}
+
+// BuildTo is Build with the compiler output written to stderr instead of os.Stderr.
+func (m *StandaloneModule) BuildTo(stderr io.Writer) (string, error) {
+	binPath := filepath.Join(m.Dir, "gala-run")
+	if isWindows() {
+		binPath += ".exe"
+	}
+	// -mod=mod lets go add requirements for third-party Go imports of the program
+	cmd := exec.Command("go", "build", "-mod=mod", "-o", binPath, ".")
+	cmd.Dir = m.Dir
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("go build: %w", err)
+	}
+	return binPath, nil
+}
+
+// standaloneGoMod requires every stdlib package and replaces it with its extracted copy.
+func standaloneGoMod(goVersion transpiler.GoVersion) string {
+	var sb strings.Builder
+	sb.WriteString("// Code generated by GALA build system. DO NOT EDIT.\n")
+	sb.WriteString("module gala-run\n\n")
+	sb.WriteString(goDirective(goVersion))
+
+	sb.WriteString("require (\n")
+	for _, pkg := range StdlibPackages {
+		fmt.Fprintf(&sb, "\t%s v0.0.0\n", StdlibImportPaths[pkg])
+	}
+	sb.WriteString(")\n\n")
+	for _, pkg := range StdlibPackages {
+		fmt.Fprintf(&sb, "replace %s => ./%s/%s\n", StdlibImportPaths[pkg], standaloneStdDir, pkg)
+	}
+	return sb.String()
+}
+
+// RunFile transpiles a single .gala file into a StandaloneModule in a temp
+// directory, builds it and runs it from the current directory with args. The
+// #if feature("name") blocks of features are compiled.
+func RunFile(galaFile string, goVersion transpiler.GoVersion, features []string, args []string, verbose bool) error {
+	dir, err := os.MkdirTemp("", "gala-run-*")
+	if err != nil {
+		return fmt.Errorf("creating temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	binPath, err := BuildFile(galaFile, dir, goVersion, features, verbose)
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(binPath, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// StandaloneSearchPaths returns the directories BuildFile resolves the imports
+// of galaFile in, when building it in dir: the directory of the file and the
+// stdlib copy of the module.
+func StandaloneSearchPaths(galaFile string, dir string) []string {
+	return []string{filepath.Dir(galaFile), filepath.Join(dir, standaloneStdDir)}
+}
+
+// BuildFile transpiles a single .gala file into a StandaloneModule in dir and
+// builds it, as RunFile does, and returns the path of the binary. dir can be
+// reused for later builds of the same file.
+func BuildFile(galaFile string, dir string, goVersion transpiler.GoVersion, features []string, verbose bool) (string, error) {
+	content, err := os.ReadFile(galaFile)
+	if err != nil {
+		return "", fmt.Errorf("reading %s: %w", galaFile, err)
+	}
+
+	m, err := NewStandaloneModule(dir, goVersion)
+	if err != nil {
+		return "", err
+	}
+	if verbose {
+		fmt.Printf("Using standalone module: %s\n", dir)
+	}
+
+	p := transpiler.NewAntlrGalaParserWithFeatures(features)
+	a := analyzer.NewGalaAnalyzer(p, StandaloneSearchPaths(galaFile, dir))
+	t := transpiler.NewGalaToGoTranspiler(p, a, transformer.NewGalaASTTransformerWithTarget(goVersion), generator.NewGoCodeGeneratorWithTarget(goVersion))
+	goCode, err := t.Transpile(string(content), galaFile)
+	if err != nil {
+		return "", fmt.Errorf("transpiling %s: %w", galaFile, err)
+	}
+	for _, w := range t.Warnings() {
+		fmt.Fprintln(os.Stderr, w)
+	}
+	if err := m.WriteMain(goCode); err != nil {
+		return "", fmt.Errorf("writing main.go: %w", err)
+	}
+	return m.Build()
+}
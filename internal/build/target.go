@@ -0,0 +1,109 @@
+package build
+
+import (
+	"fmt"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// Target is a GOOS/GOARCH pair to build for. Empty fields use the host value.
+type Target struct {
+	GOOS   string
+	GOARCH string
+}
+
+// HostTarget is the zero Target: the platform gala itself runs on.
+var HostTarget = Target{}
+
+// IsHost reports whether t leaves both GOOS and GOARCH to the host.
+func (t Target) IsHost() bool {
+	return t.GOOS == "" && t.GOARCH == ""
+}
+
+// String returns the target in `go tool dist list` form, e.g. "linux/arm64".
+func (t Target) String() string {
+	return t.os() + "/" + t.arch()
+}
+
+func (t Target) os() string {
+	if t.GOOS == "" {
+		return runtime.GOOS
+	}
+	return t.GOOS
+}
+
+func (t Target) arch() string {
+	if t.GOARCH == "" {
+		return runtime.GOARCH
+	}
+	return t.GOARCH
+}
+
+// Env returns the environment entries that select t for the go toolchain.
+func (t Target) Env() []string {
+	var env []string
+	if t.GOOS != "" {
+		env = append(env, "GOOS="+t.GOOS)
+	}
+	if t.GOARCH != "" {
+		env = append(env, "GOARCH="+t.GOARCH)
+	}
+	return env
+}
+
+// OutputPath returns the binary path for t derived from base. Cross builds get a
+// -<goos>-<goarch> suffix so several targets can share one output directory, and
+// Windows binaries get an .exe extension.
+func (t Target) OutputPath(base string) string {
+	ext := ""
+	if strings.HasSuffix(base, ".exe") {
+		base, ext = strings.TrimSuffix(base, ".exe"), ".exe"
+	}
+	if !t.IsHost() {
+		base += "-" + t.os() + "-" + t.arch()
+	}
+	if t.os() == "windows" {
+		ext = ".exe"
+	}
+	return base + ext
+}
+
+var targetNameRe = regexp.MustCompile(`^[a-z0-9]+$`)
+
+// ParseTargets expands comma-separated GOOS and GOARCH lists into every
+// combination of the two. Empty lists keep the host value, so ParseTargets("", "")
+// returns just HostTarget.
+func ParseTargets(goos, goarch string) ([]Target, error) {
+	oses, err := splitTargetList("GOOS", goos)
+	if err != nil {
+		return nil, err
+	}
+	arches, err := splitTargetList("GOARCH", goarch)
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []Target
+	for _, o := range oses {
+		for _, a := range arches {
+			targets = append(targets, Target{GOOS: o, GOARCH: a})
+		}
+	}
+	return targets, nil
+}
+
+func splitTargetList(kind, list string) ([]string, error) {
+	if list == "" {
+		return []string{""}, nil
+	}
+	var values []string
+	for _, v := range strings.Split(list, ",") {
+		v = strings.TrimSpace(v)
+		if !targetNameRe.MatchString(v) {
+			return nil, fmt.Errorf("invalid %s %q", kind, v)
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
@@ -0,0 +1,184 @@
+package build
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"martianoff/gala/compiler"
+	"martianoff/gala/internal/transpiler"
+)
+
+// maxDiffLines limits the lines of each side shown in a Drift diff.
+const maxDiffLines = 5
+
+// Drift is a committed generated file that differs from what the current
+// sources and transpiler produce.
+type Drift struct {
+	GenFile string // path of the committed .gen.go file
+	Source  string // path of its .gala source; empty if the source was removed
+	Reason  string // why the file is stale, e.g. "source changed"
+	Diff    string // summary of the differing lines; empty if the source was removed
+	Command string // regeneration command recorded in the file, if any
+}
+
+func (d Drift) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s: %s", d.GenFile, d.Reason)
+	if d.Diff != "" {
+		sb.WriteString("\n" + d.Diff)
+	}
+	if d.Command != "" {
+		fmt.Fprintf(&sb, "\n  regenerate with: %s", d.Command)
+	}
+	return sb.String()
+}
+
+// Verify regenerates in memory every .gen.go file committed beside its .gala
+// source in the project and reports the files that differ from the committed
+// ones. Generated files whose source was removed are reported as well. The
+// regeneration command in the header is not compared. Nothing is written.
+func (b *Builder) Verify() ([]Drift, error) {
+	if err := b.ensureStdlib(); err != nil {
+		return nil, fmt.Errorf("ensuring stdlib: %w", err)
+	}
+	if err := b.loadFeatures(); err != nil {
+		return nil, err
+	}
+	project, err := compiler.NewProject(b.workspace.ProjectDir, compiler.Options{
+		SearchPaths: b.SearchPaths(),
+		GoVersion:   b.goVersion.String(),
+		Features:    b.features,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	galaFiles, err := findGalaFilesRecursive(b.workspace.ProjectDir)
+	if err != nil {
+		return nil, fmt.Errorf("finding gala files: %w", err)
+	}
+
+	var drifts []Drift
+	for _, galaFile := range galaFiles {
+		genPath := filepath.Join(filepath.Dir(galaFile), genFileName(galaFile))
+		committed, err := os.ReadFile(genPath)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		src, err := os.ReadFile(galaFile)
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := project.CompileFile(galaFile)
+		if err != nil {
+			return nil, err
+		}
+		if err := result.Diagnostics.Err(); err != nil {
+			return nil, fmt.Errorf("transpiling %s: %w", galaFile, err)
+		}
+		if b.verbose {
+			fmt.Printf("  verifying %s\n", b.relPath(genPath))
+		}
+
+		want, got := withoutCommand(string(committed)), withoutCommand(string(result.Go))
+		if want == got {
+			continue
+		}
+		p, _ := transpiler.ParseProvenance(string(committed))
+		drifts = append(drifts, Drift{
+			GenFile: b.relPath(genPath),
+			Source:  b.relPath(galaFile),
+			Reason:  driftReason(string(committed), string(src)),
+			Diff:    diffSummary(want, got),
+			Command: p.Command,
+		})
+	}
+
+	err = walkSourceTree(b.workspace.ProjectDir, func(path string) {
+		if !strings.HasSuffix(path, ".gen.go") {
+			return
+		}
+		if _, err := os.Stat(strings.TrimSuffix(path, ".gen.go") + ".gala"); !os.IsNotExist(err) {
+			return
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return
+		}
+		if p, ok := transpiler.ParseProvenance(string(content)); ok {
+			drifts = append(drifts, Drift{GenFile: b.relPath(path), Reason: "source " + p.Source + " was removed"})
+		}
+	})
+	return drifts, err
+}
+
+// relPath returns path relative to the project directory, for reports.
+func (b *Builder) relPath(path string) string {
+	if rel, err := filepath.Rel(b.workspace.ProjectDir, path); err == nil {
+		return rel
+	}
+	return path
+}
+
+// driftReason explains from the provenance header of a stale generated file
+// why it no longer matches its source src.
+func driftReason(committed, src string) string {
+	p, ok := transpiler.ParseProvenance(committed)
+	switch {
+	case !ok:
+		return "generated without a provenance header"
+	case p.Hash != transpiler.SourceHash(src):
+		return "source changed since the file was generated"
+	case p.Version != transpiler.Version:
+		return fmt.Sprintf("generated by gala %s, current is %s", p.Version, transpiler.Version)
+	default:
+		return "generated code differs (imported packages or the Go target may have changed)"
+	}
+}
+
+// withoutCommand removes the "Regenerate with" line from the header of
+// generated code, which depends on how the file was produced.
+func withoutCommand(code string) string {
+	p, ok := transpiler.ParseProvenance(code)
+	if !ok || p.Command == "" {
+		return code
+	}
+	return strings.Replace(code, "// Regenerate with: "+p.Command+"\n", "", 1)
+}
+
+// diffSummary describes where want and got differ: the line of the first
+// difference and the differing lines of each side, cut to maxDiffLines.
+func diffSummary(want, got string) string {
+	a, b := strings.Split(want, "\n"), strings.Split(got, "\n")
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	removed, added := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "  line %d: %d committed line(s) replaced by %d regenerated line(s)", prefix+1, len(removed), len(added))
+	writeDiffLines(&sb, "-", removed)
+	writeDiffLines(&sb, "+", added)
+	return sb.String()
+}
+
+func writeDiffLines(sb *strings.Builder, mark string, lines []string) {
+	for i, line := range lines {
+		if i == maxDiffLines {
+			fmt.Fprintf(sb, "\n  %s ... %d more", mark, len(lines)-i)
+			return
+		}
+		fmt.Fprintf(sb, "\n  %s %s", mark, line)
+	}
+}
@@ -118,6 +118,12 @@ func (w *Workspace) WriteGenFile(filename string, content []byte) error {
 	return os.WriteFile(filePath, content, 0644)
 }
 
+// CreateGenFile creates, or truncates, a generated Go file in the workspace
+// for the caller to write.
+func (w *Workspace) CreateGenFile(filename string) (*os.File, error) {
+	return os.Create(filepath.Join(w.GenDir, filename))
+}
+
 // GenFiles returns all .go files in the gen directory.
 func (w *Workspace) GenFiles() ([]string, error) {
 	entries, err := os.ReadDir(w.GenDir)
@@ -0,0 +1,125 @@
+package parser
+
+import (
+	"strings"
+
+	"martianoff/gala/internal/parser/grammar"
+
+	"github.com/antlr4-go/antlr/v4"
+)
+
+// This file contains for comprehensions, which are rewritten into the calls
+// they stand for before the file is parsed:
+//
+//	for {
+//	    x <- xs
+//	    y <- ys if y > x
+//	} yield x + y
+//
+// becomes
+//
+//	xs.FlatMap((x) =>
+//	    ys.Filter((y) => y > x).Map((y) => x + y))
+//
+// so they work on any type with FlatMap, Map and, for guards, Filter methods,
+// such as Option, Try, Either and the collections.
+// Functions: desugarComprehensions, innermostComprehension, desugarComprehension, isPostfixChain
+
+// desugarComprehensions rewrites the for comprehensions of input, innermost
+// first. Input with syntax errors is returned as is for the parser to report.
+func desugarComprehensions(input string) string {
+	if !strings.Contains(input, "yield") {
+		return input
+	}
+	for {
+		errorListener := &GalaErrorListener{}
+		lexer := grammar.NewgalaLexer(antlr.NewInputStream(input))
+		lexer.RemoveErrorListeners()
+		lexer.AddErrorListener(errorListener)
+		parser := grammar.NewgalaParser(antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel))
+		parser.RemoveErrorListeners()
+		parser.AddErrorListener(errorListener)
+
+		tree := parser.SourceFile()
+		if len(errorListener.Errors) > 0 {
+			return input
+		}
+		c := innermostComprehension(tree)
+		if c == nil {
+			return input
+		}
+		input = desugarComprehension([]rune(input), c)
+	}
+}
+
+// innermostComprehension returns the first for comprehension of tree that
+// contains no other, or nil if there is none.
+func innermostComprehension(tree antlr.Tree) *grammar.ForComprehensionContext {
+	for i := 0; i < tree.GetChildCount(); i++ {
+		if c := innermostComprehension(tree.GetChild(i)); c != nil {
+			return c
+		}
+	}
+	c, _ := tree.(*grammar.ForComprehensionContext)
+	return c
+}
+
+// desugarComprehension returns src with the for comprehension c replaced by
+// its calls. The generators, guards and the yielded expression keep their
+// lines, so diagnostics still point into the comprehension.
+func desugarComprehension(src []rune, c *grammar.ForComprehensionContext) string {
+	var sb strings.Builder
+	pos := c.GetStart().GetStart()
+	// text returns the source of ctx, preceded by the line breaks between it
+	// and the text returned before it
+	text := func(ctx antlr.ParserRuleContext) string {
+		var lines strings.Builder
+		for _, r := range src[pos:ctx.GetStart().GetStart()] {
+			if r == '\n' {
+				lines.WriteRune(r)
+			}
+		}
+		pos = ctx.GetStop().GetStop() + 1
+		return lines.String() + string(src[ctx.GetStart().GetStart():pos])
+	}
+
+	enums := c.AllEnumerator()
+	for i, e := range enums {
+		name := e.Identifier().GetText()
+		source := text(e.GetSource())
+		if !isPostfixChain(e.GetSource()) {
+			source = "(" + source + ")"
+		}
+		sb.WriteString(source)
+		if e.GetGuard() != nil {
+			sb.WriteString(".Filter((" + name + ") => " + text(e.GetGuard()) + ")")
+		}
+		if i < len(enums)-1 {
+			sb.WriteString(".FlatMap((" + name + ") => ")
+		} else {
+			sb.WriteString(".Map((" + name + ") => " + text(c.Expression()) + ")")
+		}
+	}
+	sb.WriteString(strings.Repeat(")", len(enums)-1))
+
+	return string(src[:c.GetStart().GetStart()]) + sb.String() + string(src[c.GetStop().GetStop()+1:])
+}
+
+// isPostfixChain reports whether expr is a primary expression followed by
+// selectors, calls and indexes only, which a method call can follow without
+// parentheses.
+func isPostfixChain(expr antlr.Tree) bool {
+	for {
+		switch n := expr.(type) {
+		case *grammar.PostfixExprContext:
+			return n.PrimaryExpr().Primary() != nil && len(n.AllCaseClause()) == 0
+		case antlr.ParserRuleContext:
+			if n.GetChildCount() != 1 {
+				return false
+			}
+			expr = n.GetChild(0)
+		default:
+			return false
+		}
+	}
+}
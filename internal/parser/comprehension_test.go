@@ -0,0 +1,58 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDesugarComprehensions(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "single generator",
+			input: "package main\nval r = for { x <- xs } yield x * 2\n",
+			want:  "package main\nval r = xs.Map((x) => x * 2)\n",
+		},
+		{
+			name: "generators on separate lines",
+			input: `package main
+val r = for {
+    a <- parse("1")
+    b <- parse("2")
+} yield a + b
+`,
+			// Each part keeps its line
+			want: "package main\nval r = \nparse(\"1\").FlatMap((a) => \nparse(\"2\").Map((b) => \na + b))\n",
+		},
+		{
+			name:  "guard",
+			input: "package main\nval r = for { x <- xs; y <- ys if x < y } yield (x, y)\n",
+			want:  "package main\nval r = xs.FlatMap((x) => ys.Filter((y) => x < y).Map((y) => (x, y)))\n",
+		},
+		{
+			name:  "operator in a generator",
+			input: "package main\nval r = for { x <- a.OrElse(b) ; y <- if (ok) c else d } yield x + y\n",
+			want:  "package main\nval r = a.OrElse(b).FlatMap((x) => (if (ok) c else d).Map((y) => x + y))\n",
+		},
+		{
+			name:  "nested comprehension",
+			input: "package main\nval r = for { x <- xs } yield for { y <- ys } yield x + y\n",
+			want:  "package main\nval r = xs.Map((x) => ys.Map((y) => x + y))\n",
+		},
+		{
+			name:  "without comprehensions",
+			input: "package main\nval r = xs.Map((x) => x)\n",
+			want:  "package main\nval r = xs.Map((x) => x)\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, desugarComprehensions(tt.input))
+		})
+	}
+}
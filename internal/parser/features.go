@@ -0,0 +1,118 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"martianoff/gala/galaerr"
+)
+
+// This file contains feature blocks: code between #if feature("name") and
+// #endif lines is only compiled when the feature is enabled, so a library can
+// ship experimental APIs without keeping them on a separate branch.
+//
+//	#if feature("experimental")
+//	func FastPath(xs []int) int = ...
+//	#else
+//	func FastPath(xs []int) int = SlowPath(xs)
+//	#endif
+//
+// Blocks are removed before parsing, by blanking their lines rather than
+// deleting them, so everything else keeps its line numbers in diagnostics and
+// in the generated code.
+// Functions: pruneFeatures, parseDirective, parseFeatureCondition, blankLine
+
+// featureBlock is an #if block being scanned.
+type featureBlock struct {
+	line   int  // Line of the #if, for unterminated blocks
+	active bool // Whether the current branch is compiled
+	parent bool // Whether the enclosing block is compiled
+	inElse bool
+}
+
+// pruneFeatures blanks the directive lines of input and the lines of the
+// branches that are not compiled with the enabled features.
+func pruneFeatures(input string, enabled map[string]bool) (string, error) {
+	if !strings.Contains(input, "#") {
+		return input, nil
+	}
+	lines := strings.SplitAfter(input, "\n")
+	var stack []featureBlock
+	for i, line := range lines {
+		lineNum := i + 1
+		active := len(stack) == 0 || stack[len(stack)-1].active
+		directive, arg := parseDirective(line)
+		switch directive {
+		case "#if":
+			name, negated, err := parseFeatureCondition(arg)
+			if err != nil {
+				return "", galaerr.NewSyntaxError(lineNum, 0, err.Error())
+			}
+			stack = append(stack, featureBlock{line: lineNum, active: active && enabled[name] != negated, parent: active})
+		case "#else":
+			if len(stack) == 0 {
+				return "", galaerr.NewSyntaxError(lineNum, 0, "#else without #if")
+			}
+			top := &stack[len(stack)-1]
+			if top.inElse {
+				return "", galaerr.NewSyntaxError(lineNum, 0, fmt.Sprintf("second #else for the #if on line %d", top.line))
+			}
+			top.inElse = true
+			top.active = top.parent && !top.active
+		case "#endif":
+			if len(stack) == 0 {
+				return "", galaerr.NewSyntaxError(lineNum, 0, "#endif without #if")
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if active {
+				continue
+			}
+		}
+		lines[i] = blankLine(line)
+	}
+	if len(stack) > 0 {
+		return "", galaerr.NewSyntaxError(stack[len(stack)-1].line, 0, "#if without #endif")
+	}
+	return strings.Join(lines, ""), nil
+}
+
+// parseDirective returns the directive on line, if any, and the text after it.
+func parseDirective(line string) (directive, arg string) {
+	text := strings.TrimSpace(line)
+	for _, d := range []string{"#if", "#else", "#endif"} {
+		rest, ok := strings.CutPrefix(text, d)
+		if ok && (rest == "" || rest[0] == ' ' || rest[0] == '\t') {
+			return d, strings.TrimSpace(rest)
+		}
+	}
+	return "", ""
+}
+
+// parseFeatureCondition parses the condition of an #if: feature("name") or
+// !feature("name").
+func parseFeatureCondition(cond string) (name string, negated bool, err error) {
+	rest := cond
+	if r, ok := strings.CutPrefix(rest, "!"); ok {
+		negated = true
+		rest = strings.TrimSpace(r)
+	}
+	if r, ok := strings.CutPrefix(rest, "feature("); ok && strings.HasSuffix(r, ")") {
+		if name, err := strconv.Unquote(strings.TrimSpace(r[:len(r)-1])); err == nil && name != "" {
+			return name, negated, nil
+		}
+	}
+	return "", false, fmt.Errorf(`expected feature("name") after #if, got %q`, cond)
+}
+
+// blankLine returns line without its content, keeping the line break.
+func blankLine(line string) string {
+	if strings.HasSuffix(line, "\r\n") {
+		return "\r\n"
+	}
+	if strings.HasSuffix(line, "\n") {
+		return "\n"
+	}
+	return ""
+}
@@ -0,0 +1,97 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPruneFeatures(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		features []string
+		want     string
+		wantErr  string
+	}{
+		{
+			name:  "no directives",
+			input: "package main\n\nfunc f() int = 1\n",
+			want:  "package main\n\nfunc f() int = 1\n",
+		},
+		{
+			name:  "disabled block is blanked",
+			input: "package main\n\n#if feature(\"exp\")\nfunc f() int = 1\n#endif\nfunc g() int = 2\n",
+			want:  "package main\n\n\n\n\nfunc g() int = 2\n",
+		},
+		{
+			name:     "enabled block is kept",
+			input:    "package main\n\n#if feature(\"exp\")\nfunc f() int = 1\n#endif\n",
+			features: []string{"exp"},
+			want:     "package main\n\n\nfunc f() int = 1\n\n",
+		},
+		{
+			name:  "else branch",
+			input: "#if feature(\"exp\")\nfast\n#else\nslow\n#endif\n",
+			want:  "\n\n\nslow\n\n",
+		},
+		{
+			name:     "negated condition",
+			input:    "#if !feature(\"exp\")\nslow\n#else\nfast\n#endif\n",
+			features: []string{"exp"},
+			want:     "\n\n\nfast\n\n",
+		},
+		{
+			name:     "nested block in a disabled branch stays disabled",
+			input:    "#if feature(\"a\")\n#if feature(\"b\")\nx\n#else\ny\n#endif\n#endif\n",
+			features: []string{"b"},
+			want:     "\n\n\n\n\n\n\n",
+		},
+		{
+			name:     "nested blocks",
+			input:    "#if feature(\"a\")\n  #if feature(\"b\")\n  x\n  #else\n  y\n  #endif\n#endif\n",
+			features: []string{"a"},
+			want:     "\n\n\n\n  y\n\n\n",
+		},
+		{
+			name:  "shebang is left alone",
+			input: "#!/usr/bin/env gala\nprintln(1)\n",
+			want:  "#!/usr/bin/env gala\nprintln(1)\n",
+		},
+		{
+			name:    "unterminated block",
+			input:   "package main\n\n#if feature(\"exp\")\nfunc f() int = 1\n",
+			wantErr: "line 3:0 #if without #endif",
+		},
+		{
+			name:    "endif without if",
+			input:   "package main\n#endif\n",
+			wantErr: "line 2:0 #endif without #if",
+		},
+		{
+			name:    "second else",
+			input:   "#if feature(\"exp\")\n#else\n#else\n#endif\n",
+			wantErr: "line 3:0 second #else for the #if on line 1",
+		},
+		{
+			name:    "malformed condition",
+			input:   "#if exp\n#endif\n",
+			wantErr: `line 1:0 expected feature("name") after #if, got "exp"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewAntlrGalaParserWithFeatures(tt.features)
+			got, err := pruneFeatures(tt.input, p.features)
+			if tt.wantErr != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tt.wantErr)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,553 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"martianoff/gala/internal/parser/grammar"
+
+	"github.com/antlr4-go/antlr/v4"
+)
+
+// This file contains the formatter behind gala fmt, which rewrites a file in
+// the canonical layout:
+//
+//   - four spaces of indentation for each line that leaves brackets open, or
+//     an expression that goes on on the next line
+//   - opening braces on the line of the declaration or statement they belong to
+//   - the => of the case clauses of a match, the types of struct fields and
+//     trailing comments aligned over consecutive lines
+//   - one space around assignments, comparisons, logical operators and =>,
+//     after commas and keywords, and none inside brackets
+//   - at most one blank line in a row, and one after the package clause and
+//     after the imports
+//
+// Line breaks stay where the author put them. Comments are kept, and #if
+// feature directives stay in the first column, so every branch is formatted.
+// Functions: Format, formatterTokens, blankDirectives, formatTokens
+
+// Format returns the source file input in the canonical layout. It fails if
+// input does not parse.
+func Format(input string) (string, error) {
+	if _, err := NewAntlrGalaParser().parse(input, false); err != nil {
+		return "", err
+	}
+	toks, err := formatterTokens(input)
+	if err != nil {
+		return "", err
+	}
+	out := formatTokens([]rune(input), toks)
+
+	// The layout must only change the space between the tokens
+	check, err := formatterTokens(out)
+	if err != nil {
+		return "", err
+	}
+	if len(check) != len(toks) {
+		return "", fmt.Errorf("formatting changed the number of tokens from %d to %d", len(toks), len(check))
+	}
+	for i := range toks {
+		if check[i].text != toks[i].text {
+			return "", fmt.Errorf("formatting changed token %q to %q", toks[i].text, check[i].text)
+		}
+	}
+	return out, nil
+}
+
+// formatterTokens returns the tokens of input, directives and a leading #!
+// line aside.
+func formatterTokens(input string) ([]fmtToken, error) {
+	errorListener := &GalaErrorListener{}
+	lexer := grammar.NewgalaLexer(antlr.NewInputStream(blankDirectives(blankShebang(input))))
+	lexer.RemoveErrorListeners()
+	lexer.AddErrorListener(errorListener)
+
+	var toks []fmtToken
+	for tok := lexer.NextToken(); tok.GetTokenType() != antlr.TokenEOF; tok = lexer.NextToken() {
+		toks = append(toks, fmtToken{text: tok.GetText(), start: tok.GetStart(), stop: tok.GetStop()})
+	}
+	if len(errorListener.Errors) > 0 {
+		return nil, errorListener.Errors[0]
+	}
+	return toks, nil
+}
+
+// blankDirectives returns input with the #if, #else and #endif lines
+// replaced by spaces, keeping the offsets of the runes after them.
+func blankDirectives(input string) string {
+	if !strings.Contains(input, "#") {
+		return input
+	}
+	lines := strings.SplitAfter(input, "\n")
+	for i, line := range lines {
+		if directive, _ := parseDirective(line); directive != "" {
+			content := strings.TrimRight(line, "\r\n")
+			lines[i] = strings.Repeat(" ", utf8.RuneCountInString(content)) + line[len(content):]
+		}
+	}
+	return strings.Join(lines, "")
+}
+
+// fmtToken is a token of the formatted file, located by rune offsets.
+type fmtToken struct {
+	text        string
+	start, stop int // offsets of the first and the last rune
+}
+
+// fmtLine is a line of formatted output.
+type fmtLine struct {
+	indent  int
+	text    string
+	raw     bool         // a directive, printed in the first column
+	comment bool         // text ends with a // comment
+	cols    [numCols]int // byte offsets in text of the aligned columns, or -1
+	groups  [numCols]int // consecutive lines of a group align a column
+}
+
+// Columns aligned across consecutive lines.
+const (
+	colArrow = iota // the => of a case clause
+	colField        // the type of a struct field
+	colNote         // a trailing comment
+	numCols
+)
+
+func newLine(indent int, text string) fmtLine {
+	return fmtLine{indent: indent, text: text, cols: [numCols]int{-1, -1, -1}}
+}
+
+func (l fmtLine) blank() bool {
+	return l.text == "" && !l.raw
+}
+
+// fmtOpen is an open bracket, or an expression that goes on on the next
+// line, which indents the lines after the one it was opened on.
+type fmtOpen struct {
+	text   string // "{", "(" or "[", or "" for a continued expression
+	line   int    // output line it was opened on
+	id     int
+	chain  bool // continued by lines starting with . or else, not by an operator
+	ifCond bool // the parenthesized condition of an if
+	imp    bool // the parentheses of an import declaration
+	fields bool // the braces of a struct type
+}
+
+// formatter lays out the tokens of a file line by line. Line breaks stay
+// where the author put them, except before an opening brace; indentation and
+// the spacing between tokens are recomputed.
+type formatter struct {
+	src   []rune
+	toks  []fmtToken
+	out   []fmtLine
+	cur   *fmtLine // line being built, nil between lines
+	stack []fmtOpen
+	ids   int
+
+	prev      string // last token
+	prevLine  int    // output line of the last token
+	continued bool   // the line of the last token has ended
+	closedIf  bool   // the last token closed the condition of an if
+
+	caseDepth int // stack depth of the case clause whose => is due, or -1
+	caseMatch int // block of that case clause
+	lineToks  int // tokens on the current line
+
+	afterPackage bool // the package clause is being written
+	inImport     bool // an import declaration is being written
+	forceBlank   bool // a blank line must follow the package clause
+	importsEnded bool // a blank line must follow unless another import does
+}
+
+// continuations end a line whose expression goes on on the next line.
+var continuations = map[string]bool{
+	"=": true, ":=": true, "=>": true, "+": true, "-": true, "*": true, "/": true, "%": true,
+	"&&": true, "||": true, "==": true, "!=": true, "<": true, ">": true, "<=": true, ">=": true,
+	"|": true, "&": true, "^": true, "&^": true, "<<": true, ">>": true,
+	"+=": true, "-=": true, "*=": true, "/=": true, "<-": true, "else": true,
+}
+
+// spacedOps are written with a space on both sides.
+var spacedOps = map[string]bool{
+	"=": true, ":=": true, "=>": true, "==": true, "!=": true, "<=": true, ">=": true,
+	"<": true, ">": true, "&&": true, "||": true, "+=": true, "-=": true, "*=": true, "/=": true,
+}
+
+// keywords are followed by a space.
+var keywords = map[string]bool{
+	"if": true, "for": true, "case": true, "return": true, "match": true, "else": true,
+	"yield": true, "val": true, "var": true, "import": true, "package": true, "type": true,
+	"range": true, "sealed": true, "struct": true, "interface": true, "newtype": true,
+	"require": true, "private": true, "internal": true,
+}
+
+// formatTokens returns src, whose tokens are toks, in the canonical layout.
+func formatTokens(src []rune, toks []fmtToken) string {
+	f := &formatter{src: src, toks: toks, caseDepth: -1}
+	end := 0
+	for i, tok := range toks {
+		f.gap(src[end:tok.start], i)
+		f.token(i)
+		end = tok.stop + 1
+	}
+	f.gap(src[end:], len(toks))
+	f.endLine()
+	for col := range numCols {
+		f.align(col)
+	}
+	return f.String()
+}
+
+// gap writes the comments and directives between the last token and token
+// i, which is len(f.toks) at the end of the file, and breaks the line where
+// the source does.
+func (f *formatter) gap(gap []rune, i int) {
+	next := ""
+	if i < len(f.toks) {
+		next = f.toks[i].text
+	}
+	if f.inImport && !f.top().imp && strings.ContainsRune(string(gap), '\n') {
+		f.inImport, f.importsEnded = false, true
+	}
+	force := f.forceBlank || f.importsEnded && next != "import"
+	f.forceBlank, f.importsEnded = false, false
+
+	newlines, spaced, comments := 0, false, false
+	for j := 0; j < len(gap); j++ {
+		r := gap[j]
+		switch {
+		case r == '\n':
+			newlines++
+		case unicode.IsSpace(r):
+			spaced = true
+		default:
+			k := commentEnd(gap, j)
+			text := string(gap[j:k])
+			comments = true
+			if newlines == 0 && f.cur != nil && r != '#' {
+				if strings.HasPrefix(text, "//") {
+					f.cur.cols[colNote], f.cur.groups[colNote] = len(f.cur.text)+1, 1
+				}
+				f.cur.text += " " + text
+			} else {
+				f.breakLines(newlines, force)
+				force = false
+				if r == '#' {
+					f.out = append(f.out, fmtLine{text: text, raw: true})
+					newlines, spaced = 0, false
+					j = k - 1
+					continue
+				}
+				f.startLine(f.level(f.stack))
+				f.cur.text = text
+			}
+			f.cur.comment = strings.HasPrefix(text, "//")
+			newlines, spaced = 0, false
+			j = k - 1
+		}
+	}
+	if next == "" {
+		return
+	}
+	join := newlines == 0 || next == "{" && !comments && !opensList(f.prev)
+	if f.cur != nil && !f.cur.comment && join {
+		if f.spaced(next, spaced || newlines > 0) {
+			f.cur.text += " "
+		}
+		return
+	}
+	f.breakLines(newlines, force)
+}
+
+// commentEnd returns the end of the comment or directive at gap[i].
+func commentEnd(gap []rune, i int) int {
+	if gap[i] == '/' && i+1 < len(gap) && gap[i+1] == '*' {
+		for j := i + 2; j+1 < len(gap); j++ {
+			if gap[j] == '*' && gap[j+1] == '/' {
+				return j + 2
+			}
+		}
+		return len(gap)
+	}
+	j := i
+	for j < len(gap) && gap[j] != '\n' {
+		j++
+	}
+	for j > i && unicode.IsSpace(gap[j-1]) {
+		j--
+	}
+	return j
+}
+
+// breakLines ends the current line, followed by a blank line if the source
+// has one there or one is required.
+func (f *formatter) breakLines(newlines int, force bool) {
+	f.endLine()
+	if !f.continued {
+		f.continueLine()
+		f.continued = true
+	}
+	if (newlines > 1 || force) && len(f.out) > 0 && !f.out[len(f.out)-1].blank() {
+		f.out = append(f.out, newLine(0, ""))
+	}
+}
+
+// continueLine indents the lines after the line of the last token if its
+// expression goes on, and ends the indentation of an expression that ended.
+func (f *formatter) continueLine() {
+	if continuations[f.prev] || f.prev == ")" && f.closedIf {
+		if top := f.top(); top.text != "" || top.chain || top.id == 0 {
+			f.push(fmtOpen{line: f.prevLine})
+		}
+		return
+	}
+	for top := f.top(); top.id != 0 && top.text == "" && !top.chain; top = f.top() {
+		f.stack = f.stack[:len(f.stack)-1]
+	}
+}
+
+// token writes token i.
+func (f *formatter) token(i int) {
+	text := f.toks[i].text
+	if f.cur == nil {
+		f.chainLine(text)
+		f.startLine(f.level(f.leadingClosers(i)))
+	}
+	f.closedIf = false
+	if text == ")" || text == "]" || text == "}" {
+		f.close()
+	}
+	line := len(f.out)
+	if text == "=>" && f.caseDepth == len(f.stack) && f.cur.cols[colArrow] < 0 {
+		f.cur.cols[colArrow], f.cur.groups[colArrow] = len(f.cur.text), f.caseMatch
+		f.caseDepth = -1
+	}
+	if top := f.top(); top.fields && f.lineToks == 1 && isIdent(f.prev) && text != "," {
+		f.cur.cols[colField], f.cur.groups[colField] = len(f.cur.text), top.id
+	}
+	f.cur.text += text
+	f.lineToks++
+
+	switch {
+	case text == "case":
+		f.caseDepth, f.caseMatch = len(f.stack), f.top().id
+	case text == "package" && len(f.stack) == 0:
+		f.afterPackage = true
+	case f.afterPackage:
+		f.afterPackage, f.forceBlank = false, true
+	case text == "import" && len(f.stack) == 0:
+		f.inImport = true
+	}
+	if text == "(" || text == "[" || text == "{" {
+		f.push(fmtOpen{text: text, line: line, ifCond: text == "(" && f.prev == "if", imp: text == "(" && f.prev == "import", fields: text == "{" && f.prev == "struct"})
+	}
+	f.prev, f.prevLine, f.continued = text, line, false
+}
+
+// chainLine starts or ends the indentation of lines continuing an
+// expression with a method call or an else branch, given the first token of
+// a line.
+func (f *formatter) chainLine(text string) {
+	top := f.top()
+	if top.imp {
+		return
+	}
+	if text == "." || text == "?." || text == "else" && f.prev != "}" {
+		if !top.chain {
+			f.push(fmtOpen{line: f.prevLine, chain: true})
+		}
+	} else if top.chain {
+		f.stack = f.stack[:len(f.stack)-1]
+	}
+}
+
+// close pops the bracket closed by the current token, and the continued
+// expressions inside it.
+func (f *formatter) close() {
+	stack := popClosed(f.stack)
+	f.closedIf = len(stack) < len(f.stack) && f.stack[len(stack)].ifCond
+	f.stack = stack
+	if f.inImport && len(f.stack) == 0 {
+		f.inImport, f.importsEnded = false, true
+	}
+}
+
+func popClosed(stack []fmtOpen) []fmtOpen {
+	for len(stack) > 0 && stack[len(stack)-1].text == "" {
+		stack = stack[:len(stack)-1]
+	}
+	if len(stack) > 0 {
+		stack = stack[:len(stack)-1]
+	}
+	return stack
+}
+
+// leadingClosers returns the stack after the closing brackets that start the
+// line at token i, which the line is indented as.
+func (f *formatter) leadingClosers(i int) []fmtOpen {
+	stack := f.stack
+	for k := i; k < len(f.toks) && isCloser(f.toks[k].text); k++ {
+		if k > i && strings.ContainsRune(string(f.src[f.toks[k-1].stop+1:f.toks[k].start]), '\n') {
+			break
+		}
+		stack = popClosed(stack)
+	}
+	return stack
+}
+
+func isCloser(text string) bool {
+	return text == ")" || text == "]" || text == "}"
+}
+
+// level returns the indentation of a line with stack open: one level for
+// each earlier line that left brackets or expressions open.
+func (f *formatter) level(stack []fmtOpen) int {
+	level, last := 0, -1
+	for _, open := range stack {
+		if open.line != last {
+			level++
+			last = open.line
+		}
+	}
+	return level
+}
+
+func (f *formatter) push(open fmtOpen) {
+	f.ids++
+	open.id = f.ids
+	f.stack = append(f.stack, open)
+}
+
+// top returns the innermost open bracket or expression, or the zero fmtOpen.
+func (f *formatter) top() fmtOpen {
+	if len(f.stack) == 0 {
+		return fmtOpen{}
+	}
+	return f.stack[len(f.stack)-1]
+}
+
+func (f *formatter) startLine(indent int) {
+	l := newLine(indent, "")
+	f.cur = &l
+	f.lineToks = 0
+}
+
+func (f *formatter) endLine() {
+	if f.cur != nil {
+		f.out = append(f.out, *f.cur)
+		f.cur = nil
+	}
+}
+
+// spaced reports whether a space separates the last token from next on the
+// same line; hadSpace tells whether the source has one.
+func (f *formatter) spaced(next string, hadSpace bool) bool {
+	prev := f.prev
+	switch {
+	case next == "," || next == ";" || next == ")" || next == "]" || next == "?.":
+		return false
+	case prev == "(" || prev == "[" || prev == "?.":
+		return false
+	case prev == "," || prev == ";":
+		return true
+	case prev == ".":
+		return hadSpace && !isIdent(next)
+	case next == ".":
+		// 1 .String() must not become the float 1.
+		return hadSpace && isDigit(prev) || keywords[prev]
+	case spacedOps[prev] || spacedOps[next] || keywords[prev]:
+		return true
+	case isWord(prev) && isWord(next):
+		return true
+	case next == "(" && isIdent(prev) && prev != "func":
+		return false
+	case next == "{" && prev == ")":
+		return true
+	}
+	return hadSpace
+}
+
+// opensList reports whether a token opens a list, after which a brace on the
+// next line stays there.
+func opensList(text string) bool {
+	return text == "(" || text == "[" || text == "{" || text == ","
+}
+
+func isWord(text string) bool {
+	r, _ := utf8.DecodeRuneInString(text)
+	return r == '_' || r == '"' || r == '`' || r == '\'' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func isIdent(text string) bool {
+	r, _ := utf8.DecodeRuneInString(text)
+	return (r == '_' || unicode.IsLetter(r)) && !keywords[text]
+}
+
+func isDigit(text string) bool {
+	r, _ := utf8.DecodeRuneInString(text)
+	return unicode.IsDigit(r)
+}
+
+// align pads column col of consecutive lines of the same group and
+// indentation to the same width.
+func (f *formatter) align(col int) {
+	for i := 0; i < len(f.out); {
+		j := i
+		for j < len(f.out) && f.alignable(j, col) && f.out[j].groups[col] == f.out[i].groups[col] && f.out[j].indent == f.out[i].indent {
+			j++
+		}
+		if j-i > 1 {
+			width := 0
+			for _, l := range f.out[i:j] {
+				width = max(width, utf8.RuneCountInString(strings.TrimRight(l.text[:l.cols[col]], " ")))
+			}
+			for k := i; k < j; k++ {
+				l := &f.out[k]
+				at := l.cols[col]
+				head := strings.TrimRight(l.text[:at], " ")
+				padding := strings.Repeat(" ", width-utf8.RuneCountInString(head)+1)
+				l.text = head + padding + l.text[at:]
+				for c := range l.cols {
+					if l.cols[c] >= at {
+						l.cols[c] += len(head) + len(padding) - at
+					}
+				}
+			}
+		}
+		i = max(j, i+1)
+	}
+}
+
+// alignable reports whether line i has column col, and the line ends after
+// it: a case clause whose body continues on the next line does not align.
+func (f *formatter) alignable(i, col int) bool {
+	l := f.out[i]
+	if l.cols[col] < 0 || strings.Contains(l.text, "\n") {
+		return false
+	}
+	code := l.text
+	if note := l.cols[colNote]; note >= 0 {
+		code = strings.TrimRight(code[:note], " ")
+	}
+	return col != colArrow || !strings.HasSuffix(code, "=>") && !strings.HasSuffix(code, "{")
+}
+
+func (f *formatter) String() string {
+	lines := f.out
+	for len(lines) > 0 && lines[0].blank() {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && lines[len(lines)-1].blank() {
+		lines = lines[:len(lines)-1]
+	}
+	var sb strings.Builder
+	for _, l := range lines {
+		if !l.raw && l.text != "" {
+			sb.WriteString(strings.Repeat("    ", l.indent))
+		}
+		sb.WriteString(l.text)
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
@@ -0,0 +1,148 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "indentation and spacing",
+			input: "package main\n\nfunc add(a int,b int) int {\n\tval sum=a+b\n  return sum\n}\n",
+			want:  "package main\n\nfunc add(a int, b int) int {\n    val sum = a+b\n    return sum\n}\n",
+		},
+		{
+			name:  "braces join their line",
+			input: "package main\n\nfunc f(x int) int {\n    if (x > 0)\n    {\n        return 1\n    }\n    return 0\n}\n",
+			want:  "package main\n\nfunc f(x int) int {\n    if (x > 0) {\n        return 1\n    }\n    return 0\n}\n",
+		},
+		{
+			name:  "blank lines",
+			input: "package main\nimport \"fmt\"\nfunc a() = fmt.Println(1)\n\n\n\nfunc b() = fmt.Println(2)\n\n",
+			want:  "package main\n\nimport \"fmt\"\n\nfunc a() = fmt.Println(1)\n\nfunc b() = fmt.Println(2)\n",
+		},
+		{
+			name: "match alignment",
+			input: `package main
+
+func name(n int) string = n match {
+  case 1 => "one"
+  case 100 => "hundred"
+  case _ => {
+    "many"
+  }
+}
+`,
+			want: `package main
+
+func name(n int) string = n match {
+    case 1   => "one"
+    case 100 => "hundred"
+    case _ => {
+        "many"
+    }
+}
+`,
+		},
+		{
+			name: "struct fields and trailing comments",
+			input: `package main
+
+type Person struct {
+    Name string // full name
+    Age int // in years
+}
+`,
+			want: `package main
+
+type Person struct {
+    Name string // full name
+    Age  int    // in years
+}
+`,
+		},
+		{
+			name: "continued expressions",
+			input: `package main
+
+func f(xs List[int]) int {
+    val total = xs
+    .Map((x) => x * 2)
+    .Filter((x) => {
+    x > 2
+    })
+    .Size()
+    val sum = total +
+    1
+    return sum
+}
+`,
+			want: `package main
+
+func f(xs List[int]) int {
+    val total = xs
+        .Map((x) => x * 2)
+        .Filter((x) => {
+            x > 2
+        })
+        .Size()
+    val sum = total +
+        1
+    return sum
+}
+`,
+		},
+		{
+			name: "feature directives",
+			input: `package main
+
+  #if feature("exp")
+func f() int = 1
+  #else
+func f() int = 2
+  #endif
+`,
+			want: `package main
+
+#if feature("exp")
+func f() int = 1
+#else
+func f() int = 2
+#endif
+`,
+		},
+		{
+			name:  "shebang line is kept",
+			input: "#!/usr/bin/env gala\npackage main\n\nval x=1\n",
+			want:  "#!/usr/bin/env gala\npackage main\n\nval x = 1\n",
+		},
+		{
+			name:  "grouped imports",
+			input: "package main\n\nimport (\n\"fmt\"\n. \"martianoff/gala/collection_immutable\"\n)\nfunc main() = fmt.Println(1)\n",
+			want:  "package main\n\nimport (\n    \"fmt\"\n    . \"martianoff/gala/collection_immutable\"\n)\n\nfunc main() = fmt.Println(1)\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Format(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+
+			again, err := Format(got)
+			assert.NoError(t, err)
+			assert.Equal(t, got, again, "formatting is not idempotent")
+		})
+	}
+}
+
+func TestFormatSyntaxError(t *testing.T) {
+	_, err := Format("package main\n\nfunc f( int = 1\n")
+	assert.Error(t, err)
+}
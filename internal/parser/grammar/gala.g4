@@ -5,15 +5,42 @@ sourceFile: packageClause importDeclaration* topLevelDeclaration* EOF;
 
 packageClause: PACKAGE identifier;
 
+// Script files have no package clause; their bare statements are wrapped into a
+// synthesized main function (see parser.wrapScript).
+scriptFile: importDeclaration* scriptItem* EOF;
+
+scriptItem
+    : annotation* visibilityModifier?
+      ( functionDeclaration
+      | typeDeclaration
+      | structShorthandDeclaration
+      | sealedTypeDeclaration
+      | newtypeDeclaration
+      )
+    | statement
+    ;
+
 topLevelDeclaration
-    : valDeclaration
-    | varDeclaration
-    | functionDeclaration
-    | typeDeclaration
-    | structShorthandDeclaration
-    | sealedTypeDeclaration
+    : annotation* visibilityModifier?
+      ( valDeclaration
+      | varDeclaration
+      | functionDeclaration
+      | typeDeclaration
+      | structShorthandDeclaration
+      | sealedTypeDeclaration
+      | newtypeDeclaration
+      )
+    | initBlock
     ;
 
+// Runs once when the package is initialized, after its package-level vals and
+// vars; compiled to a Go init function.
+initBlock: INIT block;
+
+annotation: '@' identifier ('(' STRING ')')?;
+
+visibilityModifier: PRIVATE | INTERNAL;
+
 structShorthandDeclaration: 'struct' identifier parameters;
 
 sealedTypeDeclaration: SEALED 'type' identifier (typeParameters)? '{' sealedCase+ '}';
@@ -21,6 +48,9 @@ sealedCase: CASE identifier '(' sealedCaseFieldList? ')';
 sealedCaseFieldList: sealedCaseField (',' sealedCaseField)*;
 sealedCaseField: identifier type;
 
+// newtype UserId = string: a distinct type with the representation of string.
+newtypeDeclaration: NEWTYPE identifier '=' type;
+
 declaration
     : valDeclaration
     | varDeclaration
@@ -34,14 +64,19 @@ declaration
 
 importDeclaration: 'import' ( importSpec | '(' importSpec* ')' );
 
-importSpec: ('.' | identifier)? STRING;
+importSpec: ('.' | identifier)? STRING importSelectors?;
+
+// Selective import: import "pkg".{Point, Origin => Zero}
+importSelectors: '.' '{' importSelector (',' importSelector)* ','? '}';
+importSelector: identifier ('=>' identifier)?;
 
 typeDeclaration: 'type' identifier (typeParameters)? (structType | interfaceType | typeAlias);
 
-typeAlias: identifier | type;
+// type Dollars int defines a type; type UserId = string is an alias
+typeAlias: (alias='=')? type;
 
 structType: 'struct' '{' structField* '}';
-structField: (VAL | VAR)? identifier type (STRING)?;
+structField: annotation* (VAL | VAR)? identifier type (STRING)?;
 
 interfaceType: 'interface' '{' methodSpec* '}';
 methodSpec: identifier (typeParameters)? signature;
@@ -64,7 +99,9 @@ parameterList: parameter (',' parameter)*;
 // - Named with type: "x int", "val x int", "x ...int"
 // - Named without type: "x" (type inferred)
 // - Type only (for function types): "int", "Option[T]", "...int"
-parameter: (VAL | VAR)? (identifier ELLIPSIS? type? | ELLIPSIS? type);
+// - By-name: "x => T", evaluated each time it is read
+// Fields of shorthand structs may add an invariant: "Name string require Name != \"\"".
+parameter: (VAL | VAR)? (identifier ELLIPSIS? type? | identifier byName='=>' type | ELLIPSIS? type) (REQUIRE expression)?;
 
 ELLIPSIS: '...';
 
@@ -81,12 +118,14 @@ statement
 
 returnStatement: 'return' expression?;
 
-ifStatement: 'if' (simpleStatement ';')? expression block ('else' (block | ifStatement))?;
+ifStatement: 'if' (ifLetCondition | (simpleStatement ';')? expression) block ('else' (block | ifStatement))?;
+ifLetCondition: '(' VAL pattern '=' expression ')';  // if (val Some(x) = opt) binds x in the then-branch
 
-forStatement: 'for' (forClause | rangeClause | forCondition)? block;
+forStatement: 'for' (forClause | rangeClause | generatorClause | forCondition)? block;
 forClause: simpleStatement? ';' expression? ';' simpleStatement?;
 forCondition: expression;
 rangeClause: (identifierList (':=' | '=') )? 'range' expression;
+generatorClause: identifier '<-' expression;  // for x <- xs binds each element of xs
 
 simpleStatement
     : incDecStmt
@@ -143,6 +182,7 @@ postfixExpr
 
 postfixSuffix
     : '.' identifier
+    | '?.' identifier
     | '(' argumentList? ')'
     | '[' expressionList ']'
     ;
@@ -152,6 +192,7 @@ primaryExpr
     | primary
     | ifExpression
     | partialFunctionLiteral
+    | forComprehension
     ;
 
 partialFunctionLiteral: '{' caseClause+ '}';
@@ -181,6 +222,11 @@ pattern
 
 ifExpression: 'if' '(' expression ')' expression 'else' expression;
 
+// for { x <- xs; y <- ys if x < y } yield (x, y); the parser desugars it into
+// FlatMap, Filter and Map calls before the file is parsed for good
+forComprehension: 'for' '{' enumerator (';'? enumerator)* ';'? '}' 'yield' expression;
+enumerator: identifier '<-' source=expression (IF guard=expression)?;
+
 type
     : qualifiedIdentifier (typeArguments)?
     | '[' ']' type // slice
@@ -193,7 +239,8 @@ typeArguments: '[' typeList ']';
 typeList: type (',' type)*;
 
 qualifiedIdentifier: identifier ('.' identifier)*;
-identifier: IDENTIFIER;
+// init is only a keyword at the start of a top-level declaration
+identifier: IDENTIFIER | INIT;
 
 literal
     : INT_LIT
@@ -220,9 +267,15 @@ ELSE: 'else';
 FOR: 'for';
 RANGE: 'range';
 RETURN: 'return';
+YIELD: 'yield';
 IMPORT: 'import';
 PACKAGE: 'package';
 SEALED: 'sealed';
+NEWTYPE: 'newtype';
+REQUIRE: 'require';
+PRIVATE: 'private';
+INTERNAL: 'internal';
+INIT: 'init';
 COLON: ':';
 
 binaryOp: '||' | '&&' | '==' | '!=' | '<' | '<=' | '>' | '>=' | '+' | '-' | '|' | '^' | '*' | '/' | '%' | '<<' | '>>' | '&' | '&^';
@@ -232,8 +285,9 @@ IDENTIFIER: [a-zA-Z_] [a-zA-Z0-9_]*;
 INT_LIT: [0-9]+;
 FLOAT_LIT: [0-9]+ '.' [0-9]* | '.' [0-9]+;
 STRING: '"' (~["\r\n\\] | '\\' .)* '"';
-CHAR_LIT: '\'' (~['\r\n\\] | '\\' .) '\'';
+CHAR_LIT: '\'' (~['\r\n\\] | '\\' ('x' HEX_DIGIT HEX_DIGIT | 'u' HEX_DIGIT HEX_DIGIT HEX_DIGIT HEX_DIGIT | 'U' HEX_DIGIT HEX_DIGIT HEX_DIGIT HEX_DIGIT HEX_DIGIT HEX_DIGIT HEX_DIGIT HEX_DIGIT | [0-7] [0-7] [0-7] | ~[xuU0-7])) '\'';
 RAW_STRING: '`' ~[`]* '`';
+fragment HEX_DIGIT: [0-9a-fA-F];
 WS: [ \t\r\n]+ -> skip;
 COMMENT: '//' ~[\r\n]* -> skip;
 MULTILINE_COMMENT: '/*' .*? '*/' -> skip;
@@ -10,13 +10,45 @@ import (
 )
 
 type AntlrGalaParser struct {
+	features map[string]bool // Features whose #if feature blocks are compiled
 }
 
 func NewAntlrGalaParser() *AntlrGalaParser {
 	return &AntlrGalaParser{}
 }
 
+// NewAntlrGalaParserWithFeatures creates a parser that compiles the
+// #if feature("name") blocks of the given features.
+func NewAntlrGalaParserWithFeatures(features []string) *AntlrGalaParser {
+	p := &AntlrGalaParser{features: make(map[string]bool)}
+	for _, f := range features {
+		p.features[f] = true
+	}
+	return p
+}
+
 func (p *AntlrGalaParser) Parse(input string) (antlr.Tree, error) {
+	return p.parse(input, true)
+}
+
+// parse parses input, checking the empty lines required after the package
+// clause and the imports if checkLayout is set.
+func (p *AntlrGalaParser) parse(input string, checkLayout bool) (antlr.Tree, error) {
+	input, err := pruneFeatures(blankShebang(input), p.features)
+	if err != nil {
+		return nil, &galaerr.MultiError{Errors: []error{err}}
+	}
+
+	script := isScript(input)
+	if script {
+		wrapped, err := wrapScript(input)
+		if err != nil {
+			return nil, err
+		}
+		input = wrapped
+	}
+	input = desugarComprehensions(input)
+
 	is := antlr.NewInputStream(input)
 	lexer := grammar.NewgalaLexer(is)
 	stream := antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel)
@@ -36,6 +68,12 @@ func (p *AntlrGalaParser) Parse(input string) (antlr.Tree, error) {
 		return nil, &galaerr.MultiError{Errors: errorListener.Errors}
 	}
 
+	// The synthesized script wrapper deliberately keeps everything on the
+	// script's own lines, so the layout rules only apply to regular files.
+	if script || !checkLayout {
+		return tree, nil
+	}
+
 	if err := p.checkEmptyLines(input, tree); err != nil {
 		return nil, &galaerr.MultiError{Errors: []error{err}}
 	}
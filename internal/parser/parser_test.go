@@ -137,8 +137,37 @@ type Box struct {
 			wantErr: false,
 		},
 		{
-			name:    "Missing package declaration",
+			name:    "Missing package declaration is a script",
 			input:   `val x = 10`,
+			wantErr: false,
+		},
+		{
+			name: "Shebang line is ignored",
+			input: `#!/usr/bin/env gala
+package main
+
+val x = 10`,
+			wantErr: false,
+		},
+		{
+			name: "Shebang line in a script",
+			input: `#!/usr/bin/env gala
+import "fmt"
+
+fmt.Println("hi")`,
+			wantErr: false,
+		},
+		{
+			name: "Shebang after the first line is rejected",
+			input: `package main
+
+#!/usr/bin/env gala
+val x = 10`,
+			wantErr: true,
+		},
+		{
+			name:    "Script with syntax error",
+			input:   `val x = `,
 			wantErr: true,
 		},
 	}
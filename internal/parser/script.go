@@ -0,0 +1,111 @@
+package parser
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"martianoff/gala/galaerr"
+	"martianoff/gala/internal/parser/grammar"
+
+	"github.com/antlr4-go/antlr/v4"
+)
+
+// This file contains script mode: a file without a package clause is a program
+// whose bare statements form the body of main, as in `gala run script.gala`.
+// Functions: blankShebang, isScript, wrapScript, blankOut
+
+// blankShebang returns input with an interpreter line such as
+// #!/usr/bin/env gala replaced by spaces when it is the first line, so that
+// a script marked executable runs directly. A #! anywhere else is left to the
+// lexer, which rejects it.
+func blankShebang(input string) string {
+	if !strings.HasPrefix(input, "#!") {
+		return input
+	}
+	end := strings.IndexAny(input, "\r\n")
+	if end < 0 {
+		end = len(input)
+	}
+	return strings.Repeat(" ", utf8.RuneCountInString(input[:end])) + input[end:]
+}
+
+// isScript reports whether input is a script, i.e. its first token is not the
+// package keyword. Empty input is not a script.
+func isScript(input string) bool {
+	lexer := grammar.NewgalaLexer(antlr.NewInputStream(input))
+	lexer.RemoveErrorListeners()
+	tok := lexer.NextToken()
+	return tok.GetTokenType() != antlr.TokenEOF && tok.GetText() != "package"
+}
+
+// wrapScript rewrites a script into the source of a regular main package.
+// Imports and declarations before the first statement stay where they are, and
+// everything from the first statement on becomes the body of func main. The
+// wrapper is added without inserting line breaks, so statements keep their
+// original lines in diagnostics. Declarations found between statements cannot
+// live inside main; they are moved after it.
+func wrapScript(input string) (string, error) {
+	errorListener := &GalaErrorListener{}
+	lexer := grammar.NewgalaLexer(antlr.NewInputStream(input))
+	lexer.RemoveErrorListeners()
+	lexer.AddErrorListener(errorListener)
+	parser := grammar.NewgalaParser(antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel))
+	parser.RemoveErrorListeners()
+	parser.AddErrorListener(errorListener)
+
+	script := parser.ScriptFile()
+	if len(errorListener.Errors) > 0 {
+		return "", &galaerr.MultiError{Errors: errorListener.Errors}
+	}
+
+	items := script.AllScriptItem()
+	first := len(items)
+	for i, item := range items {
+		if item.Statement() != nil {
+			first = i
+			break
+		}
+	}
+	if first == len(items) {
+		// Declarations only, e.g. a script that defines its own main.
+		return "package main " + input, nil
+	}
+
+	// Token positions are rune offsets into the input.
+	src := []rune(input)
+	bodyStart := items[first].GetStart().GetStart()
+	body := append([]rune(nil), src[bodyStart:]...)
+	var moved []string
+	for _, item := range items[first+1:] {
+		if item.Statement() != nil {
+			continue
+		}
+		start := item.GetStart().GetStart() - bodyStart
+		stop := item.GetStop().GetStop() - bodyStart
+		moved = append(moved, string(body[start:stop+1]))
+		blankOut(body[start : stop+1])
+	}
+
+	var sb strings.Builder
+	sb.WriteString("package main ")
+	sb.WriteString(string(src[:bodyStart]))
+	sb.WriteString("func main() { ")
+	sb.WriteString(string(body))
+	sb.WriteString("\n}\n")
+	for _, decl := range moved {
+		sb.WriteString("\n")
+		sb.WriteString(decl)
+		sb.WriteString("\n")
+	}
+	return sb.String(), nil
+}
+
+// blankOut replaces text with spaces, keeping line breaks so that the code
+// after it stays on the same lines.
+func blankOut(text []rune) {
+	for i, r := range text {
+		if r != '\n' && r != '\r' {
+			text[i] = ' '
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrapScript(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name: "statements become main",
+			input: `import "fmt"
+
+val name = "world"
+fmt.Println("hello " + name)
+`,
+			want: `package main import "fmt"
+
+func main() { val name = "world"
+fmt.Println("hello " + name)
+
+}
+`,
+		},
+		{
+			name: "declarations before statements stay in place",
+			input: `func square(x int) int = x * x
+
+println(square(3))`,
+			want: `package main func square(x int) int = x * x
+
+func main() { println(square(3))
+}
+`,
+		},
+		{
+			name: "declarations between statements move after main",
+			input: `println(twice(2))
+func twice(x int) int = x * 2
+println(3)`,
+			want: "package main func main() { println(twice(2))\n" +
+				strings.Repeat(" ", len("func twice(x int) int = x * 2")) + "\nprintln(3)\n}\n\nfunc twice(x int) int = x * 2\n",
+		},
+		{
+			name:  "declarations only",
+			input: `func main() { println(1) }`,
+			want:  `package main func main() { println(1) }`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.True(t, isScript(tt.input))
+			got, err := wrapScript(tt.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestIsScript(t *testing.T) {
+	assert.False(t, isScript("package main\n\nval x = 1"))
+	assert.False(t, isScript("// comment\npackage main\n\nval x = 1"))
+	assert.False(t, isScript(""))
+	assert.True(t, isScript("// comment\nprintln(1)"))
+	assert.True(t, isScript(blankShebang("#!/usr/bin/env gala\nprintln(1)")))
+	assert.False(t, isScript(blankShebang("#!/usr/bin/env gala\npackage main\n\nval x = 1")))
+}
+
+func TestBlankShebang(t *testing.T) {
+	assert.Equal(t, "                   \nprintln(1)", blankShebang("#!/usr/bin/env gala\nprintln(1)"))
+	assert.Equal(t, "package main\n#!/usr/bin/env gala\n", blankShebang("package main\n#!/usr/bin/env gala\n"))
+	assert.Equal(t, "  ", blankShebang("#!"))
+}
@@ -0,0 +1,307 @@
+// Package pgo reads pprof CPU profiles for profile-guided builds. It decodes
+// just enough of the profile.proto format to attribute sample weight to
+// functions; the profile itself is passed on to go build -pgo unchanged.
+package pgo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Profile is the sample weight of a pprof profile broken down by function.
+type Profile struct {
+	// Total is the weight of all samples.
+	Total int64
+	// Flat is the weight of the samples whose innermost frame is in a function,
+	// keyed by the fully qualified Go name, e.g. example.com/app.parse.
+	Flat map[string]int64
+}
+
+// Load reads a pprof profile, gzipped or not, from path.
+func Load(path string) (*Profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return p, nil
+}
+
+// Parse decodes a pprof profile, gzipped or not.
+func Parse(data []byte) (*Profile, error) {
+	if len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b {
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("not a pprof profile: %w", err)
+		}
+		if data, err = io.ReadAll(zr); err != nil {
+			return nil, fmt.Errorf("not a pprof profile: %w", err)
+		}
+	}
+
+	var (
+		sampleTypes   []int64 // string table index of the type of every sample value
+		defaultType   int64
+		samples       []sample
+		locationFuncs = make(map[uint64]uint64) // location id -> innermost function id
+		funcNames     = make(map[uint64]int64)  // function id -> string table index
+		strs          []string
+	)
+	err := eachField(data, func(num int, wire int, v uint64, b []byte) error {
+		switch num {
+		case 1: // sample_type
+			typ, err := parseValueType(b)
+			if err != nil {
+				return err
+			}
+			sampleTypes = append(sampleTypes, typ)
+		case 2: // sample
+			s, err := parseSample(b)
+			if err != nil {
+				return err
+			}
+			samples = append(samples, s)
+		case 4: // location
+			id, fn, err := parseLocation(b)
+			if err != nil {
+				return err
+			}
+			locationFuncs[id] = fn
+		case 5: // function
+			id, name, err := parseFunction(b)
+			if err != nil {
+				return err
+			}
+			funcNames[id] = name
+		case 6: // string_table
+			strs = append(strs, string(b))
+		case 14: // default_sample_type
+			defaultType = int64(v)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("not a pprof profile: %w", err)
+	}
+	if len(sampleTypes) == 0 {
+		return nil, errors.New("not a pprof profile: no sample types")
+	}
+
+	// Like pprof, use the default sample type, or else the last one (cpu
+	// nanoseconds rather than the sample count for CPU profiles)
+	valueIndex := len(sampleTypes) - 1
+	for i, typ := range sampleTypes {
+		if defaultType != 0 && typ == defaultType {
+			valueIndex = i
+		}
+	}
+	p := &Profile{Flat: make(map[string]int64)}
+	for _, s := range samples {
+		if valueIndex >= len(s.values) || len(s.locations) == 0 {
+			continue
+		}
+		weight := s.values[valueIndex]
+		p.Total += weight
+		if nameIdx, ok := funcNames[locationFuncs[s.locations[0]]]; ok && nameIdx >= 0 && nameIdx < int64(len(strs)) {
+			p.Flat[strs[nameIdx]] += weight
+		}
+	}
+	return p, nil
+}
+
+// HotFunctions returns the top-level functions whose flat share of the
+// profile is at least threshold (0.01 for 1%). Names are qualified with the
+// last element of the package path, e.g. app.parse, like the functions of a
+// GALA package named app. Methods and closures are left out.
+func (p *Profile) HotFunctions(threshold float64) map[string]bool {
+	hot := make(map[string]bool)
+	if p.Total == 0 {
+		return hot
+	}
+	for name, weight := range p.Flat {
+		if float64(weight)/float64(p.Total) < threshold {
+			continue
+		}
+		if short, ok := topLevelFuncName(name); ok {
+			hot[short] = true
+		}
+	}
+	return hot
+}
+
+// SortedNames returns the names of set in sorted order.
+func SortedNames(set map[string]bool) []string {
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// topLevelFuncName shortens the Go name of a top-level function, such as
+// example.com/app.parse, to app.parse. Methods (app.(*T).M, app.T.M) and
+// closures (app.parse.func1) are rejected.
+func topLevelFuncName(name string) (string, bool) {
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	pkg, fn, ok := strings.Cut(name, ".")
+	if !ok || pkg == "" || fn == "" || strings.ContainsAny(fn, ".()[]") {
+		return "", false
+	}
+	return pkg + "." + fn, true
+}
+
+type sample struct {
+	locations []uint64
+	values    []int64
+}
+
+func parseValueType(data []byte) (typ int64, err error) {
+	err = eachField(data, func(num int, wire int, v uint64, b []byte) error {
+		if num == 1 {
+			typ = int64(v)
+		}
+		return nil
+	})
+	return typ, err
+}
+
+func parseSample(data []byte) (sample, error) {
+	var s sample
+	err := eachField(data, func(num int, wire int, v uint64, b []byte) error {
+		switch num {
+		case 1:
+			ids, err := repeatedVarints(wire, v, b)
+			s.locations = append(s.locations, ids...)
+			return err
+		case 2:
+			values, err := repeatedVarints(wire, v, b)
+			for _, x := range values {
+				s.values = append(s.values, int64(x))
+			}
+			return err
+		}
+		return nil
+	})
+	return s, err
+}
+
+// parseLocation returns the id of a location and the function of its first
+// line, which is the innermost frame when calls were inlined.
+func parseLocation(data []byte) (id, fn uint64, err error) {
+	seenLine := false
+	err = eachField(data, func(num int, wire int, v uint64, b []byte) error {
+		switch num {
+		case 1:
+			id = v
+		case 4:
+			if seenLine {
+				return nil
+			}
+			seenLine = true
+			return eachField(b, func(num int, wire int, v uint64, b []byte) error {
+				if num == 1 {
+					fn = v
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	return id, fn, err
+}
+
+func parseFunction(data []byte) (id uint64, name int64, err error) {
+	err = eachField(data, func(num int, wire int, v uint64, b []byte) error {
+		switch num {
+		case 1:
+			id = v
+		case 2:
+			name = int64(v)
+		}
+		return nil
+	})
+	return id, name, err
+}
+
+// Protobuf wire types.
+const (
+	wireVarint = 0
+	wire64     = 1
+	wireBytes  = 2
+	wire32     = 5
+)
+
+// eachField calls fn for every field of the protobuf message in data with its
+// number and wire type, and either its varint value or its bytes.
+func eachField(data []byte, fn func(num int, wire int, v uint64, b []byte) error) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return errors.New("truncated field key")
+		}
+		data = data[n:]
+		num, wire := int(key>>3), int(key&7)
+		var v uint64
+		var b []byte
+		switch wire {
+		case wireVarint:
+			if v, n = binary.Uvarint(data); n <= 0 {
+				return errors.New("truncated varint")
+			}
+			data = data[n:]
+		case wire64:
+			if len(data) < 8 {
+				return errors.New("truncated fixed64")
+			}
+			data = data[8:]
+		case wire32:
+			if len(data) < 4 {
+				return errors.New("truncated fixed32")
+			}
+			data = data[4:]
+		case wireBytes:
+			size, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < size {
+				return errors.New("truncated bytes")
+			}
+			b = data[n : n+int(size)]
+			data = data[n+int(size):]
+		default:
+			return fmt.Errorf("unsupported wire type %d", wire)
+		}
+		if err := fn(num, wire, v, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// repeatedVarints decodes a repeated varint field, packed or not.
+func repeatedVarints(wire int, v uint64, b []byte) ([]uint64, error) {
+	if wire == wireVarint {
+		return []uint64{v}, nil
+	}
+	var values []uint64
+	for len(b) > 0 {
+		x, n := binary.Uvarint(b)
+		if n <= 0 {
+			return nil, errors.New("truncated packed varint")
+		}
+		values = append(values, x)
+		b = b[n:]
+	}
+	return values, nil
+}
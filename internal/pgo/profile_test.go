@@ -0,0 +1,120 @@
+package pgo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// message encodes protobuf fields; values are varints, []byte and message
+// values are length-delimited and []uint64 values are packed.
+type message []any
+
+func (m message) encode() []byte {
+	var out []byte
+	for i := 0; i < len(m); i += 2 {
+		num := uint64(m[i].(int))
+		switch v := m[i+1].(type) {
+		case int:
+			out = binary.AppendUvarint(out, num<<3|wireVarint)
+			out = binary.AppendUvarint(out, uint64(v))
+		case string:
+			out = appendBytes(out, num, []byte(v))
+		case message:
+			out = appendBytes(out, num, v.encode())
+		case []uint64:
+			var packed []byte
+			for _, x := range v {
+				packed = binary.AppendUvarint(packed, x)
+			}
+			out = appendBytes(out, num, packed)
+		}
+	}
+	return out
+}
+
+func appendBytes(out []byte, num uint64, b []byte) []byte {
+	out = binary.AppendUvarint(out, num<<3|wireBytes)
+	out = binary.AppendUvarint(out, uint64(len(b)))
+	return append(out, b...)
+}
+
+// testProfile is a CPU profile with samples in a hot function, a cold one,
+// a method and a closure. Location 1 is square inlined into sum.
+func testProfile() []byte {
+	strs := []string{"", "samples", "count", "cpu", "nanoseconds",
+		"example.com/app.square", "example.com/app.sum", "main.cold",
+		"main.(*Box).Get", "main.run.func1"}
+	m := message{
+		1, message{1, 1, 2, 2},
+		1, message{1, 3, 2, 4},
+		// square (inlined into sum): 60, sum: 20, cold: 1, Get: 10, closure: 9
+		2, message{1, []uint64{1}, 2, []uint64{6, 60}},
+		2, message{1, []uint64{2, 1}, 2, []uint64{2, 20}},
+		2, message{1, []uint64{3}, 2, []uint64{1, 1}},
+		2, message{1, []uint64{4}, 2, []uint64{1, 10}},
+		2, message{1, []uint64{5}, 2, []uint64{1, 9}},
+		4, message{1, 1, 4, message{1, 1, 2, 3}, 4, message{1, 2, 2, 10}},
+		4, message{1, 2, 4, message{1, 2, 2, 11}},
+		4, message{1, 3, 4, message{1, 3}},
+		4, message{1, 4, 4, message{1, 4}},
+		4, message{1, 5, 4, message{1, 5}},
+		5, message{1, 1, 2, 5},
+		5, message{1, 2, 2, 6},
+		5, message{1, 3, 2, 7},
+		5, message{1, 4, 2, 8},
+		5, message{1, 5, 2, 9},
+	}
+	for _, s := range strs {
+		m = append(m, 6, s)
+	}
+	return m.encode()
+}
+
+func TestParse(t *testing.T) {
+	p, err := Parse(testProfile())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), p.Total)
+	assert.Equal(t, map[string]int64{
+		"example.com/app.square": 60,
+		"example.com/app.sum":    20,
+		"main.cold":              1,
+		"main.(*Box).Get":        10,
+		"main.run.func1":         9,
+	}, p.Flat)
+}
+
+func TestParseGzipped(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(testProfile())
+	zw.Close()
+	p, err := Parse(buf.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), p.Total)
+}
+
+func TestParseDefaultSampleType(t *testing.T) {
+	// default_sample_type "samples" selects the sample counts
+	data := append(testProfile(), message{14, 1}.encode()...)
+	p, err := Parse(data)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(11), p.Total)
+}
+
+func TestParseRejectsOtherFiles(t *testing.T) {
+	_, err := Parse([]byte("not a profile"))
+	assert.Error(t, err)
+	_, err = Parse(nil)
+	assert.ErrorContains(t, err, "no sample types")
+}
+
+func TestHotFunctions(t *testing.T) {
+	p, err := Parse(testProfile())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"app.square", "app.sum"}, SortedNames(p.HotFunctions(0.05)))
+	assert.Equal(t, []string{"app.square", "app.sum", "main.cold"}, SortedNames(p.HotFunctions(0.01)))
+}
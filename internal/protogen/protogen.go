@@ -0,0 +1,554 @@
+// Package protogen generates GALA functions converting the structs and sealed
+// types of a package to and from the Go structs protoc-gen-go generates for
+// matching protobuf messages. It works from the analyzer's metadata, so the
+// messages are not read; they are expected to follow these conventions:
+//
+//   - A struct S maps to message S, field by field. A GALA field f is the
+//     message field whose Go name is f with its first letter upper-cased.
+//   - A sealed type T maps to message T holding a oneof, "kind" by default,
+//     with one member per variant V of message type V.
+//   - Option[E] maps to an optional field, or to a message field left nil for
+//     None; a slice maps to a repeated field.
+//   - int and uint map to int64 and uint64; newtypes map to their
+//     underlying type.
+package protogen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"martianoff/gala/internal/transpiler"
+)
+
+// Options configure Generate.
+type Options struct {
+	// Package is the GALA package whose types are converted.
+	Package string
+	// PbImport is the import path of the Go package protoc-gen-go generated.
+	PbImport string
+	// PbAlias is the name the generated file imports PbImport as; "pb" when empty.
+	PbAlias string
+	// Oneof is the oneof of a sealed type's message holding its variants;
+	// "kind" when empty.
+	Oneof string
+	// Types lists the types to convert. When empty, every struct and sealed
+	// type that can be converted is, and the others are reported as skipped.
+	Types []string
+}
+
+// Skipped is a type Generate left out, with the reason.
+type Skipped struct {
+	Type   string
+	Reason string
+}
+
+// scalarTypes maps GALA scalar types to the Go types of protobuf scalars.
+var scalarTypes = map[string]string{
+	"bool":    "bool",
+	"string":  "string",
+	"int32":   "int32",
+	"int64":   "int64",
+	"uint32":  "uint32",
+	"uint64":  "uint64",
+	"float32": "float32",
+	"float64": "float64",
+	"int":     "int64",
+	"uint":    "uint64",
+}
+
+// Generate returns the GALA source of the conversion functions for the types
+// of opts.Package in r: <T>ToProto and <T>FromProto for every converted type
+// T. FromProto of a struct with require clauses returns a Validated.
+func Generate(r *transpiler.RichAST, opts Options) (string, []Skipped, error) {
+	if opts.PbImport == "" {
+		return "", nil, fmt.Errorf("the import path of the protobuf Go package is required")
+	}
+	g := &generator{
+		r:       r,
+		opts:    opts,
+		alias:   opts.PbAlias,
+		oneof:   opts.Oneof,
+		convert: make(map[string]*transpiler.TypeMetadata),
+		helpers: make(map[string]bool),
+	}
+	if g.alias == "" {
+		g.alias = "pb"
+	}
+	if g.oneof == "" {
+		g.oneof = "kind"
+	}
+
+	skipped, err := g.selectTypes()
+	if err != nil {
+		return "", nil, err
+	}
+	// Checking the fields of dropped types may have asked for helpers
+	g.helpers = make(map[string]bool)
+	if len(g.convert) == 0 {
+		return "", skipped, fmt.Errorf("package %s has no types that can be converted", opts.Package)
+	}
+
+	var names []string
+	for name := range g.convert {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var body strings.Builder
+	for _, name := range names {
+		meta := g.convert[name]
+		if meta.IsSealed {
+			g.writeSealed(&body, meta)
+		} else {
+			g.writeStruct(&body, meta)
+		}
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "// Code generated by gala protogen. DO NOT EDIT.\n\npackage %s\n\n", opts.Package)
+	fmt.Fprintf(&out, "import (\n    %s %q\n)\n", g.alias, opts.PbImport)
+	out.WriteString(body.String())
+	g.writeHelpers(&out)
+	return out.String(), skipped, nil
+}
+
+type generator struct {
+	r       *transpiler.RichAST
+	opts    Options
+	alias   string
+	oneof   string
+	convert map[string]*transpiler.TypeMetadata // types getting conversions, by name
+	helpers map[string]bool                     // helper functions the conversions call
+}
+
+// selectTypes fills g.convert. Without an explicit list it starts from every
+// struct and sealed type and drops types whose fields cannot be converted
+// until the rest only refer to each other.
+func (g *generator) selectTypes() ([]Skipped, error) {
+	candidates := make(map[string]*transpiler.TypeMetadata)
+	variants := make(map[string]bool)
+	for _, meta := range g.r.Types {
+		if meta.Package != g.opts.Package {
+			continue
+		}
+		candidates[meta.Name] = meta
+		for _, v := range meta.SealedVariants {
+			variants[v.Name] = true
+		}
+	}
+
+	var skipped []Skipped
+	if len(g.opts.Types) > 0 {
+		for _, name := range g.opts.Types {
+			meta, ok := candidates[name]
+			if !ok {
+				return nil, fmt.Errorf("package %s has no type %s", g.opts.Package, name)
+			}
+			if reason := g.shapeProblem(meta); reason != "" {
+				return nil, fmt.Errorf("cannot convert %s: %s", name, reason)
+			}
+			g.convert[name] = meta
+		}
+		// Types the listed ones refer to are converted as well
+		for changed := true; changed; {
+			changed = false
+			for _, meta := range g.convert {
+				for _, t := range fieldTypes(meta) {
+					if dep := g.localType(t); dep != nil && !dep.IsNewtype() && g.convert[dep.Name] == nil && g.shapeProblem(dep) == "" {
+						g.convert[dep.Name] = dep
+						changed = true
+					}
+				}
+			}
+		}
+		for _, meta := range g.convert {
+			if reason := g.fieldProblem(meta); reason != "" {
+				return nil, fmt.Errorf("cannot convert %s: %s", meta.Name, reason)
+			}
+		}
+		return nil, nil
+	}
+
+	for name, meta := range candidates {
+		if variants[name] || meta.IsNewtype() || (!meta.IsSealed && len(meta.FieldNames) == 0) {
+			// Companions of variants, newtypes, interfaces and helper types
+			continue
+		}
+		if reason := g.shapeProblem(meta); reason != "" {
+			skipped = append(skipped, Skipped{Type: name, Reason: reason})
+			continue
+		}
+		g.convert[name] = meta
+	}
+	for changed := true; changed; {
+		changed = false
+		for name, meta := range g.convert {
+			if reason := g.fieldProblem(meta); reason != "" {
+				skipped = append(skipped, Skipped{Type: name, Reason: reason})
+				delete(g.convert, name)
+				changed = true
+			}
+		}
+	}
+	sort.Slice(skipped, func(i, j int) bool { return skipped[i].Type < skipped[j].Type })
+	return skipped, nil
+}
+
+// shapeProblem reports why meta cannot become a message regardless of its fields.
+func (g *generator) shapeProblem(meta *transpiler.TypeMetadata) string {
+	switch {
+	case len(meta.TypeParams) > 0:
+		return "generic types have no message"
+	case meta.IsNewtype():
+		return "newtypes convert as the fields of a message, not as a message"
+	case meta.IsSealed && len(meta.SealedVariants) == 0:
+		return "sealed type has no variants"
+	}
+	return ""
+}
+
+// fieldProblem reports the first field of meta that cannot be converted.
+func (g *generator) fieldProblem(meta *transpiler.TypeMetadata) string {
+	check := func(owner, field string, t transpiler.Type) string {
+		if _, err := g.conv(t); err != nil {
+			return fmt.Sprintf("field %s%s: %v", owner, field, err)
+		}
+		return ""
+	}
+	if meta.IsSealed {
+		for _, v := range meta.SealedVariants {
+			for i, f := range v.FieldNames {
+				if reason := check(v.Name+".", f, variantType(v, i)); reason != "" {
+					return reason
+				}
+			}
+		}
+		return ""
+	}
+	for _, f := range meta.FieldNames {
+		if reason := check("", f, meta.Fields[f]); reason != "" {
+			return reason
+		}
+	}
+	return ""
+}
+
+func fieldTypes(meta *transpiler.TypeMetadata) []transpiler.Type {
+	var types []transpiler.Type
+	if meta.IsSealed {
+		for _, v := range meta.SealedVariants {
+			for i := range v.FieldNames {
+				types = append(types, unwrapContainer(variantType(v, i)))
+			}
+		}
+		return types
+	}
+	for _, f := range meta.FieldNames {
+		types = append(types, unwrapContainer(meta.Fields[f]))
+	}
+	return types
+}
+
+func variantType(v transpiler.SealedVariant, i int) transpiler.Type {
+	if i < len(v.FieldTypes) {
+		return v.FieldTypes[i]
+	}
+	return transpiler.NilType{}
+}
+
+// unwrapContainer returns the element type of an Option or slice, which is
+// what a field refers to when selecting types.
+func unwrapContainer(t transpiler.Type) transpiler.Type {
+	if elem, ok := optionElem(t); ok {
+		return elem
+	}
+	if arr, ok := t.(transpiler.ArrayType); ok && arr.Elem != nil {
+		return arr.Elem
+	}
+	return t
+}
+
+// localType returns the metadata of t when it names a type of the package.
+func (g *generator) localType(t transpiler.Type) *transpiler.TypeMetadata {
+	var name string
+	switch t := t.(type) {
+	case transpiler.BasicType:
+		name = t.Name
+	case transpiler.NamedType:
+		if t.Package != g.opts.Package {
+			return nil
+		}
+		name = t.Name
+	default:
+		return nil
+	}
+	meta := g.r.Types[transpiler.QualifiedName(g.opts.Package, name)]
+	if meta == nil || meta.Package != g.opts.Package {
+		return nil
+	}
+	return meta
+}
+
+func optionElem(t transpiler.Type) (transpiler.Type, bool) {
+	gt, ok := t.(transpiler.GenericType)
+	if !ok || len(gt.Params) != 1 {
+		return nil, false
+	}
+	if base := gt.Base.String(); base == "Option" || base == "std.Option" {
+		return gt.Params[0], true
+	}
+	return nil, false
+}
+
+// conversion converts between a GALA type and the Go type of a message field.
+type conversion struct {
+	pbType string
+	// to and from turn an expression of one type into one of the other
+	to, from func(x string) string
+	// identity is set when the values need no conversion
+	identity bool
+	// raw is set when the field is read directly rather than through its
+	// getter, which hides whether an optional field is set
+	raw bool
+}
+
+func (g *generator) conv(t transpiler.Type) (conversion, error) {
+	if elem, ok := optionElem(t); ok {
+		return g.optionConv(elem)
+	}
+	if arr, ok := t.(transpiler.ArrayType); ok {
+		return g.sliceConv(arr)
+	}
+	return g.valueConv(t)
+}
+
+// valueConv converts scalars, newtypes and message types.
+func (g *generator) valueConv(t transpiler.Type) (conversion, error) {
+	if b, ok := t.(transpiler.BasicType); ok {
+		if pb, ok := scalarTypes[b.Name]; ok {
+			if pb == b.Name {
+				return conversion{pbType: pb, to: same, from: same, identity: true}, nil
+			}
+			return conversion{pbType: pb, to: convertTo(pb), from: convertTo(b.Name)}, nil
+		}
+	}
+	meta := g.localType(t)
+	if meta == nil {
+		if t == nil || t.IsNil() {
+			return conversion{}, fmt.Errorf("unknown type")
+		}
+		return conversion{}, fmt.Errorf("no protobuf type for %s", t)
+	}
+	if meta.IsNewtype() {
+		under, err := g.valueConv(meta.Underlying)
+		if err != nil || g.localType(meta.Underlying) != nil {
+			return conversion{}, fmt.Errorf("newtype %s must wrap a scalar", meta.Name)
+		}
+		return conversion{
+			pbType: under.pbType,
+			to:     func(x string) string { return under.to(x + ".Value()") },
+			from:   func(x string) string { return meta.Name + transpiler.NewtypeFromSuffix + "(" + under.from(x) + ")" },
+		}, nil
+	}
+	if g.convert[meta.Name] == nil {
+		return conversion{}, fmt.Errorf("type %s is not converted", meta.Name)
+	}
+	if len(meta.Invariants) > 0 {
+		return conversion{}, fmt.Errorf("%s has require clauses, so its FromProto returns a Validated", meta.Name)
+	}
+	return conversion{
+		pbType: "*" + g.alias + "." + meta.Name,
+		to:     func(x string) string { return meta.Name + "ToProto(" + x + ")" },
+		from:   func(x string) string { return meta.Name + "FromProto(" + x + ")" },
+	}, nil
+}
+
+func (g *generator) optionConv(elem transpiler.Type) (conversion, error) {
+	if _, ok := optionElem(elem); ok {
+		return conversion{}, fmt.Errorf("nested options have no protobuf field")
+	}
+	c, err := g.valueConv(elem)
+	if err != nil {
+		return conversion{}, err
+	}
+	galaElem := g.galaType(elem)
+	g.helpers["protoOption"] = true
+	if strings.HasPrefix(c.pbType, "*") {
+		// A message field, nil for None
+		return conversion{
+			pbType: c.pbType,
+			to: func(x string) string {
+				return fmt.Sprintf("%s.Map[%s]((e) => %s).GetOrElse(nil)", x, c.pbType, c.to("e"))
+			},
+			from: func(x string) string {
+				return fmt.Sprintf("protoOption[%s, %s](%s, (p %s) => %s)", c.pbType[1:], galaElem, x, c.pbType, c.from("p"))
+			},
+			raw: true,
+		}, nil
+	}
+	g.helpers["protoPtr"] = true
+	return conversion{
+		pbType: "*" + c.pbType,
+		to: func(x string) string {
+			if c.identity {
+				return fmt.Sprintf("protoPtr(%s)", x)
+			}
+			return fmt.Sprintf("protoPtr(%s.Map[%s]((e) => %s))", x, c.pbType, c.to("e"))
+		},
+		from: func(x string) string {
+			return fmt.Sprintf("protoOption[%s, %s](%s, (p *%s) => %s)", c.pbType, galaElem, x, c.pbType, c.from("*p"))
+		},
+		raw: true,
+	}, nil
+}
+
+func (g *generator) sliceConv(arr transpiler.ArrayType) (conversion, error) {
+	if b, ok := arr.Elem.(transpiler.BasicType); ok && (b.Name == "byte" || b.Name == "uint8") {
+		return conversion{pbType: "[]byte", to: same, from: same, identity: true}, nil
+	}
+	c, err := g.valueConv(arr.Elem)
+	if err != nil {
+		return conversion{}, err
+	}
+	if c.identity {
+		return conversion{pbType: "[]" + c.pbType, to: same, from: same, identity: true}, nil
+	}
+	galaElem := g.galaType(arr.Elem)
+	g.helpers["protoSlice"] = true
+	return conversion{
+		pbType: "[]" + c.pbType,
+		to: func(x string) string {
+			return fmt.Sprintf("protoSlice[%s, %s](%s, (e %s) => %s)", galaElem, c.pbType, x, galaElem, c.to("e"))
+		},
+		from: func(x string) string {
+			return fmt.Sprintf("protoSlice[%s, %s](%s, (p %s) => %s)", c.pbType, galaElem, x, c.pbType, c.from("p"))
+		},
+	}, nil
+}
+
+// galaType renders t as written inside the package.
+func (g *generator) galaType(t transpiler.Type) string {
+	if meta := g.localType(t); meta != nil {
+		return meta.Name
+	}
+	return t.String()
+}
+
+func same(x string) string { return x }
+
+func convertTo(typ string) func(string) string {
+	return func(x string) string { return typ + "(" + x + ")" }
+}
+
+// goFieldName is the Go name protoc-gen-go gives the message field for a
+// GALA field or variant.
+func goFieldName(name string) string {
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+func (g *generator) writeStruct(w *strings.Builder, meta *transpiler.TypeMetadata) {
+	name, pb := meta.Name, g.alias+"."+meta.Name
+
+	var to, from []string
+	for _, f := range meta.FieldNames {
+		c, _ := g.conv(meta.Fields[f])
+		to = append(to, fmt.Sprintf("%s: %s", goFieldName(f), c.to("v."+f)))
+		from = append(from, fmt.Sprintf("%s = %s", f, c.from(g.read("m", f, c))))
+	}
+
+	fmt.Fprintf(w, "\n// %sToProto converts a %s to the message %s.\n", name, name, pb)
+	fmt.Fprintf(w, "func %sToProto(v %s) *%s = &%s{%s}\n", name, name, pb, pb, strings.Join(to, ", "))
+
+	result := name
+	if len(meta.Invariants) > 0 {
+		result = "Validated[" + name + "]"
+	}
+	fmt.Fprintf(w, "\n// %sFromProto converts the message %s to a %s.\n", name, pb, result)
+	fmt.Fprintf(w, "func %sFromProto(m *%s) %s = %s(%s)\n", name, pb, result, name, strings.Join(from, ", "))
+}
+
+func (g *generator) writeSealed(w *strings.Builder, meta *transpiler.TypeMetadata) {
+	name, pb := meta.Name, g.alias+"."+meta.Name
+	oneof := goFieldName(g.oneof)
+
+	fmt.Fprintf(w, "\n// %sToProto converts a %s to the message %s, setting its oneof %s.\n", name, name, pb, g.oneof)
+	fmt.Fprintf(w, "func %sToProto(v %s) *%s = v match {\n", name, name, pb)
+	for _, v := range meta.SealedVariants {
+		var binds, fields []string
+		for i, f := range v.FieldNames {
+			bind := fmt.Sprintf("p%d", i)
+			c, _ := g.conv(variantType(v, i))
+			binds = append(binds, bind)
+			fields = append(fields, fmt.Sprintf("%s: %s", goFieldName(f), c.to(bind)))
+		}
+		member := goFieldName(v.Name)
+		fmt.Fprintf(w, "    case %s(%s) => &%s{%s: &%s_%s{%s: &%s.%s{%s}}}\n",
+			v.Name, strings.Join(binds, ", "), pb, oneof, pb, member, member, g.alias, v.Name, strings.Join(fields, ", "))
+	}
+	w.WriteString("}\n")
+
+	g.helpers["protoUnset"] = true
+	fmt.Fprintf(w, "\n// %sFromProto converts the message %s to a %s. It panics when the\n// oneof %s is not set.\n", name, pb, name, g.oneof)
+	fmt.Fprintf(w, "func %sFromProto(m *%s) %s {\n", name, pb, name)
+	for _, v := range meta.SealedVariants {
+		getter := "m.Get" + goFieldName(v.Name) + "()"
+		var args []string
+		for i, f := range v.FieldNames {
+			c, _ := g.conv(variantType(v, i))
+			args = append(args, c.from(g.read(getter, f, c)))
+		}
+		fmt.Fprintf(w, "    if %s != nil {\n        return %s(%s)\n    }\n", getter, v.Name, strings.Join(args, ", "))
+	}
+	fmt.Fprintf(w, "    return protoUnset[%s](%q)\n}\n", name, pb+" has no "+g.oneof+" set")
+}
+
+// read returns the expression reading field of message m.
+func (g *generator) read(m, field string, c conversion) string {
+	if c.raw {
+		return m + "." + goFieldName(field)
+	}
+	return m + ".Get" + goFieldName(field) + "()"
+}
+
+// helperSources are the helpers of the generated conversions, in output order.
+var helperSources = []struct{ name, src string }{
+	{"protoPtr", `
+// protoPtr returns a pointer to the value of o, or nil for None.
+func protoPtr[T any](o Option[T]) *T {
+    if o.IsEmpty() {
+        return nil
+    }
+    var v = o.Get()
+    return &v
+}
+`},
+	{"protoOption", `
+// protoOption converts the value p points to with f, or returns None for nil.
+func protoOption[P any, T any](p *P, f func(*P) T) Option[T] = if (p == nil) None[T]() else Some[T](f(p))
+`},
+	{"protoSlice", `
+// protoSlice converts every element of xs with f.
+func protoSlice[A any, B any](xs []A, f func(A) B) []B {
+    var out = make([]B, 0, len(xs))
+    for _, x := range xs {
+        out = append(out, f(x))
+    }
+    return out
+}
+`},
+	{"protoUnset", `
+// protoUnset reports a message whose oneof holds no variant.
+func protoUnset[T any](msg string) T {
+    panic(msg)
+}
+`},
+}
+
+func (g *generator) writeHelpers(w *strings.Builder) {
+	for _, h := range helperSources {
+		if g.helpers[h.name] {
+			w.WriteString(h.src)
+		}
+	}
+}
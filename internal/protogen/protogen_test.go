@@ -0,0 +1,146 @@
+package protogen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"martianoff/gala/internal/transpiler"
+)
+
+func shopAST() *transpiler.RichAST {
+	str := transpiler.BasicType{Name: "string"}
+	integer := transpiler.BasicType{Name: "int"}
+	named := func(name string) transpiler.Type { return transpiler.NamedType{Package: "shop", Name: name} }
+	option := func(elem transpiler.Type) transpiler.Type {
+		return transpiler.GenericType{Base: transpiler.NamedType{Package: "std", Name: "Option"}, Params: []transpiler.Type{elem}}
+	}
+	structType := func(name string, fields ...any) *transpiler.TypeMetadata {
+		meta := &transpiler.TypeMetadata{Name: name, Package: "shop", Fields: map[string]transpiler.Type{}}
+		for i := 0; i < len(fields); i += 2 {
+			meta.FieldNames = append(meta.FieldNames, fields[i].(string))
+			meta.Fields[fields[i].(string)] = fields[i+1].(transpiler.Type)
+		}
+		return meta
+	}
+
+	account := structType("Account", "Email", str)
+	account.Invariants = []transpiler.Invariant{{Field: "Email", Requirement: `Email != ""`}}
+	box := structType("Box", "Item", transpiler.BasicType{Name: "T"})
+	box.TypeParams = []string{"T"}
+	types := []*transpiler.TypeMetadata{
+		{Name: "UserId", Package: "shop", Underlying: str},
+		structType("Address", "City", str, "zip", option(str)),
+		structType("User",
+			"Id", named("UserId"),
+			"Age", integer,
+			"Home", named("Address"),
+			"Work", option(named("Address")),
+			"Visits", option(integer),
+			"Tags", transpiler.ArrayType{Elem: str},
+			"Scores", transpiler.ArrayType{Elem: integer},
+			"Photo", transpiler.ArrayType{Elem: transpiler.BasicType{Name: "byte"}},
+		),
+		{Name: "Payment", Package: "shop", IsSealed: true, SealedVariants: []transpiler.SealedVariant{
+			{Name: "Card", FieldNames: []string{"Number", "Holder"}, FieldTypes: []transpiler.Type{str, named("User")}},
+			{Name: "Cash"},
+		}},
+		{Name: "Card", Package: "shop"},
+		{Name: "Cash", Package: "shop"},
+		structType("Job", "Run", transpiler.FuncType{}),
+		structType("Queue", "Next", named("Job")),
+		account,
+		box,
+		{Name: "Option", Package: "std"},
+	}
+	r := &transpiler.RichAST{PackageName: "shop", Types: map[string]*transpiler.TypeMetadata{}}
+	for _, meta := range types {
+		r.Types[transpiler.QualifiedName(meta.Package, meta.Name)] = meta
+	}
+	return r
+}
+
+func TestGenerate(t *testing.T) {
+	src, skipped, err := Generate(shopAST(), Options{Package: "shop", PbImport: "example.com/shop/shoppb"})
+	assert.NoError(t, err)
+	assert.Equal(t, []Skipped{
+		{Type: "Box", Reason: "generic types have no message"},
+		{Type: "Job", Reason: "field Run: no protobuf type for func"},
+		{Type: "Queue", Reason: "field Next: type Job is not converted"},
+	}, skipped)
+
+	assert.Contains(t, src, "// Code generated by gala protogen. DO NOT EDIT.\n\npackage shop\n\nimport (\n    pb \"example.com/shop/shoppb\"\n)\n")
+	for _, want := range []string{
+		"func AccountToProto(v Account) *pb.Account = &pb.Account{Email: v.Email}",
+		"func AccountFromProto(m *pb.Account) Validated[Account] = Account(Email = m.GetEmail())",
+		"func AddressToProto(v Address) *pb.Address = &pb.Address{City: v.City, Zip: protoPtr(v.zip)}",
+		"func AddressFromProto(m *pb.Address) Address = Address(City = m.GetCity(), zip = protoOption[string, string](m.Zip, (p *string) => *p))",
+		"func UserToProto(v User) *pb.User = &pb.User{" +
+			"Id: v.Id.Value(), " +
+			"Age: int64(v.Age), " +
+			"Home: AddressToProto(v.Home), " +
+			"Work: v.Work.Map[*pb.Address]((e) => AddressToProto(e)).GetOrElse(nil), " +
+			"Visits: protoPtr(v.Visits.Map[int64]((e) => int64(e))), " +
+			"Tags: v.Tags, " +
+			"Scores: protoSlice[int, int64](v.Scores, (e int) => int64(e)), " +
+			"Photo: v.Photo}",
+		"func UserFromProto(m *pb.User) User = User(" +
+			"Id = UserIdFrom(m.GetId()), " +
+			"Age = int(m.GetAge()), " +
+			"Home = AddressFromProto(m.GetHome()), " +
+			"Work = protoOption[pb.Address, Address](m.Work, (p *pb.Address) => AddressFromProto(p)), " +
+			"Visits = protoOption[int64, int](m.Visits, (p *int64) => int(*p)), " +
+			"Tags = m.GetTags(), " +
+			"Scores = protoSlice[int64, int](m.GetScores(), (p int64) => int(p)), " +
+			"Photo = m.GetPhoto())",
+		"func PaymentToProto(v Payment) *pb.Payment = v match {\n" +
+			"    case Card(p0, p1) => &pb.Payment{Kind: &pb.Payment_Card{Card: &pb.Card{Number: p0, Holder: UserToProto(p1)}}}\n" +
+			"    case Cash() => &pb.Payment{Kind: &pb.Payment_Cash{Cash: &pb.Cash{}}}\n}\n",
+		"func PaymentFromProto(m *pb.Payment) Payment {\n" +
+			"    if m.GetCard() != nil {\n        return Card(m.GetCard().GetNumber(), UserFromProto(m.GetCard().GetHolder()))\n    }\n" +
+			"    if m.GetCash() != nil {\n        return Cash()\n    }\n" +
+			"    return protoUnset[Payment](\"pb.Payment has no kind set\")\n}\n",
+		"func protoPtr[T any](o Option[T]) *T {",
+		"func protoOption[P any, T any](p *P, f func(*P) T) Option[T]",
+		"func protoSlice[A any, B any](xs []A, f func(A) B) []B {",
+		"func protoUnset[T any](msg string) T {",
+	} {
+		assert.Contains(t, src, want)
+	}
+	assert.NotContains(t, src, "CardToProto")
+	assert.NotContains(t, src, "UserIdToProto")
+}
+
+func TestGenerateSelectedTypes(t *testing.T) {
+	src, skipped, err := Generate(shopAST(), Options{Package: "shop", PbImport: "example.com/shop/v1", PbAlias: "shopv1", Oneof: "method", Types: []string{"Payment"}})
+	assert.NoError(t, err)
+	assert.Empty(t, skipped)
+	assert.Contains(t, src, "    shopv1 \"example.com/shop/v1\"")
+	assert.Contains(t, src, "&shopv1.Payment{Method: &shopv1.Payment_Card{")
+	assert.Contains(t, src, "return protoUnset[Payment](\"shopv1.Payment has no method set\")")
+	// Payment refers to User, which refers to Address
+	assert.Contains(t, src, "func UserToProto(")
+	assert.Contains(t, src, "func AddressFromProto(")
+	assert.NotContains(t, src, "AccountToProto")
+
+	// Only the helpers the conversions call
+	src, _, err = Generate(shopAST(), Options{Package: "shop", PbImport: "pb", Types: []string{"Address"}})
+	assert.NoError(t, err)
+	assert.Contains(t, src, "func protoPtr")
+	assert.NotContains(t, src, "func protoSlice")
+	assert.NotContains(t, src, "func protoUnset")
+}
+
+func TestGenerateErrors(t *testing.T) {
+	_, _, err := Generate(shopAST(), Options{Package: "shop"})
+	assert.EqualError(t, err, "the import path of the protobuf Go package is required")
+
+	_, _, err = Generate(shopAST(), Options{Package: "shop", PbImport: "pb", Types: []string{"Missing"}})
+	assert.EqualError(t, err, "package shop has no type Missing")
+
+	_, _, err = Generate(shopAST(), Options{Package: "shop", PbImport: "pb", Types: []string{"Queue"}})
+	assert.EqualError(t, err, "cannot convert Job: field Run: no protobuf type for func")
+
+	_, _, err = Generate(shopAST(), Options{Package: "shop", PbImport: "pb", Types: []string{"Box"}})
+	assert.EqualError(t, err, "cannot convert Box: generic types have no message")
+}
@@ -0,0 +1,88 @@
+// Package reduce shrinks a failing input to a small one that still fails, for
+// bug reports. It implements delta debugging: chunks of the input are removed
+// as long as a caller-supplied test keeps reporting the failure.
+package reduce
+
+import "strings"
+
+// Minimize returns a subset of units, in their original order, for which test
+// holds and from which no single unit can be removed without test failing.
+// test must hold for units itself. It is not called with the full input.
+func Minimize[T any](units []T, test func([]T) bool) []T {
+	n := 2
+	for len(units) > 0 {
+		n = min(n, len(units))
+		reduced := false
+		for i := 0; i < n; i++ {
+			start, end := i*len(units)/n, (i+1)*len(units)/n
+			candidate := append(append([]T(nil), units[:start]...), units[end:]...)
+			if test(candidate) {
+				units = candidate
+				n = max(n-1, 2)
+				reduced = true
+				break
+			}
+		}
+		if reduced {
+			continue
+		}
+		if n == len(units) {
+			break
+		}
+		n *= 2
+	}
+	return units
+}
+
+// Source reduces src line by line. Brace-delimited blocks are first tried as
+// a whole, since removing only a block's opening or closing line rarely leaves
+// a meaningful program. test reports whether a candidate still fails; it must
+// hold for src.
+func Source(src string, test func(string) bool) string {
+	lines := strings.Split(src, "\n")
+	testLines := func(candidate []string) bool {
+		return test(strings.Join(candidate, "\n"))
+	}
+	for {
+		before := len(lines)
+		lines = removeBlocks(lines, testLines)
+		lines = Minimize(lines, testLines)
+		if len(lines) == before {
+			return strings.Join(lines, "\n")
+		}
+	}
+}
+
+// removeBlocks removes every block of lines that test allows, outer blocks
+// first.
+func removeBlocks(lines []string, test func([]string) bool) []string {
+	for i := 0; i < len(lines); i++ {
+		end := blockEnd(lines, i)
+		if end < 0 {
+			continue
+		}
+		candidate := append(append([]string(nil), lines[:i]...), lines[end+1:]...)
+		if test(candidate) {
+			lines = candidate
+			i--
+		}
+	}
+	return lines
+}
+
+// blockEnd returns the index of the line closing the block opened on line
+// start, or -1 if start does not open one. Braces in strings and comments are
+// counted too; a miscounted block is merely a candidate that fails.
+func blockEnd(lines []string, start int) int {
+	depth := 0
+	for i := start; i < len(lines); i++ {
+		depth += strings.Count(lines[i], "{") - strings.Count(lines[i], "}")
+		if i == start && depth <= 0 {
+			return -1
+		}
+		if depth <= 0 {
+			return i
+		}
+	}
+	return -1
+}
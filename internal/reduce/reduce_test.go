@@ -0,0 +1,67 @@
+package reduce
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMinimize(t *testing.T) {
+	units := []int{1, 2, 3, 4, 5, 6, 7, 8, 9}
+	calls := 0
+	got := Minimize(units, func(c []int) bool {
+		calls++
+		return slices.Contains(c, 3) && slices.Contains(c, 7)
+	})
+	assert.Equal(t, []int{3, 7}, got)
+	assert.Less(t, calls, 40)
+}
+
+func TestMinimizeKeepsOrder(t *testing.T) {
+	got := Minimize([]string{"a", "b", "c", "d"}, func(c []string) bool {
+		b, d := slices.Index(c, "b"), slices.Index(c, "d")
+		return b >= 0 && d > b
+	})
+	assert.Equal(t, []string{"b", "d"}, got)
+}
+
+func TestMinimizeToEmpty(t *testing.T) {
+	got := Minimize([]int{1, 2, 3}, func([]int) bool { return true })
+	assert.Empty(t, got)
+}
+
+func TestSource(t *testing.T) {
+	src := `package main
+
+import "fmt"
+
+func helper() int {
+    return 1
+}
+
+func main() {
+    val x = 1
+    fmt.Println(x)
+    bad()
+}`
+	// The failure needs bad() inside a balanced main.
+	test := func(s string) bool {
+		return strings.Contains(s, "bad()") && strings.Count(s, "{") == strings.Count(s, "}") &&
+			strings.Contains(s, "func main() {")
+	}
+	got := Source(src, test)
+	assert.Equal(t, "func main() {\n    bad()\n}", got)
+}
+
+func TestSourceRemovesWholeBlocks(t *testing.T) {
+	src := "a\nif x {\n    y\n}\nb"
+	var tested []string
+	got := Source(src, func(s string) bool {
+		tested = append(tested, s)
+		return strings.Contains(s, "b") && strings.Count(s, "{") == strings.Count(s, "}")
+	})
+	assert.Equal(t, "b", got)
+	assert.Contains(t, tested, "a\nb")
+}
@@ -99,6 +99,47 @@ func generatePackageGoMod(pkgName, importPath string) string {
 		content += "\nreplace martianoff/gala/std => ../std\n"
 		content += "\nreplace martianoff/gala/go_interop => ../go_interop\n"
 		content += "replace martianoff/gala/collection_immutable => ../collection_immutable\n"
+	case "cli":
+		content += "\nrequire (\n"
+		content += "\tmartianoff/gala/std v0.0.0\n"
+		content += "\tmartianoff/gala/collection_immutable v0.0.0\n"
+		content += ")\n"
+		content += "\nreplace martianoff/gala/std => ../std\n"
+		content += "replace martianoff/gala/collection_immutable => ../collection_immutable\n"
+	case "env":
+		content += "\nrequire (\n"
+		content += "\tmartianoff/gala/std v0.0.0\n"
+		content += ")\n"
+		content += "\nreplace martianoff/gala/std => ../std\n"
+	case "files":
+		content += "\nrequire (\n"
+		content += "\tmartianoff/gala/std v0.0.0\n"
+		content += "\tmartianoff/gala/go_interop v0.0.0\n"
+		content += "\tmartianoff/gala/collection_immutable v0.0.0\n"
+		content += "\tmartianoff/gala/stream v0.0.0\n"
+		content += ")\n"
+		content += "\nreplace martianoff/gala/std => ../std\n"
+		content += "replace martianoff/gala/go_interop => ../go_interop\n"
+		content += "replace martianoff/gala/collection_immutable => ../collection_immutable\n"
+		content += "replace martianoff/gala/stream => ../stream\n"
+	case "random":
+		content += "\nrequire (\n"
+		content += "\tmartianoff/gala/std v0.0.0\n"
+		content += "\tmartianoff/gala/go_interop v0.0.0\n"
+		content += "\tmartianoff/gala/collection_immutable v0.0.0\n"
+		content += ")\n"
+		content += "\nreplace martianoff/gala/std => ../std\n"
+		content += "replace martianoff/gala/go_interop => ../go_interop\n"
+		content += "replace martianoff/gala/collection_immutable => ../collection_immutable\n"
+	case "sql":
+		content += "\nrequire (\n"
+		content += "\tmartianoff/gala/std v0.0.0\n"
+		content += "\tmartianoff/gala/go_interop v0.0.0\n"
+		content += "\tmartianoff/gala/collection_immutable v0.0.0\n"
+		content += ")\n"
+		content += "\nreplace martianoff/gala/std => ../std\n"
+		content += "replace martianoff/gala/go_interop => ../go_interop\n"
+		content += "replace martianoff/gala/collection_immutable => ../collection_immutable\n"
 	case "stream":
 		content += "\nrequire (\n"
 		content += "\tmartianoff/gala/std v0.0.0\n"
@@ -108,6 +149,13 @@ func generatePackageGoMod(pkgName, importPath string) string {
 		content += "\nreplace martianoff/gala/std => ../std\n"
 		content += "replace martianoff/gala/go_interop => ../go_interop\n"
 		content += "replace martianoff/gala/collection_immutable => ../collection_immutable\n"
+	case "logging":
+		content += "\nrequire (\n"
+		content += "\tmartianoff/gala/std v0.0.0\n"
+		content += "\tmartianoff/gala/collection_immutable v0.0.0\n"
+		content += ")\n"
+		content += "\nreplace martianoff/gala/std => ../std\n"
+		content += "replace martianoff/gala/collection_immutable => ../collection_immutable\n"
 	case "string_utils":
 		content += "\nrequire (\n"
 		content += "\tmartianoff/gala/std v0.0.0\n"
@@ -120,6 +168,13 @@ func generatePackageGoMod(pkgName, importPath string) string {
 		content += "\tmartianoff/gala/std v0.0.0\n"
 		content += ")\n"
 		content += "\nreplace martianoff/gala/std => ../std\n"
+	case "web":
+		content += "\nrequire (\n"
+		content += "\tmartianoff/gala/std v0.0.0\n"
+		content += "\tmartianoff/gala/collection_immutable v0.0.0\n"
+		content += ")\n"
+		content += "\nreplace martianoff/gala/std => ../std\n"
+		content += "replace martianoff/gala/collection_immutable => ../collection_immutable\n"
 	}
 
 	return content
@@ -0,0 +1,56 @@
+package analyzer
+
+import (
+	"martianoff/gala/internal/transpiler"
+)
+
+// expandTypeAliases replaces the aliases declared with type X = T in the
+// field, method and function types of package pkgName by the types they stand
+// for, so that methods and fields are looked up on the aliased type. Metadata
+// of other packages is left alone: it may be shared, and its own analysis
+// expanded it already.
+func expandTypeAliases(richAST *transpiler.RichAST, pkgName string) {
+	lookup := func(name string) *transpiler.TypeMetadata { return richAST.Types[name] }
+	hasAlias := false
+	for _, meta := range richAST.Types {
+		hasAlias = hasAlias || meta.IsAlias
+	}
+	if !hasAlias {
+		return
+	}
+	expand := func(typ transpiler.Type) transpiler.Type {
+		if typ == nil {
+			return nil
+		}
+		return transpiler.ExpandAliases(typ, lookup)
+	}
+	expandAll := func(types []transpiler.Type) {
+		for i, typ := range types {
+			types[i] = expand(typ)
+		}
+	}
+
+	for _, meta := range richAST.Types {
+		if meta.Package != pkgName {
+			continue
+		}
+		for name, typ := range meta.Fields {
+			meta.Fields[name] = expand(typ)
+		}
+		for _, m := range meta.Methods {
+			expandAll(m.ParamTypes)
+			m.ReturnType = expand(m.ReturnType)
+		}
+		for _, v := range meta.SealedVariants {
+			expandAll(v.FieldTypes)
+		}
+		meta.Underlying = expand(meta.Underlying)
+	}
+	for _, fn := range richAST.Functions {
+		if fn.Package != pkgName {
+			continue
+		}
+		expandAll(fn.ParamTypes)
+		fn.ReturnType = expand(fn.ReturnType)
+	}
+}
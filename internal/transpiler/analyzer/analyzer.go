@@ -6,10 +6,12 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/antlr4-go/antlr/v4"
 
+	"martianoff/gala/galaerr"
 	"martianoff/gala/internal/parser/grammar"
 	"martianoff/gala/internal/transpiler"
 	"martianoff/gala/internal/transpiler/generator"
@@ -22,14 +24,13 @@ import (
 // In normal compilation flow, std is loaded via implicit import in Analyze().
 func GetBaseMetadata(p transpiler.GalaParser, searchPaths []string) *transpiler.RichAST {
 	a := &galaAnalyzer{
-		parser:       p,
-		searchPaths:  searchPaths,
-		analyzedPkgs: make(map[string]*transpiler.RichAST),
-		checkedDirs:  make(map[string]bool),
-		resolver:     module.NewResolver(searchPaths),
+		parser:      p,
+		searchPaths: searchPaths,
+		cache:       NewPackageCache(),
+		resolver:    module.NewResolver(searchPaths),
 	}
 
-	stdAST, err := a.analyzePackage(registry.StdPackageName)
+	stdAST, err := a.analyzePackage(newAnalysis(nil), registry.StdPackageName)
 	if err != nil {
 		// Return empty RichAST if std can't be loaded
 		return &transpiler.RichAST{
@@ -51,25 +52,43 @@ func CheckStdConflict(name, pkgName string) error {
 	return registry.CheckStdConflict(name, pkgName)
 }
 
+// galaAnalyzer is safe for concurrent use: everything an Analyze call changes
+// lives in its analysis or in the PackageCache.
 type galaAnalyzer struct {
 	baseMetadata *transpiler.RichAST
 	parser       transpiler.GalaParser
 	searchPaths  []string
-	packageFiles []string                       // Explicit sibling files belonging to the same package
-	analyzedPkgs map[string]*transpiler.RichAST // Cache of analyzed packages
-	checkedDirs  map[string]bool
+	packageFiles []string         // Explicit sibling files belonging to the same package
+	cache        *PackageCache    // Analyzed packages, possibly shared with other analyzers
 	resolver     *module.Resolver // Handles module root discovery and package path resolution
 }
 
+// analysis is the state of a single Analyze call, including the packages it
+// imports recursively.
+type analysis struct {
+	packageFiles []string        // Sibling files of the package being analyzed; nil inside imports
+	checkedDirs  map[string]bool // Directories whose siblings have been collected
+	importDepth  int             // Number of imported packages currently being analyzed
+	started      map[string]bool // Import paths whose analysis has begun, to stop recursion
+	importStack  []string        // Import paths of the packages being analyzed, outermost first
+}
+
+func newAnalysis(packageFiles []string) *analysis {
+	return &analysis{
+		packageFiles: packageFiles,
+		checkedDirs:  make(map[string]bool),
+		started:      make(map[string]bool),
+	}
+}
+
 // NewGalaAnalyzer creates a new transpiler.Analyzer implementation.
 // It automatically finds the module root by looking for go.mod from the current working directory.
 func NewGalaAnalyzer(p transpiler.GalaParser, searchPaths []string) transpiler.Analyzer {
 	return &galaAnalyzer{
-		parser:       p,
-		searchPaths:  searchPaths,
-		analyzedPkgs: make(map[string]*transpiler.RichAST),
-		checkedDirs:  make(map[string]bool),
-		resolver:     module.NewResolver(searchPaths),
+		parser:      p,
+		searchPaths: searchPaths,
+		cache:       NewPackageCache(),
+		resolver:    module.NewResolver(searchPaths),
 	}
 }
 
@@ -79,8 +98,7 @@ func NewGalaAnalyzerWithBase(base *transpiler.RichAST, p transpiler.GalaParser,
 		baseMetadata: base,
 		parser:       p,
 		searchPaths:  searchPaths,
-		analyzedPkgs: make(map[string]*transpiler.RichAST),
-		checkedDirs:  make(map[string]bool),
+		cache:        NewPackageCache(),
 		resolver:     module.NewResolver(searchPaths),
 	}
 }
@@ -89,18 +107,156 @@ func NewGalaAnalyzerWithBase(base *transpiler.RichAST, p transpiler.GalaParser,
 // for sibling discovery instead of directory scanning. This enables full cross-file type
 // resolution for main/test packages where directory scanning is too broad.
 func NewGalaAnalyzerWithPackageFiles(p transpiler.GalaParser, searchPaths []string, packageFiles []string) transpiler.Analyzer {
+	return NewGalaAnalyzerWithCache(p, searchPaths, packageFiles, NewPackageCache())
+}
+
+// NewGalaAnalyzerWithCache creates an analyzer that keeps imported packages in cache,
+// which may be shared with analyzers of other files. packageFiles is as in
+// NewGalaAnalyzerWithPackageFiles and may be nil.
+func NewGalaAnalyzerWithCache(p transpiler.GalaParser, searchPaths []string, packageFiles []string, cache *PackageCache) transpiler.Analyzer {
 	return &galaAnalyzer{
 		parser:       p,
 		searchPaths:  searchPaths,
 		packageFiles: packageFiles,
-		analyzedPkgs: make(map[string]*transpiler.RichAST),
-		checkedDirs:  make(map[string]bool),
+		cache:        cache,
 		resolver:     module.NewResolver(searchPaths),
 	}
 }
 
 // Analyze walk the ANTLR tree and collects metadata for RichAST.
 func (a *galaAnalyzer) Analyze(tree antlr.Tree, filePath string) (*transpiler.RichAST, error) {
+	state := newAnalysis(a.packageFiles)
+	importPath := a.packageImportPath(filePath)
+	// Test files may import the package they test
+	if importPath != "" && !strings.HasSuffix(filePath, "_test.gala") {
+		state.importStack = []string{importPath}
+	}
+	richAST, err := a.analyze(state, tree, filePath)
+	if err != nil {
+		return nil, err
+	}
+	richAST.ImportPath = importPath
+	return richAST, nil
+}
+
+// packageImportPath returns the import path of the package containing filePath,
+// or "" if the file does not belong to the current module.
+func (a *galaAnalyzer) packageImportPath(filePath string) string {
+	root, name := a.resolver.ModuleRoot(), a.resolver.ModuleName()
+	if filePath == "" || root == "" || name == "" {
+		return ""
+	}
+	dir, err := filepath.Abs(filepath.Dir(filePath))
+	if err != nil {
+		return ""
+	}
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return ""
+	}
+	if rel == "." {
+		return name
+	}
+	return name + "/" + filepath.ToSlash(rel)
+}
+
+// importCycleError reports that importing path from filePath closes a cycle
+// through the packages on the import stack.
+func importCycleError(state *analysis, path, filePath string, line, col int) error {
+	start := slices.Index(state.importStack, path)
+	chain := append(slices.Clone(state.importStack[start:]), path)
+	msg := "import cycle not allowed: " + strings.Join(chain, " -> ")
+	return galaerr.NewSemanticErrorInFile(filePath, line, col, msg).WithCode(galaerr.CodeImportCycle)
+}
+
+// importing reports whether path is one of the packages being analyzed.
+func (state *analysis) importing(path string) bool {
+	return slices.Contains(state.importStack, path)
+}
+
+// importPackage merges the metadata of the package imported as path into
+// richAST, analyzing it unless it is cached. Imports of Go packages are
+// ignored. line and col locate the import in filePath and are zero for
+// implicit imports. Only import cycles are returned as errors; a package that
+// fails to analyze is reported as a warning.
+func (a *galaAnalyzer) importPackage(state *analysis, richAST *transpiler.RichAST, path, filePath string, line, col int) error {
+	relPath, external, ok := a.galaImport(path)
+	if !ok {
+		return nil
+	}
+
+	if state.importing(path) {
+		return importCycleError(state, path, filePath, line, col)
+	}
+
+	if cached, ok := a.cache.get(path); ok {
+		// Use cached metadata
+		richAST.Merge(cached)
+		if cached.PackageName != "" && cached.PackageName != "main" && cached.PackageName != "test" {
+			richAST.Packages[path] = cached.PackageName
+		}
+		return nil
+	}
+	if state.started[path] {
+		return nil
+	}
+	// First time analyzing this package - mark it to prevent infinite recursion
+	state.started[path] = true
+
+	// For external GALA packages, ensure they're transpiled
+	if external {
+		if err := a.ensureTranspiled(path); err != nil {
+			// Log error but continue - we'll still try to analyze
+			fmt.Fprintf(os.Stderr, "Warning: failed to transpile dependency %s: %v\n", path, err)
+		}
+	}
+
+	state.importStack = append(state.importStack, path)
+	importedAST, err := a.analyzePackage(state, relPath)
+	state.importStack = state.importStack[:len(state.importStack)-1]
+	if galaerr.CodeOf(err) == galaerr.CodeImportCycle {
+		return err
+	}
+	if err != nil {
+		if line > 0 {
+			fmt.Fprintf(os.Stderr, "Warning: failed to analyze package %s (imported at line %d): %v\n", relPath, line, err)
+		} else {
+			fmt.Fprintf(os.Stderr, "Warning: failed to analyze package %s: %v\n", relPath, err)
+		}
+		return nil
+	}
+
+	a.cache.put(path, importedAST)
+	richAST.Merge(importedAST)
+	// Store package name from the imported package
+	if importedAST.PackageName != "" && importedAST.PackageName != "main" && importedAST.PackageName != "test" {
+		richAST.Packages[path] = importedAST.PackageName
+	} else {
+		// Fallback if PackageName is not set properly
+		for _, typeMeta := range importedAST.Types {
+			if typeMeta.Package != "" && typeMeta.Package != "main" && typeMeta.Package != "test" && !registry.Global.IsPreludePackage(typeMeta.Package) {
+				richAST.Packages[path] = typeMeta.Package
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// galaImport returns the path of the GALA package imported as path relative to
+// the search paths, and whether it is an external package. ok is false for Go
+// packages.
+func (a *galaAnalyzer) galaImport(path string) (relPath string, external, ok bool) {
+	if strings.HasPrefix(path, "martianoff/gala/") {
+		return strings.TrimPrefix(path, "martianoff/gala/"), false, true
+	}
+	if a.resolver.IsGalaPackage(path) {
+		return path, true, true // External packages use full path
+	}
+	return "", false, false
+}
+
+func (a *galaAnalyzer) analyze(state *analysis, tree antlr.Tree, filePath string) (*transpiler.RichAST, error) {
 	sourceFile, ok := tree.(*grammar.SourceFileContext)
 	if !ok {
 		return nil, fmt.Errorf("expected *grammar.SourceFileContext, got %T", tree)
@@ -109,29 +265,25 @@ func (a *galaAnalyzer) Analyze(tree antlr.Tree, filePath string) (*transpiler.Ri
 	pkgName := sourceFile.PackageClause().(*grammar.PackageClauseContext).Identifier().GetText()
 
 	var siblingTrees []*grammar.SourceFileContext
-	if len(a.packageFiles) > 0 {
+	if len(state.packageFiles) > 0 {
 		// Explicit package files: parse each one, validate package name, add to siblings
 		absFilePath, _ := filepath.Abs(filePath)
-		for _, pf := range a.packageFiles {
+		var others []string
+		for _, pf := range state.packageFiles {
 			absPf, _ := filepath.Abs(pf)
 			if absPf == absFilePath {
 				continue // skip self
 			}
-			content, err := ioutil.ReadFile(pf)
-			if err != nil {
-				continue
-			}
-			tree, err := a.parser.Parse(string(content))
-			if err != nil {
-				continue
-			}
-			otherSF, ok := tree.(*grammar.SourceFileContext)
-			if !ok {
+			others = append(others, pf)
+		}
+		for _, other := range a.parseFiles(others) {
+			otherSF := other.tree
+			if otherSF == nil {
 				continue
 			}
 			otherPkgName := otherSF.PackageClause().(*grammar.PackageClauseContext).Identifier().GetText()
 			if otherPkgName != pkgName {
-				return nil, fmt.Errorf("package file %s has package %s, expected %s", pf, otherPkgName, pkgName)
+				return nil, fmt.Errorf("package file %s has package %s, expected %s", other.path, otherPkgName, pkgName)
 			}
 			siblingTrees = append(siblingTrees, otherSF)
 		}
@@ -139,37 +291,33 @@ func (a *galaAnalyzer) Analyze(tree antlr.Tree, filePath string) (*transpiler.Ri
 		// Directory-discovered siblings (existing behavior)
 		dirPath := filepath.Dir(filePath)
 		absDirPath, err := filepath.Abs(dirPath)
-		if err == nil && !a.checkedDirs[absDirPath] {
-			a.checkedDirs[absDirPath] = true
+		if err == nil && !state.checkedDirs[absDirPath] {
+			state.checkedDirs[absDirPath] = true
 			files, err := ioutil.ReadDir(dirPath)
 			if err == nil {
+				var others []string
 				for _, f := range files {
 					if !f.IsDir() && filepath.Ext(f.Name()) == ".gala" {
 						otherPath := filepath.Join(dirPath, f.Name())
-						if otherPath == filePath {
-							continue
-						}
-						content, err := ioutil.ReadFile(otherPath)
-						if err != nil {
-							continue
-						}
-						tree, err := a.parser.Parse(string(content))
-						if err != nil {
+						if otherPath == filePath || !a.resolver.BuildContext().MatchFile(otherPath) {
 							continue
 						}
-						otherSF, ok := tree.(*grammar.SourceFileContext)
-						if !ok {
-							continue
-						}
-						otherPkgName := otherSF.PackageClause().(*grammar.PackageClauseContext).Identifier().GetText()
-						// Allow _test.gala files to have different package names (like Go's _test.go convention)
-						isTestFile := strings.HasSuffix(f.Name(), "_test.gala") || strings.HasSuffix(filePath, "_test.gala")
-						if otherPkgName != pkgName && !isTestFile {
-							return nil, fmt.Errorf("multiple package names in directory %s: %s and %s", dirPath, pkgName, otherPkgName)
-						}
-						if otherPkgName == pkgName && !isTestFile {
-							siblingTrees = append(siblingTrees, otherSF)
-						}
+						others = append(others, otherPath)
+					}
+				}
+				for _, other := range a.parseFiles(others) {
+					otherSF := other.tree
+					if otherSF == nil {
+						continue
+					}
+					otherPkgName := otherSF.PackageClause().(*grammar.PackageClauseContext).Identifier().GetText()
+					// Allow _test.gala files to have different package names (like Go's _test.go convention)
+					isTestFile := strings.HasSuffix(other.path, "_test.gala") || strings.HasSuffix(filePath, "_test.gala")
+					if otherPkgName != pkgName && !isTestFile {
+						return nil, fmt.Errorf("multiple package names in directory %s: %s and %s", dirPath, pkgName, otherPkgName)
+					}
+					if otherPkgName == pkgName && !isTestFile {
+						siblingTrees = append(siblingTrees, otherSF)
 					}
 				}
 			}
@@ -193,18 +341,18 @@ func (a *galaAnalyzer) Analyze(tree antlr.Tree, filePath string) (*transpiler.Ri
 	// 0.25 Load std package metadata
 	// For non-std packages: add as implicit import
 	// For std package: still load for intra-package type resolution, but don't add to Packages
-	if cachedStd, ok := a.analyzedPkgs[registry.StdImportPath]; ok && cachedStd != nil {
+	if cachedStd, ok := a.cache.get(registry.StdImportPath); ok {
 		// Use cached std metadata
 		richAST.Merge(cachedStd)
 		if pkgName != registry.StdPackageName {
 			richAST.Packages[registry.StdImportPath] = registry.StdPackageName
 		}
-	} else if _, inProgress := a.analyzedPkgs[registry.StdImportPath]; !inProgress {
-		// First time analyzing std - set placeholder to prevent infinite recursion
-		a.analyzedPkgs[registry.StdImportPath] = nil
-		stdAST, err := a.analyzePackage(registry.StdPackageName)
+	} else if !state.started[registry.StdImportPath] {
+		// First time analyzing std in this call - mark it to prevent infinite recursion
+		state.started[registry.StdImportPath] = true
+		stdAST, err := a.analyzePackage(state, registry.StdPackageName)
 		if err == nil {
-			a.analyzedPkgs[registry.StdImportPath] = stdAST
+			a.cache.put(registry.StdImportPath, stdAST)
 			richAST.Merge(stdAST)
 			if pkgName != registry.StdPackageName {
 				richAST.Packages[registry.StdImportPath] = registry.StdPackageName
@@ -212,66 +360,41 @@ func (a *galaAnalyzer) Analyze(tree antlr.Tree, filePath string) (*transpiler.Ri
 		}
 	}
 
+	// 0.3 Analyze the imported packages concurrently; the imports below merge
+	// them from the cache in source order
+	a.prefetchImports(state, importPaths(sourceFile))
+
+	// 0.4 Load the project prelude
+	// Prelude packages are implicitly dot-imported into every package of the
+	// module except std and the prelude packages themselves
+	if pkgName != registry.StdPackageName && (filePath == "" || a.packageImportPath(filePath) != "") {
+		prelude, err := a.resolver.Config()
+		if err != nil {
+			return nil, err
+		}
+		ownPath := a.packageImportPath(filePath)
+		for _, path := range prelude.Prelude {
+			if path == ownPath {
+				continue
+			}
+			if !strings.HasPrefix(path, "martianoff/gala/") && !a.resolver.IsGalaPackage(path) {
+				return nil, fmt.Errorf("prelude package %s in %s is not a GALA package", path, module.ConfigFileName)
+			}
+			if err := a.importPackage(state, richAST, path, filePath, 0, 0); err != nil {
+				return nil, err
+			}
+			richAST.Prelude = append(richAST.Prelude, path)
+		}
+	}
+
 	// 0.5 Scan imports
 	for _, impDecl := range sourceFile.AllImportDeclaration() {
 		ctx := impDecl.(*grammar.ImportDeclarationContext)
 		for _, spec := range ctx.AllImportSpec() {
 			s := spec.(*grammar.ImportSpecContext)
 			path := strings.Trim(s.STRING().GetText(), "\"")
-
-			// Check if this is a GALA package (internal or external)
-			isInternalGala := strings.HasPrefix(path, "martianoff/gala/")
-			isExternalGala := a.resolver.IsGalaPackage(path)
-
-			if isInternalGala || isExternalGala {
-				// Determine how to resolve the package
-				var relPath string
-				if isInternalGala {
-					relPath = strings.TrimPrefix(path, "martianoff/gala/")
-				} else {
-					relPath = path // External packages use full path
-				}
-
-				if cached, ok := a.analyzedPkgs[path]; ok && cached != nil {
-					// Use cached metadata
-					richAST.Merge(cached)
-					if cached.PackageName != "" && cached.PackageName != "main" && cached.PackageName != "test" {
-						richAST.Packages[path] = cached.PackageName
-					}
-				} else if _, inProgress := a.analyzedPkgs[path]; !inProgress {
-					// First time analyzing this package - set placeholder to prevent infinite recursion
-					a.analyzedPkgs[path] = nil
-
-					// For external GALA packages, ensure they're transpiled
-					if isExternalGala && !isInternalGala {
-						if err := a.ensureTranspiled(path); err != nil {
-							// Log error but continue - we'll still try to analyze
-							fmt.Fprintf(os.Stderr, "Warning: failed to transpile dependency %s: %v\n", path, err)
-						}
-					}
-
-					importedAST, err := a.analyzePackage(relPath)
-					if err != nil {
-						line := s.GetStart().GetLine()
-						fmt.Fprintf(os.Stderr, "Warning: failed to analyze package %s (imported at line %d): %v\n", relPath, line, err)
-					}
-					if err == nil {
-						a.analyzedPkgs[path] = importedAST
-						richAST.Merge(importedAST)
-						// Store package name from the imported package
-						if importedAST.PackageName != "" && importedAST.PackageName != "main" && importedAST.PackageName != "test" {
-							richAST.Packages[path] = importedAST.PackageName
-						} else {
-							// Fallback if PackageName is not set properly
-							for _, typeMeta := range importedAST.Types {
-								if typeMeta.Package != "" && typeMeta.Package != "main" && typeMeta.Package != "test" && !registry.Global.IsPreludePackage(typeMeta.Package) {
-									richAST.Packages[path] = typeMeta.Package
-									break
-								}
-							}
-						}
-					}
-				}
+			if err := a.importPackage(state, richAST, path, filePath, s.GetStart().GetLine(), s.GetStart().GetColumn()); err != nil {
+				return nil, err
 			}
 		}
 	}
@@ -338,9 +461,14 @@ func (a *galaAnalyzer) Analyze(tree antlr.Tree, filePath string) (*transpiler.Ri
 					meta.ImmutFlags = append(meta.ImmutFlags, fctx.VAR() == nil)
 				}
 			}
+			if alias := ctx.TypeAlias(); alias != nil {
+				meta.AliasOf = a.resolveTypeWithParams(alias.Type_().GetText(), pkgName, meta.TypeParams)
+				meta.IsAlias = alias.GetAlias() != nil
+			}
 
 			// Extract interface method signatures as type methods
 			if ctx.InterfaceType() != nil {
+				meta.IsInterface = true
 				ifaceType := ctx.InterfaceType().(*grammar.InterfaceTypeContext)
 				for _, ms := range ifaceType.AllMethodSpec() {
 					msCtx := ms.(*grammar.MethodSpecContext)
@@ -369,11 +497,7 @@ func (a *galaAnalyzer) Analyze(tree antlr.Tree, filePath string) (*transpiler.Ri
 						if pList := pCtx.ParameterList(); pList != nil {
 							for _, p := range pList.(*grammar.ParameterListContext).AllParameter() {
 								paramCtx := p.(*grammar.ParameterContext)
-								if paramCtx.Type_() != nil {
-									methodMeta.ParamTypes = append(methodMeta.ParamTypes, a.resolveTypeWithParams(paramCtx.Type_().GetText(), pkgName, allTypeParams))
-								} else {
-									methodMeta.ParamTypes = append(methodMeta.ParamTypes, transpiler.NilType{})
-								}
+								methodMeta.ParamTypes = append(methodMeta.ParamTypes, a.paramType(paramCtx, pkgName, allTypeParams))
 							}
 						}
 					}
@@ -428,15 +552,23 @@ func (a *galaAnalyzer) Analyze(tree antlr.Tree, filePath string) (*transpiler.Ri
 						meta.ImmutFlags = append(meta.ImmutFlags, pctx.VAR() == nil)
 					}
 				}
+				a.collectInvariants(ctx, pkgName, meta)
 			}
 		}
 	}
 
-	// 1.5 Collect sealed types
+	// 1.5 Collect sealed types and newtypes
 	for _, topDecl := range sourceFile.AllTopLevelDeclaration() {
 		if sealedCtx := topDecl.SealedTypeDeclaration(); sealedCtx != nil {
 			a.analyzeSealedType(sealedCtx.(*grammar.SealedTypeDeclarationContext), pkgName, richAST)
 		}
+		if newtypeCtx := topDecl.NewtypeDeclaration(); newtypeCtx != nil {
+			ctx := newtypeCtx.(*grammar.NewtypeDeclarationContext)
+			if err := CheckStdConflict(ctx.Identifier().GetText(), pkgName); err != nil {
+				return nil, err
+			}
+			a.analyzeNewtype(ctx, pkgName, richAST)
+		}
 	}
 
 	// 2. Collect methods and functions
@@ -454,8 +586,9 @@ func (a *galaAnalyzer) Analyze(tree antlr.Tree, filePath string) (*transpiler.Ri
 					}
 
 					methodMeta := &transpiler.MethodMetadata{
-						Name:    methodName,
-						Package: pkgName,
+						Name:            methodName,
+						Package:         pkgName,
+						PointerReceiver: strings.HasPrefix(recvCtx.Type_().GetText(), "*"),
 					}
 					if ctx.TypeParameters() != nil {
 						tpCtx := ctx.TypeParameters().(*grammar.TypeParametersContext)
@@ -493,11 +626,7 @@ func (a *galaAnalyzer) Analyze(tree antlr.Tree, filePath string) (*transpiler.Ri
 						if pList := pCtx.ParameterList(); pList != nil {
 							for _, p := range pList.(*grammar.ParameterListContext).AllParameter() {
 								paramCtx := p.(*grammar.ParameterContext)
-								if paramCtx.Type_() != nil {
-									methodMeta.ParamTypes = append(methodMeta.ParamTypes, a.resolveTypeWithParams(paramCtx.Type_().GetText(), pkgName, allTypeParams))
-								} else {
-									methodMeta.ParamTypes = append(methodMeta.ParamTypes, transpiler.NilType{})
-								}
+								methodMeta.ParamTypes = append(methodMeta.ParamTypes, a.paramType(paramCtx, pkgName, allTypeParams))
 							}
 						}
 					}
@@ -555,11 +684,7 @@ func (a *galaAnalyzer) Analyze(tree antlr.Tree, filePath string) (*transpiler.Ri
 					if pList := pCtx.ParameterList(); pList != nil {
 						for _, p := range pList.(*grammar.ParameterListContext).AllParameter() {
 							paramCtx := p.(*grammar.ParameterContext)
-							if paramCtx.Type_() != nil {
-								funcMeta.ParamTypes = append(funcMeta.ParamTypes, a.resolveTypeWithParams(paramCtx.Type_().GetText(), pkgName, funcMeta.TypeParams))
-							} else {
-								funcMeta.ParamTypes = append(funcMeta.ParamTypes, transpiler.NilType{})
-							}
+							funcMeta.ParamTypes = append(funcMeta.ParamTypes, a.paramType(paramCtx, pkgName, funcMeta.TypeParams))
 						}
 					}
 				}
@@ -573,7 +698,7 @@ func (a *galaAnalyzer) Analyze(tree antlr.Tree, filePath string) (*transpiler.Ri
 	// to enable cross-file type resolution even in main/test packages.
 	// When using directory scanning, only extract method/function signatures for non-main packages
 	// to avoid interfering with isImmutableField and .Get() auto-unwrapping.
-	if len(a.packageFiles) > 0 {
+	if len(state.packageFiles) > 0 {
 		// Explicit package files: full metadata extraction for ALL packages including main/test
 		for _, sibTree := range siblingTrees {
 			a.extractSiblingFullMetadata(sibTree, pkgName, richAST)
@@ -585,12 +710,101 @@ func (a *galaAnalyzer) Analyze(tree antlr.Tree, filePath string) (*transpiler.Ri
 		}
 	}
 
+	// 2.6 Attach annotations now that every declaration has metadata
+	if err := a.applyAnnotations(sourceFile, pkgName, richAST); err != nil {
+		return nil, err
+	}
+	for _, sibTree := range siblingTrees {
+		if err := a.applyAnnotations(sibTree, pkgName, richAST); err != nil {
+			return nil, err
+		}
+	}
+
+	// 2.7 Record visibility modifiers, then check this file's references against them.
+	// Imported packages are checked when they are compiled themselves.
+	if err := collectVisibility(sourceFile, pkgName, richAST); err != nil {
+		return nil, err
+	}
+	for _, sibTree := range siblingTrees {
+		if err := collectVisibility(sibTree, pkgName, richAST); err != nil {
+			return nil, err
+		}
+	}
+	if state.importDepth == 0 {
+		if err := a.checkVisibility(sourceFile, richAST); err != nil {
+			return nil, err
+		}
+		if err := checkSelectiveImports(sourceFile, pkgName, richAST); err != nil {
+			return nil, err
+		}
+		if err := checkInitBlocks(sourceFile, siblingTrees); err != nil {
+			return nil, err
+		}
+	}
+
+	// 2.8 See through type aliases now that the package's aliases are all known
+	expandTypeAliases(richAST, pkgName)
+
 	// 3. Discover companion objects - types with Unapply methods that can be used for pattern matching
-	a.discoverCompanionObjects(richAST)
+	if err := a.discoverCompanionObjects(sourceFile, richAST); err != nil {
+		return nil, err
+	}
 
 	return richAST, nil
 }
 
+// analyzeNewtype registers metadata for a newtype declaration: the type with
+// its underlying type and Value method, and its From constructor.
+func (a *galaAnalyzer) analyzeNewtype(ctx *grammar.NewtypeDeclarationContext, pkgName string, richAST *transpiler.RichAST) {
+	typeName := ctx.Identifier().GetText()
+	fullTypeName := typeName
+	if pkgName != "" && pkgName != "main" && pkgName != "test" {
+		fullTypeName = pkgName + "." + typeName
+	}
+	underlying := a.resolveTypeWithParams(ctx.Type_().GetText(), pkgName, nil)
+
+	// Keep methods that were collected before the declaration was seen
+	methods := make(map[string]*transpiler.MethodMetadata)
+	if existing, ok := richAST.Types[fullTypeName]; ok && existing.Package == pkgName {
+		for k, v := range existing.Methods {
+			methods[k] = v
+		}
+	}
+	methods["Value"] = &transpiler.MethodMetadata{
+		Name:       "Value",
+		Package:    pkgName,
+		ReturnType: underlying,
+	}
+	richAST.Types[fullTypeName] = &transpiler.TypeMetadata{
+		Name:       typeName,
+		Package:    pkgName,
+		Methods:    methods,
+		Fields:     make(map[string]transpiler.Type),
+		Underlying: underlying,
+	}
+
+	self := a.resolveTypeWithParams(typeName, pkgName, nil)
+	richAST.Functions[fullTypeName+transpiler.NewtypeFromSuffix] = &transpiler.FunctionMetadata{
+		Name:       typeName + transpiler.NewtypeFromSuffix,
+		Package:    pkgName,
+		ParamTypes: []transpiler.Type{underlying},
+		ReturnType: self,
+	}
+}
+
+// analyzeSiblingNewtype registers a newtype declared in a sibling file unless
+// the file being analyzed already did.
+func (a *galaAnalyzer) analyzeSiblingNewtype(ctx *grammar.NewtypeDeclarationContext, pkgName string, richAST *transpiler.RichAST) {
+	fullTypeName := ctx.Identifier().GetText()
+	if pkgName != "" && pkgName != "main" && pkgName != "test" {
+		fullTypeName = pkgName + "." + fullTypeName
+	}
+	if existing, ok := richAST.Types[fullTypeName]; ok && existing.Underlying != nil {
+		return
+	}
+	a.analyzeNewtype(ctx, pkgName, richAST)
+}
+
 // analyzeSealedType registers metadata for a sealed type declaration.
 // It creates the parent type (with all variant fields merged + _variant),
 // companion types for each case, and Apply/Unapply/IsXxx methods.
@@ -698,7 +912,12 @@ func (a *galaAnalyzer) analyzeSealedType(ctx *grammar.SealedTypeDeclarationConte
 	for _, vi := range variants {
 		sv := transpiler.SealedVariant{Name: vi.name}
 		for _, f := range vi.fields {
+			structFieldName := f.name
+			if conflictingFields[f.name] {
+				structFieldName = vi.name + f.name
+			}
 			sv.FieldNames = append(sv.FieldNames, f.name)
+			sv.StructFieldNames = append(sv.StructFieldNames, structFieldName)
 			sv.FieldTypes = append(sv.FieldTypes, a.resolveTypeWithParams(f.typeName, pkgName, typeParams))
 		}
 		parentMeta.SealedVariants = append(parentMeta.SealedVariants, sv)
@@ -706,6 +925,17 @@ func (a *galaAnalyzer) analyzeSealedType(ctx *grammar.SealedTypeDeclarationConte
 
 	richAST.Types[fullTypeName] = parentMeta
 
+	// TypeVariants lists the cases
+	richAST.Functions[fullTypeName+transpiler.SealedVariantsSuffix] = &transpiler.FunctionMetadata{
+		Name:       typeName + transpiler.SealedVariantsSuffix,
+		Package:    pkgName,
+		ReturnType: transpiler.ArrayType{Elem: transpiler.NamedType{Package: registry.StdPackageName, Name: "SealedVariant"}},
+	}
+
+	// A @compact sealed type builds its variants with one constructor function
+	// each; there are no companion types and so no Unapply
+	compact := declaredWith(ctx, transpiler.AnnotationCompact)
+
 	// For each variant, create companion type and register methods
 	for _, vi := range variants {
 		companionName := vi.name
@@ -745,6 +975,16 @@ func (a *galaAnalyzer) analyzeSealedType(ctx *grammar.SealedTypeDeclarationConte
 				applyMeta.ReturnType = transpiler.BasicType{Name: typeName}
 			}
 		}
+		if compact {
+			richAST.Functions[fullCompanionName] = &transpiler.FunctionMetadata{
+				Name:       companionName,
+				Package:    pkgName,
+				ParamTypes: applyMeta.ParamTypes,
+				ReturnType: applyMeta.ReturnType,
+				TypeParams: typeParams,
+			}
+			continue
+		}
 		companionMeta.Methods["Apply"] = applyMeta
 
 		// Unapply method
@@ -788,6 +1028,16 @@ func (a *galaAnalyzer) analyzeSealedType(ctx *grammar.SealedTypeDeclarationConte
 		richAST.Types[fullCompanionName] = companionMeta
 	}
 
+	// A compact sealed type reports its variant through a single Variant method
+	if compact {
+		parentMeta.Methods["Variant"] = &transpiler.MethodMetadata{
+			Name:       "Variant",
+			Package:    pkgName,
+			ReturnType: transpiler.BasicType{Name: "string"},
+		}
+		return
+	}
+
 	// Register isXxx() methods on parent type (private)
 	for _, vi := range variants {
 		isMethodName := "is" + vi.name
@@ -802,8 +1052,12 @@ func (a *galaAnalyzer) analyzeSealedType(ctx *grammar.SealedTypeDeclarationConte
 // discoverCompanionObjects identifies types that can be used as pattern extractors.
 // A companion object is a type that has an Unapply method and optionally an Apply method.
 // From the Apply method, we can determine what container type it works with and which
-// type parameter indices are extracted.
-func (a *galaAnalyzer) discoverCompanionObjects(richAST *transpiler.RichAST) {
+// type parameter indices are extracted. The Unapply methods declared in sf must extract
+// as many values as the Apply of their type takes.
+func (a *galaAnalyzer) discoverCompanionObjects(sf *grammar.SourceFileContext, richAST *transpiler.RichAST) error {
+	if err := checkCompanionShapes(sf, richAST); err != nil {
+		return err
+	}
 	for typeName, meta := range richAST.Types {
 		// Check if this type has an Unapply method
 		if _, hasUnapply := meta.Methods["Unapply"]; !hasUnapply {
@@ -857,6 +1111,104 @@ func (a *galaAnalyzer) discoverCompanionObjects(richAST *transpiler.RichAST) {
 			richAST.CompanionObjects[typeName] = companionMeta
 		}
 	}
+	return nil
+}
+
+// checkCompanionShapes reports an Unapply method declared in sf whose type
+// has an Apply taking a different number of values than Unapply extracts:
+// Pair(a, b) builds a Pair from two values, so case Pair(a, b) must get two
+// back. Apply without parameters is not checked, as for None or Nil the
+// pattern binds nothing whatever Unapply returns.
+func checkCompanionShapes(sf *grammar.SourceFileContext, richAST *transpiler.RichAST) error {
+	for _, topDecl := range sf.AllTopLevelDeclaration() {
+		if topDecl.FunctionDeclaration() == nil {
+			continue
+		}
+		ctx := topDecl.FunctionDeclaration().(*grammar.FunctionDeclarationContext)
+		if ctx.Receiver() == nil || ctx.Identifier().GetText() != "Unapply" {
+			continue
+		}
+		typeName := getBaseTypeName(ctx.Receiver().(*grammar.ReceiverContext).Type_())
+		meta, ok := richAST.Types[transpiler.QualifiedName(richAST.PackageName, typeName)]
+		if !ok {
+			continue
+		}
+		apply, unapply := meta.Methods["Apply"], meta.Methods["Unapply"]
+		if apply == nil || unapply == nil || len(apply.ParamTypes) == 0 {
+			continue
+		}
+		extracted, known := unapplyArity(unapply.ReturnType)
+		if !known || extracted == len(apply.ParamTypes) {
+			continue
+		}
+		// A single tuple parameter may be taken apart by the pattern
+		if len(apply.ParamTypes) == 1 && tupleArity(apply.ParamTypes[0]) == extracted {
+			continue
+		}
+		line, col := ctx.GetStart().GetLine(), ctx.GetStart().GetColumn()
+		msg := fmt.Sprintf("Unapply of %s extracts %s but Apply takes %s:\n  %s\n  %s", typeName,
+			countOf(extracted, "value"), countOf(len(apply.ParamTypes), "parameter"),
+			methodSignature(apply), methodSignature(unapply))
+		return galaerr.NewSemanticErrorAt(line, col, msg).WithCode(galaerr.CodeExtractorShape)
+	}
+	return nil
+}
+
+// unapplyArity returns how many values an Unapply returning typ extracts:
+// none for bool, the size of the tuple for Option of a tuple and one for
+// any other Option.
+func unapplyArity(typ transpiler.Type) (int, bool) {
+	switch t := typ.(type) {
+	case transpiler.BasicType:
+		return 0, t.Name == "bool"
+	case transpiler.GenericType:
+		if base := t.Base.BaseName(); (base == "Option" || base == "std.Option") && len(t.Params) == 1 {
+			if n := tupleArity(t.Params[0]); n > 0 {
+				return n, true
+			}
+			return 1, true
+		}
+	}
+	return 0, false
+}
+
+// tupleArity returns the number of elements of typ if it is one of std's
+// tuple types, or 0.
+func tupleArity(typ transpiler.Type) int {
+	g, ok := typ.(transpiler.GenericType)
+	if !ok {
+		return 0
+	}
+	name := strings.TrimPrefix(g.Base.BaseName(), registry.StdPackageName+".")
+	if name != transpiler.TypeTuple && name != fmt.Sprintf("%s%d", transpiler.TypeTuple, len(g.Params)) {
+		return 0
+	}
+	return len(g.Params)
+}
+
+// methodSignature renders the parameter and result types of m, as in
+// Apply(int, string) Pair.
+func methodSignature(m *transpiler.MethodMetadata) string {
+	params := make([]string, len(m.ParamTypes))
+	for i, p := range m.ParamTypes {
+		params[i] = p.String()
+	}
+	sig := m.Name + "(" + strings.Join(params, ", ") + ")"
+	if m.ReturnType != nil && !m.ReturnType.IsNil() {
+		sig += " " + m.ReturnType.String()
+	}
+	return sig
+}
+
+// countOf renders n things, as in "no values", "1 value" or "2 values".
+func countOf(n int, thing string) string {
+	switch n {
+	case 0:
+		return "no " + thing + "s"
+	case 1:
+		return "1 " + thing
+	}
+	return fmt.Sprintf("%d %ss", n, thing)
 }
 
 // computeExtractIndices determines which type parameter indices are extracted by a companion object.
@@ -982,6 +1334,19 @@ func extractBaseAndArgs(typeStr string) (string, []string) {
 	return base, args
 }
 
+// paramType resolves the declared type of a parameter. A by-name parameter
+// (x => T) has type func() T marked ByName; an untyped one has NilType.
+func (a *galaAnalyzer) paramType(ctx *grammar.ParameterContext, pkgName string, typeParams []string) transpiler.Type {
+	if ctx.Type_() == nil {
+		return transpiler.NilType{}
+	}
+	typ := a.resolveTypeWithParams(ctx.Type_().GetText(), pkgName, typeParams)
+	if ctx.GetByName() != nil {
+		return transpiler.FuncType{Results: []transpiler.Type{typ}, ByName: true}
+	}
+	return typ
+}
+
 func (a *galaAnalyzer) resolveType(typeName string, pkgName string) transpiler.Type {
 	return a.resolveTypeWithParams(typeName, pkgName, nil)
 }
@@ -1176,13 +1541,17 @@ func (a *galaAnalyzer) isStdType(name string) bool {
 	return registry.IsStdType(name)
 }
 
-func (a *galaAnalyzer) analyzePackage(relPath string) (*transpiler.RichAST, error) {
+func (a *galaAnalyzer) analyzePackage(state *analysis, relPath string) (*transpiler.RichAST, error) {
 	// Save and clear packageFiles to prevent them from interfering with recursive
-	// Analyze calls. packageFiles are specific to the current compilation unit's package
+	// analyze calls. packageFiles are specific to the current compilation unit's package
 	// and must not be applied when analyzing other packages (e.g., std).
-	savedPackageFiles := a.packageFiles
-	a.packageFiles = nil
-	defer func() { a.packageFiles = savedPackageFiles }()
+	savedPackageFiles := state.packageFiles
+	state.packageFiles = nil
+	state.importDepth++
+	defer func() {
+		state.packageFiles = savedPackageFiles
+		state.importDepth--
+	}()
 
 	// Use the resolver to find the package directory
 	dirPath, err := a.resolver.ResolvePackagePath(relPath)
@@ -1202,31 +1571,28 @@ func (a *galaAnalyzer) analyzePackage(relPath string) (*transpiler.RichAST, erro
 		CompanionObjects: make(map[string]*transpiler.CompanionObjectMetadata),
 	}
 
-	for _, f := range files {
-		// Skip test files — they are not part of the package's public API and may have
-		// different package names (e.g., package main for benchmark binaries).
-		if !f.IsDir() && filepath.Ext(f.Name()) == ".gala" && !strings.HasSuffix(f.Name(), "_test.gala") {
-			filePath := filepath.Join(dirPath, f.Name())
-			content, err := ioutil.ReadFile(filePath)
-			if err != nil {
-				continue
-			}
-			tree, err := a.parser.Parse(string(content))
-			if err != nil {
-				continue
-			}
-			res, err := a.Analyze(tree, filePath)
-			if err == nil {
-				if pkgAST.PackageName == "" {
-					pkgAST.PackageName = res.PackageName
-				} else if pkgAST.PackageName != res.PackageName {
-					return nil, fmt.Errorf("multiple package names in directory %s: %s and %s", dirPath, pkgAST.PackageName, res.PackageName)
-				}
-				pkgAST.Merge(res)
+	// The files are parsed concurrently and analyzed in order
+	for _, file := range a.parseFiles(a.packageGalaFiles(dirPath, files)) {
+		if file.tree == nil {
+			continue
+		}
+		res, err := a.analyze(state, file.tree, file.path)
+		if galaerr.CodeOf(err) == galaerr.CodeImportCycle {
+			return nil, err
+		}
+		if err == nil {
+			if pkgAST.PackageName == "" {
+				pkgAST.PackageName = res.PackageName
+			} else if pkgAST.PackageName != res.PackageName {
+				return nil, fmt.Errorf("multiple package names in directory %s: %s and %s", dirPath, pkgAST.PackageName, res.PackageName)
 			}
+			mergePackageFile(pkgAST, res)
 		}
 	}
 
+	// Aliases declared in another file of the package are only known now
+	expandTypeAliases(pkgAST, pkgAST.PackageName)
+
 	// For Go-only packages (no .gala files), scan .go files for exported symbols.
 	// This enables the transpiler to warn when two dot-imported packages export the same symbol.
 	hasGalaFiles := false
@@ -1243,6 +1609,61 @@ func (a *galaAnalyzer) analyzePackage(relPath string) (*transpiler.RichAST, erro
 	return pkgAST, nil
 }
 
+// packageGalaFiles returns the paths of the .gala files among files, the
+// entries of the package directory dirPath, that are built for the target.
+func (a *galaAnalyzer) packageGalaFiles(dirPath string, files []os.FileInfo) []string {
+	var galaFiles []string
+	for _, f := range files {
+		// Skip test files — they are not part of the package's public API and may have
+		// different package names (e.g., package main for benchmark binaries).
+		if !f.IsDir() && filepath.Ext(f.Name()) == ".gala" && !strings.HasSuffix(f.Name(), "_test.gala") {
+			filePath := filepath.Join(dirPath, f.Name())
+			if a.resolver.BuildContext().MatchFile(filePath) {
+				galaFiles = append(galaFiles, filePath)
+			}
+		}
+	}
+	return galaFiles
+}
+
+// mergePackageFile merges the analysis of one file of a package into pkgAST.
+// RichAST.Merge replaces types wholesale, which drops methods (and their annotations)
+// when a type and its methods live in different files: each file only sees a partial
+// view of the type. Here the views are combined, keeping the declared fields and every
+// method. Merged types are copied so metadata shared with other caches is not mutated.
+func mergePackageFile(pkgAST, res *transpiler.RichAST) {
+	prev := make(map[string]*transpiler.TypeMetadata, len(pkgAST.Types))
+	for name, meta := range pkgAST.Types {
+		prev[name] = meta
+	}
+	pkgAST.Merge(res)
+
+	for name, old := range prev {
+		cur := pkgAST.Types[name]
+		if cur == old || cur.Package != pkgAST.PackageName || old.Package != pkgAST.PackageName {
+			continue
+		}
+		merged := *cur
+		if len(cur.FieldNames) == 0 && len(old.FieldNames) > 0 {
+			// cur only holds the methods declared in res's file
+			merged = *old
+		}
+		// Methods analyzed in res's own file win over signatures seen from a sibling
+		merged.Methods = make(map[string]*transpiler.MethodMetadata, len(old.Methods)+len(cur.Methods))
+		for m, mm := range old.Methods {
+			merged.Methods[m] = mm
+		}
+		for m, mm := range cur.Methods {
+			merged.Methods[m] = mm
+		}
+		merged.Annotations = cur.Annotations
+		if len(merged.Annotations) == 0 {
+			merged.Annotations = old.Annotations
+		}
+		pkgAST.Types[name] = &merged
+	}
+}
+
 // goExportedFuncRe matches exported (capitalized) standalone function declarations in Go files.
 // Only matches top-level functions, not methods (which have a receiver before the name).
 var goExportedFuncRe = regexp.MustCompile(`(?m)^func\s+([A-Z]\w*)\s*[\[(]`)
@@ -1363,11 +1784,10 @@ func (a *galaAnalyzer) ensureTranspiled(importPath string) error {
 		// Analyze without recursion by using a separate analyzer
 		// This avoids circular dependency issues
 		tempAnalyzer := &galaAnalyzer{
-			parser:       a.parser,
-			searchPaths:  a.searchPaths,
-			analyzedPkgs: make(map[string]*transpiler.RichAST),
-			checkedDirs:  make(map[string]bool),
-			resolver:     a.resolver,
+			parser:      a.parser,
+			searchPaths: a.searchPaths,
+			cache:       NewPackageCache(),
+			resolver:    a.resolver,
 		}
 
 		richAST, err := tempAnalyzer.Analyze(tree, srcPath)
@@ -1476,7 +1896,12 @@ func (a *galaAnalyzer) extractSiblingFullMetadata(sibTree *grammar.SourceFileCon
 					meta.ImmutFlags = append(meta.ImmutFlags, fctx.VAR() == nil)
 				}
 			}
+			if alias := ctx.TypeAlias(); alias != nil {
+				meta.AliasOf = a.resolveTypeWithParams(alias.Type_().GetText(), pkgName, meta.TypeParams)
+				meta.IsAlias = alias.GetAlias() != nil
+			}
 			if ctx.InterfaceType() != nil {
+				meta.IsInterface = true
 				ifaceType := ctx.InterfaceType().(*grammar.InterfaceTypeContext)
 				for _, ms := range ifaceType.AllMethodSpec() {
 					msCtx := ms.(*grammar.MethodSpecContext)
@@ -1505,11 +1930,7 @@ func (a *galaAnalyzer) extractSiblingFullMetadata(sibTree *grammar.SourceFileCon
 						if pList := pCtx.ParameterList(); pList != nil {
 							for _, p := range pList.(*grammar.ParameterListContext).AllParameter() {
 								paramCtx := p.(*grammar.ParameterContext)
-								if paramCtx.Type_() != nil {
-									methodMeta.ParamTypes = append(methodMeta.ParamTypes, a.resolveTypeWithParams(paramCtx.Type_().GetText(), pkgName, allTypeParams))
-								} else {
-									methodMeta.ParamTypes = append(methodMeta.ParamTypes, transpiler.NilType{})
-								}
+								methodMeta.ParamTypes = append(methodMeta.ParamTypes, a.paramType(paramCtx, pkgName, allTypeParams))
 							}
 						}
 					}
@@ -1557,13 +1978,17 @@ func (a *galaAnalyzer) extractSiblingFullMetadata(sibTree *grammar.SourceFileCon
 						meta.ImmutFlags = append(meta.ImmutFlags, pctx.VAR() == nil)
 					}
 				}
+				a.collectInvariants(ctx, pkgName, meta)
 			}
 			richAST.Types[fullTypeName] = meta
 		}
 	}
 
-	// 2. Collect sealed types
+	// 2. Collect sealed types and newtypes
 	for _, topDecl := range sibTree.AllTopLevelDeclaration() {
+		if newtypeCtx := topDecl.NewtypeDeclaration(); newtypeCtx != nil {
+			a.analyzeSiblingNewtype(newtypeCtx.(*grammar.NewtypeDeclarationContext), pkgName, richAST)
+		}
 		if sealedCtx := topDecl.SealedTypeDeclaration(); sealedCtx != nil {
 			ctx := sealedCtx.(*grammar.SealedTypeDeclarationContext)
 			typeName := ctx.Identifier().GetText()
@@ -1597,8 +2022,9 @@ func (a *galaAnalyzer) extractSiblingFullMetadata(sibTree *grammar.SourceFileCon
 				}
 
 				methodMeta := &transpiler.MethodMetadata{
-					Name:    methodName,
-					Package: pkgName,
+					Name:            methodName,
+					Package:         pkgName,
+					PointerReceiver: strings.HasPrefix(recvCtx.Type_().GetText(), "*"),
 				}
 				if ctx.TypeParameters() != nil {
 					tpCtx := ctx.TypeParameters().(*grammar.TypeParametersContext)
@@ -1624,11 +2050,7 @@ func (a *galaAnalyzer) extractSiblingFullMetadata(sibTree *grammar.SourceFileCon
 					if pList := pCtx.ParameterList(); pList != nil {
 						for _, p := range pList.(*grammar.ParameterListContext).AllParameter() {
 							paramCtx := p.(*grammar.ParameterContext)
-							if paramCtx.Type_() != nil {
-								methodMeta.ParamTypes = append(methodMeta.ParamTypes, a.resolveTypeWithParams(paramCtx.Type_().GetText(), pkgName, allTypeParams))
-							} else {
-								methodMeta.ParamTypes = append(methodMeta.ParamTypes, transpiler.NilType{})
-							}
+							methodMeta.ParamTypes = append(methodMeta.ParamTypes, a.paramType(paramCtx, pkgName, allTypeParams))
 						}
 					}
 				}
@@ -1673,11 +2095,7 @@ func (a *galaAnalyzer) extractSiblingFullMetadata(sibTree *grammar.SourceFileCon
 						if pList := pCtx.ParameterList(); pList != nil {
 							for _, p := range pList.(*grammar.ParameterListContext).AllParameter() {
 								paramCtx := p.(*grammar.ParameterContext)
-								if paramCtx.Type_() != nil {
-									funcMeta.ParamTypes = append(funcMeta.ParamTypes, a.resolveTypeWithParams(paramCtx.Type_().GetText(), pkgName, funcMeta.TypeParams))
-								} else {
-									funcMeta.ParamTypes = append(funcMeta.ParamTypes, transpiler.NilType{})
-								}
+								funcMeta.ParamTypes = append(funcMeta.ParamTypes, a.paramType(paramCtx, pkgName, funcMeta.TypeParams))
 							}
 						}
 					}
@@ -1722,6 +2140,9 @@ func (a *galaAnalyzer) extractSiblingMethodSignatures(sibTree *grammar.SourceFil
 				richAST.Types[fullTypeName] = meta
 			}
 		}
+		if newtypeCtx := topDecl.NewtypeDeclaration(); newtypeCtx != nil {
+			a.analyzeSiblingNewtype(newtypeCtx.(*grammar.NewtypeDeclarationContext), pkgName, richAST)
+		}
 	}
 
 	// Second pass: collect method and function signatures
@@ -1742,8 +2163,9 @@ func (a *galaAnalyzer) extractSiblingMethodSignatures(sibTree *grammar.SourceFil
 				}
 
 				methodMeta := &transpiler.MethodMetadata{
-					Name:    methodName,
-					Package: pkgName,
+					Name:            methodName,
+					Package:         pkgName,
+					PointerReceiver: strings.HasPrefix(recvCtx.Type_().GetText(), "*"),
 				}
 				if ctx.TypeParameters() != nil {
 					tpCtx := ctx.TypeParameters().(*grammar.TypeParametersContext)
@@ -1769,11 +2191,7 @@ func (a *galaAnalyzer) extractSiblingMethodSignatures(sibTree *grammar.SourceFil
 					if pList := pCtx.ParameterList(); pList != nil {
 						for _, p := range pList.(*grammar.ParameterListContext).AllParameter() {
 							paramCtx := p.(*grammar.ParameterContext)
-							if paramCtx.Type_() != nil {
-								methodMeta.ParamTypes = append(methodMeta.ParamTypes, a.resolveTypeWithParams(paramCtx.Type_().GetText(), pkgName, allTypeParams))
-							} else {
-								methodMeta.ParamTypes = append(methodMeta.ParamTypes, transpiler.NilType{})
-							}
+							methodMeta.ParamTypes = append(methodMeta.ParamTypes, a.paramType(paramCtx, pkgName, allTypeParams))
 						}
 					}
 				}
@@ -1814,11 +2232,7 @@ func (a *galaAnalyzer) extractSiblingMethodSignatures(sibTree *grammar.SourceFil
 						if pList := pCtx.ParameterList(); pList != nil {
 							for _, p := range pList.(*grammar.ParameterListContext).AllParameter() {
 								paramCtx := p.(*grammar.ParameterContext)
-								if paramCtx.Type_() != nil {
-									funcMeta.ParamTypes = append(funcMeta.ParamTypes, a.resolveTypeWithParams(paramCtx.Type_().GetText(), pkgName, funcMeta.TypeParams))
-								} else {
-									funcMeta.ParamTypes = append(funcMeta.ParamTypes, transpiler.NilType{})
-								}
+								funcMeta.ParamTypes = append(funcMeta.ParamTypes, a.paramType(paramCtx, pkgName, funcMeta.TypeParams))
 							}
 						}
 					}
@@ -1,10 +1,14 @@
 package analyzer_test
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sync"
 	"testing"
 
+	"martianoff/gala/galaerr"
 	"martianoff/gala/internal/transpiler"
 	"martianoff/gala/internal/transpiler/analyzer"
 
@@ -123,6 +127,52 @@ func (e External) Action() = 1`,
 				assert.Empty(t, meta.Fields)
 			},
 		},
+		{
+			name: "Annotations",
+			input: `package main
+
+@noCopy
+@deprecated("use B")
+struct A(x int)
+
+@inline
+func double(n int) int = n * 2
+
+@deprecated
+func (a A) Get() int = a.x
+
+type C struct {
+	@equalIgnore
+	cache map[string]int
+	id int
+}`,
+			validate: func(t *testing.T, ast *transpiler.RichAST) {
+				assert.Equal(t, []transpiler.Annotation{{Name: "noCopy"}, {Name: "deprecated", Arg: "use B"}}, ast.Types["A"].Annotations)
+				assert.Equal(t, map[string][]transpiler.Annotation{"cache": {{Name: "equalIgnore"}}}, ast.Types["C"].FieldAnnotations)
+				assert.Equal(t, []transpiler.Annotation{{Name: "inline"}}, ast.Functions["double"].Annotations)
+				assert.Equal(t, []transpiler.Annotation{{Name: "deprecated"}}, ast.Types["A"].Methods["Get"].Annotations)
+			},
+		},
+		{
+			name: "Visibility modifiers",
+			input: `package main
+
+private val (lo, hi) = (0, 10)
+
+internal struct Config(Limit int)
+
+private func helper() int = 1
+
+func Public() int = helper()`,
+			validate: func(t *testing.T, ast *transpiler.RichAST) {
+				assert.Equal(t, map[string]transpiler.Visibility{
+					"lo":     transpiler.VisibilityPrivate,
+					"hi":     transpiler.VisibilityPrivate,
+					"Config": transpiler.VisibilityInternal,
+					"helper": transpiler.VisibilityPrivate,
+				}, ast.Visibility)
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -181,6 +231,66 @@ func TestCompanionObjectDiscovery(t *testing.T) {
 	})
 }
 
+func TestCompanionShapes(t *testing.T) {
+	const box = "package main\n\nstruct Box(A int, B int)\n\ntype Pair struct {}\n\n"
+	tests := []struct {
+		name    string
+		input   string
+		wantErr []string
+	}{
+		{
+			name:  "tuple of as many values as Apply takes",
+			input: box + "func (p Pair) Apply(a int, b int) Box = Box(a, b)\nfunc (p Pair) Unapply(b Box) Option[Tuple[int, int]] = Some((b.A, b.B))",
+		},
+		{
+			name:  "single value",
+			input: "package main\n\nstruct Wrap[T any](Value T)\n\ntype Wrapper[T any] struct {}\n\nfunc (w Wrapper[T]) Apply(v T) Wrap[T] = Wrap[T](Value = v)\nfunc (w Wrapper[T]) Unapply(o Wrap[T]) Option[T] = Some[T](o.Value)",
+		},
+		{
+			name:  "tuple parameter taken apart",
+			input: box + "func (p Pair) Apply(t Tuple[int, int]) Box = Box(t.V1, t.V2)\nfunc (p Pair) Unapply(b Box) Option[Tuple[int, int]] = Some((b.A, b.B))",
+		},
+		{
+			name:  "Apply without parameters",
+			input: box + "func (p Pair) Apply() Box = Box(0, 0)\nfunc (p Pair) Unapply(b Box) Option[Tuple[int, int]] = Some((b.A, b.B))",
+		},
+		{
+			name:  "fewer values than Apply takes",
+			input: box + "func (p Pair) Apply(a int, b int) Box = Box(a, b)\nfunc (p Pair) Unapply(b Box) Option[int] = Some(b.A + b.B)",
+			wantErr: []string{
+				"Unapply of Pair extracts 1 value but Apply takes 2 parameters",
+				"Apply(int, int) Box",
+				"Unapply(Box) ",
+			},
+		},
+		{
+			name:    "guard for a constructor",
+			input:   box + "func (p Pair) Apply(a int) Box = Box(a, a)\nfunc (p Pair) Unapply(b Box) bool = b.A == b.B",
+			wantErr: []string{"Unapply of Pair extracts no values but Apply takes 1 parameter"},
+		},
+	}
+
+	searchPaths := []string{"../../../", "../../", "../"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := transpiler.NewAntlrGalaParser()
+			a := analyzer.NewGalaAnalyzer(p, searchPaths)
+			tree, err := p.Parse(tt.input)
+			require.NoError(t, err)
+
+			_, err = a.Analyze(tree, "")
+			if len(tt.wantErr) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			for _, want := range tt.wantErr {
+				assert.ErrorContains(t, err, want)
+			}
+			assert.Equal(t, galaerr.CodeExtractorShape, galaerr.CodeOf(err))
+		})
+	}
+}
+
 func TestPackageFilesFullMetadata(t *testing.T) {
 	p := transpiler.NewAntlrGalaParser()
 	searchPaths := getStdSearchPath()
@@ -280,6 +390,163 @@ func (p Person) Greet() string = fmt.Sprintf("Hi %s", p.Name)
 	})
 }
 
+func TestVisibility(t *testing.T) {
+	tempDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "go.mod"), []byte("module testmod\n\ngo 1.21\n"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(tempDir, "geo"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "geo", "geo.gala"), []byte(`package geo
+
+private func origin() int = 0
+
+internal func Origin() int = origin()
+
+func Zero() int = Origin()
+`), 0644))
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(tempDir))
+
+	// "martianoff/gala/geo" resolves to the same directory but belongs to another module
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{
+			name:  "public declaration",
+			input: "package main\n\nimport \"testmod/geo\"\n\nval z = geo.Zero()",
+		},
+		{
+			name:  "internal declaration from the same module",
+			input: "package main\n\nimport \"testmod/geo\"\n\nval o = geo.Origin()",
+		},
+		{
+			name:    "private declaration from another package",
+			input:   "package main\n\nimport \"testmod/geo\"\n\nval o = geo.origin()",
+			wantErr: "geo.origin is private to package geo",
+		},
+		{
+			name:    "internal declaration from another module",
+			input:   "package main\n\nimport g \"martianoff/gala/geo\"\n\nval o = g.Origin()",
+			wantErr: "g.Origin is internal to the module of package geo",
+		},
+		{
+			name:    "private name must be lowercase",
+			input:   "package main\n\nprivate func Helper() int = 1",
+			wantErr: "private declaration Helper must start with a lowercase letter",
+		},
+		{
+			name:    "internal name must be uppercase",
+			input:   "package main\n\ninternal val limit = 10",
+			wantErr: "internal declaration limit must start with an uppercase letter",
+		},
+		{
+			name:    "internal method",
+			input:   "package main\n\nstruct P(X int)\n\ninternal func (p P) Get() int = p.X",
+			wantErr: "internal cannot be applied to a method",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := transpiler.NewAntlrGalaParser()
+			a := analyzer.NewGalaAnalyzer(p, nil)
+			tree, err := p.Parse(tt.input)
+			require.NoError(t, err)
+
+			_, err = a.Analyze(tree, "")
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+			assert.Equal(t, galaerr.CodeVisibility, galaerr.CodeOf(err))
+		})
+	}
+}
+
+func TestImportCycle(t *testing.T) {
+	tempDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "go.mod"), []byte("module testmod\n\ngo 1.21\n"), 0644))
+	for pkg, imported := range map[string]string{"a": "b", "b": "a"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(tempDir, pkg), 0755))
+		src := fmt.Sprintf("package %s\n\nimport \"testmod/%s\"\n\nfunc Get() int = %s.Get()\n", pkg, imported, imported)
+		require.NoError(t, os.WriteFile(filepath.Join(tempDir, pkg, pkg+".gala"), []byte(src), 0644))
+	}
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(tempDir))
+
+	tests := []struct {
+		name     string
+		input    string
+		filePath string
+	}{
+		{
+			name:     "package in the cycle",
+			input:    "package a\n\nimport \"testmod/b\"\n\nfunc Get() int = b.Get()\n",
+			filePath: "a/a.gala",
+		},
+		{
+			name:  "importer of the cycle",
+			input: "package main\n\nimport \"testmod/a\"\n\nval x = a.Get()\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := transpiler.NewAntlrGalaParser()
+			a := analyzer.NewGalaAnalyzer(p, nil)
+			tree, err := p.Parse(tt.input)
+			require.NoError(t, err)
+
+			_, err = a.Analyze(tree, tt.filePath)
+			assert.ErrorContains(t, err, "import cycle not allowed: testmod/a -> testmod/b -> testmod/a")
+			assert.Equal(t, galaerr.CodeImportCycle, galaerr.CodeOf(err))
+		})
+	}
+}
+
+func TestBuildTags(t *testing.T) {
+	otherOS := "windows"
+	if runtime.GOOS == otherOS {
+		otherOS = "linux"
+	}
+	tempDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "go.mod"), []byte("module testmod\n\ngo 1.21\n"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(tempDir, "platform"), 0755))
+	files := map[string]string{
+		"base.gala":                        "package platform\n\nfunc Base() int = 0\n",
+		"native_" + runtime.GOOS + ".gala": "package platform\n\nfunc Native() int = 1\n",
+		"foreign_" + otherOS + ".gala":     "package platform\n\nfunc Foreign() int = 2\n",
+		"experimental.gala":                "//go:build gala_experimental\n\npackage platform\n\nfunc Experimental() int = 3\n",
+	}
+	for name, src := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(tempDir, "platform", name), []byte(src), 0644))
+	}
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(tempDir))
+
+	p := transpiler.NewAntlrGalaParser()
+	a := analyzer.NewGalaAnalyzer(p, nil)
+	tree, err := p.Parse("package main\n\nimport \"testmod/platform\"\n\nval x = platform.Base()\n")
+	require.NoError(t, err)
+	richAST, err := a.Analyze(tree, "")
+	require.NoError(t, err)
+
+	assert.Contains(t, richAST.Functions, "platform.Base")
+	assert.Contains(t, richAST.Functions, "platform.Native")
+	assert.NotContains(t, richAST.Functions, "platform.Foreign")
+	assert.NotContains(t, richAST.Functions, "platform.Experimental")
+}
+
 func keysOf(m map[string]*transpiler.TypeMetadata) []string {
 	var keys []string
 	for k := range m {
@@ -287,3 +554,127 @@ func keysOf(m map[string]*transpiler.TypeMetadata) []string {
 	}
 	return keys
 }
+
+func TestConcurrentAnalyze(t *testing.T) {
+	p := transpiler.NewAntlrGalaParser()
+	cache := analyzer.NewPackageCache()
+	shared := analyzer.NewGalaAnalyzerWithCache(p, getStdSearchPath(), nil, cache)
+
+	const workers = 8
+	results := make([]*transpiler.RichAST, workers)
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// Half of the workers share one analyzer, the rest only share its cache
+			a := shared
+			if i%2 == 1 {
+				a = analyzer.NewGalaAnalyzerWithCache(p, getStdSearchPath(), nil, cache)
+			}
+			src := fmt.Sprintf("package main\n\nstruct Box%d(val value Option[int])\n", i)
+			tree, err := p.Parse(src)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			results[i], errs[i] = a.Analyze(tree, "")
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < workers; i++ {
+		require.NoError(t, errs[i])
+		name := fmt.Sprintf("Box%d", i)
+		require.Contains(t, results[i].Types, name)
+		assert.Equal(t, []string{"value"}, results[i].Types[name].FieldNames)
+		for j := 0; j < workers; j++ {
+			if j != i {
+				assert.NotContains(t, results[i].Types, fmt.Sprintf("Box%d", j))
+			}
+		}
+		require.Contains(t, results[i].Types, "std.Option")
+	}
+
+	// Results are private copies: changing one does not leak into the cache
+	delete(results[0].Types["std.Option"].Methods, "Get")
+	tree, err := p.Parse("package main\n\nval x = 1\n")
+	require.NoError(t, err)
+	again, err := shared.Analyze(tree, "")
+	require.NoError(t, err)
+	assert.Contains(t, again.Types["std.Option"].Methods, "Get")
+}
+
+func TestConcurrentImports(t *testing.T) {
+	tempDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "go.mod"), []byte("module testmod\n\ngo 1.21\n"), 0644))
+	sources := map[string]string{
+		"shared": "package shared\n\nstruct Id(value int)\n",
+		"cyclic": "package cyclic\n\nimport \"testmod/loop\"\n\nfunc Get() int = loop.Get()\n",
+		"loop":   "package loop\n\nimport \"testmod/cyclic\"\n\nfunc Get() int = cyclic.Get()\n",
+	}
+	var imports string
+	for i := 0; i < 6; i++ {
+		pkg := fmt.Sprintf("p%d", i)
+		sources[pkg] = fmt.Sprintf("package %s\n\nimport \"testmod/shared\"\n\nstruct Item%d(id shared.Id)\n\nfunc Make%d() Item%d = Item%d(shared.Id(%d))\n", pkg, i, i, i, i, i)
+		imports += fmt.Sprintf("    \"testmod/%s\"\n", pkg)
+	}
+	for pkg, src := range sources {
+		require.NoError(t, os.MkdirAll(filepath.Join(tempDir, pkg), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(tempDir, pkg, pkg+".gala"), []byte(src), 0644))
+	}
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(tempDir))
+
+	p := transpiler.NewAntlrGalaParser()
+	tree, err := p.Parse("package main\n\nimport (\n" + imports + ")\n\nval x = p0.Make0()\n")
+	require.NoError(t, err)
+	richAST, err := analyzer.NewGalaAnalyzer(p, nil).Analyze(tree, "")
+	require.NoError(t, err)
+	for i := 0; i < 6; i++ {
+		pkg := fmt.Sprintf("p%d", i)
+		assert.Equal(t, pkg, richAST.Packages["testmod/"+pkg])
+		assert.Contains(t, richAST.Types, fmt.Sprintf("%s.Item%d", pkg, i))
+		assert.Contains(t, richAST.Functions, fmt.Sprintf("%s.Make%d", pkg, i))
+	}
+	assert.Contains(t, richAST.Types, "shared.Id")
+
+	// A cycle among packages analyzed concurrently is reported where it is imported
+	tree, err = p.Parse("package main\n\nimport (\n    \"testmod/p0\"\n    \"testmod/cyclic\"\n)\n\nval x = cyclic.Get()\n")
+	require.NoError(t, err)
+	_, err = analyzer.NewGalaAnalyzer(p, nil).Analyze(tree, "")
+	assert.ErrorContains(t, err, "import cycle not allowed: testmod/cyclic -> testmod/loop -> testmod/cyclic")
+	assert.Equal(t, galaerr.CodeImportCycle, galaerr.CodeOf(err))
+}
+
+func TestImportedFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "go.mod"), []byte("module testmod\n\ngo 1.21\n"), 0644))
+	sources := map[string]string{
+		"app/main.gala":         "package main\n\nimport (\n    \"fmt\"\n    \"testmod/model\"\n)\n\nfunc main() {\n    fmt.Println(model.Id(1))\n}\n",
+		"model/model.gala":      "package model\n\nimport \"testmod/shared\"\n\ntype Id = shared.Key\n",
+		"model/model_test.gala": "package model\n\nimport \"testmod/unused\"\n",
+		"shared/shared.gala":    "package shared\n\nimport \"testmod/model\"\n\ntype Key int\n",
+		"unused/unused.gala":    "package unused\n",
+	}
+	for path, src := range sources {
+		require.NoError(t, os.MkdirAll(filepath.Join(tempDir, filepath.Dir(path)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(tempDir, path), []byte(src), 0644))
+	}
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(tempDir))
+
+	files := analyzer.ImportedFiles(transpiler.NewAntlrGalaParser(), nil, []string{filepath.Join(tempDir, "app", "main.gala")})
+	// Go packages, test files and the cycle back to model are left out
+	assert.Equal(t, []string{
+		filepath.Join(tempDir, "model", "model.gala"),
+		filepath.Join(tempDir, "shared", "shared.gala"),
+	}, files)
+}
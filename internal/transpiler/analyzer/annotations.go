@@ -0,0 +1,352 @@
+package analyzer
+
+import (
+	"fmt"
+	"strconv"
+
+	"martianoff/gala/galaerr"
+	"martianoff/gala/internal/parser/grammar"
+	"martianoff/gala/internal/transpiler"
+)
+
+// annotationTargets lists the declaration kinds each known annotation may be attached to.
+var annotationTargets = map[string][]string{
+	transpiler.AnnotationInline:      {"function"},
+	transpiler.AnnotationDeprecated:  {"function", "method", "type"},
+	transpiler.AnnotationTailrec:     {"function"},
+	transpiler.AnnotationNoCopy:      {"type"},
+	transpiler.AnnotationEqualIgnore: {"field"},
+	transpiler.AnnotationDeepCopy:    {"field"},
+	transpiler.AnnotationShallowCopy: {"field"},
+	transpiler.AnnotationArena:       {"type"},
+	transpiler.AnnotationCompact:     {"type"},
+	transpiler.AnnotationJSON:        {"type"},
+	transpiler.AnnotationExtractor:   {"function"},
+	transpiler.AnnotationTraced:      {"function", "method"},
+	transpiler.AnnotationGoStruct:    {"type"},
+	transpiler.AnnotationSkip:        {"function", "method"},
+	transpiler.AnnotationCapture:     {"function"},
+}
+
+// applyAnnotations validates the annotations of every top-level declaration and
+// struct field in sf and records them on the matching type, function or method
+// metadata. It must run after types and functions have been collected.
+func (a *galaAnalyzer) applyAnnotations(sf *grammar.SourceFileContext, pkgName string, richAST *transpiler.RichAST) error {
+	for _, topDecl := range sf.AllTopLevelDeclaration() {
+		if err := applyFieldAnnotations(topDecl, pkgName, richAST); err != nil {
+			return err
+		}
+		if len(topDecl.AllAnnotation()) == 0 {
+			continue
+		}
+		kind, name := declarationKind(topDecl)
+		annotations, err := parseAnnotations(topDecl.AllAnnotation(), kind)
+		if err != nil {
+			return err
+		}
+
+		switch kind {
+		case "type":
+			meta, ok := richAST.Types[transpiler.QualifiedName(pkgName, name)]
+			if !ok {
+				continue
+			}
+			meta.Annotations = annotations
+			if _, arena := transpiler.FindAnnotation(annotations, transpiler.AnnotationArena); arena {
+				if !meta.IsSealed {
+					line, col := topDecl.GetStart().GetLine(), topDecl.GetStart().GetColumn()
+					return galaerr.NewSemanticErrorAt(line, col, fmt.Sprintf("annotation @%s can only be applied to a sealed type", transpiler.AnnotationArena)).WithCode(galaerr.CodeBadAnnotation)
+				}
+				registerSealedArena(meta, pkgName, richAST)
+			}
+			for _, sealedOnly := range []string{transpiler.AnnotationJSON, transpiler.AnnotationCompact} {
+				if _, ok := transpiler.FindAnnotation(annotations, sealedOnly); ok && !meta.IsSealed {
+					line, col := topDecl.GetStart().GetLine(), topDecl.GetStart().GetColumn()
+					return galaerr.NewSemanticErrorAt(line, col, fmt.Sprintf("annotation @%s can only be applied to a sealed type", sealedOnly)).WithCode(galaerr.CodeBadAnnotation)
+				}
+			}
+			if _, ok := transpiler.FindAnnotation(annotations, transpiler.AnnotationGoStruct); ok {
+				if meta.IsSealed || meta.IsInterface || meta.IsNewtype() || meta.AliasOf != nil {
+					line, col := topDecl.GetStart().GetLine(), topDecl.GetStart().GetColumn()
+					return galaerr.NewSemanticErrorAt(line, col, fmt.Sprintf("annotation @%s can only be applied to a struct type", transpiler.AnnotationGoStruct)).WithCode(galaerr.CodeBadAnnotation)
+				}
+				registerGoStruct(meta, pkgName, richAST)
+			}
+		case "function":
+			if meta, ok := richAST.Functions[transpiler.QualifiedName(pkgName, name)]; ok {
+				meta.Annotations = annotations
+				if _, ok := transpiler.FindAnnotation(annotations, transpiler.AnnotationExtractor); ok {
+					if err := checkExtractorFunction(topDecl, meta); err != nil {
+						return err
+					}
+				}
+				if _, ok := transpiler.FindAnnotation(annotations, transpiler.AnnotationCapture); ok {
+					if err := checkCaptureFunction(topDecl, meta); err != nil {
+						return err
+					}
+				}
+			}
+		case "method":
+			ctx := topDecl.FunctionDeclaration().(*grammar.FunctionDeclarationContext)
+			baseType := getBaseTypeName(ctx.Receiver().(*grammar.ReceiverContext).Type_())
+			if typeMeta, ok := richAST.Types[transpiler.QualifiedName(pkgName, baseType)]; ok {
+				if meta, ok := typeMeta.Methods[name]; ok {
+					meta.Annotations = annotations
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// checkExtractorFunction makes sure an @extractor function can be called on
+// the matched value: it takes that value as its only parameter and returns
+// bool for a guard or Option[T] for an extractor binding T.
+func checkExtractorFunction(topDecl grammar.ITopLevelDeclarationContext, meta *transpiler.FunctionMetadata) error {
+	fail := func(msg string) error {
+		line, col := topDecl.GetStart().GetLine(), topDecl.GetStart().GetColumn()
+		return galaerr.NewSemanticErrorAt(line, col, fmt.Sprintf("@%s function %s %s", transpiler.AnnotationExtractor, meta.Name, msg)).WithCode(galaerr.CodeBadAnnotation)
+	}
+	if len(meta.TypeParams) > 0 {
+		return fail("cannot have type parameters")
+	}
+	if len(meta.ParamTypes) != 1 {
+		return fail(fmt.Sprintf("must take exactly one parameter, the matched value, not %d", len(meta.ParamTypes)))
+	}
+	switch ret := meta.ReturnType.(type) {
+	case transpiler.BasicType:
+		if ret.Name == "bool" {
+			return nil
+		}
+	case transpiler.GenericType:
+		if name := ret.Base.BaseName(); name == "Option" || name == "std.Option" {
+			return nil
+		}
+	}
+	return fail("must return bool or Option[T]")
+}
+
+// checkCaptureFunction makes sure a @capture function has the trailing
+// ...string parameter the source text of the arguments of its calls is passed
+// to.
+func checkCaptureFunction(topDecl grammar.ITopLevelDeclarationContext, meta *transpiler.FunctionMetadata) error {
+	fn := topDecl.FunctionDeclaration().(*grammar.FunctionDeclarationContext)
+	if list := fn.Signature().Parameters().ParameterList(); list != nil {
+		params := list.AllParameter()
+		last := params[len(params)-1]
+		if last.ELLIPSIS() != nil && last.Type_() != nil && last.Type_().GetText() == "string" {
+			return nil
+		}
+	}
+	line, col := topDecl.GetStart().GetLine(), topDecl.GetStart().GetColumn()
+	return galaerr.NewSemanticErrorAt(line, col, fmt.Sprintf("@%s function %s must end with a ...string parameter", transpiler.AnnotationCapture, meta.Name)).WithCode(galaerr.CodeBadAnnotation)
+}
+
+// applyFieldAnnotations validates the annotations of the fields of a struct
+// type declaration and records them in the type's FieldAnnotations.
+func applyFieldAnnotations(topDecl grammar.ITopLevelDeclarationContext, pkgName string, richAST *transpiler.RichAST) error {
+	typeDecl, ok := topDecl.TypeDeclaration().(*grammar.TypeDeclarationContext)
+	if !ok || typeDecl.StructType() == nil {
+		return nil
+	}
+	meta := richAST.Types[transpiler.QualifiedName(pkgName, typeDecl.Identifier().GetText())]
+	for _, fCtx := range typeDecl.StructType().(*grammar.StructTypeContext).AllStructField() {
+		field := fCtx.(*grammar.StructFieldContext)
+		if len(field.AllAnnotation()) == 0 {
+			continue
+		}
+		annotations, err := parseAnnotations(field.AllAnnotation(), "field")
+		if err != nil {
+			return err
+		}
+		_, deep := transpiler.FindAnnotation(annotations, transpiler.AnnotationDeepCopy)
+		if _, shallow := transpiler.FindAnnotation(annotations, transpiler.AnnotationShallowCopy); deep && shallow {
+			line, col := field.GetStart().GetLine(), field.GetStart().GetColumn()
+			return galaerr.NewSemanticErrorAt(line, col, "annotations @deepCopy and @shallowCopy exclude each other").WithCode(galaerr.CodeBadAnnotation)
+		}
+		if meta == nil {
+			continue
+		}
+		if meta.FieldAnnotations == nil {
+			meta.FieldAnnotations = make(map[string][]transpiler.Annotation)
+		}
+		meta.FieldAnnotations[field.Identifier().GetText()] = annotations
+	}
+	return nil
+}
+
+// registerSealedArena records the arena builder generated for an @arena sealed
+// type: the type <Name>Arena with one constructor method per variant, taking the
+// same parameters as the variant's Apply, and the function New<Name>Arena.
+func registerSealedArena(meta *transpiler.TypeMetadata, pkgName string, richAST *transpiler.RichAST) {
+	arenaName := meta.Name + "Arena"
+	arena := &transpiler.TypeMetadata{
+		Name:       arenaName,
+		Package:    pkgName,
+		Methods:    make(map[string]*transpiler.MethodMetadata),
+		Fields:     make(map[string]transpiler.Type),
+		TypeParams: meta.TypeParams,
+	}
+	for _, variant := range meta.SealedVariants {
+		companion, ok := richAST.Types[transpiler.QualifiedName(pkgName, variant.Name)]
+		if !ok || companion.Methods["Apply"] == nil {
+			continue
+		}
+		apply := companion.Methods["Apply"]
+		arena.Methods[variant.Name] = &transpiler.MethodMetadata{
+			Name:       variant.Name,
+			Package:    pkgName,
+			ParamTypes: apply.ParamTypes,
+			ReturnType: apply.ReturnType,
+		}
+	}
+	richAST.Types[transpiler.QualifiedName(pkgName, arenaName)] = arena
+
+	richAST.Functions[transpiler.QualifiedName(pkgName, "New"+arenaName)] = &transpiler.FunctionMetadata{
+		Name:       "New" + arenaName,
+		Package:    pkgName,
+		TypeParams: meta.TypeParams,
+		ReturnType: declaredType(pkgName, arenaName, meta.TypeParams),
+	}
+}
+
+// registerGoStruct records the plain Go struct generated for the @goStruct
+// type meta describes, with a field of the same name for each of its fields:
+// Option[T] becomes *T and the rest keep their type. The type gets a
+// ToGoStruct method returning it, and the Go struct a FromGoStruct method
+// converting back.
+func registerGoStruct(meta *transpiler.TypeMetadata, pkgName string, richAST *transpiler.RichAST) {
+	goName := meta.Name + transpiler.GoStructSuffix
+	goStruct := &transpiler.TypeMetadata{
+		Name:       goName,
+		Package:    pkgName,
+		Methods:    make(map[string]*transpiler.MethodMetadata),
+		Fields:     make(map[string]transpiler.Type),
+		FieldNames: meta.FieldNames,
+		ImmutFlags: make([]bool, len(meta.FieldNames)),
+		TypeParams: meta.TypeParams,
+	}
+	for _, name := range meta.FieldNames {
+		typ := meta.Fields[name]
+		if g, ok := typ.(transpiler.GenericType); ok && len(g.Params) == 1 {
+			if base := g.Base.BaseName(); base == "Option" || base == "std.Option" {
+				typ = transpiler.PointerType{Elem: g.Params[0]}
+			}
+		}
+		goStruct.Fields[name] = typ
+	}
+	goStruct.Methods[transpiler.MethodFromGoStruct] = &transpiler.MethodMetadata{
+		Name:       transpiler.MethodFromGoStruct,
+		Package:    pkgName,
+		ReturnType: declaredType(pkgName, meta.Name, meta.TypeParams),
+	}
+	richAST.Types[transpiler.QualifiedName(pkgName, goName)] = goStruct
+
+	if meta.Methods == nil {
+		meta.Methods = make(map[string]*transpiler.MethodMetadata)
+	}
+	meta.Methods[transpiler.MethodToGoStruct] = &transpiler.MethodMetadata{
+		Name:       transpiler.MethodToGoStruct,
+		Package:    pkgName,
+		ReturnType: declaredType(pkgName, goName, meta.TypeParams),
+	}
+}
+
+// declaredType is the type of a value of the type called name declared in
+// pkgName, instantiated with its own type parameters.
+func declaredType(pkgName, name string, typeParams []string) transpiler.Type {
+	var typ transpiler.Type = transpiler.NamedType{Package: pkgName, Name: name}
+	if len(typeParams) > 0 {
+		var params []transpiler.Type
+		for _, tp := range typeParams {
+			params = append(params, transpiler.BasicType{Name: tp})
+		}
+		return transpiler.GenericType{Base: typ, Params: params}
+	}
+	if transpiler.QualifiedName(pkgName, name) == name {
+		return transpiler.BasicType{Name: name}
+	}
+	return typ
+}
+
+// declaredWith reports whether the top-level declaration around ctx carries
+// the annotation called name. It serves annotations that change which
+// metadata is collected, which applyAnnotations records too late.
+func declaredWith(ctx grammar.ISealedTypeDeclarationContext, name string) bool {
+	topDecl, ok := ctx.GetParent().(grammar.ITopLevelDeclarationContext)
+	if !ok {
+		return false
+	}
+	for _, an := range topDecl.AllAnnotation() {
+		if an.(*grammar.AnnotationContext).Identifier().GetText() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// declarationKind classifies a top-level declaration for annotation checks.
+func declarationKind(topDecl grammar.ITopLevelDeclarationContext) (kind, name string) {
+	switch {
+	case topDecl.FunctionDeclaration() != nil:
+		ctx := topDecl.FunctionDeclaration().(*grammar.FunctionDeclarationContext)
+		if ctx.Receiver() != nil {
+			return "method", ctx.Identifier().GetText()
+		}
+		return "function", ctx.Identifier().GetText()
+	case topDecl.TypeDeclaration() != nil:
+		return "type", topDecl.TypeDeclaration().(*grammar.TypeDeclarationContext).Identifier().GetText()
+	case topDecl.StructShorthandDeclaration() != nil:
+		return "type", topDecl.StructShorthandDeclaration().(*grammar.StructShorthandDeclarationContext).Identifier().GetText()
+	case topDecl.SealedTypeDeclaration() != nil:
+		return "type", topDecl.SealedTypeDeclaration().(*grammar.SealedTypeDeclarationContext).Identifier().GetText()
+	case topDecl.NewtypeDeclaration() != nil:
+		return "type", topDecl.NewtypeDeclaration().(*grammar.NewtypeDeclarationContext).Identifier().GetText()
+	case topDecl.ValDeclaration() != nil:
+		return "val", ""
+	default:
+		return "var", ""
+	}
+}
+
+// parseAnnotations converts annotations and rejects unknown names, duplicates
+// and annotations that do not apply to a declaration of kind.
+func parseAnnotations(annotations []grammar.IAnnotationContext, kind string) ([]transpiler.Annotation, error) {
+	var result []transpiler.Annotation
+	for _, an := range annotations {
+		ctx := an.(*grammar.AnnotationContext)
+		line, col := ctx.GetStart().GetLine(), ctx.GetStart().GetColumn()
+		name := ctx.Identifier().GetText()
+
+		targets, known := annotationTargets[name]
+		if !known {
+			return nil, galaerr.NewSemanticErrorAt(line, col, fmt.Sprintf("unknown annotation @%s", name)).WithCode(galaerr.CodeBadAnnotation)
+		}
+		if !containsString(targets, kind) {
+			return nil, galaerr.NewSemanticErrorAt(line, col, fmt.Sprintf("annotation @%s cannot be applied to a %s", name, kind)).WithCode(galaerr.CodeBadAnnotation)
+		}
+		if _, dup := transpiler.FindAnnotation(result, name); dup {
+			return nil, galaerr.NewSemanticErrorAt(line, col, fmt.Sprintf("duplicate annotation @%s", name)).WithCode(galaerr.CodeBadAnnotation)
+		}
+
+		arg := ""
+		if s := ctx.STRING(); s != nil {
+			unquoted, err := strconv.Unquote(s.GetText())
+			if err != nil {
+				return nil, galaerr.NewSemanticErrorAt(line, col, fmt.Sprintf("invalid argument of @%s: %v", name, err)).WithCode(galaerr.CodeBadAnnotation)
+			}
+			arg = unquoted
+		}
+		result = append(result, transpiler.Annotation{Name: name, Arg: arg})
+	}
+	return result, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
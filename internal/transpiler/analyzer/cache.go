@@ -0,0 +1,125 @@
+package analyzer
+
+import (
+	"sync"
+
+	"martianoff/gala/internal/transpiler"
+)
+
+// PackageCache holds the metadata of analyzed packages by import path. It is
+// safe for concurrent use, so one cache can back all analyzers of a build or
+// an editor session. Analyzers sharing a cache must resolve imports the same
+// way, i.e. run in the same module with the same search paths.
+//
+// Packages are copied on the way in and out: analysis and transformation
+// update the metadata they merge, and those updates must not leak into other
+// files or race with other goroutines.
+type PackageCache struct {
+	mu   sync.RWMutex
+	pkgs map[string]*transpiler.RichAST
+}
+
+// NewPackageCache creates an empty PackageCache.
+func NewPackageCache() *PackageCache {
+	return &PackageCache{pkgs: make(map[string]*transpiler.RichAST)}
+}
+
+// get returns a private copy of the package cached for path.
+func (c *PackageCache) get(path string) (*transpiler.RichAST, bool) {
+	c.mu.RLock()
+	pkg, ok := c.pkgs[path]
+	c.mu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	return clonePackage(pkg), true
+}
+
+// has reports whether a package is cached for path.
+func (c *PackageCache) has(path string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, ok := c.pkgs[path]
+	return ok
+}
+
+// put caches a copy of pkg for path. When two analyzers race on the same
+// package the last result wins; both describe the same sources.
+func (c *PackageCache) put(path string, pkg *transpiler.RichAST) {
+	pkg = clonePackage(pkg)
+	c.mu.Lock()
+	c.pkgs[path] = pkg
+	c.mu.Unlock()
+}
+
+// clonePackage copies the metadata of r down to the individual type, method,
+// function and companion entries. Slices are capped so appends reallocate
+// instead of writing into the original's backing arrays.
+func clonePackage(r *transpiler.RichAST) *transpiler.RichAST {
+	c := &transpiler.RichAST{
+		PackageName:      r.PackageName,
+		Types:            make(map[string]*transpiler.TypeMetadata, len(r.Types)),
+		Functions:        make(map[string]*transpiler.FunctionMetadata, len(r.Functions)),
+		Packages:         make(map[string]string, len(r.Packages)),
+		CompanionObjects: make(map[string]*transpiler.CompanionObjectMetadata, len(r.CompanionObjects)),
+	}
+	for name, t := range r.Types {
+		ct := *t
+		ct.Methods = make(map[string]*transpiler.MethodMetadata, len(t.Methods))
+		for m, mm := range t.Methods {
+			cm := *mm
+			cm.ParamTypes = capped(mm.ParamTypes)
+			cm.TypeParams = capped(mm.TypeParams)
+			cm.Annotations = capped(mm.Annotations)
+			ct.Methods[m] = &cm
+		}
+		ct.Fields = make(map[string]transpiler.Type, len(t.Fields))
+		for f, ft := range t.Fields {
+			ct.Fields[f] = ft
+		}
+		if t.TypeParamConstraints != nil {
+			ct.TypeParamConstraints = make(map[string]string, len(t.TypeParamConstraints))
+			for p, constraint := range t.TypeParamConstraints {
+				ct.TypeParamConstraints[p] = constraint
+			}
+		}
+		ct.FieldNames = capped(t.FieldNames)
+		ct.TypeParams = capped(t.TypeParams)
+		ct.ImmutFlags = capped(t.ImmutFlags)
+		ct.SealedVariants = capped(t.SealedVariants)
+		ct.Annotations = capped(t.Annotations)
+		c.Types[name] = &ct
+	}
+	for name, f := range r.Functions {
+		cf := *f
+		cf.ParamTypes = capped(f.ParamTypes)
+		cf.TypeParams = capped(f.TypeParams)
+		cf.Annotations = capped(f.Annotations)
+		c.Functions[name] = &cf
+	}
+	for path, name := range r.Packages {
+		c.Packages[path] = name
+	}
+	for name, co := range r.CompanionObjects {
+		cco := *co
+		cco.ExtractIndices = capped(co.ExtractIndices)
+		c.CompanionObjects[name] = &cco
+	}
+	if r.GoExports != nil {
+		c.GoExports = make(map[string][]string, len(r.GoExports))
+		for pkg, symbols := range r.GoExports {
+			c.GoExports[pkg] = capped(symbols)
+		}
+	}
+	if r.Visibility != nil {
+		c.Visibility = make(map[string]transpiler.Visibility, len(r.Visibility))
+		for name, v := range r.Visibility {
+			c.Visibility[name] = v
+		}
+	}
+	return c
+}
+
+func capped[T any](s []T) []T {
+	return s[:len(s):len(s)]
+}
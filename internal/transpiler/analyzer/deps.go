@@ -0,0 +1,73 @@
+package analyzer
+
+import (
+	"io/ioutil"
+	"strings"
+
+	"martianoff/gala/internal/parser/grammar"
+	"martianoff/gala/internal/transpiler"
+	"martianoff/gala/internal/transpiler/module"
+	"martianoff/gala/internal/transpiler/registry"
+)
+
+// ImportedFiles returns the .gala files of the GALA packages that the files
+// at paths import, directly or through other GALA packages, resolved through
+// searchPaths as Analyze resolves them. std and the project prelude count as
+// imported. Go packages, and packages that cannot be found or read, are left
+// out; so are the files at paths themselves.
+//
+// Only import declarations are read, so this is much cheaper than analyzing
+// the files, e.g. for finding the files a rebuild depends on.
+func ImportedFiles(p transpiler.GalaParser, searchPaths []string, paths []string) []string {
+	a := &galaAnalyzer{parser: p, searchPaths: searchPaths, resolver: module.NewResolver(searchPaths)}
+	imports := []string{registry.StdImportPath}
+	if config, err := a.resolver.Config(); err == nil {
+		imports = append(imports, config.Prelude...)
+	}
+
+	var files []string
+	seen := make(map[string]bool)
+	for len(paths) > 0 || len(imports) > 0 {
+		for _, file := range a.parseFiles(paths) {
+			if file.tree != nil {
+				imports = append(imports, importPaths(file.tree)...)
+			}
+		}
+		paths = nil
+		pending := imports
+		imports = nil
+		for _, path := range pending {
+			if seen[path] {
+				continue
+			}
+			seen[path] = true
+			relPath, _, ok := a.galaImport(path)
+			if !ok {
+				continue
+			}
+			dirPath, err := a.resolver.ResolvePackagePath(relPath)
+			if err != nil {
+				continue
+			}
+			entries, err := ioutil.ReadDir(dirPath)
+			if err != nil {
+				continue
+			}
+			pkgFiles := a.packageGalaFiles(dirPath, entries)
+			files = append(files, pkgFiles...)
+			paths = append(paths, pkgFiles...)
+		}
+	}
+	return files
+}
+
+// importPaths returns the paths the import declarations of sf name.
+func importPaths(sf *grammar.SourceFileContext) []string {
+	var paths []string
+	for _, impDecl := range sf.AllImportDeclaration() {
+		for _, spec := range impDecl.(*grammar.ImportDeclarationContext).AllImportSpec() {
+			paths = append(paths, strings.Trim(spec.(*grammar.ImportSpecContext).STRING().GetText(), "\""))
+		}
+	}
+	return paths
+}
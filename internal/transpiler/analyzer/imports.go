@@ -0,0 +1,252 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"martianoff/gala/galaerr"
+	"martianoff/gala/internal/parser/grammar"
+	"martianoff/gala/internal/transpiler"
+	"martianoff/gala/internal/transpiler/registry"
+)
+
+// selectedSymbol is a symbol a selective import, import "pkg".{A, B => C},
+// brings into scope under a local name.
+type selectedSymbol struct {
+	pkg    string // name of the package declaring the symbol
+	symbol string // name of the symbol in its package
+}
+
+// importSelectors returns the selectors of s, or nil for a plain import.
+func importSelectors(s *grammar.ImportSpecContext) []*grammar.ImportSelectorContext {
+	if s.ImportSelectors() == nil {
+		return nil
+	}
+	var selectors []*grammar.ImportSelectorContext
+	for _, sel := range s.ImportSelectors().(*grammar.ImportSelectorsContext).AllImportSelector() {
+		selectors = append(selectors, sel.(*grammar.ImportSelectorContext))
+	}
+	return selectors
+}
+
+// selectorNames returns the name sel imports and the local name it is known by.
+func selectorNames(sel *grammar.ImportSelectorContext) (symbol, local string) {
+	ids := sel.AllIdentifier()
+	return ids[0].GetText(), ids[len(ids)-1].GetText()
+}
+
+// checkSelectiveImports validates the selective imports of sf. A local name
+// can be listed once and must not be declared again in the package or the
+// file, a dot import cannot list symbols, and exports of a selectively
+// imported package that are not listed must be used qualified.
+func checkSelectiveImports(sf *grammar.SourceFileContext, pkgName string, richAST *transpiler.RichAST) error {
+	selectiveError := func(ctx antlr.ParserRuleContext, format string, args ...any) error {
+		line, col := ctx.GetStart().GetLine(), ctx.GetStart().GetColumn()
+		return galaerr.NewSemanticErrorAt(line, col, fmt.Sprintf(format, args...)).WithCode(galaerr.CodeSelectiveImport)
+	}
+
+	exports := packageExports(richAST)
+	topLevel := make(map[string]bool)
+	for _, topDecl := range sf.AllTopLevelDeclaration() {
+		for _, name := range declaredTopLevelNames(topDecl) {
+			topLevel[name] = true
+		}
+	}
+
+	selected := make(map[string]selectedSymbol) // local name -> symbol
+	listed := make(map[string]map[string]bool)  // package name -> symbols listed for it
+	aliases := make(map[string]string)          // package name -> alias of its selective import
+	dotPkgs := []string{registry.StdPackageName, pkgName}
+	dotPkgs = append(dotPkgs, preludePackages(richAST)...)
+	for _, impDecl := range sf.AllImportDeclaration() {
+		for _, spec := range impDecl.(*grammar.ImportDeclarationContext).AllImportSpec() {
+			s := spec.(*grammar.ImportSpecContext)
+			path := strings.Trim(s.STRING().GetText(), "\"")
+			pkg, ok := richAST.Packages[path]
+			if !ok {
+				pkg = path[strings.LastIndex(path, "/")+1:]
+			}
+			isDot := strings.HasPrefix(s.GetText(), ".")
+			if isDot {
+				dotPkgs = append(dotPkgs, pkg)
+			}
+			selectors := importSelectors(s)
+			if len(selectors) == 0 {
+				continue
+			}
+			if isDot {
+				return selectiveError(s, "a dot import cannot list symbols: it already imports all of %s", pkg)
+			}
+			alias := pkg
+			if s.Identifier() != nil {
+				alias = s.Identifier().GetText()
+			}
+			aliases[pkg] = alias
+			if listed[pkg] == nil {
+				listed[pkg] = make(map[string]bool)
+			}
+			for _, sel := range selectors {
+				symbol, local := selectorNames(sel)
+				if prev, dup := selected[local]; dup {
+					return selectiveError(sel, "%s is already imported from %s", local, prev.pkg)
+				}
+				if topLevel[local] || exports[pkgName][local] {
+					return selectiveError(sel, "%s is imported from %s but also declared in package %s", local, pkg, pkgName)
+				}
+				selected[local] = selectedSymbol{pkg: pkg, symbol: symbol}
+				listed[pkg][symbol] = true
+			}
+		}
+	}
+	if len(selected) == 0 {
+		return nil
+	}
+
+	// Unqualified names that another import or the package itself provides are
+	// not references to a selectively imported package
+	elsewhere := func(name string) bool {
+		for _, pkg := range dotPkgs {
+			if exports[pkg][name] {
+				return true
+			}
+		}
+		return false
+	}
+	pkgs := make([]string, 0, len(listed))
+	for pkg := range listed {
+		pkgs = append(pkgs, pkg)
+	}
+	sort.Strings(pkgs)
+
+	locals := make(map[string]bool)
+	var uses []antlr.ParserRuleContext
+	var err error
+	declare := func(name string, ctx antlr.ParserRuleContext) {
+		if sym, ok := selected[name]; ok && err == nil {
+			err = selectiveError(ctx, "%s is imported from %s and cannot be redeclared", name, sym.pkg)
+		}
+		locals[name] = true
+	}
+	declareList := func(list grammar.IIdentifierListContext, ctx antlr.ParserRuleContext) {
+		if list == nil {
+			return
+		}
+		for _, id := range list.(*grammar.IdentifierListContext).AllIdentifier() {
+			declare(id.GetText(), ctx)
+		}
+	}
+	var walk func(n antlr.Tree)
+	walk = func(n antlr.Tree) {
+		switch ctx := n.(type) {
+		case *grammar.ImportDeclarationContext:
+			return
+		case *grammar.ParameterContext:
+			if ctx.Identifier() != nil {
+				declare(ctx.Identifier().GetText(), ctx)
+			}
+		case *grammar.ValDeclarationContext:
+			if ctx.TuplePattern() != nil {
+				declareList(ctx.TuplePattern().(*grammar.TuplePatternContext).IdentifierList(), ctx)
+			} else {
+				declareList(ctx.IdentifierList(), ctx)
+			}
+		case *grammar.VarDeclarationContext:
+			if ctx.TuplePattern() != nil {
+				declareList(ctx.TuplePattern().(*grammar.TuplePatternContext).IdentifierList(), ctx)
+			} else {
+				declareList(ctx.IdentifierList(), ctx)
+			}
+		case *grammar.ShortVarDeclContext:
+			declareList(ctx.IdentifierList(), ctx)
+		case *grammar.TypedPatternContext:
+			declare(ctx.Identifier().GetText(), ctx)
+		case *grammar.KeyedElementContext:
+			// A bare identifier key names a struct field
+			if len(ctx.AllExpression()) == 2 && isIdentifier(ctx.Expression(0).GetText()) {
+				walk(ctx.Expression(1))
+				return
+			}
+		case *grammar.PrimaryContext:
+			if ctx.Identifier() != nil {
+				uses = append(uses, ctx)
+			}
+		case *grammar.QualifiedIdentifierContext:
+			if len(ctx.AllIdentifier()) == 1 {
+				uses = append(uses, ctx)
+			}
+		}
+		for i := 0; i < n.GetChildCount(); i++ {
+			walk(n.GetChild(i))
+		}
+	}
+	walk(sf)
+	if err != nil {
+		return err
+	}
+
+	for _, use := range uses {
+		name := use.GetText()
+		if _, ok := selected[name]; ok || locals[name] || elsewhere(name) {
+			continue
+		}
+		for _, pkg := range pkgs {
+			if exports[pkg][name] && !listed[pkg][name] {
+				return selectiveError(use, "%s is not in the import list of %s: add it to the list or write %s.%s", name, pkg, aliases[pkg], name)
+			}
+		}
+	}
+	return nil
+}
+
+// isIdentifier reports whether text is a single identifier.
+func isIdentifier(text string) bool {
+	for i, r := range text {
+		if !(r == '_' || unicode.IsLetter(r) || i > 0 && unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return text != ""
+}
+
+// packageExports returns the type, function and companion names declared by
+// each package richAST knows, including the Go-only exports of GALA packages.
+func packageExports(richAST *transpiler.RichAST) map[string]map[string]bool {
+	exports := make(map[string]map[string]bool)
+	add := func(pkg, name string) {
+		if exports[pkg] == nil {
+			exports[pkg] = make(map[string]bool)
+		}
+		exports[pkg][name] = true
+	}
+	for _, meta := range richAST.Types {
+		add(meta.Package, meta.Name)
+	}
+	for _, meta := range richAST.Functions {
+		add(meta.Package, meta.Name)
+	}
+	for _, meta := range richAST.CompanionObjects {
+		add(meta.Package, meta.Name)
+	}
+	for pkg, names := range richAST.GoExports {
+		for _, name := range names {
+			add(pkg, name)
+		}
+	}
+	return exports
+}
+
+// preludePackages returns the names of the project prelude packages, which
+// every file dot-imports implicitly.
+func preludePackages(richAST *transpiler.RichAST) []string {
+	var pkgs []string
+	for _, path := range richAST.Prelude {
+		if pkg, ok := richAST.Packages[path]; ok {
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	return pkgs
+}
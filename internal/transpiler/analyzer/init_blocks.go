@@ -0,0 +1,96 @@
+package analyzer
+
+import (
+	"fmt"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"martianoff/gala/galaerr"
+	"martianoff/gala/internal/parser/grammar"
+)
+
+// checkInitBlocks rejects init blocks of sf that assign to a package-level
+// val. Every val of the package, in sf or in a sibling file, is initialized
+// before the first init block runs, so a val an init block could assign would
+// be observed with two values. Names the block declares itself are locals.
+func checkInitBlocks(sf *grammar.SourceFileContext, siblings []*grammar.SourceFileContext) error {
+	var blocks []*grammar.InitBlockContext
+	for _, topDecl := range sf.AllTopLevelDeclaration() {
+		if topDecl.InitBlock() != nil {
+			blocks = append(blocks, topDecl.InitBlock().(*grammar.InitBlockContext))
+		}
+	}
+	if len(blocks) == 0 {
+		return nil
+	}
+
+	vals := make(map[string]bool)
+	for _, file := range append([]*grammar.SourceFileContext{sf}, siblings...) {
+		for _, topDecl := range file.AllTopLevelDeclaration() {
+			if topDecl.ValDeclaration() == nil {
+				continue
+			}
+			for _, name := range declaredTopLevelNames(topDecl) {
+				vals[name] = true
+			}
+		}
+	}
+
+	for _, block := range blocks {
+		locals := make(map[string]bool)
+		declareList := func(list grammar.IIdentifierListContext) {
+			if list == nil {
+				return
+			}
+			for _, id := range list.(*grammar.IdentifierListContext).AllIdentifier() {
+				locals[id.GetText()] = true
+			}
+		}
+		var targets []antlr.ParserRuleContext
+		var walk func(n antlr.Tree)
+		walk = func(n antlr.Tree) {
+			switch ctx := n.(type) {
+			case *grammar.ParameterContext:
+				if ctx.Identifier() != nil {
+					locals[ctx.Identifier().GetText()] = true
+				}
+			case *grammar.ValDeclarationContext:
+				if ctx.TuplePattern() != nil {
+					declareList(ctx.TuplePattern().(*grammar.TuplePatternContext).IdentifierList())
+				} else {
+					declareList(ctx.IdentifierList())
+				}
+			case *grammar.VarDeclarationContext:
+				if ctx.TuplePattern() != nil {
+					declareList(ctx.TuplePattern().(*grammar.TuplePatternContext).IdentifierList())
+				} else {
+					declareList(ctx.IdentifierList())
+				}
+			case *grammar.ShortVarDeclContext:
+				declareList(ctx.IdentifierList())
+			case *grammar.TypedPatternContext:
+				locals[ctx.Identifier().GetText()] = true
+			case *grammar.AssignmentContext:
+				for _, target := range ctx.ExpressionList(0).(*grammar.ExpressionListContext).AllExpression() {
+					targets = append(targets, target)
+				}
+			case *grammar.IncDecStmtContext:
+				targets = append(targets, ctx.Expression())
+			}
+			for i := 0; i < n.GetChildCount(); i++ {
+				walk(n.GetChild(i))
+			}
+		}
+		walk(block.Block())
+
+		for _, target := range targets {
+			name := target.GetText()
+			if vals[name] && !locals[name] {
+				line, col := target.GetStart().GetLine(), target.GetStart().GetColumn()
+				msg := fmt.Sprintf("init block cannot reassign val %s: package vals are initialized before init blocks run; declare it with var to set it here", name)
+				return galaerr.NewSemanticErrorAt(line, col, msg).WithCode(galaerr.CodeImmutableAssign)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package analyzer
+
+import (
+	"github.com/antlr4-go/antlr/v4"
+
+	"martianoff/gala/internal/parser/grammar"
+	"martianoff/gala/internal/transpiler"
+	"martianoff/gala/internal/transpiler/registry"
+)
+
+// collectInvariants records the require clauses on the fields of a shorthand
+// struct. A struct with invariants is only built through the Apply the
+// transformer generates for it, which checks them and returns a
+// std.Validated, so that method is registered here as well.
+func (a *galaAnalyzer) collectInvariants(ctx *grammar.StructShorthandDeclarationContext, pkgName string, meta *transpiler.TypeMetadata) {
+	meta.Invariants = nil
+	paramsCtx := ctx.Parameters().(*grammar.ParametersContext)
+	if paramsCtx.ParameterList() == nil {
+		return
+	}
+	for _, param := range paramsCtx.ParameterList().(*grammar.ParameterListContext).AllParameter() {
+		pctx := param.(*grammar.ParameterContext)
+		if pctx.REQUIRE() == nil {
+			continue
+		}
+		meta.Invariants = append(meta.Invariants, transpiler.Invariant{
+			Field:       pctx.Identifier().GetText(),
+			Requirement: sourceText(pctx.Expression()),
+		})
+	}
+	if len(meta.Invariants) == 0 {
+		return
+	}
+
+	var paramTypes []transpiler.Type
+	for _, name := range meta.FieldNames {
+		paramTypes = append(paramTypes, meta.Fields[name])
+	}
+	meta.Methods["Apply"] = &transpiler.MethodMetadata{
+		Name:       "Apply",
+		Package:    pkgName,
+		ParamTypes: paramTypes,
+		ReturnType: transpiler.GenericType{
+			Base:   transpiler.NamedType{Package: registry.StdPackageName, Name: "Validated"},
+			Params: []transpiler.Type{a.resolveTypeWithParams(meta.Name, pkgName, nil)},
+		},
+	}
+}
+
+// sourceText returns the text of ctx as written, keeping its whitespace.
+func sourceText(ctx antlr.ParserRuleContext) string {
+	start, stop := ctx.GetStart(), ctx.GetStop()
+	return start.GetInputStream().GetText(start.GetStart(), stop.GetStop())
+}
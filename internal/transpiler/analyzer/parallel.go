@@ -0,0 +1,118 @@
+package analyzer
+
+import (
+	"io/ioutil"
+	"maps"
+	"runtime"
+	"slices"
+	"sync"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"martianoff/gala/internal/parser/grammar"
+)
+
+// Source files are parsed and imported packages analyzed concurrently, which
+// matters for projects importing many packages of std and the collections.
+// Results are still merged in source order, so the metadata does not depend on
+// which goroutine finishes first.
+
+// analysisSlots bounds the goroutines parsing and analyzing for all analyzers
+// of the process. Work finding no free slot runs in the goroutine asking for
+// it, so nested analyses never wait for a slot.
+var analysisSlots = make(chan struct{}, runtime.GOMAXPROCS(0))
+
+// parallel calls work for 0 through n-1 and returns when all calls returned.
+func parallel(n int, work func(i int)) {
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		select {
+		case analysisSlots <- struct{}{}:
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-analysisSlots }()
+				work(i)
+			}()
+		default:
+			work(i)
+		}
+	}
+	wg.Wait()
+}
+
+// parsedFile is a source file read and parsed by parseFiles. tree is nil if
+// the file could not be read or parsed.
+type parsedFile struct {
+	path string
+	tree *grammar.SourceFileContext
+}
+
+// parseFiles reads and parses the files at paths, returned in the same order.
+func (a *galaAnalyzer) parseFiles(paths []string) []parsedFile {
+	files := make([]parsedFile, len(paths))
+	parallel(len(paths), func(i int) {
+		files[i].path = paths[i]
+		content, err := ioutil.ReadFile(paths[i])
+		if err != nil {
+			return
+		}
+		var tree antlr.Tree
+		if tree, err = a.parser.Parse(string(content)); err == nil {
+			files[i].tree, _ = tree.(*grammar.SourceFileContext)
+		}
+	})
+	return files
+}
+
+// prefetchImports analyzes the GALA packages imported as paths concurrently
+// and caches them, so importPackage finds them in the cache. Packages that
+// fail to analyze are not cached and left to importPackage, which reports
+// their errors where they are imported.
+func (a *galaAnalyzer) prefetchImports(state *analysis, paths []string) {
+	type pendingImport struct {
+		path, relPath string
+		external      bool
+	}
+	var pending []pendingImport
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		relPath, external, ok := a.galaImport(path)
+		if !ok || seen[path] || state.started[path] || state.importing(path) {
+			continue
+		}
+		if a.cache.has(path) {
+			continue
+		}
+		seen[path] = true
+		pending = append(pending, pendingImport{path, relPath, external})
+	}
+	// A single package gains nothing from another goroutine
+	if len(pending) < 2 {
+		return
+	}
+
+	parallel(len(pending), func(i int) {
+		imp := pending[i]
+		if imp.external && a.ensureTranspiled(imp.path) != nil {
+			return
+		}
+		pkgAST, err := a.analyzePackage(state.fork(imp.path), imp.relPath)
+		if err == nil {
+			a.cache.put(imp.path, pkgAST)
+		}
+	})
+}
+
+// fork returns the state of analyzing the package imported as path in another
+// goroutine, as importPackage would analyze it in this one.
+func (state *analysis) fork(path string) *analysis {
+	started := maps.Clone(state.started)
+	started[path] = true
+	return &analysis{
+		checkedDirs: maps.Clone(state.checkedDirs),
+		importDepth: state.importDepth,
+		started:     started,
+		importStack: append(slices.Clone(state.importStack), path),
+	}
+}
@@ -0,0 +1,194 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"martianoff/gala/galaerr"
+	"martianoff/gala/internal/parser/grammar"
+	"martianoff/gala/internal/transpiler"
+)
+
+// collectVisibility validates the visibility modifiers of the top-level declarations
+// in sf and records private and internal declarations in richAST.Visibility.
+func collectVisibility(sf *grammar.SourceFileContext, pkgName string, richAST *transpiler.RichAST) error {
+	for _, topDecl := range sf.AllTopLevelDeclaration() {
+		modCtx := topDecl.VisibilityModifier()
+		if modCtx == nil {
+			continue
+		}
+		vis := transpiler.VisibilityPrivate
+		if modCtx.(*grammar.VisibilityModifierContext).INTERNAL() != nil {
+			vis = transpiler.VisibilityInternal
+		}
+		line, col := modCtx.GetStart().GetLine(), modCtx.GetStart().GetColumn()
+		visibilityError := func(format string, args ...any) error {
+			return galaerr.NewSemanticErrorAt(line, col, fmt.Sprintf(format, args...)).WithCode(galaerr.CodeVisibility)
+		}
+
+		kind, _ := declarationKind(topDecl)
+		if kind == "method" && vis == transpiler.VisibilityInternal {
+			return visibilityError("internal cannot be applied to a method")
+		}
+		for _, name := range declaredTopLevelNames(topDecl) {
+			if name == "_" {
+				continue
+			}
+			exported := isExportedName(name)
+			if vis == transpiler.VisibilityPrivate && exported {
+				return visibilityError("private declaration %s must start with a lowercase letter", name)
+			}
+			if vis == transpiler.VisibilityInternal && !exported {
+				return visibilityError("internal declaration %s must start with an uppercase letter", name)
+			}
+			if kind == "method" {
+				continue // methods are only reachable through their type; Go enforces the lowercase name
+			}
+			if richAST.Visibility == nil {
+				richAST.Visibility = make(map[string]transpiler.Visibility)
+			}
+			richAST.Visibility[transpiler.QualifiedName(pkgName, name)] = vis
+		}
+	}
+	return nil
+}
+
+// declaredTopLevelNames returns the names introduced by a top-level declaration.
+func declaredTopLevelNames(topDecl grammar.ITopLevelDeclarationContext) []string {
+	var ids grammar.IIdentifierListContext
+	switch {
+	case topDecl.ValDeclaration() != nil:
+		ctx := topDecl.ValDeclaration().(*grammar.ValDeclarationContext)
+		ids = ctx.IdentifierList()
+		if ctx.TuplePattern() != nil {
+			ids = ctx.TuplePattern().(*grammar.TuplePatternContext).IdentifierList()
+		}
+	case topDecl.VarDeclaration() != nil:
+		ctx := topDecl.VarDeclaration().(*grammar.VarDeclarationContext)
+		ids = ctx.IdentifierList()
+		if ctx.TuplePattern() != nil {
+			ids = ctx.TuplePattern().(*grammar.TuplePatternContext).IdentifierList()
+		}
+	case topDecl.NewtypeDeclaration() != nil:
+		name := topDecl.NewtypeDeclaration().(*grammar.NewtypeDeclarationContext).Identifier().GetText()
+		return []string{name, name + transpiler.NewtypeFromSuffix}
+	case topDecl.InitBlock() != nil:
+		return nil
+	default:
+		_, name := declarationKind(topDecl)
+		return []string{name}
+	}
+
+	var names []string
+	if ids != nil {
+		for _, id := range ids.(*grammar.IdentifierListContext).AllIdentifier() {
+			names = append(names, id.GetText())
+		}
+	}
+	return names
+}
+
+func isExportedName(name string) bool {
+	r, _ := utf8.DecodeRuneInString(name)
+	return unicode.IsUpper(r)
+}
+
+// importedPackage is a GALA package imported by the file being checked.
+type importedPackage struct {
+	name    string // package name, the prefix of its metadata keys
+	foreign bool   // imported from another module
+}
+
+// checkVisibility rejects references in sf to private declarations of other packages
+// and to internal declarations of packages that belong to another module.
+// Only qualified references (pkg.Name) are checked.
+func (a *galaAnalyzer) checkVisibility(sf *grammar.SourceFileContext, richAST *transpiler.RichAST) error {
+	if len(richAST.Visibility) == 0 {
+		return nil
+	}
+
+	imports := make(map[string]importedPackage)
+	type selection struct {
+		alias string
+		sel   *grammar.ImportSelectorContext
+	}
+	var selections []selection
+	for _, impDecl := range sf.AllImportDeclaration() {
+		for _, spec := range impDecl.(*grammar.ImportDeclarationContext).AllImportSpec() {
+			s := spec.(*grammar.ImportSpecContext)
+			path := strings.Trim(s.STRING().GetText(), "\"")
+			pkg, ok := richAST.Packages[path]
+			if !ok || strings.HasPrefix(s.GetText(), ".") {
+				continue
+			}
+			alias := pkg
+			if s.Identifier() != nil {
+				alias = s.Identifier().GetText()
+			}
+			imports[alias] = importedPackage{name: pkg, foreign: !a.sameModule(path)}
+			for _, sel := range importSelectors(s) {
+				selections = append(selections, selection{alias: alias, sel: sel})
+			}
+		}
+	}
+	if len(imports) == 0 {
+		return nil
+	}
+
+	var err error
+	check := func(alias, name string, ctx antlr.ParserRuleContext) {
+		pkg, ok := imports[alias]
+		if !ok || err != nil {
+			return
+		}
+		line, col := ctx.GetStart().GetLine(), ctx.GetStart().GetColumn()
+		switch richAST.Visibility[pkg.name+"."+name] {
+		case transpiler.VisibilityPrivate:
+			err = galaerr.NewSemanticErrorAt(line, col, fmt.Sprintf("%s.%s is private to package %s", alias, name, pkg.name)).WithCode(galaerr.CodeVisibility)
+		case transpiler.VisibilityInternal:
+			if pkg.foreign {
+				err = galaerr.NewSemanticErrorAt(line, col, fmt.Sprintf("%s.%s is internal to the module of package %s", alias, name, pkg.name)).WithCode(galaerr.CodeVisibility)
+			}
+		}
+	}
+	// Symbols named in selective imports are references too
+	for _, s := range selections {
+		symbol, _ := selectorNames(s.sel)
+		check(s.alias, symbol, s.sel)
+	}
+	walkTree(sf, func(n antlr.Tree) {
+		switch ctx := n.(type) {
+		case *grammar.PostfixExprContext:
+			primary := ctx.PrimaryExpr().(*grammar.PrimaryExprContext).Primary()
+			suffixes := ctx.AllPostfixSuffix()
+			if primary == nil || primary.Identifier() == nil || len(suffixes) == 0 || suffixes[0].Identifier() == nil {
+				return
+			}
+			check(primary.Identifier().GetText(), suffixes[0].Identifier().GetText(), ctx)
+		case *grammar.QualifiedIdentifierContext:
+			if ids := ctx.AllIdentifier(); len(ids) == 2 {
+				check(ids[0].GetText(), ids[1].GetText(), ctx)
+			}
+		}
+	})
+	return err
+}
+
+// sameModule reports whether importPath belongs to the module being compiled.
+// Without a module name every import is treated as local.
+func (a *galaAnalyzer) sameModule(importPath string) bool {
+	modName := a.resolver.ModuleName()
+	return modName == "" || !strings.Contains(importPath, "/") ||
+		importPath == modName || strings.HasPrefix(importPath, modName+"/")
+}
+
+func walkTree(node antlr.Tree, visit func(antlr.Tree)) {
+	visit(node)
+	for i := 0; i < node.GetChildCount(); i++ {
+		walkTree(node.GetChild(i), visit)
+	}
+}
@@ -0,0 +1,56 @@
+package transpiler
+
+// Annotation is a pragma written before a top-level declaration or a struct
+// field, such as @inline or @deprecated("use NewFoo").
+type Annotation struct {
+	Name string
+	Arg  string // unquoted string argument, "" when absent
+}
+
+// Annotations understood by the compiler.
+const (
+	// AnnotationInline substitutes the body of a single-expression function at its call sites.
+	AnnotationInline = "inline"
+	// AnnotationDeprecated reports a warning wherever the declaration is used.
+	AnnotationDeprecated = "deprecated"
+	// AnnotationTailrec compiles self-recursive tail calls into a loop.
+	AnnotationTailrec = "tailrec"
+	// AnnotationNoCopy suppresses the generated Copy method of a struct.
+	AnnotationNoCopy = "noCopy"
+	// AnnotationEqualIgnore leaves a struct field out of the generated Equal method.
+	AnnotationEqualIgnore = "equalIgnore"
+	// AnnotationDeepCopy makes Copy deep-copy a field even though it is immutable.
+	AnnotationDeepCopy = "deepCopy"
+	// AnnotationShallowCopy makes Copy share a field with the original even though it is mutable.
+	AnnotationShallowCopy = "shallowCopy"
+	// AnnotationArena generates an arena builder that allocates the variants of a sealed type in bulk.
+	AnnotationArena = "arena"
+	// AnnotationCompact builds the variants of a sealed type with constructor functions instead of companion types.
+	AnnotationCompact = "compact"
+	// AnnotationJSON derives JSON and YAML codecs for a sealed type, writing the variant to a discriminator member.
+	AnnotationJSON = "json"
+	// AnnotationExtractor lets a function taking the matched value serve as an extractor in patterns.
+	AnnotationExtractor = "extractor"
+	// AnnotationTraced wraps a function in an OpenTelemetry span.
+	AnnotationTraced = "traced"
+	// AnnotationGoStruct generates a plain Go struct for a struct type, with conversions both ways.
+	AnnotationGoStruct = "goStruct"
+	// AnnotationSkip reports a test function or method as skipped instead of running it.
+	AnnotationSkip = "skip"
+	// AnnotationCapture passes the source text of the arguments of each call to the function's trailing ...string parameter.
+	AnnotationCapture = "capture"
+)
+
+// GoStructSuffix names the plain Go struct generated for a @goStruct type:
+// UserGo for User.
+const GoStructSuffix = "Go"
+
+// FindAnnotation returns the annotation called name, if present.
+func FindAnnotation(annotations []Annotation, name string) (Annotation, bool) {
+	for _, a := range annotations {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return Annotation{}, false
+}
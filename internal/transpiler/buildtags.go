@@ -0,0 +1,31 @@
+package transpiler
+
+import (
+	"strings"
+
+	"martianoff/gala/internal/transpiler/buildtags"
+)
+
+// withBuildConstraint copies the //go:build line of the GALA source src into
+// the generated code, so that go build selects the same files the analyzer
+// did. The line goes above the comments preceding the package clause, which
+// document the package.
+func withBuildConstraint(code, src string) string {
+	expr := buildtags.Constraint(src)
+	if expr == "" {
+		return code
+	}
+	lines := strings.Split(code, "\n")
+	at := 0
+	for i, line := range lines {
+		if strings.HasPrefix(line, "package ") {
+			at = i
+			for at > 0 && strings.HasPrefix(lines[at-1], "//") {
+				at--
+			}
+			break
+		}
+	}
+	constraint := []string{"//go:build " + expr, ""}
+	return strings.Join(append(lines[:at:at], append(constraint, lines[at:]...)...), "\n")
+}
@@ -0,0 +1,153 @@
+// Package buildtags decides which .gala files of a package are built for a
+// target, following Go's rules for file name suffixes and //go:build lines.
+package buildtags
+
+import (
+	"go/build"
+	"go/build/constraint"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Context describes a build target. A file named name_GOOS, name_GOARCH or
+// name_GOOS_GOARCH (optionally followed by _test) is only built for that
+// platform, and a //go:build line before the package clause must be
+// satisfied by the target's tags.
+type Context struct {
+	GOOS   string
+	GOARCH string
+	Tags   []string // Additional build tags, as given to go build -tags
+}
+
+// Default returns the context go build would use: GOOS and GOARCH from the
+// environment and the tags passed with -tags in GOFLAGS.
+func Default() Context {
+	return Context{
+		GOOS:   build.Default.GOOS,
+		GOARCH: build.Default.GOARCH,
+		Tags:   goflagsTags(os.Getenv("GOFLAGS")),
+	}
+}
+
+// goflagsTags extracts the tags of a -tags flag in GOFLAGS.
+func goflagsTags(goflags string) []string {
+	var tags []string
+	for _, flag := range strings.Fields(goflags) {
+		flag = strings.TrimPrefix(flag, "-")
+		flag = strings.TrimPrefix(flag, "-")
+		if value, ok := strings.CutPrefix(flag, "tags="); ok {
+			tags = nil
+			for _, tag := range strings.Split(value, ",") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					tags = append(tags, tag)
+				}
+			}
+		}
+	}
+	return tags
+}
+
+// MatchFile reports whether the .gala file at path is part of the build. A
+// file that cannot be read is included so that the error surfaces later.
+func (c Context) MatchFile(path string) bool {
+	if !c.matchName(filepath.Base(path)) {
+		return false
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return true
+	}
+	return c.matchConstraint(string(content))
+}
+
+// Match reports whether a file with the given base name and source is part
+// of the build.
+func (c Context) Match(name, src string) bool {
+	return c.matchName(name) && c.matchConstraint(src)
+}
+
+// matchName applies the _GOOS and _GOARCH file name suffixes.
+func (c Context) matchName(name string) bool {
+	name, _, _ = strings.Cut(name, ".")
+	i := strings.Index(name, "_")
+	if i < 0 {
+		return true
+	}
+	parts := strings.Split(name[i:], "_")
+	if n := len(parts); n > 0 && parts[n-1] == "test" {
+		parts = parts[:n-1]
+	}
+	n := len(parts)
+	if n >= 2 && knownOS[parts[n-2]] && knownArch[parts[n-1]] {
+		return c.matchTag(parts[n-2]) && c.matchTag(parts[n-1])
+	}
+	if n >= 1 && (knownOS[parts[n-1]] || knownArch[parts[n-1]]) {
+		return c.matchTag(parts[n-1])
+	}
+	return true
+}
+
+func (c Context) matchConstraint(src string) bool {
+	expr := Constraint(src)
+	if expr == "" {
+		return true
+	}
+	parsed, err := constraint.Parse("//go:build " + expr)
+	if err != nil {
+		return true // go build reports the malformed line on the generated file
+	}
+	return parsed.Eval(c.matchTag)
+}
+
+// matchTag reports whether tag is satisfied by the context.
+func (c Context) matchTag(tag string) bool {
+	switch {
+	case tag == c.GOOS || tag == c.GOARCH:
+		return true
+	case tag == "linux" && c.GOOS == "android", tag == "darwin" && c.GOOS == "ios":
+		return true
+	case tag == "unix" && unixOS[c.GOOS]:
+		return true
+	}
+	return slices.Contains(c.Tags, tag) || slices.Contains(build.Default.ReleaseTags, tag)
+}
+
+// Constraint returns the expression of the //go:build line in the
+// comments that precede the package clause of src, or "" if there is none.
+func Constraint(src string) string {
+	for _, line := range strings.Split(src, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+		case constraint.IsGoBuild(line):
+			return strings.TrimSpace(strings.TrimPrefix(line, "//go:build"))
+		case strings.HasPrefix(line, "//"):
+		default:
+			return ""
+		}
+	}
+	return ""
+}
+
+var knownOS = map[string]bool{
+	"aix": true, "android": true, "darwin": true, "dragonfly": true, "freebsd": true,
+	"hurd": true, "illumos": true, "ios": true, "js": true, "linux": true, "nacl": true,
+	"netbsd": true, "openbsd": true, "plan9": true, "solaris": true, "wasip1": true,
+	"windows": true, "zos": true,
+}
+
+var unixOS = map[string]bool{
+	"aix": true, "android": true, "darwin": true, "dragonfly": true, "freebsd": true,
+	"hurd": true, "illumos": true, "ios": true, "linux": true, "netbsd": true,
+	"openbsd": true, "solaris": true,
+}
+
+var knownArch = map[string]bool{
+	"386": true, "amd64": true, "amd64p32": true, "arm": true, "armbe": true,
+	"arm64": true, "arm64be": true, "loong64": true, "mips": true, "mipsle": true,
+	"mips64": true, "mips64le": true, "mips64p32": true, "mips64p32le": true,
+	"ppc": true, "ppc64": true, "ppc64le": true, "riscv": true, "riscv64": true,
+	"s390": true, "s390x": true, "sparc": true, "sparc64": true, "wasm": true,
+}
@@ -0,0 +1,61 @@
+package buildtags
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatch(t *testing.T) {
+	linux := Context{GOOS: "linux", GOARCH: "amd64", Tags: []string{"experimental"}}
+	tests := []struct {
+		name string
+		file string
+		src  string
+		want bool
+	}{
+		{name: "plain file", file: "net.gala", src: "package net\n", want: true},
+		{name: "matching OS suffix", file: "net_linux.gala", src: "package net\n", want: true},
+		{name: "other OS suffix", file: "net_windows.gala", src: "package net\n", want: false},
+		{name: "OS and arch suffix", file: "net_linux_arm64.gala", src: "package net\n", want: false},
+		{name: "arch suffix on test file", file: "net_amd64_test.gala", src: "package net\n", want: true},
+		{name: "suffix needs an underscore", file: "linux.gala", src: "package net\n", want: true},
+		{name: "unknown suffix", file: "net_fast.gala", src: "package net\n", want: true},
+		{name: "satisfied constraint", file: "net.gala", src: "//go:build linux && !cgo_only\n\npackage net\n", want: true},
+		{name: "unsatisfied constraint", file: "net.gala", src: "//go:build windows\n\npackage net\n", want: false},
+		{name: "unix tag", file: "net.gala", src: "// Networking\n//go:build unix\n\npackage net\n", want: true},
+		{name: "custom tag", file: "net.gala", src: "//go:build experimental\n\npackage net\n", want: true},
+		{name: "release tag", file: "net.gala", src: "//go:build go1.18\n\npackage net\n", want: true},
+		{name: "constraint after package clause", file: "net.gala", src: "package net\n\n//go:build windows\n", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, linux.Match(tt.file, tt.src))
+		})
+	}
+}
+
+func TestMatchFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "exp.gala")
+	require.NoError(t, os.WriteFile(path, []byte("//go:build experimental\n\npackage exp\n"), 0644))
+
+	assert.False(t, Context{GOOS: "linux", GOARCH: "amd64"}.MatchFile(path))
+	assert.True(t, Context{GOOS: "linux", GOARCH: "amd64", Tags: []string{"experimental"}}.MatchFile(path))
+	assert.False(t, Context{GOOS: "linux", GOARCH: "amd64"}.MatchFile(filepath.Join(dir, "missing_windows.gala")))
+}
+
+func TestConstraint(t *testing.T) {
+	assert.Equal(t, "linux || darwin", Constraint("// Copyright\n\n//go:build linux || darwin\n\npackage p\n"))
+	assert.Equal(t, "", Constraint("package p\n"))
+}
+
+func TestGoflagsTags(t *testing.T) {
+	assert.Equal(t, []string{"a", "b"}, goflagsTags("-mod=mod -tags=a,b"))
+	assert.Equal(t, []string{"c"}, goflagsTags("--tags=a -tags=c"))
+	assert.Nil(t, goflagsTags("-mod=mod"))
+}
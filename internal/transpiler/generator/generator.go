@@ -1,14 +1,18 @@
 package generator
 
 import (
-	"bytes"
+	"fmt"
 	"go/ast"
 	"go/format"
 	"go/token"
+	"io"
+	"strings"
+
 	"martianoff/gala/internal/transpiler"
 )
 
 type goCodeGenerator struct {
+	target transpiler.GoVersion
 }
 
 // NewGoCodeGenerator creates a new instance of CodeGenerator that generates Go code.
@@ -16,17 +20,80 @@ func NewGoCodeGenerator() transpiler.CodeGenerator {
 	return &goCodeGenerator{}
 }
 
+// NewGoCodeGeneratorWithTarget creates a CodeGenerator that records the target Go version in the file header.
+func NewGoCodeGeneratorWithTarget(target transpiler.GoVersion) transpiler.CodeGenerator {
+	return &goCodeGenerator{target: target}
+}
+
 // generatedHeader is the standard Go generated file header.
 // This tells tools like `go generate` and IDEs that the file is auto-generated.
 const generatedHeader = "// Code generated by GALA transpiler. DO NOT EDIT.\n\n"
 
 // Generate implements the CodeGenerator interface.
 func (g *goCodeGenerator) Generate(fset *token.FileSet, file *ast.File) (string, error) {
-	var buf bytes.Buffer
-	if err := format.Node(&buf, fset, file); err != nil {
+	var sb strings.Builder
+	if err := g.GenerateTo(&sb, fset, file, nil, ""); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// GenerateTo implements the StreamingGenerator interface. The header and the
+// build constraint are written first, then the printer output goes straight
+// to w.
+func (g *goCodeGenerator) GenerateTo(w io.Writer, fset *token.FileSet, file *ast.File, p *transpiler.Provenance, constraint string) error {
+	header := g.header()
+	if p != nil {
+		header = g.provenanceHeader(*p)
+	}
+	if constraint != "" {
+		header += "//go:build " + constraint + "\n\n"
+	}
+	if _, err := io.WriteString(w, header); err != nil {
+		return err
+	}
+	return format.Node(w, fset, file)
+}
+
+// header returns the generated file header, naming the target Go version when one is set.
+func (g *goCodeGenerator) header() string {
+	if !g.target.IsSet() {
+		return generatedHeader
+	}
+	return fmt.Sprintf("// Code generated by GALA transpiler for %s. DO NOT EDIT.\n\n", g.target)
+}
+
+// GenerateWithProvenance implements the ProvenanceGenerator interface. The
+// header names the GALA version and source and records the source hash:
+//
+//	// Code generated by gala v0.9.0 from myapp/models/user.gala; DO NOT EDIT.
+//	// Source hash: sha256:9f86d0...
+//	// Regenerate with: gala build
+func (g *goCodeGenerator) GenerateWithProvenance(fset *token.FileSet, file *ast.File, p transpiler.Provenance) (string, error) {
+	var sb strings.Builder
+	if err := g.GenerateTo(&sb, fset, file, &p, ""); err != nil {
 		return "", err
 	}
-	return generatedHeader + buf.String(), nil
+	return sb.String(), nil
 }
 
-var _ transpiler.CodeGenerator = (*goCodeGenerator)(nil)
+func (g *goCodeGenerator) provenanceHeader(p transpiler.Provenance) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "// Code generated by gala %s from %s", p.Version, p.Source)
+	if g.target.IsSet() {
+		fmt.Fprintf(&sb, " for %s", g.target)
+	}
+	sb.WriteString("; DO NOT EDIT.\n")
+	fmt.Fprintf(&sb, "// Source hash: %s\n", p.Hash)
+	if p.Command != "" {
+		fmt.Fprintf(&sb, "// Regenerate with: %s\n", p.Command)
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+var (
+	_ transpiler.CodeGenerator       = (*goCodeGenerator)(nil)
+	_ transpiler.ProvenanceGenerator = (*goCodeGenerator)(nil)
+	_ transpiler.StreamingGenerator  = (*goCodeGenerator)(nil)
+)
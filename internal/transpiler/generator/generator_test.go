@@ -3,8 +3,11 @@ package generator
 import (
 	"go/parser"
 	"go/token"
+	"strings"
 	"testing"
 
+	"martianoff/gala/internal/transpiler"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -68,3 +71,54 @@ func (p Point) Sum() int { return p.X + p.Y }
 		})
 	}
 }
+
+func TestGoCodeGenerator_TargetHeader(t *testing.T) {
+	g := NewGoCodeGeneratorWithTarget(transpiler.GoVersion{Major: 1, Minor: 21})
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "test.go", "package main\n", parser.ParseComments)
+	assert.NoError(t, err)
+
+	got, err := g.Generate(fset, file)
+	assert.NoError(t, err)
+	assert.Equal(t, "// Code generated by GALA transpiler for go1.21. DO NOT EDIT.\n\npackage main\n", got)
+}
+
+func TestGoCodeGenerator_ProvenanceHeader(t *testing.T) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "test.go", "package main\n", parser.ParseComments)
+	assert.NoError(t, err)
+
+	p := transpiler.Provenance{
+		Version: "v0.9.0",
+		Source:  "myapp/main.gala",
+		Hash:    transpiler.SourceHash("package main\n"),
+		Command: "gala build",
+	}
+	got, err := NewGoCodeGeneratorWithTarget(transpiler.GoVersion{Major: 1, Minor: 21}).(transpiler.ProvenanceGenerator).GenerateWithProvenance(fset, file, p)
+	assert.NoError(t, err)
+	assert.Equal(t, "// Code generated by gala v0.9.0 from myapp/main.gala for go1.21; DO NOT EDIT.\n"+
+		"// Source hash: "+p.Hash+"\n"+
+		"// Regenerate with: gala build\n"+
+		"\n"+
+		"package main\n", got)
+	assert.Regexp(t, `^// Code generated .* DO NOT EDIT\.$`, strings.SplitN(got, "\n", 2)[0])
+}
+
+func TestGoCodeGenerator_GenerateTo(t *testing.T) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "test.go", "// Package main runs things.\npackage main\n", parser.ParseComments)
+	assert.NoError(t, err)
+
+	g := NewGoCodeGenerator().(transpiler.StreamingGenerator)
+	var sb strings.Builder
+	assert.NoError(t, g.GenerateTo(&sb, fset, file, nil, "linux && amd64"))
+	assert.Equal(t, generatedHeader+"//go:build linux && amd64\n\n// Package main runs things.\npackage main\n", sb.String())
+
+	// Without a constraint the output matches Generate
+	sb.Reset()
+	assert.NoError(t, g.GenerateTo(&sb, fset, file, nil, ""))
+	want, err := NewGoCodeGenerator().Generate(fset, file)
+	assert.NoError(t, err)
+	assert.Equal(t, want, sb.String())
+}
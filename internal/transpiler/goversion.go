@@ -0,0 +1,72 @@
+package transpiler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MinGoVersion is the oldest Go release generated code can target (first with generics).
+var MinGoVersion = GoVersion{Major: 1, Minor: 18}
+
+// GoVersion is the Go release generated code must compile with.
+// The zero value means no explicit target: every supported language feature may be emitted.
+type GoVersion struct {
+	Major int
+	Minor int
+}
+
+// ParseGoVersion parses a version such as "1.21", "go1.21" or "1.21.3".
+// The patch component is accepted and ignored.
+func ParseGoVersion(s string) (GoVersion, error) {
+	parts := strings.Split(strings.TrimPrefix(strings.TrimSpace(s), "go"), ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return GoVersion{}, fmt.Errorf("invalid Go version %q: expected form 1.N", s)
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return GoVersion{}, fmt.Errorf("invalid Go version %q: %v", s, err)
+	}
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return GoVersion{}, fmt.Errorf("invalid Go version %q: %v", s, err)
+	}
+	v := GoVersion{Major: major, Minor: minor}
+	if !v.AtLeast(MinGoVersion.Major, MinGoVersion.Minor) {
+		return GoVersion{}, fmt.Errorf("unsupported Go version %s: generated code requires generics (%s or later)", v, MinGoVersion)
+	}
+	return v, nil
+}
+
+// IsSet reports whether an explicit target was configured.
+func (v GoVersion) IsSet() bool {
+	return v.Major != 0
+}
+
+// String returns the version in go directive form, e.g. "go1.21".
+func (v GoVersion) String() string {
+	if !v.IsSet() {
+		return ""
+	}
+	return fmt.Sprintf("go%d.%d", v.Major, v.Minor)
+}
+
+// AtLeast reports whether the target is major.minor or newer. An unset target satisfies every version.
+func (v GoVersion) AtLeast(major, minor int) bool {
+	if !v.IsSet() {
+		return true
+	}
+	return v.Major > major || (v.Major == major && v.Minor >= minor)
+}
+
+// SupportsMinMaxBuiltins reports whether the min and max builtins are available (Go 1.21).
+func (v GoVersion) SupportsMinMaxBuiltins() bool { return v.AtLeast(1, 21) }
+
+// SupportsRangeOverInt reports whether `for i := range n` over an integer is available (Go 1.22).
+func (v GoVersion) SupportsRangeOverInt() bool { return v.AtLeast(1, 22) }
+
+// SupportsRangeOverFunc reports whether ranging over iterator functions is available (Go 1.23).
+func (v GoVersion) SupportsRangeOverFunc() bool { return v.AtLeast(1, 23) }
+
+// SupportsGenericAliases reports whether type aliases can have type parameters (Go 1.24).
+func (v GoVersion) SupportsGenericAliases() bool { return v.AtLeast(1, 24) }
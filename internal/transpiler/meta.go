@@ -0,0 +1,159 @@
+package transpiler
+
+import "sort"
+
+// MetaSchemaVersion is bumped whenever a field of the metadata export changes
+// meaning or is removed. Adding fields does not change the version.
+const MetaSchemaVersion = 1
+
+// PackageMeta is the machine-readable export of a package's RichAST, printed
+// by `gala meta`. Entries are sorted by name so the output is stable.
+type PackageMeta struct {
+	SchemaVersion int             `json:"schemaVersion"`
+	Package       string          `json:"package"`
+	Types         []TypeMeta      `json:"types"`
+	Functions     []FuncMeta      `json:"functions"`
+	Companions    []CompanionMeta `json:"companions"`
+}
+
+// TypeMeta describes a struct, sealed type, newtype or type defined as another.
+type TypeMeta struct {
+	Name       string        `json:"name"`
+	TypeParams []TypeParam   `json:"typeParams,omitempty"`
+	Fields     []FieldMeta   `json:"fields"`
+	Methods    []FuncMeta    `json:"methods"`
+	Sealed     bool          `json:"sealed,omitempty"`
+	Variants   []VariantMeta `json:"variants,omitempty"`
+	Underlying string        `json:"underlying,omitempty"` // represented type of a newtype
+	AliasOf    string        `json:"aliasOf,omitempty"`    // type a type X T declaration is defined as
+	Alias      bool          `json:"alias,omitempty"`      // declared as type X = T, an alias of AliasOf
+}
+
+// TypeParam is a type parameter and its constraint.
+type TypeParam struct {
+	Name       string `json:"name"`
+	Constraint string `json:"constraint"`
+}
+
+// FieldMeta is a struct field in declaration order.
+type FieldMeta struct {
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	Immutable bool   `json:"immutable"`
+}
+
+// FuncMeta is the signature of a function or method.
+type FuncMeta struct {
+	Name       string   `json:"name"`
+	TypeParams []string `json:"typeParams,omitempty"`
+	Params     []string `json:"params"`
+	Result     string   `json:"result,omitempty"`
+}
+
+// VariantMeta is one case of a sealed type.
+type VariantMeta struct {
+	Name   string      `json:"name"`
+	Fields []FieldMeta `json:"fields"`
+}
+
+// CompanionMeta is an extractor usable in patterns.
+type CompanionMeta struct {
+	Name           string `json:"name"`
+	TargetType     string `json:"targetType"`
+	ExtractIndices []int  `json:"extractIndices,omitempty"`
+}
+
+// ExportMeta converts the declarations of pkgName in r into a PackageMeta.
+// Metadata of imported packages that r also carries is left out.
+func ExportMeta(r *RichAST, pkgName string) *PackageMeta {
+	meta := &PackageMeta{
+		SchemaVersion: MetaSchemaVersion,
+		Package:       pkgName,
+		Types:         []TypeMeta{},
+		Functions:     []FuncMeta{},
+		Companions:    []CompanionMeta{},
+	}
+
+	for _, t := range r.Types {
+		if t.Package != pkgName {
+			continue
+		}
+		tm := TypeMeta{Name: t.Name, Fields: []FieldMeta{}, Methods: []FuncMeta{}, Sealed: t.IsSealed}
+		if t.IsNewtype() {
+			tm.Underlying = typeString(t.Underlying)
+		}
+		if t.AliasOf != nil {
+			tm.AliasOf = typeString(t.AliasOf)
+			tm.Alias = t.IsAlias
+		}
+		for _, p := range t.TypeParams {
+			constraint := t.TypeParamConstraints[p]
+			if constraint == "" {
+				constraint = "any"
+			}
+			tm.TypeParams = append(tm.TypeParams, TypeParam{Name: p, Constraint: constraint})
+		}
+		for i, name := range t.FieldNames {
+			tm.Fields = append(tm.Fields, FieldMeta{
+				Name:      name,
+				Type:      typeString(t.Fields[name]),
+				Immutable: i < len(t.ImmutFlags) && t.ImmutFlags[i],
+			})
+		}
+		for _, m := range t.Methods {
+			tm.Methods = append(tm.Methods, funcMeta(m.Name, m.TypeParams, m.ParamTypes, m.ReturnType))
+		}
+		sortFuncs(tm.Methods)
+		for _, v := range t.SealedVariants {
+			vm := VariantMeta{Name: v.Name, Fields: []FieldMeta{}}
+			for i, name := range v.FieldNames {
+				var ft Type
+				if i < len(v.FieldTypes) {
+					ft = v.FieldTypes[i]
+				}
+				vm.Fields = append(vm.Fields, FieldMeta{Name: name, Type: typeString(ft), Immutable: true})
+			}
+			tm.Variants = append(tm.Variants, vm)
+		}
+		meta.Types = append(meta.Types, tm)
+	}
+	sort.Slice(meta.Types, func(i, j int) bool { return meta.Types[i].Name < meta.Types[j].Name })
+
+	for _, f := range r.Functions {
+		if f.Package != pkgName {
+			continue
+		}
+		meta.Functions = append(meta.Functions, funcMeta(f.Name, f.TypeParams, f.ParamTypes, f.ReturnType))
+	}
+	sortFuncs(meta.Functions)
+
+	for _, c := range r.CompanionObjects {
+		if c.Package != pkgName {
+			continue
+		}
+		meta.Companions = append(meta.Companions, CompanionMeta{Name: c.Name, TargetType: c.TargetType, ExtractIndices: c.ExtractIndices})
+	}
+	sort.Slice(meta.Companions, func(i, j int) bool { return meta.Companions[i].Name < meta.Companions[j].Name })
+
+	return meta
+}
+
+func funcMeta(name string, typeParams []string, params []Type, result Type) FuncMeta {
+	fm := FuncMeta{Name: name, TypeParams: typeParams, Params: []string{}, Result: typeString(result)}
+	for _, p := range params {
+		fm.Params = append(fm.Params, typeString(p))
+	}
+	return fm
+}
+
+func sortFuncs(fs []FuncMeta) {
+	sort.Slice(fs, func(i, j int) bool { return fs[i].Name < fs[j].Name })
+}
+
+// typeString renders t in GALA syntax, or "" for a missing or nil type.
+func typeString(t Type) string {
+	if t == nil || t.IsNil() {
+		return ""
+	}
+	return t.String()
+}
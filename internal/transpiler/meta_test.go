@@ -0,0 +1,70 @@
+package transpiler_test
+
+import (
+	"encoding/json"
+	"martianoff/gala/internal/transpiler"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportMeta(t *testing.T) {
+	intType := transpiler.BasicType{Name: "int"}
+	strType := transpiler.BasicType{Name: "string"}
+	r := &transpiler.RichAST{
+		Types: map[string]*transpiler.TypeMetadata{
+			"models.User": {
+				Name:       "User",
+				Package:    "models",
+				Fields:     map[string]transpiler.Type{"Name": strType, "Age": intType},
+				FieldNames: []string{"Name", "Age"},
+				ImmutFlags: []bool{true, false},
+				Methods: map[string]*transpiler.MethodMetadata{
+					"Greet": {Name: "Greet", ReturnType: strType},
+				},
+			},
+			"models.Shape": {
+				Name:     "Shape",
+				Package:  "models",
+				IsSealed: true,
+				SealedVariants: []transpiler.SealedVariant{
+					{Name: "Circle", FieldNames: []string{"Radius"}, FieldTypes: []transpiler.Type{intType}},
+				},
+			},
+			"models.UserId": {Name: "UserId", Package: "models", Underlying: strType},
+			"std.Option":    {Name: "Option", Package: "std"},
+		},
+		Functions: map[string]*transpiler.FunctionMetadata{
+			"models.NewUser": {Name: "NewUser", Package: "models", ParamTypes: []transpiler.Type{strType}, ReturnType: transpiler.NamedType{Package: "models", Name: "User"}},
+		},
+		CompanionObjects: map[string]*transpiler.CompanionObjectMetadata{
+			"Circle": {Name: "Circle", Package: "models", TargetType: "Shape"},
+		},
+	}
+
+	meta := transpiler.ExportMeta(r, "models")
+	assert.Equal(t, transpiler.MetaSchemaVersion, meta.SchemaVersion)
+	assert.Len(t, meta.Types, 3)
+	assert.Equal(t, "Shape", meta.Types[0].Name)
+	assert.True(t, meta.Types[0].Sealed)
+	assert.Equal(t, "Radius", meta.Types[0].Variants[0].Fields[0].Name)
+
+	user := meta.Types[1]
+	assert.Equal(t, []transpiler.FieldMeta{
+		{Name: "Name", Type: "string", Immutable: true},
+		{Name: "Age", Type: "int", Immutable: false},
+	}, user.Fields)
+	assert.Equal(t, "Greet", user.Methods[0].Name)
+	assert.Equal(t, "string", user.Methods[0].Result)
+	assert.Empty(t, user.Underlying)
+	assert.Equal(t, "string", meta.Types[2].Underlying)
+
+	assert.Len(t, meta.Functions, 1)
+	assert.Equal(t, []string{"string"}, meta.Functions[0].Params)
+	assert.Equal(t, "Circle", meta.Companions[0].Name)
+
+	data, err := json.Marshal(meta)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"schemaVersion":1`)
+	assert.Contains(t, string(data), `"package":"models"`)
+}
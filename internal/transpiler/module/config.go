@@ -0,0 +1,170 @@
+package module
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ConfigFileName is the name of the optional project configuration file in
+// the module root.
+const ConfigFileName = "gala.toml"
+
+// Config is the project configuration read from gala.toml. The file uses a
+// small subset of TOML: tables, comments and keys whose values are strings or
+// arrays of strings.
+//
+//	[project]
+//	prelude = ["myapp/domain"]
+//	features = ["experimental"]
+type Config struct {
+	// Prelude lists the import paths of the project's prelude packages. Their
+	// exports are available in every file of the module without an import,
+	// the way std is.
+	Prelude []string
+	// Features lists the features enabled for the build, whose
+	// #if feature("name") blocks are compiled.
+	Features []string
+}
+
+// ConfigError reports a malformed gala.toml.
+type ConfigError struct {
+	Line    int
+	Message string
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("%s:%d: %s", ConfigFileName, e.Line, e.Message)
+}
+
+// LoadConfig reads gala.toml from dir. A missing file yields an empty Config.
+func LoadConfig(dir string) (*Config, error) {
+	content, err := os.ReadFile(filepath.Join(dir, ConfigFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", ConfigFileName, err)
+	}
+	return ParseConfig(string(content))
+}
+
+// ParseConfig parses the contents of a gala.toml file.
+func ParseConfig(content string) (*Config, error) {
+	c := &Config{}
+	table := ""
+	lines := strings.Split(content, "\n")
+	for i := 0; i < len(lines); i++ {
+		lineNum := i + 1
+		line := strings.TrimSpace(stripComment(lines[i]))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, &ConfigError{Line: lineNum, Message: "unterminated table header"}
+			}
+			table = strings.TrimSpace(line[1 : len(line)-1])
+			if table != "project" {
+				return nil, &ConfigError{Line: lineNum, Message: fmt.Sprintf("unknown table: %s", table)}
+			}
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, &ConfigError{Line: lineNum, Message: fmt.Sprintf("expected key = value, got %q", line)}
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+
+		// Arrays may span several lines
+		for strings.HasPrefix(value, "[") && !strings.HasSuffix(value, "]") && i+1 < len(lines) {
+			i++
+			value += " " + strings.TrimSpace(stripComment(lines[i]))
+		}
+
+		switch {
+		case table == "project" && key == "prelude":
+			paths, err := parseStringArray(value)
+			if err != nil {
+				return nil, &ConfigError{Line: lineNum, Message: fmt.Sprintf("prelude: %v", err)}
+			}
+			c.Prelude = paths
+		case table == "project" && key == "features":
+			names, err := parseStringArray(value)
+			if err != nil {
+				return nil, &ConfigError{Line: lineNum, Message: fmt.Sprintf("features: %v", err)}
+			}
+			c.Features = names
+		case table == "":
+			return nil, &ConfigError{Line: lineNum, Message: fmt.Sprintf("key %s must be inside a table", key)}
+		default:
+			return nil, &ConfigError{Line: lineNum, Message: fmt.Sprintf("unknown key: %s.%s", table, key)}
+		}
+	}
+	return c, nil
+}
+
+// stripComment removes a # comment that is not inside a string.
+func stripComment(line string) string {
+	inString := false
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			if inString {
+				i++
+			}
+		case '"':
+			inString = !inString
+		case '#':
+			if !inString {
+				return line[:i]
+			}
+		}
+	}
+	return line
+}
+
+// parseStringArray parses a TOML array of basic strings, e.g. ["a", "b"].
+func parseStringArray(value string) ([]string, error) {
+	if !strings.HasPrefix(value, "[") || !strings.HasSuffix(value, "]") {
+		return nil, fmt.Errorf("expected an array of strings, got %s", value)
+	}
+	rest := strings.TrimSpace(value[1 : len(value)-1])
+	var result []string
+	for rest != "" {
+		if rest[0] != '"' {
+			return nil, fmt.Errorf("expected a string, got %s", rest)
+		}
+		end := 1
+		for end < len(rest) && rest[end] != '"' {
+			if rest[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(rest) {
+			return nil, fmt.Errorf("unterminated string %s", rest)
+		}
+		s, err := strconv.Unquote(rest[:end+1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid string %s", rest[:end+1])
+		}
+		result = append(result, s)
+
+		rest = strings.TrimSpace(rest[end+1:])
+		if rest == "" {
+			break
+		}
+		if rest[0] != ',' {
+			return nil, fmt.Errorf("expected , between strings, got %s", rest)
+		}
+		rest = strings.TrimSpace(rest[1:])
+	}
+	return result, nil
+}
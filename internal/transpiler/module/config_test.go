@@ -0,0 +1,116 @@
+package module
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+		wantErr string
+	}{
+		{
+			name:    "empty",
+			content: "",
+		},
+		{
+			name:    "single line prelude",
+			content: "[project]\nprelude = [\"myapp/domain\", \"myapp/ids\"]\n",
+			want:    []string{"myapp/domain", "myapp/ids"},
+		},
+		{
+			name: "multi line prelude with comments",
+			content: `# Project settings
+[project]
+prelude = [
+    "myapp/domain", # core types
+    "myapp/ids",
+]
+`,
+			want: []string{"myapp/domain", "myapp/ids"},
+		},
+		{
+			name:    "hash inside string",
+			content: "[project]\nprelude = [\"myapp/#tag\"]\n",
+			want:    []string{"myapp/#tag"},
+		},
+		{
+			name:    "unknown table",
+			content: "[build]\n",
+			wantErr: "gala.toml:1: unknown table: build",
+		},
+		{
+			name:    "unknown key",
+			content: "[project]\npreludes = []\n",
+			wantErr: "gala.toml:2: unknown key: project.preludes",
+		},
+		{
+			name:    "key outside table",
+			content: "prelude = []\n",
+			wantErr: "gala.toml:1: key prelude must be inside a table",
+		},
+		{
+			name:    "prelude not an array",
+			content: "[project]\nprelude = \"myapp/domain\"\n",
+			wantErr: "gala.toml:2: prelude: expected an array of strings",
+		},
+		{
+			name:    "missing comma",
+			content: "[project]\nprelude = [\"a\" \"b\"]\n",
+			wantErr: "gala.toml:2: prelude: expected , between strings",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseConfig(tt.content)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, c.Prelude)
+		})
+	}
+}
+
+func TestParseConfig_Features(t *testing.T) {
+	c, err := ParseConfig("[project]\nprelude = [\"myapp/domain\"]\nfeatures = [\"experimental\", \"simd\"]\n")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"experimental", "simd"}, c.Features)
+	assert.Equal(t, []string{"myapp/domain"}, c.Prelude)
+
+	_, err = ParseConfig("[project]\nfeatures = \"experimental\"\n")
+	assert.ErrorContains(t, err, "gala.toml:2: features: expected an array of strings")
+}
+
+func TestResolver_Config(t *testing.T) {
+	tempDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "go.mod"), []byte("module test/project\n\ngo 1.21\n"), 0644))
+
+	originalWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(originalWd)
+	require.NoError(t, os.Chdir(tempDir))
+
+	// No gala.toml
+	config, err := NewResolver(nil).Config()
+	require.NoError(t, err)
+	assert.Empty(t, config.Prelude)
+
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, ConfigFileName), []byte("[project]\nprelude = [\"test/project/domain\"]\n"), 0644))
+	config, err = NewResolver(nil).Config()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"test/project/domain"}, config.Prelude)
+
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, ConfigFileName), []byte("[project]\nprelude = \n"), 0644))
+	_, err = NewResolver(nil).Config()
+	assert.Error(t, err)
+}
@@ -8,6 +8,7 @@ import (
 
 	"martianoff/gala/internal/depman/fetch"
 	"martianoff/gala/internal/depman/mod"
+	"martianoff/gala/internal/transpiler/buildtags"
 )
 
 // Resolver handles module root discovery and package path resolution.
@@ -19,12 +20,15 @@ import (
 //	resolver := NewResolver(searchPaths)
 //	fsPath, err := resolver.ResolvePackagePath("martianoff/gala/std")
 type Resolver struct {
-	moduleRoot  string       // Filesystem path to module root (where go.mod is located)
-	moduleName  string       // Module name from go.mod (e.g., "martianoff/gala")
-	searchPaths []string     // Fallback search paths when module resolution fails
-	galaMod     *mod.File    // Parsed gala.mod file (if present)
-	galaModPath string       // Path to gala.mod file
-	cache       *fetch.Cache // GALA dependency cache
+	moduleRoot  string            // Filesystem path to module root (where go.mod is located)
+	moduleName  string            // Module name from go.mod (e.g., "martianoff/gala")
+	searchPaths []string          // Fallback search paths when module resolution fails
+	galaMod     *mod.File         // Parsed gala.mod file (if present)
+	galaModPath string            // Path to gala.mod file
+	config      *Config           // Parsed gala.toml, empty if absent
+	configErr   error             // Error reading gala.toml
+	build       buildtags.Context // Target the package files are selected for
+	cache       *fetch.Cache      // GALA dependency cache
 }
 
 // NewResolver creates a Resolver by searching for go.mod and gala.mod.
@@ -35,7 +39,8 @@ type Resolver struct {
 // 2. If not found, try each search path
 // 3. Extract module name from go.mod or gala.mod when found
 // 4. Load gala.mod if present (for replace directives and dependencies)
-// 5. Initialize the GALA dependency cache
+// 5. Load gala.toml if present (for project configuration)
+// 6. Initialize the GALA dependency cache
 func NewResolver(searchPaths []string) *Resolver {
 	moduleRoot, moduleName := findModuleRootFromCwdOrPaths(searchPaths)
 
@@ -43,6 +48,7 @@ func NewResolver(searchPaths []string) *Resolver {
 		moduleRoot:  moduleRoot,
 		moduleName:  moduleName,
 		searchPaths: searchPaths,
+		build:       buildtags.Default(),
 		cache:       fetch.NewCache(fetch.DefaultConfig()),
 	}
 
@@ -62,6 +68,15 @@ func NewResolver(searchPaths []string) *Resolver {
 		}
 	}
 
+	r.config = &Config{}
+	if r.moduleRoot != "" {
+		if config, err := LoadConfig(r.moduleRoot); err != nil {
+			r.configErr = err
+		} else {
+			r.config = config
+		}
+	}
+
 	return r
 }
 
@@ -80,6 +95,19 @@ func (r *Resolver) GalaMod() *mod.File {
 	return r.galaMod
 }
 
+// Config returns the project configuration from gala.toml in the module root.
+// It is empty, not nil, when there is no such file; the error reports a
+// gala.toml that could not be read or parsed.
+func (r *Resolver) Config() (*Config, error) {
+	return r.config, r.configErr
+}
+
+// BuildContext returns the target whose file name suffixes and build
+// constraints decide which .gala files belong to a package.
+func (r *Resolver) BuildContext() buildtags.Context {
+	return r.build
+}
+
 // HasGalaMod returns true if a gala.mod file was found.
 func (r *Resolver) HasGalaMod() bool {
 	return r.galaMod != nil
@@ -17,6 +17,14 @@ func NewAntlrGalaParser() GalaParser {
 	}
 }
 
+// NewAntlrGalaParserWithFeatures creates a GalaParser that compiles the
+// #if feature("name") blocks of the given features.
+func NewAntlrGalaParserWithFeatures(features []string) GalaParser {
+	return &antlrGalaParser{
+		wrapper: parser.NewAntlrGalaParserWithFeatures(features),
+	}
+}
+
 // Parse implements the GalaParser interface.
 func (p *antlrGalaParser) Parse(input string) (antlr.Tree, error) {
 	return p.wrapper.Parse(input)
@@ -0,0 +1,73 @@
+package transpiler
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+)
+
+// Pass is a hook run by GalaToGoTranspiler around the transformer. Tools use
+// passes to inject instrumentation, enforce project rules, or derive companion
+// files (SQL schemas, protobuf bindings, ...) from GALA type metadata.
+//
+// Either hook may be nil. Passes run in registration order; the first error
+// aborts transpilation.
+type Pass struct {
+	// Name identifies the pass in error messages.
+	Name string
+	// Analyzed runs after analysis, over the typed AST. ctx.File is nil.
+	Analyzed func(ctx *PassContext) error
+	// Generated runs over the generated Go AST before it is printed.
+	Generated func(ctx *PassContext) error
+}
+
+// PassContext is the state shared with a pass for one transpiled file.
+type PassContext struct {
+	RichAST *RichAST
+	FileSet *token.FileSet
+	File    *ast.File
+
+	companions map[string]string
+}
+
+// Metadata returns the declarations of the package being transpiled, in the
+// form printed by `gala meta`.
+func (c *PassContext) Metadata() *PackageMeta {
+	return ExportMeta(c.RichAST, c.RichAST.PackageName)
+}
+
+// EmitFile records a companion file produced by a pass. The file is not written
+// by the transpiler; callers collect it with GalaToGoTranspiler.CompanionFiles.
+func (c *PassContext) EmitFile(name, content string) {
+	c.companions[name] = content
+}
+
+// Option configures a GalaToGoTranspiler.
+type Option func(*GalaToGoTranspiler)
+
+// WithPass registers a pass on the transpiler.
+func WithPass(p Pass) Option {
+	return func(t *GalaToGoTranspiler) {
+		t.passes = append(t.passes, p)
+	}
+}
+
+// runPasses runs the hook selected by stage for every registered pass.
+func (t *GalaToGoTranspiler) runPasses(ctx *PassContext, stage func(Pass) func(*PassContext) error) error {
+	for _, p := range t.passes {
+		hook := stage(p)
+		if hook == nil {
+			continue
+		}
+		if err := hook(ctx); err != nil {
+			return fmt.Errorf("pass %s: %w", p.Name, err)
+		}
+	}
+	return nil
+}
+
+// CompanionFiles returns the files emitted by passes during the last Transpile call,
+// keyed by name.
+func (t *GalaToGoTranspiler) CompanionFiles() map[string]string {
+	return t.companions
+}
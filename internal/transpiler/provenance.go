@@ -0,0 +1,94 @@
+package transpiler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"go/ast"
+	"go/token"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Version is the GALA release named in the header of generated files. The
+// gala command sets it to its own version.
+var Version = "dev"
+
+// Provenance identifies the GALA source a Go file was generated from. It is
+// recorded in the file header so that stale generated files can be detected.
+type Provenance struct {
+	Version string // GALA release that generated the file
+	Source  string // slash-separated source path, module-qualified when known
+	Hash    string // SourceHash of the source content
+	Command string // command that regenerates the file; may be empty
+}
+
+// ProvenanceGenerator is implemented by code generators that record the
+// provenance of the code in the header of the generated file.
+type ProvenanceGenerator interface {
+	GenerateWithProvenance(fset *token.FileSet, file *ast.File, p Provenance) (string, error)
+}
+
+// SourceHash returns the digest of GALA source content recorded in generated
+// file headers, e.g. "sha256:9f86d0...".
+func SourceHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// ParseProvenance reads the provenance recorded in the header of generated
+// code. It reports false if the code does not start with a gala header.
+func ParseProvenance(code string) (Provenance, bool) {
+	lines := strings.SplitN(code, "\n", 4)
+	first, ok := strings.CutPrefix(lines[0], "// Code generated by gala ")
+	if !ok {
+		return Provenance{}, false
+	}
+	first, ok = strings.CutSuffix(first, "; DO NOT EDIT.")
+	if !ok {
+		return Provenance{}, false
+	}
+	var p Provenance
+	p.Version, p.Source, ok = strings.Cut(first, " from ")
+	if !ok {
+		return Provenance{}, false
+	}
+	if i := strings.LastIndex(p.Source, " for go"); i >= 0 {
+		p.Source = p.Source[:i]
+	}
+	for _, line := range lines[1:] {
+		if hash, ok := strings.CutPrefix(line, "// Source hash: "); ok {
+			p.Hash = hash
+		} else if command, ok := strings.CutPrefix(line, "// Regenerate with: "); ok {
+			p.Command = command
+		}
+	}
+	return p, true
+}
+
+// WithRegenerateCommand names the command that regenerates the output in
+// generated file headers, e.g. "gala build".
+func WithRegenerateCommand(command string) Option {
+	return func(t *GalaToGoTranspiler) {
+		t.regenerate = command
+	}
+}
+
+// provenance describes the file at filePath in package importPath. The source
+// is named by import path when known, so headers do not depend on where the
+// module is checked out.
+func (t *GalaToGoTranspiler) provenance(input, filePath, importPath string) Provenance {
+	source := filepath.ToSlash(filePath)
+	switch {
+	case importPath != "":
+		source = path.Join(importPath, filepath.Base(filePath))
+	case filepath.IsAbs(filePath):
+		source = filepath.Base(filePath)
+	}
+	return Provenance{
+		Version: Version,
+		Source:  source,
+		Hash:    SourceHash(input),
+		Command: t.regenerate,
+	}
+}
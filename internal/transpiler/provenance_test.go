@@ -0,0 +1,59 @@
+package transpiler_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"martianoff/gala/internal/transpiler"
+)
+
+func TestParseProvenance(t *testing.T) {
+	tests := []struct {
+		name   string
+		code   string
+		want   transpiler.Provenance
+		wantOK bool
+	}{
+		{
+			name: "full header",
+			code: "// Code generated by gala v0.9.0 from myapp/models/user.gala; DO NOT EDIT.\n" +
+				"// Source hash: sha256:abc\n" +
+				"// Regenerate with: gala build\n\npackage models\n",
+			want: transpiler.Provenance{
+				Version: "v0.9.0",
+				Source:  "myapp/models/user.gala",
+				Hash:    "sha256:abc",
+				Command: "gala build",
+			},
+			wantOK: true,
+		},
+		{
+			name: "target and no command",
+			code: "// Code generated by gala dev from main.gala for go1.21; DO NOT EDIT.\n" +
+				"// Source hash: sha256:abc\n\npackage main\n",
+			want:   transpiler.Provenance{Version: "dev", Source: "main.gala", Hash: "sha256:abc"},
+			wantOK: true,
+		},
+		{
+			name: "header without provenance",
+			code: "// Code generated by GALA transpiler. DO NOT EDIT.\n\npackage main\n",
+		},
+		{
+			name: "hand-written file",
+			code: "package main\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := transpiler.ParseProvenance(tt.code)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSourceHash(t *testing.T) {
+	assert.Equal(t, "sha256:2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", transpiler.SourceHash("hello"))
+}
@@ -10,27 +10,62 @@ func StdPackageInfo() PackageInfo {
 			// Core types
 			"Option",
 			"Immutable",
+			// Immutable struct fields of large types
+			"ImmutableRef",
 			"Either",
 			"Try",
+			"Validated",
 			// Tuple types (Tuple is the 2-tuple, Tuple3+ are higher arities)
 			"Tuple", "Tuple3", "Tuple4", "Tuple5", "Tuple6", "Tuple7", "Tuple8", "Tuple9", "Tuple10",
 			// Collection traits
 			"Traversable",
 			"Iterable",
+			"Iterator",
+			// Typeclasses
+			"Semigroup",
+			"Monoid",
+			// Numeric constraints
+			"Integer", "Float", "Number",
+			// Bulk allocation
+			"Arena",
+			// Failed exhaustive matches
+			"MatchError",
+			// Failed require clauses of smart constructors
+			"InvariantError",
+			// Codecs of @json sealed types
+			"SealedField", "SealedJSON",
+			// Cases of sealed types, listed by their Variants functions
+			"SealedVariant",
+			// Structured concurrency
+			"Unit", "TaskScope",
+			// Rate limiting
+			"Semaphore", "RateLimiter",
 			// Companion objects also act as types
-			"Some", "None", "Left", "Right", "Success", "Failure",
+			"Some", "None", "Left", "Right", "Success", "Failure", "Valid", "Invalid",
 		},
 		Functions: []string{
 			"NewImmutable",
+			"NewImmutableRef",
 			"Copy",
 			"Equal",
+			"NewArena", "NewArenaOfSize",
+			"MatchFailure",
+			"MarshalSealedJSON", "UnmarshalSealedJSON", "SealedToYAML", "SealedFromYAML",
+			"Scope",
+			"NewSemaphore", "NewRateLimiter", "WithPermit", "WithRateLimit",
+			// Panic traces with GALA locations
+			"RecoverPretty", "SourceLocation", "RegisterSourceMap",
 			// Companion constructors
-			"Some", "None", "Left", "Right", "Success", "Failure",
+			"Some", "None", "Left", "Right", "Success", "Failure", "Valid", "Invalid",
 			// Try conversion functions
 			"FromOption", "FromEitherError",
+			// Option fields of @goStruct structs
+			"OptionToPointer", "OptionFromPointer",
+			// Validated constructors
+			"InvalidOf",
 		},
 		Companions: []string{
-			"Some", "None", "Left", "Right", "Success", "Failure",
+			"Some", "None", "Left", "Right", "Success", "Failure", "Valid", "Invalid",
 		},
 		IsPrelude: true,
 	}
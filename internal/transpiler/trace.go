@@ -0,0 +1,308 @@
+package transpiler
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"martianoff/gala/internal/parser/grammar"
+)
+
+// This file contains the trace mode of the transpiler, which dumps the state
+// between pipeline phases for debugging inference and code generation issues.
+// Functions: WithTrace, ParseTracePhases, trace, matches, traceParseTree,
+//            traceAnalysis, traceGoAST, declNames, identifierTexts, baseTypeName,
+//            recvTypeName, signatureString
+
+// Trace phases, in pipeline order.
+const (
+	// TraceParse dumps the ANTLR parse tree.
+	TraceParse = "parse"
+	// TraceAnalysis dumps the type, function and companion metadata collected
+	// by the analyzer for the file's package.
+	TraceAnalysis = "analysis"
+	// TraceGoAST dumps the generated go/ast just before it is printed.
+	TraceGoAST = "goast"
+)
+
+// TracePhases lists all trace phases in pipeline order.
+var TracePhases = []string{TraceParse, TraceAnalysis, TraceGoAST}
+
+// tracer writes the dumps requested by WithTrace.
+type tracer struct {
+	w      io.Writer
+	phases map[string]bool
+	filter string
+}
+
+// WithTrace makes Transpile write the state after each of phases to w. With a
+// non-empty filter, only declarations named filter and methods of the type
+// named filter are dumped.
+func WithTrace(w io.Writer, phases []string, filter string) Option {
+	return func(t *GalaToGoTranspiler) {
+		t.tracer = &tracer{w: w, phases: make(map[string]bool), filter: filter}
+		for _, phase := range phases {
+			t.tracer.phases[phase] = true
+		}
+	}
+}
+
+// ParseTracePhases parses a comma-separated list of trace phases; "all"
+// selects every phase.
+func ParseTracePhases(s string) ([]string, error) {
+	if s == "all" {
+		return TracePhases, nil
+	}
+	var phases []string
+	for _, phase := range strings.Split(s, ",") {
+		phase = strings.TrimSpace(phase)
+		found := false
+		for _, known := range TracePhases {
+			found = found || phase == known
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown trace phase %q (want %s or all)", phase, strings.Join(TracePhases, ", "))
+		}
+		phases = append(phases, phase)
+	}
+	return phases, nil
+}
+
+// trace reports whether phase is traced and, if so, writes its header.
+func (tr *tracer) trace(phase string) bool {
+	if tr == nil || !tr.phases[phase] {
+		return false
+	}
+	fmt.Fprintf(tr.w, "==== %s ====\n", phase)
+	return true
+}
+
+// matches reports whether a declaration with the given names passes the filter.
+func (tr *tracer) matches(names ...string) bool {
+	if tr.filter == "" {
+		return true
+	}
+	for _, name := range names {
+		if name == tr.filter {
+			return true
+		}
+	}
+	return false
+}
+
+// traceParseTree dumps the parse tree, one node per line indented by depth.
+func (tr *tracer) traceParseTree(tree antlr.Tree) {
+	if !tr.trace(TraceParse) {
+		return
+	}
+	var dump func(node antlr.Tree, depth int)
+	dump = func(node antlr.Tree, depth int) {
+		indent := strings.Repeat("  ", depth)
+		if term, ok := node.(antlr.TerminalNode); ok {
+			fmt.Fprintf(tr.w, "%s%s\n", indent, strconv.Quote(term.GetText()))
+			return
+		}
+		if decl, ok := node.(grammar.ITopLevelDeclarationContext); ok && !tr.matches(declNames(decl)...) {
+			return
+		}
+		name := strings.TrimSuffix(reflect.TypeOf(node).Elem().Name(), "Context")
+		if rule, ok := node.(antlr.ParserRuleContext); ok && rule.GetStart() != nil {
+			fmt.Fprintf(tr.w, "%s%s @%d:%d\n", indent, name, rule.GetStart().GetLine(), rule.GetStart().GetColumn()+1)
+		} else {
+			fmt.Fprintf(tr.w, "%s%s\n", indent, name)
+		}
+		for _, child := range node.GetChildren() {
+			dump(child, depth+1)
+		}
+	}
+	dump(tree, 0)
+}
+
+// traceAnalysis dumps the metadata of the declarations of r's own package.
+// Metadata of imported packages is left out.
+func (tr *tracer) traceAnalysis(r *RichAST) {
+	if !tr.trace(TraceAnalysis) {
+		return
+	}
+	own := func(pkg string) bool { return pkg == "" || pkg == r.PackageName }
+
+	typeNames := make([]string, 0, len(r.Types))
+	for name, meta := range r.Types {
+		if own(meta.Package) && tr.matches(meta.Name) {
+			typeNames = append(typeNames, name)
+		}
+	}
+	sort.Strings(typeNames)
+	for _, name := range typeNames {
+		meta := r.Types[name]
+		fmt.Fprintf(tr.w, "type %s", meta.Name)
+		if len(meta.TypeParams) > 0 {
+			fmt.Fprintf(tr.w, "[%s]", strings.Join(meta.TypeParams, ", "))
+		}
+		if meta.IsSealed {
+			fmt.Fprint(tr.w, " sealed")
+		}
+		fmt.Fprintln(tr.w)
+		for i, field := range meta.FieldNames {
+			kind := "var"
+			if i < len(meta.ImmutFlags) && meta.ImmutFlags[i] {
+				kind = "val"
+			}
+			fmt.Fprintf(tr.w, "  %s %s %s\n", kind, field, typeString(meta.Fields[field]))
+		}
+		for _, variant := range meta.SealedVariants {
+			fmt.Fprintf(tr.w, "  case %s(%s)\n", variant.Name, strings.Join(variant.FieldNames, ", "))
+		}
+		methodNames := make([]string, 0, len(meta.Methods))
+		for name := range meta.Methods {
+			methodNames = append(methodNames, name)
+		}
+		sort.Strings(methodNames)
+		for _, name := range methodNames {
+			m := meta.Methods[name]
+			fmt.Fprintf(tr.w, "  method %s\n", signatureString(m.Name, m.TypeParams, m.ParamTypes, m.ReturnType))
+		}
+	}
+
+	funcNames := make([]string, 0, len(r.Functions))
+	for name, meta := range r.Functions {
+		if own(meta.Package) && tr.matches(meta.Name) {
+			funcNames = append(funcNames, name)
+		}
+	}
+	sort.Strings(funcNames)
+	for _, name := range funcNames {
+		f := r.Functions[name]
+		fmt.Fprintf(tr.w, "func %s\n", signatureString(f.Name, f.TypeParams, f.ParamTypes, f.ReturnType))
+	}
+
+	companionNames := make([]string, 0, len(r.CompanionObjects))
+	for name, meta := range r.CompanionObjects {
+		if own(meta.Package) && tr.matches(meta.Name, meta.TargetType) {
+			companionNames = append(companionNames, name)
+		}
+	}
+	sort.Strings(companionNames)
+	for _, name := range companionNames {
+		c := r.CompanionObjects[name]
+		fmt.Fprintf(tr.w, "companion %s extracts %s%v\n", c.Name, c.TargetType, c.ExtractIndices)
+	}
+}
+
+// traceGoAST dumps the generated declarations with ast.Fprint.
+func (tr *tracer) traceGoAST(fset *token.FileSet, file *ast.File) {
+	if !tr.trace(TraceGoAST) {
+		return
+	}
+	for _, decl := range file.Decls {
+		var names []string
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			names = append(names, d.Name.Name)
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				names = append(names, recvTypeName(d.Recv.List[0].Type))
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					names = append(names, s.Name.Name)
+				case *ast.ValueSpec:
+					for _, n := range s.Names {
+						names = append(names, n.Name)
+					}
+				}
+			}
+		}
+		if tr.matches(names...) {
+			ast.Fprint(tr.w, fset, decl, ast.NotNilFilter)
+		}
+	}
+}
+
+// declNames returns the names a top-level declaration declares. A method also
+// reports its receiver's type name, so filtering by a type includes its methods.
+func declNames(ctx grammar.ITopLevelDeclarationContext) []string {
+	switch {
+	case ctx.FunctionDeclaration() != nil:
+		fn := ctx.FunctionDeclaration()
+		names := []string{fn.Identifier().GetText()}
+		if recv := fn.Receiver(); recv != nil && recv.Type_() != nil {
+			names = append(names, baseTypeName(recv.Type_().GetText()))
+		}
+		return names
+	case ctx.TypeDeclaration() != nil:
+		return []string{ctx.TypeDeclaration().Identifier().GetText()}
+	case ctx.StructShorthandDeclaration() != nil:
+		return []string{ctx.StructShorthandDeclaration().Identifier().GetText()}
+	case ctx.SealedTypeDeclaration() != nil:
+		return []string{ctx.SealedTypeDeclaration().Identifier().GetText()}
+	case ctx.NewtypeDeclaration() != nil:
+		name := ctx.NewtypeDeclaration().Identifier().GetText()
+		return []string{name, name + NewtypeFromSuffix}
+	case ctx.ValDeclaration() != nil && ctx.ValDeclaration().IdentifierList() != nil:
+		return identifierTexts(ctx.ValDeclaration().IdentifierList())
+	case ctx.ValDeclaration() != nil && ctx.ValDeclaration().TuplePattern() != nil:
+		return identifierTexts(ctx.ValDeclaration().TuplePattern().IdentifierList())
+	case ctx.VarDeclaration() != nil && ctx.VarDeclaration().IdentifierList() != nil:
+		return identifierTexts(ctx.VarDeclaration().IdentifierList())
+	}
+	return nil
+}
+
+func identifierTexts(list grammar.IIdentifierListContext) []string {
+	var names []string
+	for _, id := range list.AllIdentifier() {
+		names = append(names, id.GetText())
+	}
+	return names
+}
+
+// baseTypeName strips pointers and type arguments from a type, e.g. *Box[T] to Box.
+func baseTypeName(typ string) string {
+	typ = strings.TrimLeft(typ, "*")
+	if i := strings.Index(typ, "["); i >= 0 {
+		typ = typ[:i]
+	}
+	return typ
+}
+
+// recvTypeName returns the type name of a receiver such as *Box[T].
+func recvTypeName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.StarExpr:
+		return recvTypeName(e.X)
+	case *ast.IndexExpr:
+		return recvTypeName(e.X)
+	case *ast.IndexListExpr:
+		return recvTypeName(e.X)
+	}
+	return ""
+}
+
+// signatureString renders a function signature such as Map[U](func(T) U) List[U].
+func signatureString(name string, typeParams []string, params []Type, result Type) string {
+	var sb strings.Builder
+	sb.WriteString(name)
+	if len(typeParams) > 0 {
+		fmt.Fprintf(&sb, "[%s]", strings.Join(typeParams, ", "))
+	}
+	parts := make([]string, len(params))
+	for i, p := range params {
+		parts[i] = typeString(p)
+	}
+	fmt.Fprintf(&sb, "(%s)", strings.Join(parts, ", "))
+	if res := typeString(result); res != "" {
+		sb.WriteString(" " + res)
+	}
+	return sb.String()
+}
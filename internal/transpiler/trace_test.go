@@ -0,0 +1,88 @@
+package transpiler_test
+
+import (
+	"go/ast"
+	"go/token"
+	"martianoff/gala/internal/transpiler"
+	"strings"
+	"testing"
+
+	"github.com/antlr4-go/antlr/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+// Stub pipeline stages, so tracing can be tested without the parser.
+type stubParser struct{}
+
+func (stubParser) Parse(string) (antlr.Tree, error) { return nil, nil }
+
+type stubAnalyzer struct{ r *transpiler.RichAST }
+
+func (a stubAnalyzer) Analyze(antlr.Tree, string) (*transpiler.RichAST, error) { return a.r, nil }
+
+type stubTransformer struct{}
+
+func (stubTransformer) Transform(*transpiler.RichAST) (*token.FileSet, *ast.File, error) {
+	file := &ast.File{Name: ast.NewIdent("main"), Decls: []ast.Decl{
+		&ast.FuncDecl{Name: ast.NewIdent("Area"), Type: &ast.FuncType{}},
+		&ast.FuncDecl{Name: ast.NewIdent("helper"), Type: &ast.FuncType{}},
+	}}
+	return token.NewFileSet(), file, nil
+}
+
+type stubGenerator struct{}
+
+func (stubGenerator) Generate(*token.FileSet, *ast.File) (string, error) { return "", nil }
+
+func TestTrace(t *testing.T) {
+	intType := transpiler.BasicType{Name: "int"}
+	r := &transpiler.RichAST{
+		PackageName: "main",
+		Types: map[string]*transpiler.TypeMetadata{
+			"main.Box": {
+				Name: "Box", Package: "main", TypeParams: []string{"T"},
+				Fields: map[string]transpiler.Type{"Size": intType}, FieldNames: []string{"Size"}, ImmutFlags: []bool{true},
+				Methods: map[string]*transpiler.MethodMetadata{"Grow": {Name: "Grow", ParamTypes: []transpiler.Type{intType}, ReturnType: intType}},
+			},
+			"std.Option": {Name: "Option", Package: "std"},
+		},
+		Functions: map[string]*transpiler.FunctionMetadata{
+			"main.Area": {Name: "Area", Package: "main", ParamTypes: []transpiler.Type{intType, intType}, ReturnType: intType},
+		},
+	}
+	run := func(phases []string, filter string) string {
+		var out strings.Builder
+		trans := transpiler.NewGalaToGoTranspiler(stubParser{}, stubAnalyzer{r}, stubTransformer{}, stubGenerator{},
+			transpiler.WithTrace(&out, phases, filter))
+		_, err := trans.Transpile("", "")
+		assert.NoError(t, err)
+		return out.String()
+	}
+
+	got := run([]string{transpiler.TraceAnalysis}, "")
+	assert.Contains(t, got, "==== analysis ====")
+	assert.Contains(t, got, "type Box[T]\n  val Size int\n  method Grow(int) int\n")
+	assert.Contains(t, got, "func Area(int, int) int\n")
+	assert.NotContains(t, got, "Option")
+	assert.NotContains(t, got, "==== goast ====")
+
+	got = run([]string{transpiler.TraceAnalysis, transpiler.TraceGoAST}, "Area")
+	assert.NotContains(t, got, "type Box")
+	assert.Contains(t, got, "func Area")
+	assert.Contains(t, got, "==== goast ====")
+	assert.Contains(t, got, `Name: "Area"`)
+	assert.NotContains(t, got, `Name: "helper"`)
+}
+
+func TestParseTracePhases(t *testing.T) {
+	phases, err := transpiler.ParseTracePhases("all")
+	assert.NoError(t, err)
+	assert.Equal(t, transpiler.TracePhases, phases)
+
+	phases, err = transpiler.ParseTracePhases("parse, goast")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{transpiler.TraceParse, transpiler.TraceGoAST}, phases)
+
+	_, err = transpiler.ParseTracePhases("typing")
+	assert.ErrorContains(t, err, `unknown trace phase "typing"`)
+}
@@ -0,0 +1,151 @@
+package transformer
+
+import (
+	"go/ast"
+	"strconv"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+	"martianoff/gala/galaerr"
+	"martianoff/gala/internal/parser/grammar"
+	"martianoff/gala/internal/transpiler"
+	"martianoff/gala/internal/transpiler/registry"
+)
+
+// This file applies declaration annotations (@deprecated, @noCopy, @tailrec, @inline)
+// and struct field annotations (@equalIgnore).
+// The analyzer validates annotations and records them in the metadata; the transformer
+// reads them back from the metadata, or from the parse tree for the declaration it is
+// currently generating.
+
+// declAnnotation returns the argument of the annotation called name on topDecl.
+func declAnnotation(topDecl grammar.ITopLevelDeclarationContext, name string) (string, bool) {
+	return findAnnotation(topDecl.AllAnnotation(), name)
+}
+
+// findAnnotation returns the argument of the annotation called name among annotations.
+func findAnnotation(annotations []grammar.IAnnotationContext, name string) (string, bool) {
+	for _, an := range annotations {
+		ctx := an.(*grammar.AnnotationContext)
+		if ctx.Identifier().GetText() != name {
+			continue
+		}
+		if s := ctx.STRING(); s != nil {
+			arg, _ := strconv.Unquote(s.GetText())
+			return arg, true
+		}
+		return "", true
+	}
+	return "", false
+}
+
+// fieldHasAnnotation reports whether field of the struct type called typeName
+// carries the given annotation.
+func (t *galaASTTransformer) fieldHasAnnotation(typeName, field, annotation string) bool {
+	meta := t.getTypeMeta(typeName)
+	if meta == nil {
+		return false
+	}
+	_, ok := transpiler.FindAnnotation(meta.FieldAnnotations[field], annotation)
+	return ok
+}
+
+// typeHasAnnotation reports whether the type called name carries the given annotation.
+func (t *galaASTTransformer) typeHasAnnotation(name, annotation string) bool {
+	_, ok := t.typeAnnotation(name, annotation)
+	return ok
+}
+
+// typeAnnotation returns the given annotation of the type called name.
+func (t *galaASTTransformer) typeAnnotation(name, annotation string) (transpiler.Annotation, bool) {
+	meta := t.getTypeMeta(name)
+	if meta == nil {
+		return transpiler.Annotation{}, false
+	}
+	return transpiler.FindAnnotation(meta.Annotations, annotation)
+}
+
+// deprecationDoc appends a Go "Deprecated:" paragraph to the doc lines of a
+// @deprecated declaration so that Go tooling flags it as well.
+func deprecationDoc(topDecl grammar.ITopLevelDeclarationContext, lines []string) []string {
+	msg, ok := declAnnotation(topDecl, transpiler.AnnotationDeprecated)
+	if !ok {
+		return lines
+	}
+	if len(lines) > 0 {
+		lines = append(lines, "//")
+	}
+	if msg == "" {
+		return append(lines, "// Deprecated: do not use.")
+	}
+	return append(lines, "// Deprecated: "+msg)
+}
+
+// Warnings returns the warnings reported by the last Transform call.
+func (t *galaASTTransformer) Warnings() []galaerr.Warning {
+	return t.warnings
+}
+
+// warnAt records a warning positioned at ctx.
+func (t *galaASTTransformer) warnAt(ctx antlr.ParserRuleContext, msg string) {
+	w := galaerr.Warning{FilePath: t.filePath, Msg: msg}
+	if ctx != nil && ctx.GetStart() != nil {
+		w.Line = ctx.GetStart().GetLine()
+		w.Column = ctx.GetStart().GetColumn()
+	}
+	t.warnings = append(t.warnings, w)
+}
+
+// warnDeprecatedCall records a warning when fun, the callee of a call, refers to a
+// function, method or type annotated with @deprecated.
+func (t *galaASTTransformer) warnDeprecatedCall(fun ast.Expr, ctx antlr.ParserRuleContext) {
+	switch f := fun.(type) {
+	case *ast.IndexExpr:
+		fun = f.X
+	case *ast.IndexListExpr:
+		fun = f.X
+	}
+
+	var what string
+	var annotations []transpiler.Annotation
+	lookup := func(name string) {
+		if fm := t.getFunction(name); fm != nil {
+			what, annotations = "function "+t.displayName(fm.Package, fm.Name), fm.Annotations
+		} else if tm := t.getTypeMeta(name); tm != nil {
+			what, annotations = "type "+t.displayName(tm.Package, tm.Name), tm.Annotations
+		}
+	}
+	switch f := fun.(type) {
+	case *ast.Ident:
+		if !t.isVal(f.Name) && !t.isVar(f.Name) {
+			lookup(f.Name)
+		}
+	case *ast.SelectorExpr:
+		if id, ok := f.X.(*ast.Ident); ok && (t.importManager.IsPackage(id.Name) || id.Name == registry.StdPackageName) {
+			lookup(t.getBaseTypeName(f))
+			break
+		}
+		recvType := strings.TrimPrefix(t.getExprTypeName(f.X).BaseName(), "*")
+		if tm := t.getTypeMeta(recvType); tm != nil {
+			if mm, ok := tm.Methods[f.Sel.Name]; ok {
+				what, annotations = "method "+t.displayName(tm.Package, tm.Name)+"."+f.Sel.Name, mm.Annotations
+			}
+		}
+	}
+
+	if a, ok := transpiler.FindAnnotation(annotations, transpiler.AnnotationDeprecated); ok {
+		msg := what + " is deprecated"
+		if a.Arg != "" {
+			msg += ": " + a.Arg
+		}
+		t.warnAt(ctx, msg)
+	}
+}
+
+// displayName qualifies name with its package when it is declared outside the current one.
+func (t *galaASTTransformer) displayName(pkg, name string) string {
+	if pkg == "" || pkg == t.packageName {
+		return name
+	}
+	return pkg + "." + name
+}
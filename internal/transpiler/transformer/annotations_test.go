@@ -0,0 +1,455 @@
+package transformer_test
+
+import (
+	"martianoff/gala/galaerr"
+	"martianoff/gala/internal/transpiler"
+	"martianoff/gala/internal/transpiler/analyzer"
+	"martianoff/gala/internal/transpiler/generator"
+	"martianoff/gala/internal/transpiler/transformer"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnnotations(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		contains    []string
+		notContains []string
+		warnings    []string
+		wantErr     string
+	}{
+		{
+			name: "noCopy skips the Copy method",
+			input: `package main
+
+@noCopy
+type Lock struct {
+    Owner string
+}`,
+			contains:    []string{"func (s Lock) Equal("},
+			notContains: []string{"func (s Lock) Copy()"},
+		},
+		{
+			name: "noCopy rejects Copy calls",
+			input: `package main
+
+@noCopy
+type Lock struct {
+    Owner string
+}
+
+func main() {
+    val l = Lock(Owner = "a")
+    val m = l.Copy()
+}`,
+			wantErr: "type Lock is annotated with @noCopy and cannot be copied",
+		},
+		{
+			name: "deprecated function warns at call sites",
+			input: `package main
+
+@deprecated("use NewGreeting")
+func greeting() string = "hi"
+
+func main() {
+    println(greeting())
+}`,
+			contains: []string{"// Deprecated: use NewGreeting\nfunc greeting() string"},
+			warnings: []string{"[Warning] line 7:12 function greeting is deprecated: use NewGreeting"},
+		},
+		{
+			name: "deprecated method warns at call sites",
+			input: `package main
+
+type Box struct {
+    V int
+}
+
+@deprecated("read V directly")
+func (b Box) Value() int = b.V
+
+func main() {
+    val b = Box(V = 1)
+    println(b.Value())
+}`,
+			warnings: []string{"[Warning] line 12:12 method Box.Value is deprecated: read V directly"},
+		},
+		{
+			name: "inline substitutes the body",
+			input: `package main
+
+@inline
+func square(x int) int = x * x
+
+func main() {
+    var n = 3
+    println(square(n))
+}`,
+			contains: []string{"func square(x int) int", "println(int(n * n))"},
+		},
+		{
+			name: "tailrec compiles into a loop",
+			input: `package main
+
+@tailrec
+func sum(n int, acc int) int = if (n == 0) acc else sum(n - 1, acc + n)`,
+			contains:    []string{"_tailrec:", "for {", "n, acc = n-1, acc+n", "continue _tailrec"},
+			notContains: []string{"return sum("},
+		},
+		{
+			name: "tailrec copies parameters captured by lambdas",
+			input: `package main
+
+@tailrec
+func last(n int, f func() int) int = if (n == 0) f() else last(n - 1, () => n)`,
+			contains: []string{"func last(_tailrec_n int, f func() int) int {", "for {\n\t\tn := _tailrec_n\n", "_tailrec_n, f = n-1, func() int {"},
+		},
+		{
+			name: "tailrec rejects non-tail recursion",
+			input: `package main
+
+@tailrec
+func sum(n int) int = if (n == 0) 0 else n + sum(n - 1)`,
+			wantErr: "recursive call to sum is not in tail position",
+		},
+		{
+			name: "unknown annotation",
+			input: `package main
+
+@fast
+func one() int = 1`,
+			wantErr: "unknown annotation @fast",
+		},
+		{
+			name: "misplaced annotation",
+			input: `package main
+
+@inline
+type Point struct {
+    X int
+}`,
+			wantErr: "annotation @inline cannot be applied to a type",
+		},
+		{
+			name: "deepCopy and shallowCopy override the copy depth of a field",
+			input: `package main
+
+type Buffer struct {
+    @deepCopy
+    Data []byte
+    @shallowCopy
+    var Shared []int
+    var Scratch []int
+    Name string
+}`,
+			contains: []string{
+				"Data: std.Copy(s.Data)",
+				"Shared: s.Shared",
+				"Scratch: std.Copy(s.Scratch)",
+				"Name: s.Name",
+			},
+		},
+		{
+			name: "deepCopy and shallowCopy exclude each other",
+			input: `package main
+
+type Buffer struct {
+    @deepCopy
+    @shallowCopy
+    var Data []byte
+}`,
+			wantErr: "annotations @deepCopy and @shallowCopy exclude each other",
+		},
+		{
+			name: "field annotation on a function",
+			input: `package main
+
+@equalIgnore
+func one() int = 1`,
+			wantErr: "annotation @equalIgnore cannot be applied to a function",
+		},
+		{
+			name: "declaration annotation on a field",
+			input: `package main
+
+type Point struct {
+    @inline
+    X int
+}`,
+			wantErr: "annotation @inline cannot be applied to a field",
+		},
+		{
+			name: "arena builder for a sealed type",
+			input: `package main
+
+@arena
+sealed type Expr {
+    case Num(Value int)
+    case Add(Left Expr, Right Expr)
+}
+
+func build() Expr {
+    val a = NewExprArena()
+    return a.Add(a.Num(1), a.Num(2))
+}`,
+			contains: []string{
+				"type ExprArena struct {\n\tnodes *std.Arena[Expr]\n}",
+				"return ExprArena{nodes: std.NewArena[Expr]()}",
+				"func (a ExprArena) Add(Left Expr, Right Expr) Expr {\n\treturn Expr{Left: a.nodes.Alloc(Left), Right: a.nodes.Alloc(Right), _variant: _Expr_Add}",
+				"func (a ExprArena) Num(Value int) Expr {\n\treturn Expr{Value: std.NewImmutable(Value), _variant: _Expr_Num}",
+			},
+		},
+		{
+			name: "arena builder for a generic sealed type",
+			input: `package main
+
+@arena
+sealed type Tree[T any] {
+    case Leaf(Value T)
+    case Node(Left Tree[T], Right Tree[T])
+}`,
+			contains: []string{
+				"type TreeArena[T any] struct {\n\tnodes *std.Arena[Tree[T]]\n}",
+				"func NewTreeArena[T any]() TreeArena[T] {",
+				"func (a TreeArena[T]) Node(Left Tree[T], Right Tree[T]) Tree[T] {",
+			},
+		},
+		{
+			name: "arena on a struct",
+			input: `package main
+
+@arena
+type Point struct {
+    X int
+}`,
+			wantErr: "annotation @arena can only be applied to a sealed type",
+		},
+		{
+			name: "json codecs for a sealed type",
+			input: `package main
+
+@json
+sealed type Expr {
+    case Num(Value int)
+    case Add(Left Expr, Right Expr)
+    case Zero()
+}`,
+			contains: []string{
+				"func (s Expr) MarshalJSON() ([]byte, error) {\n\tswitch s._variant {\n\tcase _Expr_Num:\n\t\treturn std.MarshalSealedJSON(\"type\", \"Num\", std.SealedField{Name: \"Value\", Value: s.Value.Get()})",
+				"case _Expr_Add:\n\t\treturn std.MarshalSealedJSON(\"type\", \"Add\", std.SealedField{Name: \"Left\", Value: s.Left}, std.SealedField{Name: \"Right\", Value: s.Right})\n\t}\n\treturn std.MarshalSealedJSON(\"type\", \"Zero\")\n}",
+				"func (s *Expr) UnmarshalJSON(data []byte) error {\n\tobj, err := std.UnmarshalSealedJSON(data, \"type\", \"Expr\")",
+				"case \"Add\":\n\t\tvar f0 Expr\n\t\tif err := obj.Field(\"Left\", &f0); err != nil {\n\t\t\treturn err\n\t\t}",
+				"*s = Add{}.Apply(f0, f1)\n\t\treturn nil",
+				"*s = Zero{}.Apply()",
+				"return obj.UnknownVariant()",
+				"func (s Expr) MarshalYAML() (any, error) {\n\treturn std.SealedToYAML(s)\n}",
+				"func (s *Expr) UnmarshalYAML(unmarshal func(any) error) error {\n\treturn std.SealedFromYAML(unmarshal, s)\n}",
+			},
+		},
+		{
+			name: "json codecs with a custom discriminator",
+			input: `package main
+
+@json("kind")
+sealed type Box[T any] {
+    case Full(Item T)
+}`,
+			contains: []string{
+				"func (s Box[T]) MarshalJSON() ([]byte, error) {\n\treturn std.MarshalSealedJSON(\"kind\", \"Full\", std.SealedField{Name: \"Item\", Value: s.Item.Get()})\n}",
+				"obj, err := std.UnmarshalSealedJSON(data, \"kind\", \"Box\")",
+				"var f0 T",
+				"*s = Full[T]{}.Apply(f0)",
+			},
+		},
+		{
+			name: "json discriminator clashing with a field",
+			input: `package main
+
+@json("Text")
+sealed type Token {
+    case Word(Text string)
+}`,
+			wantErr: `discriminator "Text" of Token clashes with the field Text of Word`,
+		},
+		{
+			name: "json on a struct",
+			input: `package main
+
+@json
+type Point struct {
+    X int
+}`,
+			wantErr: "annotation @json can only be applied to a sealed type",
+		},
+		{
+			name: "traced function continues the span of its context",
+			input: `package main
+
+import "context"
+
+@traced
+func handle(ctx context.Context, id int) int = id + 1`,
+			contains: []string{
+				`"go.opentelemetry.io/otel"`,
+				"ctx, _span := otel.Tracer(\"main\").Start(ctx, \"main.handle\")\n\tdefer _span.End()\n\treturn id + 1",
+			},
+		},
+		{
+			name: "traced methods without a context start root spans",
+			input: `package main
+
+struct Cart(Items int)
+
+@traced("checkout")
+func (c Cart) Total() int = c.Items * 2
+
+@traced
+func (c *Cart) Count() int = c.Items`,
+			contains: []string{
+				`"context"`,
+				`_, _span := otel.Tracer("main").Start(context.Background(), "checkout")`,
+				`_, _span := otel.Tracer("main").Start(context.Background(), "main.Cart.Count")`,
+			},
+		},
+		{
+			name: "traced on a type",
+			input: `package main
+
+@traced
+type Point struct {
+    X int
+}`,
+			wantErr: "annotation @traced cannot be applied to a type",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := transpiler.NewAntlrGalaParser()
+			a := analyzer.NewGalaAnalyzer(p, getStdSearchPath())
+			trans := transpiler.NewGalaToGoTranspiler(p, a, transformer.NewGalaASTTransformer(), generator.NewGoCodeGenerator())
+
+			got, err := trans.Transpile(tt.input, "")
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			for _, s := range tt.contains {
+				assert.Contains(t, got, s)
+			}
+			for _, s := range tt.notContains {
+				assert.NotContains(t, got, s)
+			}
+			var warnings []string
+			for _, w := range trans.Warnings() {
+				warnings = append(warnings, w.String())
+			}
+			assert.Equal(t, tt.warnings, warnings)
+		})
+	}
+}
+
+func TestAnnotationErrorCodes(t *testing.T) {
+	p := transpiler.NewAntlrGalaParser()
+	a := analyzer.NewGalaAnalyzer(p, getStdSearchPath())
+	trans := transpiler.NewGalaToGoTranspiler(p, a, transformer.NewGalaASTTransformer(), generator.NewGoCodeGenerator())
+
+	_, err := trans.Transpile("package main\n\n@tailrec\nfunc f(n int) int = n", "")
+	assert.Equal(t, galaerr.CodeNotTailRecursive, galaerr.CodeOf(err))
+
+	_, err = trans.Transpile("package main\n\n@noCopy\nfunc f() int = 1", "")
+	assert.Equal(t, galaerr.CodeBadAnnotation, galaerr.CodeOf(err))
+}
+
+func TestCrossPackageDeprecation(t *testing.T) {
+	tempDir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module testmod\n\ngo 1.21\n",
+		// The method is declared in a file that sorts before its type
+		"legacy/a_ops.gala": `package legacy
+
+@deprecated("use Size")
+func (b Box) Len() int = b.V
+`,
+		"legacy/box.gala": `package legacy
+
+type Box struct {
+    V int
+}
+
+@deprecated("use Box")
+type OldBox struct {
+    V int
+}
+
+@deprecated("use NewGreet")
+func Greet() string = "hi"
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(tempDir, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	originalWd, err := os.Getwd()
+	assert.NoError(t, err)
+	defer os.Chdir(originalWd)
+	assert.NoError(t, os.Chdir(tempDir))
+
+	p := transpiler.NewAntlrGalaParser()
+	a := analyzer.NewGalaAnalyzer(p, nil)
+	trans := transpiler.NewGalaToGoTranspiler(p, a, transformer.NewGalaASTTransformer(), generator.NewGoCodeGenerator())
+
+	input := `package main
+
+import "testmod/legacy"
+
+func main() {
+    println(legacy.Greet())
+    val b = legacy.Box(V = 2)
+    println(b.Len())
+    val o = legacy.OldBox(V = 3)
+    println(o.V)
+}`
+	_, err = trans.Transpile(input, "main.gala")
+	assert.NoError(t, err)
+
+	var warnings []string
+	for _, w := range trans.Warnings() {
+		warnings = append(warnings, w.String())
+	}
+	assert.Equal(t, []string{
+		"[Warning] main.gala:6:12 function legacy.Greet is deprecated: use NewGreet",
+		"[Warning] main.gala:8:12 method legacy.Box.Len is deprecated: use Size",
+		"[Warning] main.gala:9:12 type legacy.OldBox is deprecated: use Box",
+	}, warnings, strings.Join(warnings, "\n"))
+}
+
+func TestProfileGuidedInlining(t *testing.T) {
+	input := `package main
+
+func square(x int) int = x * x
+
+func cube(x int) int = x * x * x
+
+func main() {
+    var n = 3
+    println(square(n))
+    println(cube(n))
+}`
+	p := transpiler.NewAntlrGalaParser()
+	trans := transpiler.NewGalaToGoTranspiler(p, analyzer.NewGalaAnalyzer(p, getStdSearchPath()),
+		transformer.NewGalaASTTransformerWithProfile(transpiler.GoVersion{}, map[string]bool{"main.square": true}),
+		generator.NewGoCodeGenerator())
+	got, err := trans.Transpile(input, "")
+	assert.NoError(t, err)
+	assert.Contains(t, got, "println(int(n * n))")
+	assert.Contains(t, got, "println(cube(n))")
+}
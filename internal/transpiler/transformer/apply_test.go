@@ -42,17 +42,19 @@ type Append struct {
 }
 
 func (s Append) Copy() Append {
-	return Append{Name: std.Copy(s.Name)}
+	return Append{Name: s.Name}
 }
 func (s Append) Equal(other Append) bool {
 	return std.Equal(s.Name, other.Name)
 }
 func (s Append) Unapply(v any) (std.Immutable[string], bool) {
-	if p, ok := v.(Append); ok {
-		return p.Name, true
-	}
-	if p, ok := v.(*Append); ok && p != nil {
+	switch p := v.(type) {
+	case Append:
 		return p.Name, true
+	case *Append:
+		if p != nil {
+			return p.Name, true
+		}
 	}
 	return *new(std.Immutable[string]), false
 }
@@ -82,17 +84,19 @@ type Append struct {
 }
 
 func (s Append) Copy() Append {
-	return Append{Name: std.Copy(s.Name)}
+	return Append{Name: s.Name}
 }
 func (s Append) Equal(other Append) bool {
 	return std.Equal(s.Name, other.Name)
 }
 func (s Append) Unapply(v any) (std.Immutable[string], bool) {
-	if p, ok := v.(Append); ok {
-		return p.Name, true
-	}
-	if p, ok := v.(*Append); ok && p != nil {
+	switch p := v.(type) {
+	case Append:
 		return p.Name, true
+	case *Append:
+		if p != nil {
+			return p.Name, true
+		}
 	}
 	return *new(std.Immutable[string]), false
 }
@@ -126,10 +130,8 @@ func (s Implode) Equal(other Implode) bool {
 	return true
 }
 func (s Implode) Unapply(v any) bool {
-	if _, ok := v.(Implode); ok {
-		return true
-	}
-	if _, ok := v.(*Implode); ok {
+	switch v.(type) {
+	case Implode, *Implode:
 		return true
 	}
 	return false
@@ -172,10 +174,8 @@ func (_ Identity[T]) IsIdentity() bool {
 	return true
 }
 func (s Identity[T]) Unapply(v any) bool {
-	if _, ok := v.(Identity[T]); ok {
-		return true
-	}
-	if _, ok := v.(*Identity[T]); ok {
+	switch v.(type) {
+	case Identity[T], *Identity[T]:
 		return true
 	}
 	return false
@@ -0,0 +1,84 @@
+package transformer_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"martianoff/gala/internal/transpiler"
+	"martianoff/gala/internal/transpiler/analyzer"
+	"martianoff/gala/internal/transpiler/generator"
+	"martianoff/gala/internal/transpiler/transformer"
+)
+
+func TestByNameParameters(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		contains []string
+	}{
+		{
+			name: "declaration and call",
+			input: `package main
+
+func expensive() string = "computed"
+
+func choose(flag bool, fallback => string) string {
+    if flag {
+        return "set"
+    }
+    return fallback
+}
+
+func run() string = choose(true, expensive())
+`,
+			contains: []string{
+				"func choose(flag bool, fallback func() string) string {",
+				"return fallback()",
+				"choose(true, func() string {",
+				"return expensive()",
+			},
+		},
+		{
+			name: "generic parameter",
+			input: `package main
+
+func orElse[T any](value T, ok bool, fallback => T) T {
+    if ok {
+        return value
+    }
+    return fallback
+}
+
+func run() int = orElse(1, false, 2)
+`,
+			contains: []string{
+				"fallback func() T",
+				"orElse(1, false, func() int {",
+			},
+		},
+		{
+			name: "std GetOrElse is lazy",
+			input: `package main
+
+func run(o Option[int]) int = o.GetOrElse(0)
+`,
+			contains: []string{
+				"o.GetOrElse(func() int {",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := transpiler.NewAntlrGalaParser()
+			trans := transpiler.NewGalaToGoTranspiler(p, analyzer.NewGalaAnalyzer(p, getStdSearchPath()),
+				transformer.NewGalaASTTransformer(), generator.NewGoCodeGenerator())
+			got, err := trans.Transpile(tt.input, "")
+			assert.NoError(t, err)
+			for _, want := range tt.contains {
+				assert.Contains(t, got, want)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package transformer
 import (
 	"fmt"
 	"go/ast"
+	"go/token"
 	"strings"
 
 	"martianoff/gala/galaerr"
@@ -22,6 +23,11 @@ func (t *galaASTTransformer) applyCallSuffix(base ast.Expr, suffix *grammar.Post
 	// This is because at parse time we don't know if T[A, B] is a type instantiation or array access.
 	base = t.qualifyTypeArgsInExpr(base)
 
+	// Report uses of @deprecated functions, methods and types
+	if call, ok := suffix.GetParent().(*grammar.PostfixExprContext); ok {
+		t.warnDeprecatedCall(base, call)
+	}
+
 	argList := suffix.ArgumentList()
 	if argList == nil {
 		// Empty argument list - check for zero-argument Apply method
@@ -131,16 +137,23 @@ func (t *galaASTTransformer) applyCallSuffix(base ast.Expr, suffix *grammar.Post
 						Args: []ast.Expr{receiver},
 					}, nil
 				}
+			} else if funExpr, _ := t.specializedMethodFunc(lookupBaseName, recvType, method); funExpr != nil {
+				return &ast.CallExpr{
+					Fun:  funExpr,
+					Args: []ast.Expr{receiver},
+				}, nil
 			}
 		}
 
-		return &ast.CallExpr{Fun: base, Args: nil}, nil
+		call := &ast.CallExpr{Fun: base, Args: nil}
+		t.recordStdGet(call)
+		return call, nil
 	}
 
 	return t.transformCallWithArgsCtx(base, argList.(*grammar.ArgumentListContext))
 }
 
-func (t *galaASTTransformer) transformCallWithArgsCtx(fun ast.Expr, argListCtx *grammar.ArgumentListContext) (ast.Expr, error) {
+func (t *galaASTTransformer) transformCallWithArgsCtx(fun ast.Expr, argListCtx *grammar.ArgumentListContext) (result ast.Expr, err error) {
 	// Handle Copy method call with overrides
 	if sel, ok := fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "Copy" {
 		return t.transformCopyCall(sel.X, argListCtx)
@@ -194,6 +207,15 @@ func (t *galaASTTransformer) transformCallWithArgsCtx(fun ast.Expr, argListCtx *
 	// Strip pointer prefix for genericMethods lookup since methods are registered under base type name
 	lookupBaseName := strings.TrimPrefix(recvBaseName, "*")
 
+	// Remember the Array combinator calls that -O may fuse into one loop
+	if t.fuse && receiver != nil && lookupBaseName == fusedArrayType && fusedStages[method] {
+		defer func() {
+			if call, ok := result.(*ast.CallExpr); ok && err == nil {
+				t.recordArrayStage(call, method)
+			}
+		}()
+	}
+
 	// Check for generic method - try all possible package lookups
 	isGenericMethod := len(typeArgs) > 0 || t.isGenericMethodWithImports(lookupBaseName, recvType.GetPackage(), method)
 
@@ -255,8 +277,8 @@ func (t *galaASTTransformer) transformCallWithArgsCtx(fun ast.Expr, argListCtx *
 					if !ok {
 						continue
 					}
-					// Skip lambda and partial function arguments — can't infer types from them
-					if t.findLambdaInExpression(ep.Expression()) != nil || t.findPartialFunctionInExpression(ep.Expression()) != nil {
+					// Skip lambda, partial function and `_` arguments — can't infer types from them
+					if t.findLambdaInExpression(ep.Expression()) != nil || t.findPartialFunctionInExpression(ep.Expression()) != nil || containsPlaceholder(ep.Expression()) {
 						continue
 					}
 					expr, err := t.transformExpression(ep.Expression())
@@ -279,10 +301,12 @@ func (t *galaASTTransformer) transformCallWithArgsCtx(fun ast.Expr, argListCtx *
 				}
 			}
 			// Default remaining unresolved method type params to "any"
+			defaulted := make(map[string]bool)
 			if methodMeta != nil {
 				for _, tp := range methodMeta.TypeParams {
 					if _, ok := typeSubst[tp]; !ok {
 						typeSubst[tp] = "any"
+						defaulted[tp] = true
 					}
 				}
 			}
@@ -296,18 +320,21 @@ func (t *galaASTTransformer) transformCallWithArgsCtx(fun ast.Expr, argListCtx *
 					return nil, galaerr.NewSemanticError("only expressions allowed as function arguments")
 				}
 
-				// Reuse pre-transformed expression if available (already processed during type inference)
-				if expr, ok := preTransformed[i]; ok {
-					mArgs = append(mArgs, expr)
-					continue
-				}
-
 				// Get expected parameter type if available, with type substitution
 				var expectedType transpiler.Type = transpiler.NilType{}
 				if methodMeta != nil && i < len(methodMeta.ParamTypes) {
 					expectedType = t.substituteTranspilerTypeParams(methodMeta.ParamTypes[i], typeSubst)
 				}
 
+				// Reuse pre-transformed expression if available (already processed during type inference)
+				if expr, ok := preTransformed[i]; ok {
+					if fn, isFunc := expectedType.(transpiler.FuncType); isFunc && fn.ByName && len(fn.Results) == 1 {
+						expr = t.byNameThunk(expr, fn.Results[0])
+					}
+					mArgs = append(mArgs, expr)
+					continue
+				}
+
 				expr, err := t.transformArgumentWithExpectedType(ep.Expression(), expectedType)
 				if err != nil {
 					return nil, err
@@ -358,10 +385,39 @@ func (t *galaASTTransformer) transformCallWithArgsCtx(fun ast.Expr, argListCtx *
 				}
 			}
 
-			return &ast.CallExpr{
+			call := &ast.CallExpr{
 				Fun:  funExpr,
 				Args: append([]ast.Expr{receiver}, mArgs...),
-			}, nil
+			}
+			if methodMeta != nil && len(defaulted) > 0 {
+				t.noteErasedTypeArg(call, method, methodMeta, typeSubst, defaulted, mArgs)
+			}
+			return call, nil
+		}
+	}
+
+	// Prefer a monomorphic specialization (e.g., Array_Contains_int) when the receiver's
+	// element type is statically known and the declaring package provides one
+	if receiver != nil && !isGenericMethod && method != "" {
+		if funExpr, funcMeta := t.specializedMethodFunc(lookupBaseName, recvType, method); funExpr != nil {
+			mArgs := []ast.Expr{receiver}
+			for i, argCtx := range argListCtx.AllArgument() {
+				arg := argCtx.(*grammar.ArgumentContext)
+				ep, ok := arg.Pattern().(*grammar.ExpressionPatternContext)
+				if !ok {
+					return nil, galaerr.NewSemanticError("only expressions allowed as function arguments")
+				}
+				var expectedType transpiler.Type = transpiler.NilType{}
+				if i+1 < len(funcMeta.ParamTypes) {
+					expectedType = funcMeta.ParamTypes[i+1]
+				}
+				expr, err := t.transformArgumentWithExpectedType(ep.Expression(), expectedType)
+				if err != nil {
+					return nil, err
+				}
+				mArgs = append(mArgs, expr)
+			}
+			return &ast.CallExpr{Fun: funExpr, Args: mArgs}, nil
 		}
 	}
 
@@ -508,11 +564,17 @@ func (t *galaASTTransformer) transformCallWithArgsCtx(fun ast.Expr, argListCtx *
 								}
 							}
 							expectedType = t.substituteTranspilerTypeParams(funcMeta.ParamTypes[argIdx], typeSubst)
+						} else if ft.ByName {
+							// By-name arguments are wrapped even when T is inferred
+							expectedType = ft
 						}
 					} else {
 						// Non-generic function with concrete function param types - pass as-is
 						expectedType = ft
 					}
+				} else if len(funcMeta.TypeParams) == 0 {
+					// Concrete value parameter - lets constants and widenings take its numeric width
+					expectedType = funcMeta.ParamTypes[argIdx]
 				}
 			}
 			expr, err := t.transformArgumentWithExpectedType(ep.Expression(), expectedType)
@@ -529,9 +591,24 @@ func (t *galaASTTransformer) transformCallWithArgsCtx(fun ast.Expr, argListCtx *
 		return t.handleNamedArgsCall(fun, args, namedArgs)
 	}
 
+	// fmt.Sprintf with a simple literal format becomes plain string concatenation
+	if t.isPackageFunc(fun, "fmt", "Sprintf") {
+		if lowered, ok := t.lowerSprintf(args); ok {
+			return lowered, nil
+		}
+	}
+
+	// The min and max builtins only exist since Go 1.21
+	if id, ok := fun.(*ast.Ident); ok && (id.Name == "min" || id.Name == "max") && !t.goVersion.SupportsMinMaxBuiltins() {
+		if t.getFunction(id.Name) == nil && !t.isVal(id.Name) && !t.isVar(id.Name) {
+			return t.lowerMinMax(id.Name, args)
+		}
+	}
+
 	// Check if the function being called is a type with an Apply method
 	// This handles companion object calls like Some[A](value) -> Some[A]{}.Apply(value)
 	typeName := t.getBaseTypeName(fun)
+	sourceTypeName := typeName
 	if typeName != "" {
 		// Use unified resolution to find type metadata
 		typeMeta, resolvedTypeMeta := t.getTypeMetaResolved(typeName)
@@ -539,21 +616,23 @@ func (t *galaASTTransformer) transformCallWithArgsCtx(fun ast.Expr, argListCtx *
 			// Update typeName to resolved name for subsequent lookups
 			typeName = resolvedTypeMeta
 			// First check if this looks like positional struct construction
-			// (args match struct field count) - prefer struct construction over Apply
+			// (args match struct field count) - prefer struct construction over Apply,
+			// unless the struct has invariants that only its Apply checks
 			resolvedTypeName := t.resolveStructTypeName(typeName)
-			if fields, structOk := t.structFields[resolvedTypeName]; structOk && len(args) > 0 && len(args) == len(fields) {
+			if fields, structOk := t.structFields[resolvedTypeName]; structOk && len(args) > 0 && len(args) == len(fields) && len(typeMeta.Invariants) == 0 {
 				// It's struct construction with positional arguments matching field count
 				var elts []ast.Expr
 				immutFlags := t.structImmutFields[resolvedTypeName]
 				for i, fieldName := range fields {
+					arg := t.convertToNumericType(args[i], t.structFieldTypes[resolvedTypeName][fieldName])
 					var valExpr ast.Expr
 					if immutFlags != nil && i < len(immutFlags) && immutFlags[i] {
 						valExpr = &ast.CallExpr{
 							Fun:  t.stdIdent("NewImmutable"),
-							Args: []ast.Expr{args[i]},
+							Args: []ast.Expr{arg},
 						}
 					} else {
-						valExpr = args[i]
+						valExpr = arg
 					}
 					elts = append(elts, &ast.KeyValueExpr{
 						Key:   ast.NewIdent(fieldName),
@@ -562,6 +641,12 @@ func (t *galaASTTransformer) transformCallWithArgsCtx(fun ast.Expr, argListCtx *
 				}
 				return &ast.CompositeLit{Type: fun, Elts: elts}, nil
 			}
+			// The Apply of a struct with invariants takes all of its fields
+			if fields, ok := t.structFields[resolvedTypeName]; ok && len(fields) > 0 && len(typeMeta.Invariants) > 0 {
+				if err := checkConstructorArgs(sourceTypeName, fields, args, nil); err != nil {
+					return nil, err
+				}
+			}
 
 			if methodMeta, hasApply := typeMeta.Methods["Apply"]; hasApply {
 				// Check if the base expression is a type (not a variable)
@@ -679,21 +764,25 @@ func (t *galaASTTransformer) transformCallWithArgsCtx(fun ast.Expr, argListCtx *
 				// No Apply method - check if this is struct construction with positional args
 				resolvedTypeName := t.resolveStructTypeName(typeName)
 				if fields, ok := t.structFields[resolvedTypeName]; ok && len(args) > 0 {
-					// It's struct construction with positional arguments
+					// It's struct construction with positional arguments. Types
+					// without fields may be other kinds of types, converted
+					if len(fields) > 0 {
+						if err := checkConstructorArgs(sourceTypeName, fields, args, nil); err != nil {
+							return nil, err
+						}
+					}
 					var elts []ast.Expr
 					immutFlags := t.structImmutFields[resolvedTypeName]
 					for i, fieldName := range fields {
-						if i >= len(args) {
-							break
-						}
+						arg := t.convertToNumericType(args[i], t.structFieldTypes[resolvedTypeName][fieldName])
 						var valExpr ast.Expr
 						if immutFlags != nil && i < len(immutFlags) && immutFlags[i] {
 							valExpr = &ast.CallExpr{
 								Fun:  t.stdIdent("NewImmutable"),
-								Args: []ast.Expr{args[i]},
+								Args: []ast.Expr{arg},
 							}
 						} else {
-							valExpr = args[i]
+							valExpr = arg
 						}
 						elts = append(elts, &ast.KeyValueExpr{
 							Key:   ast.NewIdent(fieldName),
@@ -807,9 +896,18 @@ func (t *galaASTTransformer) handleNamedArgsCall(fun ast.Expr, args []ast.Expr,
 		typeName = f.Sel.Name
 	}
 
+	// A variant of a @compact sealed type is built by a function taking its fields in order
+	if _, variant, ok := t.compactSealedVariant(typeName); ok {
+		return compactVariantCall(fun, variant, args, namedArgs)
+	}
+
 	// Check if this is a known struct type
 	resolvedTypeName := t.resolveStructTypeName(typeName)
 	if fields, ok := t.structFields[resolvedTypeName]; ok {
+		if meta := t.getTypeMeta(resolvedTypeName); meta != nil && len(meta.Invariants) > 0 {
+			return t.smartConstructorCall(fun, typeName, fields, args, namedArgs)
+		}
+
 		// Check if this is a sealed variant companion (empty struct with Apply method)
 		// Sealed variants are registered with nil fields because the companion struct is empty.
 		// The actual field info lives in the parent sealed type's SealedVariants metadata.
@@ -834,7 +932,16 @@ func (t *galaASTTransformer) handleNamedArgsCall(fun ast.Expr, args []ast.Expr,
 			}
 		}
 
-		// It's struct construction with named arguments
+		// It's struct construction with named arguments, which positional
+		// arguments may precede
+		if len(fields) > 0 {
+			if err := checkConstructorArgs(typeName, fields, args, namedArgs); err != nil {
+				return nil, err
+			}
+			for i, arg := range args {
+				namedArgs[fields[i]] = arg
+			}
+		}
 		var elts []ast.Expr
 		immutFlags := t.structImmutFields[resolvedTypeName]
 		fieldTypes := t.structFieldTypes[resolvedTypeName]
@@ -916,6 +1023,7 @@ func (t *galaASTTransformer) handleNamedArgsCall(fun ast.Expr, args []ast.Expr,
 					}
 				}
 
+				val = t.convertToNumericType(val, fieldTypes[fieldName])
 				var valExpr ast.Expr
 				if immutFlags != nil && i < len(immutFlags) && immutFlags[i] {
 					valExpr = &ast.CallExpr{
@@ -953,6 +1061,20 @@ func (t *galaASTTransformer) findSealedVariantFields(variantName string) []strin
 }
 
 func (t *galaASTTransformer) transformArgumentWithExpectedType(exprCtx grammar.IExpressionContext, expectedType transpiler.Type) (ast.Expr, error) {
+	// Arguments for by-name parameters are evaluated by the callee
+	if fn, ok := expectedType.(transpiler.FuncType); ok && fn.ByName && len(fn.Results) == 1 {
+		expr, err := t.transformArgumentWithExpectedType(exprCtx, fn.Results[0])
+		if err != nil {
+			return nil, err
+		}
+		return t.byNameThunk(expr, fn.Results[0]), nil
+	}
+
+	// `_ * 2` where a one-parameter function is expected is shorthand for a lambda
+	if fn, ok := expectedType.(transpiler.FuncType); ok && len(fn.Params) == 1 && containsPlaceholder(exprCtx) {
+		return t.transformPlaceholderLambda(exprCtx, fn)
+	}
+
 	// Try to find a partial function literal in this expression
 	if pfCtx := t.findPartialFunctionInExpression(exprCtx); pfCtx != nil {
 		return t.transformPartialFunctionLiteral(pfCtx, expectedType)
@@ -975,7 +1097,35 @@ func (t *galaASTTransformer) transformArgumentWithExpectedType(exprCtx grammar.I
 		return t.transformLambdaWithExpectedType(lambdaCtx, expectedRetType, expectedParamTypes)
 	}
 	// Not a lambda or partial function, transform normally
-	return t.transformExpression(exprCtx)
+	expr, err := t.transformExpression(exprCtx)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.checkNewtypeArgument(exprCtx, expr, expectedType); err != nil {
+		return nil, err
+	}
+	if err := t.checkErasedTypeArg(exprCtx, expr, expectedType); err != nil {
+		return nil, err
+	}
+	return t.convertToNumericType(expr, expectedType), nil
+}
+
+// byNameThunk wraps the argument expr for a by-name parameter of type
+// resultType in func() resultType { return expr }. When resultType still
+// names a type parameter, the type of expr is used instead.
+func (t *galaASTTransformer) byNameThunk(expr ast.Expr, resultType transpiler.Type) ast.Expr {
+	if resultType.IsNil() || t.hasTypeParams(resultType) {
+		if argType := t.getExprTypeName(expr); !argType.IsNil() {
+			resultType = argType
+		}
+	}
+	return &ast.FuncLit{
+		Type: &ast.FuncType{
+			Params:  &ast.FieldList{},
+			Results: &ast.FieldList{List: []*ast.Field{{Type: t.typeToExpr(resultType)}}},
+		},
+		Body: &ast.BlockStmt{List: []ast.Stmt{&ast.ReturnStmt{Results: []ast.Expr{expr}}}},
+	}
 }
 
 func (t *galaASTTransformer) inferTypeArgsFromApply(
@@ -1050,9 +1200,11 @@ func (t *galaASTTransformer) isGenericMethodWithImports(lookupBaseName, recvPkg,
 			return true
 		}
 	}
-	// Search through all imported packages (dot and non-dot)
-	for _, entry := range t.importManager.All() {
-		if t.isGenericMethodName(entry.PkgName+"."+lookupBaseName, methodName) {
+	// Search the imported packages declaring the type (dot and non-dot)
+	isType := func(name string) bool { return t.typeMetas[name] != nil || t.genericMethods[name] != nil }
+	dot, named := t.importedSymbols(lookupBaseName, isType)
+	for _, typeName := range append(dot, named...) {
+		if t.isGenericMethodName(typeName, methodName) {
 			return true
 		}
 	}
@@ -1066,11 +1218,9 @@ func (t *galaASTTransformer) isGenericMethodWithImports(lookupBaseName, recvPkg,
 			return true
 		}
 	}
-	for _, entry := range t.importManager.All() {
-		if entry.IsDot {
-			if t.isMethodGenericViaTypeMeta(entry.PkgName+"."+lookupBaseName, methodName) {
-				return true
-			}
+	for _, typeName := range dot {
+		if t.isMethodGenericViaTypeMeta(typeName, methodName) {
+			return true
 		}
 	}
 	return false
@@ -1086,6 +1236,120 @@ func (t *galaASTTransformer) isMethodGenericViaTypeMeta(typeName, methodName str
 	return false
 }
 
+// lowerMinMax rewrites a min/max builtin call into an immediately invoked function
+// literal for targets older than Go 1.21. The operands are evaluated once, left to right.
+// As with the builtin, constant operands take the type of the other operands, and a
+// NaN operand of a float type makes the result NaN.
+func (t *galaASTTransformer) lowerMinMax(name string, args []ast.Expr) (ast.Expr, error) {
+	if len(args) == 0 {
+		return nil, galaerr.NewSemanticError(fmt.Sprintf("not enough arguments in call to %s", name))
+	}
+	argType := t.minMaxOperandType(args)
+	if argType == nil || argType.IsNil() || argType.IsAny() {
+		return nil, galaerr.NewSemanticError(fmt.Sprintf("cannot infer operand type of %s for target %s; builtin %s requires Go 1.21", name, t.goVersion, name)).WithCode(galaerr.CodeGoVersion)
+	}
+	operand := func(arg ast.Expr) ast.Expr {
+		if _, isConst := untypedConstKind(arg); isConst && t.getExprTypeNameManual(arg).String() != argType.String() {
+			return &ast.CallExpr{Fun: t.typeToExpr(argType), Args: []ast.Expr{arg}}
+		}
+		return arg
+	}
+	op := token.LSS
+	if name == "max" {
+		op = token.GTR
+	}
+	isFloat := false
+	if basic, ok := argType.(transpiler.BasicType); ok {
+		isFloat = numericTypes[basic.Name].kind == 'f'
+	}
+	result := t.nextTempVar()
+	body := []ast.Stmt{&ast.AssignStmt{
+		Lhs: []ast.Expr{ast.NewIdent(result)},
+		Tok: token.DEFINE,
+		Rhs: []ast.Expr{operand(args[0])},
+	}}
+	for _, arg := range args[1:] {
+		next := t.nextTempVar()
+		var cond ast.Expr = &ast.BinaryExpr{X: ast.NewIdent(next), Op: op, Y: ast.NewIdent(result)}
+		if isFloat {
+			// NaN compares false with everything, itself included
+			cond = &ast.BinaryExpr{X: cond, Op: token.LOR, Y: &ast.BinaryExpr{X: ast.NewIdent(next), Op: token.NEQ, Y: ast.NewIdent(next)}}
+		}
+		body = append(body,
+			&ast.AssignStmt{Lhs: []ast.Expr{ast.NewIdent(next)}, Tok: token.DEFINE, Rhs: []ast.Expr{operand(arg)}},
+			&ast.IfStmt{
+				Cond: cond,
+				Body: &ast.BlockStmt{List: []ast.Stmt{&ast.AssignStmt{
+					Lhs: []ast.Expr{ast.NewIdent(result)},
+					Tok: token.ASSIGN,
+					Rhs: []ast.Expr{ast.NewIdent(next)},
+				}}},
+			})
+	}
+	body = append(body, &ast.ReturnStmt{Results: []ast.Expr{ast.NewIdent(result)}})
+	return &ast.CallExpr{
+		Fun: &ast.FuncLit{
+			Type: &ast.FuncType{
+				Params:  &ast.FieldList{},
+				Results: &ast.FieldList{List: []*ast.Field{{Type: t.typeToExpr(argType)}}},
+			},
+			Body: &ast.BlockStmt{List: body},
+		},
+	}, nil
+}
+
+// minMaxOperandType returns the type min or max computes in: that of the first
+// operand that is not an untyped constant or, when all of them are, the default
+// type of the constant of the widest kind, so min(1, 2.5) is a float64.
+func (t *galaASTTransformer) minMaxOperandType(args []ast.Expr) transpiler.Type {
+	var constType transpiler.Type
+	widest := -1
+	for _, arg := range args {
+		kind, isConst := untypedConstKind(arg)
+		if !isConst {
+			return t.getExprTypeName(arg)
+		}
+		if kind > widest {
+			widest, constType = kind, t.getExprTypeNameManual(arg)
+		}
+	}
+	return constType
+}
+
+// specializableElemTypes lists the element types for which a package may provide
+// monomorphic method specializations named Type_Method_elem.
+var specializableElemTypes = map[string]bool{
+	"int":     true,
+	"string":  true,
+	"float64": true,
+}
+
+// specializedMethodFunc returns the function expression for a monomorphic specialization
+// of typeName.method when the receiver has a single specializable type argument and the
+// declaring package defines a matching Type_Method_elem function (e.g., Array_Contains_int).
+// Returns nil when no specialization applies.
+func (t *galaASTTransformer) specializedMethodFunc(typeName string, recvType transpiler.Type, method string) (ast.Expr, *transpiler.FunctionMetadata) {
+	if typeName == "" || recvType == nil || recvType.IsNil() {
+		return nil, nil
+	}
+	recvTypeArgs := t.getReceiverTypeArgStrings(recvType)
+	if len(recvTypeArgs) != 1 || !specializableElemTypes[recvTypeArgs[0]] {
+		return nil, nil
+	}
+	funcName := typeName + "_" + method + "_" + recvTypeArgs[0]
+	if recvPkg := recvType.GetPackage(); recvPkg != "" && !strings.Contains(funcName, ".") {
+		funcName = recvPkg + "." + funcName
+	}
+	funcMeta, ok := t.functions[funcName]
+	if !ok || len(funcMeta.TypeParams) > 0 || len(funcMeta.ParamTypes) == 0 {
+		return nil, nil
+	}
+	if hasStdPrefix(funcName) {
+		return t.stdIdent(stripStdPrefix(funcName)), funcMeta
+	}
+	return t.ident(funcName), funcMeta
+}
+
 // extractFuncName extracts the base function name from a call expression's Fun node.
 // Handles: Ident (f), IndexExpr (f[T]), IndexListExpr (f[T, U]).
 func (t *galaASTTransformer) extractFuncName(fun ast.Expr) string {
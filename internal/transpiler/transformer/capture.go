@@ -0,0 +1,80 @@
+package transformer
+
+import (
+	"go/ast"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"martianoff/gala/internal/parser/grammar"
+	"martianoff/gala/internal/transpiler"
+)
+
+// This file contains the source capture of calls to @capture functions, which
+// receive the text of their arguments as it was written:
+//
+//	Eq(t, add(1, 2), 3)
+//
+// becomes
+//
+//	Eq(t, add(1, 2), 3, "t", "add(1, 2)", "3")
+//
+// so that assertions can report the expressions that failed.
+// Functions: captureArgs, calledFunction, sourceText
+
+// captureArgs appends the source text of each argument of the call to the
+// arguments of call when base names a @capture function. Calls that pass the
+// trailing strings themselves, or use named arguments, are left alone.
+func (t *galaASTTransformer) captureArgs(base ast.Expr, call ast.Expr, suffix *grammar.PostfixSuffixContext) ast.Expr {
+	c, ok := call.(*ast.CallExpr)
+	if !ok || c.Ellipsis.IsValid() || suffix.ArgumentList() == nil {
+		return call
+	}
+	fm := t.calledFunction(base)
+	if fm == nil {
+		return call
+	}
+	if _, ok := transpiler.FindAnnotation(fm.Annotations, transpiler.AnnotationCapture); !ok {
+		return call
+	}
+	args := suffix.ArgumentList().AllArgument()
+	if len(args) != len(fm.ParamTypes)-1 || len(c.Args) != len(args) {
+		return call
+	}
+	for _, arg := range args {
+		if arg.Identifier() != nil {
+			return call
+		}
+	}
+	for _, arg := range args {
+		c.Args = append(c.Args, stringLit(sourceText(arg)))
+	}
+	return c
+}
+
+// calledFunction returns the metadata of the function fun names, the callee
+// of a call, or nil if it is not a package-level function.
+func (t *galaASTTransformer) calledFunction(fun ast.Expr) *transpiler.FunctionMetadata {
+	switch f := fun.(type) {
+	case *ast.IndexExpr:
+		fun = f.X
+	case *ast.IndexListExpr:
+		fun = f.X
+	}
+	switch f := fun.(type) {
+	case *ast.Ident:
+		if !t.isVal(f.Name) && !t.isVar(f.Name) {
+			return t.getFunction(f.Name)
+		}
+	case *ast.SelectorExpr:
+		if id, ok := f.X.(*ast.Ident); ok && t.importManager.IsPackage(id.Name) {
+			return t.getFunction(t.getBaseTypeName(f))
+		}
+	}
+	return nil
+}
+
+// sourceText returns the text of ctx as written, including its whitespace.
+func sourceText(ctx antlr.ParserRuleContext) string {
+	start, stop := ctx.GetStart(), ctx.GetStop()
+	return start.GetInputStream().GetText(start.GetStart(), stop.GetStop())
+}
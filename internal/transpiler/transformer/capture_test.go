@@ -0,0 +1,78 @@
+package transformer_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"martianoff/gala/internal/transpiler"
+	"martianoff/gala/internal/transpiler/analyzer"
+	"martianoff/gala/internal/transpiler/generator"
+	"martianoff/gala/internal/transpiler/transformer"
+)
+
+func TestCaptureArgs(t *testing.T) {
+	const check = `package main
+
+@capture
+func check(ok bool, exprs ...string) string = if (ok) "" else exprs[0]
+
+func add(a int, b int) int = a + b
+`
+	tests := []struct {
+		name        string
+		input       string
+		contains    []string
+		notContains []string
+		wantErr     string
+	}{
+		{
+			name: "arguments passed as written",
+			input: check + `
+func main() {
+    println(check(add(1,  2) == 3))
+}
+`,
+			contains: []string{`check(add(1, 2) == 3, "add(1,  2) == 3")`},
+		},
+		{
+			name: "explicit strings are kept",
+			input: check + `
+func main() {
+    println(check(false, "mine"))
+}
+`,
+			contains:    []string{`check(false, "mine")`},
+			notContains: []string{`"false"`},
+		},
+		{
+			name: "function without trailing strings",
+			input: `package main
+
+@capture
+func check(ok bool) bool = ok
+`,
+			wantErr: "@capture function check must end with a ...string parameter",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := transpiler.NewAntlrGalaParser()
+			trans := transpiler.NewGalaToGoTranspiler(p, analyzer.NewGalaAnalyzer(p, getStdSearchPath()),
+				transformer.NewGalaASTTransformer(), generator.NewGoCodeGenerator())
+			got, err := trans.Transpile(tt.input, "")
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			for _, want := range tt.contains {
+				assert.Contains(t, got, want)
+			}
+			for _, unwanted := range tt.notContains {
+				assert.NotContains(t, got, unwanted)
+			}
+		})
+	}
+}
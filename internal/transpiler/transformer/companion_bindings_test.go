@@ -0,0 +1,73 @@
+package transformer_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"martianoff/gala/internal/transpiler"
+	"martianoff/gala/internal/transpiler/analyzer"
+	"martianoff/gala/internal/transpiler/generator"
+	"martianoff/gala/internal/transpiler/transformer"
+)
+
+func TestCompanionBindingTypes(t *testing.T) {
+	const boxed = `package main
+
+type Box[T any] struct {
+    Value T
+}
+
+type Boxed struct {}
+
+func (b Boxed) Apply[T any](v T) Box[T] = Box[T](Value = v)
+
+func (b Boxed) Unapply(v any) Option[any] = v match {
+    case bx: Box[int] => Some[any](bx.Value)
+    case _ => None[any]()
+}
+`
+	tests := []struct {
+		name        string
+		input       string
+		contains    []string
+		notContains []string
+	}{
+		{
+			name: "binding typed from the matched type",
+			input: boxed + `
+func twice(b Box[int]) int = b match {
+    case Boxed(n) => n * 2
+    case _ => 0
+}
+`,
+			contains: []string{"std.As[int](", "return n * 2"},
+		},
+		{
+			name: "unknown matched type keeps any",
+			input: boxed + `
+func present(v any) bool = v match {
+    case Boxed(n) => n != nil
+    case _ => false
+}
+`,
+			notContains: []string{"std.As[int]("},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := transpiler.NewAntlrGalaParser()
+			trans := transpiler.NewGalaToGoTranspiler(p, analyzer.NewGalaAnalyzer(p, getStdSearchPath()),
+				transformer.NewGalaASTTransformer(), generator.NewGoCodeGenerator())
+			got, err := trans.Transpile(tt.input, "")
+			assert.NoError(t, err)
+			for _, want := range tt.contains {
+				assert.Contains(t, got, want)
+			}
+			for _, unwanted := range tt.notContains {
+				assert.NotContains(t, got, unwanted)
+			}
+		})
+	}
+}
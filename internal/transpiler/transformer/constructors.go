@@ -4,18 +4,27 @@ import (
 	"fmt"
 	"go/ast"
 	"go/token"
+	"slices"
+	"sort"
+	"strings"
 
+	"martianoff/gala/galaerr"
 	"martianoff/gala/internal/parser/grammar"
 	"martianoff/gala/internal/transpiler"
 	"martianoff/gala/internal/transpiler/registry"
 )
 
 // This file contains struct construction and literal transformation logic extracted from expressions.go
-// Functions: transformPrimary, transformCompositeLiteral, transformLiteral
+// Functions: transformPrimary, transformCompositeLiteral, transformLiteral, checkConstructorArgs
 
 func (t *galaASTTransformer) transformPrimary(ctx *grammar.PrimaryContext) (ast.Expr, error) {
 	if ctx.Identifier() != nil {
 		name := ctx.Identifier().GetText()
+		if name == placeholderName {
+			if param := t.currentPlaceholder(); param != "" {
+				return ast.NewIdent(param), nil
+			}
+		}
 		ident := ast.NewIdent(name)
 		// First check if it's a local variable - if so, don't try to resolve as std type
 		if t.isVal(name) || t.isVar(name) {
@@ -27,6 +36,10 @@ func (t *galaASTTransformer) transformPrimary(ctx *grammar.PrimaryContext) (ast.
 					},
 				}, nil
 			}
+			if fn, ok := t.getType(name).(transpiler.FuncType); ok && fn.ByName {
+				// Reading a by-name parameter evaluates its argument
+				return &ast.CallExpr{Fun: ident}, nil
+			}
 			return ident, nil
 		}
 
@@ -79,12 +92,12 @@ func (t *galaASTTransformer) transformCompositeLiteral(ctx *grammar.CompositeLit
 
 	// Reject slice literals - users should use collection_immutable.Array, collection_mutable.Array, or go_interop.SliceOf() or go_interop.SliceEmpty() for Go interop
 	if _, isArray := typeExpr.(*ast.ArrayType); isArray {
-		return nil, fmt.Errorf("slice literals are not supported in GALA; use collection_immutable.Array or collection_mutable.Array for type-safe collections, or go_interop.SliceOf() or go_interop.SliceEmpty() for Go interoperability")
+		return nil, galaerr.NewSemanticError("slice literals are not supported in GALA; use collection_immutable.Array or collection_mutable.Array for type-safe collections, or go_interop.SliceOf() or go_interop.SliceEmpty() for Go interoperability").WithCode(galaerr.CodeUnsupportedLiteral)
 	}
 
 	// Reject map literals - users should use collection_immutable.HashMap, collection_mutable.HashMap or go_interop.MapEmpty() for Go interop
 	if _, isMap := typeExpr.(*ast.MapType); isMap {
-		return nil, fmt.Errorf("map literals are not supported in GALA; use collection_immutable.HashMap or collection_mutable.HashMap for type-safe maps, or go_interop.MapEmpty()/go_interop.MapPut() for Go interoperability")
+		return nil, galaerr.NewSemanticError("map literals are not supported in GALA; use collection_immutable.HashMap or collection_mutable.HashMap for type-safe maps, or go_interop.MapEmpty()/go_interop.MapPut() for Go interoperability").WithCode(galaerr.CodeUnsupportedLiteral)
 	}
 
 	// Transform the elements
@@ -146,3 +159,49 @@ func (t *galaASTTransformer) transformLiteral(ctx *grammar.LiteralContext) (ast.
 	}
 	return nil, nil
 }
+
+// checkConstructorArgs reports a construction of typeName whose arguments do
+// not match fields: more positional arguments than fields, positional
+// arguments alone that leave fields out, or a named argument that is not a
+// field or repeats a positional one. Fields may be left out only by naming the
+// ones that are set. The errors list the fields so the call can be fixed.
+func checkConstructorArgs(typeName string, fields []string, args []ast.Expr, namedArgs map[string]ast.Expr) error {
+	switch {
+	case len(args) > len(fields):
+		msg := fmt.Sprintf("too many arguments in construction of %s: got %d, but %s", typeName, len(args), describeFields(typeName, fields))
+		return galaerr.NewSemanticError(msg).WithCode(galaerr.CodeConstructorArgs)
+	case len(namedArgs) == 0 && len(args) < len(fields):
+		msg := fmt.Sprintf("not enough arguments in construction of %s: got %d, but %s; pass them all or name the ones to set, as in %s(%s = ...)",
+			typeName, len(args), describeFields(typeName, fields), typeName, strings.Join(fields, " = ..., "))
+		return galaerr.NewSemanticError(msg).WithCode(galaerr.CodeConstructorArgs)
+	}
+	names := make([]string, 0, len(namedArgs))
+	for name := range namedArgs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		i := slices.Index(fields, name)
+		if i < 0 {
+			msg := fmt.Sprintf("%s has no field %s; %s", typeName, name, describeFields(typeName, fields))
+			return galaerr.NewSemanticError(msg).WithCode(galaerr.CodeConstructorArgs)
+		}
+		if i < len(args) {
+			msg := fmt.Sprintf("field %s of %s is set twice, by position and by name", name, typeName)
+			return galaerr.NewSemanticError(msg).WithCode(galaerr.CodeConstructorArgs)
+		}
+	}
+	return nil
+}
+
+// describeFields lists the fields of typeName in a sentence.
+func describeFields(typeName string, fields []string) string {
+	switch len(fields) {
+	case 0:
+		return typeName + " has no fields"
+	case 1:
+		return typeName + " has 1 field, " + fields[0]
+	}
+	list := strings.Join(fields[:len(fields)-1], ", ") + " and " + fields[len(fields)-1]
+	return fmt.Sprintf("%s has %d fields, %s", typeName, len(fields), list)
+}
@@ -55,3 +55,161 @@ func check(x int) string {
 		})
 	}
 }
+
+func TestIfLet(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		contains []string
+		wantErr  string
+	}{
+		{
+			name: "Some binding with else",
+			input: `package main
+
+import "fmt"
+
+func show(o Option[int]) {
+    if (val Some(n) = o) {
+        fmt.Println(n + 1)
+    } else {
+        fmt.Println("none")
+    }
+}`,
+			contains: []string{
+				"_tmp_0 := o",
+				"std.Some[int]{}.Unapply(_tmp_0)",
+				"fmt.Println(n + 1)",
+				"fmt.Println(\"none\")",
+			},
+		},
+		{
+			name: "else-if chains into another if-let",
+			input: `package main
+
+func pick(a Option[string], b Option[string]) string {
+    if (val Some(x) = a) {
+        return x
+    } else if (val Some(y) = b) {
+        return y
+    }
+    return ""
+}`,
+			contains: []string{
+				"std.Some[string]{}.Unapply(_tmp_0)",
+				"return x",
+				"return y",
+			},
+		},
+		{
+			name: "unused binding is an error",
+			input: `package main
+
+import "fmt"
+
+func show(o Option[int]) {
+    if (val Some(n) = o) {
+        fmt.Println("present")
+    }
+}`,
+			wantErr: "unused variable 'n' in if (val ...) pattern",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := transpiler.NewAntlrGalaParser()
+			trans := transpiler.NewGalaToGoTranspiler(p, analyzer.NewGalaAnalyzer(p, getStdSearchPath()),
+				transformer.NewGalaASTTransformer(), generator.NewGoCodeGenerator())
+			got, err := trans.Transpile(tt.input, "")
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			for _, want := range tt.contains {
+				assert.Contains(t, got, want)
+			}
+		})
+	}
+}
+
+func TestGeneratorLoop(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		contains []string
+		wantErr  string
+	}{
+		{
+			name: "slice",
+			input: `package main
+
+func sum(xs []int) int {
+    var total = 0
+    for x <- xs {
+        total = total + x
+    }
+    return total
+}`,
+			contains: []string{"for _, x := range xs {", "total = total + x"},
+		},
+		{
+			name: "collection",
+			input: `package main
+
+import "fmt"
+import . "martianoff/gala/collection_immutable"
+
+func greet(names Array[string]) {
+    for name <- names {
+        fmt.Println(name + "!")
+    }
+}`,
+			contains: []string{"for _, name := range names.ToGoSlice() {", `fmt.Println(name + "!")`},
+		},
+		{
+			name: "string",
+			input: `package main
+
+func count(s string) int {
+    var n = 0
+    for r <- s {
+        if r == 'a' {
+            n++
+        }
+    }
+    return n
+}`,
+			contains: []string{"for _, r := range s {"},
+		},
+		{
+			name: "not iterable",
+			input: `package main
+
+func loop() {
+    for x <- 42 {
+        println(x)
+    }
+}`,
+			wantErr: "cannot iterate over '42' with <-",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := transpiler.NewAntlrGalaParser()
+			trans := transpiler.NewGalaToGoTranspiler(p, analyzer.NewGalaAnalyzer(p, getStdSearchPath()),
+				transformer.NewGalaASTTransformer(), generator.NewGoCodeGenerator())
+			got, err := trans.Transpile(tt.input, "")
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			for _, want := range tt.contains {
+				assert.Contains(t, got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,648 @@
+package transformer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"martianoff/gala/internal/transpiler"
+	"martianoff/gala/internal/transpiler/registry"
+)
+
+// This file implements purity inference and common-subexpression elimination (CSE)
+// over the generated Go AST.
+//
+// A top-level function of the current file is pure when its body mutates nothing
+// (no assignments other than :=, no ++/--, no channel ops, no go/defer), reads no
+// package var, reads nothing through a pointer and every call it makes is itself
+// pure: another pure function of the file, a Go builtin or conversion, an entry in
+// pureGoFuncs, or a Get() unwrapping a std Option, Immutable or Try.
+//
+// With gala build -O, within a single statement, a pure call that appears more than
+// once before the first impure call of the statement is evaluated once into a temp
+// declared right before the statement. Occurrences on the right side of && / || and
+// inside function literals are conditionally evaluated and are left alone, and so
+// are calls returning a slice, map, pointer or channel, which each call makes anew.
+
+// WithCommonSubexpressions makes tr, a transformer created by this package, hoist
+// repeated pure calls into temps as described above.
+func WithCommonSubexpressions(tr transpiler.ASTTransformer) transpiler.ASTTransformer {
+	tr.(*galaASTTransformer).cse = true
+	return tr
+}
+
+// pureGoFuncs lists package-qualified Go functions that are known to be side-effect free.
+var pureGoFuncs = map[string]bool{
+	"math.Abs": true, "math.Ceil": true, "math.Floor": true, "math.Round": true,
+	"math.Sqrt": true, "math.Pow": true, "math.Exp": true, "math.Log": true,
+	"math.Max": true, "math.Min": true, "math.Mod": true, "math.Trunc": true,
+	"strings.Contains": true, "strings.HasPrefix": true, "strings.HasSuffix": true,
+	"strings.Index": true, "strings.Repeat": true, "strings.ToLower": true,
+	"strings.ToUpper": true, "strings.TrimSpace": true,
+	"strconv.Itoa": true, "strconv.FormatInt": true, "strconv.Quote": true,
+}
+
+// pureBuiltins lists Go builtins that are side-effect free.
+var pureBuiltins = map[string]bool{
+	"len": true, "cap": true, "min": true, "max": true, "real": true, "imag": true, "complex": true,
+}
+
+// pureStdFuncs lists std functions that are side-effect free.
+var pureStdFuncs = map[string]bool{
+	"NewImmutable": true,
+}
+
+// purityChecker decides purity of expressions given the set of pure file-level functions.
+type purityChecker struct {
+	t         *galaASTTransformer
+	pureFuncs map[string]bool
+	freshRefs map[string]bool // file functions returning a slice, map, pointer or channel
+	locals    map[string]bool // names declared by the function being checked
+	pointers  map[string]bool // locals that may hold a pointer
+}
+
+// eliminateCommonSubexpressions infers purity for the functions in decls and hoists
+// repeated pure calls within each statement of every function body into temps.
+func (t *galaASTTransformer) eliminateCommonSubexpressions(decls []ast.Decl) {
+	if !t.cse {
+		return
+	}
+	pc := &purityChecker{t: t, pureFuncs: t.inferPureFuncs(decls), freshRefs: referenceResultFuncs(decls)}
+	for _, decl := range decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+		pc.locals = declaredNames(fn)
+		pc.pointers = pc.pointerNames(fn)
+		var lists []*[]ast.Stmt
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			switch s := n.(type) {
+			case *ast.BlockStmt:
+				lists = append(lists, &s.List)
+			case *ast.CaseClause:
+				lists = append(lists, &s.Body)
+			case *ast.CommClause:
+				lists = append(lists, &s.Body)
+			}
+			return true
+		})
+		for _, list := range lists {
+			*list = pc.hoistInList(*list)
+		}
+	}
+}
+
+// inferPureFuncs computes the set of pure top-level functions (no receiver) by fixed point.
+func (t *galaASTTransformer) inferPureFuncs(decls []ast.Decl) map[string]bool {
+	candidates := make(map[string]*ast.FuncDecl)
+	for _, decl := range decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil && fn.Body != nil &&
+			fn.Type.Results != nil && len(fn.Type.Results.List) > 0 && fn.Name.Name != "main" && fn.Name.Name != "init" {
+			candidates[fn.Name.Name] = fn
+		}
+	}
+	// Start optimistic and remove functions until nothing changes, so mutually
+	// recursive pure functions are recognized.
+	pc := &purityChecker{t: t, pureFuncs: make(map[string]bool)}
+	for name := range candidates {
+		pc.pureFuncs[name] = true
+	}
+	for changed := true; changed; {
+		changed = false
+		for name, fn := range candidates {
+			pc.locals = declaredNames(fn)
+			pc.pointers = pc.pointerNames(fn)
+			if pc.pureFuncs[name] && !pc.isPureBody(fn.Body) {
+				delete(pc.pureFuncs, name)
+				changed = true
+			}
+		}
+	}
+	return pc.pureFuncs
+}
+
+// isPureBody reports whether a function body performs no mutation, reads no
+// package var, reads nothing through a pointer and only makes pure calls.
+func (pc *purityChecker) isPureBody(body *ast.BlockStmt) bool {
+	pure := true
+	var visit func(n ast.Node) bool
+	visit = func(n ast.Node) bool {
+		if !pure {
+			return false
+		}
+		switch s := n.(type) {
+		case *ast.Ident:
+			if !pc.isPureIdent(s.Name) {
+				pure = false
+			}
+		case *ast.SelectorExpr:
+			// Sel names a field, a method or a member of a package; a package
+			// member other than a called function may be a var, and a field
+			// of a pointed-to struct may be changed through another pointer
+			if pc.isPackageRef(s.X) || pc.mayBePointer(s.X) {
+				pure = false
+				return false
+			}
+			ast.Inspect(s.X, visit)
+			return false
+		case *ast.StarExpr:
+			pure = false
+		case *ast.KeyValueExpr:
+			// Keys of struct literals name fields
+			if _, ok := s.Key.(*ast.Ident); !ok {
+				ast.Inspect(s.Key, visit)
+			}
+			ast.Inspect(s.Value, visit)
+			return false
+		case *ast.CompositeLit:
+			// The type of a literal is not read
+			for _, elt := range s.Elts {
+				ast.Inspect(elt, visit)
+			}
+			return false
+		case *ast.ValueSpec:
+			for _, v := range s.Values {
+				ast.Inspect(v, visit)
+			}
+			return false
+		case *ast.TypeAssertExpr:
+			ast.Inspect(s.X, visit)
+			return false
+		case *ast.LabeledStmt:
+			ast.Inspect(s.Stmt, visit)
+			return false
+		case *ast.FuncType, *ast.BranchStmt:
+			// Types and labels are not read
+			return false
+		case *ast.AssignStmt:
+			if s.Tok != token.DEFINE {
+				pure = false
+			}
+		case *ast.IncDecStmt, *ast.SendStmt, *ast.GoStmt, *ast.DeferStmt:
+			pure = false
+		case *ast.UnaryExpr:
+			if s.Op == token.ARROW {
+				pure = false
+			}
+		case *ast.CallExpr:
+			if !pc.isPureCallee(s) {
+				pure = false
+				return false
+			}
+			// The callee is known, only its receiver and the arguments are read
+			if sel, ok := s.Fun.(*ast.SelectorExpr); ok && !pc.isPackageRef(sel.X) {
+				ast.Inspect(sel.X, visit)
+			}
+			for _, arg := range s.Args {
+				ast.Inspect(arg, visit)
+			}
+			return false
+		}
+		return true
+	}
+	ast.Inspect(body, visit)
+	return pure
+}
+
+// isPureIdent reports whether reading name in the function being checked only
+// reads state the function owns or nothing can change: a local, a val, a
+// function, a type, a package or a predeclared identifier. Anything else is
+// taken for a package var, of this file or another, which may change between
+// calls.
+func (pc *purityChecker) isPureIdent(name string) bool {
+	if pc.locals[name] || name == "_" || types.Universe.Lookup(name) != nil {
+		return true
+	}
+	if pc.pureFuncs[name] || pc.t.getFunction(name) != nil || pc.t.getTypeMeta(name) != nil {
+		return true
+	}
+	return pc.t.isVal(name) || pc.isPackageName(name)
+}
+
+// isPackageName reports whether name refers to an imported package.
+func (pc *purityChecker) isPackageName(name string) bool {
+	return name == registry.StdPackageName || pc.t.importManager.IsPackage(name)
+}
+
+// isPackageRef reports whether e is the name of an imported package.
+func (pc *purityChecker) isPackageRef(e ast.Expr) bool {
+	id, ok := e.(*ast.Ident)
+	return ok && pc.isPackageName(id.Name)
+}
+
+// isPureCallee reports whether the function being called is known to be pure,
+// without looking at the arguments.
+func (pc *purityChecker) isPureCallee(call *ast.CallExpr) bool {
+	fun := call.Fun
+	switch f := fun.(type) {
+	case *ast.IndexExpr:
+		fun = f.X
+	case *ast.IndexListExpr:
+		fun = f.X
+	case *ast.ParenExpr:
+		// Conversions to composite types, e.g. (*T)(x)
+		return len(call.Args) == 1
+	case *ast.ArrayType, *ast.MapType, *ast.FuncType, *ast.ChanType:
+		return len(call.Args) == 1
+	}
+	switch f := fun.(type) {
+	case *ast.Ident:
+		if pc.pureFuncs[f.Name] || pureBuiltins[f.Name] || transpiler.IsPrimitiveType(f.Name) {
+			return true
+		}
+		return pureStdFuncs[f.Name] && (pc.t.packageName == registry.StdPackageName || pc.t.importManager.IsDotImported(registry.StdPackageName))
+	case *ast.SelectorExpr:
+		if pc.t.stdGets[call] {
+			return true
+		}
+		if id, ok := f.X.(*ast.Ident); ok {
+			if id.Name == registry.StdPackageName && pureStdFuncs[f.Sel.Name] {
+				return true
+			}
+			return pureGoFuncs[id.Name+"."+f.Sel.Name]
+		}
+	}
+	return false
+}
+
+// isPureExpr reports whether evaluating e has no side effects.
+func (pc *purityChecker) isPureExpr(e ast.Expr) bool {
+	switch x := e.(type) {
+	case *ast.BasicLit:
+		return true
+	case *ast.Ident:
+		return pc.isPureIdent(x.Name)
+	case *ast.ParenExpr:
+		return pc.isPureExpr(x.X)
+	case *ast.SelectorExpr:
+		if pc.isPackageRef(x.X) || pc.mayBePointer(x.X) {
+			return false
+		}
+		return pc.isPureExpr(x.X)
+	case *ast.UnaryExpr:
+		return x.Op != token.ARROW && x.Op != token.AND && pc.isPureExpr(x.X)
+	case *ast.BinaryExpr:
+		return pc.isPureExpr(x.X) && pc.isPureExpr(x.Y)
+	case *ast.IndexExpr:
+		return pc.isPureExpr(x.X) && pc.isPureExpr(x.Index)
+	case *ast.CallExpr:
+		if !pc.isPureCallee(x) {
+			return false
+		}
+		if sel, ok := x.Fun.(*ast.SelectorExpr); ok && !pc.isPackageRef(sel.X) && !pc.isPureExpr(sel.X) {
+			return false
+		}
+		for _, arg := range x.Args {
+			if !pc.isPureExpr(arg) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// isWorthHoisting filters out pure calls that are cheaper to recompute than to store,
+// such as builtins, conversions and single-level val unwraps (x.Get()), and calls
+// whose result each caller must get a fresh copy of.
+func (pc *purityChecker) isWorthHoisting(call *ast.CallExpr) bool {
+	fun := call.Fun
+	if idx, ok := fun.(*ast.IndexExpr); ok {
+		fun = idx.X
+	} else if idxList, ok := fun.(*ast.IndexListExpr); ok {
+		fun = idxList.X
+	}
+	switch f := fun.(type) {
+	case *ast.Ident:
+		return pc.pureFuncs[f.Name] && !pc.freshRefs[f.Name]
+	case *ast.SelectorExpr:
+		if pc.t.stdGets[call] {
+			containsCall := false
+			ast.Inspect(f.X, func(n ast.Node) bool {
+				if _, ok := n.(*ast.CallExpr); ok {
+					containsCall = true
+				}
+				return !containsCall
+			})
+			return containsCall
+		}
+		if id, ok := f.X.(*ast.Ident); ok {
+			return pureGoFuncs[id.Name+"."+f.Sel.Name]
+		}
+	}
+	return false
+}
+
+// hoistInList applies CSE to every statement of a statement list and returns the new list.
+func (pc *purityChecker) hoistInList(list []ast.Stmt) []ast.Stmt {
+	var result []ast.Stmt
+	for _, stmt := range list {
+		slots := statementExprSlots(stmt)
+		if len(slots) == 0 {
+			result = append(result, stmt)
+			continue
+		}
+		// Hoist the largest repeated expression first, then rescan including the new
+		// temp, so repeats nested inside it get their own (earlier) temp.
+		var hoisted []*ast.AssignStmt
+		for {
+			// Temps are evaluated before the statement, nested ones first
+			scan := make([]*ast.Expr, 0, len(hoisted)+len(slots))
+			for _, assign := range hoisted {
+				scan = append(scan, &assign.Rhs[0])
+			}
+			scan = append(scan, slots...)
+			occurrences := make(map[string][]*ast.Expr)
+			var order []string
+			impure := false
+			for _, slot := range scan {
+				pc.collectCandidates(slot, occurrences, &order, &impure)
+			}
+			best := ""
+			for _, key := range order {
+				if len(occurrences[key]) > 1 && len(key) > len(best) {
+					best = key
+				}
+			}
+			if best == "" {
+				break
+			}
+			tmp := pc.t.nextTempVar()
+			pc.locals[tmp] = true
+			assign := &ast.AssignStmt{
+				Lhs: []ast.Expr{ast.NewIdent(tmp)},
+				Tok: token.DEFINE,
+				Rhs: []ast.Expr{*occurrences[best][0]},
+			}
+			for _, slot := range occurrences[best] {
+				*slot = ast.NewIdent(tmp)
+			}
+			hoisted = append([]*ast.AssignStmt{assign}, hoisted...)
+		}
+		for _, assign := range hoisted {
+			result = append(result, assign)
+		}
+		result = append(result, stmt)
+	}
+	return result
+}
+
+// statementExprSlots returns the unconditionally evaluated expression slots of a statement.
+func statementExprSlots(stmt ast.Stmt) []*ast.Expr {
+	var slots []*ast.Expr
+	switch s := stmt.(type) {
+	case *ast.ReturnStmt:
+		for i := range s.Results {
+			slots = append(slots, &s.Results[i])
+		}
+	case *ast.AssignStmt:
+		for i := range s.Rhs {
+			slots = append(slots, &s.Rhs[i])
+		}
+	case *ast.ExprStmt:
+		slots = append(slots, &s.X)
+	case *ast.DeclStmt:
+		if gen, ok := s.Decl.(*ast.GenDecl); ok {
+			for _, spec := range gen.Specs {
+				if vs, ok := spec.(*ast.ValueSpec); ok {
+					for i := range vs.Values {
+						slots = append(slots, &vs.Values[i])
+					}
+				}
+			}
+		}
+	}
+	return slots
+}
+
+// collectCandidates records the slots of hoistable pure calls reachable from slot,
+// in evaluation order. Once a call that may have side effects has been evaluated,
+// which impure records, later occurrences are left in place: a temp computed
+// before the statement would not see those effects.
+func (pc *purityChecker) collectCandidates(slot *ast.Expr, occurrences map[string][]*ast.Expr, order *[]string, impure *bool) {
+	switch x := (*slot).(type) {
+	case *ast.CallExpr:
+		if !*impure && pc.isWorthHoisting(x) && pc.isPureExpr(x) {
+			key := types.ExprString(x)
+			if _, seen := occurrences[key]; !seen {
+				*order = append(*order, key)
+			}
+			occurrences[key] = append(occurrences[key], slot)
+		}
+		pc.collectCandidates(&x.Fun, occurrences, order, impure)
+		for i := range x.Args {
+			pc.collectCandidates(&x.Args[i], occurrences, order, impure)
+		}
+		if !pc.isPureCallee(x) {
+			*impure = true
+		}
+	case *ast.BinaryExpr:
+		pc.collectCandidates(&x.X, occurrences, order, impure)
+		if x.Op != token.LAND && x.Op != token.LOR {
+			pc.collectCandidates(&x.Y, occurrences, order, impure)
+		} else if !pc.isPureExpr(x.Y) {
+			*impure = true
+		}
+	case *ast.ParenExpr:
+		pc.collectCandidates(&x.X, occurrences, order, impure)
+	case *ast.SelectorExpr:
+		pc.collectCandidates(&x.X, occurrences, order, impure)
+	case *ast.StarExpr:
+		pc.collectCandidates(&x.X, occurrences, order, impure)
+	case *ast.UnaryExpr:
+		pc.collectCandidates(&x.X, occurrences, order, impure)
+		if x.Op == token.ARROW {
+			*impure = true
+		}
+	case *ast.IndexExpr:
+		pc.collectCandidates(&x.X, occurrences, order, impure)
+		pc.collectCandidates(&x.Index, occurrences, order, impure)
+	case *ast.CompositeLit:
+		for i := range x.Elts {
+			pc.collectCandidates(&x.Elts[i], occurrences, order, impure)
+		}
+	case *ast.KeyValueExpr:
+		pc.collectCandidates(&x.Value, occurrences, order, impure)
+	case *ast.BasicLit, *ast.Ident, *ast.FuncLit,
+		*ast.ArrayType, *ast.MapType, *ast.FuncType, *ast.ChanType, *ast.InterfaceType, *ast.StructType:
+		// Nothing is evaluated: function literals run when called, types are not values
+	default:
+		if !pc.isPureExpr(x) {
+			*impure = true
+		}
+	}
+}
+
+// pointerNames returns the locals of fn that may hold a pointer: those declared
+// with a pointer type, and those initialized from an expression that may yield
+// one. Range variables are included, since the element type is not known.
+func (pc *purityChecker) pointerNames(fn *ast.FuncDecl) map[string]bool {
+	pc.pointers = make(map[string]bool)
+	ast.Inspect(fn, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.Field:
+			if _, ok := x.Type.(*ast.StarExpr); ok {
+				for _, id := range x.Names {
+					pc.pointers[id.Name] = true
+				}
+			}
+		case *ast.ValueSpec:
+			_, isPtr := x.Type.(*ast.StarExpr)
+			for i, id := range x.Names {
+				if isPtr || x.Type == nil && i < len(x.Values) && pc.mayBePointer(x.Values[i]) {
+					pc.pointers[id.Name] = true
+				}
+			}
+		case *ast.AssignStmt:
+			if x.Tok != token.DEFINE {
+				break
+			}
+			for i, l := range x.Lhs {
+				id, ok := l.(*ast.Ident)
+				if !ok {
+					continue
+				}
+				if len(x.Rhs) != len(x.Lhs) || pc.mayBePointer(x.Rhs[i]) {
+					pc.pointers[id.Name] = true
+				}
+			}
+		case *ast.RangeStmt:
+			if x.Tok == token.DEFINE {
+				for _, e := range []ast.Expr{x.Key, x.Value} {
+					if id, ok := e.(*ast.Ident); ok {
+						pc.pointers[id.Name] = true
+					}
+				}
+			}
+		}
+		return true
+	})
+	return pc.pointers
+}
+
+// mayBePointer reports whether e may evaluate to a pointer. Expressions whose
+// type is not known here are assumed to.
+func (pc *purityChecker) mayBePointer(e ast.Expr) bool {
+	switch x := e.(type) {
+	case *ast.BasicLit, *ast.CompositeLit, *ast.FuncLit, *ast.BinaryExpr:
+		return false
+	case *ast.Ident:
+		if pc.locals[x.Name] {
+			return pc.pointers[x.Name]
+		}
+		return !pc.isPackageName(x.Name)
+	case *ast.ParenExpr:
+		return pc.mayBePointer(x.X)
+	case *ast.UnaryExpr:
+		return x.Op == token.AND
+	case *ast.StarExpr:
+		return true
+	case *ast.SelectorExpr:
+		if pc.isPackageRef(x.X) {
+			return true
+		}
+		return pc.mayBePointerField(x.Sel.Name)
+	case *ast.CallExpr:
+		if pc.t.stdGets[x] {
+			// Get returns what the Immutable, Option or Try holds
+			if sel, ok := x.Fun.(*ast.SelectorExpr); ok {
+				if recv, ok := sel.X.(*ast.SelectorExpr); ok {
+					return pc.mayBePointerField(recv.Sel.Name)
+				}
+			}
+			return true
+		}
+		if id, ok := x.Fun.(*ast.Ident); ok {
+			if pureBuiltins[id.Name] || transpiler.IsPrimitiveType(id.Name) {
+				return false
+			}
+			if fn := pc.t.getFunction(id.Name); fn != nil && fn.ReturnType != nil {
+				_, isPtr := fn.ReturnType.(transpiler.PointerType)
+				return isPtr
+			}
+		}
+		return true
+	}
+	return true
+}
+
+// mayBePointerField reports whether a struct field called name may hold a
+// pointer: some struct of the file declares it with a pointer type, or none
+// declares it at all.
+func (pc *purityChecker) mayBePointerField(name string) bool {
+	found := false
+	for _, fields := range pc.t.structFieldTypes {
+		typ, ok := fields[name]
+		if !ok {
+			continue
+		}
+		if _, isPtr := typ.(transpiler.PointerType); isPtr {
+			return true
+		}
+		found = true
+	}
+	return !found
+}
+
+// referenceResultFuncs returns the top-level functions of decls whose result is
+// a slice, map, pointer or channel. Two calls return distinct values, so a
+// caller mutating one must not see the change in the other.
+func referenceResultFuncs(decls []ast.Decl) map[string]bool {
+	funcs := make(map[string]bool)
+	for _, decl := range decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv != nil || fn.Type.Results == nil {
+			continue
+		}
+		for _, field := range fn.Type.Results.List {
+			switch r := field.Type.(type) {
+			case *ast.ArrayType:
+				funcs[fn.Name.Name] = funcs[fn.Name.Name] || r.Len == nil
+			case *ast.MapType, *ast.StarExpr, *ast.ChanType:
+				funcs[fn.Name.Name] = true
+			}
+		}
+	}
+	return funcs
+}
+
+// stdGetTypes lists the std types whose Get() only reads the value they hold.
+var stdGetTypes = map[string]bool{
+	transpiler.TypeOption:    true,
+	transpiler.TypeImmutable: true,
+	transpiler.TypeTry:       true,
+}
+
+// immutableGet returns x.Get() for x of type std.Immutable and records the
+// call as pure.
+func (t *galaASTTransformer) immutableGet(x ast.Expr) *ast.CallExpr {
+	call := &ast.CallExpr{Fun: &ast.SelectorExpr{X: x, Sel: ast.NewIdent(transpiler.MethodGet)}}
+	t.stdGets[call] = true
+	return call
+}
+
+// recordStdGet records call as pure when it is a Get() on a value of one of
+// stdGetTypes. Get() methods of other types may have side effects.
+func (t *galaASTTransformer) recordStdGet(call *ast.CallExpr) {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != transpiler.MethodGet || len(call.Args) > 0 {
+		return
+	}
+	typ := t.getExprTypeName(sel.X)
+	if typ == nil || typ.IsNil() {
+		return
+	}
+	name := typ.BaseName()
+	if q := t.getType(name); !q.IsNil() {
+		name = q.BaseName()
+	}
+	base, isStd := strings.CutPrefix(name, registry.StdPackageName+".")
+	if !isStd && t.packageName == registry.StdPackageName {
+		base, isStd = name, true
+	}
+	if isStd && stdGetTypes[base] {
+		t.stdGets[call] = true
+	}
+}
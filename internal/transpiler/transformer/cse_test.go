@@ -0,0 +1,259 @@
+package transformer_test
+
+import (
+	"martianoff/gala/internal/transpiler"
+	"martianoff/gala/internal/transpiler/analyzer"
+	"martianoff/gala/internal/transpiler/generator"
+	"martianoff/gala/internal/transpiler/transformer"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommonSubexpressionElimination(t *testing.T) {
+	p := transpiler.NewAntlrGalaParser()
+	a := analyzer.NewGalaAnalyzer(p, getStdSearchPath())
+	tr := transformer.WithCommonSubexpressions(transformer.NewGalaASTTransformer())
+	g := generator.NewGoCodeGenerator()
+	trans := transpiler.NewGalaToGoTranspiler(p, a, tr, g)
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name: "repeated pure call is hoisted",
+			input: `package main
+
+func square(x int) int = x * x
+func compute(a int) int = square(a) + square(a)`,
+			expected: `package main
+
+func square(x int) int {
+	return x * x
+}
+func compute(a int) int {
+	_tmp_0 := square(a)
+	return _tmp_0 + _tmp_0
+}
+`,
+		},
+		{
+			name: "impure call is not hoisted",
+			input: `package main
+
+var counter = 0
+func next(x int) int {
+    counter = counter + 1
+    return x
+}
+func twice(a int) int = next(a) + next(a)`,
+			expected: `package main
+
+var counter = 0
+
+func next(x int) int {
+	counter = counter + 1
+	return x
+}
+func twice(a int) int {
+	return next(a) + next(a)
+}
+`,
+		},
+		{
+			name: "reading a package var is impure",
+			input: `package main
+
+var scale = 2
+func scaled(x int) int = x * scale
+func twice(a int) int = scaled(a) + scaled(a)`,
+			expected: `package main
+
+var scale = 2
+
+func scaled(x int) int {
+	return x * scale
+}
+func twice(a int) int {
+	return scaled(a) + scaled(a)
+}
+`,
+		},
+		{
+			name: "reading a package val is pure",
+			input: `package main
+
+val factor = 3
+func scaled(x int) int = x * factor
+func twice(a int) int = scaled(a) + scaled(a)`,
+			expected: `package main
+
+import "martianoff/gala/std"
+
+var factor = std.NewImmutable(3)
+
+func scaled(x int) int {
+	return x * factor.Get()
+}
+func twice(a int) int {
+	_tmp_0 := scaled(a)
+	return _tmp_0 + _tmp_0
+}
+`,
+		},
+		{
+			name: "reading through a pointer is impure",
+			input: `package main
+
+type Counter struct {
+	var N int
+}
+
+func get(c *Counter) int = c.N
+func bump(c *Counter) int {
+    c.N = c.N + 1
+    return 0
+}
+func total(c *Counter) int = get(c) + bump(c) + get(c)`,
+			expected: `package main
+
+import "martianoff/gala/std"
+
+type Counter struct {
+	N int
+}
+
+func (s Counter) Copy() Counter {
+	return Counter{N: std.Copy(s.N)}
+}
+func (s Counter) Equal(other Counter) bool {
+	return std.Equal(s.N, other.N)
+}
+func (s Counter) Unapply(v any) (int, bool) {
+	switch p := v.(type) {
+	case Counter:
+		return p.N, true
+	case *Counter:
+		if p != nil {
+			return p.N, true
+		}
+	}
+	return *new(int), false
+}
+
+func get(c *Counter) int {
+	return c.N
+}
+func bump(c *Counter) int {
+	c.N = c.N + 1
+	return 0
+}
+func total(c *Counter) int {
+	return get(c) + bump(c) + get(c)
+}
+`,
+		},
+		{
+			name: "occurrences after an impure call are not hoisted",
+			input: `package main
+
+var ticks = 0
+func tick() int {
+    ticks = ticks + 1
+    return ticks
+}
+func square(x int) int = x * x
+func spaced(a int) int = square(a) + tick() + square(a)
+func grouped(a int) int = square(a) + square(a) + tick() + square(a)`,
+			expected: `package main
+
+var ticks = 0
+
+func tick() int {
+	ticks = ticks + 1
+	return ticks
+}
+func square(x int) int {
+	return x * x
+}
+func spaced(a int) int {
+	return square(a) + tick() + square(a)
+}
+func grouped(a int) int {
+	_tmp_0 := square(a)
+	return _tmp_0 + _tmp_0 + tick() + square(a)
+}
+`,
+		},
+		{
+			name: "calls returning a new slice are not hoisted",
+			input: `package main
+
+import "strings"
+
+func fresh(n int) []int = []int{n}
+func sizes(n int) int = len(fresh(n)) + len(fresh(n))
+func parts(s string) int = len(strings.Split(s, ",")) + len(strings.Split(s, ","))`,
+			expected: `package main
+
+import "strings"
+
+func fresh(n int) []int {
+	return []int{n}
+}
+func sizes(n int) int {
+	return len(fresh(n)) + len(fresh(n))
+}
+func parts(s string) int {
+	return len(strings.Split(s, ",")) + len(strings.Split(s, ","))
+}
+`,
+		},
+		{
+			name: "short-circuit operands are not hoisted",
+			input: `package main
+
+func square(x int) int = x * x
+func check(a int) bool = a > 0 && square(a) > 1 && square(a) < 10`,
+			expected: `package main
+
+func square(x int) int {
+	return x * x
+}
+func check(a int) bool {
+	return a > 0 && square(a) > 1 && square(a) < 10
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := trans.Transpile(tt.input, "")
+			assert.NoError(t, err)
+			assert.Equal(t, strings.TrimSpace(tt.expected), strings.TrimSpace(stripGeneratedHeader(got)))
+		})
+	}
+}
+
+func TestCommonSubexpressionEliminationNeedsOption(t *testing.T) {
+	p := transpiler.NewAntlrGalaParser()
+	trans := transpiler.NewGalaToGoTranspiler(p, analyzer.NewGalaAnalyzer(p, getStdSearchPath()),
+		transformer.NewGalaASTTransformer(), generator.NewGoCodeGenerator())
+	got, err := trans.Transpile(`package main
+
+func square(x int) int = x * x
+func compute(a int) int = square(a) + square(a)`, "")
+	assert.NoError(t, err)
+	assert.Equal(t, `package main
+
+func square(x int) int {
+	return x * x
+}
+func compute(a int) int {
+	return square(a) + square(a)
+}`, strings.TrimSpace(stripGeneratedHeader(got)))
+}
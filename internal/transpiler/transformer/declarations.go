@@ -13,9 +13,14 @@ import (
 	"github.com/antlr4-go/antlr/v4"
 )
 
-func (t *galaASTTransformer) transformTopLevelDeclaration(ctx grammar.ITopLevelDeclarationContext) ([]ast.Decl, error) {
+func (t *galaASTTransformer) transformTopLevelDeclaration(ctx grammar.ITopLevelDeclarationContext) (_ []ast.Decl, err error) {
+	defer func() { err = t.locate(ctx, err) }()
 	if valCtx := ctx.ValDeclaration(); valCtx != nil {
-		decl, err := t.transformValDeclaration(valCtx.(*grammar.ValDeclarationContext))
+		transform := t.transformValDeclaration
+		if valCtx.TuplePattern() != nil {
+			transform = t.transformTopLevelValTuplePattern
+		}
+		decl, err := transform(valCtx.(*grammar.ValDeclarationContext))
 		if err != nil {
 			return nil, err
 		}
@@ -29,7 +34,12 @@ func (t *galaASTTransformer) transformTopLevelDeclaration(ctx grammar.ITopLevelD
 		return []ast.Decl{decl}, nil
 	}
 	if funcCtx := ctx.FunctionDeclaration(); funcCtx != nil {
+		// Temps are local to the function, so its numbering restarts; package-level
+		// temps keep counting on the file-wide counter.
+		fileTemps := t.tempVarCount
+		t.tempVarCount = 0
 		decl, err := t.transformFunctionDeclaration(funcCtx.(*grammar.FunctionDeclarationContext))
+		t.tempVarCount = fileTemps
 		if err != nil {
 			return nil, err
 		}
@@ -44,9 +54,42 @@ func (t *galaASTTransformer) transformTopLevelDeclaration(ctx grammar.ITopLevelD
 	if sealedCtx := ctx.SealedTypeDeclaration(); sealedCtx != nil {
 		return t.transformSealedTypeDeclaration(sealedCtx.(*grammar.SealedTypeDeclarationContext))
 	}
+	if newtypeCtx := ctx.NewtypeDeclaration(); newtypeCtx != nil {
+		return t.transformNewtypeDeclaration(newtypeCtx.(*grammar.NewtypeDeclarationContext))
+	}
+	if initCtx := ctx.InitBlock(); initCtx != nil {
+		fileTemps := t.tempVarCount
+		t.tempVarCount = 0
+		decl, err := t.transformInitBlock(initCtx.(*grammar.InitBlockContext))
+		t.tempVarCount = fileTemps
+		if err != nil {
+			return nil, err
+		}
+		return []ast.Decl{decl}, nil
+	}
 	return nil, nil
 }
 
+// transformInitBlock turns init { ... } into a Go init function. Go runs the
+// init functions of a package after all its package-level variables are
+// initialized, in the order their files are given to the compiler and in
+// source order within a file.
+func (t *galaASTTransformer) transformInitBlock(ctx *grammar.InitBlockContext) (ast.Decl, error) {
+	prevFuncReturnType := t.currentFuncReturnType
+	t.currentFuncReturnType = nil
+	defer func() { t.currentFuncReturnType = prevFuncReturnType }()
+
+	body, err := t.transformBlock(ctx.Block().(*grammar.BlockContext))
+	if err != nil {
+		return nil, err
+	}
+	return &ast.FuncDecl{
+		Name: ast.NewIdent("init"),
+		Type: &ast.FuncType{Params: &ast.FieldList{}},
+		Body: body,
+	}, nil
+}
+
 func (t *galaASTTransformer) transformDeclaration(ctx grammar.IDeclarationContext) (ast.Decl, ast.Stmt, error) {
 	if valCtx := ctx.ValDeclaration(); valCtx != nil {
 		decl, err := t.transformValDeclaration(valCtx.(*grammar.ValDeclarationContext))
@@ -142,7 +185,7 @@ func (t *galaASTTransformer) transformValDeclaration(ctx *grammar.ValDeclaration
 				typeExpr, _ := t.transformType(ctx.Type_())
 				typeName = t.exprToType(typeExpr)
 				if t.isImmutableType(typeName) {
-					panic(galaerr.NewSemanticError("recursive Immutable wrapping is not allowed"))
+					panic(galaerr.NewSemanticError("recursive Immutable wrapping is not allowed").WithCode(galaerr.CodeNestedImmutable))
 				}
 			}
 
@@ -203,7 +246,7 @@ func (t *galaASTTransformer) transformValDeclaration(ctx *grammar.ValDeclaration
 			typeExpr, _ := t.transformType(ctx.Type_())
 			typeName = t.exprToType(typeExpr)
 			if t.isImmutableType(typeName) {
-				panic(galaerr.NewSemanticError("recursive Immutable wrapping is not allowed"))
+				panic(galaerr.NewSemanticError("recursive Immutable wrapping is not allowed").WithCode(galaerr.CodeNestedImmutable))
 			}
 		} else if len(rhsExprs) == len(namesCtx) {
 			typeName = t.getExprTypeName(rhsExprs[i])
@@ -229,7 +272,12 @@ func (t *galaASTTransformer) transformValDeclaration(ctx *grammar.ValDeclaration
 		}
 
 		if t.isNoneCall(val) && ctx.Type_() == nil {
-			return nil, t.semanticErrorAt(ctx, "variable assigned to None() must have an explicit type")
+			return nil, t.semanticErrorAt(ctx, "variable assigned to None() must have an explicit type").WithCode(galaerr.CodeUntypedNone)
+		}
+		if ctx.Type_() != nil {
+			if err := t.checkErasedTypeArg(ctx.ExpressionList(), val, typeName); err != nil {
+				return nil, err
+			}
 		}
 
 		var fun ast.Expr = t.stdIdent("NewImmutable")
@@ -238,6 +286,10 @@ func (t *galaASTTransformer) transformValDeclaration(ctx *grammar.ValDeclaration
 			if err != nil {
 				return nil, err
 			}
+			// The explicit type argument already types constants; only widen typed values
+			if _, isConst := untypedConstKind(val); !isConst {
+				val = t.convertToNumericType(val, typeName)
+			}
 			fun = &ast.IndexExpr{
 				X:     fun,
 				Index: typeExpr,
@@ -346,6 +398,68 @@ func (t *galaASTTransformer) transformValTuplePattern(ctx *grammar.ValDeclaratio
 	}, nil
 }
 
+// transformTopLevelValTuplePattern handles tuple destructuring at package
+// scope: val (host, port) = parseAddr(cfg). A package-level temp holding the
+// tuple could clash with the temps of the package's other files, so the tuple
+// is taken apart by a function called when the package is initialized:
+//
+//	var host, port = func() (std.Immutable[string], std.Immutable[int]) {
+//	    _tuple_0 := parseAddr(cfg)
+//	    return _tuple_0.V1, _tuple_0.V2
+//	}()
+func (t *galaASTTransformer) transformTopLevelValTuplePattern(ctx *grammar.ValDeclarationContext) (ast.Decl, error) {
+	tupleCtx := ctx.TuplePattern().(*grammar.TuplePatternContext)
+	namesCtx := tupleCtx.IdentifierList().(*grammar.IdentifierListContext).AllIdentifier()
+
+	rhsExprs, err := t.transformExpressionList(ctx.ExpressionList().(*grammar.ExpressionListContext))
+	if err != nil {
+		return nil, err
+	}
+	if len(rhsExprs) != 1 {
+		return nil, galaerr.NewSemanticError("tuple destructuring requires exactly one expression on the right side")
+	}
+
+	// The function's results are spelled out, so the tuple type must be known
+	tupleType, ok := t.getExprTypeName(rhsExprs[0]).(transpiler.GenericType)
+	if !ok || !t.isTupleTypeName(tupleType.Base.BaseName()) || len(tupleType.Params) < len(namesCtx) {
+		return nil, t.semanticErrorAt(ctx, fmt.Sprintf("cannot destructure '%s' at package level: expected a tuple of %d or more values",
+			ctx.ExpressionList().GetText(), len(namesCtx)))
+	}
+
+	tempName := t.nextNamedTempVar("tuple")
+	var idents []*ast.Ident
+	var results []*ast.Field
+	var values []ast.Expr
+	for i, idCtx := range namesCtx {
+		name := idCtx.GetText()
+		componentType := tupleType.Params[i]
+		if t.isImmutableType(componentType) {
+			if gen, ok := componentType.(transpiler.GenericType); ok && len(gen.Params) > 0 {
+				componentType = gen.Params[0]
+			}
+		}
+		if name != "_" {
+			t.addVal(name, componentType)
+		}
+		idents = append(idents, ast.NewIdent(name))
+		// Tuple fields are already Immutable[T], so they are returned as they are
+		results = append(results, &ast.Field{Type: &ast.IndexExpr{X: t.stdIdent(transpiler.TypeImmutable), Index: t.typeToExpr(componentType)}})
+		values = append(values, &ast.SelectorExpr{X: ast.NewIdent(tempName), Sel: ast.NewIdent(fmt.Sprintf("V%d", i+1))})
+	}
+
+	split := &ast.CallExpr{Fun: &ast.FuncLit{
+		Type: &ast.FuncType{Params: &ast.FieldList{}, Results: &ast.FieldList{List: results}},
+		Body: &ast.BlockStmt{List: []ast.Stmt{
+			&ast.AssignStmt{Lhs: []ast.Expr{ast.NewIdent(tempName)}, Tok: token.DEFINE, Rhs: []ast.Expr{t.unwrapImmutable(rhsExprs[0])}},
+			&ast.ReturnStmt{Results: values},
+		}},
+	}}
+	return &ast.GenDecl{
+		Tok:   token.VAR,
+		Specs: []ast.Spec{&ast.ValueSpec{Names: idents, Values: []ast.Expr{split}}},
+	}, nil
+}
+
 func (t *galaASTTransformer) transformVarDeclaration(ctx *grammar.VarDeclarationContext) (ast.Decl, error) {
 	namesCtx := ctx.IdentifierList().(*grammar.IdentifierListContext).AllIdentifier()
 	rhsExprs := make([]ast.Expr, 0)
@@ -358,6 +472,7 @@ func (t *galaASTTransformer) transformVarDeclaration(ctx *grammar.VarDeclaration
 	}
 
 	var idents []*ast.Ident
+	var varTypes []transpiler.Type
 	for i, idCtx := range namesCtx {
 		name := idCtx.GetText()
 		var typeName transpiler.Type = transpiler.NilType{}
@@ -382,6 +497,7 @@ func (t *galaASTTransformer) transformVarDeclaration(ctx *grammar.VarDeclaration
 
 		t.addVar(name, typeName)
 		idents = append(idents, ast.NewIdent(name))
+		varTypes = append(varTypes, typeName)
 	}
 
 	spec := &ast.ValueSpec{
@@ -392,7 +508,7 @@ func (t *galaASTTransformer) transformVarDeclaration(ctx *grammar.VarDeclaration
 		if ctx.Type_() == nil {
 			for _, r := range rhsExprs {
 				if t.isNoneCall(r) {
-					return nil, galaerr.NewSemanticError("variable assigned to None() must have an explicit type")
+					return nil, galaerr.NewSemanticError("variable assigned to None() must have an explicit type").WithCode(galaerr.CodeUntypedNone)
 				}
 			}
 		}
@@ -400,6 +516,11 @@ func (t *galaASTTransformer) transformVarDeclaration(ctx *grammar.VarDeclaration
 		unwrappedRhs := make([]ast.Expr, len(rhsExprs))
 		for i, r := range rhsExprs {
 			unwrappedRhs[i] = t.unwrapImmutable(r)
+			if ctx.Type_() != nil && len(rhsExprs) == len(namesCtx) {
+				if err := t.checkErasedTypeArg(ctx.ExpressionList(), unwrappedRhs[i], varTypes[i]); err != nil {
+					return nil, err
+				}
+			}
 		}
 		spec.Values = unwrappedRhs
 	}
@@ -438,6 +559,10 @@ func (t *galaASTTransformer) transformFunctionDeclaration(ctx *grammar.FunctionD
 
 		receiverType := t.resolveType(t.getBaseTypeName(recvTypeExpr))
 		receiverBaseName := receiverType.BaseName()
+		if meta := t.getTypeMeta(receiverBaseName); meta != nil && meta.AliasOf != nil && isPointerOrInterface(meta.AliasOf) {
+			return nil, t.semanticErrorAt(ctx, fmt.Sprintf("cannot declare method %s on %s: it is defined as the pointer or interface type %s, which Go allows no methods on",
+				name, meta.Name, meta.AliasOf.String()))
+		}
 
 		// For non-pointer receivers, try to preserve type parameters for lambda type inference
 		// Pointer receivers keep using the simple type to avoid field lookup issues
@@ -597,6 +722,9 @@ func (t *galaASTTransformer) transformFunctionDeclaration(ctx *grammar.FunctionD
 			return nil, err
 		}
 		if funcType.Results != nil && len(funcType.Results.List) > 0 {
+			if err := t.checkErasedTypeArg(ctx.Expression(), expr, t.currentFuncReturnType); err != nil {
+				return nil, err
+			}
 			expr = t.wrapWithAssertion(expr, funcType.Results.List[0].Type)
 		}
 		body = &ast.BlockStmt{
@@ -697,11 +825,13 @@ func (t *galaASTTransformer) transformStructShorthandDeclaration(ctx *grammar.St
 	}
 
 	// Copy and Equal methods
-	copyMethod, err := t.generateCopyMethod(name, fields, nil)
-	if err != nil {
-		return nil, err
+	if !t.typeHasAnnotation(name, transpiler.AnnotationNoCopy) {
+		copyMethod, err := t.generateCopyMethod(name, fields, nil)
+		if err != nil {
+			return nil, err
+		}
+		decls = append(decls, copyMethod)
 	}
-	decls = append(decls, copyMethod)
 
 	equalMethod, err := t.generateEqualMethod(name, fields, nil)
 	if err != nil {
@@ -709,6 +839,10 @@ func (t *galaASTTransformer) transformStructShorthandDeclaration(ctx *grammar.St
 	}
 	decls = append(decls, equalMethod)
 
+	if t.typeHasAnnotation(name, transpiler.AnnotationGoStruct) {
+		decls = append(decls, t.generateGoStruct(name, fields, nil)...)
+	}
+
 	// Check if Unapply already exists
 	hasUnapply := false
 	if meta := t.getTypeMeta(name); meta != nil {
@@ -727,6 +861,14 @@ func (t *galaASTTransformer) transformStructShorthandDeclaration(ctx *grammar.St
 		}
 	}
 
+	if meta := t.getTypeMeta(name); meta != nil && len(meta.Invariants) > 0 {
+		applyMethod, err := t.generateSmartConstructor(name, paramsCtx, meta.Invariants, immutFlags)
+		if err != nil {
+			return nil, err
+		}
+		decls = append(decls, applyMethod)
+	}
+
 	return decls, nil
 }
 
@@ -800,11 +942,13 @@ func (t *galaASTTransformer) transformTypeDeclaration(ctx *grammar.TypeDeclarati
 		})
 
 		// Methods
-		copyMethod, err := t.generateCopyMethod(name, fields, tParams)
-		if err != nil {
-			return nil, err
+		if !t.typeHasAnnotation(name, transpiler.AnnotationNoCopy) {
+			copyMethod, err := t.generateCopyMethod(name, fields, tParams)
+			if err != nil {
+				return nil, err
+			}
+			decls = append(decls, copyMethod)
 		}
-		decls = append(decls, copyMethod)
 
 		equalMethod, err := t.generateEqualMethod(name, fields, tParams)
 		if err != nil {
@@ -812,6 +956,10 @@ func (t *galaASTTransformer) transformTypeDeclaration(ctx *grammar.TypeDeclarati
 		}
 		decls = append(decls, equalMethod)
 
+		if t.typeHasAnnotation(name, transpiler.AnnotationGoStruct) {
+			decls = append(decls, t.generateGoStruct(name, fields, tParams)...)
+		}
+
 		// For generic structs, generate marker interface for wildcard pattern matching
 		if tParams != nil {
 			interfaceDecl, markerMethod := t.generateInstanceMarker(name, tParams)
@@ -852,14 +1000,46 @@ func (t *galaASTTransformer) transformTypeDeclaration(ctx *grammar.TypeDeclarati
 			Tok:   token.TYPE,
 			Specs: []ast.Spec{typeSpec},
 		})
-	} else if ctx.TypeAlias() != nil {
-		// TODO: implement
-		return nil, galaerr.NewSemanticError("type alias not implemented yet")
+	} else if alias := ctx.TypeAlias(); alias != nil {
+		// type Dollars int is a Go defined type: it converts to and from int
+		// explicitly and can have methods of its own. type UserId = string is
+		// an alias, another name for string
+		aliasOf, err := t.transformType(alias.Type_())
+		if err != nil {
+			return nil, err
+		}
+		spec := &ast.TypeSpec{
+			Name:       ast.NewIdent(name),
+			TypeParams: tParams,
+			Type:       aliasOf,
+		}
+		if alias.GetAlias() != nil {
+			if tParams != nil && !t.goVersion.SupportsGenericAliases() {
+				return nil, t.semanticErrorAt(ctx, fmt.Sprintf("generic type alias '%s' requires Go 1.24 or later (target is %s)", name, t.goVersion)).WithCode(galaerr.CodeGoVersion)
+			}
+			spec.Assign = 1
+		}
+		decls = append(decls, &ast.GenDecl{
+			Tok:   token.TYPE,
+			Specs: []ast.Spec{spec},
+		})
 	}
 
 	return decls, nil
 }
 
+// isPointerOrInterface reports whether typ is a pointer type or one of the
+// predeclared interface types, which Go allows no methods on.
+func isPointerOrInterface(typ transpiler.Type) bool {
+	switch typ := typ.(type) {
+	case transpiler.PointerType:
+		return true
+	case transpiler.BasicType:
+		return typ.Name == "any" || typ.Name == "error"
+	}
+	return false
+}
+
 func (t *galaASTTransformer) transformImportDeclaration(ctx *grammar.ImportDeclarationContext) (ast.Decl, error) {
 	// import "pkg"  or import ( "pkg1" "pkg2" )
 	var specs []ast.Spec
@@ -873,7 +1053,7 @@ func (t *galaASTTransformer) transformImportDeclaration(ctx *grammar.ImportDecla
 			alias := s.Identifier().GetText()
 			importSpec.Name = ast.NewIdent(alias)
 			t.importManager.Add(path, alias, false, "")
-		} else if s.GetChildCount() > 1 {
+		} else if s.GetChildCount() > 1 && s.ImportSelectors() == nil {
 			// Check for '.'
 			if dot := s.GetChild(0); dot != nil {
 				if terminal, ok := dot.(antlr.TerminalNode); ok && terminal.GetText() == "." {
@@ -885,6 +1065,12 @@ func (t *galaASTTransformer) transformImportDeclaration(ctx *grammar.ImportDecla
 			// No alias, use the last part of path as package name
 			t.importManager.Add(path, "", false, "")
 		}
+		if sels := s.ImportSelectors(); sels != nil {
+			for _, sel := range sels.(*grammar.ImportSelectorsContext).AllImportSelector() {
+				ids := sel.(*grammar.ImportSelectorContext).AllIdentifier()
+				t.importManager.Select(path, ids[len(ids)-1].GetText(), ids[0].GetText())
+			}
+		}
 		specs = append(specs, importSpec)
 	}
 	return &ast.GenDecl{
@@ -909,6 +1095,16 @@ func (t *galaASTTransformer) transformParameter(ctx *grammar.ParameterContext) (
 	if qName := t.getType(typeName.String()); !qName.IsNil() {
 		typeName = qName
 	}
+	if ctx.GetByName() != nil {
+		// x => T is passed as func() T and called wherever x is read
+		typ, err := t.transformType(ctx.Type_())
+		if err != nil {
+			return nil, err
+		}
+		t.addVar(name, transpiler.FuncType{Results: []transpiler.Type{typeName}, ByName: true})
+		field.Type = &ast.FuncType{Params: &ast.FieldList{}, Results: &ast.FieldList{List: []*ast.Field{{Type: typ}}}}
+		return field, nil
+	}
 	if isVal {
 		t.addVal(name, typeName)
 	} else {
@@ -971,6 +1167,13 @@ func (t *galaASTTransformer) transformStructField(ctx *grammar.StructFieldContex
 	if ctx.STRING() != nil {
 		field.Tag = &ast.BasicLit{Kind: token.STRING, Value: ctx.STRING().GetText()}
 	}
+
+	// Functions have no meaningful equality, so Equal skips them (see fieldEqualExpr)
+	if _, isFunc := typ.(*ast.FuncType); isFunc {
+		if _, ignored := findAnnotation(ctx.AllAnnotation(), transpiler.AnnotationEqualIgnore); !ignored {
+			t.warnAt(ctx, fmt.Sprintf("function field %s is left out of the generated Equal; annotate it with @equalIgnore to make this explicit", name))
+		}
+	}
 	return field, nil
 }
 
@@ -992,6 +1195,9 @@ func (t *galaASTTransformer) transformSignature(ctx *grammar.SignatureContext, t
 	fieldList := &ast.FieldList{}
 	if paramsCtx.ParameterList() != nil {
 		for _, pCtx := range paramsCtx.ParameterList().(*grammar.ParameterListContext).AllParameter() {
+			if err := t.checkNoRequire(pCtx.(*grammar.ParameterContext)); err != nil {
+				return nil, err
+			}
 			field, err := t.transformParameter(pCtx.(*grammar.ParameterContext))
 			if err != nil {
 				return nil, err
@@ -39,17 +39,19 @@ type Person struct {
 }
 
 func (s Person) Copy() Person {
-	return Person{Name: std.Copy(s.Name)}
+	return Person{Name: s.Name}
 }
 func (s Person) Equal(other Person) bool {
 	return std.Equal(s.Name, other.Name)
 }
 func (s Person) Unapply(v any) (std.Immutable[string], bool) {
-	if p, ok := v.(Person); ok {
-		return p.Name, true
-	}
-	if p, ok := v.(*Person); ok && p != nil {
+	switch p := v.(type) {
+	case Person:
 		return p.Name, true
+	case *Person:
+		if p != nil {
+			return p.Name, true
+		}
 	}
 	return *new(std.Immutable[string]), false
 }
@@ -71,7 +73,7 @@ type Box[T any] struct {
 }
 
 func (s Box[T]) Copy() Box[T] {
-	return Box[T]{Value: std.Copy(s.Value)}
+	return Box[T]{Value: s.Value}
 }
 func (s Box[T]) Equal(other Box[T]) bool {
 	return std.Equal(s.Value, other.Value)
@@ -85,11 +87,13 @@ func (_ Box[T]) IsBox() bool {
 	return true
 }
 func (s Box[T]) Unapply(v any) (std.Immutable[T], bool) {
-	if p, ok := v.(Box[T]); ok {
-		return p.Value, true
-	}
-	if p, ok := v.(*Box[T]); ok && p != nil {
+	switch p := v.(type) {
+	case Box[T]:
 		return p.Value, true
+	case *Box[T]:
+		if p != nil {
+			return p.Value, true
+		}
 	}
 	return *new(std.Immutable[T]), false
 }
@@ -117,11 +121,13 @@ func (s Counter) Equal(other Counter) bool {
 	return std.Equal(s.Count, other.Count)
 }
 func (s Counter) Unapply(v any) (int, bool) {
-	if p, ok := v.(Counter); ok {
-		return p.Count, true
-	}
-	if p, ok := v.(*Counter); ok && p != nil {
+	switch p := v.(type) {
+	case Counter:
 		return p.Count, true
+	case *Counter:
+		if p != nil {
+			return p.Count, true
+		}
 	}
 	return *new(int), false
 }
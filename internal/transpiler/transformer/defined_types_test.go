@@ -0,0 +1,169 @@
+package transformer_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"martianoff/gala/internal/transpiler"
+	"martianoff/gala/internal/transpiler/analyzer"
+	"martianoff/gala/internal/transpiler/generator"
+	"martianoff/gala/internal/transpiler/transformer"
+)
+
+func TestDefinedTypeMethods(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		contains []string
+		wantErr  string
+	}{
+		{
+			name: "Method on a primitive-backed type",
+			input: `package main
+
+type Dollars int
+
+func (d Dollars) Plus(o Dollars) Dollars = d + o
+
+func total() Dollars = Dollars(5).Plus(Dollars(7))`,
+			contains: []string{
+				"type Dollars int",
+				"func (d Dollars) Plus(o Dollars) Dollars {\n\treturn d + o\n}",
+				"Dollars(5).Plus(Dollars(7))",
+			},
+		},
+		{
+			name: "Generic method",
+			input: `package main
+
+import "fmt"
+
+type Dollars int
+
+func (d Dollars) Format[T any](f func(int) T) T = f(int(d))
+
+func label(d Dollars) string = d.Format[string]((n int) => fmt.Sprintf("$%d", n))`,
+			contains: []string{
+				"func Dollars_Format[T any](d Dollars, f func(int) T) T {",
+				"Dollars_Format[string](d, ",
+			},
+		},
+		{
+			name: "Method on a newtype",
+			input: `package main
+
+newtype Cents = int64
+
+func (c Cents) Dollars() float64 = float64(c.Value()) / 100.0
+
+func price(c Cents) float64 = c.Dollars()`,
+			contains: []string{
+				"func (c Cents) Dollars() float64 {",
+				"return c.Dollars()",
+			},
+		},
+		{
+			name: "Generic defined type",
+			input: `package main
+
+type Stack[T any] []T
+
+func (s Stack[T]) Push(x T) Stack[T] = append(s, x)`,
+			contains: []string{
+				"type Stack[T any] []T",
+				"func (s Stack[T]) Push(x T) Stack[T] {",
+			},
+		},
+		{
+			name: "Pointer-backed type",
+			input: `package main
+
+type Ref *int
+
+func (r Ref) Deref() int = *r`,
+			wantErr: "cannot declare method Deref on Ref: it is defined as the pointer or interface type *int",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := transpiler.NewAntlrGalaParser()
+			trans := transpiler.NewGalaToGoTranspiler(p, analyzer.NewGalaAnalyzer(p, getStdSearchPath()),
+				transformer.NewGalaASTTransformer(), generator.NewGoCodeGenerator())
+			got, err := trans.Transpile(tt.input, "")
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			for _, want := range tt.contains {
+				assert.Contains(t, got, want)
+			}
+		})
+	}
+}
+
+func TestTypeAliases(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		contains []string
+	}{
+		{
+			name: "Alias of a primitive type",
+			input: `package main
+
+type UserId = string
+
+func greet(id UserId) string = "hello " + id
+
+func admin() UserId = UserId("root")`,
+			contains: []string{
+				"type UserId = string",
+				"func greet(id UserId) string {",
+				`UserId("root")`,
+			},
+		},
+		{
+			name: "Fields are looked up on the aliased struct",
+			input: `package main
+
+struct Point(X int, Y int)
+
+type Position = Point
+
+func x(p Position) int = p.X`,
+			contains: []string{
+				"type Position = Point",
+				"return p.X.Get()",
+			},
+		},
+		{
+			name: "Generic alias",
+			input: `package main
+
+type Result[T any] = Either[error, T]
+
+func doubled(r Result[int]) Result[int] = r.Map((n) => n * 2)`,
+			contains: []string{
+				"type Result[T any] = std.Either[error, T]",
+				"std.Either_Map[",
+				"func(n int) int {",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := transpiler.NewAntlrGalaParser()
+			trans := transpiler.NewGalaToGoTranspiler(p, analyzer.NewGalaAnalyzer(p, getStdSearchPath()),
+				transformer.NewGalaASTTransformer(), generator.NewGoCodeGenerator())
+			got, err := trans.Transpile(tt.input, "")
+			assert.NoError(t, err)
+			for _, want := range tt.contains {
+				assert.Contains(t, got, want)
+			}
+		})
+	}
+}
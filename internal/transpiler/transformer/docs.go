@@ -0,0 +1,132 @@
+package transformer
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+)
+
+// This file carries GALA doc comments over to the generated Go declarations.
+//
+// The lexer skips comments, so doc comments are recovered from the source text:
+// a run of `//` lines, or a `/* ... */` block, that ends on the line directly above
+// a top-level declaration. go/printer only places a Doc group correctly when the
+// comment and the declaration have positions, so documented declarations get
+// positions in a synthetic file while everything else keeps token.NoPos.
+
+// pendingDoc pairs a generated declaration with the doc comment lines of its source.
+type pendingDoc struct {
+	decl  ast.Decl
+	lines []string
+}
+
+// docCommentLines returns the doc comment directly above ctx as Go `//` lines,
+// or nil when the declaration is undocumented.
+func (t *galaASTTransformer) docCommentLines(ctx antlr.ParserRuleContext) []string {
+	if t.sourceLines == nil || ctx.GetStart() == nil {
+		return nil
+	}
+	i := ctx.GetStart().GetLine() - 2
+	if i < 0 || i >= len(t.sourceLines) {
+		return nil
+	}
+
+	if strings.HasSuffix(strings.TrimSpace(t.sourceLines[i]), "*/") {
+		end := i
+		for i >= 0 && !strings.HasPrefix(strings.TrimSpace(t.sourceLines[i]), "/*") {
+			i--
+		}
+		if i < 0 {
+			return nil
+		}
+		return blockCommentToLines(t.sourceLines[i : end+1])
+	}
+
+	var lines []string
+	for ; i >= 0; i-- {
+		line := strings.TrimSpace(t.sourceLines[i])
+		if !strings.HasPrefix(line, "//") {
+			break
+		}
+		lines = append([]string{line}, lines...)
+	}
+	return lines
+}
+
+// blockCommentToLines converts the source lines of a /* ... */ or /** ... */ comment
+// into `//` lines, dropping the delimiters and leading asterisks.
+func blockCommentToLines(src []string) []string {
+	var body []string
+	for idx, raw := range src {
+		line := strings.TrimSpace(raw)
+		if idx == 0 {
+			line = strings.TrimPrefix(strings.TrimPrefix(line, "/**"), "/*")
+		}
+		if idx == len(src)-1 {
+			line = strings.TrimSuffix(line, "*/")
+		}
+		line = strings.TrimSpace(line)
+		if line != "*" && strings.HasPrefix(line, "* ") {
+			line = line[2:]
+		} else if line == "*" {
+			line = ""
+		}
+		body = append(body, line)
+	}
+	for len(body) > 0 && body[0] == "" {
+		body = body[1:]
+	}
+	for len(body) > 0 && body[len(body)-1] == "" {
+		body = body[:len(body)-1]
+	}
+	lines := make([]string, len(body))
+	for i, line := range body {
+		if line == "" {
+			lines[i] = "//"
+		} else {
+			lines[i] = "// " + line
+		}
+	}
+	return lines
+}
+
+// attachDocs sets the Doc field of each documented declaration and gives the
+// comment and declaration consecutive line positions in a synthetic file.
+func (t *galaASTTransformer) attachDocs(fset *token.FileSet, docs []pendingDoc) {
+	if len(docs) == 0 {
+		return
+	}
+	lineCount := 0
+	for _, d := range docs {
+		lineCount += len(d.lines) + 2
+	}
+	file := syntheticFile(fset, "gala-docs", lineCount)
+
+	line := 1
+	for _, d := range docs {
+		group := &ast.CommentGroup{}
+		for _, text := range d.lines {
+			group.List = append(group.List, &ast.Comment{Slash: file.LineStart(line), Text: text})
+			line++
+		}
+		declPos := file.LineStart(line)
+		endPos := file.LineStart(line + 1)
+		line += 2
+
+		switch decl := d.decl.(type) {
+		case *ast.FuncDecl:
+			decl.Doc = group
+			decl.Type.Func = declPos
+			if decl.Body != nil {
+				// Distinct brace lines keep the printer from collapsing short bodies onto one line
+				decl.Body.Lbrace = declPos
+				decl.Body.Rbrace = endPos
+			}
+		case *ast.GenDecl:
+			decl.Doc = group
+			decl.TokPos = declPos
+		}
+	}
+}
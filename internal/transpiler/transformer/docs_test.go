@@ -0,0 +1,105 @@
+package transformer_test
+
+import (
+	"martianoff/gala/internal/transpiler"
+	"martianoff/gala/internal/transpiler/analyzer"
+	"martianoff/gala/internal/transpiler/generator"
+	"martianoff/gala/internal/transpiler/transformer"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocComments(t *testing.T) {
+	p := transpiler.NewAntlrGalaParser()
+	a := analyzer.NewGalaAnalyzer(p, getStdSearchPath())
+	tr := transformer.NewGalaASTTransformer()
+	g := generator.NewGoCodeGenerator()
+	trans := transpiler.NewGalaToGoTranspiler(p, a, tr, g)
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name: "line comment on function",
+			input: `package main
+
+// Add returns the sum of a and b.
+// It never overflows in tests.
+func Add(a int, b int) int = a + b`,
+			expected: `package main
+
+// Add returns the sum of a and b.
+// It never overflows in tests.
+func Add(a int, b int) int {
+	return a + b
+}
+`,
+		},
+		{
+			name: "block comment on struct",
+			input: `package main
+
+/**
+ * Point is a 2D point.
+ */
+type Point struct {
+    X int
+    Y int
+}`,
+			expected: `package main
+
+import "martianoff/gala/std"
+
+// Point is a 2D point.
+type Point struct {
+	X std.Immutable[int]
+	Y std.Immutable[int]
+}
+
+func (s Point) Copy() Point {
+	return Point{X: s.X, Y: s.Y}
+}
+func (s Point) Equal(other Point) bool {
+	return std.Equal(s.X, other.X) && std.Equal(s.Y, other.Y)
+}
+func (s Point) Unapply(v any) (std.Immutable[int], std.Immutable[int], bool) {
+	switch p := v.(type) {
+	case Point:
+		return p.X, p.Y, true
+	case *Point:
+		if p != nil {
+			return p.X, p.Y, true
+		}
+	}
+	return *new(std.Immutable[int]), *new(std.Immutable[int]), false
+}
+`,
+		},
+		{
+			name: "comment separated by blank line is not a doc comment",
+			input: `package main
+
+// Section header
+
+func One() int = 1`,
+			expected: `package main
+
+func One() int {
+	return 1
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := trans.Transpile(tt.input, "")
+			assert.NoError(t, err)
+			assert.Equal(t, strings.TrimSpace(tt.expected), strings.TrimSpace(stripGeneratedHeader(got)))
+		})
+	}
+}
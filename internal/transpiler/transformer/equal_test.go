@@ -38,7 +38,7 @@ type Point struct {
 }
 
 func (s Point) Copy() Point {
-	return Point{x: std.Copy(s.x), y: std.Copy(s.y)}
+	return Point{x: s.x, y: s.y}
 }
 func (s Point) Equal(other Point) bool {
 	return std.Equal(s.x, other.x) && std.Equal(s.y, other.y)
@@ -62,10 +62,8 @@ func (s Empty) Equal(other Empty) bool {
 	return true
 }
 func (s Empty) Unapply(v any) bool {
-	if _, ok := v.(Empty); ok {
-		return true
-	}
-	if _, ok := v.(*Empty); ok {
+	switch v.(type) {
+	case Empty, *Empty:
 		return true
 	}
 	return false
@@ -90,17 +88,19 @@ type Mixed struct {
 }
 
 func (s Mixed) Copy() Mixed {
-	return Mixed{Name: std.Copy(s.Name), Age: std.Copy(s.Age)}
+	return Mixed{Name: s.Name, Age: std.Copy(s.Age)}
 }
 func (s Mixed) Equal(other Mixed) bool {
 	return std.Equal(s.Name, other.Name) && std.Equal(s.Age, other.Age)
 }
 func (s Mixed) Unapply(v any) (std.Immutable[string], int, bool) {
-	if p, ok := v.(Mixed); ok {
-		return p.Name, p.Age, true
-	}
-	if p, ok := v.(*Mixed); ok && p != nil {
+	switch p := v.(type) {
+	case Mixed:
 		return p.Name, p.Age, true
+	case *Mixed:
+		if p != nil {
+			return p.Name, p.Age, true
+		}
 	}
 	return *new(std.Immutable[string]), *new(int), false
 }
@@ -130,11 +130,13 @@ func (s Mutable) Equal(other Mutable) bool {
 	return std.Equal(s.X, other.X) && std.Equal(s.Y, other.Y)
 }
 func (s Mutable) Unapply(v any) (int, int, bool) {
-	if p, ok := v.(Mutable); ok {
-		return p.X, p.Y, true
-	}
-	if p, ok := v.(*Mutable); ok && p != nil {
+	switch p := v.(type) {
+	case Mutable:
 		return p.X, p.Y, true
+	case *Mutable:
+		if p != nil {
+			return p.X, p.Y, true
+		}
 	}
 	return *new(int), *new(int), false
 }
@@ -150,3 +152,35 @@ func (s Mutable) Unapply(v any) (int, int, bool) {
 		})
 	}
 }
+
+func TestEqualMethodFieldKinds(t *testing.T) {
+	p := transpiler.NewAntlrGalaParser()
+	a := analyzer.NewGalaAnalyzer(p, getStdSearchPath())
+	trans := transpiler.NewGalaToGoTranspiler(p, a, transformer.NewGalaASTTransformer(), generator.NewGoCodeGenerator())
+
+	got, err := trans.Transpile(`package main
+
+type Inventory struct {
+	Items []string
+	var Counts map[string]int
+	OnChange func(string)
+	@equalIgnore
+	var Cache map[string]int
+	Owner string
+}`, "")
+	assert.NoError(t, err)
+
+	// Slices and maps are compared element-wise, looking through Immutable
+	assert.Contains(t, got, "std.EqualSlices(s.Items.Get(), other.Items.Get())")
+	assert.Contains(t, got, "std.EqualMaps(s.Counts, other.Counts)")
+	assert.Contains(t, got, "std.Equal(s.Owner, other.Owner)")
+	// Function fields and @equalIgnore fields are left out
+	assert.NotContains(t, got, "other.OnChange")
+	assert.NotContains(t, got, "other.Cache")
+
+	var warnings []string
+	for _, w := range trans.Warnings() {
+		warnings = append(warnings, w.Msg)
+	}
+	assert.Equal(t, []string{"function field OnChange is left out of the generated Equal; annotate it with @equalIgnore to make this explicit"}, warnings)
+}
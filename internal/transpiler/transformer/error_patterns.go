@@ -0,0 +1,59 @@
+package transformer
+
+import (
+	"go/ast"
+	"go/token"
+
+	"martianoff/gala/galaerr"
+	"martianoff/gala/internal/parser/grammar"
+	"martianoff/gala/internal/transpiler"
+)
+
+// This file contains the lowering of error chain patterns (case IsErr(ErrNotFound) => ...)
+// AsErr[T](e) needs no special handling: it is an ordinary generic extractor.
+// Functions: isIsErrExtractor, transformIsErrPattern
+
+// isIsErrExtractor reports whether the extractor name resolves to std.IsErr.
+func (t *galaASTTransformer) isIsErrExtractor(rawName string) bool {
+	_, resolved := t.getTypeMetaResolved(rawName)
+	return resolved == withStdPrefix(transpiler.TypeIsErr)
+}
+
+// transformIsErrPattern lowers IsErr(target) matched against objExpr. Unlike
+// other extractors, the argument is the error to look for in the chain, so it
+// is evaluated as an expression and stored in the extractor:
+//
+//	_tmp := std.IsErr{Target: target}.Unapply(obj)
+func (t *galaASTTransformer) transformIsErrPattern(argList *grammar.ArgumentListContext, objExpr ast.Expr) (ast.Expr, []ast.Stmt, error) {
+	if argList == nil || len(argList.AllArgument()) != 1 {
+		return nil, nil, galaerr.NewSemanticError("IsErr expects exactly one target error, e.g. IsErr(fs.ErrNotExist)")
+	}
+	arg := argList.AllArgument()[0].(*grammar.ArgumentContext)
+	exprPat, ok := arg.Pattern().(*grammar.ExpressionPatternContext)
+	if !ok || arg.Identifier() != nil {
+		return nil, nil, galaerr.NewSemanticError("IsErr expects a target error value, e.g. IsErr(fs.ErrNotExist)")
+	}
+	target, err := t.transformExpression(exprPat.Expression())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resultName := t.nextTempVar()
+	unapplyCall := &ast.AssignStmt{
+		Lhs: []ast.Expr{ast.NewIdent(resultName)},
+		Tok: token.DEFINE,
+		Rhs: []ast.Expr{
+			&ast.CallExpr{
+				Fun: &ast.SelectorExpr{
+					X: &ast.CompositeLit{
+						Type: t.stdIdent(transpiler.TypeIsErr),
+						Elts: []ast.Expr{&ast.KeyValueExpr{Key: ast.NewIdent("Target"), Value: target}},
+					},
+					Sel: ast.NewIdent("Unapply"),
+				},
+				Args: []ast.Expr{objExpr},
+			},
+		},
+	}
+	return ast.NewIdent(resultName), []ast.Stmt{unapplyCall}, nil
+}
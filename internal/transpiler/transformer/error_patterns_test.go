@@ -0,0 +1,80 @@
+package transformer_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"martianoff/gala/internal/transpiler"
+	"martianoff/gala/internal/transpiler/analyzer"
+	"martianoff/gala/internal/transpiler/generator"
+	"martianoff/gala/internal/transpiler/transformer"
+)
+
+func TestErrorChainPatterns(t *testing.T) {
+	const prefix = `package main
+
+import (
+    "errors"
+    "io/fs"
+)
+
+var ErrNotFound = errors.New("not found")
+
+`
+	tests := []struct {
+		name     string
+		input    string
+		contains []string
+		wantErr  string
+	}{
+		{
+			name: "IsErr with a package variable",
+			input: `func describe(err error) string = err match {
+    case IsErr(ErrNotFound) => "missing"
+    case IsErr(fs.ErrPermission) => "denied"
+    case _ => "other"
+}`,
+			contains: []string{
+				"std.IsErr{Target: ErrNotFound}.Unapply(err)",
+				"std.IsErr{Target: fs.ErrPermission}.Unapply(err)",
+			},
+		},
+		{
+			name: "AsErr binds the typed error",
+			input: `func path(err error) string = err match {
+    case AsErr[*fs.PathError](pe) => pe.Path
+    case _ => ""
+}`,
+			contains: []string{
+				"std.AsErr[*fs.PathError]{}.Unapply(err)",
+				"pe.Path",
+			},
+		},
+		{
+			name: "IsErr without a target",
+			input: `func describe(err error) string = err match {
+    case IsErr() => "missing"
+    case _ => "other"
+}`,
+			wantErr: "IsErr expects exactly one target error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := transpiler.NewAntlrGalaParser()
+			trans := transpiler.NewGalaToGoTranspiler(p, analyzer.NewGalaAnalyzer(p, getStdSearchPath()),
+				transformer.NewGalaASTTransformer(), generator.NewGoCodeGenerator())
+			got, err := trans.Transpile(prefix+tt.input, "")
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			for _, want := range tt.contains {
+				assert.Contains(t, got, want)
+			}
+		})
+	}
+}
@@ -12,10 +12,11 @@ import (
 // NOTE: transformCallExpr was removed - it was dead code.
 // Call transformation goes through transformCallWithArgsCtx.
 
-func (t *galaASTTransformer) transformExpression(ctx grammar.IExpressionContext) (ast.Expr, error) {
+func (t *galaASTTransformer) transformExpression(ctx grammar.IExpressionContext) (expr ast.Expr, err error) {
 	if ctx == nil {
 		return nil, nil
 	}
+	defer func() { err = t.locate(ctx, err) }()
 
 	// With the new grammar, expression simply wraps orExpr
 	if orExpr := ctx.OrExpr(); orExpr != nil {
@@ -659,12 +660,7 @@ func (t *galaASTTransformer) unwrapImmutable(expr ast.Expr) ast.Expr {
 
 	typeObj := t.getExprTypeName(expr)
 	if t.isImmutableType(typeObj) {
-		return &ast.CallExpr{
-			Fun: &ast.SelectorExpr{
-				X:   expr,
-				Sel: ast.NewIdent(transpiler.MethodGet),
-			},
-		}
+		return t.immutableGet(expr)
 	}
 	return expr
 }
@@ -0,0 +1,106 @@
+package transformer_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"martianoff/gala/internal/transpiler"
+	"martianoff/gala/internal/transpiler/analyzer"
+	"martianoff/gala/internal/transpiler/generator"
+	"martianoff/gala/internal/transpiler/transformer"
+)
+
+func TestExtractorFunctions(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		contains    []string
+		notContains []string
+		wantErr     string
+	}{
+		{
+			name: "option extractor on a Go type",
+			input: `package main
+
+import "time"
+
+@extractor
+func Date(t time.Time) Option[Tuple3[int, int, int]] = Some((t.Year(), int(t.Month()), t.Day()))
+
+func year(t time.Time) int = t match {
+	case Date(y, _, _) => y
+	case _ => 0
+}
+`,
+			contains: []string{
+				"_date_0 := Date(obj)",
+				"if _date_0.IsDefined() {",
+			},
+			notContains: []string{"Date{}.Unapply"},
+		},
+		{
+			name: "guard extractor",
+			input: `package main
+
+import "net"
+
+@extractor
+func Loopback(ip net.IP) bool = ip.IsLoopback()
+
+func describe(ip net.IP) string = ip match {
+	case Loopback() => "local"
+	case _ => "remote"
+}
+`,
+			contains: []string{"_loopback_0 := Loopback(obj)"},
+		},
+		{
+			name: "more than one parameter",
+			input: `package main
+
+@extractor
+func Between(x int, lo int) bool = x >= lo
+`,
+			wantErr: "@extractor function Between must take exactly one parameter, the matched value, not 2",
+		},
+		{
+			name: "unsupported return type",
+			input: `package main
+
+@extractor
+func Half(x int) int = x / 2
+`,
+			wantErr: "@extractor function Half must return bool or Option[T]",
+		},
+		{
+			name: "type parameters",
+			input: `package main
+
+@extractor
+func Self[T any](x T) Option[T] = Some(x)
+`,
+			wantErr: "@extractor function Self cannot have type parameters",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := transpiler.NewAntlrGalaParser()
+			trans := transpiler.NewGalaToGoTranspiler(p, analyzer.NewGalaAnalyzer(p, getStdSearchPath()),
+				transformer.NewGalaASTTransformer(), generator.NewGoCodeGenerator())
+			got, err := trans.Transpile(tt.input, "")
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			for _, want := range tt.contains {
+				assert.Contains(t, got, want)
+			}
+			for _, unwanted := range tt.notContains {
+				assert.NotContains(t, got, unwanted)
+			}
+		})
+	}
+}
@@ -0,0 +1,346 @@
+package transformer
+
+import (
+	"go/ast"
+	"go/token"
+
+	"martianoff/gala/internal/transpiler"
+)
+
+// This file fuses chains of Array combinators into a single loop when
+// optimizing (-O). A chain such as
+//
+//	xs.Map(f).Filter(p).FoldLeft(0, g)
+//
+// normally builds an intermediate Array for every Map and Filter. Fused, it
+// becomes one loop over xs that applies f, skips the elements p rejects and
+// folds the rest, without building any intermediate Array:
+//
+//	func() int {
+//		_src_0 := xs
+//		_f_1 := f
+//		_f_2 := p
+//		_acc_3 := 0
+//		_f_4 := g
+//		for _i_5 := 0; _i_5 < _src_0.Size(); _i_5++ {
+//			_elem_6 := _f_1(_src_0.Get(_i_5))
+//			if !_f_2(_elem_6) {
+//				continue
+//			}
+//			_acc_3 = _f_4(_acc_3, _elem_6)
+//		}
+//		return _acc_3
+//	}()
+//
+// Chains ending in ForEach are fused the same way, and chains of two or more
+// Map and Filter calls whose result is used as an Array append to one slice
+// that becomes the Array at the end. The arguments are evaluated once, in the
+// order the unfused chain evaluates them, but the functions are called element
+// by element instead of stage by stage. So that no side effect is reordered,
+// a chain is only fused when its Map and Filter functions are pure: lambdas
+// whose bodies the purityChecker accepts, or pure functions. Locals of the
+// enclosing function that are reassigned or have their address taken count as
+// mutable state, since the ForEach or FoldLeft function may change them between
+// elements.
+
+// fusedArrayType is the collection whose combinator chains are fused.
+const fusedArrayType = "collection_immutable.Array"
+
+// fusedStages are the Array methods a fused chain is made of.
+var fusedStages = map[string]bool{
+	"Map":      true,
+	"Filter":   true,
+	"FoldLeft": true,
+	"ForEach":  true,
+}
+
+// WithFusion makes tr, a transformer created by this package, fuse chains of
+// Array combinators as described above.
+func WithFusion(tr transpiler.ASTTransformer) transpiler.ASTTransformer {
+	tr.(*galaASTTransformer).fuse = true
+	return tr
+}
+
+// arrayStage is a recorded combinator call: the method and the type of its
+// result, nil when it is not known.
+type arrayStage struct {
+	method     string
+	resultType transpiler.Type
+}
+
+// recordArrayStage remembers call as a call of method on an Array.
+func (t *galaASTTransformer) recordArrayStage(call *ast.CallExpr, method string) {
+	stage := arrayStage{method: method}
+	if typ := t.getExprTypeName(call); typ != nil && !typ.IsNil() && !typ.IsAny() && !t.hasTypeParams(typ) {
+		stage.resultType = typ
+	}
+	t.arrayStages[call] = stage
+}
+
+// fusedStage is one combinator call of a chain.
+type fusedStage struct {
+	arrayStage
+	args []ast.Expr // arguments other than the receiver
+}
+
+// fuseArrayChains replaces the fusable combinator chains in decls by loops.
+func (t *galaASTTransformer) fuseArrayChains(decls []ast.Decl) {
+	if !t.fuse || len(t.arrayStages) == 0 {
+		return
+	}
+	// A stage whose result another stage consumes is part of a longer chain
+	consumed := make(map[*ast.CallExpr]bool)
+	for call := range t.arrayStages {
+		if recv, ok := stageReceiver(call).(*ast.CallExpr); ok && t.arrayStages[recv].method != "" {
+			consumed[recv] = true
+		}
+	}
+	pc := &purityChecker{t: t, pureFuncs: t.inferPureFuncs(decls)}
+	for _, decl := range decls {
+		pc.locals, pc.pointers = nil, nil
+		if fn, ok := decl.(*ast.FuncDecl); ok {
+			pc.locals = declaredNames(fn)
+			for name := range mutatedNames(fn) {
+				delete(pc.locals, name)
+			}
+			pc.pointers = pc.pointerNames(fn)
+		}
+		slots := exprSlots(decl)
+		// Innermost chains first, so a chain passed as an argument of another
+		// is already fused when the outer one picks up its arguments
+		for i := len(slots) - 1; i >= 0; i-- {
+			call, ok := (*slots[i]).(*ast.CallExpr)
+			if !ok || t.arrayStages[call].method == "" || consumed[call] {
+				continue
+			}
+			if fused := t.fuseChain(pc, call); fused != nil {
+				*slots[i] = fused
+			}
+		}
+	}
+}
+
+// isPureFunc reports whether calling the function value f has no side effects
+// and reads no state that may change between calls: f is a lambda with a pure
+// body or names a pure function.
+func (pc *purityChecker) isPureFunc(f ast.Expr) bool {
+	switch x := f.(type) {
+	case *ast.ParenExpr:
+		return pc.isPureFunc(x.X)
+	case *ast.FuncLit:
+		return pc.isPureBody(x.Body)
+	}
+	return pc.isPureCallee(&ast.CallExpr{Fun: f})
+}
+
+// mutatedNames collects the names fn assigns to after declaring them, or takes
+// the address of.
+func mutatedNames(fn *ast.FuncDecl) map[string]bool {
+	names := make(map[string]bool)
+	mark := func(e ast.Expr) {
+		for {
+			switch x := e.(type) {
+			case *ast.ParenExpr:
+				e = x.X
+			case *ast.SelectorExpr:
+				e = x.X
+			case *ast.IndexExpr:
+				e = x.X
+			case *ast.StarExpr:
+				e = x.X
+			case *ast.Ident:
+				names[x.Name] = true
+				return
+			default:
+				return
+			}
+		}
+	}
+	ast.Inspect(fn, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.AssignStmt:
+			if x.Tok != token.DEFINE {
+				for _, l := range x.Lhs {
+					mark(l)
+				}
+			}
+		case *ast.IncDecStmt:
+			mark(x.X)
+		case *ast.RangeStmt:
+			if x.Tok == token.ASSIGN {
+				mark(x.Key)
+				mark(x.Value)
+			}
+		case *ast.UnaryExpr:
+			if x.Op == token.AND {
+				mark(x.X)
+			}
+		}
+		return true
+	})
+	return names
+}
+
+// stageReceiver returns the Array a combinator call is applied to: the
+// receiver of a method call, or the first argument of the standalone function
+// a generic method is lowered to.
+func stageReceiver(call *ast.CallExpr) ast.Expr {
+	if isStageMethodCall(call) {
+		return call.Fun.(*ast.SelectorExpr).X
+	}
+	if len(call.Args) == 0 {
+		return nil
+	}
+	return call.Args[0]
+}
+
+// isStageMethodCall reports whether call is a method call, xs.Filter(p),
+// rather than a call of a lowered generic method, Array_Map(xs, f).
+func isStageMethodCall(call *ast.CallExpr) bool {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	return ok && fusedStages[sel.Sel.Name]
+}
+
+// arrayChain splits the chain ending in last into its source and its stages,
+// in call order.
+func (t *galaASTTransformer) arrayChain(last *ast.CallExpr) (ast.Expr, []fusedStage) {
+	var stages []fusedStage
+	var src ast.Expr = last
+	for {
+		call, ok := src.(*ast.CallExpr)
+		if !ok || t.arrayStages[call].method == "" {
+			break
+		}
+		stage := fusedStage{arrayStage: t.arrayStages[call], args: call.Args}
+		if !isStageMethodCall(call) && len(call.Args) > 0 {
+			stage.args = call.Args[1:]
+		}
+		stages = append([]fusedStage{stage}, stages...)
+		src = stageReceiver(call)
+	}
+	return src, stages
+}
+
+// fuseChain returns the loop computing the chain ending in last, or nil if the
+// chain has nothing to fuse, its types are unknown or a Map or Filter function
+// is not known to be pure.
+func (t *galaASTTransformer) fuseChain(pc *purityChecker, last *ast.CallExpr) ast.Expr {
+	src, stages := t.arrayChain(last)
+	if src == nil || len(stages) < 2 {
+		return nil
+	}
+	terminal := stages[len(stages)-1]
+	transforms := stages
+	if terminal.method == "FoldLeft" || terminal.method == "ForEach" {
+		transforms = stages[:len(stages)-1]
+	}
+	for _, stage := range transforms {
+		if stage.method != "Map" && stage.method != "Filter" || len(stage.args) != 1 || !pc.isPureFunc(stage.args[0]) {
+			return nil
+		}
+	}
+	// A chain yielding an Array[U] collects the elements in a []U
+	var arrayType transpiler.GenericType
+	switch terminal.method {
+	case "FoldLeft":
+		if len(terminal.args) != 2 || terminal.resultType == nil {
+			return nil
+		}
+	case "ForEach":
+		if len(terminal.args) != 1 {
+			return nil
+		}
+	default:
+		generic, ok := terminal.resultType.(transpiler.GenericType)
+		if !ok || len(generic.Params) != 1 {
+			return nil
+		}
+		if _, ok := t.typeToExpr(generic).(*ast.IndexExpr); !ok {
+			return nil
+		}
+		arrayType = generic
+	}
+
+	var body []ast.Stmt
+	define := func(hint string, value ast.Expr) *ast.Ident {
+		name := ast.NewIdent(t.nextNamedTempVar(hint))
+		body = append(body, &ast.AssignStmt{Lhs: []ast.Expr{name}, Tok: token.DEFINE, Rhs: []ast.Expr{value}})
+		return ast.NewIdent(name.Name)
+	}
+	call := func(fun ast.Expr, args ...ast.Expr) *ast.CallExpr {
+		return &ast.CallExpr{Fun: fun, Args: args}
+	}
+
+	source := define("src", src)
+	funcs := make([]*ast.Ident, len(transforms))
+	for i, stage := range transforms {
+		funcs[i] = define("f", stage.args[0])
+	}
+
+	var result, resultType ast.Expr
+	var acc, out, each *ast.Ident
+	switch terminal.method {
+	case "FoldLeft":
+		acc = define("acc", terminal.args[0])
+		each = define("f", terminal.args[1])
+		result, resultType = acc, t.typeToExpr(terminal.resultType)
+	case "ForEach":
+		each = define("f", terminal.args[0])
+	default:
+		resultType = t.typeToExpr(arrayType)
+		elemType := t.typeToExpr(arrayType.Params[0])
+		out = define("out", call(ast.NewIdent("make"), &ast.ArrayType{Elt: elemType}, &ast.BasicLit{Kind: token.INT, Value: "0"}, call(&ast.SelectorExpr{X: source, Sel: ast.NewIdent("Size")})))
+		result = call(&ast.IndexExpr{X: siblingIdent(resultType.(*ast.IndexExpr).X, "ArrayFromSlice"), Index: elemType}, out)
+	}
+
+	index := ast.NewIdent(t.nextNamedTempVar("i"))
+	var elem ast.Expr = call(&ast.SelectorExpr{X: source, Sel: ast.NewIdent("Get")}, index)
+	var loop []ast.Stmt
+	for i, stage := range transforms {
+		if stage.method == "Map" {
+			elem = call(funcs[i], elem)
+			continue
+		}
+		// Filter needs the element twice, so it gets a name
+		if _, named := elem.(*ast.Ident); !named {
+			name := ast.NewIdent(t.nextNamedTempVar("elem"))
+			loop = append(loop, &ast.AssignStmt{Lhs: []ast.Expr{name}, Tok: token.DEFINE, Rhs: []ast.Expr{elem}})
+			elem = ast.NewIdent(name.Name)
+		}
+		loop = append(loop, &ast.IfStmt{
+			Cond: &ast.UnaryExpr{Op: token.NOT, X: call(funcs[i], elem)},
+			Body: &ast.BlockStmt{List: []ast.Stmt{&ast.BranchStmt{Tok: token.CONTINUE}}},
+		})
+	}
+	switch {
+	case acc != nil:
+		loop = append(loop, &ast.AssignStmt{Lhs: []ast.Expr{ast.NewIdent(acc.Name)}, Tok: token.ASSIGN, Rhs: []ast.Expr{call(each, acc, elem)}})
+	case out != nil:
+		loop = append(loop, &ast.AssignStmt{Lhs: []ast.Expr{ast.NewIdent(out.Name)}, Tok: token.ASSIGN, Rhs: []ast.Expr{call(ast.NewIdent("append"), out, elem)}})
+	default:
+		loop = append(loop, &ast.ExprStmt{X: call(each, elem)})
+	}
+
+	body = append(body, &ast.ForStmt{
+		Init: &ast.AssignStmt{Lhs: []ast.Expr{index}, Tok: token.DEFINE, Rhs: []ast.Expr{&ast.BasicLit{Kind: token.INT, Value: "0"}}},
+		Cond: &ast.BinaryExpr{X: ast.NewIdent(index.Name), Op: token.LSS, Y: call(&ast.SelectorExpr{X: source, Sel: ast.NewIdent("Size")})},
+		Post: &ast.IncDecStmt{X: ast.NewIdent(index.Name), Tok: token.INC},
+		Body: &ast.BlockStmt{List: loop},
+	})
+	funcType := &ast.FuncType{Params: &ast.FieldList{}}
+	if result != nil {
+		body = append(body, &ast.ReturnStmt{Results: []ast.Expr{result}})
+		funcType.Results = &ast.FieldList{List: []*ast.Field{{Type: resultType}}}
+	}
+	return &ast.CallExpr{Fun: &ast.FuncLit{Type: funcType, Body: &ast.BlockStmt{List: body}}}
+}
+
+// siblingIdent returns name qualified like typ, a type of the package
+// declaring Array: Array gives name, collection_immutable.Array gives
+// collection_immutable.name.
+func siblingIdent(typ ast.Expr, name string) ast.Expr {
+	if sel, ok := typ.(*ast.SelectorExpr); ok {
+		return &ast.SelectorExpr{X: sel.X, Sel: ast.NewIdent(name)}
+	}
+	return ast.NewIdent(name)
+}
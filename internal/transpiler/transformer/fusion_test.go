@@ -0,0 +1,162 @@
+package transformer_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"martianoff/gala/internal/transpiler"
+	"martianoff/gala/internal/transpiler/analyzer"
+	"martianoff/gala/internal/transpiler/generator"
+	"martianoff/gala/internal/transpiler/transformer"
+)
+
+func TestArrayChainFusion(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		contains    []string
+		notContains []string
+	}{
+		{
+			name: "map and filter folded",
+			input: `package main
+
+import . "martianoff/gala/collection_immutable"
+
+func sumOfEvenSquares(xs Array[int]) int =
+    xs.Map((x) => x * x).Filter((x) => x % 2 == 0).FoldLeft(0, (acc, x) => acc + x)
+`,
+			contains: []string{
+				"return func() int {",
+				".Size(); _i_",
+				"continue",
+			},
+			notContains: []string{"Array_Map(", ".Filter(", "Array_FoldLeft("},
+		},
+		{
+			name: "filter and map collected into an array",
+			input: `package main
+
+import . "martianoff/gala/collection_immutable"
+
+func doubledPositives(xs Array[int]) Array[int] = xs.Filter((x) => x > 0).Map((x) => x * 2)
+`,
+			contains: []string{
+				"return func() Array[int] {",
+				"make([]int, 0, _src_",
+				"ArrayFromSlice[int](_out_",
+			},
+			notContains: []string{"Array_Map(", ".Filter("},
+		},
+		{
+			name: "qualified import",
+			input: `package main
+
+import ci "martianoff/gala/collection_immutable"
+
+func lengths(xs ci.Array[string]) ci.Array[int] = xs.Map((s) => len(s)).Map((n) => n + 1)
+`,
+			contains:    []string{"ci.ArrayFromSlice[int](_out_"},
+			notContains: []string{"ci.Array_Map("},
+		},
+		{
+			name: "chain ending in ForEach",
+			input: `package main
+
+import . "martianoff/gala/collection_immutable"
+
+func printPositives(xs Array[int]) {
+    xs.Filter((x) => x > 0).ForEach((x) => println(x))
+}
+`,
+			contains:    []string{"func() {", "continue"},
+			notContains: []string{".Filter(", ".ForEach("},
+		},
+		{
+			name: "impure lambda keeps its chain unfused",
+			input: `package main
+
+import . "martianoff/gala/collection_immutable"
+
+func loudSum(xs Array[int]) int =
+    xs.Map((x int) => {
+        println(x)
+        return x * 2
+    }).Filter((x) => x > 0).FoldLeft(0, (acc, x) => acc + x)
+`,
+			contains:    []string{".Filter(", "Array_FoldLeft("},
+			notContains: []string{"for _i_"},
+		},
+		{
+			name: "lambda reading a var the ForEach changes keeps its chain unfused",
+			input: `package main
+
+import . "martianoff/gala/collection_immutable"
+
+func printOffsets(xs Array[int]) {
+    var offset = 0
+    xs.Map((x) => x + offset).ForEach((x) => {
+        offset = offset + 1
+        println(x)
+    })
+}
+`,
+			contains:    []string{"Array_Map(", ".ForEach("},
+			notContains: []string{"for _i_"},
+		},
+		{
+			name: "single stage is left alone",
+			input: `package main
+
+import . "martianoff/gala/collection_immutable"
+
+func doubled(xs Array[int]) Array[int] = xs.Map((x) => x * 2)
+`,
+			contains:    []string{"Array_Map("},
+			notContains: []string{"ArrayFromSlice"},
+		},
+		{
+			name: "chain on another collection is left alone",
+			input: `package main
+
+import . "martianoff/gala/collection_immutable"
+
+func doubled(xs List[int]) List[int] = xs.Map((x) => x * 2).Filter((x) => x > 2)
+`,
+			contains:    []string{".Filter("},
+			notContains: []string{"for _i_"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := transpiler.NewAntlrGalaParser()
+			trans := transpiler.NewGalaToGoTranspiler(p, analyzer.NewGalaAnalyzer(p, getStdSearchPath()),
+				transformer.WithFusion(transformer.NewGalaASTTransformer()), generator.NewGoCodeGenerator())
+			got, err := trans.Transpile(tt.input, "")
+			assert.NoError(t, err)
+			for _, want := range tt.contains {
+				assert.Contains(t, got, want)
+			}
+			for _, unwanted := range tt.notContains {
+				assert.NotContains(t, got, unwanted)
+			}
+		})
+	}
+}
+
+func TestArrayChainFusionOff(t *testing.T) {
+	p := transpiler.NewAntlrGalaParser()
+	trans := transpiler.NewGalaToGoTranspiler(p, analyzer.NewGalaAnalyzer(p, getStdSearchPath()),
+		transformer.NewGalaASTTransformer(), generator.NewGoCodeGenerator())
+	got, err := trans.Transpile(`package main
+
+import . "martianoff/gala/collection_immutable"
+
+func doubledPositives(xs Array[int]) Array[int] = xs.Filter((x) => x > 0).Map((x) => x * 2)
+`, "")
+	assert.NoError(t, err)
+	assert.Contains(t, got, "Array_Map(xs.Filter(")
+	assert.NotContains(t, got, "ArrayFromSlice")
+}
@@ -51,7 +51,7 @@ type Box[T any] struct {
 }
 
 func (s Box[T]) Copy() Box[T] {
-	return Box[T]{Value: std.Copy(s.Value)}
+	return Box[T]{Value: s.Value}
 }
 func (s Box[T]) Equal(other Box[T]) bool {
 	return std.Equal(s.Value, other.Value)
@@ -65,11 +65,13 @@ func (_ Box[T]) IsBox() bool {
 	return true
 }
 func (s Box[T]) Unapply(v any) (std.Immutable[T], bool) {
-	if p, ok := v.(Box[T]); ok {
-		return p.Value, true
-	}
-	if p, ok := v.(*Box[T]); ok && p != nil {
+	switch p := v.(type) {
+	case Box[T]:
 		return p.Value, true
+	case *Box[T]:
+		if p != nil {
+			return p.Value, true
+		}
 	}
 	return *new(std.Immutable[T]), false
 }
@@ -92,7 +94,7 @@ type Box[T any] struct {
 }
 
 func (s Box[T]) Copy() Box[T] {
-	return Box[T]{Value: std.Copy(s.Value)}
+	return Box[T]{Value: s.Value}
 }
 func (s Box[T]) Equal(other Box[T]) bool {
 	return std.Equal(s.Value, other.Value)
@@ -106,11 +108,13 @@ func (_ Box[T]) IsBox() bool {
 	return true
 }
 func (s Box[T]) Unapply(v any) (std.Immutable[T], bool) {
-	if p, ok := v.(Box[T]); ok {
-		return p.Value, true
-	}
-	if p, ok := v.(*Box[T]); ok && p != nil {
+	switch p := v.(type) {
+	case Box[T]:
 		return p.Value, true
+	case *Box[T]:
+		if p != nil {
+			return p.Value, true
+		}
 	}
 	return *new(std.Immutable[T]), false
 }
@@ -135,7 +139,7 @@ type Box[T any] struct {
 }
 
 func (s Box[T]) Copy() Box[T] {
-	return Box[T]{Value: std.Copy(s.Value)}
+	return Box[T]{Value: s.Value}
 }
 func (s Box[T]) Equal(other Box[T]) bool {
 	return std.Equal(s.Value, other.Value)
@@ -149,11 +153,13 @@ func (_ Box[T]) IsBox() bool {
 	return true
 }
 func (s Box[T]) Unapply(v any) (std.Immutable[T], bool) {
-	if p, ok := v.(Box[T]); ok {
-		return p.Value, true
-	}
-	if p, ok := v.(*Box[T]); ok && p != nil {
+	switch p := v.(type) {
+	case Box[T]:
 		return p.Value, true
+	case *Box[T]:
+		if p != nil {
+			return p.Value, true
+		}
 	}
 	return *new(std.Immutable[T]), false
 }
@@ -189,11 +195,13 @@ func (_ Box[T]) IsBox() bool {
 	return true
 }
 func (s Box[T]) Unapply(v any) (T, bool) {
-	if p, ok := v.(Box[T]); ok {
-		return p.Value, true
-	}
-	if p, ok := v.(*Box[T]); ok && p != nil {
+	switch p := v.(type) {
+	case Box[T]:
 		return p.Value, true
+	case *Box[T]:
+		if p != nil {
+			return p.Value, true
+		}
 	}
 	return *new(T), false
 }
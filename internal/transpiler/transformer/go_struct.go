@@ -0,0 +1,121 @@
+package transformer
+
+import (
+	"go/ast"
+	"go/token"
+
+	"martianoff/gala/internal/transpiler"
+)
+
+// A struct annotated with @goStruct gets a plain Go counterpart for Go
+// libraries that fill or read structs by reflection, such as ORMs and
+// template engines, which know nothing of Immutable and Option:
+//
+//	@goStruct
+//	struct User(Id int, Email Option[string], var Visits int)
+//
+// generates
+//
+//	type UserGo struct {
+//		Id     int
+//		Email  *string
+//		Visits int
+//	}
+//
+//	func (s User) ToGoStruct() UserGo {
+//		return UserGo{Id: s.Id.Get(), Email: std.OptionToPointer(s.Email.Get()), Visits: s.Visits}
+//	}
+//
+//	func (s UserGo) FromGoStruct() User {
+//		return User{Id: std.NewImmutable(s.Id), Email: std.NewImmutable(std.OptionFromPointer(s.Email)), Visits: s.Visits}
+//	}
+//
+// Struct tags are carried over to the Go struct.
+
+// generateGoStruct generates the Go struct of the @goStruct type name, whose
+// generated fields are fields, and the conversions between the two.
+func (t *galaASTTransformer) generateGoStruct(name string, fields *ast.FieldList, tParams *ast.FieldList) []ast.Decl {
+	goName := name + transpiler.GoStructSuffix
+	goFields := &ast.FieldList{}
+	var toElts, fromElts []ast.Expr
+	for _, field := range fields.List {
+		typ, immutable := field.Type, false
+		if idx, ok := typ.(*ast.IndexExpr); ok && isImmutableTypeExpr(idx.X) {
+			typ, immutable = idx.Index, true
+		}
+		idx, isOption := typ.(*ast.IndexExpr)
+		isOption = isOption && isOptionTypeExpr(idx.X)
+		goType := typ
+		if isOption {
+			goType = &ast.StarExpr{X: idx.Index}
+		}
+		goFields.List = append(goFields.List, &ast.Field{Names: field.Names, Type: goType, Tag: field.Tag})
+
+		for _, n := range field.Names {
+			// ToGoStruct: s.Email.Get() and then std.OptionToPointer(...)
+			var to ast.Expr = &ast.SelectorExpr{X: ast.NewIdent("s"), Sel: ast.NewIdent(n.Name)}
+			if immutable {
+				to = &ast.CallExpr{Fun: &ast.SelectorExpr{X: to, Sel: ast.NewIdent(transpiler.MethodGet)}}
+			}
+			if isOption {
+				to = &ast.CallExpr{Fun: t.stdIdent(transpiler.FuncOptionToPointer), Args: []ast.Expr{to}}
+			}
+			toElts = append(toElts, &ast.KeyValueExpr{Key: ast.NewIdent(n.Name), Value: to})
+
+			// FromGoStruct: the same steps in reverse
+			var from ast.Expr = &ast.SelectorExpr{X: ast.NewIdent("s"), Sel: ast.NewIdent(n.Name)}
+			if isOption {
+				from = &ast.CallExpr{Fun: t.stdIdent(transpiler.FuncOptionFromPointer), Args: []ast.Expr{from}}
+			}
+			if immutable {
+				from = &ast.CallExpr{Fun: t.stdIdent(transpiler.FuncNewImmutable), Args: []ast.Expr{from}}
+			}
+			fromElts = append(fromElts, &ast.KeyValueExpr{Key: ast.NewIdent(n.Name), Value: from})
+		}
+	}
+
+	typeDecl := &ast.GenDecl{
+		Tok: token.TYPE,
+		Specs: []ast.Spec{&ast.TypeSpec{
+			Name:       ast.NewIdent(goName),
+			TypeParams: tParams,
+			Type:       &ast.StructType{Fields: goFields},
+		}},
+	}
+	return []ast.Decl{
+		typeDecl,
+		t.conversionMethod(name, goName, transpiler.MethodToGoStruct, toElts, tParams),
+		t.conversionMethod(goName, name, transpiler.MethodFromGoStruct, fromElts, tParams),
+	}
+}
+
+// conversionMethod generates the method of from called method that returns
+// the to built from elts.
+func (t *galaASTTransformer) conversionMethod(from, to, method string, elts []ast.Expr, tParams *ast.FieldList) *ast.FuncDecl {
+	toType := t.buildGenericTypeExpr(to, tParams)
+	return &ast.FuncDecl{
+		Recv: &ast.FieldList{List: []*ast.Field{{
+			Names: []*ast.Ident{ast.NewIdent("s")},
+			Type:  t.buildGenericTypeExpr(from, tParams),
+		}}},
+		Name: ast.NewIdent(method),
+		Type: &ast.FuncType{
+			Params:  &ast.FieldList{},
+			Results: &ast.FieldList{List: []*ast.Field{{Type: toType}}},
+		},
+		Body: &ast.BlockStmt{List: []ast.Stmt{
+			&ast.ReturnStmt{Results: []ast.Expr{&ast.CompositeLit{Type: toType, Elts: elts}}},
+		}},
+	}
+}
+
+// isOptionTypeExpr reports whether expr names std's Option type.
+func isOptionTypeExpr(expr ast.Expr) bool {
+	switch e := expr.(type) {
+	case *ast.SelectorExpr:
+		return e.Sel.Name == transpiler.TypeOption
+	case *ast.Ident:
+		return e.Name == transpiler.TypeOption
+	}
+	return false
+}
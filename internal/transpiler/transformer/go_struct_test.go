@@ -0,0 +1,112 @@
+package transformer_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"martianoff/gala/internal/transpiler"
+	"martianoff/gala/internal/transpiler/analyzer"
+	"martianoff/gala/internal/transpiler/generator"
+	"martianoff/gala/internal/transpiler/transformer"
+)
+
+func TestGoStruct(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		contains []string
+		wantErr  string
+	}{
+		{
+			name: "shorthand struct",
+			input: `package main
+
+@goStruct
+struct User(Id int, Email Option[string], var Visits int)
+`,
+			contains: []string{
+				"type UserGo struct {\n\tId     int\n\tEmail  *string\n\tVisits int\n}",
+				"func (s User) ToGoStruct() UserGo {\n\treturn UserGo{Id: s.Id.Get(), Email: std.OptionToPointer(s.Email.Get()), Visits: s.Visits}\n}",
+				"func (s UserGo) FromGoStruct() User {\n\treturn User{Id: std.NewImmutable(s.Id), Email: std.NewImmutable(std.OptionFromPointer(s.Email)), Visits: s.Visits}\n}",
+			},
+		},
+		{
+			name: "struct tags are kept",
+			input: `package main
+
+@goStruct
+type Row struct {
+    Id int "db:\"id\""
+    var Note Option[string] "db:\"note\""
+}
+`,
+			contains: []string{
+				"type RowGo struct {",
+				`"db:\"id\""`,
+				`*string "db:\"note\""`,
+				"Note: std.OptionToPointer(s.Note)",
+				"Note: std.OptionFromPointer(s.Note)",
+			},
+		},
+		{
+			name: "generic struct",
+			input: `package main
+
+@goStruct
+type Box[T any] struct {
+    Item Option[T]
+}
+`,
+			contains: []string{
+				"type BoxGo[T any] struct {\n\tItem *T\n}",
+				"func (s Box[T]) ToGoStruct() BoxGo[T] {",
+				"func (s BoxGo[T]) FromGoStruct() Box[T] {",
+			},
+		},
+		{
+			name: "conversions called from GALA",
+			input: `package main
+
+@goStruct
+struct User(Id int, Email Option[string])
+
+func roundTrip(u User) User = u.ToGoStruct().FromGoStruct()
+
+func email(u User) *string = u.ToGoStruct().Email
+`,
+			contains: []string{
+				"return u.ToGoStruct().FromGoStruct()",
+				"return u.ToGoStruct().Email",
+			},
+		},
+		{
+			name: "goStruct on a sealed type",
+			input: `package main
+
+@goStruct
+sealed type Shape {
+    case Circle(Radius float64)
+}
+`,
+			wantErr: "annotation @goStruct can only be applied to a struct type",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := transpiler.NewAntlrGalaParser()
+			trans := transpiler.NewGalaToGoTranspiler(p, analyzer.NewGalaAnalyzer(p, getStdSearchPath()),
+				transformer.NewGalaASTTransformer(), generator.NewGoCodeGenerator())
+			got, err := trans.Transpile(tt.input, "")
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			for _, want := range tt.contains {
+				assert.Contains(t, got, want)
+			}
+		})
+	}
+}
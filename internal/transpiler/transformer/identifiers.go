@@ -0,0 +1,176 @@
+package transformer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"martianoff/gala/galaerr"
+	"martianoff/gala/internal/parser/grammar"
+)
+
+// This file keeps the names of GALA declarations valid and unambiguous in the
+// generated Go.
+//
+// GALA does not reserve every Go keyword: a val or field can be named default,
+// select or go. Such names get a trailing underscore everywhere they occur, so
+//
+//	val default = 10
+//
+// becomes var default_ and every use of default becomes default_. All
+// packages are escaped the same way, so uses across GALA packages agree. A
+// GALA name that is already the escaped form of another, such as default_
+// next to default, is an error since both would become one Go name.
+//
+// Types also get members GALA declares for them: Copy and Equal on structs
+// and sealed types, String and an isX method per case on sealed types, the
+// _variant field holding the case, and IsT on generic structs. Sealed types
+// also get functions, ShapeVariants listing the cases of Shape. A method,
+// field or function of the same name written in GALA is reported at its
+// declaration rather than left to fail as a duplicate in Go. Declarations in
+// other files of the package are not seen here and are left to the Go
+// compiler.
+
+// keywordEscape is appended to GALA names that are Go keywords.
+const keywordEscape = "_"
+
+// escapeGoKeywords renames the identifiers of decls that are Go keywords.
+func (t *galaASTTransformer) escapeGoKeywords(decls []ast.Decl) {
+	for _, decl := range decls {
+		ast.Inspect(decl, func(n ast.Node) bool {
+			// break and continue are carried to Go as bare identifiers
+			if stmt, ok := n.(*ast.ExprStmt); ok {
+				if id, ok := stmt.X.(*ast.Ident); ok && token.IsKeyword(id.Name) {
+					return false
+				}
+			}
+			id, ok := n.(*ast.Ident)
+			// Nodes shared within the tree are visited more than once
+			if !ok || t.escapedIdents[id] != "" || !token.IsKeyword(id.Name) {
+				return true
+			}
+			t.escapedIdents[id] = id.Name
+			id.Name += keywordEscape
+			return true
+		})
+	}
+}
+
+// checkNameCollisions reports the first GALA name of file that collides with
+// an escaped keyword or with a member generated for a type. origins gives the
+// GALA declaration each declaration comes from.
+func (t *galaASTTransformer) checkNameCollisions(file *ast.File, origins map[ast.Decl]antlr.ParserRuleContext) error {
+	escaped := make(map[string]string) // escaped name -> keyword
+	for _, keyword := range t.escapedIdents {
+		escaped[keyword+keywordEscape] = keyword
+	}
+	for _, decl := range file.Decls {
+		origin, ok := origins[decl]
+		if !ok || len(escaped) == 0 {
+			continue
+		}
+		var clash *ast.Ident
+		ast.Inspect(decl, func(n ast.Node) bool {
+			if id, ok := n.(*ast.Ident); ok && clash == nil && t.escapedIdents[id] == "" && escaped[id.Name] != "" {
+				clash = id
+			}
+			return clash == nil
+		})
+		if clash != nil {
+			msg := fmt.Sprintf("%s collides with %s, which is a Go keyword and named %s in the generated code", clash.Name, escaped[clash.Name], clash.Name)
+			return t.semanticErrorAt(origin, msg).WithCode(galaerr.CodeNameCollision)
+		}
+	}
+
+	// The methods generated for each type
+	generated := make(map[string]map[string]bool)
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv == nil || len(fn.Recv.List) == 0 || origins[decl] == nil || isFunctionDeclaration(origins[decl]) {
+			continue
+		}
+		typeName := recvBaseName(fn.Recv.List[0].Type)
+		if generated[typeName] == nil {
+			generated[typeName] = make(map[string]bool)
+		}
+		generated[typeName][fn.Name.Name] = true
+	}
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv == nil || len(fn.Recv.List) == 0 || !isFunctionDeclaration(origins[decl]) {
+			continue
+		}
+		if typeName := recvBaseName(fn.Recv.List[0].Type); generated[typeName][fn.Name.Name] {
+			msg := fmt.Sprintf("method %s of %s collides with the %s method generated for %s", fn.Name.Name, typeName, fn.Name.Name, typeName)
+			return t.semanticErrorAt(origins[decl], msg).WithCode(galaerr.CodeNameCollision)
+		}
+	}
+
+	// Functions declared for sealed types, such as ShapeVariants or the
+	// constructors of a @compact one
+	generatedFuncs := make(map[string]string) // function name -> sealed type
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		top, _ := origins[decl].(*grammar.TopLevelDeclarationContext)
+		if !ok || fn.Recv != nil || top == nil || top.SealedTypeDeclaration() == nil {
+			continue
+		}
+		generatedFuncs[fn.Name.Name] = top.SealedTypeDeclaration().(*grammar.SealedTypeDeclarationContext).Identifier().GetText()
+	}
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv != nil || !isFunctionDeclaration(origins[decl]) {
+			continue
+		}
+		if typeName, ok := generatedFuncs[fn.Name.Name]; ok {
+			msg := fmt.Sprintf("function %s collides with the %s function generated for %s", fn.Name.Name, fn.Name.Name, typeName)
+			return t.semanticErrorAt(origins[decl], msg).WithCode(galaerr.CodeNameCollision)
+		}
+	}
+
+	// Fields named like a generated method, or like the field holding the
+	// case of a sealed type, which comes last
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE || origins[decl] == nil {
+			continue
+		}
+		top, _ := origins[decl].(*grammar.TopLevelDeclarationContext)
+		sealed := top != nil && top.SealedTypeDeclaration() != nil
+		for _, spec := range gen.Specs {
+			ts := spec.(*ast.TypeSpec)
+			st, ok := ts.Type.(*ast.StructType)
+			if !ok || st.Fields == nil || len(st.Fields.List) == 0 {
+				continue
+			}
+			fields := st.Fields.List
+			var caseField string
+			if sealed && len(fields[len(fields)-1].Names) == 1 {
+				caseField = fields[len(fields)-1].Names[0].Name
+				fields = fields[:len(fields)-1]
+			}
+			for _, field := range fields {
+				for _, name := range field.Names {
+					if name.Name == caseField {
+						msg := fmt.Sprintf("field %s of %s collides with the %s field generated for %s", name.Name, ts.Name.Name, name.Name, ts.Name.Name)
+						return t.semanticErrorAt(origins[decl], msg).WithCode(galaerr.CodeNameCollision)
+					}
+					if generated[ts.Name.Name][name.Name] {
+						msg := fmt.Sprintf("field %s of %s collides with the %s method generated for %s", name.Name, ts.Name.Name, name.Name, ts.Name.Name)
+						return t.semanticErrorAt(origins[decl], msg).WithCode(galaerr.CodeNameCollision)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// isFunctionDeclaration reports whether ctx, the origin of a generated
+// declaration, is a function or method declared in GALA.
+func isFunctionDeclaration(ctx antlr.ParserRuleContext) bool {
+	top, ok := ctx.(*grammar.TopLevelDeclarationContext)
+	return ok && top.FunctionDeclaration() != nil
+}
@@ -0,0 +1,68 @@
+package transformer_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"martianoff/gala/galaerr"
+	"martianoff/gala/internal/transpiler"
+	"martianoff/gala/internal/transpiler/analyzer"
+	"martianoff/gala/internal/transpiler/generator"
+	"martianoff/gala/internal/transpiler/transformer"
+)
+
+func TestGoKeywordIdentifiers(t *testing.T) {
+	p := transpiler.NewAntlrGalaParser()
+	trans := transpiler.NewGalaToGoTranspiler(p, analyzer.NewGalaAnalyzer(p, getStdSearchPath()),
+		transformer.NewGalaASTTransformer(), generator.NewGoCodeGenerator())
+
+	got, err := trans.Transpile(`package main
+
+struct Options(default int)
+
+func pick(select int, o Options) int {
+    for i := 0; i < select; i++ {
+        if i > o.default {
+            break
+        }
+    }
+    return select + o.default
+}
+`, "main.gala")
+	assert.NoError(t, err)
+	assert.Contains(t, got, "default_ std.Immutable[int]")
+	assert.Contains(t, got, "func pick(select_ int, o Options) int {")
+	assert.Contains(t, got, "o.default_.Get()")
+	assert.Contains(t, got, "\t\t\tbreak\n")
+	assert.NotContains(t, got, "select ")
+
+	_, err = trans.Transpile("package main\n\nval default = 1\n\nval default_ = 2\n", "main.gala")
+	assert.Error(t, err)
+	assert.Equal(t, galaerr.CodeNameCollision, galaerr.CodeOf(err))
+	assert.Contains(t, err.Error(), "main.gala:5:1 default_ collides with default")
+}
+
+func TestGeneratedMemberCollision(t *testing.T) {
+	p := transpiler.NewAntlrGalaParser()
+	trans := transpiler.NewGalaToGoTranspiler(p, analyzer.NewGalaAnalyzer(p, getStdSearchPath()),
+		transformer.NewGalaASTTransformer(), generator.NewGoCodeGenerator())
+
+	_, err := trans.Transpile(`package main
+
+struct Point(X int, Y int)
+
+func (p Point) Equal(other Point) bool = p.X == other.X
+`, "main.gala")
+	assert.Error(t, err)
+	assert.Equal(t, galaerr.CodeNameCollision, galaerr.CodeOf(err))
+	assert.Contains(t, err.Error(), "main.gala:5:1 method Equal of Point collides with the Equal method generated for Point")
+
+	_, err = trans.Transpile("package main\n\nstruct Box(Copy int)\n", "main.gala")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "main.gala:3:1 field Copy of Box collides with the Copy method generated for Box")
+
+	// Without a generated Copy, the name is free
+	_, err = trans.Transpile("package main\n\n@noCopy\nstruct Box(Copy int)\n", "main.gala")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,176 @@
+package transformer
+
+import (
+	"go/ast"
+
+	"martianoff/gala/internal/transpiler"
+)
+
+// An immutable struct field is an Immutable[T], which holds its T in place:
+// copying the struct, as passing it by value or Copy with a changed field do,
+// copies every such field in full. When optimizing (-O), the immutable fields
+// of struct types estimated larger than immutableRefSize bytes become
+// ImmutableRef[T] instead, which holds a pointer to the value, and the
+// NewImmutable calls building them NewImmutableRef calls:
+//
+//	struct Order(Id int, Customer Customer)	// Customer takes 200 bytes
+//
+//	type Order struct {
+//		Id       std.Immutable[int]
+//		Customer std.ImmutableRef[Customer]
+//	}
+//
+// ImmutableRef has the methods of Immutable, so reading the field is unchanged.
+// Sizes are estimated from the fields the analyzer knows of; a struct with a
+// field of a type of unknown size, such as a Go type or a generic type, is
+// never held by reference.
+
+// immutableRefSize is the size in bytes above which a struct is held by reference.
+const immutableRefSize = 128
+
+// WithImmutableRefs makes tr, a transformer created by this package, hold the
+// immutable fields of large struct types by reference as described above.
+func WithImmutableRefs(tr transpiler.ASTTransformer) transpiler.ASTTransformer {
+	tr.(*galaASTTransformer).immutableRefs = true
+	return tr
+}
+
+// useImmutableRefs rewrites the declarations and the composite literals of the
+// struct types in decls that have fields held by reference.
+func (t *galaASTTransformer) useImmutableRefs(decls []ast.Decl) {
+	if !t.immutableRefs {
+		return
+	}
+	for _, decl := range decls {
+		ast.Inspect(decl, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.TypeSpec:
+				st, ok := n.Type.(*ast.StructType)
+				if !ok || n.TypeParams != nil {
+					return true
+				}
+				refs := t.refFields(t.getTypeMeta(n.Name.Name))
+				for _, field := range st.Fields.List {
+					idx, ok := field.Type.(*ast.IndexExpr)
+					if !ok || !isImmutableTypeExpr(idx.X) || len(field.Names) == 0 || !refs[field.Names[0].Name] {
+						continue
+					}
+					idx.X = siblingIdent(idx.X, transpiler.TypeImmutableRef)
+				}
+			case *ast.CompositeLit:
+				var refs map[string]bool
+				switch typ := n.Type.(type) {
+				case *ast.Ident:
+					refs = t.refFields(t.getTypeMeta(typ.Name))
+				case *ast.SelectorExpr:
+					if pkg, ok := typ.X.(*ast.Ident); ok {
+						refs = t.refFields(t.getTypeMeta(pkg.Name + "." + typ.Sel.Name))
+					}
+				}
+				for _, elt := range n.Elts {
+					kv, ok := elt.(*ast.KeyValueExpr)
+					if !ok {
+						continue
+					}
+					key, ok := kv.Key.(*ast.Ident)
+					if !ok || !refs[key.Name] {
+						continue
+					}
+					if call, ok := kv.Value.(*ast.CallExpr); ok {
+						useNewImmutableRef(call)
+					}
+				}
+			}
+			return true
+		})
+	}
+}
+
+// refFields returns the immutable fields of the struct type meta describes
+// that are held by reference.
+func (t *galaASTTransformer) refFields(meta *transpiler.TypeMetadata) map[string]bool {
+	if meta == nil || meta.IsSealed || meta.IsInterface || meta.AliasOf != nil || meta.IsNewtype() || len(meta.TypeParams) > 0 {
+		return nil
+	}
+	var refs map[string]bool
+	for i, name := range meta.FieldNames {
+		if i >= len(meta.ImmutFlags) || !meta.ImmutFlags[i] {
+			continue
+		}
+		if t.typeSize(meta.Fields[name], map[string]bool{}) > immutableRefSize {
+			if refs == nil {
+				refs = make(map[string]bool)
+			}
+			refs[name] = true
+		}
+	}
+	return refs
+}
+
+// typeSize estimates the size in bytes of a value of typ, with the fields held
+// by reference counted as pointers, or returns -1 if it is not known. seen
+// holds the struct types being measured, whose size is not known to themselves.
+func (t *galaASTTransformer) typeSize(typ transpiler.Type, seen map[string]bool) int {
+	const word = 8
+	switch typ := typ.(type) {
+	case transpiler.BasicType:
+		switch typ.Name {
+		case "bool", "byte", "int8", "uint8", "int16", "uint16", "int32", "uint32", "rune", "float32",
+			"int", "uint", "int64", "uint64", "uintptr", "float64", "complex64":
+			// Counted as a word each, as struct fields are usually padded to one
+			return word
+		case "string", "complex128", "any", "error":
+			return 2 * word
+		}
+		return -1
+	case transpiler.ArrayType:
+		return 3 * word
+	case transpiler.MapType, transpiler.PointerType, transpiler.FuncType:
+		return word
+	case transpiler.NamedType:
+		meta := t.getTypeMeta(typ.BaseName())
+		if meta == nil || meta.IsInterface || meta.AliasOf != nil || meta.IsNewtype() || len(meta.TypeParams) > 0 ||
+			len(meta.FieldNames) == 0 || seen[typ.BaseName()] {
+			return -1
+		}
+		seen[typ.BaseName()] = true
+		defer delete(seen, typ.BaseName())
+		refs := t.refFields(meta)
+		size := 0
+		for _, name := range meta.FieldNames {
+			if refs[name] {
+				size += word
+				continue
+			}
+			fieldSize := t.typeSize(meta.Fields[name], seen)
+			if fieldSize < 0 {
+				return -1
+			}
+			size += fieldSize
+		}
+		return size
+	}
+	return -1
+}
+
+// useNewImmutableRef makes call, if it is a NewImmutable call with or
+// without type arguments, a call of NewImmutableRef.
+func useNewImmutableRef(call *ast.CallExpr) {
+	fun := &call.Fun
+	if idx, ok := call.Fun.(*ast.IndexExpr); ok {
+		fun = &idx.X
+	}
+	switch f := (*fun).(type) {
+	case *ast.SelectorExpr:
+		if f.Sel.Name != transpiler.FuncNewImmutable {
+			return
+		}
+	case *ast.Ident:
+		if f.Name != transpiler.FuncNewImmutable {
+			return
+		}
+	default:
+		return
+	}
+	*fun = siblingIdent(*fun, transpiler.FuncNewImmutableRef)
+}
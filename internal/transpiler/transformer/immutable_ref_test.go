@@ -0,0 +1,112 @@
+package transformer_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"martianoff/gala/internal/transpiler"
+	"martianoff/gala/internal/transpiler/analyzer"
+	"martianoff/gala/internal/transpiler/generator"
+	"martianoff/gala/internal/transpiler/transformer"
+)
+
+// refTypes declares Address, which takes 144 bytes, held by Customer.
+const refTypes = `package main
+
+struct Address(Street string, Line2 string, City string, Region string, Zip string, Country string, Phone string, Email string, Note string)
+
+struct Customer(Id int, Name string, Address Address)
+`
+
+func TestImmutableRefs(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		contains    []string
+		notContains []string
+	}{
+		{
+			name:  "large field held by reference",
+			input: refTypes,
+			contains: []string{
+				"Address std.ImmutableRef[Address]",
+				"std.Immutable[int]",
+				"std.Immutable[string]",
+				"Address: std.NewImmutableRef(",
+			},
+			notContains: []string{"Address std.Immutable[Address]"},
+		},
+		{
+			name: "construction and copy",
+			input: refTypes + `
+func move(c Customer, to Address) Customer = c.Copy(Address = to)
+
+func newCustomer(a Address) Customer = Customer(1, "ann", a)
+`,
+			contains:    []string{"Address: std.NewImmutableRef(to)", "std.NewImmutableRef(a)"},
+			notContains: []string{"std.NewImmutable(to)", "std.NewImmutable(a)"},
+		},
+		{
+			name: "small field left alone",
+			input: `package main
+
+struct Point(X int, Y int)
+
+struct Line(From Point, To Point)
+`,
+			contains:    []string{"From std.Immutable[Point]"},
+			notContains: []string{"ImmutableRef"},
+		},
+		{
+			name: "field of unknown size left alone",
+			input: `package main
+
+import "strings"
+
+struct Doc(Title string, Body strings.Builder)
+
+struct Page(Number int, Doc Doc)
+`,
+			contains:    []string{"std.Immutable[Doc]"},
+			notContains: []string{"ImmutableRef"},
+		},
+		{
+			name: "var field left alone",
+			input: `package main
+
+struct Address(Street string, Line2 string, City string, Region string, Zip string, Country string, Phone string, Email string, Note string)
+
+struct Customer(Id int, var Address Address)
+`,
+			contains:    []string{"Address Address"},
+			notContains: []string{"ImmutableRef"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := transpiler.NewAntlrGalaParser()
+			trans := transpiler.NewGalaToGoTranspiler(p, analyzer.NewGalaAnalyzer(p, getStdSearchPath()),
+				transformer.WithImmutableRefs(transformer.NewGalaASTTransformer()), generator.NewGoCodeGenerator())
+			got, err := trans.Transpile(tt.input, "")
+			assert.NoError(t, err)
+			for _, want := range tt.contains {
+				assert.Contains(t, got, want)
+			}
+			for _, unwanted := range tt.notContains {
+				assert.NotContains(t, got, unwanted)
+			}
+		})
+	}
+}
+
+func TestImmutableRefsOff(t *testing.T) {
+	p := transpiler.NewAntlrGalaParser()
+	trans := transpiler.NewGalaToGoTranspiler(p, analyzer.NewGalaAnalyzer(p, getStdSearchPath()),
+		transformer.NewGalaASTTransformer(), generator.NewGoCodeGenerator())
+	got, err := trans.Transpile(refTypes, "")
+	assert.NoError(t, err)
+	assert.Contains(t, got, "Address std.Immutable[Address]")
+	assert.NotContains(t, got, "ImmutableRef")
+}
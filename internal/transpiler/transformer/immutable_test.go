@@ -69,17 +69,19 @@ type Config struct {
 }
 
 func (s Config) Copy() Config {
-	return Config{ID: std.Copy(s.ID)}
+	return Config{ID: s.ID}
 }
 func (s Config) Equal(other Config) bool {
 	return std.Equal(s.ID, other.ID)
 }
 func (s Config) Unapply(v any) (std.Immutable[string], bool) {
-	if p, ok := v.(Config); ok {
-		return p.ID, true
-	}
-	if p, ok := v.(*Config); ok && p != nil {
+	switch p := v.(type) {
+	case Config:
 		return p.ID, true
+	case *Config:
+		if p != nil {
+			return p.ID, true
+		}
 	}
 	return *new(std.Immutable[string]), false
 }
@@ -111,11 +113,12 @@ type Node struct {
 }
 
 func (s Node) Copy() Node {
-	return Node{value: std.Copy(s.value), isEmpty: std.Copy(s.isEmpty)}
+	return Node{value: s.value, isEmpty: s.isEmpty}
 }
 func (s Node) Equal(other Node) bool {
 	return std.Equal(s.value, other.value) && std.Equal(s.isEmpty, other.isEmpty)
 }
+
 func test(n Node) bool {
 	var local = n
 	return local.isEmpty.Get()
@@ -145,7 +148,7 @@ type Container[T any] struct {
 }
 
 func (s Container[T]) Copy() Container[T] {
-	return Container[T]{value: std.Copy(s.value), isEmpty: std.Copy(s.isEmpty)}
+	return Container[T]{value: s.value, isEmpty: s.isEmpty}
 }
 func (s Container[T]) Equal(other Container[T]) bool {
 	return std.Equal(s.value, other.value) && std.Equal(s.isEmpty, other.isEmpty)
@@ -158,6 +161,7 @@ type ContainerInstance interface {
 func (_ Container[T]) IsContainer() bool {
 	return true
 }
+
 func testEmpty[T any](c Container[T]) bool {
 	var local = c
 	return local.isEmpty.Get()
@@ -190,11 +194,12 @@ type Node struct {
 }
 
 func (s Node) Copy() Node {
-	return Node{value: std.Copy(s.value), next: std.Copy(s.next), isEmpty: std.Copy(s.isEmpty)}
+	return Node{value: s.value, next: s.next, isEmpty: s.isEmpty}
 }
 func (s Node) Equal(other Node) bool {
 	return std.Equal(s.value, other.value) && std.Equal(s.next, other.next) && std.Equal(s.isEmpty, other.isEmpty)
 }
+
 func test(n Node) bool {
 	var current = n
 	var next = *current.next.Get()
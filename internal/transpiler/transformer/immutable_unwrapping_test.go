@@ -91,6 +91,7 @@ import "martianoff/gala/std"
 func getImm() std.Immutable[int] {
 	return std.NewImmutable(1)
 }
+
 func main() {
 	var y = getImm().Get()
 }`,
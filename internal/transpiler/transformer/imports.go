@@ -26,6 +26,9 @@ type ImportEntry struct {
 	PkgName string // Actual package name: "std" (may differ from path's last component)
 	Alias   string // User alias in code, or same as PkgName if no explicit alias
 	IsDot   bool   // True for dot imports (import . "pkg")
+	// Symbols maps the local names of a selective import (import "pkg".{A, B => C})
+	// to the symbols they stand for: A -> A, C -> B. Nil for other imports.
+	Symbols map[string]string
 }
 
 // NewImportManager creates a new empty ImportManager.
@@ -119,6 +122,29 @@ func (m *ImportManager) removeEntry(entry *ImportEntry) {
 	}
 }
 
+// Select records that the import of path brings symbol into scope as local.
+func (m *ImportManager) Select(path, local, symbol string) {
+	entry, ok := m.byPath[path]
+	if !ok {
+		return
+	}
+	if entry.Symbols == nil {
+		entry.Symbols = make(map[string]string)
+	}
+	entry.Symbols[local] = symbol
+}
+
+// Selected returns the import entry and symbol a selective import binds to
+// the unqualified name local.
+func (m *ImportManager) Selected(local string) (*ImportEntry, string, bool) {
+	for _, entry := range m.entries {
+		if symbol, ok := entry.Symbols[local]; ok {
+			return entry, symbol, true
+		}
+	}
+	return nil, "", false
+}
+
 // AddFromPackages populates imports from a richAST.Packages map (path -> pkgName).
 // This is used for implicit imports like std.
 func (m *ImportManager) AddFromPackages(packages map[string]string) {
@@ -184,3 +184,26 @@ func TestImportManager_AddFromPackagesSkipsExistingPaths(t *testing.T) {
 	assert.True(t, ok)
 	assert.Equal(t, "mystd", entry.Alias) // First (explicit) one preserved
 }
+
+func TestImportManager_Select(t *testing.T) {
+	m := transformer.NewImportManager()
+	entry := m.Add("example.com/geometry", "", false, "geometry")
+	m.Select("example.com/geometry", "Point", "Point")
+	m.Select("example.com/geometry", "Zero", "Origin")
+	m.Select("example.com/unknown", "Line", "Line") // not imported: ignored
+
+	e, symbol, ok := m.Selected("Zero")
+	assert.True(t, ok)
+	assert.Equal(t, entry, e)
+	assert.Equal(t, "Origin", symbol)
+
+	_, symbol, ok = m.Selected("Point")
+	assert.True(t, ok)
+	assert.Equal(t, "Point", symbol)
+
+	_, _, ok = m.Selected("Origin")
+	assert.False(t, ok, "a renamed symbol is only reachable by its local name")
+	_, _, ok = m.Selected("Line")
+	assert.False(t, ok)
+	assert.False(t, entry.IsDot)
+}
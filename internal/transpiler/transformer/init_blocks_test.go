@@ -0,0 +1,116 @@
+package transformer_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"martianoff/gala/galaerr"
+	"martianoff/gala/internal/transpiler"
+	"martianoff/gala/internal/transpiler/analyzer"
+	"martianoff/gala/internal/transpiler/generator"
+	"martianoff/gala/internal/transpiler/transformer"
+)
+
+func TestInitBlocks(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		contains []string
+		wantErr  string
+	}{
+		{
+			name: "Init block sets a package var",
+			input: `package main
+
+var limit = 0
+
+init {
+    limit = 10
+}`,
+			contains: []string{"func init() {\n\tlimit = 10\n}"},
+		},
+		{
+			name: "Several init blocks keep their order",
+			input: `package main
+
+var steps []string
+
+init {
+    steps = append(steps, "first")
+}
+
+init {
+    steps = append(steps, "second")
+}`,
+			contains: []string{
+				"func init() {\n\tsteps = append(steps, \"first\")\n}\n\nfunc init() {\n\tsteps = append(steps, \"second\")\n}",
+			},
+		},
+		{
+			name: "init as an identifier",
+			input: `package main
+
+func first(xs []int) int {
+    val init = xs[0]
+    return init
+}`,
+			contains: []string{"var init = std.NewImmutable(xs[0])"},
+		},
+		{
+			name: "Locals shadow package vals",
+			input: `package main
+
+val total = 0
+
+init {
+    var total = 1
+    total = 2
+    println(total)
+}`,
+			contains: []string{"total = 2"},
+		},
+		{
+			name: "Reassigned val",
+			input: `package main
+
+val limit = 0
+
+init {
+    limit = 10
+}`,
+			wantErr: "init block cannot reassign val limit",
+		},
+		{
+			name: "Val declared after the block",
+			input: `package main
+
+init {
+    if true {
+        retries++
+    }
+}
+
+val retries = 3`,
+			wantErr: "init block cannot reassign val retries",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := transpiler.NewAntlrGalaParser()
+			a := analyzer.NewGalaAnalyzer(p, getStdSearchPath())
+			trans := transpiler.NewGalaToGoTranspiler(p, a, transformer.NewGalaASTTransformer(), generator.NewGoCodeGenerator())
+			got, err := trans.Transpile(tt.input, "")
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				assert.Equal(t, galaerr.CodeImmutableAssign, galaerr.CodeOf(err))
+				return
+			}
+			assert.NoError(t, err)
+			for _, want := range tt.contains {
+				assert.Contains(t, got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,264 @@
+package transformer
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"martianoff/gala/galaerr"
+)
+
+// This file orders the package-level vals and vars of a file by their
+// dependencies. An initializer depends on the vals and vars it mentions, also
+// through the functions it calls, as in Go:
+//
+//	val total = sum()
+//	func sum() int = prices.Reduce(0, add)
+//	val prices = ArrayOf(3, 4)
+//
+// emits prices before total. The declarations keep their places among the
+// other declarations; only the order of the vals and vars changes, taking the
+// earliest ready one first. A val that depends on itself is an error listing
+// the chain, such as total -> sum -> total, reported at its declaration.
+//
+// Names are matched without type information: declarations inside functions
+// shadow package names, and methods are not followed. Cycles that this misses,
+// or that span several files, are still rejected by the Go compiler.
+
+// initNode is a package-level var or function of the dependency graph.
+type initNode struct {
+	name string
+	decl int // index in file.Decls
+	deps []string
+	fn   bool
+}
+
+// orderPackageVars reorders the var declarations of file so that each comes
+// after the ones its initializer depends on. origins gives the GALA declaration
+// each declaration comes from, for the error position.
+func (t *galaASTTransformer) orderPackageVars(file *ast.File, origins map[ast.Decl]antlr.ParserRuleContext) error {
+	nodes := make(map[string]*initNode)
+	var names []string // package vars, in declaration order
+	var slots []int    // indices of the var declarations
+	for i, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Recv == nil && d.Name.Name != "init" && d.Body != nil {
+				nodes[d.Name.Name] = &initNode{name: d.Name.Name, decl: i, fn: true}
+			}
+		case *ast.GenDecl:
+			if d.Tok != token.VAR {
+				continue
+			}
+			slots = append(slots, i)
+			for _, spec := range d.Specs {
+				for _, name := range spec.(*ast.ValueSpec).Names {
+					if name.Name != "_" {
+						nodes[name.Name] = &initNode{name: name.Name, decl: i}
+						names = append(names, name.Name)
+					}
+				}
+			}
+		}
+	}
+	if len(slots) == 0 {
+		return nil
+	}
+
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if n := nodes[d.Name.Name]; n != nil && n.fn && file.Decls[n.decl] == decl {
+				n.deps = packageRefs(d, nodes)
+			}
+		case *ast.GenDecl:
+			if d.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range d.Specs {
+				vs := spec.(*ast.ValueSpec)
+				var deps []string
+				for _, value := range vs.Values {
+					deps = append(deps, packageRefs(value, nodes)...)
+				}
+				for _, name := range vs.Names {
+					if n := nodes[name.Name]; n != nil {
+						n.deps = deps
+					}
+				}
+			}
+		}
+	}
+
+	// Follow the dependencies of each var looking for a way back to it. The
+	// vars reached through functions only are the ones it depends on directly.
+	varDeps := make(map[string][]string)
+	for _, name := range names {
+		var chain []string
+		seen := make(map[string]bool)
+		var visit func(n *initNode, direct bool) bool
+		visit = func(n *initNode, direct bool) bool {
+			chain = append(chain, n.name)
+			for _, dep := range n.deps {
+				if dep == name {
+					chain = append(chain, dep)
+					return true
+				}
+				if seen[dep] {
+					continue
+				}
+				seen[dep] = true
+				d := nodes[dep]
+				if direct && !d.fn {
+					varDeps[name] = append(varDeps[name], dep)
+				}
+				if visit(d, direct && d.fn) {
+					return true
+				}
+			}
+			chain = chain[:len(chain)-1]
+			return false
+		}
+		if visit(nodes[name], true) {
+			msg := "initialization cycle: " + strings.Join(chain, " -> ")
+			return t.semanticErrorAt(origins[file.Decls[nodes[name].decl]], msg).WithCode(galaerr.CodeInitCycle)
+		}
+	}
+
+	// Fill the var slots with the declarations in dependency order, taking the
+	// earliest declaration whose dependencies are all placed
+	declDeps := make(map[int]map[int]bool)
+	for _, name := range names {
+		n := nodes[name]
+		for _, dep := range varDeps[name] {
+			if d := nodes[dep].decl; d != n.decl {
+				if declDeps[n.decl] == nil {
+					declDeps[n.decl] = make(map[int]bool)
+				}
+				declDeps[n.decl][d] = true
+			}
+		}
+	}
+	if len(declDeps) == 0 {
+		return nil
+	}
+	placed := make(map[int]bool)
+	order := make([]int, 0, len(slots))
+	for len(order) < len(slots) {
+		next := -1
+		for _, i := range slots {
+			if placed[i] {
+				continue
+			}
+			ready := true
+			for d := range declDeps[i] {
+				ready = ready && placed[d]
+			}
+			if ready {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			// Vars that depend on each other across declarations but on
+			// none of themselves keep their order
+			for _, i := range slots {
+				if !placed[i] {
+					next = i
+					break
+				}
+			}
+		}
+		placed[next] = true
+		order = append(order, next)
+	}
+	decls := make([]ast.Decl, len(order))
+	for k, i := range order {
+		decls[k] = file.Decls[i]
+	}
+	for k, i := range slots {
+		file.Decls[i] = decls[k]
+	}
+	return nil
+}
+
+// packageRefs returns the names of nodes that node refers to, in order of
+// first mention, leaving out the names it declares itself.
+func packageRefs(node ast.Node, nodes map[string]*initNode) []string {
+	locals := make(map[string]bool)
+	declare := func(fields *ast.FieldList) {
+		if fields == nil {
+			return
+		}
+		for _, field := range fields.List {
+			for _, name := range field.Names {
+				locals[name.Name] = true
+			}
+		}
+	}
+	// Names skipped as references: selected fields and methods, and the keys
+	// of composite literals
+	skip := make(map[*ast.Ident]bool)
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncDecl:
+			skip[n.Name] = true
+			declare(n.Recv)
+		case *ast.FuncType:
+			declare(n.TypeParams)
+			declare(n.Params)
+			declare(n.Results)
+		case *ast.AssignStmt:
+			if n.Tok == token.DEFINE {
+				for _, lhs := range n.Lhs {
+					if id, ok := lhs.(*ast.Ident); ok {
+						locals[id.Name] = true
+					}
+				}
+			}
+		case *ast.RangeStmt:
+			if n.Tok == token.DEFINE {
+				for _, e := range []ast.Expr{n.Key, n.Value} {
+					if id, ok := e.(*ast.Ident); ok {
+						locals[id.Name] = true
+					}
+				}
+			}
+		case *ast.DeclStmt:
+			if gen, ok := n.Decl.(*ast.GenDecl); ok {
+				for _, spec := range gen.Specs {
+					switch s := spec.(type) {
+					case *ast.ValueSpec:
+						for _, name := range s.Names {
+							locals[name.Name] = true
+						}
+					case *ast.TypeSpec:
+						locals[s.Name.Name] = true
+					}
+				}
+			}
+		case *ast.SelectorExpr:
+			skip[n.Sel] = true
+		case *ast.KeyValueExpr:
+			if id, ok := n.Key.(*ast.Ident); ok {
+				skip[id] = true
+			}
+		}
+		return true
+	})
+
+	var refs []string
+	seen := make(map[string]bool)
+	ast.Inspect(node, func(n ast.Node) bool {
+		id, ok := n.(*ast.Ident)
+		if !ok || skip[id] || locals[id.Name] || seen[id.Name] || nodes[id.Name] == nil {
+			return true
+		}
+		seen[id.Name] = true
+		refs = append(refs, id.Name)
+		return true
+	})
+	return refs
+}
@@ -0,0 +1,72 @@
+package transformer_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"martianoff/gala/galaerr"
+	"martianoff/gala/internal/transpiler"
+	"martianoff/gala/internal/transpiler/analyzer"
+	"martianoff/gala/internal/transpiler/generator"
+	"martianoff/gala/internal/transpiler/transformer"
+)
+
+func TestPackageValOrder(t *testing.T) {
+	p := transpiler.NewAntlrGalaParser()
+	trans := transpiler.NewGalaToGoTranspiler(p, analyzer.NewGalaAnalyzer(p, getStdSearchPath()),
+		transformer.NewGalaASTTransformer(), generator.NewGoCodeGenerator())
+
+	// total depends on base through sum, so base moves up into its slot
+	got, err := trans.Transpile(`package main
+
+val total = sum()
+
+func sum() int = base + 1
+
+var base = 41
+`, "main.gala")
+	assert.NoError(t, err)
+	base := strings.Index(got, "var base = 41")
+	sum := strings.Index(got, "func sum() int {")
+	total := strings.Index(got, "var total = std.NewImmutable(sum())")
+	assert.True(t, base >= 0 && sum >= 0 && total >= 0, got)
+	assert.True(t, base < sum && sum < total, got)
+
+	// Independent vals keep their order
+	got, err = trans.Transpile("package main\n\nval b = 2\n\nval a = 1\n", "main.gala")
+	assert.NoError(t, err)
+	assert.Less(t, strings.Index(got, "var b "), strings.Index(got, "var a "))
+
+	// A local with the name of a package val does not depend on it
+	got, err = trans.Transpile(`package main
+
+val first = pick()
+
+func pick() int {
+    val later = 1
+    return later
+}
+
+val later = 2
+`, "main.gala")
+	assert.NoError(t, err)
+	assert.Less(t, strings.Index(got, "var first "), strings.Index(got, "var later = std.NewImmutable(2)"))
+}
+
+func TestPackageValCycle(t *testing.T) {
+	p := transpiler.NewAntlrGalaParser()
+	trans := transpiler.NewGalaToGoTranspiler(p, analyzer.NewGalaAnalyzer(p, getStdSearchPath()),
+		transformer.NewGalaASTTransformer(), generator.NewGoCodeGenerator())
+
+	_, err := trans.Transpile(`package main
+
+val limit = twice()
+
+func twice() int = limit * 2
+`, "main.gala")
+	assert.Error(t, err)
+	assert.Equal(t, galaerr.CodeInitCycle, galaerr.CodeOf(err))
+	assert.Contains(t, err.Error(), "main.gala:3:1 initialization cycle: limit -> twice -> limit")
+}
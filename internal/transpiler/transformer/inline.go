@@ -0,0 +1,386 @@
+package transformer
+
+import (
+	"go/ast"
+	"go/token"
+
+	"martianoff/gala/internal/transpiler"
+)
+
+// This file substitutes the bodies of @inline functions at their call sites.
+// In a profile-guided build, functions the profile found hot are treated as if
+// they were annotated @inline.
+//
+// Only functions of the current file whose body is a single `return expr` are inlined,
+// and only at call sites where every argument is an identifier, a literal, a field
+// selection or an Immutable unwrap, so that substituting an argument more than once,
+// or in a different order, cannot change the program. The result is converted to the
+// declared result type so the expression keeps the type the call had. The function
+// declaration itself is kept.
+
+// inlineCandidate is an @inline or hot function whose body can be substituted.
+type inlineCandidate struct {
+	params     []*ast.Field
+	paramNames []string
+	result     ast.Expr
+	body       ast.Expr
+	freeNames  map[string]bool // identifiers of body that are not parameters
+}
+
+// inlineFunctions replaces calls to @inline and hot functions in decls with their bodies.
+func (t *galaASTTransformer) inlineFunctions(decls []ast.Decl) {
+	candidates := make(map[string]*inlineCandidate)
+	for _, decl := range decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv != nil || fn.Body == nil {
+			continue
+		}
+		meta := t.getFunction(fn.Name.Name)
+		if meta == nil {
+			continue
+		}
+		if _, ok := transpiler.FindAnnotation(meta.Annotations, transpiler.AnnotationInline); !ok && !t.hotFuncs[t.packageName+"."+fn.Name.Name] {
+			continue
+		}
+		if c := newInlineCandidate(fn); c != nil {
+			candidates[fn.Name.Name] = c
+		}
+	}
+	if len(candidates) == 0 {
+		return
+	}
+
+	for _, decl := range decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+		declared := declaredNames(fn)
+		slots := exprSlots(fn.Body)
+		// Innermost calls first, so their results are seen by the enclosing call
+		for i := len(slots) - 1; i >= 0; i-- {
+			call, ok := (*slots[i]).(*ast.CallExpr)
+			if !ok {
+				continue
+			}
+			id, ok := call.Fun.(*ast.Ident)
+			if !ok || id.Name == fn.Name.Name || declared[id.Name] {
+				continue
+			}
+			if c := candidates[id.Name]; c != nil {
+				if expr := c.expand(call, declared); expr != nil {
+					*slots[i] = expr
+				}
+			}
+		}
+	}
+}
+
+// newInlineCandidate returns the inlinable form of fn, or nil when fn cannot be inlined.
+func newInlineCandidate(fn *ast.FuncDecl) *inlineCandidate {
+	if fn.Type.TypeParams != nil || fn.Type.Results == nil || len(fn.Type.Results.List) != 1 || len(fn.Type.Results.List[0].Names) > 1 {
+		return nil
+	}
+	if len(fn.Body.List) != 1 {
+		return nil
+	}
+	ret, ok := fn.Body.List[0].(*ast.ReturnStmt)
+	if !ok || len(ret.Results) != 1 {
+		return nil
+	}
+	switch fn.Type.Results.List[0].Type.(type) {
+	case *ast.Ident, *ast.SelectorExpr, *ast.IndexExpr, *ast.IndexListExpr, *ast.ArrayType, *ast.MapType:
+	default:
+		return nil // conversion syntax would need parentheses
+	}
+
+	c := &inlineCandidate{result: fn.Type.Results.List[0].Type, body: ret.Results[0], freeNames: make(map[string]bool)}
+	params := make(map[string]bool)
+	for _, field := range fn.Type.Params.List {
+		if _, ok := field.Type.(*ast.Ellipsis); ok {
+			return nil
+		}
+		for _, n := range field.Names {
+			c.params = append(c.params, field)
+			c.paramNames = append(c.paramNames, n.Name)
+			params[n.Name] = true
+		}
+	}
+
+	inlinable := true
+	ast.Inspect(c.body, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.FuncLit:
+			inlinable = false // parameters of the literal could capture substituted arguments
+		case *ast.SelectorExpr:
+			ast.Inspect(x.X, func(n ast.Node) bool {
+				if id, ok := n.(*ast.Ident); ok && !params[id.Name] {
+					c.freeNames[id.Name] = true
+				}
+				return true
+			})
+			return false
+		case *ast.KeyValueExpr:
+			ast.Inspect(x.Value, func(n ast.Node) bool {
+				if id, ok := n.(*ast.Ident); ok && !params[id.Name] {
+					c.freeNames[id.Name] = true
+				}
+				return true
+			})
+			return false
+		case *ast.Ident:
+			if x.Name == fn.Name.Name {
+				inlinable = false
+			} else if !params[x.Name] {
+				c.freeNames[x.Name] = true
+			}
+		}
+		return inlinable
+	})
+	if !inlinable {
+		return nil
+	}
+	return c
+}
+
+// expand returns the body of c with the arguments of call substituted, or nil when
+// the call site is not safe to inline. declared holds the names declared in the caller.
+func (c *inlineCandidate) expand(call *ast.CallExpr, declared map[string]bool) ast.Expr {
+	if len(call.Args) != len(c.paramNames) || call.Ellipsis.IsValid() {
+		return nil
+	}
+	for name := range c.freeNames {
+		if declared[name] {
+			return nil // a local of the caller would shadow a name the body refers to
+		}
+	}
+	args := make(map[string]ast.Expr, len(call.Args))
+	for i, arg := range call.Args {
+		if !isSimpleArg(arg) {
+			return nil
+		}
+		if _, ok := arg.(*ast.BasicLit); ok {
+			// Keep the parameter type for untyped constants
+			arg = &ast.CallExpr{Fun: c.params[i].Type, Args: []ast.Expr{arg}}
+		}
+		args[c.paramNames[i]] = arg
+	}
+	body := substituteParams(c.body, args)
+	if body == nil {
+		return nil
+	}
+	return &ast.CallExpr{Fun: c.result, Args: []ast.Expr{body}}
+}
+
+// isSimpleArg reports whether e can be evaluated any number of times, in any order,
+// without changing the program.
+func isSimpleArg(e ast.Expr) bool {
+	switch x := e.(type) {
+	case *ast.Ident, *ast.BasicLit:
+		return true
+	case *ast.SelectorExpr:
+		return isSimpleArg(x.X)
+	case *ast.CallExpr:
+		// Immutable unwrap: v.Get()
+		sel, ok := x.Fun.(*ast.SelectorExpr)
+		return ok && sel.Sel.Name == transpiler.MethodGet && len(x.Args) == 0 && isSimpleArg(sel.X)
+	}
+	return false
+}
+
+// substituteParams returns a copy of e with parameter identifiers replaced by args.
+// It returns nil for expressions it does not know how to copy.
+func substituteParams(e ast.Expr, args map[string]ast.Expr) ast.Expr {
+	sub := func(x ast.Expr) ast.Expr {
+		if x == nil {
+			return nil
+		}
+		return substituteParams(x, args)
+	}
+	subAll := func(xs []ast.Expr) ([]ast.Expr, bool) {
+		out := make([]ast.Expr, len(xs))
+		for i, x := range xs {
+			if out[i] = sub(x); out[i] == nil {
+				return nil, false
+			}
+		}
+		return out, true
+	}
+
+	switch x := e.(type) {
+	case *ast.Ident:
+		if arg, ok := args[x.Name]; ok {
+			return arg
+		}
+		return ast.NewIdent(x.Name)
+	case *ast.BasicLit:
+		return &ast.BasicLit{Kind: x.Kind, Value: x.Value}
+	case *ast.ParenExpr:
+		if inner := sub(x.X); inner != nil {
+			return &ast.ParenExpr{X: inner}
+		}
+	case *ast.SelectorExpr:
+		if inner := sub(x.X); inner != nil {
+			return &ast.SelectorExpr{X: inner, Sel: ast.NewIdent(x.Sel.Name)}
+		}
+	case *ast.StarExpr:
+		if inner := sub(x.X); inner != nil {
+			return &ast.StarExpr{X: inner}
+		}
+	case *ast.UnaryExpr:
+		if inner := sub(x.X); inner != nil {
+			return &ast.UnaryExpr{Op: x.Op, X: inner}
+		}
+	case *ast.BinaryExpr:
+		l, r := sub(x.X), sub(x.Y)
+		if l != nil && r != nil {
+			return &ast.BinaryExpr{X: l, Op: x.Op, Y: r}
+		}
+	case *ast.CallExpr:
+		fun := sub(x.Fun)
+		callArgs, ok := subAll(x.Args)
+		if fun != nil && ok {
+			return &ast.CallExpr{Fun: fun, Args: callArgs, Ellipsis: x.Ellipsis}
+		}
+	case *ast.IndexExpr:
+		l, r := sub(x.X), sub(x.Index)
+		if l != nil && r != nil {
+			return &ast.IndexExpr{X: l, Index: r}
+		}
+	case *ast.IndexListExpr:
+		l := sub(x.X)
+		indices, ok := subAll(x.Indices)
+		if l != nil && ok {
+			return &ast.IndexListExpr{X: l, Indices: indices}
+		}
+	case *ast.CompositeLit:
+		elts := make([]ast.Expr, len(x.Elts))
+		for i, elt := range x.Elts {
+			if kv, ok := elt.(*ast.KeyValueExpr); ok {
+				// Struct keys are field names, not references
+				v := sub(kv.Value)
+				if v == nil {
+					return nil
+				}
+				elts[i] = &ast.KeyValueExpr{Key: kv.Key, Value: v}
+			} else if elts[i] = sub(elt); elts[i] == nil {
+				return nil
+			}
+		}
+		return &ast.CompositeLit{Type: x.Type, Elts: elts}
+	}
+	return nil
+}
+
+// declaredNames collects every name fn declares: parameters, results, locals,
+// local types and labels.
+func declaredNames(fn *ast.FuncDecl) map[string]bool {
+	names := make(map[string]bool)
+	ast.Inspect(fn, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.Field:
+			for _, id := range x.Names {
+				names[id.Name] = true
+			}
+		case *ast.AssignStmt:
+			if x.Tok == token.DEFINE {
+				for _, l := range x.Lhs {
+					if id, ok := l.(*ast.Ident); ok {
+						names[id.Name] = true
+					}
+				}
+			}
+		case *ast.ValueSpec:
+			for _, id := range x.Names {
+				names[id.Name] = true
+			}
+		case *ast.RangeStmt:
+			if x.Tok == token.DEFINE {
+				for _, e := range []ast.Expr{x.Key, x.Value} {
+					if id, ok := e.(*ast.Ident); ok {
+						names[id.Name] = true
+					}
+				}
+			}
+		case *ast.TypeSpec:
+			names[x.Name.Name] = true
+		case *ast.LabeledStmt:
+			names[x.Label.Name] = true
+		}
+		return true
+	})
+	return names
+}
+
+// exprSlots returns the addresses of the value expressions below node in visit order.
+func exprSlots(node ast.Node) []*ast.Expr {
+	var slots []*ast.Expr
+	ast.Inspect(node, func(n ast.Node) bool {
+		slots = append(slots, childExprSlots(n)...)
+		return true
+	})
+	return slots
+}
+
+// childExprSlots returns the addresses of the value expressions directly below n.
+func childExprSlots(n ast.Node) []*ast.Expr {
+	var slots []*ast.Expr
+	add := func(es ...*ast.Expr) {
+		for _, e := range es {
+			if *e != nil {
+				slots = append(slots, e)
+			}
+		}
+	}
+	addAll := func(es []ast.Expr) {
+		for i := range es {
+			add(&es[i])
+		}
+	}
+	switch x := n.(type) {
+	case *ast.ParenExpr:
+		add(&x.X)
+	case *ast.SelectorExpr:
+		add(&x.X)
+	case *ast.IndexExpr:
+		add(&x.X, &x.Index)
+	case *ast.SliceExpr:
+		add(&x.X, &x.Low, &x.High, &x.Max)
+	case *ast.TypeAssertExpr:
+		add(&x.X)
+	case *ast.CallExpr:
+		add(&x.Fun)
+		addAll(x.Args)
+	case *ast.StarExpr:
+		add(&x.X)
+	case *ast.UnaryExpr:
+		add(&x.X)
+	case *ast.BinaryExpr:
+		add(&x.X, &x.Y)
+	case *ast.KeyValueExpr:
+		add(&x.Value)
+	case *ast.CompositeLit:
+		addAll(x.Elts)
+	case *ast.ExprStmt:
+		add(&x.X)
+	case *ast.SendStmt:
+		add(&x.Chan, &x.Value)
+	case *ast.AssignStmt:
+		addAll(x.Rhs)
+	case *ast.ReturnStmt:
+		addAll(x.Results)
+	case *ast.IfStmt:
+		add(&x.Cond)
+	case *ast.SwitchStmt:
+		add(&x.Tag)
+	case *ast.CaseClause:
+		addAll(x.List)
+	case *ast.ForStmt:
+		add(&x.Cond)
+	case *ast.RangeStmt:
+		add(&x.X)
+	case *ast.ValueSpec:
+		addAll(x.Values)
+	}
+	return slots
+}
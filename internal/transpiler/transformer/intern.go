@@ -0,0 +1,313 @@
+package transformer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"hash/fnv"
+	"strconv"
+
+	"martianoff/gala/internal/transpiler"
+	"martianoff/gala/internal/transpiler/registry"
+)
+
+// This file implements interning of constant composite values built inside loops.
+//
+// A value is constant when it is built only from literals: a struct literal of a
+// GALA type, a sealed variant constructor (Circle(1.0), None[int]()) or a
+// NewImmutable call, whose arguments are again literals or constant values.
+// Such a value is the same on every iteration, so with gala build -O, instead
+// of being rebuilt each time it is hoisted into a package-level var:
+//
+//	for ... { f(None[int]()) }
+//
+// becomes
+//
+//	var _lit_5e0c7a12_0 = std.None[int]{}.Apply()
+//	for ... { f(_lit_5e0c7a12_0) }
+//
+// Values are copied out of the var, so sharing it is safe; operands of & are
+// left alone, as are values that mention type parameters of the enclosing
+// function and values of types with pointer-receiver methods, which could
+// change the var through it. Vars are numbered in the file and named after a
+// hash of the file path, so files of one package never collide; equal
+// expressions of one file share a var.
+
+// WithLiteralInterning makes tr, a transformer created by this package, hoist
+// the constant values built in loops into package-level vars as described above.
+func WithLiteralInterning(tr transpiler.ASTTransformer) transpiler.ASTTransformer {
+	tr.(*galaASTTransformer).internLiterals = true
+	return tr
+}
+
+// internConstantLiterals hoists the constant values built inside the loops of
+// the functions in file into package-level vars appended to file.Decls.
+func (t *galaASTTransformer) internConstantLiterals(file *ast.File) {
+	if !t.internLiterals {
+		return
+	}
+	h := fnv.New32a()
+	h.Write([]byte(t.filePath))
+	in := &interner{t: t, prefix: fmt.Sprintf("_lit_%08x_", h.Sum32()), vars: make(map[string]string)}
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+		in.typeParams = funcTypeParams(fn)
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			switch loop := n.(type) {
+			case *ast.ForStmt:
+				if loop.Cond != nil {
+					in.internSlot(&loop.Cond)
+					in.internBelow(loop.Cond)
+				}
+				if loop.Post != nil {
+					in.internBelow(loop.Post)
+				}
+				in.internBelow(loop.Body)
+				return false
+			case *ast.RangeStmt:
+				in.internBelow(loop.Body)
+				return false
+			}
+			return true
+		})
+	}
+	file.Decls = append(file.Decls, in.decls...)
+}
+
+// interner collects the package-level vars created for one file.
+type interner struct {
+	t          *galaASTTransformer
+	typeParams map[string]bool   // type parameters in scope of the current function
+	prefix     string            // names of the vars of the file, followed by their number
+	vars       map[string]string // var name by the source of its value
+	decls      []ast.Decl
+}
+
+// funcTypeParams returns the names of the type parameters of fn and of its receiver.
+func funcTypeParams(fn *ast.FuncDecl) map[string]bool {
+	names := make(map[string]bool)
+	if fn.Type.TypeParams != nil {
+		for _, field := range fn.Type.TypeParams.List {
+			for _, id := range field.Names {
+				names[id.Name] = true
+			}
+		}
+	}
+	if fn.Recv != nil && len(fn.Recv.List) > 0 {
+		recv := fn.Recv.List[0].Type
+		if star, ok := recv.(*ast.StarExpr); ok {
+			recv = star.X
+		}
+		var indices []ast.Expr
+		switch r := recv.(type) {
+		case *ast.IndexExpr:
+			indices = []ast.Expr{r.Index}
+		case *ast.IndexListExpr:
+			indices = r.Indices
+		}
+		for _, idx := range indices {
+			if id, ok := idx.(*ast.Ident); ok {
+				names[id.Name] = true
+			}
+		}
+	}
+	return names
+}
+
+// internBelow interns the constant values among the expressions below node.
+func (in *interner) internBelow(node ast.Node) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		// The address of a shared var would let callers mutate it
+		if u, ok := n.(*ast.UnaryExpr); ok && u.Op == token.AND {
+			return true
+		}
+		for _, slot := range childExprSlots(n) {
+			in.internSlot(slot)
+		}
+		return true
+	})
+}
+
+// internSlot replaces the value in slot by a package-level var when it is a
+// constant worth interning.
+func (in *interner) internSlot(slot *ast.Expr) {
+	e := *slot
+	if !in.isInternRoot(e) || !in.isConstant(e) || in.mentionsTypeParam(e) || in.hasPointerMethods(e) {
+		return
+	}
+	src := types.ExprString(e)
+	name, ok := in.vars[src]
+	if !ok {
+		name = in.prefix + strconv.Itoa(len(in.vars))
+		in.vars[src] = name
+		in.decls = append(in.decls, &ast.GenDecl{
+			Tok: token.VAR,
+			Specs: []ast.Spec{&ast.ValueSpec{
+				Names:  []*ast.Ident{ast.NewIdent(name)},
+				Values: []ast.Expr{e},
+			}},
+		})
+	}
+	*slot = ast.NewIdent(name)
+}
+
+// isInternRoot reports whether e allocates or builds enough to be worth a var:
+// a non-empty struct literal or a sealed variant constructor.
+func (in *interner) isInternRoot(e ast.Expr) bool {
+	switch x := e.(type) {
+	case *ast.CompositeLit:
+		return len(x.Elts) > 0
+	case *ast.CallExpr:
+		return in.isSealedConstructor(x)
+	}
+	return false
+}
+
+// hasPointerMethods reports whether a value built in e has a type with
+// pointer-receiver methods, through which a caller could modify a shared var.
+func (in *interner) hasPointerMethods(e ast.Expr) bool {
+	found := false
+	ast.Inspect(e, func(n ast.Node) bool {
+		var meta *transpiler.TypeMetadata
+		switch x := n.(type) {
+		case *ast.CompositeLit:
+			meta = in.t.getTypeMeta(in.t.getBaseTypeName(x.Type))
+		case *ast.CallExpr:
+			meta = in.sealedConstructorType(x)
+		}
+		if meta != nil {
+			for _, m := range meta.Methods {
+				found = found || m.PointerReceiver
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// isConstant reports whether e is built only from literals.
+func (in *interner) isConstant(e ast.Expr) bool {
+	switch x := e.(type) {
+	case *ast.BasicLit:
+		return true
+	case *ast.Ident:
+		return x.Name == "true" || x.Name == "false" || x.Name == "nil"
+	case *ast.ParenExpr:
+		return in.isConstant(x.X)
+	case *ast.UnaryExpr:
+		return x.Op != token.AND && x.Op != token.ARROW && in.isConstant(x.X)
+	case *ast.BinaryExpr:
+		return in.isConstant(x.X) && in.isConstant(x.Y)
+	case *ast.CompositeLit:
+		if x.Type == nil || in.t.getTypeMeta(in.t.getBaseTypeName(x.Type)) == nil {
+			return false
+		}
+		for _, elt := range x.Elts {
+			if kv, ok := elt.(*ast.KeyValueExpr); ok {
+				elt = kv.Value
+			}
+			if !in.isConstant(elt) {
+				return false
+			}
+		}
+		return true
+	case *ast.CallExpr:
+		if !in.isSealedConstructor(x) && !in.isNewImmutable(x) && !isPrimitiveConversion(x) {
+			return false
+		}
+		for _, arg := range x.Args {
+			if !in.isConstant(arg) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// isSealedConstructor reports whether call is Variant{}.Apply(...) for a sealed
+// variant, whose generated Apply only builds the parent struct.
+func (in *interner) isSealedConstructor(call *ast.CallExpr) bool {
+	return in.sealedConstructorType(call) != nil
+}
+
+// sealedConstructorType returns the sealed type call builds when it is a
+// sealed variant constructor, and nil otherwise.
+func (in *interner) sealedConstructorType(call *ast.CallExpr) *transpiler.TypeMetadata {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Apply" {
+		return nil
+	}
+	lit, ok := sel.X.(*ast.CompositeLit)
+	if !ok || len(lit.Elts) > 0 {
+		return nil
+	}
+	companion := in.t.getTypeMeta(in.t.getBaseTypeName(lit.Type))
+	if companion == nil || companion.Methods["Apply"] == nil {
+		return nil
+	}
+	// A generic companion must be instantiated explicitly to be used outside its context
+	switch lit.Type.(type) {
+	case *ast.IndexExpr, *ast.IndexListExpr:
+	default:
+		if len(companion.TypeParams) > 0 {
+			return nil
+		}
+	}
+	ret := companion.Methods["Apply"].ReturnType
+	if ret == nil || ret.IsNil() {
+		return nil
+	}
+	parent := in.t.getTypeMeta(ret.BaseName())
+	if parent == nil || !parent.IsSealed {
+		return nil
+	}
+	for _, v := range parent.SealedVariants {
+		if v.Name == companion.Name {
+			return parent
+		}
+	}
+	return nil
+}
+
+// isNewImmutable reports whether call is std.NewImmutable, with or without type arguments.
+func (in *interner) isNewImmutable(call *ast.CallExpr) bool {
+	fun := call.Fun
+	switch f := fun.(type) {
+	case *ast.IndexExpr:
+		fun = f.X
+	case *ast.IndexListExpr:
+		fun = f.X
+	}
+	switch f := fun.(type) {
+	case *ast.SelectorExpr:
+		id, ok := f.X.(*ast.Ident)
+		return ok && id.Name == registry.StdPackageName && f.Sel.Name == "NewImmutable"
+	case *ast.Ident:
+		return f.Name == "NewImmutable" && (in.t.packageName == registry.StdPackageName || in.t.importManager.IsDotImported(registry.StdPackageName))
+	}
+	return false
+}
+
+// isPrimitiveConversion reports whether call converts its argument to a primitive type, e.g. int64(1).
+func isPrimitiveConversion(call *ast.CallExpr) bool {
+	id, ok := call.Fun.(*ast.Ident)
+	return ok && len(call.Args) == 1 && transpiler.IsPrimitiveType(id.Name)
+}
+
+// mentionsTypeParam reports whether e refers to a type parameter in scope,
+// which a package-level var cannot see.
+func (in *interner) mentionsTypeParam(e ast.Expr) bool {
+	found := false
+	ast.Inspect(e, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && in.typeParams[id.Name] {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
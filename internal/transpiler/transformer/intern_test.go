@@ -0,0 +1,170 @@
+package transformer_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"martianoff/gala/internal/transpiler"
+	"martianoff/gala/internal/transpiler/analyzer"
+	"martianoff/gala/internal/transpiler/generator"
+	"martianoff/gala/internal/transpiler/transformer"
+)
+
+func TestInternConstantLiterals(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		contains    []string
+		notContains []string
+		vars        int
+	}{
+		{
+			name: "None in a loop",
+			input: `package main
+
+func firsts(n int) int {
+	var count = 0
+	for i := 0; i < n; i++ {
+		val o = None[int]()
+		count = count + o.GetOrElse(i)
+	}
+	return count
+}
+`,
+			contains: []string{"var _lit_811c9dc5_0 = std.None[int]{}.Apply()\n", "var o = std.NewImmutable(_lit_811c9dc5_0)"},
+			vars:     1,
+		},
+		{
+			name: "equal constants share a var",
+			input: `package main
+
+sealed type Shape {
+	case Circle(Radius float64)
+	case Square(Side float64)
+}
+
+func total(n int) int {
+	var count = 0
+	for i := 0; i < n; i++ {
+		val a = Circle(1.0)
+		val b = Circle(1.0)
+		val c = Square(2.0)
+		count = count + 1
+	}
+	return count
+}
+`,
+			contains: []string{"var _lit_811c9dc5_0 = Circle{}.Apply(1.0)\n", "var _lit_811c9dc5_1 = Square{}.Apply(2.0)\n"},
+			vars:     2,
+		},
+		{
+			name: "constant struct literal",
+			input: `package main
+
+struct Point(X int, Y int)
+
+func sum(n int) int {
+	var s = 0
+	for i := 0; i < n; i++ {
+		val p = Point(1, 2)
+		s = s + p.X
+	}
+	return s
+}
+`,
+			contains: []string{"= Point{X: std.NewImmutable(1), Y: std.NewImmutable(2)}\n"},
+			vars:     1,
+		},
+		{
+			name: "values outside loops and non-constant values stay",
+			input: `package main
+
+struct Point(X int, Y int)
+
+func sum(n int) int {
+	val origin = Point(0, 0)
+	var s = origin.X
+	for i := 0; i < n; i++ {
+		val p = Point(i, 2)
+		s = s + p.X
+	}
+	return s
+}
+`,
+			notContains: []string{"_lit_"},
+		},
+		{
+			name: "type parameters of the function",
+			input: `package main
+
+func count[T any](n int) int {
+	var c = 0
+	for i := 0; i < n; i++ {
+		val o = None[T]()
+		c = c + 1
+	}
+	return c
+}
+`,
+			notContains: []string{"_lit_"},
+		},
+		{
+			name: "types with pointer-receiver methods",
+			input: `package main
+
+struct Cart(Items int)
+
+func (c *Cart) Count() int = c.Items
+
+func total(n int) int {
+	var s = 0
+	for i := 0; i < n; i++ {
+		val c = Cart(1)
+		s = s + c.Items
+	}
+	return s
+}
+`,
+			notContains: []string{"_lit_"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := transpiler.NewAntlrGalaParser()
+			trans := transpiler.NewGalaToGoTranspiler(p, analyzer.NewGalaAnalyzer(p, getStdSearchPath()),
+				transformer.WithLiteralInterning(transformer.NewGalaASTTransformer()), generator.NewGoCodeGenerator())
+			got, err := trans.Transpile(tt.input, "")
+			assert.NoError(t, err)
+			for _, want := range tt.contains {
+				assert.Contains(t, got, want)
+			}
+			for _, unwanted := range tt.notContains {
+				assert.NotContains(t, got, unwanted)
+			}
+			assert.Equal(t, tt.vars, strings.Count(got, "\nvar _lit_"))
+		})
+	}
+}
+
+func TestInternConstantLiteralsNeedsOption(t *testing.T) {
+	input := `package main
+
+func firsts(n int) int {
+	var count = 0
+	for i := 0; i < n; i++ {
+		val o = None[int]()
+		count = count + o.GetOrElse(i)
+	}
+	return count
+}
+`
+	p := transpiler.NewAntlrGalaParser()
+	trans := transpiler.NewGalaToGoTranspiler(p, analyzer.NewGalaAnalyzer(p, getStdSearchPath()),
+		transformer.NewGalaASTTransformer(), generator.NewGoCodeGenerator())
+	got, err := trans.Transpile(input, "")
+	assert.NoError(t, err)
+	assert.NotContains(t, got, "_lit_")
+}
@@ -0,0 +1,166 @@
+package transformer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strconv"
+
+	"martianoff/gala/galaerr"
+	"martianoff/gala/internal/parser/grammar"
+	"martianoff/gala/internal/transpiler"
+)
+
+// This file contains the smart constructors of shorthand structs whose fields
+// carry require clauses. Such a struct is only built through its Apply, which
+// returns a std.Validated, so GALA code cannot create a value that breaks an
+// invariant.
+
+// generateSmartConstructor generates the Apply of a shorthand struct with
+// invariants. It checks every require clause and builds the struct only if all
+// of them hold:
+//
+//	func (_ User) Apply(Name string) std.Validated[User] {
+//		var errs []error
+//		if !(Name != "") {
+//			errs = append(errs, std.InvariantError{Type: "User", Field: "Name", Requirement: "Name != \"\""})
+//		}
+//		if len(errs) > 0 {
+//			return std.Invalid[User]{}.Apply(errs)
+//		}
+//		return std.Valid[User]{}.Apply(User{Name: std.NewImmutable(Name)})
+//	}
+func (t *galaASTTransformer) generateSmartConstructor(name string, paramsCtx *grammar.ParametersContext, invariants []transpiler.Invariant, immutFlags []bool) (*ast.FuncDecl, error) {
+	t.pushScope()
+	defer t.popScope()
+
+	requirements := make(map[string]string)
+	for _, inv := range invariants {
+		requirements[inv.Field] = inv.Requirement
+	}
+	allParams := paramsCtx.ParameterList().(*grammar.ParameterListContext).AllParameter()
+
+	// Every field is in scope in every clause, as a plain parameter
+	params := &ast.FieldList{}
+	var elts []ast.Expr
+	for i, p := range allParams {
+		param := p.(*grammar.ParameterContext)
+		field := param.Identifier().GetText()
+		if param.Type_() == nil {
+			return nil, t.semanticErrorAt(param, fmt.Sprintf("field %s of %s needs a type", field, name))
+		}
+		typ, err := t.transformType(param.Type_())
+		if err != nil {
+			return nil, err
+		}
+		params.List = append(params.List, &ast.Field{Names: []*ast.Ident{ast.NewIdent(field)}, Type: typ})
+		t.addVar(field, t.exprToType(typ))
+
+		var value ast.Expr = ast.NewIdent(field)
+		if i < len(immutFlags) && immutFlags[i] {
+			value = &ast.CallExpr{Fun: t.stdIdent(transpiler.FuncNewImmutable), Args: []ast.Expr{value}}
+		}
+		elts = append(elts, &ast.KeyValueExpr{Key: ast.NewIdent(field), Value: value})
+	}
+
+	errs := ast.NewIdent("errs")
+	body := []ast.Stmt{
+		&ast.DeclStmt{Decl: &ast.GenDecl{
+			Tok:   token.VAR,
+			Specs: []ast.Spec{&ast.ValueSpec{Names: []*ast.Ident{errs}, Type: &ast.ArrayType{Elt: ast.NewIdent("error")}}},
+		}},
+	}
+	for _, p := range allParams {
+		param := p.(*grammar.ParameterContext)
+		if param.REQUIRE() == nil {
+			continue
+		}
+		cond, err := t.transformExpression(param.Expression())
+		if err != nil {
+			return nil, err
+		}
+		field := param.Identifier().GetText()
+		violation := &ast.CompositeLit{
+			Type: t.stdIdent("InvariantError"),
+			Elts: []ast.Expr{
+				&ast.KeyValueExpr{Key: ast.NewIdent("Type"), Value: stringLit(name)},
+				&ast.KeyValueExpr{Key: ast.NewIdent("Field"), Value: stringLit(field)},
+				&ast.KeyValueExpr{Key: ast.NewIdent("Requirement"), Value: stringLit(requirements[field])},
+			},
+		}
+		body = append(body, &ast.IfStmt{
+			Cond: &ast.UnaryExpr{Op: token.NOT, X: &ast.ParenExpr{X: cond}},
+			Body: &ast.BlockStmt{List: []ast.Stmt{&ast.AssignStmt{
+				Lhs: []ast.Expr{errs},
+				Tok: token.ASSIGN,
+				Rhs: []ast.Expr{&ast.CallExpr{Fun: ast.NewIdent("append"), Args: []ast.Expr{errs, violation}}},
+			}}},
+		})
+	}
+
+	companion := func(variant string, arg ast.Expr) ast.Stmt {
+		return &ast.ReturnStmt{Results: []ast.Expr{&ast.CallExpr{
+			Fun: &ast.SelectorExpr{
+				X:   &ast.CompositeLit{Type: &ast.IndexExpr{X: t.stdIdent(variant), Index: ast.NewIdent(name)}},
+				Sel: ast.NewIdent("Apply"),
+			},
+			Args: []ast.Expr{arg},
+		}}}
+	}
+	body = append(body,
+		&ast.IfStmt{
+			Cond: &ast.BinaryExpr{
+				X:  &ast.CallExpr{Fun: ast.NewIdent("len"), Args: []ast.Expr{errs}},
+				Op: token.GTR,
+				Y:  &ast.BasicLit{Kind: token.INT, Value: "0"},
+			},
+			Body: &ast.BlockStmt{List: []ast.Stmt{companion("Invalid", errs)}},
+		},
+		companion("Valid", &ast.CompositeLit{Type: ast.NewIdent(name), Elts: elts}),
+	)
+
+	return &ast.FuncDecl{
+		Recv: &ast.FieldList{List: []*ast.Field{{Names: []*ast.Ident{ast.NewIdent("_")}, Type: ast.NewIdent(name)}}},
+		Name: ast.NewIdent("Apply"),
+		Type: &ast.FuncType{
+			Params: params,
+			Results: &ast.FieldList{List: []*ast.Field{{
+				Type: &ast.IndexExpr{X: t.stdIdent("Validated"), Index: ast.NewIdent(name)},
+			}}},
+		},
+		Body: &ast.BlockStmt{List: body},
+	}, nil
+}
+
+// smartConstructorCall builds a struct with invariants from positional and
+// named arguments by calling its Apply with the arguments in field order.
+func (t *galaASTTransformer) smartConstructorCall(fun ast.Expr, typeName string, fields []string, args []ast.Expr, namedArgs map[string]ast.Expr) (ast.Expr, error) {
+	if err := checkConstructorArgs(typeName, fields, args, namedArgs); err != nil {
+		return nil, err
+	}
+	ordered := append([]ast.Expr(nil), args...)
+	for _, field := range fields[len(args):] {
+		val, ok := namedArgs[field]
+		if !ok {
+			return nil, galaerr.NewSemanticError(fmt.Sprintf("missing field %s in construction of %s, whose Apply checks all fields", field, typeName))
+		}
+		ordered = append(ordered, val)
+	}
+	return &ast.CallExpr{
+		Fun:  &ast.SelectorExpr{X: &ast.CompositeLit{Type: fun}, Sel: ast.NewIdent("Apply")},
+		Args: ordered,
+	}, nil
+}
+
+// checkNoRequire rejects a require clause on a parameter that is not a field
+// of a shorthand struct.
+func (t *galaASTTransformer) checkNoRequire(param *grammar.ParameterContext) error {
+	if param.REQUIRE() != nil {
+		return t.semanticErrorAt(param, "require clauses are only allowed on the fields of a shorthand struct")
+	}
+	return nil
+}
+
+func stringLit(s string) *ast.BasicLit {
+	return &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(s)}
+}
@@ -0,0 +1,134 @@
+package transformer_test
+
+import (
+	"martianoff/gala/internal/transpiler"
+	"martianoff/gala/internal/transpiler/analyzer"
+	"martianoff/gala/internal/transpiler/generator"
+	"martianoff/gala/internal/transpiler/transformer"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSmartConstructors(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		contains    []string
+		notContains []string
+		wantErr     string
+	}{
+		{
+			name: "Apply checks every require clause",
+			input: `package main
+
+struct User(Name string require Name != "", Age int require Age >= 0 && Age < 150, var Visits int)`,
+			contains: []string{
+				"func (_ User) Apply(Name string, Age int, Visits int) std.Validated[User] {",
+				"\tvar errs []error\n",
+				"if !(Name != \"\") {\n\t\terrs = append(errs, std.InvariantError{Type: \"User\", Field: \"Name\", Requirement: \"Name != \\\"\\\"\"})",
+				"if !(Age >= 0 && Age < 150) {",
+				"Requirement: \"Age >= 0 && Age < 150\"",
+				"if len(errs) > 0 {\n\t\treturn std.Invalid[User]{}.Apply(errs)\n\t}",
+				"return std.Valid[User]{}.Apply(User{Name: std.NewImmutable(Name), Age: std.NewImmutable(Age), Visits: Visits})",
+			},
+		},
+		{
+			name: "Struct without invariants has no Apply",
+			input: `package main
+
+struct Point(X int, Y int)`,
+			notContains: []string{"Apply"},
+		},
+		{
+			name: "Construction goes through Apply",
+			input: `package main
+
+struct User(Name string require Name != "", Age int)
+
+func main() {
+	val a = User("ann", 31)
+	val b = User(Age = 42, Name = "bob")
+	println(a.IsValid(), b.IsValid())
+}`,
+			contains: []string{
+				`User{}.Apply("ann", 31)`,
+				`User{}.Apply("bob", 42)`,
+			},
+			notContains: []string{"User{Name:"},
+		},
+		{
+			name: "Missing named field",
+			input: `package main
+
+struct User(Name string require Name != "", Age int)
+
+func main() {
+	val u = User(Name = "ann")
+	println(u.IsValid())
+}`,
+			wantErr: "missing field Age in construction of User",
+		},
+		{
+			name: "Unknown named field",
+			input: `package main
+
+struct User(Name string require Name != "", Age int)
+
+func main() {
+	val u = User(Name = "a", Age = 1, Nmae = "b")
+	println(u.IsValid())
+}`,
+			wantErr: "User has no field Nmae; User has 2 fields, Name and Age",
+		},
+		{
+			name: "Too many positional arguments",
+			input: `package main
+
+struct User(Name string require Name != "", Age int)
+
+func main() {
+	val u = User("ann", 31, 7)
+	println(u.IsValid())
+}`,
+			wantErr: "too many arguments in construction of User: got 3, but User has 2 fields, Name and Age",
+		},
+		{
+			name: "Copy overrides",
+			input: `package main
+
+struct User(Name string require Name != "")
+
+func rename(u User) User = u.Copy(Name = "")`,
+			wantErr: "Copy overrides would skip the require clauses of User",
+		},
+		{
+			name: "Require on a function parameter",
+			input: `package main
+
+func half(n int require n % 2 == 0) int = n / 2`,
+			wantErr: "require clauses are only allowed on the fields of a shorthand struct",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := transpiler.NewAntlrGalaParser()
+			a := analyzer.NewGalaAnalyzer(p, getStdSearchPath())
+			trans := transpiler.NewGalaToGoTranspiler(p, a, transformer.NewGalaASTTransformer(), generator.NewGoCodeGenerator())
+
+			got, err := trans.Transpile(tt.input, "")
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			for _, s := range tt.contains {
+				assert.Contains(t, got, s)
+			}
+			for _, s := range tt.notContains {
+				assert.NotContains(t, got, s)
+			}
+		})
+	}
+}
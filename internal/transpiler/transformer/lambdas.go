@@ -33,6 +33,9 @@ func (t *galaASTTransformer) transformLambdaWithExpectedType(ctx *grammar.Lambda
 	if paramsCtx.ParameterList() != nil {
 		for i, pCtx := range paramsCtx.ParameterList().(*grammar.ParameterListContext).AllParameter() {
 			paramCtx := pCtx.(*grammar.ParameterContext)
+			if err := t.checkNoRequire(paramCtx); err != nil {
+				return nil, err
+			}
 			field, err := t.transformParameter(paramCtx)
 			if err != nil {
 				return nil, err
@@ -0,0 +1,147 @@
+package transformer
+
+import (
+	"go/ast"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// This file keeps the generated file laid out like its source so that diffs of
+// committed generated code stay small.
+//
+// Top-level declarations that are separated by a blank line in the source are
+// separated by a blank line in the output; declarations written back-to-back
+// stay together. Imports are merged into a single block and grouped as
+// standard library, external Go modules and GALA packages.
+
+// importGroup orders the sections of the generated import block.
+type importGroup int
+
+const (
+	importGroupStd importGroup = iota
+	importGroupExternal
+	importGroupGala
+)
+
+// blankLineBetween reports whether the source has an empty line strictly
+// between the 1-based lines from and to.
+func (t *galaASTTransformer) blankLineBetween(from, to int) bool {
+	for line := from + 1; line < to; line++ {
+		if line-1 < len(t.sourceLines) && strings.TrimSpace(t.sourceLines[line-1]) == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// markNewSection makes the printer start decl on a new paragraph. go/printer
+// always puts a blank line before a declaration that carries a doc comment,
+// so an empty group is enough; attachDocs replaces it with the real comment.
+func markNewSection(decl ast.Decl) {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		if d.Doc == nil {
+			d.Doc = &ast.CommentGroup{}
+		}
+	case *ast.GenDecl:
+		if d.Doc == nil {
+			d.Doc = &ast.CommentGroup{}
+		}
+	}
+}
+
+// classifyImport returns the import block section a package path belongs to.
+func classifyImport(path string, galaPkgs map[string]string) importGroup {
+	if _, ok := galaPkgs[path]; ok {
+		return importGroupGala
+	}
+	if first, _, _ := strings.Cut(path, "/"); strings.Contains(first, ".") {
+		return importGroupExternal
+	}
+	return importGroupStd
+}
+
+// groupImports merges the leading import declarations of file into one block
+// and separates its std, external and GALA sections with blank lines.
+// A file with a single import declaration and a single section is left as is.
+func (t *galaASTTransformer) groupImports(fset *token.FileSet, file *ast.File, galaPkgs map[string]string) {
+	n := 0
+	var specs []*ast.ImportSpec
+	for n < len(file.Decls) {
+		gen, ok := file.Decls[n].(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			break
+		}
+		for _, s := range gen.Specs {
+			specs = append(specs, s.(*ast.ImportSpec))
+		}
+		n++
+	}
+	if len(specs) < 2 {
+		return
+	}
+
+	groupOf := func(s *ast.ImportSpec) importGroup {
+		return classifyImport(strings.Trim(s.Path.Value, `"`), galaPkgs)
+	}
+	sort.SliceStable(specs, func(i, j int) bool { return groupOf(specs[i]) < groupOf(specs[j]) })
+	sections := 1
+	for i := 1; i < len(specs); i++ {
+		if groupOf(specs[i]) != groupOf(specs[i-1]) {
+			sections++
+		}
+	}
+	if n == 1 && sections == 1 {
+		return
+	}
+
+	merged := &ast.GenDecl{Tok: token.IMPORT}
+	for _, s := range specs {
+		merged.Specs = append(merged.Specs, s)
+	}
+	if sections > 1 {
+		t.positionImportSections(fset, merged, groupOf)
+	}
+	file.Decls = append([]ast.Decl{merged}, file.Decls[n:]...)
+}
+
+// positionImportSections places the specs of an import block on consecutive
+// lines of a synthetic file, leaving an empty line between sections. The
+// printer keeps those gaps, and format.Node sorts each section by path.
+func (t *galaASTTransformer) positionImportSections(fset *token.FileSet, decl *ast.GenDecl, groupOf func(*ast.ImportSpec) importGroup) {
+	file := syntheticFile(fset, "gala-imports", 2*len(decl.Specs)+2)
+	line := 1
+	decl.TokPos = file.LineStart(line)
+	decl.Lparen = file.LineStart(line)
+	for i, s := range decl.Specs {
+		spec := s.(*ast.ImportSpec)
+		line++
+		if i > 0 && groupOf(spec) != groupOf(decl.Specs[i-1].(*ast.ImportSpec)) {
+			line++
+		}
+		if spec.Name != nil {
+			spec.Name.NamePos = file.LineStart(line)
+		}
+		spec.Path.ValuePos = file.LineStart(line)
+	}
+	decl.Rparen = file.LineStart(line + 1)
+}
+
+// syntheticLineWidth is the byte width of each line in a synthetic file. Lines
+// must be wider than any single token so that End positions stay on their line.
+const syntheticLineWidth = 4096
+
+// syntheticFile adds a file with the given number of lines to fset, used to
+// give generated nodes line positions that steer go/printer's spacing. A gap
+// below the file keeps small sentinel positions such as Lparen: 1 (used to
+// force parenthesized GenDecls) outside of it.
+func syntheticFile(fset *token.FileSet, name string, lineCount int) *token.File {
+	file := fset.AddFile(name, fset.Base()+1024, lineCount*syntheticLineWidth)
+	offsets := make([]int, lineCount)
+	for i := range offsets {
+		offsets[i] = i * syntheticLineWidth
+	}
+	file.SetLines(offsets)
+	return file
+}
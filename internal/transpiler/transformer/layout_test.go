@@ -0,0 +1,127 @@
+package transformer_test
+
+import (
+	"martianoff/gala/internal/transpiler"
+	"martianoff/gala/internal/transpiler/analyzer"
+	"martianoff/gala/internal/transpiler/generator"
+	"martianoff/gala/internal/transpiler/transformer"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSourceLayoutIsPreserved(t *testing.T) {
+	p := transpiler.NewAntlrGalaParser()
+	a := analyzer.NewGalaAnalyzer(p, getStdSearchPath())
+	tr := transformer.NewGalaASTTransformer()
+	g := generator.NewGoCodeGenerator()
+	trans := transpiler.NewGalaToGoTranspiler(p, a, tr, g)
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name: "blank lines between declarations are kept",
+			input: `package main
+
+func one() int = 1
+
+func two() int = 2
+func three() int = 3`,
+			expected: `package main
+
+func one() int {
+	return 1
+}
+
+func two() int {
+	return 2
+}
+func three() int {
+	return 3
+}
+`,
+		},
+		{
+			name:  "build constraint is kept",
+			input: "//go:build linux || darwin\n\npackage main\n\nfunc one() int = 1",
+			expected: `//go:build linux || darwin
+
+package main
+
+func one() int {
+	return 1
+}
+`,
+		},
+		{
+			name: "imports are grouped by origin",
+			input: `package main
+
+import (
+    ci "martianoff/gala/collection_immutable"
+    "strings"
+)
+
+func upper(s string) string = strings.ToUpper(s)
+func size(a ci.Array[int]) int = a.Length()`,
+			expected: `package main
+
+import (
+	"strings"
+
+	ci "martianoff/gala/collection_immutable"
+)
+
+func upper(s string) string {
+	return strings.ToUpper(s)
+}
+func size(a ci.Array[int]) int {
+	return a.Length()
+}
+`,
+		},
+		{
+			name: "implicit std import joins the source imports",
+			input: `package main
+
+import "strings"
+
+val greeting = strings.ToUpper("hi")`,
+			expected: `package main
+
+import (
+	"strings"
+
+	"martianoff/gala/std"
+)
+
+var greeting = std.NewImmutable(strings.ToUpper("hi"))
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := trans.Transpile(tt.input, "")
+			assert.NoError(t, err)
+			assert.Equal(t, strings.TrimSpace(tt.expected), strings.TrimSpace(stripGeneratedHeader(got)))
+		})
+	}
+}
+
+func TestProvenanceHeader(t *testing.T) {
+	p := transpiler.NewAntlrGalaParser()
+	a := analyzer.NewGalaAnalyzer(p, getStdSearchPath())
+	trans := transpiler.NewGalaToGoTranspiler(p, a, transformer.NewGalaASTTransformer(), generator.NewGoCodeGenerator(),
+		transpiler.WithRegenerateCommand("gala build"))
+
+	input := "package models\n\nfunc one() int = 1\n"
+	got, err := trans.Transpile(input, "models/user.gala")
+	assert.NoError(t, err)
+	assert.Regexp(t, `^// Code generated by gala \S+ from \S*models/user\.gala; DO NOT EDIT\.\n`, got)
+	assert.Contains(t, got, "\n// Source hash: "+transpiler.SourceHash(input)+"\n// Regenerate with: gala build\n\npackage models\n")
+}
@@ -269,6 +269,46 @@ func main() {
 			expectError: false,
 			contains:    `'\n'`,
 		},
+		{
+			name: "char literal with hex escape",
+			input: `package main
+
+func main() {
+    val c = '\x41'
+}`,
+			expectError: false,
+			contains:    `'\x41'`,
+		},
+		{
+			name: "char literal with unicode escape",
+			input: `package main
+
+func main() {
+    val c = '\u00e9'
+}`,
+			expectError: false,
+			contains:    `'\u00e9'`,
+		},
+		{
+			name: "char arithmetic stays rune",
+			input: `package main
+
+func main() {
+    val c = Some('a' + 1)
+}`,
+			expectError: false,
+			contains:    "std.Some[rune]",
+		},
+		{
+			name: "int constant mixed with char is rune",
+			input: `package main
+
+func main() {
+    val c = Some(1 + 'a')
+}`,
+			expectError: false,
+			contains:    "std.Some[rune]",
+		},
 		{
 			name: "raw string literal",
 			input: "package main\n\nfunc main() {\n    val s = `hello raw`\n}",
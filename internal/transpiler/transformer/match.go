@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"go/ast"
 	"go/token"
+	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/antlr4-go/antlr/v4"
@@ -23,13 +25,19 @@ func (t *galaASTTransformer) transformMatchExpression(ctx grammar.IExpressionCon
 	defer t.popScope()
 	t.addVar(paramName, matchedType)
 
-	clauses, defaultBody, resultType, err := t.transformMatchClauses(ctx, paramName, matchedType)
+	cases := t.typeSwitchCases(ctx, matchedType)
+	clauses, defaultBody, resultType, err := t.transformMatchClauses(ctx, paramName, matchedType, cases)
 	if err != nil {
 		return nil, err
 	}
 
 	t.needsStdImport = true
-	body := t.buildMatchBody(clauses, defaultBody, resultType)
+	var body []ast.Stmt
+	if cases != nil {
+		body = t.buildTypeSwitchBody(paramName, cases, clauses, defaultBody, resultType)
+	} else {
+		body = t.buildMatchBody(clauses, defaultBody, resultType)
+	}
 
 	return t.generateMatchIIFE(expr, paramName, matchedType, body, resultType)
 }
@@ -56,9 +64,9 @@ func (t *galaASTTransformer) parseMatchSubject(ctx grammar.IExpressionContext) (
 	}
 	if matchedType == nil || matchedType.IsNil() {
 		if parserCtx, ok := ctx.(antlr.ParserRuleContext); ok {
-			return nil, "", nil, t.semanticErrorAt(parserCtx, "cannot infer type of matched expression. Please add explicit type annotation to the variable being matched")
+			return nil, "", nil, t.semanticErrorAt(parserCtx, "cannot infer type of matched expression. Please add explicit type annotation to the variable being matched").WithCode(galaerr.CodeMatchSubjectType)
 		}
-		return nil, "", nil, galaerr.NewSemanticError("cannot infer type of matched expression. Please add explicit type annotation to the variable being matched")
+		return nil, "", nil, galaerr.NewSemanticError("cannot infer type of matched expression. Please add explicit type annotation to the variable being matched").WithCode(galaerr.CodeMatchSubjectType)
 	}
 
 	return expr, paramName, matchedType, nil
@@ -134,8 +142,34 @@ func (t *galaASTTransformer) isSealedExhaustive(matchedType transpiler.Type, pat
 	return true, len(missing) == 0, missing
 }
 
+// matchFailure returns the default branch of a match checked exhaustive: it
+// panics with a std.MatchError holding the source location of the match and
+// the unmatched value, rendered by its String method when printed:
+//
+//	panic(std.MatchFailure("shapes.gala:12", obj))
+func (t *galaASTTransformer) matchFailure(ctx antlr.ParserRuleContext, paramName string) []ast.Stmt {
+	location := "line 0"
+	if ctx != nil && ctx.GetStart() != nil {
+		location = fmt.Sprintf("line %d", ctx.GetStart().GetLine())
+		if t.filePath != "" {
+			location = fmt.Sprintf("%s:%d", filepath.Base(t.filePath), ctx.GetStart().GetLine())
+		}
+	}
+	return []ast.Stmt{
+		&ast.ExprStmt{X: &ast.CallExpr{
+			Fun: ast.NewIdent("panic"),
+			Args: []ast.Expr{&ast.CallExpr{
+				Fun:  t.stdIdent("MatchFailure"),
+				Args: []ast.Expr{&ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(location)}, ast.NewIdent(paramName)},
+			}},
+		}},
+	}
+}
+
 // transformMatchClauses processes all case clauses and infers the common result type.
-func (t *galaASTTransformer) transformMatchClauses(ctx grammar.IExpressionContext, paramName string, matchedType transpiler.Type) ([]ast.Stmt, []ast.Stmt, transpiler.Type, error) {
+// With cases, the types of a match dispatched by a type switch, each clause is
+// transformed for the subject narrowed to its type.
+func (t *galaASTTransformer) transformMatchClauses(ctx grammar.IExpressionContext, paramName string, matchedType transpiler.Type, cases []typeSwitchCase) ([]ast.Stmt, []ast.Stmt, transpiler.Type, error) {
 	var clauses []ast.Stmt
 	var defaultBody []ast.Stmt
 	foundDefault := false
@@ -180,7 +214,11 @@ func (t *galaASTTransformer) transformMatchClauses(ctx grammar.IExpressionContex
 			continue
 		}
 
-		clause, resultType, err := t.transformCaseClauseWithType(ccCtx, paramName, matchedType)
+		clauseType, narrowed := matchedType, cases != nil
+		if narrowed {
+			clauseType = cases[len(clauses)].typ
+		}
+		clause, resultType, err := t.transformCaseClauseWithType(ccCtx, paramName, clauseType, narrowed)
 		if err != nil {
 			return nil, nil, nil, err
 		}
@@ -209,15 +247,10 @@ func (t *galaASTTransformer) transformMatchClauses(ctx grammar.IExpressionContex
 			return nil, nil, nil, galaerr.NewSemanticError(
 				fmt.Sprintf("non-exhaustive match: missing cases: %s", strings.Join(missing, ", ")))
 		} else if isSealed && isExhaustive {
-			// Exhaustive sealed match — generate synthetic panic("unreachable") default
-			defaultBody = []ast.Stmt{
-				&ast.ExprStmt{X: &ast.CallExpr{
-					Fun:  ast.NewIdent("panic"),
-					Args: []ast.Expr{&ast.BasicLit{Kind: token.STRING, Value: `"unreachable"`}},
-				}},
-			}
+			// Exhaustive sealed match — generate a default that panics with the location
+			defaultBody = t.matchFailure(ctx, paramName)
 		} else if !isSealed {
-			return nil, nil, nil, galaerr.NewSemanticError("match expression must have a default case (case _ => ...)")
+			return nil, nil, nil, galaerr.NewSemanticError("match expression must have a default case (case _ => ...)").WithCode(galaerr.CodeMatchNoDefault)
 		}
 	}
 	// When foundDefault && isSealed && isExhaustive: unreachable default is harmless, allow it
@@ -276,14 +309,14 @@ func (t *galaASTTransformer) buildMatchBody(clauses []ast.Stmt, defaultBody []as
 func (t *galaASTTransformer) generateMatchIIFE(expr ast.Expr, paramName string, matchedType transpiler.Type, body []ast.Stmt, resultType transpiler.Type) (ast.Expr, error) {
 	paramType := t.typeToExpr(matchedType)
 	if paramType == nil {
-		return nil, galaerr.NewSemanticError("cannot infer type of matched expression. Please add explicit type annotation")
+		return nil, galaerr.NewSemanticError("cannot infer type of matched expression. Please add explicit type annotation").WithCode(galaerr.CodeMatchSubjectType)
 	}
 
 	var resultsField *ast.FieldList
 	if _, isVoid := resultType.(transpiler.VoidType); !isVoid {
 		resultTypeExpr := t.typeToExpr(resultType)
 		if resultTypeExpr == nil {
-			return nil, galaerr.NewSemanticError("cannot infer result type of match expression. Please ensure all branches return the same type")
+			return nil, galaerr.NewSemanticError("cannot infer result type of match expression. Please ensure all branches return the same type").WithCode(galaerr.CodeMatchResultType)
 		}
 		resultsField = &ast.FieldList{
 			List: []*ast.Field{{Type: resultTypeExpr}},
@@ -444,7 +477,7 @@ func (t *galaASTTransformer) inferCommonResultType(types []transpiler.Type, patt
 
 		if allNilOrVoid && !hasTypeParam {
 			// Complete inference failure — no branch could be typed
-			return nil, galaerr.NewSemanticError("cannot infer result type of match expression: no branch returns a concrete type. Please add explicit type annotation")
+			return nil, galaerr.NewSemanticError("cannot infer result type of match expression: no branch returns a concrete type. Please add explicit type annotation").WithCode(galaerr.CodeMatchResultType)
 		}
 		// Type parameters or mixed type-param/nil: use 'any' as the Go type erasure
 		return transpiler.BasicType{Name: "any"}, nil
@@ -453,7 +486,7 @@ func (t *galaASTTransformer) inferCommonResultType(types []transpiler.Type, patt
 	// Check all types are compatible with the reference type
 	for i, typ := range types {
 		if typ == nil {
-			return nil, galaerr.NewSemanticError(fmt.Sprintf("cannot infer result type for '%s'. Please add explicit type annotation", patterns[i]))
+			return nil, galaerr.NewSemanticError(fmt.Sprintf("cannot infer result type for '%s'. Please add explicit type annotation", patterns[i])).WithCode(galaerr.CodeMatchResultType)
 		}
 		// VoidType is compatible with any type (for mixed match where some branches are void)
 		if _, isVoid := typ.(transpiler.VoidType); isVoid {
@@ -623,7 +656,7 @@ func (t *galaASTTransformer) isSimpleIdentifier(s string) bool {
 }
 
 // extractUserPatternVarNames walks pattern bindings AST and collects user-defined variable names.
-// It skips internal temp vars (_tmp_* prefix) and blank identifiers (_).
+// It skips internal temp vars (see isTempVarName) and blank identifiers (_).
 func extractUserPatternVarNames(bindings []ast.Stmt) []string {
 	var names []string
 	for _, stmt := range bindings {
@@ -639,7 +672,7 @@ func extractUserVarsFromStmt(stmt ast.Stmt, names *[]string) {
 			for _, lhs := range s.Lhs {
 				if ident, ok := lhs.(*ast.Ident); ok {
 					name := ident.Name
-					if name != "_" && !strings.HasPrefix(name, "_tmp_") {
+					if name != "_" && !isTempVarName(name) {
 						*names = append(*names, name)
 					}
 				}
@@ -676,15 +709,24 @@ func collectReferencedIdents(nodes []ast.Node) map[string]bool {
 	return refs
 }
 
-// transformCaseClauseWithType transforms a case clause and returns its result type
-func (t *galaASTTransformer) transformCaseClauseWithType(ctx *grammar.CaseClauseContext, paramName string, matchedType transpiler.Type) (ast.Stmt, transpiler.Type, error) {
+// transformCaseClauseWithType transforms a case clause and returns its result type.
+// narrowed is set when a type switch has already given paramName the type the
+// clause matches, matchedType.
+func (t *galaASTTransformer) transformCaseClauseWithType(ctx *grammar.CaseClauseContext, paramName string, matchedType transpiler.Type, narrowed bool) (ast.Stmt, transpiler.Type, error) {
 	t.pushScope()
 	defer t.popScope()
 
 	patCtx := ctx.Pattern()
-	cond, bindings, err := t.transformPatternWithType(patCtx, ast.NewIdent(paramName), matchedType)
-	if err != nil {
-		return nil, nil, err
+	var cond ast.Expr
+	var bindings []ast.Stmt
+	var err error
+	if typed, ok := patCtx.(*grammar.TypedPatternContext); ok && narrowed {
+		cond, bindings = t.bindSwitchedSubject(typed, paramName, matchedType)
+	} else {
+		cond, bindings, err = t.transformPatternWithType(patCtx, ast.NewIdent(paramName), matchedType)
+		if err != nil {
+			return nil, nil, err
+		}
 	}
 
 	// Transform guard expression separately so we can check variable references in it
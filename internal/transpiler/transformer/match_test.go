@@ -138,15 +138,15 @@ import "martianoff/gala/std"
 var x std.Immutable[std.Option[int]] = std.NewImmutable[std.Option[int]](std.Some[int]{}.Apply(1))
 var res = std.NewImmutable(func(obj std.Option[int]) int {
 	{
-		_tmp_1 := std.Some[int]{}.Unapply(obj)
-		_tmp_2 := _tmp_1.IsDefined()
-		var _tmp_3 int
-		if _tmp_2 {
-			_tmp_3 = _tmp_1.Get()
+		_some_0 := std.Some[int]{}.Unapply(obj)
+		_some_1 := _some_0.IsDefined()
+		var _some_2 int
+		if _some_1 {
+			_some_2 = _some_0.Get()
 		}
-		_ = _tmp_3
-		y := _tmp_3
-		if _tmp_2 {
+		_ = _some_2
+		y := _some_2
+		if _some_1 {
 			return y
 		} else {
 			return 0
@@ -171,15 +171,15 @@ import "martianoff/gala/std"
 var x std.Immutable[std.Option[any]] = std.NewImmutable[std.Option[any]](std.Some[string]{}.Apply("test"))
 var res = std.NewImmutable(func(obj std.Option[any]) string {
 	{
-		_tmp_1 := std.Some[any]{}.Unapply(obj)
-		_tmp_2 := _tmp_1.IsDefined()
-		var _tmp_3 any
-		if _tmp_2 {
-			_tmp_3 = _tmp_1.Get()
+		_some_0 := std.Some[any]{}.Unapply(obj)
+		_some_1 := _some_0.IsDefined()
+		var _some_2 any
+		if _some_1 {
+			_some_2 = _some_0.Get()
 		}
-		_ = _tmp_3
-		s, _tmp_4 := std.As[string](_tmp_3)
-		if _tmp_2 && _tmp_4 {
+		_ = _some_2
+		s, _string_3 := std.As[string](_some_2)
+		if _some_1 && _string_3 {
 			return s
 		} else {
 			return "unknown"
@@ -220,7 +220,7 @@ val res = x match {
 			wantErr: true,
 		},
 		{
-			name: "Sealed exhaustive match without default generates panic",
+			name: "Sealed exhaustive match without default panics with the location",
 			input: `package main
 
 sealed type Light {
@@ -286,20 +286,18 @@ func (s Light) String() string {
 		return "Light(<unknown>)"
 	}
 }
+func LightVariants() []std.SealedVariant {
+	return []std.SealedVariant{{Name: "On", Arity: 0}, {Name: "Off", Arity: 0}}
+}
+
 func describe(l Light) string {
 	return func(obj Light) string {
-		{
-			_tmp_1 := On{}.Unapply(obj)
-			if _tmp_1 {
-				return "on"
-			} else {
-				_tmp_2 := Off{}.Unapply(obj)
-				if _tmp_2 {
-					return "off"
-				} else {
-					panic("unreachable")
-				}
-			}
+		if obj._variant == _Light_On {
+			return "on"
+		} else if obj._variant == _Light_Off {
+			return "off"
+		} else {
+			panic(std.MatchFailure("line 8", obj))
 		}
 	}(l)
 }`,
@@ -372,20 +370,18 @@ func (s Light) String() string {
 		return "Light(<unknown>)"
 	}
 }
+func LightVariants() []std.SealedVariant {
+	return []std.SealedVariant{{Name: "On", Arity: 0}, {Name: "Off", Arity: 0}}
+}
+
 func describe(l Light) string {
 	return func(obj Light) string {
-		{
-			_tmp_1 := On{}.Unapply(obj)
-			if _tmp_1 {
-				return "on"
-			} else {
-				_tmp_2 := Off{}.Unapply(obj)
-				if _tmp_2 {
-					return "off"
-				} else {
-					return "unknown"
-				}
-			}
+		if obj._variant == _Light_On {
+			return "on"
+		} else if obj._variant == _Light_Off {
+			return "off"
+		} else {
+			return "unknown"
 		}
 	}(l)
 }`,
@@ -405,7 +401,7 @@ func describe(l Light) string = l match {
 			wantErr: true,
 		},
 		{
-			name: "Bool exhaustive match (true+false) generates panic unreachable",
+			name: "Bool exhaustive match (true+false) generates panic with location",
 			input: `package main
 
 func describe(b bool) string = b match {
@@ -414,6 +410,8 @@ func describe(b bool) string = b match {
 }`,
 			expected: `package main
 
+import "martianoff/gala/std"
+
 func describe(b bool) string {
 	return func(obj bool) string {
 		if obj == true {
@@ -421,7 +419,7 @@ func describe(b bool) string {
 		} else if obj == false {
 			return "no"
 		} else {
-			panic("unreachable")
+			panic(std.MatchFailure("line 3", obj))
 		}
 	}(b)
 }`,
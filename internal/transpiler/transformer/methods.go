@@ -41,6 +41,13 @@ func (t *galaASTTransformer) transformCopyCall(receiver ast.Expr, argListCtx *gr
 		return nil, galaerr.NewSemanticError("cannot use Copy overrides: type of receiver unknown")
 	}
 
+	if t.typeHasAnnotation(typeName, transpiler.AnnotationNoCopy) {
+		return nil, galaerr.NewSemanticError(fmt.Sprintf("type %s is annotated with @noCopy and cannot be copied", typeName))
+	}
+	if meta := t.getTypeMeta(typeName); meta != nil && len(meta.Invariants) > 0 && argListCtx != nil && len(argListCtx.AllArgument()) > 0 {
+		return nil, galaerr.NewSemanticError(fmt.Sprintf("Copy overrides would skip the require clauses of %s; build a new value with %s(...)", typeName, typeName))
+	}
+
 	fields, ok := t.structFields[typeName]
 	if !ok {
 		// If it's not a struct type but we have overrides, compilation error
@@ -88,7 +95,7 @@ func (t *galaASTTransformer) transformCopyCall(receiver ast.Expr, argListCtx *gr
 		if err != nil {
 			return nil, err
 		}
-		overrides[fieldName] = val
+		overrides[fieldName] = t.convertToNumericType(val, t.structFieldTypes[typeName][fieldName])
 	}
 
 	// 3. Construct new struct instance
@@ -108,17 +115,13 @@ func (t *galaASTTransformer) transformCopyCall(receiver ast.Expr, argListCtx *gr
 				Value: finalVal,
 			})
 		} else {
+			immutable := i < len(immutFlags) && immutFlags[i]
 			elts = append(elts, &ast.KeyValueExpr{
 				Key: ast.NewIdent(fn),
-				Value: &ast.CallExpr{
-					Fun: t.stdIdent(transpiler.FuncCopy),
-					Args: []ast.Expr{
-						&ast.SelectorExpr{
-							X:   receiver,
-							Sel: ast.NewIdent(fn),
-						},
-					},
-				},
+				Value: t.copyFieldExpr(typeName, fn, immutable, &ast.SelectorExpr{
+					X:   receiver,
+					Sel: ast.NewIdent(fn),
+				}),
 			})
 		}
 	}
@@ -129,6 +132,33 @@ func (t *galaASTTransformer) transformCopyCall(receiver ast.Expr, argListCtx *gr
 	}, nil
 }
 
+// copyFieldExpr returns the value of field in a copy of a typeName value, where
+// value reads the field of the original. An immutable field cannot change in
+// either value, so the copy shares it; mutable fields are deep-copied with
+// std.Copy. @deepCopy and @shallowCopy override this per field.
+func (t *galaASTTransformer) copyFieldExpr(typeName, field string, immutable bool, value ast.Expr) ast.Expr {
+	deep := !immutable
+	if t.fieldHasAnnotation(typeName, field, transpiler.AnnotationDeepCopy) {
+		deep = true
+	} else if t.fieldHasAnnotation(typeName, field, transpiler.AnnotationShallowCopy) {
+		deep = false
+	}
+	if !deep {
+		return value
+	}
+	return &ast.CallExpr{
+		Fun:  t.stdIdent(transpiler.FuncCopy),
+		Args: []ast.Expr{value},
+	}
+}
+
+// isImmutableField reports whether a generated struct field of type typ is
+// wrapped in Immutable.
+func isImmutableField(typ ast.Expr) bool {
+	idx, ok := typ.(*ast.IndexExpr)
+	return ok && isImmutableTypeExpr(idx.X)
+}
+
 func (t *galaASTTransformer) initGenericMethods() {
 	t.genericMethods = make(map[string]map[string]bool)
 	t.structFieldTypes = make(map[string]map[string]transpiler.Type)
@@ -142,15 +172,10 @@ func (t *galaASTTransformer) generateCopyMethod(name string, fields *ast.FieldLi
 		for _, fieldName := range field.Names {
 			elts = append(elts, &ast.KeyValueExpr{
 				Key: ast.NewIdent(fieldName.Name),
-				Value: &ast.CallExpr{
-					Fun: t.stdIdent("Copy"),
-					Args: []ast.Expr{
-						&ast.SelectorExpr{
-							X:   ast.NewIdent("s"),
-							Sel: ast.NewIdent(fieldName.Name),
-						},
-					},
-				},
+				Value: t.copyFieldExpr(name, fieldName.Name, isImmutableField(field.Type), &ast.SelectorExpr{
+					X:   ast.NewIdent("s"),
+					Sel: ast.NewIdent(fieldName.Name),
+				}),
 			})
 		}
 	}
@@ -203,18 +228,12 @@ func (t *galaASTTransformer) generateEqualMethod(name string, fields *ast.FieldL
 	var condition ast.Expr
 	for _, field := range fields.List {
 		for _, fieldName := range field.Names {
-			expr := &ast.CallExpr{
-				Fun: t.stdIdent("Equal"),
-				Args: []ast.Expr{
-					&ast.SelectorExpr{
-						X:   ast.NewIdent("s"),
-						Sel: ast.NewIdent(fieldName.Name),
-					},
-					&ast.SelectorExpr{
-						X:   ast.NewIdent("other"),
-						Sel: ast.NewIdent(fieldName.Name),
-					},
-				},
+			if t.fieldHasAnnotation(name, fieldName.Name, transpiler.AnnotationEqualIgnore) {
+				continue
+			}
+			expr := t.fieldEqualExpr(fieldName.Name, field.Type)
+			if expr == nil {
+				continue
 			}
 
 			if condition == nil {
@@ -280,6 +299,48 @@ func (t *galaASTTransformer) generateEqualMethod(name string, fields *ast.FieldL
 	}, nil
 }
 
+// fieldEqualExpr compares the field called name of s and other. Slices and maps
+// are compared element-wise with std.EqualSlices and std.EqualMaps, everything
+// else with std.Equal. Functions cannot be compared, so for them it returns nil.
+func (t *galaASTTransformer) fieldEqualExpr(name string, typ ast.Expr) ast.Expr {
+	var a ast.Expr = &ast.SelectorExpr{X: ast.NewIdent("s"), Sel: ast.NewIdent(name)}
+	var b ast.Expr = &ast.SelectorExpr{X: ast.NewIdent("other"), Sel: ast.NewIdent(name)}
+
+	// Look through Immutable[T] only when T needs special treatment, so that
+	// other fields keep comparing the wrappers
+	inner := typ
+	if isImmutableField(typ) {
+		inner = typ.(*ast.IndexExpr).Index
+	}
+	fun := "Equal"
+	switch it := inner.(type) {
+	case *ast.FuncType:
+		return nil
+	case *ast.ArrayType:
+		if it.Len == nil {
+			fun = "EqualSlices"
+		}
+	case *ast.MapType:
+		fun = "EqualMaps"
+	}
+	if fun != "Equal" && inner != typ {
+		a = &ast.CallExpr{Fun: &ast.SelectorExpr{X: a, Sel: ast.NewIdent("Get")}}
+		b = &ast.CallExpr{Fun: &ast.SelectorExpr{X: b, Sel: ast.NewIdent("Get")}}
+	}
+	return &ast.CallExpr{Fun: t.stdIdent(fun), Args: []ast.Expr{a, b}}
+}
+
+// isImmutableTypeExpr reports whether expr names std's Immutable type.
+func isImmutableTypeExpr(expr ast.Expr) bool {
+	switch e := expr.(type) {
+	case *ast.SelectorExpr:
+		return e.Sel.Name == transpiler.TypeImmutable
+	case *ast.Ident:
+		return e.Name == transpiler.TypeImmutable
+	}
+	return false
+}
+
 func (t *galaASTTransformer) generateUnapplyMethod(name string, fields *ast.FieldList, tParams *ast.FieldList) (*ast.FuncDecl, error) {
 	if meta := t.getTypeMeta(name); meta != nil {
 		if _, ok := meta.Methods["Unapply"]; ok {
@@ -343,63 +404,59 @@ func (t *galaASTTransformer) generateUnapplyMethod(name string, fields *ast.Fiel
 	retVals = append(retVals, ast.NewIdent("true"))
 	zeroVals = append(zeroVals, ast.NewIdent("false"))
 
-	pName := "p"
-	if !hasFields {
-		pName = "_"
-	}
-
-	body := []ast.Stmt{
-		&ast.IfStmt{
-			Init: &ast.AssignStmt{
-				Lhs: []ast.Expr{ast.NewIdent(pName), ast.NewIdent("ok")},
-				Tok: token.DEFINE,
-				Rhs: []ast.Expr{
-					&ast.TypeAssertExpr{
-						X:    ast.NewIdent("v"),
-						Type: retType,
-					},
-				},
-			},
-			Cond: ast.NewIdent("ok"),
-			Body: &ast.BlockStmt{
-				List: []ast.Stmt{
-					&ast.ReturnStmt{Results: retVals},
+	// A single type switch handles both the value and the pointer form, so a
+	// match costs one dynamic type check instead of two assertions.
+	var body []ast.Stmt
+	if hasFields {
+		body = []ast.Stmt{
+			&ast.TypeSwitchStmt{
+				Assign: &ast.AssignStmt{
+					Lhs: []ast.Expr{ast.NewIdent("p")},
+					Tok: token.DEFINE,
+					Rhs: []ast.Expr{&ast.TypeAssertExpr{X: ast.NewIdent("v")}},
 				},
-			},
-		},
-		&ast.IfStmt{
-			Init: &ast.AssignStmt{
-				Lhs: []ast.Expr{ast.NewIdent(pName), ast.NewIdent("ok")},
-				Tok: token.DEFINE,
-				Rhs: []ast.Expr{
-					&ast.TypeAssertExpr{
-						X:    ast.NewIdent("v"),
-						Type: &ast.StarExpr{X: retType},
+				Body: &ast.BlockStmt{
+					List: []ast.Stmt{
+						&ast.CaseClause{
+							List: []ast.Expr{retType},
+							Body: []ast.Stmt{&ast.ReturnStmt{Results: retVals}},
+						},
+						&ast.CaseClause{
+							List: []ast.Expr{&ast.StarExpr{X: retType}},
+							Body: []ast.Stmt{
+								&ast.IfStmt{
+									Cond: &ast.BinaryExpr{
+										X:  ast.NewIdent("p"),
+										Op: token.NEQ,
+										Y:  ast.NewIdent("nil"),
+									},
+									Body: &ast.BlockStmt{
+										List: []ast.Stmt{&ast.ReturnStmt{Results: retVals}},
+									},
+								},
+							},
+						},
 					},
 				},
 			},
-			Cond: &ast.BinaryExpr{
-				X:  ast.NewIdent("ok"),
-				Op: token.LAND,
-				Y: &ast.BinaryExpr{
-					X:  ast.NewIdent("p"), // still use p here for nil check if it exists
-					Op: token.NEQ,
-					Y:  ast.NewIdent("nil"),
-				},
-			},
-			Body: &ast.BlockStmt{
-				List: []ast.Stmt{
-					&ast.ReturnStmt{Results: retVals},
+			&ast.ReturnStmt{Results: zeroVals},
+		}
+	} else {
+		// Without fields there is nothing to read, so a nil pointer matches too
+		body = []ast.Stmt{
+			&ast.TypeSwitchStmt{
+				Assign: &ast.ExprStmt{X: &ast.TypeAssertExpr{X: ast.NewIdent("v")}},
+				Body: &ast.BlockStmt{
+					List: []ast.Stmt{
+						&ast.CaseClause{
+							List: []ast.Expr{retType, &ast.StarExpr{X: retType}},
+							Body: []ast.Stmt{&ast.ReturnStmt{Results: retVals}},
+						},
+					},
 				},
 			},
-		},
-		&ast.ReturnStmt{Results: zeroVals},
-	}
-
-	// Update the nil check if p is _
-	if !hasFields {
-		ifStmt := body[1].(*ast.IfStmt)
-		ifStmt.Cond = ast.NewIdent("ok") // No nil check needed if we don't use p
+			&ast.ReturnStmt{Results: zeroVals},
+		}
 	}
 
 	return &ast.FuncDecl{
@@ -0,0 +1,134 @@
+package transformer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"martianoff/gala/galaerr"
+	"martianoff/gala/internal/parser/grammar"
+	"martianoff/gala/internal/transpiler"
+)
+
+// This file contains newtype declarations and the check that keeps newtypes
+// from being mixed with each other or with their underlying type.
+
+// transformNewtypeDeclaration turns newtype UserId = string into a Go defined
+// type with the same representation, plus its conversions in both directions:
+//
+//	type UserId string
+//	func UserIdFrom(v string) UserId { return UserId(v) }
+//	func (v UserId) Value() string { return string(v) }
+func (t *galaASTTransformer) transformNewtypeDeclaration(ctx *grammar.NewtypeDeclarationContext) ([]ast.Decl, error) {
+	name := ctx.Identifier().GetText()
+	underlying, err := t.transformType(ctx.Type_())
+	if err != nil {
+		return nil, err
+	}
+	// Go allows no methods on a defined pointer or interface type, so there
+	// would be no Value method
+	switch typ := underlying.(type) {
+	case *ast.StarExpr, *ast.InterfaceType:
+		return nil, t.semanticErrorAt(ctx, fmt.Sprintf("newtype %s cannot wrap the pointer or interface type %s", name, ctx.Type_().GetText()))
+	case *ast.Ident:
+		if typ.Name == "any" || typ.Name == "error" {
+			return nil, t.semanticErrorAt(ctx, fmt.Sprintf("newtype %s cannot wrap the pointer or interface type %s", name, typ.Name))
+		}
+	}
+
+	convert := func(typ ast.Expr) ast.Expr {
+		switch typ.(type) {
+		case *ast.FuncType, *ast.ChanType:
+			typ = &ast.ParenExpr{X: typ}
+		}
+		return &ast.CallExpr{Fun: typ, Args: []ast.Expr{ast.NewIdent("v")}}
+	}
+	returning := func(expr ast.Expr) *ast.BlockStmt {
+		return &ast.BlockStmt{List: []ast.Stmt{&ast.ReturnStmt{Results: []ast.Expr{expr}}}}
+	}
+
+	return []ast.Decl{
+		&ast.GenDecl{
+			Tok:   token.TYPE,
+			Specs: []ast.Spec{&ast.TypeSpec{Name: ast.NewIdent(name), Type: underlying}},
+		},
+		&ast.FuncDecl{
+			Name: ast.NewIdent(name + transpiler.NewtypeFromSuffix),
+			Type: &ast.FuncType{
+				Params:  &ast.FieldList{List: []*ast.Field{{Names: []*ast.Ident{ast.NewIdent("v")}, Type: underlying}}},
+				Results: &ast.FieldList{List: []*ast.Field{{Type: ast.NewIdent(name)}}},
+			},
+			Body: returning(convert(ast.NewIdent(name))),
+		},
+		&ast.FuncDecl{
+			Recv: &ast.FieldList{List: []*ast.Field{{Names: []*ast.Ident{ast.NewIdent("v")}, Type: ast.NewIdent(name)}}},
+			Name: ast.NewIdent("Value"),
+			Type: &ast.FuncType{
+				Params:  &ast.FieldList{},
+				Results: &ast.FieldList{List: []*ast.Field{{Type: underlying}}},
+			},
+			Body: returning(convert(underlying)),
+		},
+	}, nil
+}
+
+// newtypeMeta returns the metadata of typ if it was declared with newtype.
+func (t *galaASTTransformer) newtypeMeta(typ transpiler.Type) *transpiler.TypeMetadata {
+	if typ == nil || typ.IsNil() {
+		return nil
+	}
+	if meta := t.getTypeMeta(typ.BaseName()); meta.IsNewtype() {
+		return meta
+	}
+	return nil
+}
+
+// checkNewtypeArgument rejects an argument expr passed where expected is
+// wanted if it mixes up a newtype: a different newtype, or a plain value of
+// the underlying type where the newtype is wanted and the other way round.
+// Untyped constants convert implicitly, as in Go.
+func (t *galaASTTransformer) checkNewtypeArgument(ctx antlr.ParserRuleContext, expr ast.Expr, expected transpiler.Type) error {
+	if expected == nil || isUntypedConstant(expr) {
+		return nil
+	}
+	want := t.newtypeMeta(expected)
+	actual := t.getExprTypeName(expr)
+	got := t.newtypeMeta(actual)
+	if want == got || actual.IsNil() {
+		return nil
+	}
+
+	var msg string
+	switch {
+	case want != nil && got != nil:
+		msg = fmt.Sprintf("cannot use %s as %s: newtypes do not convert into each other; use %s%s(v.Value()) if the value really is a %s",
+			got.Name, want.Name, want.Name, transpiler.NewtypeFromSuffix, want.Name)
+	case want != nil && actual.String() == want.Underlying.String():
+		msg = fmt.Sprintf("cannot use %s as %s: convert it with %s%s", actual, want.Name, want.Name, transpiler.NewtypeFromSuffix)
+	case got != nil && expected.String() == got.Underlying.String():
+		msg = fmt.Sprintf("cannot use %s as %s: unwrap it with Value()", got.Name, expected)
+	default:
+		return nil
+	}
+	return t.semanticErrorAt(ctx, msg).WithCode(galaerr.CodeNewtypeMismatch)
+}
+
+// isUntypedConstant reports whether expr is built from literals only, like
+// "abc" or -1, and so takes the type it is used as.
+func isUntypedConstant(expr ast.Expr) bool {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		return true
+	case *ast.Ident:
+		return e.Name == "true" || e.Name == "false"
+	case *ast.ParenExpr:
+		return isUntypedConstant(e.X)
+	case *ast.UnaryExpr:
+		return isUntypedConstant(e.X)
+	case *ast.BinaryExpr:
+		return isUntypedConstant(e.X) && isUntypedConstant(e.Y)
+	}
+	return false
+}
@@ -0,0 +1,137 @@
+package transformer_test
+
+import (
+	"martianoff/gala/galaerr"
+	"martianoff/gala/internal/transpiler"
+	"martianoff/gala/internal/transpiler/analyzer"
+	"martianoff/gala/internal/transpiler/generator"
+	"martianoff/gala/internal/transpiler/transformer"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewtype(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		contains []string
+		wantErr  string
+	}{
+		{
+			name: "Declaration",
+			input: `package main
+
+newtype UserId = string`,
+			contains: []string{
+				"type UserId string",
+				"func UserIdFrom(v string) UserId {\n\treturn UserId(v)\n}",
+				"func (v UserId) Value() string {\n\treturn string(v)\n}",
+			},
+		},
+		{
+			name: "Function underlying type",
+			input: `package main
+
+newtype Handler = func(int) string`,
+			contains: []string{
+				"type Handler func(int) string",
+				"return (func(int) string)(v)",
+			},
+		},
+		{
+			name: "Pointer underlying type",
+			input: `package main
+
+type User struct {
+	Name string
+}
+
+newtype UserRef = *User`,
+			wantErr: "newtype UserRef cannot wrap the pointer or interface type *User",
+		},
+		{
+			name: "Conversions and literals",
+			input: `package main
+
+newtype UserId = string
+
+func greet(id UserId) string = "hello " + id.Value()
+
+func main() {
+	val id = UserIdFrom("u-1")
+	println(greet(id))
+	println(greet("u-2"))
+}`,
+			contains: []string{
+				"greet(id.Get())",
+				`greet("u-2")`,
+			},
+		},
+		{
+			name: "Other newtype",
+			input: `package main
+
+newtype UserId = string
+newtype OrderId = string
+
+func cancel(id OrderId) string = id.Value()
+
+func main() {
+	val user = UserIdFrom("u-1")
+	println(cancel(user))
+}`,
+			wantErr: "cannot use UserId as OrderId",
+		},
+		{
+			name: "Underlying value for newtype",
+			input: `package main
+
+newtype UserId = string
+
+func greet(id UserId) string = id.Value()
+
+func main() {
+	val name = "u-1"
+	println(greet(name))
+}`,
+			wantErr: "cannot use string as UserId: convert it with UserIdFrom",
+		},
+		{
+			name: "Newtype for underlying value",
+			input: `package main
+
+newtype UserId = string
+
+func shout(s string) string = s + "!"
+
+func main() {
+	val id = UserIdFrom("u-1")
+	println(shout(id))
+}`,
+			wantErr: "cannot use UserId as string: unwrap it with Value()",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := transpiler.NewAntlrGalaParser()
+			a := analyzer.NewGalaAnalyzer(p, getStdSearchPath())
+			trans := transpiler.NewGalaToGoTranspiler(p, a, transformer.NewGalaASTTransformer(), generator.NewGoCodeGenerator())
+
+			got, err := trans.Transpile(tt.input, "")
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				if strings.HasPrefix(tt.wantErr, "cannot use") {
+					assert.Equal(t, galaerr.CodeNewtypeMismatch, galaerr.CodeOf(err))
+				}
+				return
+			}
+			assert.NoError(t, err)
+			for _, s := range tt.contains {
+				assert.Contains(t, got, s)
+			}
+		})
+	}
+}
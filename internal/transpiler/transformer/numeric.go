@@ -0,0 +1,83 @@
+package transformer
+
+import (
+	"go/ast"
+	"go/token"
+
+	"martianoff/gala/internal/transpiler"
+)
+
+// This file contains numeric width handling for values stored into slots of a declared
+// numeric type (struct fields, typed vals and parameters).
+// Functions: convertToNumericType, isSafeNumericWidening
+
+// numericType describes a Go numeric type for widening checks. bits is the value
+// range in bits; for floats it is the mantissa width, the largest integer size
+// a float holds exactly.
+type numericType struct {
+	kind byte // 'i' signed, 'u' unsigned, 'f' float
+	bits int
+}
+
+var numericTypes = map[string]numericType{
+	"int8": {'i', 8}, "int16": {'i', 16}, "int32": {'i', 32}, "rune": {'i', 32}, "int64": {'i', 64}, "int": {'i', 64},
+	"uint8": {'u', 8}, "byte": {'u', 8}, "uint16": {'u', 16}, "uint32": {'u', 32}, "uint64": {'u', 64}, "uint": {'u', 64}, "uintptr": {'u', 64},
+	"float32": {'f', 24}, "float64": {'f', 53},
+}
+
+// convertToNumericType adapts expr to the numeric target type of the slot it is
+// stored into. Untyped constants get an explicit conversion when their default
+// type differs from target, since generic wrappers like NewImmutable would
+// otherwise be instantiated with int or float64. Typed values are converted
+// only when the conversion cannot lose information; anything else is left for
+// the Go compiler to report.
+func (t *galaASTTransformer) convertToNumericType(expr ast.Expr, target transpiler.Type) ast.Expr {
+	dst, ok := target.(transpiler.BasicType)
+	if !ok {
+		return expr
+	}
+	if _, isNumeric := numericTypes[dst.Name]; !isNumeric {
+		return expr
+	}
+	if lit, isLit := expr.(*ast.BasicLit); isLit && lit.Kind == token.STRING {
+		return expr
+	}
+	convert := &ast.CallExpr{Fun: ast.NewIdent(dst.Name), Args: []ast.Expr{expr}}
+	if _, isConst := untypedConstKind(expr); isConst {
+		if t.getExprTypeNameManual(expr).String() == dst.Name {
+			return expr
+		}
+		return convert
+	}
+	src, ok := t.getExprTypeName(expr).(transpiler.BasicType)
+	if !ok || src.Name == dst.Name || !isSafeNumericWidening(src.Name, dst.Name) {
+		return expr
+	}
+	return convert
+}
+
+// isSafeNumericWidening reports whether every value of type from is exactly
+// representable in type to on all platforms. int and uint are assumed to be
+// 64 bits wide as a source but only 32 bits wide as a destination.
+func isSafeNumericWidening(from, to string) bool {
+	src, ok := numericTypes[from]
+	if !ok {
+		return false
+	}
+	dst, ok := numericTypes[to]
+	if !ok {
+		return false
+	}
+	if to == "int" || to == "uint" || to == "uintptr" {
+		dst.bits = 32
+	}
+	switch {
+	case src.kind == dst.kind:
+		return src.bits <= dst.bits
+	case src.kind == 'u' && dst.kind == 'i':
+		return src.bits < dst.bits
+	case src.kind != 'f' && dst.kind == 'f':
+		return src.bits <= dst.bits
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package transformer_test
+
+import (
+	"martianoff/gala/internal/transpiler"
+	"martianoff/gala/internal/transpiler/analyzer"
+	"martianoff/gala/internal/transpiler/generator"
+	"martianoff/gala/internal/transpiler/transformer"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNumericWidths(t *testing.T) {
+	const header = `package main
+
+struct Packet(Kind uint8, Size int64, var Ratio float32)
+`
+	tests := []struct {
+		name        string
+		input       string
+		contains    []string
+		notContains []string
+	}{
+		{
+			name:     "positional constants take the field width",
+			input:    header + `val p = Packet(5, 7, 0.5)`,
+			contains: []string{"Kind: std.NewImmutable(uint8(5))", "Size: std.NewImmutable(int64(7))", "Ratio: float32(0.5)"},
+		},
+		{
+			name:     "named constants take the field width",
+			input:    header + `val p = Packet(Kind = 5, Size = 7, Ratio = 1)`,
+			contains: []string{"Kind: std.NewImmutable(uint8(5))", "Size: std.NewImmutable(int64(7))"},
+		},
+		{
+			name: "typed values are widened",
+			input: header + `
+func build(kind uint8, size int32) Packet = Packet(kind, size, 1)`,
+			contains:    []string{"Size: std.NewImmutable(int64(size))"},
+			notContains: []string{"uint8(kind)"},
+		},
+		{
+			name: "narrowing is left to the Go compiler",
+			input: header + `
+func build(kind int, size int64) Packet = Packet(kind, size, 1)`,
+			contains:    []string{"Kind: std.NewImmutable(kind)"},
+			notContains: []string{"uint8(kind)"},
+		},
+		{
+			name: "Copy overrides take the field width",
+			input: header + `
+func relabel(p Packet) Packet = p.Copy(Kind = 9)`,
+			contains: []string{"uint8(9)"},
+		},
+		{
+			name: "function arguments are widened",
+			input: `package main
+
+func total(n int64) int64 = n * 2
+
+func twice(n int32) int64 = total(n)`,
+			contains: []string{"total(int64(n))"},
+		},
+		{
+			name: "typed val is widened",
+			input: `package main
+
+func widen(n uint16) uint32 {
+    val m uint32 = n
+    return m
+}`,
+			contains: []string{"std.NewImmutable[uint32](uint32(n))"},
+		},
+		{
+			name: "typed val constant keeps explicit type argument only",
+			input: `package main
+
+val m uint32 = 3`,
+			contains:    []string{"std.NewImmutable[uint32](3)"},
+			notContains: []string{"uint32(3)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := transpiler.NewAntlrGalaParser()
+			a := analyzer.NewGalaAnalyzer(p, getStdSearchPath())
+			trans := transpiler.NewGalaToGoTranspiler(p, a, transformer.NewGalaASTTransformer(), generator.NewGoCodeGenerator())
+
+			got, err := trans.Transpile(tt.input, "")
+			assert.NoError(t, err)
+			for _, s := range tt.contains {
+				assert.Contains(t, got, s)
+			}
+			for _, s := range tt.notContains {
+				assert.NotContains(t, got, s)
+			}
+		})
+	}
+}
@@ -127,3 +127,65 @@ func update() {
 		})
 	}
 }
+
+func TestMapNLambdaParamInference(t *testing.T) {
+	p := transpiler.NewAntlrGalaParser()
+	a := analyzer.NewGalaAnalyzer(p, getStdSearchPath())
+	tr := transformer.NewGalaASTTransformer()
+	g := generator.NewGoCodeGenerator()
+	trans := transpiler.NewGalaToGoTranspiler(p, a, tr, g)
+
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{
+			name: "Option Map2",
+			input: `package main
+
+val a = Some(1)
+val b = Some("x")
+val c = a.Map2(b, (n, s) => n + len(s))`,
+			expected: []string{"std.Option_Map2(", "func(n int, s string)"},
+		},
+		{
+			name: "Option Map3",
+			input: `package main
+
+val a = Some(1)
+val b = Some("x")
+val c = Some(true)
+val d = a.Map3(b, c, (n, s, ok) => if (ok) n else len(s))`,
+			expected: []string{"std.Option_Map3(", "func(n int, s string, ok bool)"},
+		},
+		{
+			name: "Either Map2",
+			input: `package main
+
+val a Either[string, int] = Right[string, int](1)
+val b Either[string, float64] = Right[string, float64](2.5)
+val c = a.Map2(b, (n, f) => float64(n) * f)`,
+			expected: []string{"std.Either_Map2(", "func(n int, f float64)"},
+		},
+		{
+			name: "Validated Map2",
+			input: `package main
+
+val a Validated[int] = Valid[int](1)
+val b Validated[string] = Valid[string]("x")
+val c = a.Map2(b, (n, s) => n + len(s))`,
+			expected: []string{"std.Validated_Map2(", "func(n int, s string)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := trans.Transpile(tt.input, "")
+			assert.NoError(t, err)
+			for _, want := range tt.expected {
+				assert.Contains(t, got, want)
+			}
+		})
+	}
+}
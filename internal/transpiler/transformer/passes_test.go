@@ -0,0 +1,68 @@
+package transformer_test
+
+import (
+	"errors"
+	"go/ast"
+	"martianoff/gala/internal/transpiler"
+	"martianoff/gala/internal/transpiler/analyzer"
+	"martianoff/gala/internal/transpiler/generator"
+	"martianoff/gala/internal/transpiler/transformer"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranspilerPasses(t *testing.T) {
+	input := `package main
+
+type User struct {
+    Name string
+    Age int
+}`
+
+	schema := transpiler.Pass{
+		Name: "schema",
+		Analyzed: func(ctx *transpiler.PassContext) error {
+			meta := ctx.RichAST.Types["User"]
+			if meta == nil {
+				return errors.New("User metadata missing")
+			}
+			fields := append([]string(nil), meta.FieldNames...)
+			sort.Strings(fields)
+			ctx.EmitFile("user.sql", "CREATE TABLE user ("+strings.Join(fields, ", ")+");")
+			return nil
+		},
+	}
+	marker := transpiler.Pass{
+		Name: "marker",
+		Generated: func(ctx *transpiler.PassContext) error {
+			ctx.File.Decls = append(ctx.File.Decls, &ast.FuncDecl{
+				Name: ast.NewIdent("addedByPass"),
+				Type: &ast.FuncType{Params: &ast.FieldList{}},
+				Body: &ast.BlockStmt{},
+			})
+			return nil
+		},
+	}
+
+	p := transpiler.NewAntlrGalaParser()
+	a := analyzer.NewGalaAnalyzer(p, getStdSearchPath())
+	trans := transpiler.NewGalaToGoTranspiler(p, a, transformer.NewGalaASTTransformer(), generator.NewGoCodeGenerator(),
+		transpiler.WithPass(schema), transpiler.WithPass(marker))
+
+	got, err := trans.Transpile(input, "")
+	assert.NoError(t, err)
+	assert.Contains(t, got, "func addedByPass()")
+	assert.Equal(t, "CREATE TABLE user (Age, Name);", trans.CompanionFiles()["user.sql"])
+
+	failing := transpiler.Pass{
+		Name:     "lint",
+		Analyzed: func(ctx *transpiler.PassContext) error { return errors.New("exported struct without doc comment") },
+	}
+	trans = transpiler.NewGalaToGoTranspiler(p, a, transformer.NewGalaASTTransformer(), generator.NewGoCodeGenerator(),
+		transpiler.WithPass(failing))
+	_, err = trans.Transpile(input, "")
+	assert.EqualError(t, err, "pass lint: exported struct without doc comment")
+}
@@ -0,0 +1,125 @@
+package transformer_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"martianoff/gala/internal/transpiler"
+	"martianoff/gala/internal/transpiler/analyzer"
+	"martianoff/gala/internal/transpiler/generator"
+	"martianoff/gala/internal/transpiler/transformer"
+)
+
+func TestPatternTypeArgs(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		contains    []string
+		notContains []string
+		wantErr     string
+	}{
+		{
+			name: "generic struct pattern on any",
+			input: `package main
+
+type Box[T any] struct {
+    Value T
+}
+
+func describe(x any) int = x match {
+    case Box[int](n) => n + 1
+    case _ => 0
+}
+`,
+			contains: []string{
+				"std.As[Box[int]](",
+				".Value.Get()",
+				"return n + 1",
+			},
+		},
+		{
+			name: "generic struct pattern on the same type",
+			input: `package main
+
+type Pair[A any, B any] struct {
+    First A
+    Second B
+}
+
+func first(p Pair[int, string]) int = p match {
+    case Pair[int, string](a, _) => a * 2
+    case _ => 0
+}
+`,
+			contains:    []string{".First.Get()", "return a * 2"},
+			notContains: []string{"std.As[Pair[int, string]]("},
+		},
+		{
+			name: "generic type argument of an extractor",
+			input: `package main
+
+type Unwrap[T any] struct {}
+
+func (u Unwrap[T]) Unapply(v any) Option[T] = v match {
+    case t: T => Some(t)
+    case _ => None[T]()
+}
+
+func describe(x any) int = x match {
+    case Unwrap[Option[int]](o) => o.GetOrElse(0)
+    case _ => -1
+}
+`,
+			contains: []string{"Unwrap[std.Option[int]]{}.Unapply("},
+		},
+		{
+			name: "type arguments on a non-generic struct",
+			input: `package main
+
+struct Point(X int, Y int)
+
+func x(v any) int = v match {
+    case Point[int](a, _) => a
+    case _ => 0
+}
+`,
+			wantErr: "struct 'Point' takes no type arguments",
+		},
+		{
+			name: "wrong number of type arguments",
+			input: `package main
+
+type Box[T any] struct {
+    Value T
+}
+
+func describe(x any) int = x match {
+    case Box[int, string](n) => n
+    case _ => 0
+}
+`,
+			wantErr: "struct 'Box' expects 1 type parameters, got 2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := transpiler.NewAntlrGalaParser()
+			trans := transpiler.NewGalaToGoTranspiler(p, analyzer.NewGalaAnalyzer(p, getStdSearchPath()),
+				transformer.NewGalaASTTransformer(), generator.NewGoCodeGenerator())
+			got, err := trans.Transpile(tt.input, "")
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			for _, want := range tt.contains {
+				assert.Contains(t, got, want)
+			}
+			for _, unwanted := range tt.notContains {
+				assert.NotContains(t, got, unwanted)
+			}
+		})
+	}
+}
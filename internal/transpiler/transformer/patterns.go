@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"go/ast"
 	"go/token"
+	"strconv"
+	"strings"
 
 	"martianoff/gala/galaerr"
 	"martianoff/gala/internal/parser/grammar"
@@ -14,7 +16,7 @@ import (
 // Functions related to pattern matching, extractors, and type extraction
 
 // blankAssignTempVar appends `_ = varName` to suppress Go's "declared and not used" error
-// for internal temporary variables (_tmp_*). User-facing pattern variables are checked
+// for internal temporary variables (see nextTempVar). User-facing pattern variables are checked
 // for usage by transformCaseClauseWithType and produce a GALA compiler error if unused.
 func blankAssignTempVar(stmts []ast.Stmt, varName string) []ast.Stmt {
 	return append(stmts, &ast.AssignStmt{
@@ -82,6 +84,30 @@ func (t *galaASTTransformer) transformExpressionPatternWithType(patExprCtx gramm
 		// If it's a type name, determine how to match it
 		rawName := t.getBaseTypeName(patternExpr)
 
+		// IsErr(target) takes the error to look for, not a sub-pattern
+		if t.isIsErrExtractor(rawName) {
+			return t.transformIsErrPattern(argList, objExpr)
+		}
+
+		// Variants of a sealed type from this package are matched by their tag,
+		// reading the fields from the parent struct instead of calling Unapply
+		if parent, variant, ok := t.localSealedVariant(rawName, matchedType); ok && !t.hasRestPattern(argList) {
+			if argList == nil || len(argList.AllArgument()) == 0 || len(argList.AllArgument()) == len(variant.FieldNames) {
+				return t.generateSealedVariantPattern(parent, variant, objExpr, argList, matchedType)
+			}
+		}
+
+		// An @extractor function is called on the matched value, which is how
+		// values of Go types that cannot declare Unapply are destructured
+		if fn := t.extractorFunction(rawName); fn != nil {
+			if explicitTypeArgs != nil && len(explicitTypeArgs.AllExpression()) > 0 {
+				return nil, nil, galaerr.NewSemanticError(
+					fmt.Sprintf("extractor function '%s' takes no type arguments", rawName))
+			}
+			call := &ast.CallExpr{Fun: patternExpr, Args: []ast.Expr{objExpr}}
+			return t.bindExtractorResult(rawName, call, fn.ReturnType, argList, nil)
+		}
+
 		// Check if we can use direct Unapply call (no reflection)
 		// This applies to any extractor with an Unapply method - both generic and non-generic
 		// For generic extractors like Cons[T], Some[T], we infer type params from the matched type
@@ -94,13 +120,11 @@ func (t *galaASTTransformer) transformExpressionPatternWithType(patExprCtx gramm
 					// Check if explicit type arguments were provided (e.g., Unwrap[int](v))
 					if explicitTypeArgs != nil && len(explicitTypeArgs.AllExpression()) > 0 {
 						// Use explicit type arguments instead of inferring
-						for _, typeExpr := range explicitTypeArgs.AllExpression() {
-							typeAst, err := t.transformExpression(typeExpr)
-							if err != nil {
-								return nil, nil, err
-							}
-							inferredTypes = append(inferredTypes, t.resolveType(t.getBaseTypeName(typeAst)))
+						_, typeArgs, err := t.patternTypeArgs(explicitTypeArgs)
+						if err != nil {
+							return nil, nil, err
 						}
+						inferredTypes = typeArgs
 						if len(inferredTypes) != len(meta.TypeParams) {
 							return nil, nil, galaerr.NewSemanticError(
 								fmt.Sprintf("extractor '%s' expects %d type parameters, got %d", rawName, len(meta.TypeParams), len(inferredTypes)))
@@ -142,11 +166,14 @@ func (t *galaASTTransformer) transformExpressionPatternWithType(patExprCtx gramm
 			return t.generateDirectTupleStructMatch(objExpr, argList, matchedType)
 		}
 
-		// Check if this is a non-generic struct pattern match (e.g., Person(name, age))
+		// Check if this is a struct pattern match (e.g., Person(name, age), Box[int](v))
 		// Use direct field access for known structs
 		resolvedStructName := t.resolveStructTypeName(rawName)
 		if fields, ok := t.structFields[resolvedStructName]; ok && len(fields) > 0 {
-			return t.generateDirectStructFieldMatch(objExpr, argList, fields, resolvedStructName)
+			if explicitTypeArgs != nil && len(explicitTypeArgs.AllExpression()) > 0 {
+				return t.generateGenericStructFieldMatch(rawName, patternExpr, explicitTypeArgs, objExpr, argList, fields, resolvedStructName, matchedType)
+			}
+			return t.generateDirectStructFieldMatch(objExpr, argList, fields, resolvedStructName, t.structFieldTypes[resolvedStructName])
 		}
 
 		// Extractor not found or doesn't have Unapply method
@@ -213,7 +240,7 @@ func (t *galaASTTransformer) transformTypedPattern(ctx *grammar.TypedPatternCont
 	}
 	t.addVar(name, typeName)
 
-	okName := t.nextTempVar()
+	okName := t.nextNamedTempVar(typeName.String())
 
 	// v, ok := std.As[T](obj)
 	asCall := &ast.CallExpr{
@@ -278,8 +305,8 @@ func (t *galaASTTransformer) transformWildcardTypedPattern(name, baseName string
 	// We just need to verify it's an instance of the generic type
 	t.addVar(name, t.getExprTypeName(objExpr))
 
-	okName := t.nextTempVar()
-	instName := t.nextTempVar()
+	okName := t.nextNamedTempVar(baseName)
+	instName := t.nextNamedTempVar(baseName)
 
 	// inst, ok := any(obj).(WrapInstance)
 	typeAssert := &ast.TypeAssertExpr{
@@ -555,15 +582,7 @@ func (t *galaASTTransformer) generateDirectTupleStructMatch(objExpr ast.Expr, ar
 
 		// Generate direct field access: objExpr.V{i+1}.Get()
 		fieldName := fmt.Sprintf("V%d", i+1)
-		elemExpr := &ast.CallExpr{
-			Fun: &ast.SelectorExpr{
-				X: &ast.SelectorExpr{
-					X:   objExpr,
-					Sel: ast.NewIdent(fieldName),
-				},
-				Sel: ast.NewIdent("Get"),
-			},
-		}
+		elemExpr := t.immutableGet(&ast.SelectorExpr{X: objExpr, Sel: ast.NewIdent(fieldName)})
 
 		// Check if this is a simple binding or a nested pattern
 		patCtx := arg.Pattern()
@@ -618,7 +637,8 @@ func (t *galaASTTransformer) generateDirectTupleStructMatch(objExpr ast.Expr, ar
 // For example, Person(name, age) matching against Person{Name: "Alice", Age: 25}
 // generates: name := obj.Name; age := obj.Age
 // The condition is always true since we're just extracting fields.
-func (t *galaASTTransformer) generateDirectStructFieldMatch(objExpr ast.Expr, argList *grammar.ArgumentListContext, fields []string, structName string) (ast.Expr, []ast.Stmt, error) {
+// fieldTypes holds the types the fields are bound with, if known.
+func (t *galaASTTransformer) generateDirectStructFieldMatch(objExpr ast.Expr, argList *grammar.ArgumentListContext, fields []string, structName string, fieldTypes map[string]transpiler.Type) (ast.Expr, []ast.Stmt, error) {
 	if argList == nil {
 		return ast.NewIdent("true"), nil, nil
 	}
@@ -635,9 +655,6 @@ func (t *galaASTTransformer) generateDirectStructFieldMatch(objExpr ast.Expr, ar
 	var stmts []ast.Stmt
 	var conds []ast.Expr
 
-	// Get field types if available
-	fieldTypes := t.structFieldTypes[structName]
-
 	// Generate bindings for each pattern argument using direct field access
 	for i, argCtx := range args {
 		arg := argCtx.(*grammar.ArgumentContext)
@@ -658,15 +675,7 @@ func (t *galaASTTransformer) generateDirectStructFieldMatch(objExpr ast.Expr, ar
 
 		// Generate direct field access: objExpr.FieldName.Get()
 		// Struct fields are stored as Immutable[T], so we need to call .Get()
-		elemExpr := &ast.CallExpr{
-			Fun: &ast.SelectorExpr{
-				X: &ast.SelectorExpr{
-					X:   objExpr,
-					Sel: ast.NewIdent(fieldName),
-				},
-				Sel: ast.NewIdent("Get"),
-			},
-		}
+		elemExpr := t.immutableGet(&ast.SelectorExpr{X: objExpr, Sel: ast.NewIdent(fieldName)})
 
 		// Check if this is a simple binding or a nested pattern
 		patCtx := arg.Pattern()
@@ -710,7 +719,7 @@ func (t *galaASTTransformer) generateDirectStructFieldMatch(objExpr ast.Expr, ar
 			t.currentScope.valTypes[varName] = expectedType
 
 			// Generate: varName, okN := std.As[ExpectedType](field.Get())
-			okName := t.nextTempVar()
+			okName := t.nextNamedTempVar(expectedType.String())
 			asCall := &ast.CallExpr{
 				Fun: &ast.IndexExpr{
 					X:     t.stdIdent("As"),
@@ -747,6 +756,78 @@ func (t *galaASTTransformer) generateDirectStructFieldMatch(objExpr ast.Expr, ar
 	return finalCond, stmts, nil
 }
 
+// generateGenericStructFieldMatch generates the match of a struct pattern with
+// explicit type arguments, such as Box[int](v) for a struct Box[T] with a field Value T.
+// Unless the matched value is known to be a Box[int], it is asserted to one
+// first; the fields are bound with T replaced by int.
+func (t *galaASTTransformer) generateGenericStructFieldMatch(rawName string, patternExpr ast.Expr, typeArgs *grammar.ExpressionListContext, objExpr ast.Expr, argList *grammar.ArgumentListContext, fields []string, structName string, matchedType transpiler.Type) (ast.Expr, []ast.Stmt, error) {
+	meta := t.getTypeMeta(rawName)
+	if meta == nil || len(meta.TypeParams) == 0 {
+		return nil, nil, galaerr.NewSemanticError(fmt.Sprintf("struct '%s' takes no type arguments", rawName))
+	}
+	argExprs, argTypes, err := t.patternTypeArgs(typeArgs)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(argTypes) != len(meta.TypeParams) {
+		return nil, nil, galaerr.NewSemanticError(
+			fmt.Sprintf("struct '%s' expects %d type parameters, got %d", rawName, len(meta.TypeParams), len(argTypes)))
+	}
+
+	fieldTypes := make(map[string]transpiler.Type)
+	for name, fieldType := range t.structFieldTypes[structName] {
+		fieldTypes[name] = t.substituteConcreteTypes(fieldType, meta.TypeParams, argTypes)
+	}
+
+	var typeExpr ast.Expr = &ast.IndexExpr{X: patternExpr, Index: argExprs[0]}
+	if len(argExprs) > 1 {
+		typeExpr = &ast.IndexListExpr{X: patternExpr, Indices: argExprs}
+	}
+	structType := t.exprToType(typeExpr)
+	if matchedType != nil && !matchedType.IsNil() && matchedType.String() == structType.String() {
+		return t.generateDirectStructFieldMatch(objExpr, argList, fields, structName, fieldTypes)
+	}
+
+	// box, ok := std.As[Box[int]](obj)
+	subject := t.nextNamedTempVar(rawName)
+	okName := t.nextNamedTempVar(rawName)
+	assign := &ast.AssignStmt{
+		Lhs: []ast.Expr{ast.NewIdent(subject), ast.NewIdent(okName)},
+		Tok: token.DEFINE,
+		Rhs: []ast.Expr{&ast.CallExpr{
+			Fun:  &ast.IndexExpr{X: t.stdIdent("As"), Index: typeExpr},
+			Args: []ast.Expr{objExpr},
+		}},
+	}
+	t.needsStdImport = true
+
+	cond, stmts, err := t.generateDirectStructFieldMatch(ast.NewIdent(subject), argList, fields, structName, fieldTypes)
+	if err != nil {
+		return nil, nil, err
+	}
+	var finalCond ast.Expr = ast.NewIdent(okName)
+	if ident, ok := cond.(*ast.Ident); !ok || ident.Name != "true" {
+		finalCond = &ast.BinaryExpr{X: finalCond, Op: token.LAND, Y: cond}
+	}
+	return finalCond, append([]ast.Stmt{assign}, stmts...), nil
+}
+
+// patternTypeArgs returns the explicit type arguments of a pattern, such as
+// the int of Unwrap[int](v), as Go type expressions and as types.
+func (t *galaASTTransformer) patternTypeArgs(typeArgs *grammar.ExpressionListContext) ([]ast.Expr, []transpiler.Type, error) {
+	var exprs []ast.Expr
+	var types []transpiler.Type
+	for _, typeExpr := range typeArgs.AllExpression() {
+		typeAst, err := t.transformExpression(typeExpr)
+		if err != nil {
+			return nil, nil, err
+		}
+		exprs = append(exprs, typeAst)
+		types = append(types, t.exprToType(typeAst))
+	}
+	return exprs, types, nil
+}
+
 // hasRestPattern checks if any argument in the argument list is a rest pattern (ends with ...).
 func (t *galaASTTransformer) hasRestPattern(argList *grammar.ArgumentListContext) bool {
 	if argList == nil {
@@ -1024,7 +1105,7 @@ func (t *galaASTTransformer) generateSeqPatternMatch(objExpr ast.Expr, argList *
 			varDecls = append(varDecls, varDecl)
 
 			// Generate: varName, okN := std.As[ExpectedType](obj.Get(i)) inside guard
-			okName := t.nextTempVar()
+			okName := t.nextNamedTempVar(expectedType.String())
 			asCall := &ast.CallExpr{
 				Fun: &ast.IndexExpr{
 					X:     t.stdIdent("As"),
@@ -1154,15 +1235,7 @@ func (t *galaASTTransformer) transformTuplePattern(patternExprs []grammar.IExpre
 		// Generate direct field access: objExpr.V{i+1}.Get()
 		// Tuple fields are V1, V2, V3, etc.
 		fieldName := fmt.Sprintf("V%d", i+1)
-		elemExpr := &ast.CallExpr{
-			Fun: &ast.SelectorExpr{
-				X: &ast.SelectorExpr{
-					X:   objExpr,
-					Sel: ast.NewIdent(fieldName),
-				},
-				Sel: ast.NewIdent("Get"),
-			},
-		}
+		elemExpr := t.immutableGet(&ast.SelectorExpr{X: objExpr, Sel: ast.NewIdent(fieldName)})
 
 		// Check if this is a simple binding (identifier) or nested pattern
 		if p := t.getPrimaryFromExpression(patExpr); p != nil && p.Identifier() != nil {
@@ -1572,15 +1645,15 @@ func (t *galaASTTransformer) stripReturnStatement(stmt ast.Stmt) ast.Stmt {
 // generateDirectUnapplyPattern generates reflection-free code for generic extractors.
 // Instead of using std.UnapplyFull (which uses reflection), this generates direct method calls:
 //
-//	_tmp_opt := Cons[int]{}.Unapply(list)
-//	_tmp_ok := _tmp_opt.IsDefined()
-//	var _tmp_tuple std.Tuple[int, List[int]]
-//	if _tmp_ok {
-//	    _tmp_tuple = _tmp_opt.Get()
+//	_cons_0 := Cons[int]{}.Unapply(list)
+//	_cons_1 := _cons_0.IsDefined()
+//	var _cons_2 std.Tuple[int, List[int]]
+//	if _cons_1 {
+//	    _cons_2 = _cons_0.Get()
 //	}
-//	head := _tmp_tuple.V1
-//	tail := _tmp_tuple.V2
-//	if _tmp_ok { ... body }
+//	head := _cons_2.V1
+//	tail := _cons_2.V2
+//	if _cons_1 { ... body }
 //
 // This eliminates reflection from: UnapplyFull, UnapplyTuple, GetSafe, and As.
 // The .Get() is guarded by IsDefined() to prevent panics.
@@ -1594,9 +1667,6 @@ func (t *galaASTTransformer) generateDirectUnapplyPattern(
 	matchedType transpiler.Type,
 ) (ast.Expr, []ast.Stmt, error) {
 
-	var allBindings []ast.Stmt
-	var conds []ast.Expr
-
 	// Build the extractor type expression with inferred type parameters
 	// e.g., Cons[int]{}
 	extractorTypeExpr := t.ident(extractorName)
@@ -1614,28 +1684,58 @@ func (t *galaASTTransformer) generateDirectUnapplyPattern(
 	// e.g., Option[Tuple[T, List[T]]] -> Option[Tuple[int, List[int]]]
 	returnType := t.substituteConcreteTypes(unapplyMeta.ReturnType, extractorMeta.TypeParams, inferredTypes)
 
-	// Check if Unapply returns bool (guard pattern) or Option[T] (extractor pattern)
+	// Extractor[T]{}.Unapply(obj)
+	unapplyCall := &ast.CallExpr{
+		Fun: &ast.SelectorExpr{
+			X:   &ast.CompositeLit{Type: extractorTypeExpr},
+			Sel: ast.NewIdent("Unapply"),
+		},
+		Args: []ast.Expr{objExpr},
+	}
+	return t.bindExtractorResult(extractorName, unapplyCall, returnType, argList, matchedType)
+}
+
+// extractorFunction returns the metadata of the function called name if it is
+// annotated with @extractor.
+func (t *galaASTTransformer) extractorFunction(name string) *transpiler.FunctionMetadata {
+	fn := t.getFunction(name)
+	if fn == nil {
+		return nil
+	}
+	if _, ok := transpiler.FindAnnotation(fn.Annotations, transpiler.AnnotationExtractor); !ok {
+		return nil
+	}
+	return fn
+}
+
+// bindExtractorResult matches the result of an extractor call, returning bool
+// or Option[T], and binds the pattern arguments to the extracted value, or to
+// the elements of an extracted tuple. Values extracted as any are bound with
+// the type the extractor's companion metadata gives them in matchedType.
+func (t *galaASTTransformer) bindExtractorResult(
+	extractorName string,
+	call ast.Expr,
+	returnType transpiler.Type,
+	argList *grammar.ArgumentListContext,
+	matchedType transpiler.Type,
+) (ast.Expr, []ast.Stmt, error) {
+
+	var allBindings []ast.Stmt
+	var conds []ast.Expr
+
+	// Check if the extractor returns bool (guard pattern) or Option[T] (extractor pattern)
 	isBoolReturn := false
 	if basic, ok := returnType.(transpiler.BasicType); ok && basic.Name == "bool" {
 		isBoolReturn = true
 	}
 
 	// Generate: _tmp_result := Extractor[T]{}.Unapply(obj)
-	resultName := t.nextTempVar()
-	unapplyCall := &ast.AssignStmt{
+	resultName := t.nextNamedTempVar(extractorName)
+	allBindings = append(allBindings, &ast.AssignStmt{
 		Lhs: []ast.Expr{ast.NewIdent(resultName)},
 		Tok: token.DEFINE,
-		Rhs: []ast.Expr{
-			&ast.CallExpr{
-				Fun: &ast.SelectorExpr{
-					X:   &ast.CompositeLit{Type: extractorTypeExpr},
-					Sel: ast.NewIdent("Unapply"),
-				},
-				Args: []ast.Expr{objExpr},
-			},
-		},
-	}
-	allBindings = append(allBindings, unapplyCall)
+		Rhs: []ast.Expr{call},
+	})
 
 	var okName string
 	var innerType transpiler.Type
@@ -1649,7 +1749,7 @@ func (t *galaASTTransformer) generateDirectUnapplyPattern(
 	} else {
 		// For Option-returning extractors, check IsDefined and extract inner value
 		// Generate: _tmp_ok := _tmp_result.IsDefined()
-		okName = t.nextTempVar()
+		okName = t.nextNamedTempVar(extractorName)
 		isDefinedAssign := &ast.AssignStmt{
 			Lhs: []ast.Expr{ast.NewIdent(okName)},
 			Tok: token.DEFINE,
@@ -1687,7 +1787,7 @@ func (t *galaASTTransformer) generateDirectUnapplyPattern(
 		// Generate a guarded .Get() call:
 		// var _tmp_inner InnerType
 		// if _tmp_ok { _tmp_inner = _tmp_result.Get() }
-		innerName := t.nextTempVar()
+		innerName := t.nextNamedTempVar(extractorName)
 
 		// Declare the variable with its type
 		innerTypeExpr := t.typeToExpr(innerType)
@@ -1772,6 +1872,25 @@ func (t *galaASTTransformer) generateDirectUnapplyPattern(
 				elemExpr = ast.NewIdent(innerName)
 			}
 
+			// A companion whose Unapply yields any, such as one taking any,
+			// extracts the type arguments of the matched type its Apply builds:
+			// _tmp_typed, _ := std.As[int](_tmp_inner)
+			if elemType == nil || elemType.IsNil() || elemType.IsAny() {
+				if extracted := t.getExtractedTypeAtIndexWithArgs(extractorName, matchedType, i, numArgs); extracted != nil && !extracted.IsAny() {
+					typedName := t.nextNamedTempVar(extractorName)
+					allBindings = append(allBindings, &ast.AssignStmt{
+						Lhs: []ast.Expr{ast.NewIdent(typedName), ast.NewIdent("_")},
+						Tok: token.DEFINE,
+						Rhs: []ast.Expr{&ast.CallExpr{
+							Fun:  &ast.IndexExpr{X: t.stdIdent("As"), Index: t.typeToExpr(extracted)},
+							Args: []ast.Expr{elemExpr},
+						}},
+					})
+					t.needsStdImport = true
+					elemType, elemExpr = extracted, ast.NewIdent(typedName)
+				}
+			}
+
 			// Check if this is a simple identifier binding
 			if t.isSimpleIdentifier(patternText) {
 				varName := patternText
@@ -1822,3 +1941,186 @@ func (t *galaASTTransformer) generateDirectUnapplyPattern(
 
 	return finalCond, allBindings, nil
 }
+
+// localSealedVariant finds the variant named variantName of the sealed type
+// matchedType. It only succeeds for sealed types of the current package,
+// whose _variant tag and fields are accessible to the generated code, and for
+// @compact sealed types, which have no Unapply to fall back on.
+func (t *galaASTTransformer) localSealedVariant(variantName string, matchedType transpiler.Type) (*transpiler.TypeMetadata, transpiler.SealedVariant, bool) {
+	if matchedType == nil || matchedType.IsNil() {
+		return nil, transpiler.SealedVariant{}, false
+	}
+	meta := t.getTypeMeta(matchedType.BaseName())
+	if meta == nil || !meta.IsSealed {
+		return nil, transpiler.SealedVariant{}, false
+	}
+	if _, compact := transpiler.FindAnnotation(meta.Annotations, transpiler.AnnotationCompact); compact {
+		// shapes.Circle names the variant of a sealed type of package shapes
+		variantName = variantName[strings.LastIndex(variantName, ".")+1:]
+	} else if strings.Contains(variantName, ".") || (meta.Package != "" && meta.Package != t.packageName) {
+		return nil, transpiler.SealedVariant{}, false
+	}
+	if _, isGeneric := matchedType.(transpiler.GenericType); len(meta.TypeParams) > 0 && !isGeneric {
+		return nil, transpiler.SealedVariant{}, false
+	}
+	for _, v := range meta.SealedVariants {
+		if v.Name == variantName && len(v.StructFieldNames) == len(v.FieldNames) {
+			return meta, v, true
+		}
+	}
+	return nil, transpiler.SealedVariant{}, false
+}
+
+// generateSealedVariantPattern matches a sealed variant by comparing the tag
+// and binds the pattern arguments to the variant's fields:
+//
+//	case Circle(r) => ...
+//
+// becomes
+//
+//	r := obj.Radius.Get()
+//	if obj._variant == _Shape_Circle { ... }
+//
+// Self-referential fields are pointers, which are nil for other variants, so
+// they are only dereferenced when the tag matches.
+func (t *galaASTTransformer) generateSealedVariantPattern(
+	parent *transpiler.TypeMetadata,
+	variant transpiler.SealedVariant,
+	objExpr ast.Expr,
+	argList *grammar.ArgumentListContext,
+	matchedType transpiler.Type,
+) (ast.Expr, []ast.Stmt, error) {
+	var allBindings []ast.Stmt
+
+	// The object is read once per field, so anything but a variable is evaluated once up front
+	if _, ok := objExpr.(*ast.Ident); !ok {
+		objName := t.nextNamedTempVar(parent.Name)
+		allBindings = append(allBindings, &ast.AssignStmt{
+			Lhs: []ast.Expr{ast.NewIdent(objName)},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{objExpr},
+		})
+		objExpr = ast.NewIdent(objName)
+	}
+
+	var cond ast.Expr = &ast.BinaryExpr{
+		X:  &ast.SelectorExpr{X: objExpr, Sel: ast.NewIdent("_variant")},
+		Op: token.EQL,
+		Y:  ast.NewIdent(fmt.Sprintf("_%s_%s", parent.Name, variant.Name)),
+	}
+	foreign := parent.Package != "" && parent.Package != t.packageName
+	if foreign {
+		// The tag is unexported: compare the name Variant returns
+		cond = &ast.BinaryExpr{
+			X:  &ast.CallExpr{Fun: &ast.SelectorExpr{X: objExpr, Sel: ast.NewIdent("Variant")}},
+			Op: token.EQL,
+			Y:  &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(variant.Name)},
+		}
+	}
+	var conds []ast.Expr
+
+	var typeArgs []transpiler.Type
+	if gen, ok := matchedType.(transpiler.GenericType); ok {
+		typeArgs = gen.Params
+	}
+
+	if argList != nil {
+		var okName string
+		for i, argCtx := range argList.AllArgument() {
+			arg := argCtx.(*grammar.ArgumentContext)
+			patternText := arg.Pattern().GetText()
+			if isWildcard(patternText) {
+				continue
+			}
+
+			fieldName := variant.StructFieldNames[i]
+			if foreign && !ast.IsExported(fieldName) {
+				return nil, nil, galaerr.NewSemanticError(fmt.Sprintf("cannot match field %s of %s outside package %s: the field is unexported and @compact sealed types have no Unapply", variant.FieldNames[i], variant.Name, parent.Package)).WithCode(galaerr.CodeVisibility)
+			}
+			elemType := variant.FieldTypes[i]
+			if len(parent.TypeParams) > 0 {
+				elemType = t.substituteConcreteTypes(elemType, parent.TypeParams, typeArgs)
+			}
+			var elemExpr ast.Expr
+			if t.isSealedPointerField(parent, fieldName) {
+				// var _expr_1 Expr; if _expr_0 { _expr_1 = *obj.Left }
+				if okName == "" {
+					okName = t.nextNamedTempVar(variant.Name)
+					allBindings = append(allBindings, &ast.AssignStmt{
+						Lhs: []ast.Expr{ast.NewIdent(okName)},
+						Tok: token.DEFINE,
+						Rhs: []ast.Expr{cond},
+					})
+					cond = ast.NewIdent(okName)
+				}
+				elemName := t.nextNamedTempVar(variant.Name)
+				allBindings = append(allBindings,
+					&ast.DeclStmt{
+						Decl: &ast.GenDecl{
+							Tok: token.VAR,
+							Specs: []ast.Spec{
+								&ast.ValueSpec{
+									Names: []*ast.Ident{ast.NewIdent(elemName)},
+									Type:  t.typeToExpr(elemType),
+								},
+							},
+						},
+					},
+					&ast.IfStmt{
+						Cond: ast.NewIdent(okName),
+						Body: &ast.BlockStmt{
+							List: []ast.Stmt{
+								&ast.AssignStmt{
+									Lhs: []ast.Expr{ast.NewIdent(elemName)},
+									Tok: token.ASSIGN,
+									Rhs: []ast.Expr{&ast.StarExpr{X: &ast.SelectorExpr{X: objExpr, Sel: ast.NewIdent(fieldName)}}},
+								},
+							},
+						},
+					},
+				)
+				allBindings = blankAssignTempVar(allBindings, elemName)
+				elemExpr = ast.NewIdent(elemName)
+			} else {
+				// Immutable fields of other variants hold zero values, so Get is always safe
+				elemExpr = t.immutableGet(&ast.SelectorExpr{X: objExpr, Sel: ast.NewIdent(fieldName)})
+			}
+
+			if t.isSimpleIdentifier(patternText) {
+				t.currentScope.vals[patternText] = false
+				t.currentScope.valTypes[patternText] = elemType
+				allBindings = append(allBindings, &ast.AssignStmt{
+					Lhs: []ast.Expr{ast.NewIdent(patternText)},
+					Tok: token.DEFINE,
+					Rhs: []ast.Expr{elemExpr},
+				})
+				continue
+			}
+
+			subCond, subBindings, err := t.transformPatternWithType(arg.Pattern(), elemExpr, elemType)
+			if err != nil {
+				return nil, nil, err
+			}
+			allBindings = append(allBindings, subBindings...)
+			if ident, ok := subCond.(*ast.Ident); subCond != nil && (!ok || ident.Name != "true") {
+				conds = append(conds, subCond)
+			}
+		}
+	}
+
+	for _, c := range conds {
+		cond = &ast.BinaryExpr{X: cond, Op: token.LAND, Y: c}
+	}
+	return cond, allBindings, nil
+}
+
+// isSealedPointerField reports whether fieldName of the sealed type parent is
+// a self-referential field, which is stored as a pointer instead of an Immutable.
+func (t *galaASTTransformer) isSealedPointerField(parent *transpiler.TypeMetadata, fieldName string) bool {
+	for i, name := range parent.FieldNames {
+		if name == fieldName {
+			return i < len(parent.ImmutFlags) && !parent.ImmutFlags[i]
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package transformer
+
+import (
+	"go/ast"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"martianoff/gala/galaerr"
+	"martianoff/gala/internal/parser/grammar"
+	"martianoff/gala/internal/transpiler"
+)
+
+// This file contains the desugaring of placeholder lambdas: an argument such as
+// `_ * 2` or `_.Active` passed where a one-parameter function is expected
+// becomes `(x) => x * 2` / `(x) => x.Active`, typed from the expected function.
+// Functions: containsPlaceholder, transformPlaceholderLambda, currentPlaceholder
+
+// placeholderName is the identifier that stands for the lambda parameter.
+const placeholderName = "_"
+
+// containsPlaceholder reports whether node uses `_` as a value outside of
+// nested lambdas, case clauses and partial functions, which bind their own
+// names and handle `_` as a wildcard.
+func containsPlaceholder(node antlr.Tree) bool {
+	switch n := node.(type) {
+	case *grammar.LambdaExpressionContext, *grammar.CaseClauseContext, *grammar.PartialFunctionLiteralContext:
+		return false
+	case *grammar.PrimaryContext:
+		if n.Identifier() != nil && n.Identifier().GetText() == placeholderName {
+			return true
+		}
+	}
+	for i := 0; i < node.GetChildCount(); i++ {
+		if containsPlaceholder(node.GetChild(i)) {
+			return true
+		}
+	}
+	return false
+}
+
+// transformPlaceholderLambda turns exprCtx into a one-parameter function
+// literal of type fn. Every `_` in exprCtx that is not claimed by a nested
+// function-typed argument refers to the parameter.
+func (t *galaASTTransformer) transformPlaceholderLambda(exprCtx grammar.IExpressionContext, fn transpiler.FuncType) (ast.Expr, error) {
+	paramType := fn.Params[0]
+	if paramType == nil || paramType.IsNil() || paramType.IsAny() || t.hasTypeParams(paramType) {
+		return nil, galaerr.NewSemanticError("cannot infer the parameter type for '_' here; write an explicit lambda such as (x T) => ...")
+	}
+
+	t.pushScope()
+	defer t.popScope()
+	param := t.nextTempVar()
+	t.addVar(param, paramType)
+	t.placeholders = append(t.placeholders, param)
+	body, err := t.transformExpression(exprCtx)
+	t.placeholders = t.placeholders[:len(t.placeholders)-1]
+	if err != nil {
+		return nil, err
+	}
+
+	funcType := &ast.FuncType{
+		Params: &ast.FieldList{List: []*ast.Field{{
+			Names: []*ast.Ident{ast.NewIdent(param)},
+			Type:  t.typeToExpr(paramType),
+		}}},
+	}
+	if len(fn.Results) == 0 {
+		return &ast.FuncLit{Type: funcType, Body: &ast.BlockStmt{List: []ast.Stmt{&ast.ExprStmt{X: body}}}}, nil
+	}
+	retType := t.typeToExpr(fn.Results[0])
+	if containsAny(retType) || t.hasTypeParams(fn.Results[0]) {
+		retType = t.getExprType(body)
+	}
+	funcType.Results = &ast.FieldList{List: []*ast.Field{{Type: retType}}}
+	return &ast.FuncLit{Type: funcType, Body: &ast.BlockStmt{List: []ast.Stmt{&ast.ReturnStmt{Results: []ast.Expr{body}}}}}, nil
+}
+
+// currentPlaceholder returns the parameter name `_` refers to, or "" outside
+// of a placeholder lambda.
+func (t *galaASTTransformer) currentPlaceholder() string {
+	if len(t.placeholders) == 0 {
+		return ""
+	}
+	return t.placeholders[len(t.placeholders)-1]
+}
@@ -0,0 +1,99 @@
+package transformer_test
+
+import (
+	"martianoff/gala/internal/transpiler"
+	"martianoff/gala/internal/transpiler/analyzer"
+	"martianoff/gala/internal/transpiler/generator"
+	"martianoff/gala/internal/transpiler/transformer"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlaceholderLambda(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		contains    []string
+		notContains []string
+		wantErr     string
+	}{
+		{
+			name: "arithmetic",
+			input: `package main
+
+val a = Some(21)
+val b = a.Map(_ * 2)`,
+			contains: []string{"std.Option_Map(", "func(_tmp_0 int) int", "return _tmp_0 * 2"},
+		},
+		{
+			name: "field access",
+			input: `package main
+
+struct User(Name string, Active bool)
+
+val u = Some(User("Ann", true))
+val active = u.Filter(_.Active)`,
+			contains: []string{"func(_tmp_0 User) bool", "_tmp_0.Active"},
+		},
+		{
+			name: "method call",
+			input: `package main
+
+val a = Some("go")
+val b = a.Map(_ + "!")`,
+			contains: []string{"func(_tmp_0 string) string"},
+		},
+		{
+			name: "void callback",
+			input: `package main
+
+import "fmt"
+
+func main() {
+    Some(1).ForEach(fmt.Println(_))
+}`,
+			contains:    []string{"func(_tmp_0 int) {"},
+			notContains: []string{"return fmt.Println"},
+		},
+		{
+			name: "nested lambda keeps its own parameters",
+			input: `package main
+
+val a = Some(1)
+val b = a.Map((x) => x + 1)`,
+			contains:    []string{"func(x int) int"},
+			notContains: []string{"_tmp_0 int"},
+		},
+		{
+			name: "untyped parameter",
+			input: `package main
+
+func show(f func(any) string) string = f(1)
+
+val s = show(_ + "x")`,
+			wantErr: "cannot infer the parameter type for '_'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := transpiler.NewAntlrGalaParser()
+			a := analyzer.NewGalaAnalyzer(p, getStdSearchPath())
+			trans := transpiler.NewGalaToGoTranspiler(p, a, transformer.NewGalaASTTransformer(), generator.NewGoCodeGenerator())
+
+			got, err := trans.Transpile(tt.input, "")
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			for _, s := range tt.contains {
+				assert.Contains(t, got, s)
+			}
+			for _, s := range tt.notContains {
+				assert.NotContains(t, got, s)
+			}
+		})
+	}
+}
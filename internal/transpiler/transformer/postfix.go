@@ -3,7 +3,6 @@ package transformer
 import (
 	"fmt"
 	"go/ast"
-	"go/token"
 	"strings"
 
 	"github.com/antlr4-go/antlr/v4"
@@ -40,7 +39,10 @@ func (t *galaASTTransformer) transformPostfixExpr(ctx *grammar.PostfixExprContex
 
 	// Apply postfix suffixes
 	suffixes := ctx.AllPostfixSuffix()
-	for _, suffix := range suffixes {
+	for i, suffix := range suffixes {
+		if i > 0 && isSafeAccess(suffixes[i-1].(*grammar.PostfixSuffixContext)) && suffix.GetChild(0).(antlr.ParseTree).GetText() == "(" {
+			return nil, galaerr.NewSemanticError("?. supports field access only; call methods through Map or FlatMap")
+		}
 		result, err = t.applyPostfixSuffix(result, suffix.(*grammar.PostfixSuffixContext))
 		if err != nil {
 			return nil, err
@@ -51,6 +53,9 @@ func (t *galaASTTransformer) transformPostfixExpr(ctx *grammar.PostfixExprContex
 }
 
 func (t *galaASTTransformer) applyPostfixSuffix(base ast.Expr, suffix *grammar.PostfixSuffixContext) (ast.Expr, error) {
+	if isSafeAccess(suffix) {
+		return t.applySafeFieldAccess(base, suffix.Identifier().GetText())
+	}
 	if suffix.Identifier() != nil {
 		return t.resolveFieldAccess(base, suffix.Identifier().GetText())
 	}
@@ -59,7 +64,11 @@ func (t *galaASTTransformer) applyPostfixSuffix(base ast.Expr, suffix *grammar.P
 	if childCount >= 2 {
 		firstChild := suffix.GetChild(0).(antlr.ParseTree).GetText()
 		if firstChild == "(" {
-			return t.applyCallSuffix(base, suffix)
+			call, err := t.applyCallSuffix(base, suffix)
+			if err != nil {
+				return nil, err
+			}
+			return t.captureArgs(base, call, suffix), nil
 		}
 		if firstChild == "[" {
 			return t.resolveIndexAccess(base, suffix)
@@ -89,9 +98,7 @@ func (t *galaASTTransformer) resolveFieldAccess(base ast.Expr, selName string) (
 	selExpr := &ast.SelectorExpr{X: base, Sel: ast.NewIdent(selName)}
 
 	if t.isImmutableField(xType, selExpr, selName) {
-		return &ast.CallExpr{
-			Fun: &ast.SelectorExpr{X: selExpr, Sel: ast.NewIdent("Get")},
-		}, nil
+		return t.immutableGet(selExpr), nil
 	}
 
 	return selExpr, nil
@@ -221,7 +228,7 @@ func (t *galaASTTransformer) buildMatchExpressionFromClauses(subject ast.Expr, p
 		matchedType, _ = t.inferExprType(subject)
 	}
 	if matchedType == nil || matchedType.IsNil() {
-		return nil, galaerr.NewSemanticError("cannot infer type of matched expression")
+		return nil, galaerr.NewSemanticError("cannot infer type of matched expression").WithCode(galaerr.CodeMatchSubjectType)
 	}
 
 	// Note: We intentionally do NOT replace types with unresolved type parameters (like Box[T])
@@ -273,7 +280,7 @@ func (t *galaASTTransformer) buildMatchExpressionFromClauses(subject ast.Expr, p
 			continue
 		}
 
-		clause, resultType, err := t.transformCaseClauseWithType(ccCtx, paramName, matchedType)
+		clause, resultType, err := t.transformCaseClauseWithType(ccCtx, paramName, matchedType, false)
 		if err != nil {
 			return nil, err
 		}
@@ -316,15 +323,11 @@ func (t *galaASTTransformer) buildMatchExpressionFromClauses(subject ast.Expr, p
 				return nil, galaerr.NewSemanticError(
 					fmt.Sprintf("non-exhaustive match: missing cases: %s", strings.Join(missing, ", ")))
 			} else if isSealed && isExhaustive {
-				// Exhaustive sealed match — generate synthetic panic("unreachable") default
-				defaultBody = []ast.Stmt{
-					&ast.ExprStmt{X: &ast.CallExpr{
-						Fun:  ast.NewIdent("panic"),
-						Args: []ast.Expr{&ast.BasicLit{Kind: token.STRING, Value: `"unreachable"`}},
-					}},
-				}
+				// Exhaustive sealed match — generate a default that panics with the location
+				matchCtx, _ := caseClauses[0].GetParent().(antlr.ParserRuleContext)
+				defaultBody = t.matchFailure(matchCtx, paramName)
 			} else if !isSealed {
-				return nil, galaerr.NewSemanticError("match expression must have a default case (case _ => ...)")
+				return nil, galaerr.NewSemanticError("match expression must have a default case (case _ => ...)").WithCode(galaerr.CodeMatchNoDefault)
 			}
 		}
 		// When foundDefault && isSealed && isExhaustive: unreachable default is harmless, allow it
@@ -0,0 +1,117 @@
+package transformer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+
+	"martianoff/gala/internal/transpiler"
+)
+
+// registerPrelude dot-imports the project prelude packages listed in
+// richAST.Prelude so their exports resolve without a qualifier. It must run
+// before the file's own imports are registered: an explicit import of a
+// prelude package replaces its entry.
+func (t *galaASTTransformer) registerPrelude(richAST *transpiler.RichAST) []*ImportEntry {
+	var entries []*ImportEntry
+	for _, path := range richAST.Prelude {
+		entries = append(entries, t.importManager.Add(path, "", true, richAST.Packages[path]))
+	}
+	return entries
+}
+
+// addPreludeImports emits a dot import for every prelude entry that is still
+// in effect and whose exports the file references. Go rejects unused imports,
+// so packages the file does not use are left out.
+func (t *galaASTTransformer) addPreludeImports(file *ast.File, richAST *transpiler.RichAST, entries []*ImportEntry) {
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		if current, ok := t.importManager.GetByPath(entry.Path); !ok || current != entry {
+			continue // imported explicitly
+		}
+		if !referencesAny(file, preludeExports(richAST, entry.PkgName)) {
+			continue
+		}
+		importDecl := &ast.GenDecl{
+			Tok: token.IMPORT,
+			Specs: []ast.Spec{
+				&ast.ImportSpec{
+					Name: ast.NewIdent("."),
+					Path: &ast.BasicLit{Kind: token.STRING, Value: fmt.Sprintf("%q", entry.Path)},
+				},
+			},
+		}
+		file.Decls = append([]ast.Decl{importDecl}, file.Decls...)
+	}
+}
+
+// preludeExports returns the exported types, functions and companions that
+// package pkgName contributes to richAST.
+func preludeExports(richAST *transpiler.RichAST, pkgName string) map[string]bool {
+	names := make(map[string]bool)
+	for _, meta := range richAST.Types {
+		if meta.Package == pkgName && ast.IsExported(meta.Name) {
+			names[meta.Name] = true
+		}
+	}
+	for _, meta := range richAST.Functions {
+		if meta.Package == pkgName && ast.IsExported(meta.Name) {
+			names[meta.Name] = true
+		}
+	}
+	for _, meta := range richAST.CompanionObjects {
+		if meta.Package == pkgName && ast.IsExported(meta.Name) {
+			names[meta.Name] = true
+		}
+	}
+	return names
+}
+
+// referencesAny reports whether an unqualified identifier in file refers to
+// one of names. Selected fields and methods, declared parameter and field
+// names, function names and struct literal keys are not references.
+func referencesAny(file *ast.File, names map[string]bool) bool {
+	if len(names) == 0 {
+		return false
+	}
+	found := false
+	var visit func(n ast.Node) bool
+	visit = func(n ast.Node) bool {
+		if found || n == nil {
+			return false
+		}
+		switch n := n.(type) {
+		case *ast.Ident:
+			found = names[n.Name]
+		case *ast.SelectorExpr:
+			ast.Inspect(n.X, visit)
+			return false
+		case *ast.Field:
+			ast.Inspect(n.Type, visit)
+			return false
+		case *ast.FuncDecl:
+			if n.Recv != nil {
+				ast.Inspect(n.Recv, visit)
+			}
+			ast.Inspect(n.Type, visit)
+			if n.Body != nil {
+				ast.Inspect(n.Body, visit)
+			}
+			return false
+		case *ast.KeyValueExpr:
+			if _, isIdent := n.Key.(*ast.Ident); !isIdent {
+				ast.Inspect(n.Key, visit)
+			}
+			ast.Inspect(n.Value, visit)
+			return false
+		}
+		return !found
+	}
+	for _, decl := range file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+			continue
+		}
+		ast.Inspect(decl, visit)
+	}
+	return found
+}
@@ -0,0 +1,66 @@
+package transformer_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"martianoff/gala/internal/transpiler"
+	"martianoff/gala/internal/transpiler/analyzer"
+	"martianoff/gala/internal/transpiler/generator"
+	"martianoff/gala/internal/transpiler/transformer"
+)
+
+func TestProjectPrelude(t *testing.T) {
+	tempDir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(tempDir, "go.mod"), []byte("module testmod\n\ngo 1.21\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tempDir, "gala.toml"), []byte("[project]\nprelude = [\"testmod/domain\"]\n"), 0644))
+	assert.NoError(t, os.MkdirAll(filepath.Join(tempDir, "domain"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(tempDir, "domain", "domain.gala"), []byte(`package domain
+
+struct Money(Cents int)
+
+func Zero() Money = Money(Cents = 0)
+`), 0644))
+
+	originalWd, err := os.Getwd()
+	assert.NoError(t, err)
+	defer os.Chdir(originalWd)
+	assert.NoError(t, os.Chdir(tempDir))
+
+	transpile := func(input, filePath string) (string, error) {
+		p := transpiler.NewAntlrGalaParser()
+		trans := transpiler.NewGalaToGoTranspiler(p, analyzer.NewGalaAnalyzer(p, nil),
+			transformer.NewGalaASTTransformer(), generator.NewGoCodeGenerator())
+		return trans.Transpile(input, filePath)
+	}
+
+	t.Run("exports are available without an import", func(t *testing.T) {
+		got, err := transpile("package billing\n\nfunc Fee() Money = Zero()\n", "billing/fee.gala")
+		assert.NoError(t, err)
+		assert.Contains(t, got, `. "testmod/domain"`)
+		assert.Contains(t, got, "func Fee() Money")
+		assert.Contains(t, got, "return Zero()")
+	})
+
+	t.Run("unused prelude is not imported", func(t *testing.T) {
+		got, err := transpile("package billing\n\nfunc Rate() int = 3\n", "billing/rate.gala")
+		assert.NoError(t, err)
+		assert.NotContains(t, got, "testmod/domain")
+	})
+
+	t.Run("explicit import takes precedence", func(t *testing.T) {
+		got, err := transpile("package billing\n\nimport \"testmod/domain\"\n\nfunc Fee() domain.Money = domain.Zero()\n", "billing/fee.gala")
+		assert.NoError(t, err)
+		assert.Contains(t, got, `"testmod/domain"`)
+		assert.NotContains(t, got, `. "testmod/domain"`)
+	})
+
+	t.Run("prelude package does not import itself", func(t *testing.T) {
+		got, err := transpile("package domain\n\nfunc One() Money = Money(Cents = 100)\n", "domain/one.gala")
+		assert.NoError(t, err)
+		assert.NotContains(t, got, "testmod/domain")
+	})
+}
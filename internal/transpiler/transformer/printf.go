@@ -0,0 +1,180 @@
+package transformer
+
+import (
+	"go/ast"
+	"go/token"
+	"strconv"
+	"strings"
+
+	"martianoff/gala/internal/transpiler"
+)
+
+// This file lowers fmt.Sprintf calls with simple formats into string concatenation,
+// which avoids fmt's reflection-based formatting on hot paths such as logging.
+//
+// A call is lowered only when the format is a string literal whose verbs are plain
+// %s, %d, %t, %v or %% (no flags, width, precision or argument indexes), the number
+// of arguments matches the verbs, and every argument has a basic type whose text the
+// verb produces without fmt: strings for %s and %v, integers for %d and %v, and bools
+// for %t and %v. Anything else keeps the fmt.Sprintf call.
+// Functions: isPackageFunc, lowerSprintf, parseSimpleFormat, formatArg, strconvFunc,
+//            dropUnusedImport
+
+// isPackageFunc reports whether fun is pkgPath's function name, e.g. fmt.Sprintf.
+func (t *galaASTTransformer) isPackageFunc(fun ast.Expr, pkgPath, name string) bool {
+	sel, ok := fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != name {
+		return false
+	}
+	x, ok := sel.X.(*ast.Ident)
+	if !ok || t.isVal(x.Name) || t.isVar(x.Name) {
+		return false
+	}
+	entry, ok := t.importManager.GetByAlias(x.Name)
+	return ok && !entry.IsDot && entry.Path == pkgPath
+}
+
+// lowerSprintf returns the concatenation equivalent to fmt.Sprintf(args...),
+// or false when the call does not qualify.
+func (t *galaASTTransformer) lowerSprintf(args []ast.Expr) (ast.Expr, bool) {
+	if len(args) == 0 {
+		return nil, false
+	}
+	lit, ok := args[0].(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return nil, false
+	}
+	format, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return nil, false
+	}
+	texts, verbs, ok := parseSimpleFormat(format)
+	if !ok || len(verbs) != len(args)-1 {
+		return nil, false
+	}
+	formatted := make([]ast.Expr, len(verbs))
+	for i, verb := range verbs {
+		if formatted[i] = t.formatArg(verb, args[i+1]); formatted[i] == nil {
+			return nil, false
+		}
+	}
+
+	var parts []ast.Expr
+	for i, text := range texts {
+		if text != "" {
+			parts = append(parts, &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(text)})
+		}
+		if i < len(formatted) {
+			parts = append(parts, formatted[i])
+		}
+	}
+	if len(parts) == 0 {
+		return &ast.BasicLit{Kind: token.STRING, Value: `""`}, true
+	}
+	result := parts[0]
+	for _, part := range parts[1:] {
+		result = &ast.BinaryExpr{X: result, Op: token.ADD, Y: part}
+	}
+	t.loweredSprintf = true
+	return result, true
+}
+
+// parseSimpleFormat splits format into the literal texts around its verbs. It
+// returns len(verbs)+1 texts, or false if format uses anything but plain %s,
+// %d, %t, %v and %%.
+func parseSimpleFormat(format string) (texts []string, verbs []byte, ok bool) {
+	var text strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			text.WriteByte(format[i])
+			continue
+		}
+		if i+1 == len(format) {
+			return nil, nil, false
+		}
+		i++
+		switch verb := format[i]; verb {
+		case '%':
+			text.WriteByte('%')
+		case 's', 'd', 't', 'v':
+			texts = append(texts, text.String())
+			verbs = append(verbs, verb)
+			text.Reset()
+		default:
+			return nil, nil, false
+		}
+	}
+	return append(texts, text.String()), verbs, true
+}
+
+// formatArg returns the string expression verb produces for arg, or nil when
+// arg's type needs fmt.
+func (t *galaASTTransformer) formatArg(verb byte, arg ast.Expr) ast.Expr {
+	typ, ok := t.getExprTypeName(arg).(transpiler.BasicType)
+	if !ok {
+		return nil
+	}
+	switch {
+	case typ.Name == "string" && (verb == 's' || verb == 'v'):
+		return arg
+	case typ.Name == "bool" && (verb == 't' || verb == 'v'):
+		return &ast.CallExpr{Fun: t.strconvFunc("FormatBool"), Args: []ast.Expr{arg}}
+	case typ.Name == "int" && (verb == 'd' || verb == 'v'):
+		return &ast.CallExpr{Fun: t.strconvFunc("Itoa"), Args: []ast.Expr{arg}}
+	case transpiler.IsIntegerType(typ.Name) && (verb == 'd' || verb == 'v'):
+		if info := numericTypes[typ.Name]; info.kind == 'u' {
+			return &ast.CallExpr{Fun: t.strconvFunc("FormatUint"), Args: []ast.Expr{
+				&ast.CallExpr{Fun: ast.NewIdent("uint64"), Args: []ast.Expr{arg}},
+				&ast.BasicLit{Kind: token.INT, Value: "10"},
+			}}
+		}
+		return &ast.CallExpr{Fun: t.strconvFunc("FormatInt"), Args: []ast.Expr{
+			&ast.CallExpr{Fun: ast.NewIdent("int64"), Args: []ast.Expr{arg}},
+			&ast.BasicLit{Kind: token.INT, Value: "10"},
+		}}
+	}
+	return nil
+}
+
+// strconvFunc returns a reference to strconv.name, importing strconv if the
+// file does not already.
+func (t *galaASTTransformer) strconvFunc(name string) ast.Expr {
+	if entry, ok := t.importManager.GetByPath("strconv"); ok {
+		if entry.IsDot {
+			return ast.NewIdent(name)
+		}
+		return &ast.SelectorExpr{X: ast.NewIdent(entry.Alias), Sel: ast.NewIdent(name)}
+	}
+	t.needsStrconvImport = true
+	return &ast.SelectorExpr{X: ast.NewIdent("strconv"), Sel: ast.NewIdent(name)}
+}
+
+// dropUnusedImport removes the import of path from file when nothing in the
+// file refers to it any more, e.g. fmt after all its Sprintf calls were lowered.
+func dropUnusedImport(file *ast.File, path string) {
+	quoted := strconv.Quote(path)
+	for i, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			continue
+		}
+		for j, spec := range gen.Specs {
+			imp := spec.(*ast.ImportSpec)
+			if imp.Path.Value != quoted {
+				continue
+			}
+			name := path[strings.LastIndex(path, "/")+1:]
+			if imp.Name != nil {
+				name = imp.Name.Name
+			}
+			if name == "." || name == "_" || referencesAny(file, map[string]bool{name: true}) {
+				return
+			}
+			gen.Specs = append(gen.Specs[:j], gen.Specs[j+1:]...)
+			if len(gen.Specs) == 0 {
+				file.Decls = append(file.Decls[:i], file.Decls[i+1:]...)
+			}
+			return
+		}
+	}
+}
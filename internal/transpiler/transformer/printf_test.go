@@ -0,0 +1,127 @@
+package transformer_test
+
+import (
+	"martianoff/gala/internal/transpiler"
+	"martianoff/gala/internal/transpiler/analyzer"
+	"martianoff/gala/internal/transpiler/generator"
+	"martianoff/gala/internal/transpiler/transformer"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSprintfLowering(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		contains    []string
+		notContains []string
+	}{
+		{
+			name: "strings are concatenated",
+			input: `package main
+
+import "fmt"
+
+func greet(name string) string = fmt.Sprintf("Hi %s, bye %v!", name, name)`,
+			contains:    []string{`"Hi " + name + ", bye " + name + "!"`},
+			notContains: []string{"Sprintf", `"fmt"`},
+		},
+		{
+			name: "integers and bools use strconv",
+			input: `package main
+
+import "fmt"
+
+func describe(n int, big int64, small uint8, ok bool) string =
+    fmt.Sprintf("%d/%d/%v/%t", n, big, small, ok)`,
+			contains: []string{
+				"strconv.Itoa(n)",
+				"strconv.FormatInt(int64(big), 10)",
+				"strconv.FormatUint(uint64(small), 10)",
+				"strconv.FormatBool(ok)",
+				`"strconv"`,
+			},
+			notContains: []string{"Sprintf"},
+		},
+		{
+			name: "percent escape",
+			input: `package main
+
+import "fmt"
+
+func pct(n int) string = fmt.Sprintf("%d%%", n)`,
+			contains: []string{`strconv.Itoa(n) + "%"`},
+		},
+		{
+			name: "existing strconv alias is reused",
+			input: `package main
+
+import (
+    "fmt"
+    sc "strconv"
+)
+
+func show(n int) string = fmt.Sprintf("n=%d", n) + sc.Quote("x")`,
+			contains: []string{"sc.Itoa(n)"},
+		},
+		{
+			name: "fmt stays imported while still used",
+			input: `package main
+
+import "fmt"
+
+func main() {
+    val name = "Ann"
+    fmt.Println(fmt.Sprintf("hello %s", name))
+}`,
+			contains: []string{`"hello " + name`, `"fmt"`, "fmt.Println("},
+		},
+		{
+			name: "width and other verbs keep Sprintf",
+			input: `package main
+
+import "fmt"
+
+func pad(n int) string = fmt.Sprintf("%5d|%x", n, n)`,
+			contains: []string{`fmt.Sprintf("%5d|%x", n, n)`},
+		},
+		{
+			name: "non-basic argument keeps Sprintf",
+			input: `package main
+
+import "fmt"
+
+struct Point(X int, Y int)
+
+func show(p Point) string = fmt.Sprintf("%v", p)`,
+			contains: []string{`fmt.Sprintf("%v", p)`},
+		},
+		{
+			name: "argument count mismatch keeps Sprintf",
+			input: `package main
+
+import "fmt"
+
+func show(n int) string = fmt.Sprintf("%d %d", n)`,
+			contains: []string{`fmt.Sprintf("%d %d", n)`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := transpiler.NewAntlrGalaParser()
+			a := analyzer.NewGalaAnalyzer(p, getStdSearchPath())
+			trans := transpiler.NewGalaToGoTranspiler(p, a, transformer.NewGalaASTTransformer(), generator.NewGoCodeGenerator())
+
+			got, err := trans.Transpile(tt.input, "")
+			assert.NoError(t, err)
+			for _, s := range tt.contains {
+				assert.Contains(t, got, s)
+			}
+			for _, s := range tt.notContains {
+				assert.NotContains(t, got, s)
+			}
+		})
+	}
+}
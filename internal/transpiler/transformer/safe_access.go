@@ -0,0 +1,162 @@
+package transformer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+
+	"github.com/antlr4-go/antlr/v4"
+	"martianoff/gala/galaerr"
+	"martianoff/gala/internal/parser/grammar"
+	"martianoff/gala/internal/transpiler"
+)
+
+// This file contains the lowering of safe field access (user?.Address?.City)
+// Functions: applySafeFieldAccess, safeField, nilCheckedOption, returnNoneIfNil,
+//            someOf, optionTypeExpr, isSafeAccess
+
+// applySafeFieldAccess lowers base?.name to an Option of the field. An Option
+// base is chained with Option_FlatMap, a nil pointer base yields None and any
+// other base is read directly.
+func (t *galaASTTransformer) applySafeFieldAccess(base ast.Expr, name string) (ast.Expr, error) {
+	baseType := t.getExprTypeName(base)
+	if t.isImmutableType(baseType) {
+		base = t.unwrapImmutable(base)
+		baseType = t.getExprTypeName(base)
+	}
+	if baseType.IsNil() || baseType.IsAny() {
+		return nil, galaerr.NewSemanticError(fmt.Sprintf("cannot infer the type of the receiver of ?.%s", name))
+	}
+
+	elemType := t.unwrapOptionType(baseType)
+	_, isPointer := baseType.(transpiler.PointerType)
+	if elemType == nil && !isPointer {
+		field, _, err := t.safeField(base, baseType, name)
+		return field, err
+	}
+	if elemType == nil {
+		elemType = baseType
+	}
+
+	// Read the field inside a function of the (non-nil) receiver
+	param := t.nextTempVar()
+	t.pushScope()
+	t.addVar(param, elemType)
+	field, fieldType, err := t.safeField(ast.NewIdent(param), elemType, name)
+	t.popScope()
+	if err != nil {
+		return nil, err
+	}
+	resultType := t.optionTypeExpr(fieldType)
+
+	if isPointer {
+		// func(p *User) Option[V] { if p == nil { return None[V]{}.Apply() }; return <field> }(base)
+		return &ast.CallExpr{
+			Fun: &ast.FuncLit{
+				Type: &ast.FuncType{
+					Params:  &ast.FieldList{List: []*ast.Field{{Names: []*ast.Ident{ast.NewIdent(param)}, Type: t.typeToExpr(elemType)}}},
+					Results: &ast.FieldList{List: []*ast.Field{{Type: resultType}}},
+				},
+				Body: &ast.BlockStmt{List: []ast.Stmt{
+					t.returnNoneIfNil(ast.NewIdent(param), fieldType),
+					&ast.ReturnStmt{Results: []ast.Expr{field}},
+				}},
+			},
+			Args: []ast.Expr{base},
+		}, nil
+	}
+
+	// Option_FlatMap(base, func(p U) Option[V] { return <field> })
+	return &ast.CallExpr{
+		Fun: t.stdIdent(transpiler.TypeOption + "_FlatMap"),
+		Args: []ast.Expr{
+			base,
+			&ast.FuncLit{
+				Type: &ast.FuncType{
+					Params:  &ast.FieldList{List: []*ast.Field{{Names: []*ast.Ident{ast.NewIdent(param)}, Type: t.typeToExpr(elemType)}}},
+					Results: &ast.FieldList{List: []*ast.Field{{Type: resultType}}},
+				},
+				Body: &ast.BlockStmt{List: []ast.Stmt{
+					&ast.ReturnStmt{Results: []ast.Expr{field}},
+				}},
+			},
+		},
+	}, nil
+}
+
+// safeField reads field name of recv, whose type is recvType, as an Option:
+// Option fields are returned as they are, nil pointer fields become None and
+// other fields are wrapped in Some. It also returns the type of the Option's
+// value.
+func (t *galaASTTransformer) safeField(recv ast.Expr, recvType transpiler.Type, name string) (ast.Expr, transpiler.Type, error) {
+	access, err := t.resolveFieldAccess(recv, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	fieldType := t.getExprTypeName(access)
+	if fieldType.IsNil() {
+		return nil, nil, galaerr.NewSemanticError(fmt.Sprintf("type %s has no field %s", recvType, name))
+	}
+	if elem := t.unwrapOptionType(fieldType); elem != nil {
+		return access, elem, nil
+	}
+	if _, ok := fieldType.(transpiler.PointerType); ok {
+		return t.nilCheckedOption(access, fieldType), fieldType, nil
+	}
+	return t.someOf(access, fieldType), fieldType, nil
+}
+
+// nilCheckedOption converts the pointer expr to an Option that is None when
+// the pointer is nil, evaluating expr once.
+func (t *galaASTTransformer) nilCheckedOption(expr ast.Expr, ptrType transpiler.Type) ast.Expr {
+	param := ast.NewIdent(t.nextTempVar())
+	return &ast.CallExpr{
+		Fun: &ast.FuncLit{
+			Type: &ast.FuncType{
+				Params:  &ast.FieldList{List: []*ast.Field{{Names: []*ast.Ident{param}, Type: t.typeToExpr(ptrType)}}},
+				Results: &ast.FieldList{List: []*ast.Field{{Type: t.optionTypeExpr(ptrType)}}},
+			},
+			Body: &ast.BlockStmt{List: []ast.Stmt{
+				t.returnNoneIfNil(param, ptrType),
+				&ast.ReturnStmt{Results: []ast.Expr{t.someOf(param, ptrType)}},
+			}},
+		},
+		Args: []ast.Expr{expr},
+	}
+}
+
+// returnNoneIfNil builds: if ptr == nil { return None[elemType]{}.Apply() }
+func (t *galaASTTransformer) returnNoneIfNil(ptr ast.Expr, elemType transpiler.Type) ast.Stmt {
+	return &ast.IfStmt{
+		Cond: &ast.BinaryExpr{X: ptr, Op: token.EQL, Y: ast.NewIdent("nil")},
+		Body: &ast.BlockStmt{List: []ast.Stmt{
+			&ast.ReturnStmt{Results: []ast.Expr{
+				&ast.CallExpr{Fun: &ast.SelectorExpr{
+					X:   &ast.CompositeLit{Type: t.buildNoneType(t.typeToExpr(elemType))},
+					Sel: ast.NewIdent("Apply"),
+				}},
+			}},
+		}},
+	}
+}
+
+// someOf builds Some[typ]{}.Apply(expr).
+func (t *galaASTTransformer) someOf(expr ast.Expr, typ transpiler.Type) ast.Expr {
+	return &ast.CallExpr{
+		Fun: &ast.SelectorExpr{
+			X:   &ast.CompositeLit{Type: t.buildSomeType(t.typeToExpr(typ))},
+			Sel: ast.NewIdent("Apply"),
+		},
+		Args: []ast.Expr{expr},
+	}
+}
+
+// optionTypeExpr builds the type expression Option[elemType].
+func (t *galaASTTransformer) optionTypeExpr(elemType transpiler.Type) ast.Expr {
+	return &ast.IndexExpr{X: t.stdIdent(transpiler.TypeOption), Index: t.typeToExpr(elemType)}
+}
+
+// isSafeAccess reports whether suffix is a safe field access (?.name).
+func isSafeAccess(suffix *grammar.PostfixSuffixContext) bool {
+	return suffix.GetChildCount() == 2 && suffix.GetChild(0).(antlr.ParseTree).GetText() == "?."
+}
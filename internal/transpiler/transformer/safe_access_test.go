@@ -0,0 +1,77 @@
+package transformer_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"martianoff/gala/internal/transpiler"
+	"martianoff/gala/internal/transpiler/analyzer"
+	"martianoff/gala/internal/transpiler/generator"
+	"martianoff/gala/internal/transpiler/transformer"
+)
+
+func TestSafeFieldAccess(t *testing.T) {
+	const types = `package main
+
+struct Address(City string)
+struct User(Name string, Address Option[Address], Manager *User)
+
+func (u User) Greeting() string = "Hi " + u.Name
+`
+	tests := []struct {
+		name     string
+		input    string
+		contains []string
+		wantErr  string
+	}{
+		{
+			name:  "chain over Option fields",
+			input: "func city(u User) Option[string] = u?.Address?.City",
+			contains: []string{
+				"std.Option_FlatMap(u.Address.Get(), func(",
+				"Address) std.Option[string] {",
+				"std.Some[string]{}.Apply(",
+			},
+		},
+		{
+			name:  "nil pointer receiver",
+			input: "func name(u *User) Option[string] = u?.Name",
+			contains: []string{
+				"*User) std.Option[string] {",
+				"== nil {",
+				"std.None[string]{}.Apply()",
+			},
+		},
+		{
+			name:  "pointer field",
+			input: "func manager(u User) Option[*User] = u?.Manager",
+			contains: []string{
+				"std.None[*User]{}.Apply()",
+				"std.Some[*User]{}.Apply(",
+			},
+		},
+		{
+			name:    "method call",
+			input:   "func greeting(u User) string = u?.Greeting()",
+			wantErr: "?. supports field access only",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := transpiler.NewAntlrGalaParser()
+			trans := transpiler.NewGalaToGoTranspiler(p, analyzer.NewGalaAnalyzer(p, getStdSearchPath()),
+				transformer.NewGalaASTTransformer(), generator.NewGoCodeGenerator())
+			got, err := trans.Transpile(types+tt.input, "")
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			for _, want := range tt.contains {
+				assert.Contains(t, got, want)
+			}
+		})
+	}
+}
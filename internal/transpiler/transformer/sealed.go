@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"go/ast"
 	"go/token"
+	"strconv"
 	"strings"
 
 	"martianoff/gala/internal/parser/grammar"
@@ -202,27 +203,37 @@ func (t *galaASTTransformer) transformSealedTypeDeclaration(ctx *grammar.SealedT
 		Specs:  constSpecs,
 	})
 
-	// 3. Generate companion structs and methods for each variant
-	for _, vi := range variants {
-		companionDecls, err := t.generateSealedCompanion(name, vi, tParams, recursiveFields)
+	// 3-4. Generate companion structs and methods for each variant, then IsXxx()
+	// methods on parent. A @compact sealed type gets one constructor function
+	// per variant and a Variant method instead.
+	if t.typeHasAnnotation(name, transpiler.AnnotationCompact) {
+		compactDecls, err := t.generateSealedCompact(name, variants, tParams, recursiveFields)
 		if err != nil {
 			return nil, err
 		}
-		decls = append(decls, companionDecls...)
-	}
-
-	// 4. Generate IsXxx() methods on parent
-	for _, vi := range variants {
-		isMethod := t.generateSealedIsMethod(name, vi, tParams)
-		decls = append(decls, isMethod)
+		decls = append(decls, compactDecls...)
+	} else {
+		for _, vi := range variants {
+			companionDecls, err := t.generateSealedCompanion(name, vi, tParams, recursiveFields)
+			if err != nil {
+				return nil, err
+			}
+			decls = append(decls, companionDecls...)
+		}
+		for _, vi := range variants {
+			isMethod := t.generateSealedIsMethod(name, vi, tParams)
+			decls = append(decls, isMethod)
+		}
 	}
 
 	// 5. Generate Copy, Equal methods on parent
-	copyMethod, err := t.generateCopyMethod(name, parentFields, tParams)
-	if err != nil {
-		return nil, err
+	if !t.typeHasAnnotation(name, transpiler.AnnotationNoCopy) {
+		copyMethod, err := t.generateCopyMethod(name, parentFields, tParams)
+		if err != nil {
+			return nil, err
+		}
+		decls = append(decls, copyMethod)
 	}
-	decls = append(decls, copyMethod)
 
 	equalMethod, err := t.generateEqualMethod(name, parentFields, tParams)
 	if err != nil {
@@ -233,6 +244,7 @@ func (t *galaASTTransformer) transformSealedTypeDeclaration(ctx *grammar.SealedT
 	// 6. Generate String() method on parent
 	stringMethod := t.generateSealedStringMethod(name, variants, tParams, recursiveFields)
 	decls = append(decls, stringMethod)
+	decls = append(decls, t.generateSealedVariants(name, variants))
 
 	// 7. For generic sealed types, generate InstanceMarker
 	if tParams != nil {
@@ -240,6 +252,24 @@ func (t *galaASTTransformer) transformSealedTypeDeclaration(ctx *grammar.SealedT
 		decls = append(decls, interfaceDecl, markerMethod)
 	}
 
+	// 8. For @arena sealed types, generate the arena builder
+	if t.typeHasAnnotation(name, transpiler.AnnotationArena) {
+		arenaDecls, err := t.generateSealedArena(name, variants, tParams, recursiveFields)
+		if err != nil {
+			return nil, err
+		}
+		decls = append(decls, arenaDecls...)
+	}
+
+	// 9. For @json sealed types, generate the JSON and YAML codecs
+	if codec, ok := t.typeAnnotation(name, transpiler.AnnotationJSON); ok && len(variants) > 0 {
+		codecDecls, err := t.generateSealedCodecs(ctx, name, codec.Arg, variants, tParams, recursiveFields)
+		if err != nil {
+			return nil, err
+		}
+		decls = append(decls, codecDecls...)
+	}
+
 	return decls, nil
 }
 
@@ -306,7 +336,42 @@ func (t *galaASTTransformer) buildGenericTypeExpr(name string, tParams *ast.Fiel
 // generateSealedApply generates the Apply method for a sealed type companion.
 // For recursive fields (self-referential), it uses pointer: Field: &value instead of NewImmutable(value).
 func (t *galaASTTransformer) generateSealedApply(parentName string, vi sealedVariantInfo, companionType, parentType ast.Expr, tParams *ast.FieldList, recursiveFields map[string]bool) (*ast.FuncDecl, error) {
-	// Parameters: the variant's fields
+	params, err := t.sealedVariantParams(vi)
+	if err != nil {
+		return nil, err
+	}
+	addressOf := func(value ast.Expr) ast.Expr {
+		return &ast.UnaryExpr{Op: token.AND, X: value}
+	}
+
+	return &ast.FuncDecl{
+		Recv: &ast.FieldList{
+			List: []*ast.Field{
+				{
+					Names: []*ast.Ident{ast.NewIdent("_")},
+					Type:  companionType,
+				},
+			},
+		},
+		Name: ast.NewIdent("Apply"),
+		Type: &ast.FuncType{
+			Params: params,
+			Results: &ast.FieldList{
+				List: []*ast.Field{{Type: parentType}},
+			},
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{
+				&ast.ReturnStmt{
+					Results: []ast.Expr{t.sealedVariantLiteral(vi, parentType, recursiveFields, addressOf)},
+				},
+			},
+		},
+	}, nil
+}
+
+// sealedVariantParams returns the parameters of a constructor of the variant: its fields.
+func (t *galaASTTransformer) sealedVariantParams(vi sealedVariantInfo) (*ast.FieldList, error) {
 	params := &ast.FieldList{}
 	for _, f := range vi.fields {
 		typ, err := t.transformType(f.typeCtx)
@@ -318,23 +383,23 @@ func (t *galaASTTransformer) generateSealedApply(parentName string, vi sealedVar
 			Type:  typ,
 		})
 	}
+	return params, nil
+}
 
-	// Body: construct parent struct
+// sealedVariantLiteral builds the parent struct holding the variant, reading the
+// field values from the constructor parameters. Normal fields are wrapped in
+// NewImmutable; recursive fields are stored as the pointer ref returns for the parameter.
+func (t *galaASTTransformer) sealedVariantLiteral(vi sealedVariantInfo, parentType ast.Expr, recursiveFields map[string]bool, ref func(value ast.Expr) ast.Expr) *ast.CompositeLit {
 	// Note: struct keys use structFieldName (may be prefixed), but parameter names use the original name
 	var elts []ast.Expr
 	for _, f := range vi.fields {
 		var valueExpr ast.Expr
 		if recursiveFields[f.structFieldName] {
-			// Recursive field: store as pointer &value
-			valueExpr = &ast.UnaryExpr{
-				Op: token.AND,
-				X:  ast.NewIdent(f.name), // param name is the original (user-facing) name
-			}
+			valueExpr = ref(ast.NewIdent(f.name))
 		} else {
-			// Normal field: wrap in NewImmutable
 			valueExpr = &ast.CallExpr{
 				Fun:  t.stdIdent("NewImmutable"),
-				Args: []ast.Expr{ast.NewIdent(f.name)}, // param name is the original name
+				Args: []ast.Expr{ast.NewIdent(f.name)},
 			}
 		}
 		elts = append(elts, &ast.KeyValueExpr{
@@ -346,36 +411,82 @@ func (t *galaASTTransformer) generateSealedApply(parentName string, vi sealedVar
 		Key:   ast.NewIdent("_variant"),
 		Value: ast.NewIdent(vi.tagConst),
 	})
+	return &ast.CompositeLit{Type: parentType, Elts: elts}
+}
 
-	return &ast.FuncDecl{
-		Recv: &ast.FieldList{
-			List: []*ast.Field{
-				{
-					Names: []*ast.Ident{ast.NewIdent("_")},
-					Type:  companionType,
-				},
-			},
+// generateSealedArena generates the arena builder of an @arena sealed type:
+//
+//	type ExprArena struct { nodes *std.Arena[Expr] }
+//	func NewExprArena() ExprArena
+//	func (a ExprArena) Add(Left Expr, Right Expr) Expr
+//
+// It has one constructor method per variant. Unlike Apply, which moves every
+// recursive field to the heap on its own, these allocate them from the arena.
+func (t *galaASTTransformer) generateSealedArena(parentName string, variants []sealedVariantInfo, tParams *ast.FieldList, recursiveFields map[string]bool) ([]ast.Decl, error) {
+	arenaName := parentName + "Arena"
+	arenaType := t.buildGenericTypeExpr(arenaName, tParams)
+	parentType := t.buildGenericTypeExpr(parentName, tParams)
+	nodesType := &ast.StarExpr{X: &ast.IndexExpr{X: t.stdIdent("Arena"), Index: parentType}}
+
+	decls := []ast.Decl{
+		&ast.GenDecl{
+			Tok: token.TYPE,
+			Specs: []ast.Spec{&ast.TypeSpec{
+				Name:       ast.NewIdent(arenaName),
+				TypeParams: tParams,
+				Type: &ast.StructType{Fields: &ast.FieldList{List: []*ast.Field{
+					{Names: []*ast.Ident{ast.NewIdent("nodes")}, Type: nodesType},
+				}}},
+			}},
 		},
-		Name: ast.NewIdent("Apply"),
-		Type: &ast.FuncType{
-			Params: params,
-			Results: &ast.FieldList{
-				List: []*ast.Field{{Type: parentType}},
+		&ast.FuncDecl{
+			Name: ast.NewIdent("New" + arenaName),
+			Type: &ast.FuncType{
+				TypeParams: tParams,
+				Params:     &ast.FieldList{},
+				Results:    &ast.FieldList{List: []*ast.Field{{Type: arenaType}}},
 			},
+			Body: &ast.BlockStmt{List: []ast.Stmt{
+				&ast.ReturnStmt{Results: []ast.Expr{&ast.CompositeLit{
+					Type: arenaType,
+					Elts: []ast.Expr{&ast.KeyValueExpr{
+						Key:   ast.NewIdent("nodes"),
+						Value: &ast.CallExpr{Fun: &ast.IndexExpr{X: t.stdIdent("NewArena"), Index: parentType}},
+					}},
+				}}},
+			}},
 		},
-		Body: &ast.BlockStmt{
-			List: []ast.Stmt{
-				&ast.ReturnStmt{
-					Results: []ast.Expr{
-						&ast.CompositeLit{
-							Type: parentType,
-							Elts: elts,
-						},
-					},
-				},
+	}
+	t.structFields[arenaName] = []string{"nodes"}
+	t.structImmutFields[arenaName] = []bool{false}
+
+	alloc := func(value ast.Expr) ast.Expr {
+		return &ast.CallExpr{
+			Fun: &ast.SelectorExpr{
+				X:   &ast.SelectorExpr{X: ast.NewIdent("a"), Sel: ast.NewIdent("nodes")},
+				Sel: ast.NewIdent("Alloc"),
 			},
-		},
-	}, nil
+			Args: []ast.Expr{value},
+		}
+	}
+	for _, vi := range variants {
+		params, err := t.sealedVariantParams(vi)
+		if err != nil {
+			return nil, err
+		}
+		decls = append(decls, &ast.FuncDecl{
+			Recv: &ast.FieldList{List: []*ast.Field{{Names: []*ast.Ident{ast.NewIdent("a")}, Type: arenaType}}},
+			Name: ast.NewIdent(vi.name),
+			Type: &ast.FuncType{
+				Params:  params,
+				Results: &ast.FieldList{List: []*ast.Field{{Type: parentType}}},
+			},
+			Body: &ast.BlockStmt{List: []ast.Stmt{
+				&ast.ReturnStmt{Results: []ast.Expr{t.sealedVariantLiteral(vi, parentType, recursiveFields, alloc)}},
+			}},
+		})
+	}
+	return decls, nil
 }
 
 // sealedFieldAccessExpr generates the expression to read a sealed type field value.
@@ -798,6 +909,31 @@ func (t *galaASTTransformer) generateSealedStringMethod(parentName string, varia
 	}
 }
 
+// generateSealedVariants generates the function listing the variants of a
+// sealed type in declaration order, with the number of fields of each.
+// e.g., func ShapeVariants() []std.SealedVariant { return []std.SealedVariant{{Name: "Circle", Arity: 1}} }
+// It takes no type parameters: the variants are the same for every instance.
+func (t *galaASTTransformer) generateSealedVariants(parentName string, variants []sealedVariantInfo) *ast.FuncDecl {
+	sliceType := func() ast.Expr { return &ast.ArrayType{Elt: t.stdIdent("SealedVariant")} }
+	var elts []ast.Expr
+	for _, vi := range variants {
+		elts = append(elts, &ast.CompositeLit{Elts: []ast.Expr{
+			&ast.KeyValueExpr{Key: ast.NewIdent("Name"), Value: stringLit(vi.name)},
+			&ast.KeyValueExpr{Key: ast.NewIdent("Arity"), Value: &ast.BasicLit{Kind: token.INT, Value: strconv.Itoa(len(vi.fields))}},
+		}})
+	}
+	return &ast.FuncDecl{
+		Name: ast.NewIdent(parentName + transpiler.SealedVariantsSuffix),
+		Type: &ast.FuncType{
+			Params:  &ast.FieldList{},
+			Results: &ast.FieldList{List: []*ast.Field{{Type: sliceType()}}},
+		},
+		Body: &ast.BlockStmt{List: []ast.Stmt{
+			&ast.ReturnStmt{Results: []ast.Expr{&ast.CompositeLit{Type: sliceType(), Elts: elts}}},
+		}},
+	}
+}
+
 // isSelfReferentialSealedField checks if a field type text references the parent sealed type.
 // This handles direct references like "Expr" and generic references like "Tree[T]".
 // When a sealed type field references the parent type, it must use pointer indirection
@@ -0,0 +1,185 @@
+package transformer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+
+	"martianoff/gala/internal/parser/grammar"
+	"martianoff/gala/internal/transpiler"
+)
+
+// defaultSealedTag is the discriminator member of a @json sealed type whose
+// annotation names none.
+const defaultSealedTag = "type"
+
+// generateSealedCodecs generates the JSON and YAML codecs of a @json sealed
+// type. A variant is written as an object holding the discriminator tag next
+// to the fields of the variant, e.g. {"type":"Circle","Radius":1.5}:
+//
+//	func (s Shape) MarshalJSON() ([]byte, error)
+//	func (s *Shape) UnmarshalJSON(data []byte) error
+//	func (s Shape) MarshalYAML() (any, error)
+//	func (s *Shape) UnmarshalYAML(unmarshal func(any) error) error
+//
+// The YAML methods go through the JSON ones, in the form of the yaml.v2 and
+// yaml.v3 interfaces that needs no import of a YAML package.
+func (t *galaASTTransformer) generateSealedCodecs(ctx *grammar.SealedTypeDeclarationContext, parentName, tag string, variants []sealedVariantInfo, tParams *ast.FieldList, recursiveFields map[string]bool) ([]ast.Decl, error) {
+	if tag == "" {
+		tag = defaultSealedTag
+	}
+	for _, vi := range variants {
+		for _, f := range vi.fields {
+			if f.name == tag {
+				return nil, t.semanticErrorAt(ctx, fmt.Sprintf("discriminator %q of %s clashes with the field %s of %s; name another one with @json(\"...\")", tag, parentName, f.name, vi.name))
+			}
+		}
+	}
+
+	parentType := t.buildGenericTypeExpr(parentName, tParams)
+	recv := func(typ ast.Expr) *ast.FieldList {
+		return &ast.FieldList{List: []*ast.Field{{Names: []*ast.Ident{ast.NewIdent("s")}, Type: typ}}}
+	}
+	results := func(types ...ast.Expr) *ast.FieldList {
+		list := &ast.FieldList{}
+		for _, typ := range types {
+			list.List = append(list.List, &ast.Field{Type: typ})
+		}
+		return list
+	}
+	param := func(name string, typ ast.Expr) *ast.FieldList {
+		return &ast.FieldList{List: []*ast.Field{{Names: []*ast.Ident{ast.NewIdent(name)}, Type: typ}}}
+	}
+	byteSlice := &ast.ArrayType{Elt: ast.NewIdent("byte")}
+	ret := func(values ...ast.Expr) ast.Stmt {
+		return &ast.ReturnStmt{Results: values}
+	}
+	call := func(fun ast.Expr, args ...ast.Expr) *ast.CallExpr {
+		return &ast.CallExpr{Fun: fun, Args: args}
+	}
+	method := func(x ast.Expr, name string) ast.Expr {
+		return &ast.SelectorExpr{X: x, Sel: ast.NewIdent(name)}
+	}
+	returnIfErr := func(init ast.Stmt) ast.Stmt {
+		return &ast.IfStmt{
+			Init: init,
+			Cond: &ast.BinaryExpr{X: ast.NewIdent("err"), Op: token.NEQ, Y: ast.NewIdent("nil")},
+			Body: &ast.BlockStmt{List: []ast.Stmt{ret(ast.NewIdent("err"))}},
+		}
+	}
+
+	// MarshalJSON: one case per variant but the last, which ends the method
+	marshalVariant := func(vi sealedVariantInfo) ast.Stmt {
+		args := []ast.Expr{stringLit(tag), stringLit(vi.name)}
+		for _, f := range vi.fields {
+			var value ast.Expr = &ast.SelectorExpr{X: ast.NewIdent("s"), Sel: ast.NewIdent(f.structFieldName)}
+			if !recursiveFields[f.structFieldName] {
+				value = call(method(value, "Get"))
+			}
+			args = append(args, &ast.CompositeLit{
+				Type: t.stdIdent("SealedField"),
+				Elts: []ast.Expr{
+					&ast.KeyValueExpr{Key: ast.NewIdent("Name"), Value: stringLit(f.name)},
+					&ast.KeyValueExpr{Key: ast.NewIdent("Value"), Value: value},
+				},
+			})
+		}
+		return ret(call(t.stdIdent("MarshalSealedJSON"), args...))
+	}
+	var marshalBody []ast.Stmt
+	last := variants[len(variants)-1]
+	if len(variants) > 1 {
+		var cases []ast.Stmt
+		for _, vi := range variants[:len(variants)-1] {
+			cases = append(cases, &ast.CaseClause{
+				List: []ast.Expr{ast.NewIdent(vi.tagConst)},
+				Body: []ast.Stmt{marshalVariant(vi)},
+			})
+		}
+		marshalBody = append(marshalBody, &ast.SwitchStmt{
+			Tag:  &ast.SelectorExpr{X: ast.NewIdent("s"), Sel: ast.NewIdent("_variant")},
+			Body: &ast.BlockStmt{List: cases},
+		})
+	}
+	marshalBody = append(marshalBody, marshalVariant(last))
+
+	// UnmarshalJSON: decode every field of the named variant, then build it
+	// with the companion's Apply, or the constructor of a @compact variant
+	compact := t.typeHasAnnotation(parentName, transpiler.AnnotationCompact)
+	obj := ast.NewIdent("obj")
+	var cases []ast.Stmt
+	for _, vi := range variants {
+		var body []ast.Stmt
+		var applyArgs []ast.Expr
+		for i, f := range vi.fields {
+			typ, err := t.transformType(f.typeCtx)
+			if err != nil {
+				return nil, err
+			}
+			local := ast.NewIdent(fmt.Sprintf("f%d", i))
+			body = append(body,
+				&ast.DeclStmt{Decl: &ast.GenDecl{Tok: token.VAR, Specs: []ast.Spec{&ast.ValueSpec{Names: []*ast.Ident{local}, Type: typ}}}},
+				returnIfErr(&ast.AssignStmt{
+					Lhs: []ast.Expr{ast.NewIdent("err")},
+					Tok: token.DEFINE,
+					Rhs: []ast.Expr{call(method(obj, "Field"), stringLit(f.name), &ast.UnaryExpr{Op: token.AND, X: local})},
+				}),
+			)
+			applyArgs = append(applyArgs, local)
+		}
+		construct := method(&ast.CompositeLit{Type: t.buildGenericTypeExpr(vi.name, tParams)}, "Apply")
+		if compact {
+			construct = t.buildGenericTypeExpr(vi.name, tParams)
+		}
+		body = append(body,
+			&ast.AssignStmt{
+				Lhs: []ast.Expr{&ast.StarExpr{X: ast.NewIdent("s")}},
+				Tok: token.ASSIGN,
+				Rhs: []ast.Expr{call(construct, applyArgs...)},
+			},
+			ret(ast.NewIdent("nil")),
+		)
+		cases = append(cases, &ast.CaseClause{List: []ast.Expr{stringLit(vi.name)}, Body: body})
+	}
+	unmarshalBody := []ast.Stmt{
+		&ast.AssignStmt{
+			Lhs: []ast.Expr{obj, ast.NewIdent("err")},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{call(t.stdIdent("UnmarshalSealedJSON"), ast.NewIdent("data"), stringLit(tag), stringLit(parentName))},
+		},
+		returnIfErr(nil),
+		&ast.SwitchStmt{Tag: call(method(obj, "Variant")), Body: &ast.BlockStmt{List: cases}},
+		ret(call(method(obj, "UnknownVariant"))),
+	}
+
+	anyType := ast.NewIdent("any")
+	return []ast.Decl{
+		&ast.FuncDecl{
+			Recv: recv(parentType),
+			Name: ast.NewIdent("MarshalJSON"),
+			Type: &ast.FuncType{Params: &ast.FieldList{}, Results: results(byteSlice, ast.NewIdent("error"))},
+			Body: &ast.BlockStmt{List: marshalBody},
+		},
+		&ast.FuncDecl{
+			Recv: recv(&ast.StarExpr{X: parentType}),
+			Name: ast.NewIdent("UnmarshalJSON"),
+			Type: &ast.FuncType{Params: param("data", byteSlice), Results: results(ast.NewIdent("error"))},
+			Body: &ast.BlockStmt{List: unmarshalBody},
+		},
+		&ast.FuncDecl{
+			Recv: recv(parentType),
+			Name: ast.NewIdent("MarshalYAML"),
+			Type: &ast.FuncType{Params: &ast.FieldList{}, Results: results(anyType, ast.NewIdent("error"))},
+			Body: &ast.BlockStmt{List: []ast.Stmt{ret(call(t.stdIdent("SealedToYAML"), ast.NewIdent("s")))}},
+		},
+		&ast.FuncDecl{
+			Recv: recv(&ast.StarExpr{X: parentType}),
+			Name: ast.NewIdent("UnmarshalYAML"),
+			Type: &ast.FuncType{
+				Params:  param("unmarshal", &ast.FuncType{Params: &ast.FieldList{List: []*ast.Field{{Type: anyType}}}, Results: results(ast.NewIdent("error"))}),
+				Results: results(ast.NewIdent("error")),
+			},
+			Body: &ast.BlockStmt{List: []ast.Stmt{ret(call(t.stdIdent("SealedFromYAML"), ast.NewIdent("unmarshal"), ast.NewIdent("s")))}},
+		},
+	}, nil
+}
@@ -0,0 +1,123 @@
+package transformer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"slices"
+
+	"martianoff/gala/galaerr"
+	"martianoff/gala/internal/transpiler"
+)
+
+// generateSealedCompact generates the variant constructors of a @compact
+// sealed type and the table its Variant method reads:
+//
+//	func Circle(Radius float64) Shape { return Shape{Radius: std.NewImmutable(Radius), _variant: _Shape_Circle} }
+//	var _Shape_variants = [...]string{"Circle", "Point"}
+//	func (s Shape) Variant() string { return _Shape_variants[s._variant] }
+//
+// The companion types, with their Apply and Unapply methods, are left out.
+// Pattern matching reads the tag and the fields of the parent struct, through
+// Variant from other packages.
+func (t *galaASTTransformer) generateSealedCompact(parentName string, variants []sealedVariantInfo, tParams *ast.FieldList, recursiveFields map[string]bool) ([]ast.Decl, error) {
+	parentType := t.buildGenericTypeExpr(parentName, tParams)
+	addressOf := func(value ast.Expr) ast.Expr {
+		return &ast.UnaryExpr{Op: token.AND, X: value}
+	}
+
+	var decls []ast.Decl
+	var names []ast.Expr
+	for _, vi := range variants {
+		params, err := t.sealedVariantParams(vi)
+		if err != nil {
+			return nil, err
+		}
+		decls = append(decls, &ast.FuncDecl{
+			Name: ast.NewIdent(vi.name),
+			Type: &ast.FuncType{
+				TypeParams: tParams,
+				Params:     params,
+				Results:    &ast.FieldList{List: []*ast.Field{{Type: parentType}}},
+			},
+			Body: &ast.BlockStmt{List: []ast.Stmt{
+				&ast.ReturnStmt{Results: []ast.Expr{t.sealedVariantLiteral(vi, parentType, recursiveFields, addressOf)}},
+			}},
+		})
+		names = append(names, &ast.BasicLit{Kind: token.STRING, Value: fmt.Sprintf("%q", vi.name)})
+	}
+
+	table := sealedVariantTable(parentName)
+	decls = append(decls,
+		&ast.GenDecl{
+			Tok: token.VAR,
+			Specs: []ast.Spec{&ast.ValueSpec{
+				Names: []*ast.Ident{ast.NewIdent(table)},
+				Values: []ast.Expr{&ast.CompositeLit{
+					Type: &ast.ArrayType{Len: &ast.Ellipsis{}, Elt: ast.NewIdent("string")},
+					Elts: names,
+				}},
+			}},
+		},
+		&ast.FuncDecl{
+			Recv: &ast.FieldList{List: []*ast.Field{{Names: []*ast.Ident{ast.NewIdent("s")}, Type: parentType}}},
+			Name: ast.NewIdent("Variant"),
+			Type: &ast.FuncType{
+				Params:  &ast.FieldList{},
+				Results: &ast.FieldList{List: []*ast.Field{{Type: ast.NewIdent("string")}}},
+			},
+			Body: &ast.BlockStmt{List: []ast.Stmt{
+				&ast.ReturnStmt{Results: []ast.Expr{&ast.IndexExpr{
+					X:     ast.NewIdent(table),
+					Index: &ast.SelectorExpr{X: ast.NewIdent("s"), Sel: ast.NewIdent("_variant")},
+				}}},
+			}},
+		},
+	)
+	return decls, nil
+}
+
+// sealedVariantTable names the table of variant names of a @compact sealed type.
+func sealedVariantTable(parentName string) string {
+	return fmt.Sprintf("_%s_variants", parentName)
+}
+
+// compactSealedVariant finds the variant called name of a @compact sealed type.
+func (t *galaASTTransformer) compactSealedVariant(name string) (*transpiler.TypeMetadata, transpiler.SealedVariant, bool) {
+	for _, meta := range t.typeMetas {
+		if !meta.IsSealed {
+			continue
+		}
+		if _, ok := transpiler.FindAnnotation(meta.Annotations, transpiler.AnnotationCompact); !ok {
+			continue
+		}
+		for _, v := range meta.SealedVariants {
+			if v.Name == name {
+				return meta, v, true
+			}
+		}
+	}
+	return nil, transpiler.SealedVariant{}, false
+}
+
+// compactVariantCall calls the constructor of a @compact variant given named
+// arguments, after any positional ones, by passing them in field order.
+func compactVariantCall(fun ast.Expr, variant transpiler.SealedVariant, args []ast.Expr, namedArgs map[string]ast.Expr) (ast.Expr, error) {
+	if len(args) > len(variant.FieldNames) {
+		return nil, galaerr.NewSemanticError(fmt.Sprintf("too many arguments in construction of %s", variant.Name))
+	}
+	ordered := append([]ast.Expr{}, args...)
+	for _, field := range variant.FieldNames[len(args):] {
+		val, ok := namedArgs[field]
+		if !ok {
+			return nil, galaerr.NewSemanticError(fmt.Sprintf("missing field %s in construction of %s", field, variant.Name))
+		}
+		ordered = append(ordered, val)
+	}
+	for name := range namedArgs {
+		if !slices.Contains(variant.FieldNames[len(args):], name) {
+			return nil, galaerr.NewSemanticError(fmt.Sprintf("%s has no field %s", variant.Name, name))
+		}
+	}
+	return &ast.CallExpr{Fun: fun, Args: ordered}, nil
+}
@@ -0,0 +1,131 @@
+package transformer_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"martianoff/gala/internal/transpiler"
+	"martianoff/gala/internal/transpiler/analyzer"
+	"martianoff/gala/internal/transpiler/generator"
+	"martianoff/gala/internal/transpiler/transformer"
+)
+
+func TestCompactSealedType(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		contains    []string
+		notContains []string
+		wantErr     string
+	}{
+		{
+			name: "constructor functions and a variant table",
+			input: `package main
+
+@compact
+sealed type Shape {
+	case Circle(Radius float64)
+	case Point()
+}
+`,
+			contains: []string{
+				"func Circle(Radius float64) Shape {\n\treturn Shape{Radius: std.NewImmutable(Radius), _variant: _Shape_Circle}\n}",
+				"func Point() Shape {\n\treturn Shape{_variant: _Shape_Point}\n}",
+				"var _Shape_variants = [...]string{\"Circle\", \"Point\"}",
+				"func (s Shape) Variant() string {\n\treturn _Shape_variants[s._variant]\n}",
+				"func (s Shape) String() string {",
+			},
+			notContains: []string{"type Circle struct", "Apply(", "Unapply(", "isCircle"},
+		},
+		{
+			name: "construction and matching",
+			input: `package main
+
+@compact
+sealed type Shape {
+	case Circle(Radius float64)
+	case Rect(Width float64, Height float64)
+}
+
+func area(s Shape) float64 = s match {
+	case Circle(r) => 3.0 * r * r
+	case Rect(w, h) => w * h
+}
+
+func shapes() []Shape = []Shape{Circle(1.0), Rect(Height = 2.0, Width = 3.0)}
+`,
+			contains: []string{
+				"r := obj.Radius.Get()",
+				"if obj._variant == _Shape_Circle {",
+				"Circle(1.0)",
+				"Rect(3.0, 2.0)",
+			},
+			notContains: []string{"Circle{}", "Rect{}"},
+		},
+		{
+			name: "generic sealed type",
+			input: `package main
+
+@compact
+sealed type Tree[T any] {
+	case Leaf(Value T)
+	case Node(Left Tree[T], Right Tree[T])
+}
+
+func sum(t Tree[int]) int = t match {
+	case Leaf(v) => v
+	case Node(l, r) => sum(l) + sum(r)
+}
+`,
+			contains: []string{
+				"func Leaf[T any](Value T) Tree[T] {",
+				"func Node[T any](Left Tree[T], Right Tree[T]) Tree[T] {\n\treturn Tree[T]{Left: &Left, Right: &Right, _variant: _Tree_Node}\n}",
+				"if obj._variant == _Tree_Leaf {",
+			},
+			notContains: []string{"Leaf[int]{}"},
+		},
+		{
+			name: "missing field",
+			input: `package main
+
+@compact
+sealed type Shape {
+	case Rect(Width float64, Height float64)
+}
+
+val r = Rect(Width = 1.0)
+`,
+			wantErr: "missing field Height in construction of Rect",
+		},
+		{
+			name: "not a sealed type",
+			input: `package main
+
+@compact
+struct Point(X int, Y int)
+`,
+			wantErr: "annotation @compact can only be applied to a sealed type",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := transpiler.NewAntlrGalaParser()
+			trans := transpiler.NewGalaToGoTranspiler(p, analyzer.NewGalaAnalyzer(p, getStdSearchPath()),
+				transformer.NewGalaASTTransformer(), generator.NewGoCodeGenerator())
+			got, err := trans.Transpile(tt.input, "")
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			for _, want := range tt.contains {
+				assert.Contains(t, got, want)
+			}
+			for _, unwanted := range tt.notContains {
+				assert.NotContains(t, got, unwanted)
+			}
+		})
+	}
+}
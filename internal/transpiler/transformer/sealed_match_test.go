@@ -0,0 +1,103 @@
+package transformer_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"martianoff/gala/internal/transpiler"
+	"martianoff/gala/internal/transpiler/analyzer"
+	"martianoff/gala/internal/transpiler/generator"
+	"martianoff/gala/internal/transpiler/transformer"
+)
+
+func TestSealedVariantTagMatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		contains    []string
+		notContains []string
+	}{
+		{
+			name: "fields are read from the parent struct",
+			input: `package main
+
+sealed type Shape {
+	case Circle(Radius float64)
+	case Rect(Width float64, Height float64)
+}
+
+func area(s Shape) float64 = s match {
+	case Circle(r) => r * r
+	case Rect(w, _) => w
+}
+`,
+			contains: []string{
+				"r := obj.Radius.Get()",
+				"if obj._variant == _Shape_Circle {",
+				"w := obj.Width.Get()",
+				"obj._variant == _Shape_Rect {",
+			},
+			notContains: []string{"Circle{}.Unapply(obj)", "Rect{}.Unapply(obj)"},
+		},
+		{
+			name: "self-referential fields are dereferenced only on a tag match",
+			input: `package main
+
+sealed type Expr {
+	case Num(Value int)
+	case Add(Left Expr, Right Expr)
+}
+
+func eval(e Expr) int = e match {
+	case Num(v) => v
+	case Add(l, r) => eval(l) + eval(r)
+}
+`,
+			contains: []string{
+				"_add_0 := obj._variant == _Expr_Add",
+				"var _add_1 Expr",
+				"_add_1 = *obj.Left",
+				"l := _add_1",
+				"if _add_0 {",
+			},
+			notContains: []string{"Add{}.Unapply(obj)"},
+		},
+		{
+			name: "generic sealed type",
+			input: `package main
+
+sealed type Result[T any] {
+	case Ok(Value T)
+	case Failed(Reason string)
+}
+
+func orZero(r Result[int]) int = r match {
+	case Ok(v) => v
+	case Failed(_) => 0
+}
+`,
+			contains: []string{
+				"v := obj.Value.Get()",
+				"if obj._variant == _Result_Ok {",
+			},
+			notContains: []string{"Ok[int]{}.Unapply(obj)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := transpiler.NewAntlrGalaParser()
+			trans := transpiler.NewGalaToGoTranspiler(p, analyzer.NewGalaAnalyzer(p, getStdSearchPath()),
+				transformer.NewGalaASTTransformer(), generator.NewGoCodeGenerator())
+			got, err := trans.Transpile(tt.input, "")
+			assert.NoError(t, err)
+			for _, want := range tt.contains {
+				assert.Contains(t, got, want)
+			}
+			for _, unwanted := range tt.notContains {
+				assert.NotContains(t, got, unwanted)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package transformer_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"martianoff/gala/galaerr"
+	"martianoff/gala/internal/transpiler"
+	"martianoff/gala/internal/transpiler/analyzer"
+	"martianoff/gala/internal/transpiler/generator"
+	"martianoff/gala/internal/transpiler/transformer"
+)
+
+func TestSealedVariantsFunction(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		contains string
+	}{
+		{
+			name: "cases in declaration order",
+			input: `package main
+
+sealed type Shape {
+	case Circle(Radius float64)
+	case Rect(Width float64, Height float64)
+	case Point()
+}
+`,
+			contains: "func ShapeVariants() []std.SealedVariant {\n\treturn []std.SealedVariant{{Name: \"Circle\", Arity: 1}, {Name: \"Rect\", Arity: 2}, {Name: \"Point\", Arity: 0}}\n}",
+		},
+		{
+			name: "generic sealed type",
+			input: `package main
+
+sealed type Tree[T any] {
+	case Leaf(Value T)
+	case Node(Left Tree[T], Right Tree[T])
+}
+`,
+			contains: "func TreeVariants() []std.SealedVariant {\n\treturn []std.SealedVariant{{Name: \"Leaf\", Arity: 1}, {Name: \"Node\", Arity: 2}}\n}",
+		},
+		{
+			name: "compact sealed type",
+			input: `package main
+
+@compact
+sealed type Shape {
+	case Circle(Radius float64)
+	case Point()
+}
+`,
+			contains: "func ShapeVariants() []std.SealedVariant {",
+		},
+		{
+			name: "enumerated by generic code",
+			input: `package main
+
+sealed type Shape {
+	case Circle(Radius float64)
+	case Point()
+}
+
+func main() {
+	for _, v := range ShapeVariants() {
+		println(v.Name, v.Arity)
+	}
+}
+`,
+			contains: "for _, v := range ShapeVariants() {",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := transpiler.NewAntlrGalaParser()
+			trans := transpiler.NewGalaToGoTranspiler(p, analyzer.NewGalaAnalyzer(p, getStdSearchPath()),
+				transformer.NewGalaASTTransformer(), generator.NewGoCodeGenerator())
+			got, err := trans.Transpile(tt.input, "main.gala")
+			assert.NoError(t, err)
+			assert.Contains(t, got, tt.contains)
+		})
+	}
+}
+
+func TestSealedVariantsCollision(t *testing.T) {
+	p := transpiler.NewAntlrGalaParser()
+	trans := transpiler.NewGalaToGoTranspiler(p, analyzer.NewGalaAnalyzer(p, getStdSearchPath()),
+		transformer.NewGalaASTTransformer(), generator.NewGoCodeGenerator())
+
+	_, err := trans.Transpile(`package main
+
+sealed type Shape {
+	case Circle(Radius float64)
+}
+
+func ShapeVariants() int = 1
+`, "main.gala")
+	assert.Error(t, err)
+	assert.Equal(t, galaerr.CodeNameCollision, galaerr.CodeOf(err))
+	assert.Contains(t, err.Error(), "main.gala:7:1 function ShapeVariants collides with the ShapeVariants function generated for Shape")
+}
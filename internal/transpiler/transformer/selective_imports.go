@@ -0,0 +1,100 @@
+package transformer
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// qualifySelectedSymbols replaces the unqualified references to symbols of
+// selective imports in file by qualified ones: with
+// import "example.com/geometry".{Point, Origin => Zero}, Point becomes
+// geometry.Point and Zero becomes geometry.Origin. Go only sees the plain
+// import. The analyzer has made sure no declaration in the file reuses a
+// listed name, so every remaining identifier with that name is a reference.
+func (t *galaASTTransformer) qualifySelectedSymbols(file *ast.File) {
+	if !t.hasSelectiveImports() {
+		return
+	}
+	for _, decl := range file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+			continue
+		}
+		ast.Inspect(decl, func(n ast.Node) bool {
+			for _, slot := range append(childExprSlots(n), childTypeSlots(n)...) {
+				id, ok := (*slot).(*ast.Ident)
+				if !ok {
+					continue
+				}
+				if entry, symbol, ok := t.importManager.Selected(id.Name); ok {
+					*slot = t.ident(entry.PkgName + "." + symbol)
+				}
+			}
+			return true
+		})
+	}
+}
+
+func (t *galaASTTransformer) hasSelectiveImports() bool {
+	for _, entry := range t.importManager.All() {
+		if len(entry.Symbols) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// childTypeSlots returns the addresses of the expressions directly below n
+// that childExprSlots leaves out: types, map literal keys and assignment
+// targets.
+func childTypeSlots(n ast.Node) []*ast.Expr {
+	var slots []*ast.Expr
+	add := func(es ...*ast.Expr) {
+		for _, e := range es {
+			if *e != nil {
+				slots = append(slots, e)
+			}
+		}
+	}
+	switch x := n.(type) {
+	case *ast.Field:
+		add(&x.Type)
+	case *ast.ValueSpec:
+		add(&x.Type)
+	case *ast.TypeSpec:
+		add(&x.Type)
+	case *ast.CompositeLit:
+		add(&x.Type)
+		// Map keys are values; struct keys name fields
+		if _, isMap := x.Type.(*ast.MapType); isMap {
+			for _, elt := range x.Elts {
+				if kv, ok := elt.(*ast.KeyValueExpr); ok {
+					add(&kv.Key)
+				}
+			}
+		}
+	case *ast.TypeAssertExpr:
+		add(&x.Type)
+	case *ast.ArrayType:
+		add(&x.Len, &x.Elt)
+	case *ast.MapType:
+		add(&x.Key, &x.Value)
+	case *ast.ChanType:
+		add(&x.Value)
+	case *ast.Ellipsis:
+		add(&x.Elt)
+	case *ast.IndexListExpr:
+		add(&x.X)
+		for i := range x.Indices {
+			add(&x.Indices[i])
+		}
+	case *ast.AssignStmt:
+		if x.Tok != token.DEFINE {
+			for i := range x.Lhs {
+				add(&x.Lhs[i])
+			}
+		}
+	case *ast.IncDecStmt:
+		add(&x.X)
+	}
+	return slots
+}
@@ -0,0 +1,121 @@
+package transformer_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"martianoff/gala/galaerr"
+	"martianoff/gala/internal/transpiler"
+	"martianoff/gala/internal/transpiler/analyzer"
+	"martianoff/gala/internal/transpiler/generator"
+	"martianoff/gala/internal/transpiler/transformer"
+)
+
+func TestSelectiveImports(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		contains []string
+		wantErr  string
+	}{
+		{
+			name: "Listed and renamed symbols",
+			input: `package main
+
+import "martianoff/gala/collection_immutable".{List, ListOf => Of}
+
+func digits() List[int] = Of(1, 2, 3)`,
+			contains: []string{
+				"import \"martianoff/gala/collection_immutable\"\n",
+				"func digits() collection_immutable.List[int] {",
+				"collection_immutable.ListOf",
+			},
+		},
+		{
+			name: "Qualified access to unlisted symbols",
+			input: `package main
+
+import "martianoff/gala/collection_immutable".{List}
+
+func digits() collection_immutable.Array[int] = collection_immutable.ArrayOf(1, 2, 3)`,
+			contains: []string{"collection_immutable.ArrayOf(1, 2, 3)"},
+		},
+		{
+			name: "Aliased package",
+			input: `package main
+
+import ci "martianoff/gala/collection_immutable".{ListOf}
+
+func digits() ci.List[int] = ListOf(1, 2, 3)`,
+			contains: []string{
+				"import ci \"martianoff/gala/collection_immutable\"\n",
+				"ci.ListOf",
+			},
+		},
+		{
+			name: "Unlisted symbol used unqualified",
+			input: `package main
+
+import "martianoff/gala/collection_immutable".{List}
+
+func digits() collection_immutable.Array[int] = ArrayOf(1, 2, 3)`,
+			wantErr: "ArrayOf is not in the import list of collection_immutable: add it to the list or write collection_immutable.ArrayOf",
+		},
+		{
+			name: "Local redeclares a listed name",
+			input: `package main
+
+import "martianoff/gala/collection_immutable".{ListOf}
+
+func main() {
+    val ListOf = 1
+    println(ListOf)
+}`,
+			wantErr: "ListOf is imported from collection_immutable and cannot be redeclared",
+		},
+		{
+			name: "Package declares a listed name",
+			input: `package main
+
+import "martianoff/gala/collection_immutable".{List}
+
+type List struct {
+    Items []int
+}`,
+			wantErr: "List is imported from collection_immutable but also declared in package main",
+		},
+		{
+			name: "Listed twice",
+			input: `package main
+
+import "martianoff/gala/collection_immutable".{List, ListOf => List}`,
+			wantErr: "List is already imported from collection_immutable",
+		},
+		{
+			name: "Dot import with a list",
+			input: `package main
+
+import . "martianoff/gala/collection_immutable".{List}`,
+			wantErr: "a dot import cannot list symbols",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := transpiler.NewAntlrGalaParser()
+			a := analyzer.NewGalaAnalyzer(p, getStdSearchPath())
+			trans := transpiler.NewGalaToGoTranspiler(p, a, transformer.NewGalaASTTransformer(), generator.NewGoCodeGenerator())
+			got, err := trans.Transpile(tt.input, "")
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				assert.Equal(t, galaerr.CodeSelectiveImport, galaerr.CodeOf(err))
+				return
+			}
+			assert.NoError(t, err)
+			for _, want := range tt.contains {
+				assert.Contains(t, got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package transformer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"martianoff/gala/internal/transpiler"
+)
+
+// This file implements stack remapping, which gala build enables so that panic
+// traces name the GALA declarations of generated functions. Every generated
+// file registers where its functions come from:
+//
+//	func init() {
+//		std.RegisterSourceMap("shapes.gala", map[string]int{"Shape.String": 3, "area": 8})
+//	}
+//
+// and main defers std.RecoverPretty, which prints the trace with those
+// locations. Functions generated for a declaration, such as the Apply methods
+// of a sealed type, map to that declaration.
+
+// WithStackRemapping makes tr, a transformer created by this package, emit the
+// source map registration and the std.RecoverPretty hook described above.
+func WithStackRemapping(tr transpiler.ASTTransformer) transpiler.ASTTransformer {
+	tr.(*galaASTTransformer).stackRemap = true
+	return tr
+}
+
+// WithLineDirectives makes tr, a transformer created by this package, precede
+// every generated declaration with a //line comment naming the GALA line it
+// comes from, and every statement of a function body with a /*line*/ comment:
+//
+//	//line /home/me/app/shapes.gala:8
+//	func area(s Shape) float64 {
+//		/*line /home/me/app/shapes.gala:8:1*/
+//		r := s.Radius
+//		/*line /home/me/app/shapes.gala:9:1*/
+//		return math.Pi * r * r
+//
+// Go counts the lines after a directive from the line it names, so compiler
+// errors, panics and race reports in the generated code name the .gala file
+// and the line of the statement. A //line comment only counts at the start of
+// a line, so indented bodies use the /*line*/ form; it names the position of
+// the line break right after it, which is the line before the statement.
+func WithLineDirectives(tr transpiler.ASTTransformer) transpiler.ASTTransformer {
+	tr.(*galaASTTransformer).lineDirectives = true
+	return tr
+}
+
+// directivePath returns the name line directives give the source file. Go
+// takes a relative name as relative to the generated file, so it is absolute.
+func (t *galaASTTransformer) directivePath() string {
+	path, err := filepath.Abs(t.filePath)
+	if err != nil {
+		return t.filePath
+	}
+	return path
+}
+
+// addLineDirectives adds the //line comment for line, the line of the GALA
+// declaration decls were generated from, to the doc comment of each of decls
+// and returns the extended docs.
+func (t *galaASTTransformer) addLineDirectives(docs []pendingDoc, decls []ast.Decl, line int) []pendingDoc {
+	if !t.lineDirectives || t.filePath == "" {
+		return docs
+	}
+	directive := fmt.Sprintf("//line %s:%d", t.directivePath(), line)
+	for _, decl := range decls {
+		if n := len(docs); n > 0 && docs[n-1].decl == decl {
+			// The directive goes last, right above the declaration
+			docs[n-1].lines = append(docs[n-1].lines, directive)
+			continue
+		}
+		docs = append(docs, pendingDoc{decl: decl, lines: []string{directive}})
+	}
+	return docs
+}
+
+// addStatementDirectives puts a /*line*/ comment on its own line before each
+// statement of file that was transformed from a GALA statement. go/printer
+// prints no comments for statements, so the comment is printed as the name of
+// an identifier statement.
+func (t *galaASTTransformer) addStatementDirectives(file *ast.File) {
+	if !t.lineDirectives || t.filePath == "" || len(t.stmtLines) == 0 {
+		return
+	}
+	path := t.directivePath()
+	withDirectives := func(list []ast.Stmt) []ast.Stmt {
+		var out []ast.Stmt
+		for _, stmt := range list {
+			if line, ok := t.stmtLines[stmt]; ok && line > 1 {
+				directive := ast.NewIdent(fmt.Sprintf("/*line %s:%d:1*/", path, line-1))
+				out = append(out, &ast.ExprStmt{X: directive})
+			}
+			out = append(out, stmt)
+		}
+		return out
+	}
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.BlockStmt:
+			n.List = withDirectives(n.List)
+		case *ast.CaseClause:
+			n.Body = withDirectives(n.Body)
+		case *ast.CommClause:
+			n.Body = withDirectives(n.Body)
+		}
+		return true
+	})
+}
+
+// recordSourceLines maps the functions among decls to line, the line of the
+// GALA declaration they were generated from.
+func (t *galaASTTransformer) recordSourceLines(decls []ast.Decl, line int) {
+	if !t.stackRemap {
+		return
+	}
+	for _, decl := range decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok {
+			if name := runtimeFuncName(fn); name != "" {
+				t.funcLines[name] = line
+			}
+		}
+	}
+}
+
+// runtimeFuncName returns the name Go reports for fn in stack traces, without
+// the package path and type arguments: f, T.M or (*T).M. init functions are
+// left out since Go numbers them.
+func runtimeFuncName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		if fn.Name.Name == "init" {
+			return ""
+		}
+		return fn.Name.Name
+	}
+	recv := fn.Recv.List[0].Type
+	if star, ok := recv.(*ast.StarExpr); ok {
+		return "(*" + recvBaseName(star.X) + ")." + fn.Name.Name
+	}
+	return recvBaseName(recv) + "." + fn.Name.Name
+}
+
+// recvBaseName returns the type name of a receiver such as *Box[T].
+func recvBaseName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.StarExpr:
+		return recvBaseName(e.X)
+	case *ast.IndexExpr:
+		return recvBaseName(e.X)
+	case *ast.IndexListExpr:
+		return recvBaseName(e.X)
+	}
+	return ""
+}
+
+// emitStackRemapping appends the source map registration to file and makes
+// the main function of package main defer std.RecoverPretty.
+func (t *galaASTTransformer) emitStackRemapping(fset *token.FileSet, file *ast.File) {
+	if !t.stackRemap || t.filePath == "" {
+		return
+	}
+	if t.packageName == "main" {
+		for _, decl := range file.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil && fn.Name.Name == "main" && fn.Body != nil {
+				hook := &ast.DeferStmt{Call: &ast.CallExpr{Fun: t.stdIdent("RecoverPretty")}}
+				fn.Body.List = append([]ast.Stmt{hook}, fn.Body.List...)
+			}
+		}
+	}
+	if len(t.funcLines) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(t.funcLines))
+	for name := range t.funcLines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	// One entry per line: a synthetic file gives the printer line positions
+	pos := syntheticFile(fset, "gala-sourcemap", len(names)+2)
+	lines := &ast.CompositeLit{
+		Type:   &ast.MapType{Key: ast.NewIdent("string"), Value: ast.NewIdent("int")},
+		Lbrace: pos.LineStart(1),
+		Rbrace: pos.LineStart(len(names) + 2),
+	}
+	for i, name := range names {
+		lines.Elts = append(lines.Elts, &ast.KeyValueExpr{
+			Key:   &ast.BasicLit{ValuePos: pos.LineStart(i + 2), Kind: token.STRING, Value: strconv.Quote(name)},
+			Value: &ast.BasicLit{Kind: token.INT, Value: strconv.Itoa(t.funcLines[name])},
+		})
+	}
+	register := &ast.CallExpr{
+		Fun:  t.stdIdent("RegisterSourceMap"),
+		Args: []ast.Expr{&ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(filepath.Base(t.filePath))}, lines},
+	}
+	init := &ast.FuncDecl{
+		Name: ast.NewIdent("init"),
+		Type: &ast.FuncType{Params: &ast.FieldList{}},
+		Body: &ast.BlockStmt{List: []ast.Stmt{&ast.ExprStmt{X: register}}},
+	}
+	markNewSection(init)
+	file.Decls = append(file.Decls, init)
+}
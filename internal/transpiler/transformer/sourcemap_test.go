@@ -0,0 +1,93 @@
+package transformer_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"martianoff/gala/internal/transpiler"
+	"martianoff/gala/internal/transpiler/analyzer"
+	"martianoff/gala/internal/transpiler/generator"
+	"martianoff/gala/internal/transpiler/transformer"
+)
+
+func TestStackRemapping(t *testing.T) {
+	input := `package main
+
+sealed type Shape {
+	case Circle(Radius float64)
+	case Square(Side float64)
+}
+
+struct Canvas(Name string)
+
+func (c *Canvas) Draw(s Shape) string = c.Name
+
+func area(s Shape) float64 = s match {
+	case Circle(r) => r * r
+	case Square(a) => a * a
+}
+
+func main() {
+	println(area(Circle(1.0)))
+}
+`
+	p := transpiler.NewAntlrGalaParser()
+	trans := transpiler.NewGalaToGoTranspiler(p, analyzer.NewGalaAnalyzer(p, getStdSearchPath()),
+		transformer.WithStackRemapping(transformer.NewGalaASTTransformer()), generator.NewGoCodeGenerator())
+	got, err := trans.Transpile(input, "shapes.gala")
+	assert.NoError(t, err)
+	assert.Contains(t, got, "func main() {\n\tdefer std.RecoverPretty()\n")
+	assert.Contains(t, got, "std.RegisterSourceMap(\"shapes.gala\", map[string]int{\n")
+	for _, entry := range []string{
+		`"(*Canvas).Draw": 10,`,
+		`"Circle.Apply": 3,`,
+		`"Shape.String": 3,`,
+		`"area": 12,`,
+		`"main": 17,`,
+	} {
+		assert.Contains(t, got, entry)
+	}
+
+	// Without the option nothing is added
+	trans = transpiler.NewGalaToGoTranspiler(p, analyzer.NewGalaAnalyzer(p, getStdSearchPath()),
+		transformer.NewGalaASTTransformer(), generator.NewGoCodeGenerator())
+	got, err = trans.Transpile(input, "shapes.gala")
+	assert.NoError(t, err)
+	assert.NotContains(t, got, "RecoverPretty")
+	assert.NotContains(t, got, "RegisterSourceMap")
+}
+
+func TestLineDirectives(t *testing.T) {
+	input := `package main
+
+// Greeting is the text main prints.
+val Greeting = "hello"
+
+sealed type Shape {
+	case Circle(Radius float64)
+}
+
+func main() {
+	println(Greeting)
+}
+`
+	p := transpiler.NewAntlrGalaParser()
+	trans := transpiler.NewGalaToGoTranspiler(p, analyzer.NewGalaAnalyzer(p, getStdSearchPath()),
+		transformer.WithLineDirectives(transformer.NewGalaASTTransformer()), generator.NewGoCodeGenerator())
+	got, err := trans.Transpile(input, "app/main.gala")
+	assert.NoError(t, err)
+	path, err := filepath.Abs("app/main.gala")
+	assert.NoError(t, err)
+	assert.Contains(t, got, "// Greeting is the text main prints.\n//line "+path+":4\nvar Greeting ")
+	assert.Contains(t, got, "//line "+path+":10\nfunc main() {\n\t/*line "+path+":10:1*/\n\tprintln(")
+	assert.Contains(t, got, "//line "+path+":6\nfunc (s Shape) String() string {\n")
+
+	// Without the option nothing is added
+	trans = transpiler.NewGalaToGoTranspiler(p, analyzer.NewGalaAnalyzer(p, getStdSearchPath()),
+		transformer.NewGalaASTTransformer(), generator.NewGoCodeGenerator())
+	got, err = trans.Transpile(input, "app/main.gala")
+	assert.NoError(t, err)
+	assert.NotContains(t, got, "//line")
+}
@@ -0,0 +1,195 @@
+package transformer_test
+
+import (
+	"martianoff/gala/internal/transpiler"
+	"martianoff/gala/internal/transpiler/analyzer"
+	"martianoff/gala/internal/transpiler/generator"
+	"martianoff/gala/internal/transpiler/transformer"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMethodSpecialization(t *testing.T) {
+	p := transpiler.NewAntlrGalaParser()
+	a := analyzer.NewGalaAnalyzer(p, getStdSearchPath())
+	tr := transformer.NewGalaASTTransformer()
+	g := generator.NewGoCodeGenerator()
+	trans := transpiler.NewGalaToGoTranspiler(p, a, tr, g)
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name: "local specialization is selected for int receiver",
+			input: `package main
+
+type Box[T any] struct {
+	Value T
+}
+func (b Box[T]) Has(x T) bool = false
+func Box_Has_int(b Box[int], x int) bool = true
+
+func check(b Box[int]) bool = b.Has(1)`,
+			expected: `package main
+
+import "martianoff/gala/std"
+
+type Box[T any] struct {
+	Value std.Immutable[T]
+}
+
+func (s Box[T]) Copy() Box[T] {
+	return Box[T]{Value: s.Value}
+}
+func (s Box[T]) Equal(other Box[T]) bool {
+	return std.Equal(s.Value, other.Value)
+}
+
+type BoxInstance interface {
+	IsBox() bool
+}
+
+func (_ Box[T]) IsBox() bool {
+	return true
+}
+func (s Box[T]) Unapply(v any) (std.Immutable[T], bool) {
+	switch p := v.(type) {
+	case Box[T]:
+		return p.Value, true
+	case *Box[T]:
+		if p != nil {
+			return p.Value, true
+		}
+	}
+	return *new(std.Immutable[T]), false
+}
+func (b Box[T]) Has(x T) bool {
+	return false
+}
+func Box_Has_int(b Box[int], x int) bool {
+	return true
+}
+
+func check(b Box[int]) bool {
+	return Box_Has_int(b, 1)
+}
+`,
+		},
+		{
+			name: "no specialization keeps method call",
+			input: `package main
+
+type Box[T any] struct {
+	Value T
+}
+func (b Box[T]) Has(x T) bool = false
+func Box_Has_int(b Box[int], x int) bool = true
+
+func check(b Box[bool]) bool = b.Has(true)`,
+			expected: `package main
+
+import "martianoff/gala/std"
+
+type Box[T any] struct {
+	Value std.Immutable[T]
+}
+
+func (s Box[T]) Copy() Box[T] {
+	return Box[T]{Value: s.Value}
+}
+func (s Box[T]) Equal(other Box[T]) bool {
+	return std.Equal(s.Value, other.Value)
+}
+
+type BoxInstance interface {
+	IsBox() bool
+}
+
+func (_ Box[T]) IsBox() bool {
+	return true
+}
+func (s Box[T]) Unapply(v any) (std.Immutable[T], bool) {
+	switch p := v.(type) {
+	case Box[T]:
+		return p.Value, true
+	case *Box[T]:
+		if p != nil {
+			return p.Value, true
+		}
+	}
+	return *new(std.Immutable[T]), false
+}
+func (b Box[T]) Has(x T) bool {
+	return false
+}
+func Box_Has_int(b Box[int], x int) bool {
+	return true
+}
+
+func check(b Box[bool]) bool {
+	return b.Has(true)
+}
+`,
+		},
+		{
+			name: "Array[string] Contains uses collection specialization",
+			input: `package main
+
+import . "martianoff/gala/collection_immutable"
+
+func check(xs Array[string]) bool = xs.Contains("a")`,
+			expected: `package main
+
+import . "martianoff/gala/collection_immutable"
+
+func check(xs Array[string]) bool {
+	return Array_Contains_string(xs, "a")
+}
+`,
+		},
+		{
+			name: "Array[float64] Contains uses collection specialization",
+			input: `package main
+
+import . "martianoff/gala/collection_immutable"
+
+func check(xs Array[float64]) bool = xs.Contains(1.5)`,
+			expected: `package main
+
+import . "martianoff/gala/collection_immutable"
+
+func check(xs Array[float64]) bool {
+	return Array_Contains_float64(xs, 1.5)
+}
+`,
+		},
+		{
+			name: "Array[float64] Sorted uses collection specialization",
+			input: `package main
+
+import . "martianoff/gala/collection_immutable"
+
+func check(xs Array[float64]) Array[float64] = xs.Sorted()`,
+			expected: `package main
+
+import . "martianoff/gala/collection_immutable"
+
+func check(xs Array[float64]) Array[float64] {
+	return Array_Sorted_float64(xs)
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := trans.Transpile(tt.input, "")
+			assert.NoError(t, err)
+			assert.Equal(t, strings.TrimSpace(tt.expected), strings.TrimSpace(stripGeneratedHeader(got)))
+		})
+	}
+}
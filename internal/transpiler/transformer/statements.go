@@ -7,6 +7,7 @@ import (
 	"go/token"
 	"martianoff/gala/galaerr"
 	"martianoff/gala/internal/parser/grammar"
+	"martianoff/gala/internal/transpiler"
 	"strings"
 )
 
@@ -49,7 +50,7 @@ func (t *galaASTTransformer) transformIncDecStmt(ctx *grammar.IncDecStmtContext)
 	// Check for mutability - get the name if it's an identifier
 	if ident, ok := expr.(*ast.Ident); ok {
 		if t.isVal(ident.Name) {
-			return nil, t.semanticErrorAt(ctx, fmt.Sprintf("cannot increment/decrement immutable variable %s", ident.Name))
+			return nil, t.semanticErrorAt(ctx, fmt.Sprintf("cannot increment/decrement immutable variable %s", ident.Name)).WithCode(galaerr.CodeImmutableAssign)
 		}
 	}
 
@@ -69,7 +70,8 @@ func (t *galaASTTransformer) transformIncDecStmt(ctx *grammar.IncDecStmtContext)
 	}, nil
 }
 
-func (t *galaASTTransformer) transformStatement(ctx *grammar.StatementContext) (ast.Stmt, error) {
+func (t *galaASTTransformer) transformStatement(ctx *grammar.StatementContext) (_ ast.Stmt, err error) {
+	defer func() { err = t.locate(ctx, err) }()
 	if declCtx := ctx.Declaration(); declCtx != nil {
 		decl, stmt, err := t.transformDeclaration(declCtx)
 		if err != nil {
@@ -104,13 +106,13 @@ func (t *galaASTTransformer) transformAssignment(ctx *grammar.AssignmentContext)
 			if pc.Identifier() != nil {
 				name := pc.Identifier().GetText()
 				if t.isVal(name) {
-					return nil, t.semanticErrorAt(ctx, fmt.Sprintf("cannot assign to immutable variable %s", name))
+					return nil, t.semanticErrorAt(ctx, fmt.Sprintf("cannot assign to immutable variable %s", name)).WithCode(galaerr.CodeImmutableAssign)
 				}
 			}
 		}
 		// Check for dereference assignment (*ptr = value) where ptr is ConstPtr
 		if t.isConstPtrDerefAssignment(exprCtx) {
-			return nil, galaerr.NewSemanticError("cannot assign through ConstPtr - read-only pointer to immutable value")
+			return nil, galaerr.NewSemanticError("cannot assign through ConstPtr - read-only pointer to immutable value").WithCode(galaerr.CodeImmutableAssign)
 		}
 		if exprCtx.GetChildCount() == 3 && exprCtx.GetChild(1).(antlr.ParseTree).GetText() == "." {
 			selName := exprCtx.GetChild(2).(antlr.ParseTree).GetText()
@@ -127,7 +129,7 @@ func (t *galaASTTransformer) transformAssignment(ctx *grammar.AssignmentContext)
 					for i, f := range fields {
 						if f == selName {
 							if t.structImmutFields[resolvedTypeName][i] {
-								return nil, galaerr.NewSemanticError(fmt.Sprintf("cannot assign to immutable field %s", selName))
+								return nil, galaerr.NewSemanticError(fmt.Sprintf("cannot assign to immutable field %s", selName)).WithCode(galaerr.CodeImmutableAssign)
 							}
 							break
 						}
@@ -209,7 +211,7 @@ func (t *galaASTTransformer) transformShortVarDeclWithMutability(ctx *grammar.Sh
 		}
 
 		if t.isNoneCall(val) {
-			return nil, galaerr.NewSemanticError("variable assigned to None() must have an explicit type")
+			return nil, galaerr.NewSemanticError("variable assigned to None() must have an explicit type").WithCode(galaerr.CodeUntypedNone)
 		}
 
 		if mutable {
@@ -239,6 +241,9 @@ func (t *galaASTTransformer) transformBlock(ctx *grammar.BlockContext) (*ast.Blo
 		if err != nil {
 			return nil, err
 		}
+		if t.lineDirectives && stmt != nil {
+			t.stmtLines[stmt] = stmtCtx.GetStart().GetLine()
+		}
 		block.List = append(block.List, stmt)
 	}
 	return block, nil
@@ -275,6 +280,13 @@ func (t *galaASTTransformer) transformForStatement(ctx *grammar.ForStatementCont
 			return nil, err
 		}
 
+		// Check the range form against the target Go release
+		rangeType := t.getExprTypeName(rangeExpr)
+		if _, isFunc := rangeType.(transpiler.FuncType); isFunc && !t.goVersion.SupportsRangeOverFunc() {
+			return nil, t.semanticErrorAt(rangeClause, fmt.Sprintf("ranging over a function requires Go 1.23 or later (target is %s)", t.goVersion)).WithCode(galaerr.CodeGoVersion)
+		}
+		lowerIntRange := !rangeType.IsNil() && transpiler.IsIntegerType(rangeType.String()) && !t.goVersion.SupportsRangeOverInt()
+
 		// Infer key/value types from range expression
 		keyType, valueType := t.inferRangeTypes(rangeExpr)
 
@@ -313,6 +325,10 @@ func (t *galaASTTransformer) transformForStatement(ctx *grammar.ForStatementCont
 			return nil, err
 		}
 
+		if lowerIntRange {
+			return t.lowerIntRange(rangeClause, key, tok, rangeType, rangeExpr, body)
+		}
+
 		return &ast.RangeStmt{
 			Key:   key,
 			Value: value,
@@ -322,6 +338,11 @@ func (t *galaASTTransformer) transformForStatement(ctx *grammar.ForStatementCont
 		}, nil
 	}
 
+	// Handle generator clause: for x <- xs
+	if genCtx := ctx.GeneratorClause(); genCtx != nil {
+		return t.transformGeneratorLoop(genCtx.(*grammar.GeneratorClauseContext), ctx.Block().(*grammar.BlockContext))
+	}
+
 	// Handle for clause: for init; condition; post
 	if forClauseCtx := ctx.ForClause(); forClauseCtx != nil {
 		forClause := forClauseCtx.(*grammar.ForClauseContext)
@@ -385,6 +406,135 @@ func (t *galaASTTransformer) transformForStatement(ctx *grammar.ForStatementCont
 	}, nil
 }
 
+// transformGeneratorLoop transpiles `for x <- xs { ... }`, which runs the body
+// with x bound to each element of xs in turn: the elements of a Go slice, the
+// runes of a string, the values an iterator function yields, or the elements
+// of a collection such as Array or List, ranged over through ToGoSlice:
+//
+//	for x <- names { ... }	// for _, x := range names.ToGoSlice() { ... }
+func (t *galaASTTransformer) transformGeneratorLoop(gen *grammar.GeneratorClauseContext, block *grammar.BlockContext) (ast.Stmt, error) {
+	t.pushScope()
+	defer t.popScope()
+
+	src, err := t.transformExpression(gen.Expression())
+	if err != nil {
+		return nil, err
+	}
+	srcType := t.getExprTypeName(src)
+
+	var elemType transpiler.Type
+	var index ast.Expr = ast.NewIdent("_")
+	switch typ := srcType.(type) {
+	case transpiler.ArrayType:
+		elemType = typ.Elem
+	case transpiler.FuncType:
+		if !t.goVersion.SupportsRangeOverFunc() {
+			return nil, t.semanticErrorAt(gen, fmt.Sprintf("ranging over a function requires Go 1.23 or later (target is %s)", t.goVersion)).WithCode(galaerr.CodeGoVersion)
+		}
+		// func(yield func(T) bool) yields one value per iteration
+		if len(typ.Params) == 1 {
+			if yield, ok := typ.Params[0].(transpiler.FuncType); ok && len(yield.Params) == 1 {
+				elemType, index = yield.Params[0], nil
+			}
+		}
+	default:
+		if !srcType.IsNil() && srcType.String() == "string" {
+			elemType = transpiler.BasicType{Name: "rune"}
+		} else if elemType = t.goSliceElemType(srcType); elemType != nil {
+			src = &ast.CallExpr{Fun: &ast.SelectorExpr{X: src, Sel: ast.NewIdent(transpiler.MethodToGoSlice)}}
+		}
+	}
+	if elemType == nil {
+		return nil, t.semanticErrorAt(gen, fmt.Sprintf("cannot iterate over '%s' with <-: expected a slice, a string, an iterator function or a collection", gen.Expression().GetText()))
+	}
+
+	name := gen.Identifier().GetText()
+	if name != "_" {
+		t.addVar(name, elemType)
+	}
+	body, err := t.transformBlock(block)
+	if err != nil {
+		return nil, err
+	}
+
+	loop := &ast.RangeStmt{X: src, Body: body}
+	switch {
+	case name == "_":
+		// for range xs
+	case index == nil:
+		loop.Key, loop.Tok = ast.NewIdent(name), token.DEFINE
+	default:
+		loop.Key, loop.Value, loop.Tok = index, ast.NewIdent(name), token.DEFINE
+	}
+	return loop, nil
+}
+
+// goSliceElemType returns the element type of the collection typ, given by
+// the result of its ToGoSlice method, or nil if typ has no such method.
+func (t *galaASTTransformer) goSliceElemType(typ transpiler.Type) transpiler.Type {
+	if ptr, ok := typ.(transpiler.PointerType); ok {
+		typ = ptr.Elem
+	}
+	if typ == nil || typ.IsNil() {
+		return nil
+	}
+	meta := t.getTypeMeta(typ.BaseName())
+	if meta == nil {
+		return nil
+	}
+	method, ok := meta.Methods[transpiler.MethodToGoSlice]
+	if !ok || len(method.ParamTypes) > 0 {
+		return nil
+	}
+	var typeArgs []transpiler.Type
+	if generic, ok := typ.(transpiler.GenericType); ok {
+		typeArgs = generic.Params
+	}
+	slice, ok := t.substituteConcreteTypes(method.ReturnType, meta.TypeParams, typeArgs).(transpiler.ArrayType)
+	if !ok {
+		return nil
+	}
+	return slice.Elem
+}
+
+// lowerIntRange rewrites `for i := range n` over an integer into a three-clause loop
+// for targets older than Go 1.22. The bound is evaluated once and each iteration
+// declares its own copy of the key, as with range.
+func (t *galaASTTransformer) lowerIntRange(ctx antlr.ParserRuleContext, key ast.Expr, tok token.Token, rangeType transpiler.Type, rangeExpr ast.Expr, body *ast.BlockStmt) (ast.Stmt, error) {
+	if tok != token.DEFINE {
+		return nil, t.semanticErrorAt(ctx, fmt.Sprintf("range over an integer with '=' requires Go 1.22 or later (target is %s)", t.goVersion)).WithCode(galaerr.CodeGoVersion)
+	}
+	keyName := "_"
+	if id, ok := key.(*ast.Ident); ok {
+		keyName = id.Name
+	}
+	if keyName == "_" {
+		keyName = t.nextTempVar()
+	} else if usesName(body, keyName) {
+		// Each iteration gets its own copy, as range does since Go 1.22
+		body.List = append([]ast.Stmt{&ast.AssignStmt{
+			Lhs: []ast.Expr{ast.NewIdent(keyName)},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{ast.NewIdent(keyName)},
+		}}, body.List...)
+	}
+	limit := t.nextTempVar()
+	var zero ast.Expr = &ast.BasicLit{Kind: token.INT, Value: "0"}
+	if rangeType.String() != "int" {
+		zero = &ast.CallExpr{Fun: ast.NewIdent(rangeType.String()), Args: []ast.Expr{zero}}
+	}
+	return &ast.ForStmt{
+		Init: &ast.AssignStmt{
+			Lhs: []ast.Expr{ast.NewIdent(keyName), ast.NewIdent(limit)},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{zero, rangeExpr},
+		},
+		Cond: &ast.BinaryExpr{X: ast.NewIdent(keyName), Op: token.LSS, Y: ast.NewIdent(limit)},
+		Post: &ast.IncDecStmt{X: ast.NewIdent(keyName), Tok: token.INC},
+		Body: body,
+	}, nil
+}
+
 // isConstPtrDerefAssignment checks if the expression is a pointer dereference
 // where the pointer type is ConstPtr. Such assignments are not allowed because
 // ConstPtr provides read-only access to the pointed-to value.
@@ -438,6 +588,9 @@ func (t *galaASTTransformer) isConstPtrDerefAssignment(ctx grammar.IExpressionCo
 }
 
 func (t *galaASTTransformer) transformIfStatement(ctx *grammar.IfStatementContext) (ast.Stmt, error) {
+	if ctx.IfLetCondition() != nil {
+		return t.transformIfLetStatement(ctx)
+	}
 	cond, err := t.transformExpression(ctx.Expression())
 	if err != nil {
 		return nil, err
@@ -459,21 +612,92 @@ func (t *galaASTTransformer) transformIfStatement(ctx *grammar.IfStatementContex
 		stmt.Init = init
 	}
 
-	if ctx.ELSE() != nil {
-		if ctx.Block(1) != nil {
-			elseBody, err := t.transformBlock(ctx.Block(1).(*grammar.BlockContext))
-			if err != nil {
-				return nil, err
-			}
-			stmt.Else = elseBody
-		} else if ctx.IfStatement() != nil {
-			elseIf, err := t.transformIfStatement(ctx.IfStatement().(*grammar.IfStatementContext))
-			if err != nil {
-				return nil, err
-			}
-			stmt.Else = elseIf
+	if err := t.transformElseBranch(ctx, stmt); err != nil {
+		return nil, err
+	}
+	return stmt, nil
+}
+
+// transformElseBranch sets stmt.Else from the else block or else-if of ctx, if any.
+func (t *galaASTTransformer) transformElseBranch(ctx *grammar.IfStatementContext, stmt *ast.IfStmt) error {
+	if ctx.ELSE() == nil {
+		return nil
+	}
+	if ctx.Block(1) != nil {
+		elseBody, err := t.transformBlock(ctx.Block(1).(*grammar.BlockContext))
+		if err != nil {
+			return err
 		}
+		stmt.Else = elseBody
+	} else if ctx.IfStatement() != nil {
+		elseIf, err := t.transformIfStatement(ctx.IfStatement().(*grammar.IfStatementContext))
+		if err != nil {
+			return err
+		}
+		stmt.Else = elseIf
 	}
+	return nil
+}
 
-	return stmt, nil
+// transformIfLetStatement lowers `if (val Pattern = expr) { ... } else { ... }`.
+// The subject is evaluated once, the pattern's bindings are declared before the
+// if (they hold zero values when the pattern does not match), and the pattern
+// condition selects the branch:
+//
+//	{
+//		_tmp_1 := expr
+//		<bindings>
+//		if <cond> { ... } else { ... }
+//	}
+//
+// The bindings are in GALA scope only inside the then-branch.
+func (t *galaASTTransformer) transformIfLetStatement(ctx *grammar.IfStatementContext) (ast.Stmt, error) {
+	letCtx := ctx.IfLetCondition().(*grammar.IfLetConditionContext)
+	subject, err := t.transformExpression(letCtx.Expression())
+	if err != nil {
+		return nil, err
+	}
+	subjectType := t.getExprTypeNameManual(subject)
+	if subjectType == nil || subjectType.IsNil() {
+		subjectType, _ = t.inferExprType(subject)
+	}
+	if subjectType == nil || subjectType.IsNil() {
+		return nil, t.semanticErrorAt(letCtx, "cannot infer type of the value matched in if (val ... = ...); add an explicit type annotation").WithCode(galaerr.CodeMatchSubjectType)
+	}
+
+	subjectName := t.nextTempVar()
+	t.pushScope()
+	t.addVar(subjectName, subjectType)
+	cond, bindings, err := t.transformPatternWithType(letCtx.Pattern(), ast.NewIdent(subjectName), subjectType)
+	if err != nil {
+		t.popScope()
+		return nil, err
+	}
+	body, err := t.transformBlock(ctx.Block(0).(*grammar.BlockContext))
+	t.popScope()
+	if err != nil {
+		return nil, err
+	}
+
+	refs := collectReferencedIdents([]ast.Node{body})
+	for _, name := range extractUserPatternVarNames(bindings) {
+		if !refs[name] {
+			return nil, t.semanticErrorAt(letCtx, fmt.Sprintf("unused variable '%s' in if (val ...) pattern — use '_' to discard this value", name))
+		}
+	}
+
+	stmt := &ast.IfStmt{Cond: cond, Body: body}
+	if err := t.transformElseBranch(ctx, stmt); err != nil {
+		return nil, err
+	}
+
+	list := []ast.Stmt{&ast.AssignStmt{
+		Lhs: []ast.Expr{ast.NewIdent(subjectName)},
+		Tok: token.DEFINE,
+		Rhs: []ast.Expr{subject},
+	}}
+	list = blankAssignTempVar(list, subjectName)
+	list = append(list, bindings...)
+	list = append(list, stmt)
+	return &ast.BlockStmt{List: list}, nil
 }
@@ -1,6 +1,7 @@
 package transformer_test
 
 import (
+	"martianoff/gala/galaerr"
 	"martianoff/gala/internal/transpiler"
 	"martianoff/gala/internal/transpiler/analyzer"
 	"martianoff/gala/internal/transpiler/generator"
@@ -41,17 +42,19 @@ type Person struct {
 }
 
 func (s Person) Copy() Person {
-	return Person{Name: std.Copy(s.Name), Age: std.Copy(s.Age)}
+	return Person{Name: s.Name, Age: s.Age}
 }
 func (s Person) Equal(other Person) bool {
 	return std.Equal(s.Name, other.Name) && std.Equal(s.Age, other.Age)
 }
 func (s Person) Unapply(v any) (std.Immutable[string], std.Immutable[int], bool) {
-	if p, ok := v.(Person); ok {
-		return p.Name, p.Age, true
-	}
-	if p, ok := v.(*Person); ok && p != nil {
+	switch p := v.(type) {
+	case Person:
 		return p.Name, p.Age, true
+	case *Person:
+		if p != nil {
+			return p.Name, p.Age, true
+		}
 	}
 	return *new(std.Immutable[string]), *new(std.Immutable[int]), false
 }
@@ -75,17 +78,19 @@ type Config struct {
 }
 
 func (s Config) Copy() Config {
-	return Config{ID: std.Copy(s.ID), Count: std.Copy(s.Count)}
+	return Config{ID: s.ID, Count: std.Copy(s.Count)}
 }
 func (s Config) Equal(other Config) bool {
 	return std.Equal(s.ID, other.ID) && std.Equal(s.Count, other.Count)
 }
 func (s Config) Unapply(v any) (std.Immutable[string], int, bool) {
-	if p, ok := v.(Config); ok {
-		return p.ID, p.Count, true
-	}
-	if p, ok := v.(*Config); ok && p != nil {
+	switch p := v.(type) {
+	case Config:
 		return p.ID, p.Count, true
+	case *Config:
+		if p != nil {
+			return p.ID, p.Count, true
+		}
 	}
 	return *new(std.Immutable[string]), *new(int), false
 }
@@ -107,17 +112,19 @@ type User struct {
 }
 
 func (s User) Copy() User {
-	return User{Name: std.Copy(s.Name)}
+	return User{Name: s.Name}
 }
 func (s User) Equal(other User) bool {
 	return std.Equal(s.Name, other.Name)
 }
 func (s User) Unapply(v any) (std.Immutable[string], bool) {
-	if p, ok := v.(User); ok {
-		return p.Name, true
-	}
-	if p, ok := v.(*User); ok && p != nil {
+	switch p := v.(type) {
+	case User:
 		return p.Name, true
+	case *User:
+		if p != nil {
+			return p.Name, true
+		}
 	}
 	return *new(std.Immutable[string]), false
 }
@@ -139,7 +146,7 @@ type Person struct {
 }
 
 func (s Person) Copy() Person {
-	return Person{name: std.Copy(s.name), age: std.Copy(s.age)}
+	return Person{name: s.name, age: s.age}
 }
 func (s Person) Equal(other Person) bool {
 	return std.Equal(s.name, other.name) && std.Equal(s.age, other.age)
@@ -158,3 +165,36 @@ var p = std.NewImmutable(Person{name: std.NewImmutable("Alice"), age: std.NewImm
 		})
 	}
 }
+
+func TestConstructorArgumentMismatch(t *testing.T) {
+	p := transpiler.NewAntlrGalaParser()
+	trans := transpiler.NewGalaToGoTranspiler(p, analyzer.NewGalaAnalyzer(p, getStdSearchPath()),
+		transformer.NewGalaASTTransformer(), generator.NewGoCodeGenerator())
+	const decl = "package main\n\nstruct Point(X int, Y int, Z int)\n\n"
+
+	tests := []struct {
+		name  string
+		call  string
+		error string
+	}{
+		{"too many", "Point(1, 2, 3, 4)", "too many arguments in construction of Point: got 4, but Point has 3 fields, X, Y and Z"},
+		{"too few", "Point(1, 2)", "not enough arguments in construction of Point: got 2, but Point has 3 fields, X, Y and Z; pass them all or name the ones to set, as in Point(X = ..., Y = ..., Z = ...)"},
+		{"unknown name", "Point(1, W = 2)", "Point has no field W; Point has 3 fields, X, Y and Z"},
+		{"set twice", "Point(1, X = 2)", "field X of Point is set twice, by position and by name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := trans.Transpile(decl+"val p = "+tt.call+"\n", "main.gala")
+			assert.Error(t, err)
+			assert.Equal(t, galaerr.CodeConstructorArgs, galaerr.CodeOf(err))
+			assert.Contains(t, err.Error(), tt.error)
+		})
+	}
+
+	// Leading positional arguments set the first fields, named ones the rest
+	got, err := trans.Transpile(decl+"val p = Point(1, Z = 3)\n", "main.gala")
+	assert.NoError(t, err)
+	assert.Contains(t, got, "X: std.NewImmutable(1)")
+	assert.Contains(t, got, "Z: std.NewImmutable(3)")
+	assert.NotContains(t, got, "Y:")
+}
@@ -0,0 +1,87 @@
+package transformer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// This file indexes the imported types and functions by their simple names.
+// Imported metadata is keyed by qualified names such as
+// collection_immutable.Array. Rather than trying a simple name with each
+// import in turn, which costs a lookup per import and lets the import order
+// decide between packages declaring the same name, the index built when a
+// Transform starts lists the qualified names of Array at once. A name found
+// in more than one package of the same kind of import is ambiguous and
+// reported at the declaration that uses it.
+
+// symbolIndex maps a simple name to the qualified names it stands for in the
+// metadata of imported packages, sorted.
+type symbolIndex map[string][]string
+
+// indexSymbols builds the symbol index from the type and function metadata.
+// Declarations of the current file are found by their simple names and need
+// no index.
+func (t *galaASTTransformer) indexSymbols() {
+	seen := make(map[string]bool)
+	t.symbols = make(symbolIndex)
+	add := func(qualified string) {
+		idx := strings.LastIndex(qualified, ".")
+		if idx < 0 || seen[qualified] {
+			return
+		}
+		seen[qualified] = true
+		name := qualified[idx+1:]
+		t.symbols[name] = append(t.symbols[name], qualified)
+	}
+	for name := range t.typeMetas {
+		add(name)
+	}
+	for name := range t.structFields {
+		add(name)
+	}
+	for name := range t.genericMethods {
+		add(name)
+	}
+	for name := range t.functions {
+		add(name)
+	}
+	for _, candidates := range t.symbols {
+		sort.Strings(candidates)
+	}
+}
+
+// importedSymbols returns the qualified names of name that exist in packages
+// the file imports, split into dot imports and imports used by name.
+func (t *galaASTTransformer) importedSymbols(name string, exists func(string) bool) (dot, named []string) {
+	for _, qualified := range t.symbols[name] {
+		pkg := qualified[:strings.LastIndex(qualified, ".")]
+		entry, ok := t.importManager.GetByPkgName(pkg)
+		if !ok || !exists(qualified) {
+			continue
+		}
+		if entry.IsDot {
+			dot = append(dot, qualified)
+		} else {
+			named = append(named, qualified)
+		}
+	}
+	return dot, named
+}
+
+// resolveImportedSymbol resolves name to the qualified name of an imported
+// symbol for which exists holds, preferring dot imports. If several packages
+// of the preferred kind declare it, the first is returned and the ambiguity
+// is recorded for the enclosing declaration.
+func (t *galaASTTransformer) resolveImportedSymbol(name string, exists func(string) bool) (string, bool) {
+	dot, named := t.importedSymbols(name, exists)
+	for _, candidates := range [][]string{dot, named} {
+		if len(candidates) > 1 && t.ambiguity == "" {
+			t.ambiguity = fmt.Sprintf("ambiguous name %s: it could be %s; qualify it with its package", name, strings.Join(candidates, " or "))
+		}
+		if len(candidates) > 0 {
+			return candidates[0], true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,53 @@
+package transformer_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"martianoff/gala/internal/transpiler"
+	"martianoff/gala/internal/transpiler/analyzer"
+	"martianoff/gala/internal/transpiler/generator"
+	"martianoff/gala/internal/transpiler/transformer"
+)
+
+func TestSameNameInImportedPackages(t *testing.T) {
+	tempDir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(tempDir, "go.mod"), []byte("module testmod\n\ngo 1.21\n"), 0644))
+	// Point is immutable in pkg_a and mutable in pkg_b
+	for pkg, src := range map[string]string{
+		"pkg_a": "package pkg_a\n\nstruct Point(X int, Y int)\n",
+		"pkg_b": "package pkg_b\n\nstruct Point(var X int, var Y int)\n",
+	} {
+		assert.NoError(t, os.MkdirAll(filepath.Join(tempDir, pkg), 0755))
+		assert.NoError(t, os.WriteFile(filepath.Join(tempDir, pkg, pkg+".gala"), []byte(src), 0644))
+	}
+
+	originalWd, err := os.Getwd()
+	assert.NoError(t, err)
+	defer os.Chdir(originalWd)
+	assert.NoError(t, os.Chdir(tempDir))
+
+	p := transpiler.NewAntlrGalaParser()
+	trans := transpiler.NewGalaToGoTranspiler(p, analyzer.NewGalaAnalyzer(p, nil),
+		transformer.NewGalaASTTransformer(), generator.NewGoCodeGenerator())
+
+	// Each qualified Point resolves to its own package whatever the import order
+	for _, imports := range []string{"\"testmod/pkg_a\"\n\"testmod/pkg_b\"", "\"testmod/pkg_b\"\n\"testmod/pkg_a\""} {
+		got, err := trans.Transpile(`package main
+
+import (
+`+imports+`
+)
+
+func ax(p pkg_a.Point) int = p.X
+
+func bx(p pkg_b.Point) int = p.X
+`, "main.gala")
+		assert.NoError(t, err)
+		assert.Contains(t, got, "return p.X.Get()")
+		assert.Contains(t, got, "return p.X\n")
+	}
+}
@@ -0,0 +1,336 @@
+package transformer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"martianoff/gala/galaerr"
+	"martianoff/gala/internal/parser/grammar"
+	"martianoff/gala/internal/transpiler"
+)
+
+// This file compiles @tailrec functions into loops.
+//
+// The generated body is wrapped in a labeled `for {}`. Every `return f(args)` in tail
+// position becomes a parallel assignment of args to the parameters followed by
+// `continue`. Expression bodies are generated as IIFEs (if/else and match expressions),
+// so a returned IIFE whose result type is the function's own result type is flattened
+// into the loop first; its returns then return from the function directly.
+// Any recursive call left after the rewrite is not a tail call and is rejected.
+//
+// A closure captures variables, not values, so a function literal made in one
+// iteration would see the parameters of the next. Parameters referred to by a
+// function literal are renamed in the signature and copied into fresh variables
+// of their original name at the top of each iteration:
+//
+//	func f(_tailrec_n int, acc []func() int) []func() int {
+//	_tailrec:
+//		for {
+//			n := _tailrec_n
+//			...
+//			_tailrec_n, acc = n-1, append(acc, func() int { return n })
+//			continue _tailrec
+
+const tailrecLabel = "_tailrec"
+
+// applyTailrec rewrites the function generated for topDecl when it is annotated with @tailrec.
+func (t *galaASTTransformer) applyTailrec(topDecl grammar.ITopLevelDeclarationContext, decls []ast.Decl) error {
+	if _, ok := declAnnotation(topDecl, transpiler.AnnotationTailrec); !ok {
+		return nil
+	}
+	name := topDecl.FunctionDeclaration().(*grammar.FunctionDeclarationContext).Identifier().GetText()
+	for _, decl := range decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv != nil || fn.Body == nil || fn.Name.Name != name {
+			continue
+		}
+		if err := rewriteTailCalls(fn); err != nil {
+			return t.semanticErrorAt(topDecl, err.Error()).WithCode(galaerr.CodeNotTailRecursive)
+		}
+	}
+	return nil
+}
+
+// tailrecRewriter holds the state of one @tailrec rewrite.
+type tailrecRewriter struct {
+	fn      *ast.FuncDecl
+	params  []*ast.Ident
+	result  string // printed result type, for matching IIFEs
+	rewrote bool
+	targets map[string][]*ast.Ident // parameters assigned by the jumps, by name
+}
+
+func rewriteTailCalls(fn *ast.FuncDecl) error {
+	name := fn.Name.Name
+	r := &tailrecRewriter{fn: fn, targets: make(map[string][]*ast.Ident)}
+	if fn.Type.Results == nil || len(fn.Type.Results.List) != 1 || len(fn.Type.Results.List[0].Names) > 1 {
+		return fmt.Errorf("@tailrec function %s must return a single value", name)
+	}
+	r.result = types.ExprString(fn.Type.Results.List[0].Type)
+
+	paramNames := make(map[string]bool)
+	for _, field := range fn.Type.Params.List {
+		if _, ok := field.Type.(*ast.Ellipsis); ok {
+			return fmt.Errorf("@tailrec function %s cannot be variadic", name)
+		}
+		for _, n := range field.Names {
+			r.params = append(r.params, n)
+			paramNames[n.Name] = true
+		}
+	}
+	if shadowed := redeclaredName(fn.Body, paramNames); shadowed != "" {
+		return fmt.Errorf("@tailrec function %s redeclares its parameter %s", name, shadowed)
+	}
+
+	body := r.stmts(fn.Body.List)
+
+	var nonTail bool
+	for _, s := range body {
+		ast.Inspect(s, func(n ast.Node) bool {
+			if call, ok := n.(*ast.CallExpr); ok && r.isSelfCall(call) {
+				nonTail = true
+			}
+			return !nonTail
+		})
+	}
+	if nonTail {
+		return fmt.Errorf("recursive call to %s is not in tail position", name)
+	}
+	if !r.rewrote {
+		return fmt.Errorf("@tailrec function %s has no tail-recursive calls", name)
+	}
+
+	if copies := r.copyCapturedParams(body); copies != nil {
+		body = append([]ast.Stmt{copies}, body...)
+	}
+
+	fn.Body = &ast.BlockStmt{
+		List: []ast.Stmt{
+			&ast.LabeledStmt{
+				Label: ast.NewIdent(tailrecLabel),
+				Stmt:  &ast.ForStmt{Body: &ast.BlockStmt{List: body}},
+			},
+		},
+	}
+	return nil
+}
+
+// stmts rewrites the tail calls reachable from a statement list.
+func (r *tailrecRewriter) stmts(list []ast.Stmt) []ast.Stmt {
+	var out []ast.Stmt
+	for _, s := range list {
+		out = append(out, r.stmt(s)...)
+	}
+	return out
+}
+
+func (r *tailrecRewriter) stmt(s ast.Stmt) []ast.Stmt {
+	switch x := s.(type) {
+	case *ast.ReturnStmt:
+		if len(x.Results) != 1 {
+			return []ast.Stmt{x}
+		}
+		call, ok := x.Results[0].(*ast.CallExpr)
+		if !ok {
+			return []ast.Stmt{x}
+		}
+		if r.isSelfCall(call) && len(call.Args) == len(r.params) && !call.Ellipsis.IsValid() {
+			r.rewrote = true
+			return r.jump(call.Args)
+		}
+		if lit, ok := call.Fun.(*ast.FuncLit); ok && r.isFlattenable(lit, call) {
+			var out []ast.Stmt
+			for i, field := range lit.Type.Params.List {
+				p := field.Names[0]
+				if p.Name == "_" || !usesName(lit.Body, p.Name) {
+					out = append(out, &ast.AssignStmt{Lhs: []ast.Expr{ast.NewIdent("_")}, Tok: token.ASSIGN, Rhs: []ast.Expr{call.Args[i]}})
+					continue
+				}
+				out = append(out, &ast.DeclStmt{Decl: &ast.GenDecl{
+					Tok:   token.VAR,
+					Specs: []ast.Spec{&ast.ValueSpec{Names: []*ast.Ident{p}, Type: field.Type, Values: []ast.Expr{call.Args[i]}}},
+				}})
+			}
+			body := r.stmts(lit.Body.List)
+			if len(out) == 0 {
+				return body
+			}
+			return []ast.Stmt{&ast.BlockStmt{List: append(out, body...)}}
+		}
+	case *ast.BlockStmt:
+		x.List = r.stmts(x.List)
+	case *ast.IfStmt:
+		x.Body.List = r.stmts(x.Body.List)
+		if x.Else != nil {
+			x.Else = r.stmt(x.Else)[0]
+		}
+	case *ast.SwitchStmt:
+		r.clauses(x.Body)
+	case *ast.TypeSwitchStmt:
+		r.clauses(x.Body)
+	case *ast.SelectStmt:
+		r.clauses(x.Body)
+	case *ast.ForStmt:
+		x.Body.List = r.stmts(x.Body.List)
+	case *ast.RangeStmt:
+		x.Body.List = r.stmts(x.Body.List)
+	}
+	return []ast.Stmt{s}
+}
+
+func (r *tailrecRewriter) clauses(body *ast.BlockStmt) {
+	for _, c := range body.List {
+		switch cc := c.(type) {
+		case *ast.CaseClause:
+			cc.Body = r.stmts(cc.Body)
+		case *ast.CommClause:
+			cc.Body = r.stmts(cc.Body)
+		}
+	}
+}
+
+// jump assigns args to the parameters and restarts the loop.
+func (r *tailrecRewriter) jump(args []ast.Expr) []ast.Stmt {
+	var lhs, rhs []ast.Expr
+	for i, p := range r.params {
+		if id, ok := args[i].(*ast.Ident); ok && id.Name == p.Name {
+			continue
+		}
+		target := ast.NewIdent(p.Name)
+		r.targets[p.Name] = append(r.targets[p.Name], target)
+		lhs = append(lhs, target)
+		rhs = append(rhs, args[i])
+	}
+	var out []ast.Stmt
+	if len(lhs) > 0 {
+		out = append(out, &ast.AssignStmt{Lhs: lhs, Tok: token.ASSIGN, Rhs: rhs})
+	}
+	return append(out, &ast.BranchStmt{Tok: token.CONTINUE, Label: ast.NewIdent(tailrecLabel)})
+}
+
+// copyCapturedParams renames the parameters that a function literal in body
+// refers to, in the signature and in the jumps, and returns the statement
+// declaring variables of their original names at the top of the loop. It
+// returns nil when no parameter is captured.
+func (r *tailrecRewriter) copyCapturedParams(body []ast.Stmt) ast.Stmt {
+	captured := make(map[string]bool)
+	for _, s := range body {
+		ast.Inspect(s, func(n ast.Node) bool {
+			if lit, ok := n.(*ast.FuncLit); ok {
+				for _, p := range r.params {
+					captured[p.Name] = captured[p.Name] || usesName(lit.Body, p.Name)
+				}
+				return false
+			}
+			return true
+		})
+	}
+	var lhs, rhs []ast.Expr
+	for _, p := range r.params {
+		if !captured[p.Name] {
+			continue
+		}
+		renamed := tailrecLabel + "_" + p.Name
+		lhs = append(lhs, ast.NewIdent(p.Name))
+		rhs = append(rhs, ast.NewIdent(renamed))
+		for _, target := range r.targets[p.Name] {
+			target.Name = renamed
+		}
+		p.Name = renamed
+	}
+	if len(lhs) == 0 {
+		return nil
+	}
+	return &ast.AssignStmt{Lhs: lhs, Tok: token.DEFINE, Rhs: rhs}
+}
+
+// isSelfCall reports whether call invokes the function being rewritten.
+func (r *tailrecRewriter) isSelfCall(call *ast.CallExpr) bool {
+	fun := call.Fun
+	switch f := fun.(type) {
+	case *ast.IndexExpr:
+		fun = f.X
+	case *ast.IndexListExpr:
+		fun = f.X
+	}
+	id, ok := fun.(*ast.Ident)
+	return ok && id.Name == r.fn.Name.Name
+}
+
+// isFlattenable reports whether the immediately invoked lit can be inlined into the
+// loop: it returns exactly the function's result type and binds one named value per argument.
+func (r *tailrecRewriter) isFlattenable(lit *ast.FuncLit, call *ast.CallExpr) bool {
+	res := lit.Type.Results
+	if res == nil || len(res.List) != 1 || len(res.List[0].Names) > 1 || types.ExprString(res.List[0].Type) != r.result {
+		return false
+	}
+	if call.Ellipsis.IsValid() {
+		return false
+	}
+	n := 0
+	for _, field := range lit.Type.Params.List {
+		if len(field.Names) != 1 {
+			return false
+		}
+		n++
+	}
+	return n == len(call.Args)
+}
+
+// redeclaredName returns a name from names that is declared again inside body, or "".
+func redeclaredName(body *ast.BlockStmt, names map[string]bool) string {
+	found := ""
+	check := func(id *ast.Ident) {
+		if found == "" && names[id.Name] {
+			found = id.Name
+		}
+	}
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.AssignStmt:
+			if x.Tok == token.DEFINE {
+				for _, l := range x.Lhs {
+					if id, ok := l.(*ast.Ident); ok {
+						check(id)
+					}
+				}
+			}
+		case *ast.ValueSpec:
+			for _, id := range x.Names {
+				check(id)
+			}
+		case *ast.FuncType:
+			if x.Params != nil {
+				for _, field := range x.Params.List {
+					for _, id := range field.Names {
+						check(id)
+					}
+				}
+			}
+		case *ast.RangeStmt:
+			if x.Tok == token.DEFINE {
+				for _, e := range []ast.Expr{x.Key, x.Value} {
+					if id, ok := e.(*ast.Ident); ok {
+						check(id)
+					}
+				}
+			}
+		}
+		return found == ""
+	})
+	return found
+}
+
+// usesName reports whether node refers to an identifier called name.
+func usesName(node ast.Node, name string) bool {
+	used := false
+	ast.Inspect(node, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && id.Name == name {
+			used = true
+		}
+		return !used
+	})
+	return used
+}
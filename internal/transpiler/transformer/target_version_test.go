@@ -0,0 +1,230 @@
+package transformer_test
+
+import (
+	"martianoff/gala/internal/transpiler"
+	"martianoff/gala/internal/transpiler/analyzer"
+	"martianoff/gala/internal/transpiler/generator"
+	"martianoff/gala/internal/transpiler/transformer"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTargetGoVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   string
+		input    string
+		expected string
+		wantErr  string
+	}{
+		{
+			name:   "range over int is kept for Go 1.22",
+			target: "1.22",
+			input: `package main
+
+func sum(n int) int {
+    var total = 0
+    for i := range n {
+        total = total + i
+    }
+    return total
+}`,
+			expected: `// Code generated by GALA transpiler for go1.22. DO NOT EDIT.
+
+package main
+
+func sum(n int) int {
+	var total = 0
+	for i := range n {
+		total = total + i
+	}
+	return total
+}`,
+		},
+		{
+			name:   "range over int is lowered for Go 1.21",
+			target: "1.21",
+			input: `package main
+
+func sum(n int) int {
+    var total = 0
+    for i := range n {
+        total = total + i
+    }
+    return total
+}`,
+			expected: `// Code generated by GALA transpiler for go1.21. DO NOT EDIT.
+
+package main
+
+func sum(n int) int {
+	var total = 0
+	for i, _tmp_0 := 0, n; i < _tmp_0; i++ {
+		i := i
+		total = total + i
+	}
+	return total
+}`,
+		},
+		{
+			name:   "lowered range over int copies only a used key",
+			target: "1.21",
+			input: `package main
+
+func repeat(n int) {
+    for i := range n {
+        println("again")
+    }
+}`,
+			expected: `// Code generated by GALA transpiler for go1.21. DO NOT EDIT.
+
+package main
+
+func repeat(n int) {
+	for i, _tmp_0 := 0, n; i < _tmp_0; i++ {
+		println("again")
+	}
+}`,
+		},
+		{
+			name:   "min builtin is lowered for Go 1.20",
+			target: "1.20",
+			input: `package main
+
+func smaller(a int, b int) int = min(a, b)`,
+			expected: `// Code generated by GALA transpiler for go1.20. DO NOT EDIT.
+
+package main
+
+func smaller(a int, b int) int {
+	return func() int {
+		_tmp_0 := a
+		_tmp_1 := b
+		if _tmp_1 < _tmp_0 {
+			_tmp_0 = _tmp_1
+		}
+		return _tmp_0
+	}()
+}`,
+		},
+		{
+			name:   "constant operand of a lowered min takes the float type",
+			target: "1.20",
+			input: `package main
+
+func atMostOne(x float64) float64 = min(1, x)`,
+			expected: `// Code generated by GALA transpiler for go1.20. DO NOT EDIT.
+
+package main
+
+func atMostOne(x float64) float64 {
+	return func() float64 {
+		_tmp_0 := float64(1)
+		_tmp_1 := x
+		if _tmp_1 < _tmp_0 || _tmp_1 != _tmp_1 {
+			_tmp_0 = _tmp_1
+		}
+		return _tmp_0
+	}()
+}`,
+		},
+		{
+			name:   "lowered max of floats propagates NaN",
+			target: "1.20",
+			input: `package main
+
+func larger(a float64, b float64, c float64) float64 = max(a, b, c)`,
+			expected: `// Code generated by GALA transpiler for go1.20. DO NOT EDIT.
+
+package main
+
+func larger(a float64, b float64, c float64) float64 {
+	return func() float64 {
+		_tmp_0 := a
+		_tmp_1 := b
+		if _tmp_1 > _tmp_0 || _tmp_1 != _tmp_1 {
+			_tmp_0 = _tmp_1
+		}
+		_tmp_2 := c
+		if _tmp_2 > _tmp_0 || _tmp_2 != _tmp_2 {
+			_tmp_0 = _tmp_2
+		}
+		return _tmp_0
+	}()
+}`,
+		},
+		{
+			name:   "min builtin is kept for Go 1.21",
+			target: "1.21",
+			input: `package main
+
+func smaller(a int, b int) int = min(a, b)`,
+			expected: `// Code generated by GALA transpiler for go1.21. DO NOT EDIT.
+
+package main
+
+func smaller(a int, b int) int {
+	return min(a, b)
+}`,
+		},
+		{
+			name:   "range over func is rejected before Go 1.23",
+			target: "1.22",
+			input: `package main
+
+func each(seq func(func(int) bool)) {
+    for x := range seq {
+        println(x)
+    }
+}`,
+			wantErr: "requires Go 1.23",
+		},
+		{
+			name:   "generic type alias is rejected before Go 1.24",
+			target: "1.23",
+			input: `package main
+
+type Result[T any] = Either[error, T]`,
+			wantErr: "generic type alias 'Result' requires Go 1.24",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, err := transpiler.ParseGoVersion(tt.target)
+			assert.NoError(t, err)
+
+			p := transpiler.NewAntlrGalaParser()
+			a := analyzer.NewGalaAnalyzer(p, getStdSearchPath())
+			tr := transformer.NewGalaASTTransformerWithTarget(target)
+			g := generator.NewGoCodeGeneratorWithTarget(target)
+			trans := transpiler.NewGalaToGoTranspiler(p, a, tr, g)
+
+			got, err := trans.Transpile(tt.input, "")
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, strings.TrimSpace(tt.expected), strings.TrimSpace(got))
+		})
+	}
+}
+
+func TestParseGoVersion(t *testing.T) {
+	v, err := transpiler.ParseGoVersion("go1.21.3")
+	assert.NoError(t, err)
+	assert.Equal(t, transpiler.GoVersion{Major: 1, Minor: 21}, v)
+	assert.False(t, v.SupportsRangeOverInt())
+	assert.True(t, v.SupportsMinMaxBuiltins())
+
+	_, err = transpiler.ParseGoVersion("1.17")
+	assert.Error(t, err)
+
+	_, err = transpiler.ParseGoVersion("latest")
+	assert.Error(t, err)
+
+	assert.True(t, transpiler.GoVersion{}.SupportsRangeOverFunc())
+}
@@ -0,0 +1,131 @@
+package transformer
+
+import (
+	"go/ast"
+	"go/token"
+	"path"
+	"strconv"
+
+	"martianoff/gala/internal/parser/grammar"
+	"martianoff/gala/internal/transpiler"
+)
+
+// This file instruments @traced functions with OpenTelemetry spans.
+//
+// The span is started from the function's context.Context parameter, which is
+// then rebound to the span's context so that calls made with it nest below:
+//
+//	func handle(ctx context.Context, id int) string {
+//		ctx, _span := otel.Tracer("example.com/app").Start(ctx, "app.handle")
+//		defer _span.End()
+//		...
+//	}
+//
+// A function without a context parameter starts a root span from
+// context.Background(). The tracer is named after the package import path and
+// the span after the function, unless the annotation gives a name:
+// @traced("checkout").
+
+const (
+	otelImportPath  = "go.opentelemetry.io/otel"
+	tracedSpanVar   = "_span"
+	contextPkgPath  = "context"
+	contextTypeName = "Context"
+)
+
+// applyTraced instruments the function generated for topDecl when it is annotated with @traced.
+func (t *galaASTTransformer) applyTraced(topDecl grammar.ITopLevelDeclarationContext, decls []ast.Decl) {
+	spanName, ok := declAnnotation(topDecl, transpiler.AnnotationTraced)
+	if !ok {
+		return
+	}
+	fnCtx := topDecl.FunctionDeclaration().(*grammar.FunctionDeclarationContext)
+	name := fnCtx.Identifier().GetText()
+	isMethod := fnCtx.Receiver() != nil
+	for _, decl := range decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil || fn.Name.Name != name || (fn.Recv != nil) != isMethod {
+			continue
+		}
+		if spanName == "" {
+			spanName = t.packageName + "." + name
+			if isMethod {
+				spanName = t.packageName + "." + recvBaseName(fn.Recv.List[0].Type) + "." + name
+			}
+		}
+		t.traceFunc(fn, spanName)
+	}
+}
+
+// traceFunc starts a span named spanName at the top of fn and ends it on return.
+func (t *galaASTTransformer) traceFunc(fn *ast.FuncDecl, spanName string) {
+	ctxParam := t.contextParam(fn)
+	var parent ast.Expr
+	var ctxResult ast.Expr = ast.NewIdent("_")
+	if ctxParam != "" {
+		parent = ast.NewIdent(ctxParam)
+		ctxResult = ast.NewIdent(ctxParam)
+	} else {
+		parent = &ast.CallExpr{Fun: &ast.SelectorExpr{X: ast.NewIdent(t.importedPackage(contextPkgPath)), Sel: ast.NewIdent("Background")}}
+	}
+
+	tracer := &ast.CallExpr{
+		Fun:  &ast.SelectorExpr{X: ast.NewIdent(t.importedPackage(otelImportPath)), Sel: ast.NewIdent("Tracer")},
+		Args: []ast.Expr{&ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(t.tracerName())}},
+	}
+	start := &ast.AssignStmt{
+		Lhs: []ast.Expr{ctxResult, ast.NewIdent(tracedSpanVar)},
+		Tok: token.DEFINE,
+		Rhs: []ast.Expr{&ast.CallExpr{
+			Fun:  &ast.SelectorExpr{X: tracer, Sel: ast.NewIdent("Start")},
+			Args: []ast.Expr{parent, &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(spanName)}},
+		}},
+	}
+	end := &ast.DeferStmt{Call: &ast.CallExpr{
+		Fun: &ast.SelectorExpr{X: ast.NewIdent(tracedSpanVar), Sel: ast.NewIdent("End")},
+	}}
+	fn.Body.List = append([]ast.Stmt{start, end}, fn.Body.List...)
+}
+
+// contextParam returns the name of the first context.Context parameter of fn,
+// or "" if it has none.
+func (t *galaASTTransformer) contextParam(fn *ast.FuncDecl) string {
+	for _, field := range fn.Type.Params.List {
+		sel, ok := field.Type.(*ast.SelectorExpr)
+		if !ok || sel.Sel.Name != contextTypeName {
+			continue
+		}
+		pkg, ok := sel.X.(*ast.Ident)
+		if !ok {
+			continue
+		}
+		if p, ok := t.importManager.GetPath(pkg.Name); !ok || p != contextPkgPath {
+			continue
+		}
+		for _, n := range field.Names {
+			if n.Name != "_" {
+				return n.Name
+			}
+		}
+	}
+	return ""
+}
+
+// importedPackage returns the name pkgPath is imported under, recording the
+// import for Transform to add when the file does not import pkgPath yet.
+func (t *galaASTTransformer) importedPackage(pkgPath string) string {
+	if entry, ok := t.importManager.GetByPath(pkgPath); ok && !entry.IsDot {
+		return entry.Alias
+	}
+	t.tracedImports[pkgPath] = true
+	return path.Base(pkgPath)
+}
+
+// tracerName returns the instrumentation scope of the package's spans: its
+// import path when known, otherwise its name.
+func (t *galaASTTransformer) tracerName() string {
+	if t.importPath != "" {
+		return t.importPath
+	}
+	return t.packageName
+}
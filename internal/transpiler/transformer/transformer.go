@@ -5,6 +5,7 @@ import (
 	"go/ast"
 	"go/token"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/antlr4-go/antlr/v4"
@@ -23,6 +24,8 @@ type galaASTTransformer struct {
 	structImmutFields     map[string][]bool
 	needsStdImport        bool
 	needsFmtImport        bool
+	needsStrconvImport    bool // a lowered fmt.Sprintf formats numbers or bools with strconv
+	loweredSprintf        bool // a fmt.Sprintf call was lowered, so fmt may have become unused
 	activeTypeParams      map[string]bool
 	structFields          map[string][]string
 	structFieldTypes      map[string]map[string]transpiler.Type // structName -> fieldName -> typeName
@@ -32,10 +35,31 @@ type galaASTTransformer struct {
 	companionObjects      map[string]*transpiler.CompanionObjectMetadata // companion name -> metadata
 	importManager         *ImportManager                                 // unified import tracking
 	tempVarCount          int
+	placeholders          []string // parameter names of the enclosing `_` lambdas, innermost last
 	inferer               *infer.Inferer
-	currentFuncReturnType transpiler.Type // return type of the function currently being transformed
-	filePath              string           // source file path (for error reporting)
-	sourceLines           []string         // source lines (for error snippets)
+	currentFuncReturnType transpiler.Type                 // return type of the function currently being transformed
+	filePath              string                          // source file path (for error reporting)
+	sourceLines           []string                        // source lines (for error snippets)
+	goVersion             transpiler.GoVersion            // target Go release; zero means newest
+	warnings              []galaerr.Warning               // non-fatal diagnostics of the current Transform
+	hotFuncs              map[string]bool                 // profiled hot functions as pkg.name, inlined like @inline ones
+	stackRemap            bool                            // register source maps and install std.RecoverPretty in main
+	funcLines             map[string]int                  // GALA declaration line of each generated function, for stackRemap
+	lineDirectives        bool                            // precede declarations and statements with line directives naming their GALA line
+	stmtLines             map[ast.Stmt]int                // GALA line of each statement transformed from a block, for lineDirectives
+	importPath            string                          // import path of the package, when known
+	tracedImports         map[string]bool                 // packages @traced functions use that the file does not import
+	erasedTypeArgs        map[*ast.CallExpr]erasedTypeArg // generic method calls whose type argument fell back to any
+	fuse                  bool                            // fuse chains of Array combinators into loops (-O)
+	arrayStages           map[*ast.CallExpr]arrayStage    // Array combinator calls a fused chain can be made of
+	immutableRefs         bool                            // hold immutable fields of large struct types by reference (-O)
+	internLiterals        bool                            // hoist constant values built in loops into package vars (-O)
+	cse                   bool                            // hoist repeated pure calls into temps (-O)
+	stdGets               map[*ast.CallExpr]bool          // Get() calls unwrapping a std Option, Immutable or Try, which are pure
+	hasAliases            bool                            // a type of typeMetas is an alias declared as type X = T
+	escapedIdents         map[*ast.Ident]string           // identifiers renamed because they are Go keywords, to the GALA name
+	symbols               symbolIndex                     // qualified names of imported symbols by simple name
+	ambiguity             string                          // first ambiguous name met in the current declaration, as an error message
 }
 
 // NewGalaASTTransformer creates a new instance of ASTTransformer for GALA.
@@ -52,14 +76,35 @@ func NewGalaASTTransformer() transpiler.ASTTransformer {
 		companionObjects:  make(map[string]*transpiler.CompanionObjectMetadata),
 		importManager:     NewImportManager(),
 		inferer:           infer.NewInferer(),
+		stdGets:           make(map[*ast.CallExpr]bool),
 	}
 }
 
+// NewGalaASTTransformerWithTarget creates an ASTTransformer that only emits Go features
+// available in the given target release, lowering newer constructs where possible.
+func NewGalaASTTransformerWithTarget(target transpiler.GoVersion) transpiler.ASTTransformer {
+	t := NewGalaASTTransformer().(*galaASTTransformer)
+	t.goVersion = target
+	return t
+}
+
+// NewGalaASTTransformerWithProfile creates an ASTTransformer for the target release
+// that also inlines the functions a CPU profile found hot. hot holds Go function
+// names qualified with the package name, e.g. main.square; functions that cannot
+// be inlined are left alone.
+func NewGalaASTTransformerWithProfile(target transpiler.GoVersion, hot map[string]bool) transpiler.ASTTransformer {
+	t := NewGalaASTTransformerWithTarget(target).(*galaASTTransformer)
+	t.hotFuncs = hot
+	return t
+}
+
 func (t *galaASTTransformer) Transform(richAST *transpiler.RichAST) (fset *token.FileSet, file *ast.File, err error) {
+	// Errors raised by panicking point at the declaration being transformed
+	var decl antlr.ParserRuleContext
 	defer func() {
 		if r := recover(); r != nil {
 			if semErr, ok := r.(*galaerr.SemanticError); ok {
-				err = semErr
+				err = t.locate(decl, semErr)
 			} else {
 				panic(r)
 			}
@@ -69,6 +114,8 @@ func (t *galaASTTransformer) Transform(richAST *transpiler.RichAST) (fset *token
 	t.currentScope = nil
 	t.needsStdImport = false
 	t.needsFmtImport = false
+	t.needsStrconvImport = false
+	t.loweredSprintf = false
 	t.immutFields = make(map[string]bool)
 	t.structImmutFields = make(map[string][]bool)
 	t.activeTypeParams = make(map[string]bool)
@@ -77,12 +124,26 @@ func (t *galaASTTransformer) Transform(richAST *transpiler.RichAST) (fset *token
 	t.genericMethods = make(map[string]map[string]bool)
 	t.functions = richAST.Functions
 	t.typeMetas = richAST.Types
+	t.hasAliases = false
+	for _, meta := range t.typeMetas {
+		t.hasAliases = t.hasAliases || meta.IsAlias
+	}
 	t.companionObjects = richAST.CompanionObjects
 	if t.companionObjects == nil {
 		t.companionObjects = make(map[string]*transpiler.CompanionObjectMetadata)
 	}
 	t.importManager = NewImportManager()
 	t.tempVarCount = 0
+	t.warnings = nil
+	t.funcLines = make(map[string]int)
+	t.stmtLines = make(map[ast.Stmt]int)
+	t.escapedIdents = make(map[*ast.Ident]string)
+	t.ambiguity = ""
+	t.tracedImports = make(map[string]bool)
+	t.erasedTypeArgs = make(map[*ast.CallExpr]erasedTypeArg)
+	t.arrayStages = make(map[*ast.CallExpr]arrayStage)
+	t.stdGets = make(map[*ast.CallExpr]bool)
+	t.importPath = richAST.ImportPath
 	t.filePath = richAST.FilePath
 	if richAST.SourceContent != "" {
 		t.sourceLines = strings.Split(richAST.SourceContent, "\n")
@@ -92,6 +153,7 @@ func (t *galaASTTransformer) Transform(richAST *transpiler.RichAST) (fset *token
 
 	// Populate imports from richAST.Packages (includes implicit std import from analyzer)
 	t.importManager.AddFromPackages(richAST.Packages)
+	prelude := t.registerPrelude(richAST)
 
 	// Populate metadata from RichAST
 	for typeName, meta := range richAST.Types {
@@ -107,6 +169,7 @@ func (t *galaASTTransformer) Transform(richAST *transpiler.RichAST) (fset *token
 			}
 		}
 	}
+	t.indexSymbols()
 
 	t.pushScope() // Global scope
 	defer t.popScope()
@@ -142,16 +205,85 @@ func (t *galaASTTransformer) Transform(richAST *transpiler.RichAST) (fset *token
 		return nil, nil, err
 	}
 
+	var docs []pendingDoc
+	origins := make(map[ast.Decl]antlr.ParserRuleContext)
+	prevStopLine := 0
 	for _, topDeclCtx := range sourceFile.AllTopLevelDeclaration() {
+		decl = topDeclCtx
 		decls, err := t.transformTopLevelDeclaration(topDeclCtx)
+		if t.ambiguity != "" {
+			// The ambiguity is the cause of any error it led to
+			return nil, nil, t.semanticErrorAt(topDeclCtx, t.ambiguity).WithCode(galaerr.CodeAmbiguousName)
+		}
 		if err != nil {
 			return nil, nil, err
 		}
-		if decls != nil {
+		if err := t.applyTailrec(topDeclCtx, decls); err != nil {
+			return nil, nil, err
+		}
+		t.applyTraced(topDeclCtx, decls)
+		t.escapeGoKeywords(decls)
+		if len(decls) > 0 {
+			// Keep the source's blank-line grouping between declarations
+			if prevStopLine > 0 && t.blankLineBetween(prevStopLine, topDeclCtx.GetStart().GetLine()) {
+				markNewSection(decls[0])
+			}
 			file.Decls = append(file.Decls, decls...)
+			for _, d := range decls {
+				origins[d] = topDeclCtx
+			}
+			t.recordSourceLines(decls, topDeclCtx.GetStart().GetLine())
+			if lines := deprecationDoc(topDeclCtx, t.docCommentLines(topDeclCtx)); len(lines) > 0 {
+				docs = append(docs, pendingDoc{decl: decls[0], lines: lines})
+			}
+			docs = t.addLineDirectives(docs, decls, topDeclCtx.GetStart().GetLine())
 		}
+		prevStopLine = topDeclCtx.GetStop().GetLine()
 	}
 
+	if err := t.checkNameCollisions(file, origins); err != nil {
+		return nil, nil, err
+	}
+	// Initialize package-level vals after the ones they depend on
+	if err := t.orderPackageVars(file, origins); err != nil {
+		return nil, nil, err
+	}
+	// attachDocs positions the doc comments in the order of the declarations
+	index := make(map[ast.Decl]int, len(file.Decls))
+	for i, d := range file.Decls {
+		index[d] = i
+	}
+	sort.SliceStable(docs, func(i, j int) bool { return index[docs[i].decl] < index[docs[j].decl] })
+
+	// Substitute the bodies of @inline functions at their call sites
+	t.inlineFunctions(file.Decls)
+
+	// Turn chains of Array combinators into single loops
+	t.fuseArrayChains(file.Decls)
+
+	// Hold the immutable fields of large structs by reference
+	t.useImmutableRefs(file.Decls)
+
+	// Hoist repeated pure subexpressions into temps
+	t.eliminateCommonSubexpressions(file.Decls)
+
+	// Build constant values used in loops once, at package level
+	t.internConstantLiterals(file)
+
+	// Register where generated functions come from, for panic traces
+	t.emitStackRemapping(fset, file)
+
+	// Carry GALA doc comments over to the generated declarations
+	t.attachDocs(fset, docs)
+
+	// Point the statements of function bodies at their GALA lines
+	t.addStatementDirectives(file)
+
+	// Qualify the symbols of selective imports with their package
+	t.qualifySelectedSymbols(file)
+
+	t.addPreludeImports(file, richAST, prelude)
+
 	if t.needsStdImport && t.packageName != registry.StdPackageName {
 		// Check if std is already imported (e.g., as a dot import)
 		stdAlreadyImported := t.importManager.IsDotImported(registry.StdPackageName)
@@ -192,6 +324,32 @@ func (t *galaASTTransformer) Transform(richAST *transpiler.RichAST) (fset *token
 		}
 	}
 
+	if t.needsStrconvImport {
+		importDecl := &ast.GenDecl{
+			Tok:   token.IMPORT,
+			Specs: []ast.Spec{&ast.ImportSpec{Path: &ast.BasicLit{Kind: token.STRING, Value: "\"strconv\""}}},
+		}
+		file.Decls = append([]ast.Decl{importDecl}, file.Decls...)
+	}
+	if t.loweredSprintf {
+		dropUnusedImport(file, "fmt")
+	}
+	tracedPaths := make([]string, 0, len(t.tracedImports))
+	for path := range t.tracedImports {
+		tracedPaths = append(tracedPaths, path)
+	}
+	sort.Strings(tracedPaths)
+	for _, path := range tracedPaths {
+		importDecl := &ast.GenDecl{
+			Tok:   token.IMPORT,
+			Specs: []ast.Spec{&ast.ImportSpec{Path: &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(path)}}},
+		}
+		file.Decls = append([]ast.Decl{importDecl}, file.Decls...)
+	}
+
+	// Merge imports into one block grouped as std, external and GALA packages
+	t.groupImports(fset, file, richAST.Packages)
+
 	return fset, file, nil
 }
 
@@ -276,7 +434,7 @@ func (t *galaASTTransformer) checkDotImportClashes(richAST *transpiler.RichAST)
 
 	if len(clashes) > 0 {
 		msg := "dot-import symbol collision(s) detected:\n" + strings.Join(clashes, "\n") + "\nUse an aliased import for one of the packages to resolve the conflict."
-		return galaerr.NewSemanticError(msg)
+		return galaerr.NewSemanticError(msg).WithCode(galaerr.CodeDotImportClash)
 	}
 	return nil
 }
@@ -291,6 +449,16 @@ func (t *galaASTTransformer) semanticErrorAt(ctx antlr.ParserRuleContext, msg st
 	return galaerr.NewSemanticError(msg)
 }
 
+// locate gives err the position of ctx if it has none. Errors pass through the
+// transformation of each enclosing expression, statement and declaration, so
+// the innermost of them sets it.
+func (t *galaASTTransformer) locate(ctx antlr.ParserRuleContext, err error) error {
+	if err == nil || ctx == nil || ctx.GetStart() == nil {
+		return err
+	}
+	return galaerr.Locate(err, t.filePath, ctx.GetStart().GetLine(), ctx.GetStart().GetColumn())
+}
+
 var _ transpiler.ASTTransformer = (*galaASTTransformer)(nil)
 
 // resolveTypeName is a unified type resolution function that searches for a type name
@@ -301,10 +469,10 @@ var _ transpiler.ASTTransformer = (*galaASTTransformer)(nil)
 //  1. Exact match
 //  2. If name has package prefix: try replacing prefix with std/current/imported packages
 //     (but NOT for external Go packages like "time", "fmt", etc.)
-//  3. Try current package prefix
-//  4. Try std package prefix
-//  5. Try all explicitly imported packages (non-dot)
-//  6. Try dot-imported packages
+//  3. Try std package prefix
+//  4. Try current package prefix
+//  5. Try the imported packages declaring the name, found in the symbol index;
+//     dot imports come first, and a name several of them declare is ambiguous
 //
 // Returns the resolved name and whether resolution succeeded.
 func (t *galaASTTransformer) resolveTypeName(typeName string, exists func(string) bool) (string, bool) {
@@ -323,30 +491,18 @@ func (t *galaASTTransformer) resolveTypeName(typeName string, exists func(string
 		// If so, don't try to resolve the simple name to GALA types - external types
 		// like time.Duration should not be confused with GALA's Duration type
 		isExternalPackage := false
-		for _, entry := range t.importManager.All() {
-			// Get the alias used in code (e.g., "time" for import "time")
-			alias := entry.Alias
-			if alias == "" {
-				// Extract last component from import path (e.g., "time" from "time")
-				if lastSlash := strings.LastIndex(entry.Path, "/"); lastSlash != -1 {
-					alias = entry.Path[lastSlash+1:]
-				} else {
-					alias = entry.Path
-				}
-			}
-			if alias == pkgPrefix && !entry.IsDot {
-				// Check if it's a GALA package by looking at the import path
-				// GALA packages typically have paths containing "/gala/"
-				if !strings.Contains(entry.Path, "/gala/") {
-					isExternalPackage = true
-					break
-				}
-			}
+		if entry, ok := t.importManager.GetByAlias(pkgPrefix); ok {
+			// GALA packages have paths containing "/gala/"
+			isExternalPackage = !strings.Contains(entry.Path, "/gala/")
 		}
 
-		// Only try to resolve the simple name if it's not from an external package
+		// Only try to resolve the simple name if it's not from an external package.
+		// The name is qualified, so other packages declaring it are no ambiguity
 		if !isExternalPackage {
-			if resolved, found := t.tryResolveSimpleName(simpleName, exists); found {
+			ambiguity := t.ambiguity
+			resolved, found := t.tryResolveSimpleName(simpleName, exists)
+			t.ambiguity = ambiguity
+			if found {
 				return resolved, true
 			}
 		}
@@ -368,6 +524,13 @@ func (t *galaASTTransformer) tryResolveSimpleName(name string, exists func(strin
 		return name, true
 	}
 
+	// Names listed in a selective import stand for a symbol of that package
+	if entry, symbol, ok := t.importManager.Selected(name); ok {
+		if fullName := entry.PkgName + "." + symbol; exists(fullName) {
+			return fullName, true
+		}
+	}
+
 	// Try std package for standard library types like Tuple, Option, etc.
 	if stdName := registry.StdPackageName + "." + name; exists(stdName) {
 		return stdName, true
@@ -380,27 +543,9 @@ func (t *galaASTTransformer) tryResolveSimpleName(name string, exists func(strin
 		}
 	}
 
-	// Try imported packages (dot imports first — they bring names into the current scope,
-	// so they take precedence over non-dot imports for unqualified name resolution)
-	for _, entry := range t.importManager.All() {
-		if !entry.IsDot {
-			continue
-		}
-		if fullName := entry.PkgName + "." + name; exists(fullName) {
-			return fullName, true
-		}
-	}
-
-	for _, entry := range t.importManager.All() {
-		if entry.IsDot {
-			continue
-		}
-		if fullName := entry.PkgName + "." + name; exists(fullName) {
-			return fullName, true
-		}
-	}
-
-	return "", false
+	// Try imported packages, dot imports first: they bring names into the
+	// current scope, so they take precedence over non-dot imports
+	return t.resolveImportedSymbol(name, exists)
 }
 
 // resolveStructTypeName resolves a type name to the key used in structFields/structImmutFields maps.
@@ -1,20 +1,23 @@
 package transformer
 
 import (
+	"fmt"
 	"go/ast"
 	"go/token"
 	"strings"
 
+	"github.com/antlr4-go/antlr/v4"
+
 	"martianoff/gala/galaerr"
 	"martianoff/gala/internal/transpiler"
 	"martianoff/gala/internal/transpiler/registry"
 )
 
 // This file contains type inference logic extracted from types.go
-// Functions: getExprTypeNameManual, resolveType, substituteConcreteTypes, inferMethodTypeParamsFromArgs,
-//            inferFuncTypeParamsFromArgs, unifyForInference, substituteInType, isTupleTypeName,
+// Functions: getExprTypeNameManual, binaryOperandType, untypedConstKind, resolveType,
+//            substituteConcreteTypes, inferMethodTypeParamsFromArgs, inferFuncTypeParamsFromArgs, unifyForInference, substituteInType, isTupleTypeName,
 //            hasTupleTypePrefix, getTupleTypeFromName, getReceiverTypeArgs, getReceiverTypeArgStrings,
-//            exprToTypeString, substituteTranspilerTypeParams
+//            exprToTypeString, substituteTranspilerTypeParams, noteErasedTypeArg, checkErasedTypeArg
 
 func (t *galaASTTransformer) getExprTypeNameManual(expr ast.Expr) transpiler.Type {
 	if expr == nil {
@@ -77,8 +80,10 @@ func (t *galaASTTransformer) getExprTypeNameManual(expr ast.Expr) transpiler.Typ
 		switch e.Op {
 		case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ, token.LAND, token.LOR:
 			return transpiler.BasicType{Name: "bool"}
-		default:
+		case token.SHL, token.SHR:
 			return t.getExprTypeNameManual(e.X)
+		default:
+			return t.binaryOperandType(e.X, e.Y)
 		}
 	case *ast.SelectorExpr:
 		xType := t.getExprTypeNameManual(e.X)
@@ -257,7 +262,7 @@ func (t *galaASTTransformer) getExprTypeNameManual(expr ast.Expr) transpiler.Typ
 				if len(e.Args) > 0 {
 					innerType := t.getExprTypeNameManual(e.Args[0])
 					if t.isImmutableType(innerType) {
-						panic(galaerr.NewSemanticError("recursive Immutable wrapping is not allowed"))
+						panic(galaerr.NewSemanticError("recursive Immutable wrapping is not allowed").WithCode(galaerr.CodeNestedImmutable))
 					}
 					return transpiler.GenericType{
 						Base:   transpiler.NamedType{Package: registry.StdPackageName, Name: transpiler.TypeImmutable},
@@ -491,7 +496,7 @@ func (t *galaASTTransformer) getExprTypeNameManual(expr ast.Expr) transpiler.Typ
 				if len(e.Args) > 0 {
 					innerType := t.getExprTypeNameManual(e.Args[0])
 					if t.isImmutableType(innerType) {
-						panic(galaerr.NewSemanticError("recursive Immutable wrapping is not allowed"))
+						panic(galaerr.NewSemanticError("recursive Immutable wrapping is not allowed").WithCode(galaerr.CodeNestedImmutable))
 					}
 					return transpiler.GenericType{
 						Base:   transpiler.NamedType{Package: registry.StdPackageName, Name: transpiler.TypeImmutable},
@@ -557,6 +562,18 @@ func (t *galaASTTransformer) getExprTypeNameManual(expr ast.Expr) transpiler.Typ
 			if _, ok := t.structFields[id.Name]; ok {
 				return transpiler.BasicType{Name: id.Name}
 			}
+			// Dollars(5) converts to a type defined as another, e.g. type Dollars int,
+			// and UserId("u1") to the type an alias stands for
+			if meta, resolved := t.getTypeMetaResolved(id.Name); meta != nil && meta.AliasOf != nil {
+				var typ transpiler.Type = transpiler.ParseType(resolved)
+				if len(typeArgs) > 0 {
+					typ = transpiler.GenericType{Base: typ, Params: typeArgs}
+				}
+				if meta.IsAlias {
+					return transpiler.ExpandAliases(typ, t.getTypeMeta)
+				}
+				return typ
+			}
 			if fMeta := t.getFunction(id.Name); fMeta != nil {
 				// Substitute type arguments if the function is generic
 				if len(typeArgs) > 0 && len(fMeta.TypeParams) > 0 {
@@ -675,6 +692,63 @@ func (t *galaASTTransformer) getExprTypeNameManual(expr ast.Expr) transpiler.Typ
 	return transpiler.NilType{}
 }
 
+// binaryOperandType returns the type of an arithmetic expression. An untyped
+// constant operand takes the type of the other operand, as in Go; between two
+// constants the later kind in int < rune < float < complex wins, so 'a' + 1 is
+// a rune rather than an int.
+func (t *galaASTTransformer) binaryOperandType(x, y ast.Expr) transpiler.Type {
+	xType := t.getExprTypeNameManual(x)
+	xKind, xConst := untypedConstKind(x)
+	yKind, yConst := untypedConstKind(y)
+	switch {
+	case xConst && yConst:
+		if yKind > xKind {
+			return t.getExprTypeNameManual(y)
+		}
+	case xConst:
+		if yType := t.getExprTypeNameManual(y); !yType.IsNil() {
+			return yType
+		}
+	}
+	return xType
+}
+
+// untypedConstKind reports whether expr is an untyped constant expression built
+// from literals, and ranks its kind for mixing with other constants.
+func untypedConstKind(expr ast.Expr) (int, bool) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		switch e.Kind {
+		case token.INT:
+			return 0, true
+		case token.CHAR:
+			return 1, true
+		case token.FLOAT:
+			return 2, true
+		case token.IMAG:
+			return 3, true
+		case token.STRING:
+			return 0, true
+		}
+	case *ast.ParenExpr:
+		return untypedConstKind(e.X)
+	case *ast.UnaryExpr:
+		if e.Op == token.SUB || e.Op == token.ADD || e.Op == token.XOR {
+			return untypedConstKind(e.X)
+		}
+	case *ast.BinaryExpr:
+		xKind, xConst := untypedConstKind(e.X)
+		if e.Op == token.SHL || e.Op == token.SHR {
+			return xKind, xConst
+		}
+		yKind, yConst := untypedConstKind(e.Y)
+		if xConst && yConst {
+			return max(xKind, yKind), true
+		}
+	}
+	return 0, false
+}
+
 func (t *galaASTTransformer) resolveType(name string) transpiler.Type {
 	if name == "" {
 		return transpiler.NilType{}
@@ -844,6 +918,10 @@ func (t *galaASTTransformer) unifyForInference(pattern, concrete transpiler.Type
 	// Check if both are function types
 	patternFunc, patternIsFunc := pattern.(transpiler.FuncType)
 	concreteFunc, concreteIsFunc := concrete.(transpiler.FuncType)
+	if patternIsFunc && patternFunc.ByName && len(patternFunc.Results) == 1 && !(concreteIsFunc && len(concreteFunc.Params) == 0) {
+		// The argument of a by-name parameter has the result type
+		return t.unifyForInference(patternFunc.Results[0], concrete, typeParams, inferredMap)
+	}
 	if patternIsFunc && concreteIsFunc {
 		// Try to unify result types
 		// This handles cases like func(T) Try[U] with func(User) Try[User]
@@ -926,7 +1004,7 @@ func (t *galaASTTransformer) substituteInType(typ transpiler.Type, paramMap map[
 		for i, r := range v.Results {
 			newResults[i] = t.substituteInType(r, paramMap)
 		}
-		return transpiler.FuncType{Params: newParams, Results: newResults}
+		return transpiler.FuncType{Params: newParams, Results: newResults, ByName: v.ByName}
 	default:
 		return typ
 	}
@@ -1081,7 +1159,7 @@ func (t *galaASTTransformer) substituteTranspilerTypeParams(typ transpiler.Type,
 		for i, r := range ty.Results {
 			newResults[i] = t.substituteTranspilerTypeParams(r, subst)
 		}
-		return transpiler.FuncType{Params: newParams, Results: newResults}
+		return transpiler.FuncType{Params: newParams, Results: newResults, ByName: ty.ByName}
 	case transpiler.MapType:
 		return transpiler.MapType{
 			Key:  t.substituteTranspilerTypeParams(ty.Key, subst),
@@ -1135,3 +1213,70 @@ func getKnownGoStdlibReturnType(fullName string) transpiler.Type {
 	}
 	return nil
 }
+
+// erasedTypeArg records a generic method call like opt.Map((x) => f(x)) whose
+// type parameter could only be inferred from a lambda result the transformer
+// does not know, so it fell back to any.
+type erasedTypeArg struct {
+	method     string
+	typeParam  string          // the method type parameter that became any
+	typeParams []string        // all type parameters of the method
+	result     transpiler.Type // method result type with the known type arguments substituted
+}
+
+// noteErasedTypeArg records call in t.erasedTypeArgs when one of its lambda
+// arguments returns any and the method infers a defaulted type parameter from
+// that lambda's result.
+func (t *galaASTTransformer) noteErasedTypeArg(call *ast.CallExpr, method string, methodMeta *transpiler.MethodMetadata, typeSubst map[string]string, defaulted map[string]bool, args []ast.Expr) {
+	for i, arg := range args {
+		lit, ok := arg.(*ast.FuncLit)
+		if !ok || i >= len(methodMeta.ParamTypes) || lit.Type.Results == nil || len(lit.Type.Results.List) != 1 {
+			continue
+		}
+		if id, ok := lit.Type.Results.List[0].Type.(*ast.Ident); !ok || id.Name != "any" {
+			continue
+		}
+		fn, ok := methodMeta.ParamTypes[i].(transpiler.FuncType)
+		if !ok || len(fn.Results) != 1 || !defaulted[fn.Results[0].String()] {
+			continue
+		}
+		known := make(map[string]string)
+		for tp, arg := range typeSubst {
+			if !defaulted[tp] {
+				known[tp] = arg
+			}
+		}
+		t.erasedTypeArgs[call] = erasedTypeArg{
+			method:     method,
+			typeParam:  fn.Results[0].String(),
+			typeParams: methodMeta.TypeParams,
+			result:     t.substituteTranspilerTypeParams(methodMeta.ReturnType, known),
+		}
+		return
+	}
+}
+
+// checkErasedTypeArg rejects expr where a value of type expected is wanted if
+// expr is a call noted by noteErasedTypeArg and expected pins the erased type
+// parameter to a concrete type. Go would otherwise report a mismatch between
+// Option[any] and Option[string] in the generated code; the error names the
+// type argument to write instead.
+func (t *galaASTTransformer) checkErasedTypeArg(ctx antlr.ParserRuleContext, expr ast.Expr, expected transpiler.Type) error {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok || expected == nil || expected.IsNil() {
+		return nil
+	}
+	erased, ok := t.erasedTypeArgs[call]
+	if !ok {
+		return nil
+	}
+	inferred := make(map[string]transpiler.Type)
+	t.unifyForInference(erased.result, expected, erased.typeParams, inferred)
+	want, ok := inferred[erased.typeParam]
+	if !ok || want.IsNil() || want.IsAny() || t.hasTypeParams(want) {
+		return nil
+	}
+	msg := fmt.Sprintf("cannot infer type parameter %s of %s: the lambda's result type is unknown, so it would be any; add an explicit type parameter: .%s[%s](...)",
+		erased.typeParam, erased.method, erased.method, want)
+	return t.semanticErrorAt(ctx, msg).WithCode(galaerr.CodeLambdaTypeInfer)
+}
@@ -1,6 +1,7 @@
 package transformer_test
 
 import (
+	"martianoff/gala/galaerr"
 	"martianoff/gala/internal/transpiler"
 	"martianoff/gala/internal/transpiler/analyzer"
 	"martianoff/gala/internal/transpiler/generator"
@@ -121,3 +122,79 @@ func main() {
 		})
 	}
 }
+
+func TestErasedLambdaTypeArgument(t *testing.T) {
+	p := transpiler.NewAntlrGalaParser()
+	a := analyzer.NewGalaAnalyzer(p, getStdSearchPath())
+	trans := transpiler.NewGalaToGoTranspiler(p, a, transformer.NewGalaASTTransformer(), generator.NewGoCodeGenerator())
+
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{
+			name: "Explicit val type",
+			input: `package main
+
+func main() {
+    val id = Some(1)
+    val name Option[string] = id.Map((i) => lookup(i))
+}`,
+			wantErr: "add an explicit type parameter: .Map[string](...)",
+		},
+		{
+			name: "Function result",
+			input: `package main
+
+func name(id Option[int]) Option[string] = id.Map((i) => lookup(i))`,
+			wantErr: "add an explicit type parameter: .Map[string](...)",
+		},
+		{
+			name: "Function argument",
+			input: `package main
+
+func show(name Option[string]) string = name.GetOrElse("?")
+
+func main() {
+    val id = Some(1)
+    println(show(id.Map((i) => lookup(i))))
+}`,
+			wantErr: "add an explicit type parameter: .Map[string](...)",
+		},
+		{
+			name: "Explicit type parameter",
+			input: `package main
+
+func name(id Option[int]) Option[string] = id.Map[string]((i) => lookup(i))`,
+		},
+		{
+			name: "Known lambda result",
+			input: `package main
+
+func name(id Option[int]) Option[string] = id.Map((i) => "user")`,
+		},
+		{
+			name: "No concrete target",
+			input: `package main
+
+func main() {
+    val id = Some(1)
+    val name = id.Map((i) => lookup(i))
+    println(name)
+}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := trans.Transpile(tt.input, "")
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+			assert.Equal(t, galaerr.CodeLambdaTypeInfer, galaerr.CodeOf(err))
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package transformer
+
+import (
+	"go/ast"
+	"go/token"
+
+	"martianoff/gala/internal/parser/grammar"
+	"martianoff/gala/internal/transpiler"
+)
+
+// A match on a value of a GALA interface type whose cases each name a
+// concrete type, by a typed pattern or a struct pattern, dispatches with one
+// Go type switch instead of testing the cases one by one:
+//
+//	func area(s Shape) float64 = s match {
+//		case Circle(r) => 3.14 * r * r
+//		case sq: Square => sq.Side * sq.Side
+//		case _ => 0.0
+//	}
+//
+// becomes
+//
+//	switch s := s.(type) {
+//	case Circle:
+//		r := s.Radius.Get()
+//		...
+//	case Square:
+//		sq := s
+//		...
+//	}
+//	return 0.0
+//
+// Each case runs the clauses of its type in source order, guards included;
+// when none of them matches, control leaves the switch for the default body.
+
+// typeSwitchCase is the concrete type a case clause of such a match handles.
+type typeSwitchCase struct {
+	typ  transpiler.Type
+	expr ast.Expr // the type as written in the generated case
+}
+
+// typeSwitchCases returns the type each non-default case clause of the match
+// ctx handles, in order, or nil when the match is not dispatched by type.
+func (t *galaASTTransformer) typeSwitchCases(ctx grammar.IExpressionContext, matchedType transpiler.Type) []typeSwitchCase {
+	if matchedType == nil || matchedType.IsNil() {
+		return nil
+	}
+	iface := t.getTypeMeta(matchedType.BaseName())
+	if iface == nil || !iface.IsInterface {
+		return nil
+	}
+	var cases []typeSwitchCase
+	for i := 3; i < ctx.GetChildCount()-1; i++ {
+		ccCtx, ok := ctx.GetChild(i).(*grammar.CaseClauseContext)
+		if !ok || isWildcard(ccCtx.Pattern().GetText()) {
+			continue
+		}
+		c, ok := t.typeSwitchCase(ccCtx.Pattern(), iface)
+		if !ok {
+			return nil
+		}
+		cases = append(cases, c)
+	}
+	// A single case costs one type assertion either way
+	if len(cases) < 2 {
+		return nil
+	}
+	return cases
+}
+
+// typeSwitchCase returns the type pattern patCtx matches when it only matches
+// values of one concrete type implementing iface, as x: T and T(...) do for
+// a struct T without an Unapply method of its own.
+func (t *galaASTTransformer) typeSwitchCase(patCtx grammar.IPatternContext, iface *transpiler.TypeMetadata) (typeSwitchCase, bool) {
+	var typeExpr ast.Expr
+	switch p := patCtx.(type) {
+	case *grammar.TypedPatternContext:
+		expr, err := t.transformType(p.Type_())
+		if err != nil {
+			return typeSwitchCase{}, false
+		}
+		typeExpr = expr
+	case *grammar.ExpressionPatternContext:
+		primary, argList, typeArgs := t.getCallPatternWithTypeArgsFromExpression(p.Expression())
+		if primary == nil || (typeArgs != nil && len(typeArgs.AllExpression()) > 0) || t.hasRestPattern(argList) {
+			return typeSwitchCase{}, false
+		}
+		expr, err := t.transformPrimaryExpr(primary)
+		if err != nil {
+			return typeSwitchCase{}, false
+		}
+		name := t.getBaseTypeName(expr)
+		fields, ok := t.structFields[t.resolveStructTypeName(name)]
+		if !ok || t.extractorFunction(name) != nil {
+			return typeSwitchCase{}, false
+		}
+		if argList != nil && len(argList.AllArgument()) > len(fields) {
+			return typeSwitchCase{}, false
+		}
+		if meta := t.getTypeMeta(name); meta != nil && meta.Methods["Unapply"] != nil {
+			return typeSwitchCase{}, false
+		}
+		typeExpr = expr
+	default:
+		return typeSwitchCase{}, false
+	}
+
+	switch typeExpr.(type) {
+	case *ast.Ident, *ast.SelectorExpr:
+	default:
+		return typeSwitchCase{}, false
+	}
+	name := t.getBaseTypeName(typeExpr)
+	meta := t.getTypeMeta(name)
+	if meta == nil || meta.IsInterface || meta.IsSealed || len(meta.TypeParams) > 0 {
+		return typeSwitchCase{}, false
+	}
+	// Go rejects a case for a type that cannot implement the interface
+	for method := range iface.Methods {
+		if _, ok := meta.Methods[method]; !ok {
+			return typeSwitchCase{}, false
+		}
+	}
+	return typeSwitchCase{typ: t.resolveType(name), expr: typeExpr}, true
+}
+
+// bindSwitchedSubject binds the variable of a typed pattern to the subject of
+// a type switch case, which already has the pattern's type.
+func (t *galaASTTransformer) bindSwitchedSubject(ctx *grammar.TypedPatternContext, paramName string, typ transpiler.Type) (ast.Expr, []ast.Stmt) {
+	name := ctx.Identifier().GetText()
+	if name == "_" {
+		return ast.NewIdent("true"), nil
+	}
+	t.addVar(name, typ)
+	return ast.NewIdent("true"), []ast.Stmt{&ast.AssignStmt{
+		Lhs: []ast.Expr{ast.NewIdent(name)},
+		Tok: token.DEFINE,
+		Rhs: []ast.Expr{ast.NewIdent(paramName)},
+	}}
+}
+
+// buildTypeSwitchBody is buildMatchBody for a match dispatched by type: the
+// clauses, one per case, are grouped by type into the cases of a switch on
+// paramName, followed by the default body.
+func (t *galaASTTransformer) buildTypeSwitchBody(paramName string, cases []typeSwitchCase, clauses []ast.Stmt, defaultBody []ast.Stmt, resultType transpiler.Type) []ast.Stmt {
+	_, isVoid := resultType.(transpiler.VoidType)
+	var order []string
+	groups := make(map[string][]ast.Stmt)
+	caseExprs := make(map[string]ast.Expr)
+	for i, clause := range clauses {
+		key := cases[i].typ.String()
+		if _, seen := groups[key]; !seen {
+			order = append(order, key)
+			caseExprs[key] = cases[i].expr
+		}
+		// A matching clause must not fall out of the switch into the default
+		if leaf := findLeafIf(clause); isVoid && leaf != nil {
+			if n := len(leaf.Body.List); n == 0 || !isReturnStmt(leaf.Body.List[n-1]) {
+				leaf.Body.List = append(leaf.Body.List, &ast.ReturnStmt{})
+			}
+		}
+		groups[key] = append(groups[key], clause)
+	}
+
+	var caseClauses []ast.Stmt
+	var caseBodies []ast.Node
+	for _, key := range order {
+		body := t.buildMatchBody(groups[key], nil, resultType)
+		for _, stmt := range body {
+			caseBodies = append(caseBodies, stmt)
+		}
+		caseClauses = append(caseClauses, &ast.CaseClause{List: []ast.Expr{caseExprs[key]}, Body: body})
+	}
+	typeSwitch := &ast.TypeSwitchStmt{
+		Assign: &ast.ExprStmt{X: &ast.TypeAssertExpr{X: ast.NewIdent(paramName)}},
+		Body:   &ast.BlockStmt{List: caseClauses},
+	}
+	// The cases see the subject as a value of their type, unless none uses it
+	if collectReferencedIdents(caseBodies)[paramName] {
+		typeSwitch.Assign = &ast.AssignStmt{
+			Lhs: []ast.Expr{ast.NewIdent(paramName)},
+			Tok: token.DEFINE,
+			Rhs: []ast.Expr{&ast.TypeAssertExpr{X: ast.NewIdent(paramName)}},
+		}
+	}
+	return append([]ast.Stmt{typeSwitch}, t.buildMatchBody(nil, defaultBody, resultType)...)
+}
+
+// isReturnStmt reports whether stmt is a return statement.
+func isReturnStmt(stmt ast.Stmt) bool {
+	_, ok := stmt.(*ast.ReturnStmt)
+	return ok
+}
@@ -0,0 +1,145 @@
+package transformer_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"martianoff/gala/internal/transpiler"
+	"martianoff/gala/internal/transpiler/analyzer"
+	"martianoff/gala/internal/transpiler/generator"
+	"martianoff/gala/internal/transpiler/transformer"
+)
+
+const shapes = `package main
+
+import "fmt"
+
+type Shape interface {
+    Area() float64
+}
+
+struct Circle(Radius float64)
+struct Square(Side float64)
+struct Rect(Width float64, Height float64)
+
+func (c Circle) Area() float64 = 3.0 * c.Radius * c.Radius
+func (s Square) Area() float64 = s.Side * s.Side
+func (r Rect) Area() float64 = r.Width * r.Height
+`
+
+func TestInterfaceMatchTypeSwitch(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		contains    []string
+		notContains []string
+		matches     []string
+		switchOnce  []string // cases the switch on the subject has exactly once
+	}{
+		{
+			name: "struct and typed patterns",
+			input: shapes + `
+func describe(s Shape) string = s match {
+    case Circle(r) => fmt.Sprintf("circle %v", r)
+    case sq: Square => fmt.Sprintf("square %v", sq.Side)
+    case _ => "other"
+}
+`,
+			contains: []string{
+				"switch s := s.(type) {",
+				"case Circle:",
+				"r := s.Radius.Get()",
+				"case Square:",
+				"sq := s",
+				`return "other"`,
+			},
+			notContains: []string{"std.As[Square]"},
+		},
+		{
+			name: "clauses of one type share a case",
+			input: shapes + `
+func size(s Shape) string = s match {
+    case Circle(r) if r > 10.0 => "big circle"
+    case Rect(w, h) => fmt.Sprintf("%v x %v", w, h)
+    case Circle(_) => "circle"
+    case _ => "other"
+}
+`,
+			contains:   []string{"switch s := s.(type) {", "r > 10.0"},
+			switchOnce: []string{"case Circle:", "case Rect:"},
+		},
+		{
+			name: "subject unused by the cases",
+			input: shapes + `
+func kind(s Shape) string = s match {
+    case _: Circle => "circle"
+    case Square(_) => "square"
+    case _ => "other"
+}
+`,
+			contains: []string{"switch s.(type) {"},
+		},
+		{
+			name: "void match returns after a case",
+			input: shapes + `
+func show(s Shape) {
+    s match {
+        case c: Circle => fmt.Println(c.Radius)
+        case sq: Square => fmt.Println(sq.Side)
+        case _ => fmt.Println("other")
+    }
+}
+`,
+			matches: []string{`Println\(c\.Radius[^\n]*\)\s+return\s`, `Println\(sq\.Side[^\n]*\)\s+return\s`},
+		},
+		{
+			name: "binding pattern keeps the if chain",
+			input: shapes + `
+func describe(s Shape) string = s match {
+    case Circle(r) => fmt.Sprintf("circle %v", r)
+    case other => fmt.Sprintf("%v", other.Area())
+}
+`,
+			notContains: []string{"s.(type)"},
+		},
+		{
+			name: "matches on any keep the if chain",
+			input: shapes + `
+func describe(v any) string = v match {
+    case _: Circle => "circle"
+    case _: Square => "square"
+    case _ => "other"
+}
+`,
+			contains: []string{"std.As[Circle](v)", "std.As[Square](v)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := transpiler.NewAntlrGalaParser()
+			trans := transpiler.NewGalaToGoTranspiler(p, analyzer.NewGalaAnalyzer(p, getStdSearchPath()),
+				transformer.NewGalaASTTransformer(), generator.NewGoCodeGenerator())
+			got, err := trans.Transpile(tt.input, "")
+			assert.NoError(t, err)
+			for _, want := range tt.contains {
+				assert.Contains(t, got, want)
+			}
+			for _, unwanted := range tt.notContains {
+				assert.NotContains(t, got, unwanted)
+			}
+			for _, pattern := range tt.matches {
+				assert.Regexp(t, pattern, got)
+			}
+			if len(tt.switchOnce) > 0 {
+				// The subject's switch follows the generated Unapply methods
+				match := got[strings.Index(got, "switch s := s.(type)"):]
+				for _, want := range tt.switchOnce {
+					assert.Equal(t, 1, strings.Count(match, want), want)
+				}
+			}
+		})
+	}
+}
@@ -138,7 +138,10 @@ func (t *galaASTTransformer) getExprType(expr ast.Expr) ast.Expr {
 		case token.LOR, token.LAND, token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ:
 			return ast.NewIdent("bool")
 		default:
-			return t.getExprType(e.X)
+			// A constant left operand such as 1 in 1 + x takes its type from the right
+			if _, isConst := untypedConstKind(e.X); !isConst {
+				return t.getExprType(e.X)
+			}
 		}
 	case *ast.UnaryExpr:
 		if e.Op == token.NOT {
@@ -501,7 +504,17 @@ func (t *galaASTTransformer) typeArgToString(arg ast.Expr) string {
 	return ""
 }
 
+// exprToType returns the type expr denotes, seeing through type aliases.
 func (t *galaASTTransformer) exprToType(expr ast.Expr) transpiler.Type {
+	typ := t.exprToTypeAsWritten(expr)
+	if !t.hasAliases {
+		return typ
+	}
+	return transpiler.ExpandAliases(typ, t.getTypeMeta)
+}
+
+// exprToTypeAsWritten returns the type expr denotes, naming aliases as written.
+func (t *galaASTTransformer) exprToTypeAsWritten(expr ast.Expr) transpiler.Type {
 	if expr == nil {
 		return transpiler.NilType{}
 	}
@@ -520,27 +533,27 @@ func (t *galaASTTransformer) exprToType(expr ast.Expr) transpiler.Type {
 		}
 		return transpiler.NamedType{Package: x.Name, Name: e.Sel.Name}
 	case *ast.IndexExpr:
-		base := t.exprToType(e.X)
-		param := t.exprToType(e.Index)
+		base := t.exprToTypeAsWritten(e.X)
+		param := t.exprToTypeAsWritten(e.Index)
 		return transpiler.GenericType{Base: base, Params: []transpiler.Type{param}}
 	case *ast.IndexListExpr:
-		base := t.exprToType(e.X)
+		base := t.exprToTypeAsWritten(e.X)
 		params := make([]transpiler.Type, len(e.Indices))
 		for i, idx := range e.Indices {
-			params[i] = t.exprToType(idx)
+			params[i] = t.exprToTypeAsWritten(idx)
 		}
 		return transpiler.GenericType{Base: base, Params: params}
 	case *ast.StarExpr:
-		return transpiler.PointerType{Elem: t.exprToType(e.X)}
+		return transpiler.PointerType{Elem: t.exprToTypeAsWritten(e.X)}
 	case *ast.ArrayType:
-		return transpiler.ArrayType{Elem: t.exprToType(e.Elt)}
+		return transpiler.ArrayType{Elem: t.exprToTypeAsWritten(e.Elt)}
 	case *ast.FuncType:
 		// Handle function types like func(S) Option[Tuple[T, S]]
 		var params []transpiler.Type
 		var results []transpiler.Type
 		if e.Params != nil {
 			for _, field := range e.Params.List {
-				paramType := t.exprToType(field.Type)
+				paramType := t.exprToTypeAsWritten(field.Type)
 				// If there are multiple names, repeat the type for each
 				if len(field.Names) > 0 {
 					for range field.Names {
@@ -553,7 +566,7 @@ func (t *galaASTTransformer) exprToType(expr ast.Expr) transpiler.Type {
 		}
 		if e.Results != nil {
 			for _, field := range e.Results.List {
-				resultType := t.exprToType(field.Type)
+				resultType := t.exprToTypeAsWritten(field.Type)
 				if len(field.Names) > 0 {
 					for range field.Names {
 						results = append(results, resultType)
@@ -610,7 +623,7 @@ func (t *galaASTTransformer) isImmutableType(typ transpiler.Type) bool {
 		if gen, ok := typ.(transpiler.GenericType); ok {
 			for _, p := range gen.Params {
 				if t.isImmutableType(p) {
-					panic(galaerr.NewSemanticError("recursive Immutable wrapping is not allowed"))
+					panic(galaerr.NewSemanticError("recursive Immutable wrapping is not allowed").WithCode(galaerr.CodeNestedImmutable))
 				}
 			}
 		}
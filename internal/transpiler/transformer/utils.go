@@ -4,7 +4,10 @@ import (
 	"fmt"
 	"go/ast"
 	"martianoff/gala/internal/transpiler/registry"
+	"regexp"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 // stripStdPrefix removes the "std." prefix from a type/package name if present.
@@ -27,14 +30,40 @@ func isWildcard(text string) bool {
 	return text == "_"
 }
 
+// nextTempVar returns a fresh name for a compiler temporary. Temps are numbered
+// per top-level function, so editing one function does not renumber the temps
+// of every other one in committed generated code.
 func (t *galaASTTransformer) nextTempVar() string {
-	t.tempVarCount++
-	return fmt.Sprintf("_tmp_%d", t.tempVarCount)
+	return t.nextNamedTempVar("tmp")
+}
+
+// nextNamedTempVar is nextTempVar with a name derived from hint, the extractor
+// or type a pattern temp belongs to: Some gives _some_0, std.Left[int] _left_1.
+func (t *galaASTTransformer) nextNamedTempVar(hint string) string {
+	if i := strings.IndexByte(hint, '['); i >= 0 {
+		hint = hint[:i]
+	}
+	hint = strings.TrimLeft(hint[strings.LastIndexByte(hint, '.')+1:], "*")
+	if hint == "" {
+		hint = "tmp"
+	}
+	r, size := utf8.DecodeRuneInString(hint)
+	return fmt.Sprintf("_%c%s_%d", unicode.ToLower(r), hint[size:], t.nextTupleID())
 }
 
 func (t *galaASTTransformer) nextTupleID() int {
+	id := t.tempVarCount
 	t.tempVarCount++
-	return t.tempVarCount
+	return id
+}
+
+// tempVarPattern matches the names produced by nextTempVar and nextNamedTempVar.
+var tempVarPattern = regexp.MustCompile(`^_[a-zA-Z][a-zA-Z0-9]*_[0-9]+$`)
+
+// isTempVarName reports whether name is a compiler temporary rather than a
+// variable the user bound.
+func isTempVarName(name string) bool {
+	return tempVarPattern.MatchString(name)
 }
 
 func (t *galaASTTransformer) isNoneCall(expr ast.Expr) bool {
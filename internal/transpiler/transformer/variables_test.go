@@ -81,3 +81,65 @@ var y = x.Get()
 		})
 	}
 }
+
+func TestTopLevelTupleDestructuring(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		contains []string
+		wantErr  string
+	}{
+		{
+			name: "destructured at initialization",
+			input: `package main
+
+import "fmt"
+
+func parseAddr(addr string) Tuple[string, int] = (addr, 8080)
+
+val (host, port) = parseAddr("localhost")
+
+func url() string = fmt.Sprintf("%s:%d", host, port)
+`,
+			contains: []string{
+				"var host, port = func() (std.Immutable[string], std.Immutable[int]) {",
+				"_tuple_0 := parseAddr(\"localhost\")",
+				"return _tuple_0.V1, _tuple_0.V2",
+				"host.Get(), port.Get()",
+			},
+		},
+		{
+			name: "skipped component",
+			input: `package main
+
+val (_, limit) = (0, 10)
+`,
+			contains: []string{"var _, limit = func() (std.Immutable[int], std.Immutable[int]) {"},
+		},
+		{
+			name: "not a tuple",
+			input: `package main
+
+val (a, b) = 42
+`,
+			wantErr: "cannot destructure '42' at package level",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := transpiler.NewAntlrGalaParser()
+			trans := transpiler.NewGalaToGoTranspiler(p, analyzer.NewGalaAnalyzer(p, getStdSearchPath()),
+				transformer.NewGalaASTTransformer(), generator.NewGoCodeGenerator())
+			got, err := trans.Transpile(tt.input, "")
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			for _, want := range tt.contains {
+				assert.Contains(t, got, want)
+			}
+		})
+	}
+}
@@ -3,8 +3,12 @@ package transpiler
 import (
 	"go/ast"
 	"go/token"
+	"io"
+	"strings"
 
 	"github.com/antlr4-go/antlr/v4"
+	"martianoff/gala/galaerr"
+	"martianoff/gala/internal/transpiler/buildtags"
 )
 
 // Type and function name constants for the std library.
@@ -26,6 +30,11 @@ const (
 	TypeTry         = "Try"
 	TypeTraversable = "Traversable"
 	TypeIterable    = "Iterable"
+	TypeIsErr       = "IsErr"
+
+	// Held by immutable struct fields of large types
+	TypeImmutableRef    = "ImmutableRef"
+	FuncNewImmutableRef = "NewImmutableRef"
 
 	FuncSome         = "Some"
 	FuncNone         = "None"
@@ -42,6 +51,15 @@ const (
 	TypeConstPtr    = "ConstPtr"
 	FuncNewConstPtr = "NewConstPtr"
 	MethodDeref     = "Deref"
+
+	// Conversions of @goStruct types to and from plain Go structs
+	MethodToGoStruct      = "ToGoStruct"
+	MethodFromGoStruct    = "FromGoStruct"
+	FuncOptionToPointer   = "OptionToPointer"
+	FuncOptionFromPointer = "OptionFromPointer"
+
+	// Ranged over by `for x <- xs` loops on collections
+	MethodToGoSlice = "ToGoSlice"
 )
 
 // RichAST provides metadata about a Gala source file.
@@ -53,6 +71,9 @@ type RichAST struct {
 	Packages         map[string]string                   // path -> pkgName
 	CompanionObjects map[string]*CompanionObjectMetadata // companion name -> metadata
 	GoExports        map[string][]string                 // pkgName -> exported symbol names (from Go-only packages)
+	Visibility       map[string]Visibility               // qualified name -> visibility of private and internal declarations
+	Prelude          []string                            // import paths of project prelude packages, dot-imported implicitly
+	ImportPath       string                              // import path of the file's package; empty outside a module
 	FilePath         string                              // source file path (for error reporting)
 	SourceContent    string                              // raw source text (for error snippets)
 }
@@ -86,6 +107,14 @@ func (r *RichAST) Merge(other *RichAST) {
 	for k, v := range other.CompanionObjects {
 		r.CompanionObjects[k] = v
 	}
+	if len(other.Visibility) > 0 {
+		if r.Visibility == nil {
+			r.Visibility = make(map[string]Visibility)
+		}
+		for k, v := range other.Visibility {
+			r.Visibility[k] = v
+		}
+	}
 	if len(other.GoExports) > 0 {
 		if r.GoExports == nil {
 			r.GoExports = make(map[string][]string)
@@ -106,7 +135,33 @@ type TypeMetadata struct {
 	TypeParamConstraints map[string]string // TypeParam name -> constraint (e.g., "T" -> "comparable")
 	ImmutFlags           []bool
 	IsSealed             bool            // True if this type was generated from a sealed type declaration
+	IsInterface          bool            // True if this type was declared as an interface
 	SealedVariants       []SealedVariant // Variant info for sealed types (empty for non-sealed)
+	Annotations          []Annotation
+	FieldAnnotations     map[string][]Annotation // Field name -> annotations of that struct field
+	Underlying           Type                    // Represented type of a newtype declaration; nil for other types
+	AliasOf              Type                    // Type a type X T declaration is defined as; nil for other types
+	IsAlias              bool                    // True if declared as type X = T, an alias of AliasOf
+	Invariants           []Invariant             // Require clauses of a shorthand struct, checked by its Apply
+}
+
+// Invariant is a require clause on a field of a shorthand struct.
+type Invariant struct {
+	Field       string
+	Requirement string // The condition as written, e.g. Name != ""
+}
+
+// NewtypeFromSuffix names the constructor generated for a newtype: UserIdFrom
+// converts a value of the underlying type to a UserId.
+const NewtypeFromSuffix = "From"
+
+// SealedVariantsSuffix names the function generated for a sealed type that
+// lists its cases: ShapeVariants returns a std.SealedVariant per case of Shape.
+const SealedVariantsSuffix = "Variants"
+
+// IsNewtype reports whether the type was declared with newtype.
+func (m *TypeMetadata) IsNewtype() bool {
+	return m != nil && m.Underlying != nil
 }
 
 // SealedVariant holds metadata about a single case in a sealed type declaration.
@@ -114,23 +169,30 @@ type SealedVariant struct {
 	Name       string
 	FieldNames []string
 	FieldTypes []Type
+	// StructFieldNames are the fields of the parent struct holding FieldNames;
+	// they carry the variant name as prefix when variants disagree on a field's type.
+	StructFieldNames []string
 }
 
 type MethodMetadata struct {
-	Name       string
-	Package    string
-	ParamTypes []Type
-	ReturnType Type
-	TypeParams []string
-	IsGeneric  bool // Force transformation to standalone function
+	Name        string
+	Package     string
+	ParamTypes  []Type
+	ReturnType  Type
+	TypeParams  []string
+	IsGeneric   bool // Force transformation to standalone function
+	Annotations []Annotation
+	// PointerReceiver is set for methods declared on *T, which may mutate the value
+	PointerReceiver bool
 }
 
 type FunctionMetadata struct {
-	Name       string
-	Package    string
-	ParamTypes []Type
-	ReturnType Type
-	TypeParams []string
+	Name        string
+	Package     string
+	ParamTypes  []Type
+	ReturnType  Type
+	TypeParams  []string
+	Annotations []Annotation
 }
 
 // CompanionObjectMetadata stores information about companion objects that can be used
@@ -157,11 +219,26 @@ type ASTTransformer interface {
 	Transform(richAST *RichAST) (*token.FileSet, *ast.File, error)
 }
 
+// WarningSource is implemented by pipeline stages that report non-fatal diagnostics.
+// Warnings describe the most recent Transform or Analyze call.
+type WarningSource interface {
+	Warnings() []galaerr.Warning
+}
+
 // CodeGenerator generates Go source code from a Go AST file and its FileSet.
 type CodeGenerator interface {
 	Generate(fset *token.FileSet, file *ast.File) (string, error)
 }
 
+// StreamingGenerator is implemented by code generators that print straight to
+// a writer, so that a large generated file is never held as one string.
+type StreamingGenerator interface {
+	// GenerateTo writes the code for file to w. A non-nil p is recorded in the
+	// header as by GenerateWithProvenance, and a non-empty constraint is
+	// written as the //go:build line. On error, w may hold part of the code.
+	GenerateTo(w io.Writer, fset *token.FileSet, file *ast.File, p *Provenance, constraint string) error
+}
+
 // Transpiler defines the high-level interface for the Gala to Go conversion.
 type Transpiler interface {
 	Transpile(input string, filePath string) (string, error)
@@ -173,6 +250,11 @@ type GalaToGoTranspiler struct {
 	analyzer    Analyzer
 	transformer ASTTransformer
 	generator   CodeGenerator
+	passes      []Pass
+	regenerate  string
+	companions  map[string]string
+	warnings    []galaerr.Warning
+	tracer      *tracer
 }
 
 // NewGalaToGoTranspiler creates a new instance of GalaToGoTranspiler with its dependencies.
@@ -181,33 +263,93 @@ func NewGalaToGoTranspiler(
 	analyzer Analyzer,
 	transformer ASTTransformer,
 	generator CodeGenerator,
+	opts ...Option,
 ) *GalaToGoTranspiler {
-	return &GalaToGoTranspiler{
+	t := &GalaToGoTranspiler{
 		parser:      parser,
 		analyzer:    analyzer,
 		transformer: transformer,
 		generator:   generator,
 	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
 }
 
 // Transpile executes the full transpilation pipeline.
 func (t *GalaToGoTranspiler) Transpile(input string, filePath string) (string, error) {
+	var sb strings.Builder
+	if err := t.TranspileTo(&sb, input, filePath); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// TranspileTo executes the full transpilation pipeline and writes the
+// generated code to w. With a StreamingGenerator the code is printed straight
+// to w; on a generation error w may then hold part of it.
+func (t *GalaToGoTranspiler) TranspileTo(w io.Writer, input string, filePath string) error {
+	t.warnings = nil
 	tree, err := t.parser.Parse(input)
 	if err != nil {
-		return "", err
+		return err
 	}
+	t.tracer.traceParseTree(tree)
 
 	richAST, err := t.analyzer.Analyze(tree, filePath)
 	if err != nil {
-		return "", err
+		// The analyzer knows positions but not the file
+		return galaerr.Locate(err, filePath, 0, 0)
 	}
+	t.tracer.traceAnalysis(richAST)
 	richAST.FilePath = filePath
 	richAST.SourceContent = input
 
+	t.companions = make(map[string]string)
+	ctx := &PassContext{RichAST: richAST, companions: t.companions}
+	if err := t.runPasses(ctx, func(p Pass) func(*PassContext) error { return p.Analyzed }); err != nil {
+		return err
+	}
+
 	fset, file, err := t.transformer.Transform(richAST)
+	if ws, ok := t.transformer.(WarningSource); ok {
+		t.warnings = ws.Warnings()
+	}
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	return t.generator.Generate(fset, file)
+	ctx.FileSet, ctx.File = fset, file
+	if err := t.runPasses(ctx, func(p Pass) func(*PassContext) error { return p.Generated }); err != nil {
+		return err
+	}
+	t.tracer.traceGoAST(fset, file)
+
+	if sg, ok := t.generator.(StreamingGenerator); ok {
+		var p *Provenance
+		if _, ok := t.generator.(ProvenanceGenerator); ok && filePath != "" {
+			prov := t.provenance(input, filePath, richAST.ImportPath)
+			p = &prov
+		}
+		return sg.GenerateTo(w, fset, file, p, buildtags.Constraint(input))
+	}
+
+	var code string
+	if pg, ok := t.generator.(ProvenanceGenerator); ok && filePath != "" {
+		code, err = pg.GenerateWithProvenance(fset, file, t.provenance(input, filePath, richAST.ImportPath))
+	} else {
+		code, err = t.generator.Generate(fset, file)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, withBuildConstraint(code, input))
+	return err
+}
+
+// Warnings returns the warnings reported during the last Transpile call,
+// such as uses of @deprecated declarations.
+func (t *GalaToGoTranspiler) Warnings() []galaerr.Warning {
+	return t.warnings
 }
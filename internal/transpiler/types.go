@@ -114,6 +114,9 @@ func (t PointerType) GetPackage() string { return "" }
 type FuncType struct {
 	Params  []Type
 	Results []Type
+	// ByName marks the func() T type of a by-name parameter (x => T).
+	// Arguments for it are wrapped in a function by the caller.
+	ByName bool
 }
 
 func (t FuncType) String() string     { return "func" }
@@ -157,6 +160,17 @@ func IsPrimitiveType(name string) bool {
 	return false
 }
 
+// IsIntegerType checks if a type name is one of Go's integer types.
+func IsIntegerType(name string) bool {
+	switch name {
+	case "int", "int8", "int16", "int32", "int64",
+		"uint", "uint8", "uint16", "uint32", "uint64", "uintptr",
+		"byte", "rune":
+		return true
+	}
+	return false
+}
+
 // ParseType is a helper to transition from string-based types to structured types.
 // It should be used sparingly as we want the analyzer to produce structured types directly.
 func ParseType(s string) Type {
@@ -219,3 +233,98 @@ func ParseType(s string) Type {
 	}
 	return BasicType{Name: s}
 }
+
+// ExpandAliases returns typ with every alias in it, declared as type X = T,
+// replaced by the type it stands for, with the alias's type parameters bound
+// to the type arguments. lookup returns the metadata of a type by base name.
+func ExpandAliases(typ Type, lookup func(name string) *TypeMetadata) Type {
+	return expandAliases(typ, lookup, make(map[string]bool))
+}
+
+// expandAliases is ExpandAliases; seen holds the aliases being expanded, which
+// an invalid alias could otherwise refer back to forever.
+func expandAliases(typ Type, lookup func(string) *TypeMetadata, seen map[string]bool) Type {
+	switch t := typ.(type) {
+	case BasicType, NamedType:
+		return expandAlias(typ, nil, lookup, seen)
+	case GenericType:
+		params := make([]Type, len(t.Params))
+		for i, p := range t.Params {
+			params[i] = expandAliases(p, lookup, seen)
+		}
+		return expandAlias(t.Base, params, lookup, seen)
+	case ArrayType:
+		return ArrayType{Elem: expandAliases(t.Elem, lookup, seen)}
+	case PointerType:
+		return PointerType{Elem: expandAliases(t.Elem, lookup, seen)}
+	case MapType:
+		return MapType{Key: expandAliases(t.Key, lookup, seen), Elem: expandAliases(t.Elem, lookup, seen)}
+	case FuncType:
+		fn := FuncType{ByName: t.ByName}
+		for _, p := range t.Params {
+			fn.Params = append(fn.Params, expandAliases(p, lookup, seen))
+		}
+		for _, r := range t.Results {
+			fn.Results = append(fn.Results, expandAliases(r, lookup, seen))
+		}
+		return fn
+	}
+	return typ
+}
+
+// expandAlias expands base instantiated with params, if base is an alias.
+func expandAlias(base Type, params []Type, lookup func(string) *TypeMetadata, seen map[string]bool) Type {
+	name := base.BaseName()
+	meta := lookup(name)
+	if meta == nil || !meta.IsAlias || meta.AliasOf == nil || seen[name] {
+		if len(params) == 0 {
+			return base
+		}
+		return GenericType{Base: base, Params: params}
+	}
+	seen[name] = true
+	defer delete(seen, name)
+	args := make(map[string]Type)
+	for i, p := range meta.TypeParams {
+		if i < len(params) {
+			args[p] = params[i]
+		}
+	}
+	return expandAliases(bindTypeParams(meta.AliasOf, args), lookup, seen)
+}
+
+// bindTypeParams returns typ with the type parameters in args replaced by
+// their arguments.
+func bindTypeParams(typ Type, args map[string]Type) Type {
+	if len(args) == 0 {
+		return typ
+	}
+	switch t := typ.(type) {
+	case BasicType:
+		if arg, ok := args[t.Name]; ok {
+			return arg
+		}
+	case GenericType:
+		params := make([]Type, len(t.Params))
+		for i, p := range t.Params {
+			params[i] = bindTypeParams(p, args)
+		}
+		return GenericType{Base: t.Base, Params: params}
+	case ArrayType:
+		return ArrayType{Elem: bindTypeParams(t.Elem, args)}
+	case PointerType:
+		return PointerType{Elem: bindTypeParams(t.Elem, args)}
+	case MapType:
+		return MapType{Key: bindTypeParams(t.Key, args), Elem: bindTypeParams(t.Elem, args)}
+	case FuncType:
+		fn := FuncType{ByName: t.ByName}
+		for _, p := range t.Params {
+			fn.Params = append(fn.Params, bindTypeParams(p, args))
+		}
+		for _, r := range t.Results {
+			fn.Results = append(fn.Results, bindTypeParams(r, args))
+		}
+		return fn
+	}
+	return typ
+}
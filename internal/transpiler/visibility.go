@@ -0,0 +1,34 @@
+package transpiler
+
+// Visibility restricts where a top-level declaration may be referenced from.
+// Declarations without a modifier are governed by Go capitalization alone.
+type Visibility int
+
+const (
+	VisibilityPublic Visibility = iota
+	// VisibilityPrivate limits a declaration to its own package. Its name stays
+	// lowercase, so the generated Go does not export it either.
+	VisibilityPrivate
+	// VisibilityInternal limits a declaration to the packages of its own module.
+	VisibilityInternal
+)
+
+func (v Visibility) String() string {
+	switch v {
+	case VisibilityPrivate:
+		return "private"
+	case VisibilityInternal:
+		return "internal"
+	default:
+		return "public"
+	}
+}
+
+// QualifiedName returns the metadata key of the top-level declaration name in
+// package pkgName. Declarations of main and test packages are not qualified.
+func QualifiedName(pkgName, name string) string {
+	if pkgName == "" || pkgName == "main" || pkgName == "test" {
+		return name
+	}
+	return pkgName + "." + name
+}
@@ -0,0 +1,267 @@
+package sql
+
+import (
+	gosql "database/sql"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+	"unsafe"
+
+	"martianoff/gala/std"
+)
+
+// querier is implemented by *sql.DB and *sql.Tx, so DB and Tx share their
+// query helpers.
+type querier interface {
+	Query(query string, args ...any) (*gosql.Rows, error)
+	Exec(query string, args ...any) (gosql.Result, error)
+}
+
+// runQuery and runExec pass the variadic arguments of the GALA wrappers on.
+func runQuery(q querier, query string, args []any) (*gosql.Rows, error) {
+	return q.Query(query, args...)
+}
+
+func runExec(q querier, query string, args []any) (gosql.Result, error) {
+	return q.Exec(query, args...)
+}
+
+// Rows iterates over the rows of a query result, reading each row from the
+// database as it is reached:
+//
+//	for rows.HasNext() {
+//	    val row = rows.Next()
+//	}
+//
+// The result is closed once the last row has been read. A Rows that is not
+// read to the end must be closed with Close to release its connection. Query
+// returns it as a std.Iterator[Row].
+type Rows struct {
+	rows    *gosql.Rows
+	columns []string
+	next    Row
+	ready   bool // next holds a row that Next has not returned yet
+	done    bool
+	err     error
+}
+
+var _ std.Iterator[Row] = (*Rows)(nil)
+
+// openRows runs query and returns the iterator over its rows.
+func openRows(q querier, query string, args []any) (*Rows, error) {
+	rows, err := runQuery(q, query, args)
+	if err != nil {
+		return nil, err
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		rows.Close()
+		return nil, err
+	}
+	return &Rows{rows: rows, columns: columns}, nil
+}
+
+// HasNext reports whether there is another row. It returns false at the end
+// of the result and when reading the next row fails; Err tells the two apart.
+func (r *Rows) HasNext() bool {
+	if r.ready {
+		return true
+	}
+	if r.done {
+		return false
+	}
+	if !r.rows.Next() {
+		r.finish(r.rows.Err())
+		return false
+	}
+	values := make([]any, len(r.columns))
+	dest := make([]any, len(values))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := r.rows.Scan(dest...); err != nil {
+		r.finish(err)
+		return false
+	}
+	for i, v := range values {
+		// Drivers may reuse the buffer of a []byte value for the next row
+		if b, ok := v.([]byte); ok {
+			values[i] = append([]byte(nil), b...)
+		}
+	}
+	r.next = Row{columns: r.columns, values: values}
+	r.ready = true
+	return true
+}
+
+// Next returns the next row. It panics if there is none; check with HasNext.
+func (r *Rows) Next() Row {
+	if !r.HasNext() {
+		panic("Rows.Next after the last row")
+	}
+	r.ready = false
+	return r.next
+}
+
+// Columns returns the column names of the result.
+func (r *Rows) Columns() []string {
+	return r.columns
+}
+
+// Err returns the error that ended the iteration early, or nil.
+func (r *Rows) Err() error {
+	return r.err
+}
+
+// Close closes the result before its end. It is a no-op once the last row has
+// been read.
+func (r *Rows) Close() error {
+	if r.done {
+		return nil
+	}
+	r.done = true
+	r.ready = false
+	return r.rows.Close()
+}
+
+func (r *Rows) finish(err error) {
+	r.err = err
+	r.done = true
+	if closeErr := r.rows.Close(); r.err == nil {
+		r.err = closeErr
+	}
+}
+
+// readAll reads every row of rows.
+func readAll(rows *Rows) ([]Row, error) {
+	var all []Row
+	for rows.HasNext() {
+		all = append(all, rows.Next())
+	}
+	return all, rows.Err()
+}
+
+// convertValue converts a value read by the driver to T, as std.Option scans
+// it; the result is None for NULL and for values that cannot be converted.
+func convertValue[T any](value any) std.Option[T] {
+	var o std.Option[T]
+	if err := o.Scan(value); err != nil {
+		return std.None[T]{}.Apply()
+	}
+	return o
+}
+
+// tryUnit turns the error of an operation without a result into a Try.
+func tryUnit(err error) std.Try[std.Unit] {
+	if err != nil {
+		return std.Failure[std.Unit]{}.Apply(err)
+	}
+	return std.Success[std.Unit]{}.Apply(std.Unit{})
+}
+
+// columnIndex returns the index of the column called name, matched
+// case-insensitively, or -1.
+func columnIndex(columns []string, name string) int {
+	for i, c := range columns {
+		if c == name {
+			return i
+		}
+	}
+	for i, c := range columns {
+		if strings.EqualFold(c, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// scanAll runs query and maps each row to a T. A struct T is filled field by
+// field (see structFields); any other T, including Option and time.Time, is
+// read from the single column of the result.
+func scanAll[T any](q querier, query string, args []any) ([]T, error) {
+	rows, err := runQuery(q, query, args)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []T
+	var fields map[string][]int
+	if t := reflect.TypeOf((*T)(nil)).Elem(); isRecord(t) {
+		fields = structFields(t)
+	}
+	dest := make([]any, len(columns))
+	for rows.Next() {
+		var value T
+		if fields == nil {
+			if err := rows.Scan(&value); err != nil {
+				return nil, err
+			}
+		} else {
+			v := reflect.ValueOf(&value).Elem()
+			for i, c := range columns {
+				if index, ok := fields[normalizeName(c)]; ok {
+					f := v.FieldByIndex(index)
+					// Fields of GALA structs are often unexported
+					dest[i] = reflect.NewAt(f.Type(), unsafe.Pointer(f.UnsafeAddr())).Interface()
+				} else {
+					dest[i] = new(any)
+				}
+			}
+			if err := rows.Scan(dest...); err != nil {
+				return nil, err
+			}
+		}
+		result = append(result, value)
+	}
+	return result, rows.Err()
+}
+
+var (
+	scannerType = reflect.TypeOf((*gosql.Scanner)(nil)).Elem()
+	timeType    = reflect.TypeOf(time.Time{})
+)
+
+// isRecord reports whether rows are mapped to t field by field.
+func isRecord(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t != timeType && !reflect.PointerTo(t).Implements(scannerType)
+}
+
+var structFieldsCache sync.Map // reflect.Type -> map[string][]int
+
+// structFields maps the columns that fill a struct type to the indexes of its
+// fields. A field tagged `db:"name"` is filled from column name and one tagged
+// `db:"-"` from none; other fields are filled from the column with the same
+// name, ignoring case and underscores, so created_at fills CreatedAt.
+func structFields(t reflect.Type) map[string][]int {
+	if cached, ok := structFieldsCache.Load(t); ok {
+		return cached.(map[string][]int)
+	}
+	fields := make(map[string][]int)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := f.Name
+		if tag, ok := f.Tag.Lookup("db"); ok {
+			if tag == "-" {
+				continue
+			}
+			name = tag
+		}
+		if strings.HasPrefix(name, "_") {
+			// Internal fields of generated types, e.g. the variant of a sealed type
+			continue
+		}
+		fields[normalizeName(name)] = f.Index
+	}
+	structFieldsCache.Store(t, fields)
+	return fields
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", ""))
+}
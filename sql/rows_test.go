@@ -0,0 +1,177 @@
+package sql
+
+import (
+	gosql "database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"martianoff/gala/std"
+)
+
+// fakeDriver serves canned results: every query returns the rows of the
+// fakeResult registered under its text.
+type fakeDriver struct{}
+
+type fakeResult struct {
+	columns []string
+	rows    [][]driver.Value
+	err     error // returned after the rows
+}
+
+var fakeResults = map[string]fakeResult{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) { return fakeConn{}, nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{query}, nil }
+func (fakeConn) Close() error                              { return nil }
+func (fakeConn) Begin() (driver.Tx, error)                 { return nil, errors.New("no transactions") }
+
+type fakeStmt struct{ query string }
+
+func (fakeStmt) Close() error  { return nil }
+func (fakeStmt) NumInput() int { return -1 }
+func (fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(1), nil
+}
+func (s fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	result, ok := fakeResults[s.query]
+	if !ok {
+		return nil, errors.New("unknown query " + s.query)
+	}
+	return &fakeRows{result: result}, nil
+}
+
+type fakeRows struct {
+	result fakeResult
+	pos    int
+}
+
+func (r *fakeRows) Columns() []string { return r.result.columns }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.pos == len(r.result.rows) {
+		if r.result.err != nil {
+			return r.result.err
+		}
+		return io.EOF
+	}
+	copy(dest, r.result.rows[r.pos])
+	r.pos++
+	return nil
+}
+
+func init() {
+	gosql.Register("gala_fake", fakeDriver{})
+}
+
+func openFake(t *testing.T) *gosql.DB {
+	db, err := gosql.Open("gala_fake", "")
+	assert.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+var created = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func init() {
+	fakeResults["users"] = fakeResult{
+		columns: []string{"id", "name", "email", "created_at", "extra"},
+		rows: [][]driver.Value{
+			{int64(1), []byte("ann"), "ann@example.com", created, "x"},
+			{int64(2), "bob", nil, created, "y"},
+		},
+	}
+	fakeResults["count"] = fakeResult{
+		columns: []string{"count"},
+		rows:    [][]driver.Value{{int64(2)}},
+	}
+	fakeResults["broken"] = fakeResult{
+		columns: []string{"id"},
+		rows:    [][]driver.Value{{int64(1)}},
+		err:     errors.New("connection lost"),
+	}
+}
+
+// user is shaped like a transpiled GALA struct: unexported immutable fields.
+type user struct {
+	id        std.Immutable[int]
+	name      std.Immutable[string]
+	email     std.Immutable[std.Option[string]]
+	CreatedAt time.Time
+	Nick      string `db:"-"`
+}
+
+type taggedUser struct {
+	Key  int64  `db:"id"`
+	Name string `db:"name"`
+}
+
+func TestRowsIterates(t *testing.T) {
+	rows, err := openRows(openFake(t), "users", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"id", "name", "email", "created_at", "extra"}, rows.Columns())
+
+	var names []any
+	for rows.HasNext() {
+		row := rows.Next()
+		names = append(names, row.values[1])
+	}
+	assert.NoError(t, rows.Err())
+	assert.Equal(t, []any{[]byte("ann"), "bob"}, names)
+	assert.False(t, rows.HasNext())
+	assert.Panics(t, func() { rows.Next() })
+	assert.NoError(t, rows.Close())
+}
+
+func TestRowsReportsReadError(t *testing.T) {
+	rows, err := openRows(openFake(t), "broken", nil)
+	assert.NoError(t, err)
+	all, err := readAll(rows)
+	assert.Len(t, all, 1)
+	assert.EqualError(t, err, "connection lost")
+}
+
+func TestScanAllStructs(t *testing.T) {
+	users, err := scanAll[user](openFake(t), "users", nil)
+	assert.NoError(t, err)
+	assert.Len(t, users, 2)
+	assert.Equal(t, 1, users[0].id.Get())
+	assert.Equal(t, "ann", users[0].name.Get())
+	assert.Equal(t, "ann@example.com", users[0].email.Get().Get())
+	assert.Equal(t, created, users[0].CreatedAt)
+	assert.True(t, users[1].email.Get().IsEmpty())
+	assert.Equal(t, "", users[1].Nick)
+
+	tagged, err := scanAll[taggedUser](openFake(t), "users", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []taggedUser{{1, "ann"}, {2, "bob"}}, tagged)
+}
+
+func TestScanAllScalars(t *testing.T) {
+	counts, err := scanAll[int](openFake(t), "count", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{2}, counts)
+
+	_, err = scanAll[int](openFake(t), "missing", nil)
+	assert.EqualError(t, err, "unknown query missing")
+}
+
+func TestConvertValue(t *testing.T) {
+	assert.Equal(t, 7, convertValue[int](int64(7)).Get())
+	assert.Equal(t, "ann", convertValue[string]([]byte("ann")).Get())
+	assert.True(t, convertValue[int](nil).IsEmpty())
+	assert.True(t, convertValue[int]("seven").IsEmpty())
+}
+
+func TestColumnIndex(t *testing.T) {
+	columns := []string{"id", "Name"}
+	assert.Equal(t, 1, columnIndex(columns, "Name"))
+	assert.Equal(t, 1, columnIndex(columns, "name"))
+	assert.Equal(t, -1, columnIndex(columns, "email"))
+}
@@ -0,0 +1,41 @@
+package std
+
+// defaultArenaChunkSize is the number of values NewArena allocates at once.
+const defaultArenaChunkSize = 256
+
+// Arena allocates values of type T in chunks, so building many small values
+// costs one heap allocation per chunk instead of one per value. It suits large
+// structures that are built once and dropped as a whole, such as the syntax tree
+// of a parser: a chunk is only freed when no value in it is referenced anymore.
+//
+// An Arena is not safe for concurrent use.
+type Arena[T any] struct {
+	chunk     []T
+	chunkSize int
+	count     int
+}
+
+// NewArena returns an empty arena that allocates 256 values at a time.
+func NewArena[T any]() *Arena[T] {
+	return NewArenaOfSize[T](defaultArenaChunkSize)
+}
+
+// NewArenaOfSize returns an empty arena that allocates chunkSize values at a time.
+func NewArenaOfSize[T any](chunkSize int) *Arena[T] {
+	return &Arena[T]{chunkSize: max(chunkSize, 1)}
+}
+
+// Alloc copies value into the arena and returns a pointer to the copy.
+func (a *Arena[T]) Alloc(value T) *T {
+	if len(a.chunk) == cap(a.chunk) {
+		a.chunk = make([]T, 0, a.chunkSize)
+	}
+	a.chunk = append(a.chunk, value)
+	a.count++
+	return &a.chunk[len(a.chunk)-1]
+}
+
+// Len returns the number of values allocated from the arena.
+func (a *Arena[T]) Len() int {
+	return a.count
+}
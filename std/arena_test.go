@@ -0,0 +1,36 @@
+package std
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArenaAlloc(t *testing.T) {
+	a := NewArenaOfSize[int](2)
+	p1 := a.Alloc(1)
+	p2 := a.Alloc(2)
+	p3 := a.Alloc(3)
+	assert.Equal(t, []int{1, 2, 3}, []int{*p1, *p2, *p3})
+	assert.Equal(t, 3, a.Len())
+
+	// Starting a new chunk leaves the pointers into the old one intact
+	*p1 = 10
+	assert.Equal(t, 10, *p1)
+	assert.Equal(t, 2, *p2)
+}
+
+func TestArenaAllocAmortizes(t *testing.T) {
+	a := NewArena[int]()
+	allocs := testing.AllocsPerRun(10, func() {
+		for i := 0; i < defaultArenaChunkSize; i++ {
+			a.Alloc(i)
+		}
+	})
+	assert.LessOrEqual(t, allocs, 1.0)
+}
+
+func TestNewArenaOfSizeClampsChunkSize(t *testing.T) {
+	a := NewArenaOfSize[string](0)
+	assert.Equal(t, "x", *a.Alloc("x"))
+}
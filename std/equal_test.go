@@ -0,0 +1,39 @@
+package std
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type equalPoint struct {
+	X, Y int
+}
+
+func (p equalPoint) Equal(other equalPoint) bool {
+	return p.X == other.X
+}
+
+func TestEqualSlices(t *testing.T) {
+	assert.True(t, EqualSlices([]int{1, 2}, []int{1, 2}))
+	assert.False(t, EqualSlices([]int{1, 2}, []int{2, 1}))
+	assert.False(t, EqualSlices([]int{1}, []int{1, 1}))
+	assert.True(t, EqualSlices[int](nil, []int{}))
+	// Elements are compared with their Equal method
+	assert.True(t, EqualSlices([]equalPoint{{1, 2}}, []equalPoint{{1, 3}}))
+}
+
+func TestEqualMaps(t *testing.T) {
+	assert.True(t, EqualMaps(map[string]int{"a": 1}, map[string]int{"a": 1}))
+	assert.False(t, EqualMaps(map[string]int{"a": 1}, map[string]int{"a": 2}))
+	assert.False(t, EqualMaps(map[string]int{"a": 1}, map[string]int{"b": 1}))
+	assert.True(t, EqualMaps[string, int](nil, map[string]int{}))
+	assert.True(t, EqualMaps(map[int]equalPoint{1: {1, 2}}, map[int]equalPoint{1: {1, 3}}))
+}
+
+func TestEqualFuncsByIdentity(t *testing.T) {
+	f := func() int { return 1 }
+	g := func() int { return 1 }
+	assert.True(t, Equal(f, f))
+	assert.False(t, Equal(f, g))
+}
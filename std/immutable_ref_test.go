@@ -0,0 +1,54 @@
+package std
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type refAddress struct {
+	Street Immutable[string]
+	City   Immutable[string]
+}
+
+type refCustomer struct {
+	Name    Immutable[string]
+	Address ImmutableRef[refAddress]
+}
+
+func TestImmutableRefShared(t *testing.T) {
+	addr := refAddress{Street: NewImmutable("Main St"), City: NewImmutable("Springfield")}
+	c := refCustomer{Name: NewImmutable("ann"), Address: NewImmutableRef(addr)}
+	copied := c
+	assert.Same(t, c.Address.Ptr(), copied.Address.Ptr())
+	assert.Equal(t, "Springfield", copied.Address.Get().City.Get())
+}
+
+func TestImmutableRefZero(t *testing.T) {
+	var c refCustomer
+	assert.Equal(t, "", c.Address.Get().City.Get())
+	assert.True(t, Equal(c, refCustomer{Address: NewImmutableRef(refAddress{})}))
+}
+
+func TestImmutableRefCopyAndEqual(t *testing.T) {
+	c := refCustomer{Name: NewImmutable("ann"), Address: NewImmutableRef(refAddress{City: NewImmutable("Springfield")})}
+	copied := Copy(c)
+	assert.NotSame(t, c.Address.Ptr(), copied.Address.Ptr())
+	assert.True(t, Equal(c, copied))
+	assert.False(t, Equal(c, refCustomer{Name: NewImmutable("ann"), Address: NewImmutableRef(refAddress{City: NewImmutable("Shelbyville")})}))
+}
+
+func TestImmutableRefFormatAndJSON(t *testing.T) {
+	ref := NewImmutableRef(42)
+	assert.Equal(t, fmt.Sprintf("%v", NewImmutable(42)), fmt.Sprintf("%v", ref))
+
+	data, err := json.Marshal(ref)
+	assert.NoError(t, err)
+	assert.Equal(t, "42", string(data))
+
+	var decoded ImmutableRef[int]
+	assert.NoError(t, json.Unmarshal([]byte("7"), &decoded))
+	assert.Equal(t, 7, decoded.Get())
+}
@@ -0,0 +1,24 @@
+package std
+
+import "fmt"
+
+// InvariantError reports a require clause that does not hold for the values
+// passed to the constructor of a shorthand struct, e.g. for
+//
+//	struct User(Name string require Name != "")
+//
+// User("") is Invalid with InvariantError{Type: "User", Field: "Name",
+// Requirement: `Name != ""`}. The constructor reports one error per failed
+// clause.
+type InvariantError struct {
+	// Type is the struct being constructed.
+	Type string
+	// Field is the field carrying the clause.
+	Field string
+	// Requirement is the condition as written in the GALA source.
+	Requirement string
+}
+
+func (e InvariantError) Error() string {
+	return fmt.Sprintf("%s.%s: requirement %s does not hold", e.Type, e.Field, e.Requirement)
+}
@@ -0,0 +1,17 @@
+package std
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvariantErrorMessage(t *testing.T) {
+	var err error = InvariantError{Type: "User", Field: "Age", Requirement: "Age >= 0"}
+	assert.EqualError(t, err, "User.Age: requirement Age >= 0 does not hold")
+
+	var invariant InvariantError
+	assert.True(t, errors.As(joinErrors([]error{errors.New("other"), err}), &invariant))
+	assert.Equal(t, "Age", invariant.Field)
+}
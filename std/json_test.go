@@ -0,0 +1,43 @@
+package std
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type jsonUser struct {
+	Name  Immutable[string]
+	Email Option[string]
+}
+
+func TestImmutableJSON(t *testing.T) {
+	data, err := json.Marshal(NewImmutable(42))
+	assert.NoError(t, err)
+	assert.Equal(t, "42", string(data))
+
+	var i Immutable[int]
+	assert.NoError(t, json.Unmarshal([]byte("7"), &i))
+	assert.Equal(t, 7, i.Get())
+}
+
+func TestOptionJSON(t *testing.T) {
+	some := jsonUser{Name: NewImmutable("ann"), Email: Some[string]{}.Apply("ann@example.com")}
+	data, err := json.Marshal(some)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"Name":"ann","Email":"ann@example.com"}`, string(data))
+
+	none := jsonUser{Name: NewImmutable("bob"), Email: None[string]{}.Apply()}
+	data, err = json.Marshal(none)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"Name":"bob","Email":null}`, string(data))
+
+	var decoded jsonUser
+	assert.NoError(t, json.Unmarshal([]byte(`{"Name":"cy","Email":null}`), &decoded))
+	assert.Equal(t, "cy", decoded.Name.Get())
+	assert.True(t, decoded.Email.IsEmpty())
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"Name":"cy","Email":"cy@example.com"}`), &decoded))
+	assert.Equal(t, "cy@example.com", decoded.Email.Get())
+}
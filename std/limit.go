@@ -0,0 +1,168 @@
+package std
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Semaphore limits how many goroutines run a section of code at once. It
+// holds a fixed number of permits: Acquire takes one, waiting while none is
+// available, and Release gives it back.
+type Semaphore struct {
+	permits chan struct{}
+}
+
+// NewSemaphore returns a semaphore with n permits. It panics if n < 1.
+func NewSemaphore(n int) *Semaphore {
+	if n < 1 {
+		panic("NewSemaphore: permits must be positive")
+	}
+	return &Semaphore{permits: make(chan struct{}, n)}
+}
+
+// Acquire takes a permit, waiting until one is available.
+func (s *Semaphore) Acquire() {
+	s.permits <- struct{}{}
+}
+
+// AcquireContext takes a permit like Acquire, but gives up and returns the
+// context's error if ctx is done first.
+func (s *Semaphore) AcquireContext(ctx context.Context) error {
+	select {
+	case s.permits <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// TryAcquire takes a permit if one is available right away and reports
+// whether it did.
+func (s *Semaphore) TryAcquire() bool {
+	select {
+	case s.permits <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// Release gives back a permit taken by Acquire. It panics if no permit is
+// taken.
+func (s *Semaphore) Release() {
+	select {
+	case <-s.permits:
+	default:
+		panic("Semaphore.Release without Acquire")
+	}
+}
+
+// Available returns the number of permits that are not taken.
+func (s *Semaphore) Available() int {
+	return cap(s.permits) - len(s.permits)
+}
+
+// withPermit implements WithPermit (defined in limit.gala). The permit is
+// released even if f panics.
+func withPermit[T any](s *Semaphore, f func() T) T {
+	s.Acquire()
+	defer s.Release()
+	return f()
+}
+
+// RateLimiter limits how often an operation runs, using a token bucket. The
+// bucket holds up to events tokens and is refilled at events per period, so
+// bursts of up to events operations run at once and the long-term rate is
+// events per period. Every operation takes a token.
+type RateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration // time to refill one token
+	burst    float64
+	tokens   float64
+	last     time.Time
+}
+
+// NewRateLimiter returns a limiter allowing events operations per period,
+// starting with a full bucket. It panics if events < 1 or period <= 0.
+// Example: NewRateLimiter(10, time.Second) allows 10 operations per second.
+func NewRateLimiter(events int, period time.Duration) *RateLimiter {
+	if events < 1 || period <= 0 {
+		panic("NewRateLimiter: events and period must be positive")
+	}
+	return &RateLimiter{
+		interval: max(period/time.Duration(events), 1),
+		burst:    float64(events),
+		tokens:   float64(events),
+		last:     time.Now(),
+	}
+}
+
+// Allow takes a token if one is available right away and reports whether it
+// did. Operations that are not allowed should be dropped or rejected.
+func (l *RateLimiter) Allow() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill(time.Now())
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// Wait takes a token, waiting until one is available.
+func (l *RateLimiter) Wait() {
+	if delay := l.reserve(); delay > 0 {
+		time.Sleep(delay)
+	}
+}
+
+// WaitContext takes a token like Wait, but gives up and returns the context's
+// error if ctx is done first. The token is then given back.
+func (l *RateLimiter) WaitContext(ctx context.Context) error {
+	delay := l.reserve()
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// reserve takes a token, letting the bucket go negative when it is empty, and
+// returns how long to wait before the token is due.
+func (l *RateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill(time.Now())
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens * float64(l.interval))
+}
+
+// refill adds the tokens due since the last refill, up to the bucket size.
+func (l *RateLimiter) refill(now time.Time) {
+	elapsed := now.Sub(l.last)
+	if elapsed <= 0 {
+		return
+	}
+	l.tokens = min(l.burst, l.tokens+float64(elapsed)/float64(l.interval))
+	l.last = now
+}
+
+// withRateLimit implements WithRateLimit (defined in limit.gala).
+func withRateLimit[T any](l *RateLimiter, f func() T) T {
+	l.Wait()
+	return f()
+}
@@ -0,0 +1,89 @@
+package std
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSemaphoreLimitsConcurrency(t *testing.T) {
+	sem := NewSemaphore(3)
+	var running, peak atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			withPermit(sem, func() int32 {
+				n := running.Add(1)
+				for {
+					p := peak.Load()
+					if n <= p || peak.CompareAndSwap(p, n) {
+						break
+					}
+				}
+				time.Sleep(time.Millisecond)
+				return running.Add(-1)
+			})
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(3), peak.Load())
+	assert.Equal(t, 3, sem.Available())
+}
+
+func TestSemaphoreReleasesOnPanic(t *testing.T) {
+	sem := NewSemaphore(1)
+	assert.Panics(t, func() {
+		withPermit(sem, func() int { panic("boom") })
+	})
+	assert.Equal(t, 1, sem.Available())
+}
+
+func TestSemaphoreTryAcquire(t *testing.T) {
+	sem := NewSemaphore(1)
+	assert.True(t, sem.TryAcquire())
+	assert.False(t, sem.TryAcquire())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, sem.AcquireContext(ctx), context.DeadlineExceeded)
+
+	sem.Release()
+	assert.NoError(t, sem.AcquireContext(context.Background()))
+	sem.Release()
+	assert.Panics(t, sem.Release)
+}
+
+func TestRateLimiterAllowsBurst(t *testing.T) {
+	limiter := NewRateLimiter(3, time.Hour)
+	assert.True(t, limiter.Allow())
+	assert.True(t, limiter.Allow())
+	assert.True(t, limiter.Allow())
+	assert.False(t, limiter.Allow())
+}
+
+func TestRateLimiterWaitsForTokens(t *testing.T) {
+	limiter := NewRateLimiter(2, 20*time.Millisecond)
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		assert.Equal(t, i, withRateLimit(limiter, func() int { return i }))
+	}
+	// Two tokens in the bucket, two more refilled 10ms apart
+	assert.GreaterOrEqual(t, time.Since(start), 18*time.Millisecond)
+}
+
+func TestRateLimiterWaitContext(t *testing.T) {
+	limiter := NewRateLimiter(1, time.Hour)
+	assert.NoError(t, limiter.WaitContext(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, limiter.WaitContext(ctx), context.DeadlineExceeded)
+	// The cancelled wait gives its token back, so the bucket is not overdrawn
+	assert.InDelta(t, 0, limiter.tokens, 0.01)
+}
@@ -0,0 +1,36 @@
+package std
+
+import "fmt"
+
+// MatchError is the panic value of a match expression whose cases were
+// checked to be exhaustive but did not handle the value. That only happens to
+// values the generated constructors cannot build, e.g. a sealed value with a
+// variant tag set by hand-written Go code.
+type MatchError struct {
+	// Location is the file and line of the match in the GALA source, e.g. shapes.gala:12.
+	Location string
+	// Value is the value no case matched.
+	Value any
+}
+
+// Error describes the failed match, rendering the value with its String
+// method when it has one.
+func (e MatchError) Error() string {
+	return fmt.Sprintf("%s: no case matched %v", e.Location, e.Value)
+}
+
+// OnMatchError, when set, is called with every MatchError before it is
+// raised. A program can use it to log failed matches or to exit with its own
+// status; if it returns, the match panics as usual.
+var OnMatchError func(err MatchError)
+
+// MatchFailure is called by generated code when no case of an exhaustive
+// match applies. It passes the error to OnMatchError and returns it for the
+// match to panic with.
+func MatchFailure(location string, value any) MatchError {
+	err := MatchError{Location: location, Value: value}
+	if OnMatchError != nil {
+		OnMatchError(err)
+	}
+	return err
+}
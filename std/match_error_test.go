@@ -0,0 +1,28 @@
+package std
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type color int
+
+func (c color) String() string { return [...]string{"Red", "Green"}[c] }
+
+func TestMatchErrorMessage(t *testing.T) {
+	err := MatchFailure("colors.gala:7", color(1))
+	assert.Equal(t, "colors.gala:7: no case matched Green", err.Error())
+	assert.Equal(t, "colors.gala:7: no case matched 42", MatchFailure("colors.gala:7", 42).Error())
+}
+
+func TestOnMatchError(t *testing.T) {
+	var seen []MatchError
+	OnMatchError = func(err MatchError) { seen = append(seen, err) }
+	defer func() { OnMatchError = nil }()
+
+	assert.PanicsWithError(t, "shapes.gala:3: no case matched <nil>", func() {
+		panic(MatchFailure("shapes.gala:3", nil))
+	})
+	assert.Equal(t, []MatchError{{Location: "shapes.gala:3"}}, seen)
+}
@@ -0,0 +1,56 @@
+package std
+
+import (
+	"math"
+	"unsafe"
+)
+
+// Integer is satisfied by every Go integer type.
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// Float is satisfied by every Go floating-point type.
+type Float interface {
+	~float32 | ~float64
+}
+
+// Number is satisfied by every Go integer and floating-point type.
+type Number interface {
+	Integer | Float
+}
+
+// convertNumber converts v to T and reports whether the result still denotes
+// the same value: no overflow, no sign flip, no dropped fraction and no NaN.
+// Float targets must hold the value exactly, so 0.1 does not fit in a float32.
+func convertNumber[T Number, F Number](v F) (T, bool) {
+	if isFloat[F]() && !isFloat[T]() && !inIntegerRange[T](float64(v)) {
+		return 0, false
+	}
+	r := T(v)
+	if F(r) != v || (v < 0) != (r < 0) {
+		return r, false
+	}
+	return r, true
+}
+
+// isFloat reports whether T is a floating-point type.
+func isFloat[T Number]() bool {
+	half := 0.5
+	return T(half) != 0
+}
+
+// inIntegerRange reports whether f lies within the range of the integer type T.
+// Converting an out-of-range float to an integer is implementation-defined in
+// Go, so the range is checked before converting.
+func inIntegerRange[T Number](f float64) bool {
+	var zero T
+	bits := int(unsafe.Sizeof(zero)) * 8
+	minusOne := zero - 1
+	if minusOne < 0 {
+		limit := math.Ldexp(1, bits-1)
+		return f >= -limit && f < limit
+	}
+	return f >= 0 && f < math.Ldexp(1, bits)
+}
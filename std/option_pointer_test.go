@@ -0,0 +1,28 @@
+package std
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptionToPointer(t *testing.T) {
+	p := OptionToPointer(Some[int]{}.Apply(42))
+	if assert.NotNil(t, p) {
+		assert.Equal(t, 42, *p)
+	}
+	assert.Nil(t, OptionToPointer(None[int]{}.Apply()))
+}
+
+func TestOptionFromPointer(t *testing.T) {
+	v := "ann"
+	some := OptionFromPointer(&v)
+	assert.True(t, some.IsDefined())
+	assert.Equal(t, "ann", some.Get())
+
+	// The Option holds a copy, so later writes through the pointer do not show
+	v = "bob"
+	assert.Equal(t, "ann", some.Get())
+
+	assert.True(t, OptionFromPointer[string](nil).IsEmpty())
+}
@@ -0,0 +1,128 @@
+package std
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// Scan implements database/sql's Scanner, so immutable fields of GALA
+// structs can be scanned into like the fields of the equivalent Go structs.
+func (i *Immutable[T]) Scan(src any) error {
+	return scanInto(reflect.ValueOf(&i.value).Elem(), src)
+}
+
+// Scan implements database/sql's Scanner for the fields Immutable's Scan
+// would scan into when they are held by reference.
+func (i *ImmutableRef[T]) Scan(src any) error {
+	var v T
+	if err := scanInto(reflect.ValueOf(&v).Elem(), src); err != nil {
+		return err
+	}
+	i.ref = &v
+	return nil
+}
+
+// Scan implements database/sql's Scanner: NULL scans as None and any other
+// value as Some.
+func (o *Option[T]) Scan(src any) error {
+	if src == nil {
+		*o = Option[T]{_variant: _Option_None}
+		return nil
+	}
+	var v T
+	if err := scanInto(reflect.ValueOf(&v).Elem(), src); err != nil {
+		return err
+	}
+	*o = Option[T]{Value: NewImmutable(v), _variant: _Option_Some}
+	return nil
+}
+
+// scanner is database/sql's Scanner, declared here to keep std free of the
+// database/sql dependency.
+type scanner interface {
+	Scan(src any) error
+}
+
+// scanInto stores src, a value read by a database driver (nil, int64, float64,
+// bool, []byte, string or time.Time), in dst, converting it to dst's type.
+// Numbers may be read from text, as some drivers return them, and booleans
+// from integers.
+func scanInto(dst reflect.Value, src any) error {
+	if s, ok := dst.Addr().Interface().(scanner); ok {
+		return s.Scan(src)
+	}
+	if src == nil {
+		return fmt.Errorf("cannot scan NULL into %s; use an Option", dst.Type())
+	}
+	if b, ok := src.([]byte); ok {
+		// Drivers may reuse the buffer after the scan
+		src = string(b)
+	}
+	v := reflect.ValueOf(src)
+	if v.Type().AssignableTo(dst.Type()) {
+		dst.Set(v)
+		return nil
+	}
+
+	text, isText := src.(string)
+	switch dst.Kind() {
+	case reflect.String:
+		if isText {
+			dst.SetString(text)
+			return nil
+		}
+	case reflect.Slice:
+		if isText && dst.Type().Elem().Kind() == reflect.Uint8 {
+			dst.SetBytes([]byte(text))
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := scanInt(v, text, isText)
+		if err == nil && !dst.OverflowInt(n) {
+			dst.SetInt(n)
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := scanInt(v, text, isText)
+		if err == nil && n >= 0 && !dst.OverflowUint(uint64(n)) {
+			dst.SetUint(uint64(n))
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if isText {
+			if f, err := strconv.ParseFloat(text, dst.Type().Bits()); err == nil {
+				dst.SetFloat(f)
+				return nil
+			}
+		} else if v.CanInt() {
+			dst.SetFloat(float64(v.Int()))
+			return nil
+		} else if v.CanFloat() {
+			dst.SetFloat(v.Float())
+			return nil
+		}
+	case reflect.Bool:
+		if isText {
+			if b, err := strconv.ParseBool(text); err == nil {
+				dst.SetBool(b)
+				return nil
+			}
+		} else if v.CanInt() {
+			dst.SetBool(v.Int() != 0)
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot scan %T %v into %s", src, src, dst.Type())
+}
+
+// scanInt reads an integer from an integer value or its text.
+func scanInt(v reflect.Value, text string, isText bool) (int64, error) {
+	if isText {
+		return strconv.ParseInt(text, 10, 64)
+	}
+	if v.CanInt() {
+		return v.Int(), nil
+	}
+	return 0, fmt.Errorf("%s is not an integer", v.Type())
+}
@@ -0,0 +1,65 @@
+package std
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImmutableScan(t *testing.T) {
+	var n Immutable[int]
+	assert.NoError(t, n.Scan(int64(42)))
+	assert.Equal(t, 42, n.Get())
+
+	// Numbers read as text, as the MySQL driver returns them
+	assert.NoError(t, n.Scan([]byte("7")))
+	assert.Equal(t, 7, n.Get())
+
+	var s Immutable[string]
+	buf := []byte("ann")
+	assert.NoError(t, s.Scan(buf))
+	buf[0] = 'x'
+	assert.Equal(t, "ann", s.Get())
+
+	var b Immutable[bool]
+	assert.NoError(t, b.Scan(int64(1)))
+	assert.True(t, b.Get())
+
+	var f Immutable[float64]
+	assert.NoError(t, f.Scan(int64(3)))
+	assert.Equal(t, 3.0, f.Get())
+
+	now := time.Now()
+	var ts Immutable[time.Time]
+	assert.NoError(t, ts.Scan(now))
+	assert.Equal(t, now, ts.Get())
+}
+
+func TestImmutableScanErrors(t *testing.T) {
+	var n Immutable[int8]
+	assert.EqualError(t, n.Scan(nil), "cannot scan NULL into int8; use an Option")
+	assert.EqualError(t, n.Scan(int64(300)), "cannot scan int64 300 into int8")
+
+	var u Immutable[uint]
+	assert.Error(t, u.Scan(int64(-1)))
+
+	var s Immutable[string]
+	assert.Error(t, s.Scan(int64(1)))
+}
+
+func TestOptionScan(t *testing.T) {
+	var o Option[string]
+	assert.NoError(t, o.Scan("ann@example.com"))
+	assert.Equal(t, "ann@example.com", o.Get())
+
+	assert.NoError(t, o.Scan(nil))
+	assert.True(t, o.IsEmpty())
+
+	// An immutable Option field scans through the Option
+	var field Immutable[Option[int64]]
+	assert.NoError(t, field.Scan(nil))
+	assert.True(t, field.Get().IsEmpty())
+	assert.NoError(t, field.Scan(int64(5)))
+	assert.Equal(t, int64(5), field.Get().Get())
+}
@@ -0,0 +1,93 @@
+package std
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Unit is the result type of computations run only for their effects, such as
+// a Scope. Its only value is Unit{}.
+type Unit struct{}
+
+// TaskScope is the handle Scope passes to its body for forking tasks. Every
+// task forked in a scope, including tasks forked by other tasks, finishes
+// before Scope returns.
+type TaskScope struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	mu     sync.Mutex
+	errs   []error
+}
+
+// Fork runs task in a new goroutine of the scope. If task panics, the panic is
+// recovered and fails the scope, which cancels its context so that the other
+// tasks can stop early. Fork must not be called once Scope has returned.
+// Accepts func() any to be compatible with GALA's lambda generation.
+func (s *TaskScope) Fork(task func() any) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.run(func() { task() })
+	}()
+}
+
+// Fail fails the scope with err and cancels its context, as a panicking task
+// does. A nil err is ignored.
+func (s *TaskScope) Fail(err error) {
+	if err == nil {
+		return
+	}
+	s.mu.Lock()
+	s.errs = append(s.errs, err)
+	s.mu.Unlock()
+	s.cancel()
+}
+
+// Context returns the context of the scope. It is cancelled when a task fails
+// and when the scope ends, and should be passed to the blocking calls of the
+// tasks.
+func (s *TaskScope) Context() context.Context {
+	return s.ctx
+}
+
+// Cancelled reports whether the scope has been cancelled. Long-running tasks
+// can poll it to stop once a sibling has failed.
+func (s *TaskScope) Cancelled() bool {
+	return s.ctx.Err() != nil
+}
+
+// run calls f, failing the scope if it panics.
+func (s *TaskScope) run(f func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.Fail(panicError(r))
+		}
+	}()
+	f()
+}
+
+// runScope implements Scope (defined in try.gala) with errgroup semantics:
+// wait for all tasks, cancel on the first failure, and report the failures.
+// A panic in body fails the scope like a panic in a task.
+func runScope(body func(*TaskScope)) Try[Unit] {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &TaskScope{ctx: ctx, cancel: cancel}
+	s.run(func() { body(s) })
+	s.wg.Wait()
+	cancel()
+
+	// Failures are reported in the order they happened; a single one as is,
+	// so that it can be matched by type
+	var err error
+	switch len(s.errs) {
+	case 0:
+		return Try[Unit]{Value: NewImmutable(Unit{}), _variant: _Try_Success}
+	case 1:
+		err = s.errs[0]
+	default:
+		err = errors.Join(s.errs...)
+	}
+	return Try[Unit]{Err: NewImmutable(err), _variant: _Try_Failure}
+}
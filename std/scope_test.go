@@ -0,0 +1,76 @@
+package std
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScopeWaitsForAllTasks(t *testing.T) {
+	var done atomic.Int32
+	result := runScope(func(s *TaskScope) {
+		for i := 0; i < 10; i++ {
+			s.Fork(func() any {
+				time.Sleep(time.Millisecond)
+				// Tasks forked by tasks are waited for too
+				s.Fork(func() any {
+					done.Add(1)
+					return nil
+				})
+				return done.Add(1)
+			})
+		}
+	})
+	assert.True(t, result.IsSuccess())
+	assert.Equal(t, int32(20), done.Load())
+}
+
+func TestScopeCancelsSiblingsOnFailure(t *testing.T) {
+	boom := errors.New("boom")
+	var stopped atomic.Bool
+	result := runScope(func(s *TaskScope) {
+		s.Fork(func() any {
+			select {
+			case <-s.Context().Done():
+				stopped.Store(true)
+			case <-time.After(10 * time.Second):
+			}
+			return nil
+		})
+		s.Fork(func() any { panic(boom) })
+	})
+	assert.True(t, result.IsFailure())
+	assert.Same(t, boom, result.GetError())
+	assert.True(t, stopped.Load())
+}
+
+func TestScopeCollectsFailures(t *testing.T) {
+	result := runScope(func(s *TaskScope) {
+		s.Fork(func() any { panic("first") })
+		s.Fork(func() any {
+			<-s.Context().Done()
+			s.Fail(errors.New("second"))
+			return nil
+		})
+	})
+	assert.True(t, result.IsFailure())
+	assert.Equal(t, "first\nsecond", result.GetError().Error())
+}
+
+func TestScopeBodyPanic(t *testing.T) {
+	var finished atomic.Bool
+	result := runScope(func(s *TaskScope) {
+		s.Fork(func() any {
+			<-s.Context().Done()
+			finished.Store(true)
+			return nil
+		})
+		panic("body failed")
+	})
+	assert.True(t, result.IsFailure())
+	assert.Equal(t, "body failed", result.GetError().Error())
+	assert.True(t, finished.Load())
+}
@@ -0,0 +1,184 @@
+package std
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// The codecs generated for a @json sealed type encode a variant as a JSON
+// object holding a discriminator next to the fields of the variant:
+//
+//	@json("kind")
+//	sealed type Shape {
+//	    case Circle(Radius float64)
+//	    case Point()
+//	}
+//
+// Circle(1.5) encodes as {"kind":"Circle","Radius":1.5}. The helpers below do
+// the work, so the generated methods only list the variants and their fields.
+
+// SealedField is a field of a variant, with the name it has in GALA.
+type SealedField struct {
+	Name  string
+	Value any
+}
+
+// MarshalSealedJSON encodes a variant as a JSON object whose first member is
+// the discriminator tag, set to variant, followed by fields in order.
+func MarshalSealedJSON(tag, variant string, fields ...SealedField) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	writeMember := func(name string, value any) error {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		key, _ := json.Marshal(name)
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(data)
+		return nil
+	}
+	if err := writeMember(tag, variant); err != nil {
+		return nil, err
+	}
+	for _, f := range fields {
+		buf.WriteByte(',')
+		if err := writeMember(f.Name, f.Value); err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", variant, f.Name, err)
+		}
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// SealedJSON is a JSON object read by the UnmarshalJSON of a sealed type,
+// split into its discriminator and the raw values of its other members.
+type SealedJSON struct {
+	typeName string
+	tag      string
+	variant  string
+	members  map[string]json.RawMessage
+}
+
+// UnmarshalSealedJSON reads the JSON object encoding a value of the sealed
+// type typeName. It fails unless data is an object whose member tag is a
+// string.
+func UnmarshalSealedJSON(data []byte, tag, typeName string) (SealedJSON, error) {
+	obj := SealedJSON{typeName: typeName, tag: tag}
+	if err := json.Unmarshal(data, &obj.members); err != nil {
+		return obj, fmt.Errorf("%s: %w", typeName, err)
+	}
+	if obj.members == nil {
+		return obj, fmt.Errorf("%s: expected a JSON object, got null", typeName)
+	}
+	raw, ok := obj.members[tag]
+	if !ok {
+		return obj, fmt.Errorf("%s: missing discriminator %q", typeName, tag)
+	}
+	if err := json.Unmarshal(raw, &obj.variant); err != nil {
+		return obj, fmt.Errorf("%s: discriminator %q is not a string", typeName, tag)
+	}
+	return obj, nil
+}
+
+// Variant returns the value of the discriminator.
+func (s SealedJSON) Variant() string {
+	return s.variant
+}
+
+// Field decodes the member called name into dest. A missing member leaves
+// dest unchanged, so it keeps its zero value as with encoding/json.
+func (s SealedJSON) Field(name string, dest any) error {
+	raw, ok := s.members[name]
+	if !ok {
+		return nil
+	}
+	if err := json.Unmarshal(raw, dest); err != nil {
+		return fmt.Errorf("%s.%s: %w", s.variant, name, err)
+	}
+	return nil
+}
+
+// UnknownVariant returns the error for a discriminator that names no variant.
+func (s SealedJSON) UnknownVariant() error {
+	return fmt.Errorf("%s: unknown variant %q in %q", s.typeName, s.variant, s.tag)
+}
+
+// SealedToYAML is the MarshalYAML of a @json sealed type. It returns the
+// JSON encoding of v as plain maps and values, which YAML libraries such as
+// gopkg.in/yaml.v3 encode like any other map.
+func SealedToYAML(v json.Marshaler) (any, error) {
+	data, err := v.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var plain any
+	if err := dec.Decode(&plain); err != nil {
+		return nil, err
+	}
+	return fromJSONNumbers(plain), nil
+}
+
+// SealedFromYAML is the UnmarshalYAML of a @json sealed type, in the form
+// taking an unmarshal function that both gopkg.in/yaml.v2 and v3 call. It
+// decodes the YAML node into plain values and reads them back as JSON.
+func SealedFromYAML(unmarshal func(any) error, v json.Unmarshaler) error {
+	var plain any
+	if err := unmarshal(&plain); err != nil {
+		return err
+	}
+	data, err := json.Marshal(toJSONKeys(plain))
+	if err != nil {
+		return err
+	}
+	return v.UnmarshalJSON(data)
+}
+
+// fromJSONNumbers replaces the json.Numbers of a decoded value with int64s
+// where they are integers and float64s otherwise.
+func fromJSONNumbers(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]any:
+		for k, e := range v {
+			v[k] = fromJSONNumbers(e)
+		}
+	case []any:
+		for i, e := range v {
+			v[i] = fromJSONNumbers(e)
+		}
+	}
+	return v
+}
+
+// toJSONKeys converts the map[any]any of YAML decoders to map[string]any,
+// which encoding/json can encode.
+func toJSONKeys(v any) any {
+	switch v := v.(type) {
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for k, e := range v {
+			m[fmt.Sprint(k)] = toJSONKeys(e)
+		}
+		return m
+	case map[string]any:
+		for k, e := range v {
+			v[k] = toJSONKeys(e)
+		}
+	case []any:
+		for i, e := range v {
+			v[i] = toJSONKeys(e)
+		}
+	}
+	return v
+}
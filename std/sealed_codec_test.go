@@ -0,0 +1,96 @@
+package std
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// codecShape is shaped like the code generated for
+//
+//	@json("kind")
+//	sealed type Shape {
+//	    case Circle(Radius float64, Label Option[string])
+//	    case Point()
+//	}
+type codecShape struct {
+	Radius   Immutable[float64]
+	Label    Immutable[Option[string]]
+	_variant uint8
+}
+
+func (s codecShape) MarshalJSON() ([]byte, error) {
+	switch s._variant {
+	case 0:
+		return MarshalSealedJSON("kind", "Circle", SealedField{Name: "Radius", Value: s.Radius.Get()}, SealedField{Name: "Label", Value: s.Label.Get()})
+	}
+	return MarshalSealedJSON("kind", "Point")
+}
+
+func (s *codecShape) UnmarshalJSON(data []byte) error {
+	obj, err := UnmarshalSealedJSON(data, "kind", "Shape")
+	if err != nil {
+		return err
+	}
+	switch obj.Variant() {
+	case "Circle":
+		var f0 float64
+		if err := obj.Field("Radius", &f0); err != nil {
+			return err
+		}
+		var f1 Option[string]
+		if err := obj.Field("Label", &f1); err != nil {
+			return err
+		}
+		*s = codecShape{Radius: NewImmutable(f0), Label: NewImmutable(f1), _variant: 0}
+		return nil
+	case "Point":
+		*s = codecShape{_variant: 1}
+		return nil
+	}
+	return obj.UnknownVariant()
+}
+
+func TestSealedJSONRoundTrip(t *testing.T) {
+	circle := codecShape{Radius: NewImmutable(1.5), Label: NewImmutable(Some[string]{}.Apply("c")), _variant: 0}
+	data, err := json.Marshal([]codecShape{circle, {_variant: 1}})
+	assert.NoError(t, err)
+	assert.Equal(t, `[{"kind":"Circle","Radius":1.5,"Label":"c"},{"kind":"Point"}]`, string(data))
+
+	var decoded []codecShape
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, []codecShape{circle, {_variant: 1}}, decoded)
+}
+
+func TestSealedJSONMissingField(t *testing.T) {
+	var s codecShape
+	assert.NoError(t, json.Unmarshal([]byte(`{"Radius":2,"kind":"Circle"}`), &s))
+	assert.Equal(t, 2.0, s.Radius.Get())
+	assert.True(t, s.Label.Get().IsEmpty())
+}
+
+func TestSealedJSONErrors(t *testing.T) {
+	var s codecShape
+	assert.EqualError(t, json.Unmarshal([]byte(`{"Radius":2}`), &s), `Shape: missing discriminator "kind"`)
+	assert.EqualError(t, json.Unmarshal([]byte(`{"kind":3}`), &s), `Shape: discriminator "kind" is not a string`)
+	assert.EqualError(t, json.Unmarshal([]byte(`{"kind":"Square"}`), &s), `Shape: unknown variant "Square" in "kind"`)
+	assert.EqualError(t, json.Unmarshal([]byte(`null`), &s), `Shape: expected a JSON object, got null`)
+	assert.ErrorContains(t, json.Unmarshal([]byte(`{"kind":"Circle","Radius":"big"}`), &s), "Circle.Radius: ")
+}
+
+func TestSealedYAMLBridge(t *testing.T) {
+	circle := codecShape{Radius: NewImmutable(3.0), Label: NewImmutable(None[string]{}.Apply()), _variant: 0}
+	plain, err := SealedToYAML(circle)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"kind": "Circle", "Radius": int64(3), "Label": nil}, plain)
+
+	// YAML decoders hand over maps with interface keys
+	unmarshal := func(dest any) error {
+		*dest.(*any) = map[any]any{"kind": "Circle", "Radius": 2.5}
+		return nil
+	}
+	var s codecShape
+	assert.NoError(t, SealedFromYAML(unmarshal, &s))
+	assert.Equal(t, 2.5, s.Radius.Get())
+}
@@ -0,0 +1,20 @@
+package std
+
+// SealedVariant describes a case of a sealed type. Every sealed type gets a
+// function listing its cases in declaration order, named after the type:
+//
+//	sealed type Shape {
+//	    case Circle(Radius float64)
+//	    case Rect(Width float64, Height float64)
+//	}
+//
+// ShapeVariants() returns [{Circle 1} {Rect 2}], so code that serializes,
+// parses or generates values of Shape can enumerate its cases without
+// reflection.
+type SealedVariant struct {
+	// Name is the name of the case.
+	Name string
+	// Arity is the number of fields of the case, the arguments its
+	// constructor takes.
+	Arity int
+}
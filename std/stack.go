@@ -0,0 +1,137 @@
+package std
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// sourceMap maps the Go name of a generated function, e.g. example.com/app.parse
+// or main.(*Parser).next, to the GALA declaration it was generated from.
+var sourceMap = struct {
+	sync.RWMutex
+	locations map[string]string
+}{locations: make(map[string]string)}
+
+// RegisterSourceMap records the GALA declarations the functions of the calling
+// package were generated from. lines maps a function name as Go reports it,
+// without the package path, to the line of its declaration in file:
+//
+//	std.RegisterSourceMap("shapes.gala", map[string]int{"area": 8, "Shape.String": 3, "(*Canvas).Draw": 14})
+//
+// gala build emits the call in an init function of every generated file.
+func RegisterSourceMap(file string, lines map[string]int) {
+	pkg := "main"
+	if pc, _, _, ok := runtime.Caller(1); ok {
+		if fn := runtime.FuncForPC(pc); fn != nil {
+			pkg = funcPackage(fn.Name())
+		}
+	}
+	sourceMap.Lock()
+	defer sourceMap.Unlock()
+	for name, line := range lines {
+		sourceMap.locations[pkg+"."+name] = fmt.Sprintf("%s:%d", file, line)
+	}
+}
+
+// SourceLocation returns the GALA declaration a function was generated from,
+// as file:line, given its name as reported by runtime.Frame. Closures map to
+// the declaration of the function they appear in.
+func SourceLocation(function string) (string, bool) {
+	name := strings.ReplaceAll(function, "[...]", "")
+	sourceMap.RLock()
+	defer sourceMap.RUnlock()
+	for {
+		if loc, ok := sourceMap.locations[name]; ok {
+			return loc, true
+		}
+		// Closures are named after their enclosing function: parse.func1, parse.func1.2
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 || !isClosureSuffix(name[i+1:]) {
+			return "", false
+		}
+		name = name[:i]
+	}
+}
+
+// RecoverPretty reports a panic with a stack trace whose frames name the GALA
+// declarations they come from, then exits with status 2 like an unrecovered
+// panic does. It must be deferred directly:
+//
+//	defer std.RecoverPretty()
+//
+// gala build defers it at the start of main, so it covers panics of the main
+// goroutine; other goroutines crash with the usual Go trace.
+func RecoverPretty() {
+	if r := recover(); r != nil {
+		writePanic(os.Stderr, r, panicFrames())
+		os.Exit(2)
+	}
+}
+
+// panicFrames returns the stack of a panicking goroutine from the frame that
+// panicked outwards. It must be called from a deferred function.
+func panicFrames() []runtime.Frame {
+	pcs := make([]uintptr, 64)
+	pcs = pcs[:runtime.Callers(1, pcs)]
+	var all []runtime.Frame
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		all = append(all, frame)
+		if !more {
+			break
+		}
+	}
+	for i, frame := range all {
+		if frame.Function == "runtime.gopanic" {
+			return all[i+1:]
+		}
+	}
+	return all
+}
+
+// writePanic writes the panic value and the stack, putting the GALA location
+// of each generated function above its Go location.
+func writePanic(w io.Writer, r any, frames []runtime.Frame) {
+	fmt.Fprintf(w, "panic: %v\n\ngoroutine main:\n", r)
+	for _, frame := range frames {
+		if strings.HasPrefix(frame.Function, "runtime.") {
+			continue
+		}
+		if loc, ok := SourceLocation(frame.Function); ok {
+			fmt.Fprintf(w, "%s (%s)\n", frame.Function, loc)
+		} else {
+			fmt.Fprintf(w, "%s\n", frame.Function)
+		}
+		fmt.Fprintf(w, "\t%s:%d\n", frame.File, frame.Line)
+	}
+}
+
+// funcPackage returns the package path of a function name such as
+// example.com/app.init.0.
+func funcPackage(function string) string {
+	slash := strings.LastIndexByte(function, '/')
+	if dot := strings.IndexByte(function[slash+1:], '.'); dot >= 0 {
+		return function[:slash+1+dot]
+	}
+	return function
+}
+
+// isClosureSuffix reports whether s is the last element of a closure name:
+// func1, or a plain number for closures nested in closures.
+func isClosureSuffix(s string) bool {
+	s = strings.TrimPrefix(s, "func")
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
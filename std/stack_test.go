@@ -0,0 +1,62 @@
+package std
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type stackBox[T any] struct{ v T }
+
+func (b *stackBox[T]) explode() {
+	func() { panic(errors.New("boom")) }()
+}
+
+func TestSourceLocation(t *testing.T) {
+	RegisterSourceMap("box.gala", map[string]int{"TestSourceLocation": 3, "(*stackBox).explode": 7})
+
+	loc, ok := SourceLocation("martianoff/gala/std.TestSourceLocation")
+	assert.True(t, ok)
+	assert.Equal(t, "box.gala:3", loc)
+
+	loc, ok = SourceLocation("martianoff/gala/std.TestSourceLocation.func1.2")
+	assert.True(t, ok)
+	assert.Equal(t, "box.gala:3", loc)
+
+	loc, ok = SourceLocation("martianoff/gala/std.(*stackBox[...]).explode")
+	assert.True(t, ok)
+	assert.Equal(t, "box.gala:7", loc)
+
+	_, ok = SourceLocation("martianoff/gala/std.TestSourceLocation.other")
+	assert.False(t, ok)
+	_, ok = SourceLocation("other/pkg.TestSourceLocation")
+	assert.False(t, ok)
+}
+
+func TestWritePanic(t *testing.T) {
+	RegisterSourceMap("box.gala", map[string]int{"(*stackBox).explode": 7})
+
+	var out strings.Builder
+	func() {
+		defer func() {
+			writePanic(&out, recover(), panicFrames())
+		}()
+		(&stackBox[int]{}).explode()
+	}()
+
+	lines := strings.Split(out.String(), "\n")
+	assert.Equal(t, "panic: boom", lines[0])
+	assert.Equal(t, "goroutine main:", lines[2])
+	// The closure that panicked comes first, mapped to the method it is declared in
+	assert.Regexp(t, `^martianoff/gala/std\.\(\*stackBox\[\.\.\.\]\)\.explode\.(func)?1 \(box\.gala:7\)$`, lines[3])
+	assert.Regexp(t, `^\t.*stack_test\.go:\d+$`, lines[4])
+	assert.Regexp(t, `^martianoff/gala/std\.\(\*stackBox\[\.\.\.\]\)\.explode \(box\.gala:7\)$`, lines[5])
+}
+
+func TestFuncPackage(t *testing.T) {
+	assert.Equal(t, "main", funcPackage("main.init.0"))
+	assert.Equal(t, "example.com/app", funcPackage("example.com/app.init.0"))
+	assert.Equal(t, "example.com/a.b/c", funcPackage("example.com/a.b/c.(*T).M"))
+}
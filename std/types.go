@@ -1,6 +1,9 @@
 package std
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
 )
@@ -82,6 +85,12 @@ func Equal[T any](v1, v2 T) bool {
 		}
 	}
 
+	// reflect.DeepEqual reports distinct non-nil funcs as unequal even for the
+	// same function, so funcs are compared by identity
+	if val1.Kind() == reflect.Func && val2.Kind() == reflect.Func {
+		return val1.Pointer() == val2.Pointer()
+	}
+
 	if val1.Kind() != reflect.Struct || val2.Kind() != reflect.Struct {
 		return reflect.DeepEqual(v1, v2)
 	}
@@ -100,22 +109,42 @@ func Equal[T any](v1, v2 T) bool {
 	return true
 }
 
+// EqualSlices reports whether a and b have the same length and pairwise Equal
+// elements. Unlike reflect.DeepEqual, a nil slice equals an empty one.
+func EqualSlices[T any](a, b []T) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// EqualMaps reports whether a and b have the same keys with Equal values.
+// Unlike reflect.DeepEqual, a nil map equals an empty one.
+func EqualMaps[K comparable, V any](a, b map[K]V) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, va := range a {
+		vb, ok := b[k]
+		if !ok || !Equal(va, vb) {
+			return false
+		}
+	}
+	return true
+}
+
 // tryRecover executes f with panic recovery, returning a Try.
 // Used by TryApply (defined in try.gala) as the underlying implementation
 // since GALA cannot express Go's defer/recover with named return values.
 func tryRecover[T any](f func() T) (result Try[T]) {
 	defer func() {
 		if r := recover(); r != nil {
-			var err error
-			switch e := r.(type) {
-			case error:
-				err = e
-			case string:
-				err = fmt.Errorf("%s", e)
-			default:
-				err = fmt.Errorf("panic: %v", r)
-			}
-			result = Try[T]{Err: NewImmutable(err), _variant: _Try_Failure}
+			result = Try[T]{Err: NewImmutable(panicError(r)), _variant: _Try_Failure}
 		}
 	}()
 	v := f()
@@ -123,6 +152,29 @@ func tryRecover[T any](f func() T) (result Try[T]) {
 	return
 }
 
+// panicError converts a recovered panic value into the error of a Failure.
+func panicError(r any) error {
+	switch e := r.(type) {
+	case error:
+		return e
+	case string:
+		return fmt.Errorf("%s", e)
+	default:
+		return fmt.Errorf("panic: %v", r)
+	}
+}
+
+// joinErrors wraps errors.Join for GALA code, which cannot spread a slice
+// into variadic arguments. It returns nil for no errors.
+func joinErrors(errs []error) error {
+	return errors.Join(errs...)
+}
+
+// concatErrors returns a new slice with the errors of a followed by those of b.
+func concatErrors(a, b []error) []error {
+	return append(append([]error{}, a...), b...)
+}
+
 func As[T any](obj any) (T, bool) {
 	// Direct type assertion
 	if v, ok := obj.(T); ok {
@@ -138,3 +190,125 @@ func As[T any](obj any) (T, bool) {
 	var zero T
 	return zero, false
 }
+
+// MarshalJSON encodes an Immutable as the value it holds, so GALA structs
+// encode like the equivalent Go structs.
+func (i Immutable[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(i.value)
+}
+
+// UnmarshalJSON decodes data into the held value.
+func (i *Immutable[T]) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, &i.value)
+}
+
+// ImmutableRef[T] holds an immutable value behind a pointer. The transpiler
+// declares the immutable struct fields of large types as ImmutableRef[T]
+// rather than Immutable[T] (see gala build -O), so copying the struct copies a
+// pointer instead of the value; as the value is never modified, the copies can
+// share it. The zero ImmutableRef holds the zero value of T.
+type ImmutableRef[T any] struct {
+	ref *T
+}
+
+// NewImmutableRef creates an ImmutableRef holding v.
+func NewImmutableRef[T any](v T) ImmutableRef[T] {
+	return ImmutableRef[T]{ref: &v}
+}
+
+// Get returns the held value.
+func (i ImmutableRef[T]) Get() T {
+	if i.ref == nil {
+		var zero T
+		return zero
+	}
+	return *i.ref
+}
+
+// Ptr returns a pointer to the held value.
+func (i *ImmutableRef[T]) Ptr() *T {
+	if i.ref == nil {
+		i.ref = new(T)
+	}
+	return i.ref
+}
+
+// GetAny returns the held value as any (for interface-based unwrapping).
+func (i ImmutableRef[T]) GetAny() any {
+	return i.Get()
+}
+
+// Copy returns an ImmutableRef holding a copy of the value, like the Copy of
+// an Immutable.
+func (i ImmutableRef[T]) Copy() ImmutableRef[T] {
+	if i.ref == nil {
+		return i
+	}
+	return NewImmutableRef(Copy(*i.ref))
+}
+
+// Equal reports whether other holds an Equal value.
+func (i ImmutableRef[T]) Equal(other ImmutableRef[T]) bool {
+	return Equal(i.Get(), other.Get())
+}
+
+// Format prints the value the way fmt prints an Immutable holding it, not the
+// pointer.
+func (i ImmutableRef[T]) Format(f fmt.State, verb rune) {
+	fmt.Fprintf(f, fmt.FormatString(f, verb), Immutable[T]{value: i.Get()})
+}
+
+// MarshalJSON encodes an ImmutableRef as the value it holds.
+func (i ImmutableRef[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(i.Get())
+}
+
+// UnmarshalJSON decodes data into a new held value.
+func (i *ImmutableRef[T]) UnmarshalJSON(data []byte) error {
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	i.ref = &v
+	return nil
+}
+
+// MarshalJSON encodes Some as its value and None as null.
+func (o Option[T]) MarshalJSON() ([]byte, error) {
+	if o._variant != _Option_Some {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.Value.Get())
+}
+
+// UnmarshalJSON decodes null as None and any other value as Some.
+func (o *Option[T]) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		*o = Option[T]{_variant: _Option_None}
+		return nil
+	}
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*o = Option[T]{Value: NewImmutable(v), _variant: _Option_Some}
+	return nil
+}
+
+// OptionToPointer returns a pointer to a copy of the value of Some, or nil for
+// None, the way Go structs usually represent an optional field.
+func OptionToPointer[T any](o Option[T]) *T {
+	if o._variant != _Option_Some {
+		return nil
+	}
+	v := o.Value.Get()
+	return &v
+}
+
+// OptionFromPointer returns Some holding *p, or None when p is nil.
+func OptionFromPointer[T any](p *T) Option[T] {
+	if p == nil {
+		return Option[T]{_variant: _Option_None}
+	}
+	return Option[T]{Value: NewImmutable(*p), _variant: _Option_Some}
+}